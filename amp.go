@@ -0,0 +1,45 @@
+package defuddle
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kaptinlin/requests"
+	"golang.org/x/net/html"
+
+	"github.com/kaptinlin/defuddle-go/internal/dom"
+)
+
+// ampURL returns the absolute http(s) URL of the AMP version that root
+// links with <link rel="amphtml">, resolved against base. It returns ""
+// when the page links none, or is itself the AMP version.
+func ampURL(root *html.Node, base string) string {
+	for el := range dom.Elements(root) {
+		if dom.TagName(el) != "link" {
+			continue
+		}
+		for rel := range strings.FieldsSeq(strings.ToLower(dom.AttrOr(el, "rel", ""))) {
+			if rel == "amphtml" {
+				return redirectTarget(dom.AttrOr(el, "href", ""), base)
+			}
+		}
+	}
+	return ""
+}
+
+// fetchAMP fetches the AMP version of a page at url for ParseFromURL and
+// prepares it for parsing with the page's options.
+func fetchAMP(ctx context.Context, client *requests.Client, url string, cookies map[string]string, options *Options, prefilter bool) (*Defuddle, error) {
+	html, _, err := fetchHTML(ctx, client, url, cookies, options)
+	if err != nil {
+		return nil, err
+	}
+	fetchedAt := time.Now()
+	d, err := newDefuddleFromHTML(html, options, prefilter)
+	if err != nil {
+		return nil, err
+	}
+	d.fetchedAt = fetchedAt
+	return d, nil
+}
@@ -0,0 +1,86 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFromURLPrefersAMP(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("The harbor reopened on Monday after a week of repairs. ", 6)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/harbor", "/broken":
+			amp := "/amp/harbor"
+			if r.URL.Path == "/broken" {
+				amp = "/amp/missing"
+			}
+			_, _ = w.Write([]byte(`<html><head><title>Harbor story</title><link rel="amphtml" href="` + amp + `"></head>` +
+				`<body><article><h1>Harbor story</h1><p>Page version. ` + body + `</p></article></body></html>`))
+		case "/amp/harbor":
+			_, _ = w.Write([]byte(`<html amp><head><title>Harbor story</title><link rel="canonical" href="/harbor"></head>` +
+				`<body><article><h1>Harbor story</h1><amp-img src="/photo.jpg" alt="Harbor" width="800" height="600" layout="responsive"></amp-img>` +
+				`<p>AMP version. ` + body + `</p></article></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	page, err := ParseFromURL(context.Background(), server.URL+"/harbor", nil)
+	require.NoError(t, err)
+	assert.Contains(t, page.Content, "Page version.")
+	assert.Equal(t, server.URL+"/amp/harbor", page.AMPURL)
+
+	options := &Options{PreferAMP: true}
+	amp, err := ParseFromURL(context.Background(), server.URL+"/harbor", options)
+	require.NoError(t, err)
+	assert.Contains(t, amp.Content, "AMP version.")
+	assert.Contains(t, amp.Content, `<img src="/photo.jpg" alt="Harbor"`)
+	assert.NotContains(t, amp.Content, "amp-img")
+	assert.Equal(t, server.URL+"/amp/harbor", amp.AMPURL)
+	assert.Empty(t, options.URL)
+
+	fallback, err := ParseFromURL(context.Background(), server.URL+"/broken", &Options{PreferAMP: true})
+	require.NoError(t, err)
+	assert.Contains(t, fallback.Content, "Page version.")
+}
+
+func TestParseFromURLLeavesCachedAMPParseUnchanged(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("The harbor reopened on Monday after a week of repairs. ", 6)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/amp/harbor" {
+			_, _ = w.Write([]byte(`<html amp><head><title>Harbor story</title></head><body><article><p>AMP version. ` + body + `</p></article></body></html>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<html><head><title>Harbor story</title><link rel="amphtml" href="/amp/harbor"></head>` +
+			`<body><article><p>Page version. ` + body + `</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	cache := &mapCache{results: make(map[string]*Result)}
+	result, err := ParseFromURL(context.Background(), server.URL+"/harbor", &Options{PreferAMP: true, Cache: cache})
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/amp/harbor", result.AMPURL)
+
+	// The result Parse stored for the AMP page's HTML links no AMP version
+	require.Len(t, cache.results, 2)
+	var withoutAMP int
+	for _, cached := range cache.results {
+		if cached.AMPURL == "" {
+			withoutAMP++
+		}
+	}
+	assert.Equal(t, 1, withoutAMP)
+}
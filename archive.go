@@ -0,0 +1,54 @@
+package defuddle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kaptinlin/defuddle-go/internal/webarchive"
+)
+
+// ParseFromArchive parses the page saved in an MHTML or WARC file read from
+// r. The address the archive saved the page from becomes options.URL when
+// that is empty. In a WARC file, a set options.URL selects the record to
+// parse; otherwise the first successful HTML response is parsed. Archives
+// without an HTML document fail with ErrNoArchiveDocument.
+func ParseFromArchive(ctx context.Context, r io.Reader, format ArchiveFormat, options *Options) (*Result, error) {
+	if options == nil {
+		options = &Options{
+			RemoveExactSelectors:   true,
+			RemovePartialSelectors: true,
+		}
+	}
+
+	archiveOpts := webarchive.Options{Target: options.URL, Images: options.EmbedArchiveImages}
+	var page *webarchive.Page
+	var err error
+	switch ArchiveFormat(strings.ToLower(string(format))) {
+	case ArchiveMHTML:
+		page, err = webarchive.ReadMHTML(r, archiveOpts)
+	case ArchiveWARC:
+		page, err = webarchive.ReadWARC(r, archiveOpts)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedArchiveFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s archive: %w", strings.ToUpper(string(format)), err)
+	}
+	if options.URL == "" {
+		options.URL = page.URL
+	}
+
+	content := page.HTML
+	if options.EmbedArchiveImages {
+		content = page.EmbedImages()
+	}
+
+	defuddle, err := NewDefuddle(content, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Defuddle instance: %w", err)
+	}
+
+	return defuddle.Parse(ctx)
+}
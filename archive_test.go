@@ -0,0 +1,81 @@
+package defuddle
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func archiveTestPage() string {
+	body := strings.Repeat("The harbor authority approved the new ferry schedule on Monday. ", 6)
+	return `<html><head><title>Ferry schedule</title><meta property="og:image" content="/img/ferry.png"></head><body>
+<nav><a href="/">Home</a></nav>
+<article><h1>Ferry schedule</h1><p>` + body + `</p><p><img src="/img/ferry.png" alt="The ferry" width="600" height="400"></p></article>
+</body></html>`
+}
+
+func TestParseFromArchiveMHTML(t *testing.T) {
+	t.Parallel()
+
+	mhtml := "From: <Saved by Blink>\r\n" +
+		"Snapshot-Content-Location: https://example.com/ferry\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/related; type=\"text/html\"; boundary=\"b\"\r\n\r\n" +
+		"--b\r\nContent-Type: text/html; charset=utf-8\r\nContent-Location: https://example.com/ferry\r\n\r\n" +
+		archiveTestPage() + "\r\n" +
+		"--b\r\nContent-Type: image/png\r\nContent-Transfer-Encoding: base64\r\nContent-Location: https://example.com/img/ferry.png\r\n\r\n" +
+		"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==\r\n" +
+		"--b--\r\n"
+
+	result, err := ParseFromArchive(context.Background(), strings.NewReader(mhtml), ArchiveMHTML, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Ferry schedule", result.Title)
+	assert.Equal(t, "example.com", result.Domain)
+	assert.Equal(t, "/img/ferry.png", result.Image)
+	assert.Contains(t, result.Content, "ferry schedule on Monday")
+	assert.NotContains(t, result.Content, "data:image")
+
+	result, err = ParseFromArchive(context.Background(), strings.NewReader(mhtml), "MHTML", &Options{EmbedArchiveImages: true})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result.Image, "data:image/png;base64,"), result.Image)
+	assert.Contains(t, result.Content, `src="data:image/png;base64,`)
+}
+
+func TestParseFromArchiveWARC(t *testing.T) {
+	t.Parallel()
+
+	record := func(target, block string) string {
+		return fmt.Sprintf("WARC/1.0\r\nWARC-Type: response\r\nWARC-Target-URI: %s\r\nContent-Type: application/http; msgtype=response\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n", target, len(block), block)
+	}
+	page := archiveTestPage()
+	warc := record("https://example.com/", "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n<html><body><p>Home page.</p></body></html>") +
+		record("https://example.com/ferry", fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\nContent-Length: %d\r\n\r\n%s", len(page), page))
+
+	result, err := ParseFromArchive(context.Background(), strings.NewReader(warc), ArchiveWARC, &Options{URL: "https://example.com/ferry"})
+	require.NoError(t, err)
+	assert.Equal(t, "Ferry schedule", result.Title)
+	assert.Contains(t, result.Content, "ferry schedule on Monday")
+
+	result, err = ParseFromArchive(context.Background(), strings.NewReader(warc), ArchiveWARC, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, "Home page.")
+}
+
+func TestParseFromArchiveErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseFromArchive(context.Background(), strings.NewReader(""), "zip", nil)
+	require.ErrorIs(t, err, ErrUnsupportedArchiveFormat)
+
+	css := "MIME-Version: 1.0\r\nContent-Type: multipart/related; boundary=b\r\n\r\n--b\r\nContent-Type: text/css\r\n\r\nbody {}\r\n--b--\r\n"
+	_, err = ParseFromArchive(context.Background(), strings.NewReader(css), ArchiveMHTML, nil)
+	require.ErrorIs(t, err, ErrNoArchiveDocument)
+
+	_, err = ParseFromArchive(context.Background(), strings.NewReader(base64.StdEncoding.EncodeToString([]byte("x"))), ArchiveWARC, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package defuddle
+
+import (
+	"context"
+	"sync"
+)
+
+// URLResult is the outcome of one URL of ParseURLs
+type URLResult struct {
+	URL    string
+	Result *Result
+	Err    error
+}
+
+// ParseURLs fetches and parses urls with ParseFromURL on up to concurrency
+// goroutines, returning one URLResult per URL in the order of urls. A
+// failed URL does not stop the others.
+//
+// Every URL is parsed with its own copy of options, or DefaultOptions when
+// nil, with URL cleared so each result is resolved against the page it was
+// served from. Without options.Client, one client is built from
+// options.Fetch and shared, so its rate limit and robots.txt rules apply
+// across all goroutines. Concurrency below 1 parses one URL at a time.
+func ParseURLs(ctx context.Context, urls []string, options *Options, concurrency int) ([]URLResult, error) {
+	if options == nil {
+		options = DefaultOptions()
+	}
+	client := options.Client
+	if client == nil {
+		var err error
+		if client, err = NewFetchClient(options.Fetch); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]URLResult, len(urls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(concurrency, 1), len(urls)) {
+		wg.Go(func() {
+			for i := range next {
+				opts := *options
+				opts.URL = ""
+				opts.Client = client
+				result, err := ParseFromURL(ctx, urls[i], &opts)
+				results[i] = URLResult{URL: urls[i], Result: result, Err: err}
+			}
+		})
+	}
+	for i := range urls {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,76 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURLsKeepsOrderAndSharesClient(t *testing.T) {
+	t.Parallel()
+
+	var active, peak atomic.Int32
+	var mu sync.Mutex
+	agents := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		mu.Lock()
+		agents[r.Header.Get("User-Agent")] = true
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>` + r.URL.Path + `</title></head><body><article><p>Body of ` + r.URL.Path + `.</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	options := DefaultOptions()
+	options.Fetch = &FetchOptions{UserAgent: "batch-test"}
+	urls := []string{server.URL + "/a", server.URL + "/missing", server.URL + "/b", server.URL + "/c"}
+	results, err := ParseURLs(context.Background(), urls, options, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	for i, path := range []string{"/a", "/missing", "/b", "/c"} {
+		assert.Equal(t, urls[i], results[i].URL)
+		if path == "/missing" {
+			var statusErr *HTTPStatusError
+			require.ErrorAs(t, results[i].Err, &statusErr)
+			assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+			assert.Nil(t, results[i].Result)
+			continue
+		}
+		require.NoError(t, results[i].Err)
+		assert.Equal(t, path, results[i].Result.Title)
+	}
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, map[string]bool{"batch-test": true}, agents)
+	// The caller's options are copied, not filled in
+	assert.Empty(t, options.URL)
+	assert.Nil(t, options.Client)
+}
+
+func TestParseURLsWithoutURLs(t *testing.T) {
+	t.Parallel()
+
+	results, err := ParseURLs(context.Background(), nil, nil, 4)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// File is a defuddle.Cache that keeps each result as a JSON file under a
+// directory, so results survive restarts and can be shared by processes on
+// one machine. It is safe for concurrent use.
+//
+// Results are stored as their JSON encoding, so a hit is a copy that
+// carries only the fields Result encodes, and SchemaOrgData comes back as
+// decoded JSON values. Failures to read or write the directory are treated
+// as misses.
+type File struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewFile creates a cache in dir, which is created on the first Set. A ttl
+// of zero or less keeps results until they are removed.
+func NewFile(dir string, ttl time.Duration) *File {
+	return &File{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Get implements defuddle.Cache. Expired and unreadable entries are removed
+// and reported as misses.
+func (f *File) Get(_ context.Context, key string) (*defuddle.Result, bool) {
+	path, ok := f.path(key)
+	if !ok {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if f.ttl > 0 && !f.now().Before(info.ModTime().Add(f.ttl)) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path is built from a hex key
+	if err != nil {
+		return nil, false
+	}
+	var result defuddle.Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return &result, true
+}
+
+// Set implements defuddle.Cache. The file is written to a temporary name
+// and renamed into place, so readers never see a partial entry.
+func (f *File) Set(_ context.Context, key string, result *defuddle.Result) {
+	path, ok := f.path(key)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	// Expiry counts from f.now rather than the filesystem clock
+	if f.ttl > 0 {
+		now := f.now()
+		_ = os.Chtimes(path, now, now)
+	}
+}
+
+// Purge removes every cached result. Other files in the directory are left
+// alone.
+func (f *File) Purge() error {
+	err := filepath.WalkDir(f.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if key, found := strings.CutSuffix(entry.Name(), ".json"); found && !entry.IsDir() {
+			if _, ok := f.path(key); ok {
+				return os.Remove(path)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path returns the file of key, sharded by its first two characters. ok is
+// false for keys that are not lowercase hex, such as ones that could name
+// a path outside the directory.
+func (f *File) path(key string) (string, bool) {
+	if len(key) < 3 {
+		return "", false
+	}
+	for _, c := range key {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", false
+		}
+	}
+	return filepath.Join(f.dir, key[:2], key+".json"), true
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+func TestFileStoresResultsAcrossInstances(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	key := "0123456789abcdef"
+	NewFile(dir, 0).Set(ctx, key, &defuddle.Result{
+		Metadata: defuddle.Metadata{Title: "Cached", WordCount: 3},
+		Content:  "<p>cached</p>",
+	})
+
+	got, ok := NewFile(dir, 0).Get(ctx, key)
+	require.True(t, ok)
+	assert.Equal(t, "Cached", got.Title)
+	assert.Equal(t, 3, got.WordCount)
+	assert.Equal(t, "<p>cached</p>", got.Content)
+	assert.FileExists(t, filepath.Join(dir, "01", key+".json"))
+
+	_, ok = NewFile(dir, 0).Get(ctx, "fedcba9876543210")
+	assert.False(t, ok)
+}
+
+func TestFileExpiresEntriesAfterTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC)
+	file := NewFile(t.TempDir(), time.Minute)
+	file.now = func() time.Time { return now }
+	file.Set(context.Background(), "abc123", &defuddle.Result{Content: "x"})
+
+	_, ok := file.Get(context.Background(), "abc123")
+	require.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = file.Get(context.Background(), "abc123")
+	assert.False(t, ok)
+	path, _ := file.path("abc123")
+	assert.NoFileExists(t, path)
+}
+
+func TestFileRejectsKeysOutsideTheDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := NewFile(filepath.Join(dir, "cache"), 0)
+	for _, key := range []string{"../escape", "ABC123", "", "ab"} {
+		file.Set(context.Background(), key, &defuddle.Result{})
+		_, ok := file.Get(context.Background(), key)
+		assert.False(t, ok, key)
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFilePurgeRemovesOnlyCachedResults(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := NewFile(dir, 0)
+	file.Set(context.Background(), "abc123", &defuddle.Result{})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.json"), []byte("{}"), 0o600))
+
+	require.NoError(t, file.Purge())
+	_, ok := file.Get(context.Background(), "abc123")
+	assert.False(t, ok)
+	assert.FileExists(t, filepath.Join(dir, "notes.json"))
+
+	require.NoError(t, NewFile(filepath.Join(dir, "missing"), 0).Purge())
+}
+
+func TestFileServesRepeatedParsesFromDisk(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	html := `<html><head><title>Stored</title></head><body><article><p>Parsed once and stored on disk.</p></article></body></html>`
+	first, err := defuddle.ParseFromString(context.Background(), html, &defuddle.Options{Cache: NewFile(dir, time.Hour)})
+	require.NoError(t, err)
+
+	second, err := defuddle.ParseFromString(context.Background(), html, &defuddle.Options{Cache: NewFile(dir, time.Hour)})
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+	assert.Equal(t, first.Content, second.Content)
+	assert.Equal(t, first.Title, second.Title)
+}
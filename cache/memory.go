@@ -0,0 +1,113 @@
+// Package cache provides defuddle.Cache implementations for skipping
+// re-extraction of HTML that was already parsed with the same options.
+//
+// Plug a cache into defuddle.Options.Cache. Keys are SHA-256 hashes of the
+// input HTML, or of the URL for defuddle.ParseFromURL, and the options that
+// affect the result, so identical pages submitted twice are extracted once.
+// Memory keeps results in process; File keeps them as JSON files on disk.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// DefaultMaxEntries is the Memory capacity used when maxEntries is not positive.
+const DefaultMaxEntries = 1024
+
+// Memory is an in-process defuddle.Cache with a TTL and least-recently-used
+// eviction. It is safe for concurrent use.
+type Memory struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // Front is most recently used
+	entries map[string]*list.Element
+}
+
+// memoryEntry is one cached result with its expiry.
+type memoryEntry struct {
+	key     string
+	result  *defuddle.Result
+	expires time.Time
+}
+
+// NewMemory creates a cache that keeps results for ttl and holds at most
+// maxEntries of them. A ttl of zero or less keeps results until they are
+// evicted; maxEntries of zero or less means DefaultMaxEntries.
+func NewMemory(ttl time.Duration, maxEntries int) *Memory {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Memory{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get implements defuddle.Cache. Expired entries are removed and reported as misses.
+func (m *Memory) Get(_ context.Context, key string) (*defuddle.Result, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*memoryEntry)
+	if m.ttl > 0 && !m.now().Before(entry.expires) {
+		m.remove(element)
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	return entry.result, true
+}
+
+// Set implements defuddle.Cache, evicting the least recently used entry when full.
+func (m *Memory) Set(_ context.Context, key string, result *defuddle.Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expires := m.now().Add(m.ttl)
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.result, entry.expires = result, expires
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, result: result, expires: expires})
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+}
+
+// Len returns the number of cached results, including expired ones not yet removed.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// Purge removes every cached result.
+func (m *Memory) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.order.Init()
+	clear(m.entries)
+}
+
+// remove deletes element from the cache. It must be called with m.mu held.
+func (m *Memory) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+func TestMemoryExpiresEntriesAfterTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC)
+	memory := NewMemory(time.Minute, 0)
+	memory.now = func() time.Time { return now }
+	result := &defuddle.Result{Content: "<p>cached</p>"}
+
+	memory.Set(context.Background(), "key", result)
+	got, ok := memory.Get(context.Background(), "key")
+	require.True(t, ok)
+	assert.Same(t, result, got)
+
+	now = now.Add(time.Minute)
+	_, ok = memory.Get(context.Background(), "key")
+	assert.False(t, ok)
+	assert.Equal(t, 0, memory.Len())
+}
+
+func TestMemoryWithoutTTLKeepsEntries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC)
+	memory := NewMemory(0, 0)
+	memory.now = func() time.Time { return now }
+	memory.Set(context.Background(), "key", &defuddle.Result{})
+
+	now = now.Add(24 * time.Hour)
+	_, ok := memory.Get(context.Background(), "key")
+	assert.True(t, ok)
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	memory := NewMemory(time.Hour, 2)
+	ctx := context.Background()
+	memory.Set(ctx, "a", &defuddle.Result{Content: "a"})
+	memory.Set(ctx, "b", &defuddle.Result{Content: "b"})
+	_, _ = memory.Get(ctx, "a")
+	memory.Set(ctx, "c", &defuddle.Result{Content: "c"})
+
+	_, ok := memory.Get(ctx, "b")
+	assert.False(t, ok, "b was least recently used")
+	for _, key := range []string{"a", "c"} {
+		_, ok := memory.Get(ctx, key)
+		assert.True(t, ok, key)
+	}
+
+	memory.Set(ctx, "a", &defuddle.Result{Content: "a2"})
+	got, _ := memory.Get(ctx, "a")
+	assert.Equal(t, "a2", got.Content)
+	assert.Equal(t, 2, memory.Len())
+
+	memory.Purge()
+	assert.Equal(t, 0, memory.Len())
+}
+
+func TestMemorySkipsReExtractionOfDuplicatePages(t *testing.T) {
+	t.Parallel()
+
+	memory := NewMemory(time.Minute, 0)
+	html := `<html><head><title>Duplicate</title></head><body><article><p>Submitted twice.</p></article></body></html>`
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			_, err := defuddle.ParseFromString(context.Background(), html, &defuddle.Options{Cache: memory})
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	cached, err := defuddle.ParseFromString(context.Background(), html, &defuddle.Options{Cache: memory})
+	require.NoError(t, err)
+	assert.Equal(t, 1, memory.Len())
+	assert.Contains(t, cached.Content, "Submitted twice.")
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// defuddleEngine is the name of the built-in engine.
+const defuddleEngine = "defuddle"
+
+// Manifest lists the pages to evaluate.
+type Manifest struct {
+	Pages []Page `json:"pages"`
+}
+
+// Page is a saved page and the paragraphs a good extraction must contain.
+type Page struct {
+	// Name identifies the page in the report. It defaults to File.
+	Name string `json:"name,omitempty"`
+	// File is the HTML file, relative to the manifest's directory.
+	File string `json:"file"`
+	// URL is the page's original URL, passed to the extractors.
+	URL string `json:"url,omitempty"`
+	// KeyParagraphs are passages of the main content. Recall is the share of
+	// them found in the extracted text.
+	KeyParagraphs []string `json:"keyParagraphs,omitempty"`
+
+	path string
+	html string
+}
+
+// engine extracts the main content of a page as HTML or text.
+type engine interface {
+	Name() string
+	Extract(ctx context.Context, page *Page) (string, error)
+}
+
+// builtinEngine runs defuddle-go in process.
+type builtinEngine struct{}
+
+func (builtinEngine) Name() string { return defuddleEngine }
+
+func (builtinEngine) Extract(ctx context.Context, page *Page) (string, error) {
+	// Score the pipeline users run, selector removal included
+	options := defuddle.DefaultOptions()
+	options.URL = page.URL
+	result, err := defuddle.ParseFromString(ctx, page.html, options)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// commandEngine runs an external extractor as a subprocess.
+type commandEngine struct {
+	name string
+	args []string
+}
+
+func (e *commandEngine) Name() string { return e.name }
+
+func (e *commandEngine) Extract(ctx context.Context, page *Page) (string, error) {
+	args := make([]string, len(e.args))
+	for i, arg := range e.args {
+		args[i] = strings.NewReplacer("{file}", page.path, "{url}", page.URL).Replace(arg)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) // #nosec G204 - the command is the user's own --engine flag
+	cmd.Stdin = strings.NewReader(page.html)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// buildEngines returns the built-in engine followed by one command engine
+// per NAME=COMMAND spec.
+func buildEngines(specs []string) ([]engine, error) {
+	engines := []engine{builtinEngine{}}
+	for _, spec := range specs {
+		name, command, ok := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		args := strings.Fields(command)
+		if !ok || name == "" || len(args) == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidEngine, spec)
+		}
+		if slices.ContainsFunc(engines, func(e engine) bool { return e.Name() == name }) {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateEngine, name)
+		}
+		engines = append(engines, &commandEngine{name: name, args: args})
+	}
+	return engines, nil
+}
+
+// loadManifest reads the manifest at path and the HTML of its pages.
+func loadManifest(path string) ([]Page, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - the manifest is the command's argument
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s: %w", path, err)
+	}
+	if len(manifest.Pages) == 0 {
+		return nil, ErrNoPages
+	}
+
+	dir := filepath.Dir(path)
+	pages := manifest.Pages
+	for i := range pages {
+		page := &pages[i]
+		if page.Name == "" {
+			page.Name = page.File
+		}
+		if !filepath.IsLocal(page.File) {
+			return nil, fmt.Errorf("%w: %q", ErrDirectoryTraversal, page.File)
+		}
+		page.path = filepath.Join(dir, page.File)
+		content, err := os.ReadFile(page.path) // #nosec G304 - path validated above
+		if err != nil {
+			return nil, fmt.Errorf("error reading page %s: %w", page.Name, err)
+		}
+		page.html = string(content)
+	}
+	return pages, nil
+}
+
+// PageResult is one engine's extraction of one page.
+type PageResult struct {
+	Page   string `json:"page"`
+	Engine string `json:"engine"`
+	// Length is the number of characters of extracted text.
+	Length int `json:"length"`
+	// Recall is the share of key paragraphs found, or nil when the page has
+	// none or the extraction failed.
+	Recall    *float64      `json:"recall,omitempty"`
+	Runtime   time.Duration `json:"-"`
+	RuntimeMS float64       `json:"runtimeMs"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// EngineSummary aggregates an engine's results over all pages.
+type EngineSummary struct {
+	Engine   string `json:"engine"`
+	Pages    int    `json:"pages"`
+	Failures int    `json:"failures"`
+	// MeanLength and MeanRecall average the successful extractions.
+	MeanLength     float64       `json:"meanLength"`
+	MeanRecall     *float64      `json:"meanRecall,omitempty"`
+	Runtime        time.Duration `json:"-"`
+	TotalRuntimeMS float64       `json:"totalRuntimeMs"`
+}
+
+// Report is the outcome of an evaluation.
+type Report struct {
+	Engines []EngineSummary `json:"engines"`
+	Results []PageResult    `json:"results"`
+}
+
+// evaluate runs every page through every engine, one extraction at a time
+// so runtimes are comparable.
+func evaluate(ctx context.Context, pages []Page, engines []engine, timeout time.Duration) *Report {
+	report := &Report{}
+	for i := range pages {
+		for _, e := range engines {
+			report.Results = append(report.Results, run(ctx, &pages[i], e, timeout))
+		}
+	}
+	for _, e := range engines {
+		report.Engines = append(report.Engines, summarize(e.Name(), report.Results))
+	}
+	return report
+}
+
+// run extracts page with e and scores the output.
+func run(ctx context.Context, page *Page, e engine, timeout time.Duration) PageResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := PageResult{Page: page.Name, Engine: e.Name()}
+	start := time.Now()
+	content, err := e.Extract(ctx, page)
+	result.Runtime = time.Since(start)
+	result.RuntimeMS = milliseconds(result.Runtime)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	text := extractedText(content)
+	result.Length = utf8.RuneCountInString(text)
+	if len(page.KeyParagraphs) > 0 {
+		recall := keyParagraphRecall(text, page.KeyParagraphs)
+		result.Recall = &recall
+	}
+	return result
+}
+
+// summarize aggregates the results of the named engine.
+func summarize(name string, results []PageResult) EngineSummary {
+	summary := EngineSummary{Engine: name}
+	var length, recall float64
+	var succeeded, scored int
+	for _, result := range results {
+		if result.Engine != name {
+			continue
+		}
+		summary.Pages++
+		summary.Runtime += result.Runtime
+		if result.Error != "" {
+			summary.Failures++
+			continue
+		}
+		succeeded++
+		length += float64(result.Length)
+		if result.Recall != nil {
+			scored++
+			recall += *result.Recall
+		}
+	}
+	if succeeded > 0 {
+		summary.MeanLength = length / float64(succeeded)
+	}
+	if scored > 0 {
+		mean := recall / float64(scored)
+		summary.MeanRecall = &mean
+	}
+	summary.TotalRuntimeMS = milliseconds(summary.Runtime)
+	return summary
+}
+
+// extractedText returns the visible text of content with whitespace
+// collapsed. Plain text passes through the HTML parser unchanged.
+func extractedText(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return normalizeText(content)
+	}
+	doc.Find("script, style").Remove()
+	return normalizeText(doc.Text())
+}
+
+// keyParagraphRecall returns the share of paragraphs contained in text,
+// ignoring case and whitespace differences.
+func keyParagraphRecall(text string, paragraphs []string) float64 {
+	if len(paragraphs) == 0 {
+		return 0
+	}
+	text = strings.ToLower(normalizeText(text))
+	found := 0
+	for _, paragraph := range paragraphs {
+		if strings.Contains(text, strings.ToLower(normalizeText(paragraph))) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(paragraphs))
+}
+
+// normalizeText collapses runs of whitespace into single spaces.
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// JSON returns the report as indented JSON.
+func (r *Report) JSON() (string, error) {
+	data, err := json.Marshal(r, jsontext.Multiline(true))
+	if err != nil {
+		return "", fmt.Errorf("error marshaling report: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// Markdown returns the report as a summary table followed by a table of
+// every extraction.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Engine | Pages | Failures | Mean length | Mean recall | Runtime |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: |\n")
+	for _, s := range r.Engines {
+		fmt.Fprintf(&b, "| %s | %d | %d | %.0f | %s | %s |\n",
+			s.Engine, s.Pages, s.Failures, s.MeanLength, formatRecall(s.MeanRecall), formatRuntime(s.Runtime))
+	}
+
+	b.WriteString("\n## Pages\n\n")
+	b.WriteString("| Page | Engine | Length | Recall | Runtime | Error |\n")
+	b.WriteString("| --- | --- | ---: | ---: | ---: | --- |\n")
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "| %s | %s | %d | %s | %s | %s |\n",
+			escapeCell(result.Page), result.Engine, result.Length, formatRecall(result.Recall),
+			formatRuntime(result.Runtime), escapeCell(result.Error))
+	}
+	return b.String()
+}
+
+func formatRecall(recall *float64) string {
+	if recall == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", *recall*100)
+}
+
+func formatRuntime(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
+
+// escapeCell keeps a value on one Markdown table row.
+func escapeCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return normalizeText(value)
+}
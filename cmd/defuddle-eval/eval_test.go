@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+const articleHTML = `<html><head><title>Eval</title></head><body>
+<nav><a href="/">Home</a></nav>
+<article>
+<h1>Evaluating extractors</h1>
+<p>The first paragraph of the article explains why extraction quality matters.</p>
+<p>The second paragraph describes how recall is measured against key passages.</p>
+</article>
+<footer>Copyright</footer>
+</body></html>`
+
+// TestEvalHelperProcess is run as an external engine by the tests below.
+func TestEvalHelperProcess(t *testing.T) {
+	if os.Getenv("DEFUDDLE_EVAL_HELPER") != "1" {
+		t.Skip("helper process")
+	}
+	input, _ := io.ReadAll(os.Stdin)
+	switch mode := os.Getenv("DEFUDDLE_EVAL_MODE"); mode {
+	case "fail":
+		fmt.Fprintln(os.Stderr, "extractor crashed")
+		os.Exit(2)
+	case "args":
+		fmt.Println(strings.Join(os.Args[len(os.Args)-2:], " "))
+	default:
+		// Echo only the first paragraph as plain text
+		text := string(input)
+		start := strings.Index(text, "The first")
+		end := strings.Index(text[start:], "</p>")
+		fmt.Println(text[start : start+end])
+	}
+	os.Exit(0)
+}
+
+func helperEngine(t *testing.T, name, mode string, extra ...string) engine {
+	t.Helper()
+	args := append([]string{"env", "DEFUDDLE_EVAL_HELPER=1", "DEFUDDLE_EVAL_MODE=" + mode,
+		os.Args[0], "-test.run=^TestEvalHelperProcess$", "--"}, extra...)
+	return &commandEngine{name: name, args: args}
+}
+
+func writeManifest(t *testing.T, pages string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "article.html"), []byte(articleHTML), 0600))
+	path := filepath.Join(dir, "pages.json")
+	require.NoError(t, os.WriteFile(path, []byte(pages), 0600))
+	return path
+}
+
+func TestKeyParagraphRecallIgnoresCaseAndWhitespace(t *testing.T) {
+	t.Parallel()
+
+	text := "Intro.  The FIRST paragraph\n is here. Outro."
+	recall := keyParagraphRecall(text, []string{"the first paragraph is here", "missing passage"})
+
+	assert.InDelta(t, 0.5, recall, 1e-9)
+}
+
+func TestExtractedTextStripsMarkupAndScripts(t *testing.T) {
+	t.Parallel()
+
+	text := extractedText("<p>Hello <b>world</b></p><script>var x;</script>\n<p>again</p>")
+
+	assert.Equal(t, "Hello world again", text)
+	assert.Equal(t, "plain text", extractedText("  plain\ntext "))
+}
+
+func TestBuildEnginesRejectsInvalidSpecs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		spec string
+		want error
+	}{
+		{name: "missing command", spec: "trafilatura", want: ErrInvalidEngine},
+		{name: "empty command", spec: "trafilatura= ", want: ErrInvalidEngine},
+		{name: "empty name", spec: "=trafilatura", want: ErrInvalidEngine},
+		{name: "builtin name", spec: "defuddle=defuddle parse {file}", want: ErrDuplicateEngine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := buildEngines([]string{tt.spec})
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoadManifestRejectsPathsOutsideManifestDir(t *testing.T) {
+	t.Parallel()
+
+	path := writeManifest(t, `{"pages":[{"file":"../secret.html"}]}`)
+
+	_, err := loadManifest(path)
+
+	assert.ErrorIs(t, err, ErrDirectoryTraversal)
+}
+
+func TestEvaluateScoresBuiltinAndCommandEngines(t *testing.T) {
+	t.Parallel()
+
+	path := writeManifest(t, `{"pages":[{"name":"article","file":"article.html","url":"https://example.com/a",
+		"keyParagraphs":["The first paragraph of the article explains why extraction quality matters.",
+			"The second paragraph describes how recall is measured against key passages."]}]}`)
+	pages, err := loadManifest(path)
+	require.NoError(t, err)
+
+	engines := []engine{builtinEngine{}, helperEngine(t, "partial", "first"), helperEngine(t, "broken", "fail")}
+	report := evaluate(context.Background(), pages, engines, 30*time.Second)
+
+	require.Len(t, report.Results, 3)
+	builtin, partial, broken := report.Results[0], report.Results[1], report.Results[2]
+
+	assert.Empty(t, builtin.Error)
+	require.NotNil(t, builtin.Recall)
+	assert.InDelta(t, 1.0, *builtin.Recall, 1e-9)
+	assert.Positive(t, builtin.Length)
+
+	assert.Empty(t, partial.Error)
+	require.NotNil(t, partial.Recall)
+	assert.InDelta(t, 0.5, *partial.Recall, 1e-9)
+
+	assert.Contains(t, broken.Error, "extractor crashed")
+	assert.Nil(t, broken.Recall)
+
+	require.Len(t, report.Engines, 3)
+	assert.Equal(t, 1, report.Engines[2].Failures)
+	assert.Nil(t, report.Engines[2].MeanRecall)
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "| defuddle | 1 | 0 |")
+	assert.Contains(t, markdown, "| partial | 1 | 0 |")
+	assert.Contains(t, markdown, "| article | broken | 0 | - |")
+}
+
+func TestBuiltinEngineMatchesLibraryDefaults(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Eval</title></head><body><article>
+<h1>Evaluating extractors</h1>
+<p>The first paragraph of the article explains why extraction quality matters.</p>
+<div class="sidebar"><p>Related stories from elsewhere on the site.</p></div>
+<nav><a href="/next">Next article in the series</a></nav>
+<p>The second paragraph describes how recall is measured against key passages.</p>
+</article></body></html>`
+	page := &Page{Name: "sidebar", URL: "https://example.com/a", html: html}
+
+	got, err := builtinEngine{}.Extract(context.Background(), page)
+	require.NoError(t, err)
+
+	options := defuddle.DefaultOptions()
+	options.URL = page.URL
+	want, err := defuddle.ParseFromString(context.Background(), html, options)
+	require.NoError(t, err)
+	assert.Equal(t, want.Content, got)
+	assert.NotContains(t, got, "Related stories")
+	assert.NotContains(t, got, "Next article")
+}
+
+func TestCommandEngineSubstitutesPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	page := &Page{Name: "a", path: "pages/a.html", URL: "https://example.com/a", html: articleHTML}
+	e := helperEngine(t, "args", "args", "{file}", "{url}")
+
+	out, err := e.Extract(context.Background(), page)
+
+	require.NoError(t, err)
+	assert.Equal(t, "pages/a.html https://example.com/a", strings.TrimSpace(out))
+}
+
+func TestExecuteEvalWritesJSONReport(t *testing.T) {
+	t.Parallel()
+
+	path := writeManifest(t, `{"pages":[{"file":"article.html","keyParagraphs":["explains why extraction quality matters"]}]}`)
+	output := filepath.Join(t.TempDir(), "report.json")
+
+	err := executeEval(context.Background(), &EvalOptions{Manifest: path, Timeout: 30 * time.Second, JSON: true, Output: output})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Engines, 1)
+	assert.Equal(t, "defuddle", report.Engines[0].Engine)
+	require.NotNil(t, report.Engines[0].MeanRecall)
+	assert.InDelta(t, 1.0, *report.Engines[0].MeanRecall, 1e-9)
+	assert.Equal(t, "article.html", report.Results[0].Page)
+}
@@ -0,0 +1,119 @@
+// Package main provides the defuddle-eval CLI, which compares content
+// extractors on a set of saved pages.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrInvalidEngine is returned when an --engine flag is not in NAME=COMMAND form.
+var ErrInvalidEngine = fmt.Errorf("invalid engine (expected 'NAME=COMMAND')")
+
+// ErrDuplicateEngine is returned when two engines share a name.
+var ErrDuplicateEngine = fmt.Errorf("duplicate engine name")
+
+// ErrDirectoryTraversal is returned when a page path leaves the manifest directory.
+var ErrDirectoryTraversal = fmt.Errorf("invalid page path: directory traversal detected")
+
+// ErrNoPages is returned when the manifest lists no pages.
+var ErrNoPages = fmt.Errorf("manifest lists no pages")
+
+var rootCmd = &cobra.Command{
+	Use:   "defuddle-eval <manifest>",
+	Short: "Compare content extractors on a set of saved pages",
+	Long: `defuddle-eval runs every page of a manifest through defuddle-go and any
+external extractors given with --engine, and reports the extracted text
+length, key-paragraph recall, and runtime of each.
+
+An engine command is split on spaces. "{file}" is replaced by the page's
+HTML file and "{url}" by its URL; the HTML is also written to the command's
+stdin. The command's stdout is the extracted content, as HTML or text.`,
+	Example: `  defuddle-eval pages.json \
+    --engine 'trafilatura=trafilatura --output-format txt' \
+    --engine 'readability=go-readability {file}'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEval,
+}
+
+// EvalOptions configures the eval command.
+type EvalOptions struct {
+	Manifest string
+	Engines  []string
+	Timeout  time.Duration
+	JSON     bool
+	Output   string
+}
+
+func init() {
+	rootCmd.Flags().StringArrayP("engine", "e", []string{}, "External extractor in form 'NAME=COMMAND' (repeatable)")
+	rootCmd.Flags().Duration("timeout", 30*time.Second, "Time limit for each extraction")
+	rootCmd.Flags().BoolP("json", "j", false, "Output the report as JSON")
+	rootCmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runEval(cmd *cobra.Command, args []string) error {
+	engines, _ := cmd.Flags().GetStringArray("engine")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	output, _ := cmd.Flags().GetString("output")
+
+	return executeEval(cmd.Context(), &EvalOptions{
+		Manifest: args[0],
+		Engines:  engines,
+		Timeout:  timeout,
+		JSON:     jsonOutput,
+		Output:   output,
+	})
+}
+
+func executeEval(ctx context.Context, opts *EvalOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pages, err := loadManifest(opts.Manifest)
+	if err != nil {
+		return err
+	}
+	engines, err := buildEngines(opts.Engines)
+	if err != nil {
+		return err
+	}
+
+	report := evaluate(ctx, pages, engines, opts.Timeout)
+
+	var content string
+	if opts.JSON {
+		content, err = report.JSON()
+		if err != nil {
+			return err
+		}
+	} else {
+		content = report.Markdown()
+	}
+	return writeOutput(opts.Output, content)
+}
+
+func writeOutput(filename, content string) error {
+	if filename == "" {
+		fmt.Print(content)
+		return nil
+	}
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Report written to %s\n", filename)
+	return nil
+}
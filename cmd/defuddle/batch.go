@@ -0,0 +1,684 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/spf13/cobra"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/internal/webarchive"
+	"github.com/kaptinlin/defuddle-go/sink"
+)
+
+// ErrNoBatchInputs is returned when the batch input lists no sources.
+var ErrNoBatchInputs = fmt.Errorf("no inputs to parse")
+
+// ErrInvalidConcurrency is returned when --concurrency is below one.
+var ErrInvalidConcurrency = fmt.Errorf("--concurrency must be at least 1")
+
+// ErrBatchFailed is returned after a batch in which some inputs failed.
+var ErrBatchFailed = fmt.Errorf("batch had failures")
+
+// ErrUnsupportedBatchFormat is returned when --format names an unknown
+// batch output format.
+var ErrUnsupportedBatchFormat = fmt.Errorf("unsupported batch format (expected ndjson or json)")
+
+// defaultConcurrency is the number of inputs parsed at once by default.
+const defaultConcurrency = 4
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <file-or-dir>",
+	Short: "Parse many URLs or HTML files concurrently",
+	Long: `Parse every source listed in a file (one URL or path per line; "-" reads stdin)
+or every .html and .htm file under a directory, and write one JSON record per input.
+Records are NDJSON by default and follow the input order; with --unordered each
+record is written as soon as its input finishes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: batchContent,
+}
+
+// BatchOptions configures the batch command.
+type BatchOptions struct {
+	// Input is a file listing sources, a directory of HTML files, or "-"
+	// for a list on Stdin.
+	Input       string
+	Concurrency int
+	// Format is "ndjson" or "json"; empty keeps JSON as given.
+	Format string
+	JSON   bool
+	// Unordered writes each record as soon as its input finishes instead of
+	// in input order.
+	Unordered       bool
+	Markdown        bool
+	Output          string
+	Force           bool
+	UserAgent       string
+	Headers         []string
+	Timeout         time.Duration
+	Retries         int
+	RetryDelay      time.Duration
+	RateLimit       float64
+	RespectRobots   bool
+	Proxy           string
+	DNS             string
+	UnixSocket      string
+	MaxRedirects    int
+	ConsentCookies  bool
+	PreferAMP       bool
+	WholePage       bool
+	Sanitize        bool
+	RecoverBody     bool
+	NormalizeTimes  bool
+	Extractors      string
+	ExtractorConfig string
+	BaseDir         string
+	// Sink, when set, also receives every successful result.
+	Sink defuddle.Sink
+	// WARC, when set, is a file that also receives every fetched response
+	// as WARC records; a .gz name compresses each record.
+	WARC string
+	// Stdin is read when Input is "-". Defaults to os.Stdin.
+	Stdin io.Reader
+	// Plan writes the requests and estimated schedule of the batch instead
+	// of running it.
+	Plan bool
+	// Checkpoint, when set, is a file that receives the record of each
+	// finished input as it finishes.
+	Checkpoint string
+	// Resume reuses the records of the inputs that Checkpoint lists as
+	// succeeded or skipped, instead of parsing them again.
+	Resume bool
+	// FailureReport, when set, is a file that receives a JSON report of
+	// the failed and low-confidence inputs by failure class and host.
+	FailureReport string
+	// RetryClasses limits the batch to the inputs that FailureReport, as
+	// left by an earlier batch, puts in one of these failure classes.
+	RetryClasses []string
+	// PerHost, when positive, is the most inputs of one host parsed at once.
+	PerHost int
+	// Progress, when set, receives a ProgressEvent as one JSON line each
+	// time an input starts or finishes.
+	Progress io.Writer
+	// LearnBoilerplate parses every input before writing any record, then
+	// removes the content blocks that repeat across the pages of a domain
+	// from each of its results.
+	LearnBoilerplate bool
+}
+
+// BatchRecord is the outcome of one batch input.
+type BatchRecord struct {
+	Source string           `json:"source"`
+	Result *defuddle.Result `json:"result,omitempty"`
+	Error  string           `json:"error,omitempty"`
+	// Skipped marks a URL that robots.txt disallows; it is not counted as
+	// a failure.
+	Skipped bool `json:"skipped,omitzero"`
+	// Class is the failure class of a failed input, or "low-confidence"
+	// for a result that is probably not the page's content.
+	Class string `json:"class,omitempty"`
+}
+
+func init() {
+	batchCmd.Flags().IntP("concurrency", "c", defaultConcurrency, "Number of inputs parsed at once")
+	batchCmd.Flags().Int("per-host", 0, "Most inputs of one host parsed at once (0 is unlimited); hosts always take turns")
+	batchCmd.Flags().Bool("progress", false, "Write a JSON progress event with queue depth and per-host in-flight counts to stderr as each input starts and finishes")
+	batchCmd.Flags().BoolP("json", "j", false, "Write a single JSON array instead of NDJSON")
+	batchCmd.Flags().String("format", "", "Output format: ndjson (one record per line) or json (a single array)")
+	batchCmd.Flags().Bool("unordered", false, "Write each record as soon as it finishes instead of in input order")
+	batchCmd.Flags().BoolP("markdown", "m", false, "Include contentMarkdown in each result")
+	batchCmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	batchCmd.Flags().BoolP("force", "f", false, "Overwrite an existing output file")
+	batchCmd.Flags().String("sink-dir", "", "Also write each result as a JSON file under this directory")
+	batchCmd.Flags().String("warc", "", "Also write the fetched responses to this WARC file (.gz compresses each record)")
+	batchCmd.Flags().String("user-agent", "", "Custom user agent string")
+	batchCmd.Flags().StringArrayP("header", "H", []string{}, "Custom headers in format 'Key: Value'")
+	batchCmd.Flags().Duration("timeout", 30*time.Second, "Timeout for each input")
+	batchCmd.Flags().Int("retries", 0, "Retry a URL fetch that failed with a network error, timeout, 429, or 5xx this many times")
+	batchCmd.Flags().Duration("retry-delay", time.Second, "Delay before the first retry, doubled for each retry after it")
+	batchCmd.Flags().Float64("rate-limit", 0, "Most requests per second to each host, across all workers (0 is unlimited)")
+	batchCmd.Flags().Bool("respect-robots", false, "Check each site's robots.txt and skip URLs it disallows")
+	batchCmd.Flags().String("proxy", "", "Proxy URL (e.g., http://localhost:8080, socks5://localhost:1080)")
+	batchCmd.Flags().String("unix-socket", "", "Send every request over this Unix domain socket, such as a sidecar fetcher's")
+	batchCmd.Flags().String("dns", "", "Look up hosts with this DNS server (host[:port]) or DNS-over-HTTPS URL (e.g., https://1.1.1.1/dns-query)")
+	batchCmd.Flags().Int("max-redirects", 0, "Follow up to this many meta refresh and JavaScript redirects of each URL")
+	batchCmd.Flags().Bool("consent-cookies", false, "Fetch a consent wall again with the cookies of an accepting visitor")
+	batchCmd.Flags().Bool("prefer-amp", false, "Parse the AMP version each URL links with rel=\"amphtml\" instead of the page")
+	batchCmd.Flags().Bool("whole-page", false, "Clean up the whole page body without selecting main content")
+	batchCmd.Flags().Bool("sanitize", false, "Sanitize the content HTML with a strict allowlist for embedding")
+	batchCmd.Flags().Bool("recover-article-body", false, "Replace a paywall teaser with the full text of the page's schema.org articleBody")
+	batchCmd.Flags().Bool("normalize-times", false, "Show the date of time elements whose text is relative, such as \"3 days ago\"")
+	batchCmd.Flags().String("extractors", "", "JSON manifest of external extractors to use alongside the built-ins")
+	batchCmd.Flags().String("extractor-config", "", "YAML or JSON file of selector-based extractor rules to use alongside the built-ins")
+	batchCmd.Flags().String("base-dir", "", "Only read the input, listed, and manifest files inside this directory")
+	batchCmd.Flags().Bool("learn-boilerplate", false, "Parse all inputs first, then remove content blocks repeated on at least half the pages of a domain")
+	batchCmd.Flags().String("checkpoint", "", "Record each finished input in this file so an interrupted batch can be resumed")
+	batchCmd.Flags().Bool("resume", false, "Continue from --checkpoint, reusing the records of inputs that already succeeded")
+	batchCmd.Flags().Bool("plan", false, "Print the request count, per-host schedule, and estimated duration without fetching")
+	batchCmd.Flags().String("failure-report", "", "Write the failed and low-confidence inputs, grouped by failure class and host, to this JSON file")
+	batchCmd.Flags().StringSlice("retry-failcategory", nil, "Only parse the inputs that --failure-report lists in these classes (dns, tls, 4xx, 5xx, timeout, network, parse, sink, low-confidence)")
+	completeBatchFlags(batchCmd)
+
+	rootCmd.AddCommand(batchCmd)
+}
+
+func batchContent(cmd *cobra.Command, args []string) error {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	perHost, _ := cmd.Flags().GetInt("per-host")
+	progress, _ := cmd.Flags().GetBool("progress")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	format, _ := cmd.Flags().GetString("format")
+	unordered, _ := cmd.Flags().GetBool("unordered")
+	markdown, _ := cmd.Flags().GetBool("markdown")
+	output, _ := cmd.Flags().GetString("output")
+	force, _ := cmd.Flags().GetBool("force")
+	sinkDir, _ := cmd.Flags().GetString("sink-dir")
+	warc, _ := cmd.Flags().GetString("warc")
+	userAgent, _ := cmd.Flags().GetString("user-agent")
+	headers, _ := cmd.Flags().GetStringArray("header")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryDelay, _ := cmd.Flags().GetDuration("retry-delay")
+	rateLimit, _ := cmd.Flags().GetFloat64("rate-limit")
+	respectRobots, _ := cmd.Flags().GetBool("respect-robots")
+	proxy, _ := cmd.Flags().GetString("proxy")
+	dns, _ := cmd.Flags().GetString("dns")
+	unixSocket, _ := cmd.Flags().GetString("unix-socket")
+	maxRedirects, _ := cmd.Flags().GetInt("max-redirects")
+	consentCookies, _ := cmd.Flags().GetBool("consent-cookies")
+	preferAMP, _ := cmd.Flags().GetBool("prefer-amp")
+	wholePage, _ := cmd.Flags().GetBool("whole-page")
+	sanitize, _ := cmd.Flags().GetBool("sanitize")
+	recoverBody, _ := cmd.Flags().GetBool("recover-article-body")
+	normalizeTimes, _ := cmd.Flags().GetBool("normalize-times")
+	extractorManifest, _ := cmd.Flags().GetString("extractors")
+	extractorConfig, _ := cmd.Flags().GetString("extractor-config")
+	baseDir, _ := cmd.Flags().GetString("base-dir")
+	plan, _ := cmd.Flags().GetBool("plan")
+	checkpointFile, _ := cmd.Flags().GetString("checkpoint")
+	resume, _ := cmd.Flags().GetBool("resume")
+	failureReport, _ := cmd.Flags().GetString("failure-report")
+	retryClasses, _ := cmd.Flags().GetStringSlice("retry-failcategory")
+	learnBoilerplate, _ := cmd.Flags().GetBool("learn-boilerplate")
+
+	opts := &BatchOptions{
+		Input:            args[0],
+		Concurrency:      concurrency,
+		Format:           format,
+		JSON:             jsonOutput,
+		Unordered:        unordered,
+		Markdown:         markdown,
+		Output:           output,
+		Force:            force,
+		UserAgent:        userAgent,
+		Headers:          headers,
+		Timeout:          timeout,
+		Retries:          retries,
+		RetryDelay:       retryDelay,
+		RateLimit:        rateLimit,
+		RespectRobots:    respectRobots,
+		Proxy:            proxy,
+		DNS:              dns,
+		UnixSocket:       unixSocket,
+		MaxRedirects:     maxRedirects,
+		ConsentCookies:   consentCookies,
+		PreferAMP:        preferAMP,
+		WholePage:        wholePage,
+		Sanitize:         sanitize,
+		RecoverBody:      recoverBody,
+		NormalizeTimes:   normalizeTimes,
+		Extractors:       extractorManifest,
+		ExtractorConfig:  extractorConfig,
+		BaseDir:          baseDir,
+		WARC:             warc,
+		Stdin:            cmd.InOrStdin(),
+		Plan:             plan,
+		Checkpoint:       checkpointFile,
+		Resume:           resume,
+		FailureReport:    failureReport,
+		RetryClasses:     retryClasses,
+		PerHost:          perHost,
+		LearnBoilerplate: learnBoilerplate,
+	}
+	if progress {
+		opts.Progress = cmd.ErrOrStderr()
+	}
+	if sinkDir != "" {
+		opts.Sink = sink.NewFileSink(sinkDir)
+	}
+
+	return executeBatch(opts)
+}
+
+func executeBatch(opts *BatchOptions) error {
+	if opts.Concurrency < 1 {
+		return fmt.Errorf("%w: %d", ErrInvalidConcurrency, opts.Concurrency)
+	}
+	switch strings.ToLower(opts.Format) {
+	case "":
+	case formatNDJSON:
+		opts.JSON = false
+	case formatJSON:
+		opts.JSON = true
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedBatchFormat, opts.Format)
+	}
+	if err := validateHeaders(opts.Headers); err != nil {
+		return err
+	}
+	if err := checkOutput(&ParseOptions{Output: opts.Output, Force: opts.Force}); err != nil {
+		return err
+	}
+	if err := checkOutput(&ParseOptions{Output: opts.WARC, Force: opts.Force}); err != nil {
+		return err
+	}
+	if opts.Resume && opts.Checkpoint == "" {
+		return ErrResumeWithoutCheckpoint
+	}
+	if !opts.Resume && !opts.Plan {
+		// A fresh batch would throw away the records of an interrupted one
+		if err := checkOutput(&ParseOptions{Output: opts.Checkpoint, Force: opts.Force}); err != nil {
+			return err
+		}
+	}
+	retryClasses, err := parseFailureClasses(opts.RetryClasses)
+	if err != nil {
+		return err
+	}
+	if len(retryClasses) > 0 && opts.FailureReport == "" {
+		return ErrRetryWithoutReport
+	}
+	if len(retryClasses) == 0 && !opts.Plan {
+		// A retry replaces the report it reads
+		if err := checkOutput(&ParseOptions{Output: opts.FailureReport, Force: opts.Force}); err != nil {
+			return err
+		}
+	}
+	registry, err := extractorRegistry(opts.Extractors, opts.ExtractorConfig, opts.BaseDir)
+	if err != nil {
+		return err
+	}
+	client, err := newRequestsClient(&ParseOptions{
+		UserAgent:     opts.UserAgent,
+		Headers:       opts.Headers,
+		Timeout:       opts.Timeout,
+		Retries:       opts.Retries,
+		RetryDelay:    opts.RetryDelay,
+		RateLimit:     opts.RateLimit,
+		RespectRobots: opts.RespectRobots,
+		Proxy:         opts.Proxy,
+		DNS:           opts.DNS,
+		UnixSocket:    opts.UnixSocket,
+	})
+	if err != nil {
+		return err
+	}
+
+	sources, err := batchSources(opts)
+	if err != nil {
+		return err
+	}
+	if len(retryClasses) > 0 {
+		if sources, err = retrySources(sources, opts.FailureReport, retryClasses); err != nil {
+			return err
+		}
+	}
+	if len(sources) == 0 {
+		return ErrNoBatchInputs
+	}
+	done := map[string]BatchRecord{}
+	if opts.Resume {
+		if done, err = loadCheckpoint(opts.Checkpoint); err != nil {
+			return err
+		}
+	}
+	if opts.Plan {
+		remaining := slices.DeleteFunc(slices.Clone(sources), func(source string) bool {
+			_, finished := done[source]
+			return finished
+		})
+		plan := planBatch(remaining, opts)
+		if opts.Output == "" {
+			return plan.write(os.Stdout, opts)
+		}
+		return writeFileAtomic(opts.Output, opts.Force, func(w io.Writer) error { return plan.write(w, opts) })
+	}
+	var progress *checkpoint
+	if opts.Checkpoint != "" {
+		if progress, err = openCheckpoint(opts.Checkpoint, opts.Resume); err != nil {
+			return err
+		}
+	}
+
+	parseRecord := func(source string) BatchRecord {
+		record := BatchRecord{Source: source}
+		// Start from the library defaults, so records match the API output
+		options := defuddle.DefaultOptions()
+		options.URL = source
+		options.Markdown = opts.Markdown
+		options.SeparateMarkdown = opts.Markdown
+		options.SkipContentSelection = opts.WholePage
+		options.Sanitize = opts.Sanitize
+		options.Extractors = registry
+		options.Client = client
+		options.MaxClientRedirects = opts.MaxRedirects
+		options.RecoverFromStructuredData = opts.RecoverBody
+		options.NormalizeTimes = opts.NormalizeTimes
+		options.ConsentCookies = opts.ConsentCookies
+		options.PreferAMP = opts.PreferAMP
+		result, err := loadResult(source, options, opts.Timeout, opts.BaseDir)
+		if err != nil {
+			record.Error = err.Error()
+			record.Skipped = errors.Is(err, defuddle.ErrDisallowedByRobots)
+			if !record.Skipped {
+				record.Class = failureClass(err)
+			}
+			return record
+		}
+		record.Result = result
+		return record
+	}
+	scheduleProgress := opts.Progress
+	if opts.LearnBoilerplate {
+		parseRecord, err = learnBoilerplate(sources, done, opts, parseRecord)
+		if err != nil {
+			return err
+		}
+		// The first pass reported the parses
+		scheduleProgress = nil
+	}
+	// Inputs the checkpoint finished are not parsed again
+	var triageMu sync.Mutex
+	var triaged []BatchRecord
+	parse := func(source string) BatchRecord {
+		record, ok := done[source]
+		if !ok {
+			record = parseRecord(source)
+			if record.Result != nil && lowConfidence(record.Result) {
+				record.Class = failureLowConfidence
+			}
+			if record.Result != nil && opts.Sink != nil {
+				ctx, cancel := parseContext(opts.Timeout)
+				if err := opts.Sink.Write(ctx, source, record.Result); err != nil {
+					record.Error = fmt.Sprintf("error writing to sink: %v", err)
+					record.Class = failureSink
+				}
+				cancel()
+			}
+			if progress != nil {
+				progress.add(record)
+			}
+		}
+		if opts.FailureReport != "" && record.Class != "" {
+			// The report needs no results, which runBatch releases once written
+			triageMu.Lock()
+			triaged = append(triaged, BatchRecord{Source: record.Source, Error: record.Error, Class: record.Class})
+			triageMu.Unlock()
+		}
+		return record
+	}
+
+	var failed int
+	write := func(w io.Writer) error {
+		var err error
+		sched := newHostScheduler(sources, opts.PerHost, scheduleProgress)
+		failed, err = runBatch(sched, opts.Concurrency, parse, w, opts.JSON, !opts.Unordered)
+		return err
+	}
+	output := func() error {
+		if opts.Output == "" {
+			return write(os.Stdout)
+		}
+		return writeFileAtomic(opts.Output, opts.Force, write)
+	}
+	if opts.WARC == "" {
+		err = output()
+	} else {
+		var outputErr error
+		err = writeFileAtomic(opts.WARC, opts.Force, func(w io.Writer) error {
+			ww := webarchive.NewWARCWriter(w, strings.HasSuffix(strings.ToLower(opts.WARC), ".gz"))
+			if err := ww.WriteInfo("defuddle", time.Now()); err != nil {
+				return err
+			}
+			client.AddMiddleware(warcMiddleware(ww))
+			if outputErr = output(); outputErr != nil {
+				// Leave no WARC file behind a batch whose output failed
+				return outputErr
+			}
+			return ww.Err()
+		})
+		if outputErr != nil {
+			err = outputErr
+		}
+	}
+	if progress != nil {
+		err = errors.Join(err, progress.close())
+	}
+	if opts.FailureReport != "" && err == nil {
+		order := make(map[string]int, len(sources))
+		for i, source := range slices.Backward(sources) {
+			order[source] = i
+		}
+		slices.SortStableFunc(triaged, func(a, b BatchRecord) int { return cmp.Compare(order[a.Source], order[b.Source]) })
+		report := newFailureReport(len(sources), triaged)
+		err = writeFileAtomic(opts.FailureReport, true, report.write)
+	}
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d inputs failed", ErrBatchFailed, failed, len(sources))
+	}
+	return nil
+}
+
+// learnBoilerplate parses the sources that done does not hold with
+// parseRecord, as runBatch would, and learns the boilerplate of each domain
+// from those results and the ones in done. It returns a parseRecord that
+// hands out the parsed records with that boilerplate removed.
+func learnBoilerplate(sources []string, done map[string]BatchRecord, opts *BatchOptions, parseRecord func(string) BatchRecord) (func(string) BatchRecord, error) {
+	pending := slices.DeleteFunc(slices.Clone(sources), func(source string) bool {
+		_, finished := done[source]
+		return finished
+	})
+	records := make([]BatchRecord, len(pending))
+	sched := newHostScheduler(pending, opts.PerHost, opts.Progress)
+	var wg sync.WaitGroup
+	for range min(opts.Concurrency, len(pending)) {
+		wg.Go(func() {
+			for {
+				i, ok := sched.take()
+				if !ok {
+					return
+				}
+				records[i] = parseRecord(pending[i])
+				sched.done(i)
+			}
+		})
+	}
+	wg.Wait()
+
+	domains := make(map[string][]*defuddle.Result)
+	for _, record := range slices.Concat(records, slices.Collect(maps.Values(done))) {
+		if record.Result != nil {
+			domains[record.Result.Domain] = append(domains[record.Result.Domain], record.Result)
+		}
+	}
+	reports := make(map[string]*defuddle.SiteReport, len(domains))
+	for domain, results := range domains {
+		report, err := defuddle.AggregateSite(results)
+		if err != nil {
+			return nil, err
+		}
+		reports[domain] = report
+	}
+
+	parsed := make(map[string]BatchRecord, len(pending))
+	for i, record := range records {
+		if record.Result != nil {
+			if _, err := reports[record.Result.Domain].RemoveBoilerplate(record.Result, nil); err != nil {
+				record.Error = fmt.Sprintf("error removing boilerplate: %v", err)
+				record.Class = failureParse
+				record.Result = nil
+			}
+		}
+		parsed[pending[i]] = record
+	}
+	return func(source string) BatchRecord { return parsed[source] }, nil
+}
+
+// runBatch parses the sources of sched with concurrency workers, in the
+// order sched hands them out, and writes one record per source to w, as
+// NDJSON or, with asArray, one JSON array. Records follow the input order
+// when ordered is set, and otherwise the order in which inputs finish. It returns the number of records with an error.
+func runBatch(sched *hostScheduler, concurrency int, parse func(string) BatchRecord, w io.Writer, asArray, ordered bool) (int, error) {
+	sources := sched.sources
+	records := make([]BatchRecord, len(sources))
+	done := make([]chan struct{}, len(sources))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	finished := make(chan int, len(sources))
+
+	var wg sync.WaitGroup
+	for range min(concurrency, len(sources)) {
+		wg.Go(func() {
+			for {
+				i, ok := sched.take()
+				if !ok {
+					return
+				}
+				records[i] = parse(sources[i])
+				sched.done(i)
+				close(done[i])
+				finished <- i
+			}
+		})
+	}
+	// Parsing continues if writing fails, so the workers always finish
+	defer wg.Wait()
+
+	buf := bufio.NewWriter(w)
+	failed := 0
+	if asArray {
+		_, _ = buf.WriteString("[")
+	}
+	for n := range sources {
+		i := n
+		if ordered {
+			<-done[i]
+		} else {
+			i = <-finished
+		}
+		record := records[i]
+		records[i] = BatchRecord{}
+		if record.Error != "" && !record.Skipped {
+			failed++
+		}
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			data, _ = json.Marshal(BatchRecord{Source: record.Source, Error: fmt.Sprintf("error marshaling JSON: %v", err)})
+		}
+		if asArray && n > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		if asArray {
+			_, _ = buf.WriteString("\n")
+		}
+		_, _ = buf.Write(data)
+		if !asArray {
+			_ = buf.WriteByte('\n')
+		}
+		// Flush per record so NDJSON consumers see progress
+		if err := buf.Flush(); err != nil {
+			return failed, fmt.Errorf("error writing output: %w", err)
+		}
+	}
+	if asArray {
+		_, _ = buf.WriteString("\n]\n")
+	}
+	if err := buf.Flush(); err != nil {
+		return failed, fmt.Errorf("error writing output: %w", err)
+	}
+	return failed, nil
+}
+
+// batchSources returns the sources named by opts.Input: the .html and .htm
+// files under a directory, or the non-blank lines of a list file or stdin
+// that do not start with "#".
+func batchSources(opts *BatchOptions) ([]string, error) {
+	if opts.Input == "-" {
+		stdin := opts.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		return readSourceList(stdin)
+	}
+
+	input, err := validateFilePath(opts.Input, opts.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, fmt.Errorf("error reading batch input: %w", err)
+	}
+
+	if !info.IsDir() {
+		f, err := os.Open(input) // #nosec G304 - path validated above
+		if err != nil {
+			return nil, fmt.Errorf("error reading batch input: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		return readSourceList(f)
+	}
+
+	var sources []string
+	err = filepath.WalkDir(input, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".html", ".htm":
+			if entry.Type().IsRegular() {
+				sources = append(sources, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading batch input: %w", err)
+	}
+	return sources, nil
+}
+
+// readSourceList reads one source per line, skipping blank lines and
+// "#" comments.
+func readSourceList(r io.Reader) ([]string, error) {
+	var sources []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sources = append(sources, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading batch input: %w", err)
+	}
+	return sources, nil
+}
@@ -0,0 +1,729 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/internal/webarchive"
+)
+
+func batchPage(title string) string {
+	return `<html><head><title>` + title + `</title></head><body><article><h1>` + title +
+		`</h1><p>The body of ` + title + ` has enough words to be picked as the main content.</p></article></body></html>`
+}
+
+func readRecords(t *testing.T, path string) []BatchRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var records []BatchRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record BatchRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+// recordingSink collects the sources written to it.
+type recordingSink struct {
+	mu      sync.Mutex
+	sources []string
+}
+
+func (s *recordingSink) Write(_ context.Context, source string, _ *defuddle.Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources = append(s.sources, source)
+	return nil
+}
+
+func TestExecuteBatchParsesDirectoryInOrder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pages := filepath.Join(dir, "pages")
+	require.NoError(t, os.MkdirAll(filepath.Join(pages, "nested"), 0o750))
+	for name, title := range map[string]string{"b.html": "Second", "a.htm": "First", "nested/c.HTML": "Third"} {
+		require.NoError(t, os.WriteFile(filepath.Join(pages, name), []byte(batchPage(title)), 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(pages, "notes.txt"), []byte("skipped"), 0o600))
+	output := filepath.Join(dir, "results.ndjson")
+
+	err := executeBatch(&BatchOptions{Input: pages, Concurrency: 2, Markdown: true, Output: output, Timeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	records := readRecords(t, output)
+	require.Len(t, records, 3)
+	for i, title := range []string{"First", "Second", "Third"} {
+		assert.Empty(t, records[i].Error)
+		require.NotNil(t, records[i].Result)
+		assert.Equal(t, title, records[i].Result.Title)
+		require.NotNil(t, records[i].Result.ContentMarkdown)
+	}
+	assert.Equal(t, filepath.Join(pages, "a.htm"), records[0].Source)
+}
+
+func TestExecuteBatchRemovesClutterLikeTheLibrary(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	page := `<html><head><title>Sidebar</title></head><body><article><h1>Sidebar</h1>
+<p>The first paragraph of the article explains why extraction quality matters.</p>
+<div class="sidebar"><p>Related stories from elsewhere on the site.</p></div>
+<nav><a href="/next">Next article in the series</a></nav>
+<p>The second paragraph describes how recall is measured against key passages.</p>
+</article></body></html>`
+	pages := filepath.Join(dir, "pages")
+	require.NoError(t, os.MkdirAll(pages, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(pages, "page.html"), []byte(page), 0o600))
+	output := filepath.Join(dir, "results.ndjson")
+
+	require.NoError(t, executeBatch(&BatchOptions{Input: pages, Concurrency: 1, Output: output, Timeout: 5 * time.Second}))
+
+	records := readRecords(t, output)
+	require.Len(t, records, 1)
+	require.NotNil(t, records[0].Result)
+	want, err := defuddle.ParseFromString(context.Background(), page, defuddle.DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, want.Content, records[0].Result.Content)
+	assert.NotContains(t, records[0].Result.Content, "Next article")
+}
+
+func TestExecuteBatchRecordsFailuresAndForwardsHeaders(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Trace") != "batch" || r.Header.Get("User-Agent") != "batch-agent" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(batchPage("Remote")))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.txt")
+	missing := filepath.Join(dir, "missing.html")
+	require.NoError(t, os.WriteFile(list, []byte("# sources\n"+server.URL+"\n\n"+missing+"\n"), 0o600))
+	output := filepath.Join(dir, "results.ndjson")
+
+	err := executeBatch(&BatchOptions{
+		Input:       list,
+		Concurrency: 4,
+		Output:      output,
+		UserAgent:   "batch-agent",
+		Headers:     []string{"X-Trace: batch"},
+		Timeout:     5 * time.Second,
+	})
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, err.Error(), "1 of 2 inputs failed")
+
+	records := readRecords(t, output)
+	require.Len(t, records, 2)
+	assert.Equal(t, server.URL, records[0].Source)
+	require.NotNil(t, records[0].Result)
+	assert.Equal(t, "Remote", records[0].Result.Title)
+	assert.Equal(t, missing, records[1].Source)
+	assert.Nil(t, records[1].Result)
+	assert.Contains(t, records[1].Error, "error reading file")
+}
+
+func TestExecuteBatchRateLimitsAcrossWorkers(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(batchPage(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.txt")
+	require.NoError(t, os.WriteFile(list, []byte(server.URL+"/a\n"+server.URL+"/b\n"+server.URL+"/c\n"), 0o600))
+
+	err := executeBatch(&BatchOptions{
+		Input:       list,
+		Concurrency: 3,
+		Output:      filepath.Join(dir, "results.ndjson"),
+		Timeout:     5 * time.Second,
+		RateLimit:   10,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, times, 3)
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	assert.GreaterOrEqual(t, times[2].Sub(times[0]), 190*time.Millisecond)
+}
+
+func TestExecuteBatchSkipsURLsDisallowedByRobots(t *testing.T) {
+	t.Parallel()
+
+	var robotsFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(batchPage(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.txt")
+	require.NoError(t, os.WriteFile(list, []byte(server.URL+"/public\n"+server.URL+"/private/page\n"+server.URL+"/other\n"), 0o600))
+	output := filepath.Join(dir, "results.ndjson")
+
+	err := executeBatch(&BatchOptions{
+		Input:         list,
+		Concurrency:   3,
+		Output:        output,
+		Timeout:       5 * time.Second,
+		RespectRobots: true,
+	})
+	require.NoError(t, err)
+
+	records := readRecords(t, output)
+	require.Len(t, records, 3)
+	require.NotNil(t, records[0].Result)
+	assert.True(t, records[1].Skipped)
+	assert.Nil(t, records[1].Result)
+	assert.Contains(t, records[1].Error, "disallowed by robots.txt")
+	require.NotNil(t, records[2].Result)
+	assert.Equal(t, int32(1), robotsFetches.Load())
+}
+
+func TestExecuteBatchWritesWARC(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(batchPage("Archived " + r.URL.Path)))
+	}))
+	defer server.Close()
+
+	for _, name := range []string{"crawl.warc", "crawl.warc.gz"} {
+		dir := t.TempDir()
+		list := filepath.Join(dir, "urls.txt")
+		require.NoError(t, os.WriteFile(list, []byte(server.URL+"/one\n"+server.URL+"/two\n"+server.URL+"/gone\n"), 0o600))
+		output := filepath.Join(dir, "results.ndjson")
+		warc := filepath.Join(dir, name)
+
+		err := executeBatch(&BatchOptions{Input: list, Concurrency: 2, Output: output, WARC: warc, Timeout: 5 * time.Second})
+		require.ErrorIs(t, err, ErrBatchFailed)
+
+		records := readRecords(t, output)
+		require.Len(t, records, 3)
+		assert.Equal(t, "Archived /two", records[1].Result.Title)
+
+		data, err := os.ReadFile(warc)
+		require.NoError(t, err)
+		if strings.HasSuffix(name, ".gz") {
+			assert.Equal(t, byte(0x1f), data[0])
+		} else {
+			text := string(data)
+			assert.Contains(t, text, "WARC-Type: warcinfo\r\n")
+			assert.Equal(t, 3, strings.Count(text, "WARC-Type: response\r\n"))
+			assert.Equal(t, 3, strings.Count(text, "WARC-Type: request\r\n"))
+			assert.Contains(t, text, "HTTP/1.1 404 Not Found\r\n")
+		}
+		for _, path := range []string{"/one", "/two"} {
+			page, err := webarchive.ReadWARC(strings.NewReader(string(data)), webarchive.Options{Target: server.URL + path})
+			require.NoError(t, err)
+			assert.Contains(t, page.HTML, "The body of Archived "+path)
+		}
+	}
+}
+
+func TestExecuteBatchKeepsExistingWARC(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.txt")
+	require.NoError(t, os.WriteFile(list, []byte("https://example.com/\n"), 0o600))
+	warc := filepath.Join(dir, "crawl.warc")
+	require.NoError(t, os.WriteFile(warc, []byte("keep"), 0o600))
+
+	err := executeBatch(&BatchOptions{Input: list, Concurrency: 1, WARC: warc})
+	require.ErrorIs(t, err, ErrOutputExists)
+	data, err := os.ReadFile(warc)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(data))
+}
+
+func TestExecuteBatchReadsStdinAndWritesJSONArrayAndSink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var sources []string
+	for _, title := range []string{"One", "Two"} {
+		path := filepath.Join(dir, title+".html")
+		require.NoError(t, os.WriteFile(path, []byte(batchPage(title)), 0o600))
+		sources = append(sources, path)
+	}
+	output := filepath.Join(dir, "results.json")
+	recorder := &recordingSink{}
+
+	err := executeBatch(&BatchOptions{
+		Input:       "-",
+		Stdin:       strings.NewReader(strings.Join(sources, "\n")),
+		Concurrency: 1,
+		JSON:        true,
+		Output:      output,
+		Sink:        recorder,
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	var records []BatchRecord
+	require.NoError(t, json.Unmarshal(data, &records))
+	require.Len(t, records, 2)
+	assert.Equal(t, "Two", records[1].Result.Title)
+
+	slices.Sort(recorder.sources)
+	assert.Equal(t, sources, recorder.sources)
+}
+
+func TestRunBatchUnorderedWritesRecordsAsTheyFinish(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	parse := func(source string) BatchRecord {
+		if source == "slow" {
+			<-release
+		}
+		return BatchRecord{Source: source}
+	}
+	reader, writer := io.Pipe()
+	lines := bufio.NewScanner(reader)
+	go func() {
+		_, err := runBatch(newHostScheduler([]string{"slow", "fast"}, 0, nil), 2, parse, writer, false, false)
+		_ = writer.CloseWithError(err)
+	}()
+
+	require.True(t, lines.Scan())
+	assert.JSONEq(t, `{"source":"fast"}`, lines.Text(), "the fast input is written while the slow one runs")
+	close(release)
+	require.True(t, lines.Scan())
+	assert.JSONEq(t, `{"source":"slow"}`, lines.Text())
+	assert.False(t, lines.Scan())
+}
+
+func TestExecuteBatchFormatSelectsOutput(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "page.html")
+	require.NoError(t, os.WriteFile(input, []byte(batchPage("Only")), 0o600))
+	list := filepath.Join(dir, "list.txt")
+	require.NoError(t, os.WriteFile(list, []byte(input+"\n"), 0o600))
+
+	ndjson := filepath.Join(dir, "results.ndjson")
+	require.NoError(t, executeBatch(&BatchOptions{Input: list, Concurrency: 1, Format: "ndjson", JSON: true, Output: ndjson}))
+	records := readRecords(t, ndjson)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Only", records[0].Result.Title)
+
+	array := filepath.Join(dir, "results.json")
+	require.NoError(t, executeBatch(&BatchOptions{Input: list, Concurrency: 1, Format: "json", Output: array}))
+	data, err := os.ReadFile(array)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "["))
+
+	assert.ErrorIs(t, executeBatch(&BatchOptions{Input: list, Concurrency: 1, Format: "csv"}), ErrUnsupportedBatchFormat)
+}
+
+func TestExecuteBatchRejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(empty, []byte("\n# nothing\n"), 0o600))
+
+	assert.ErrorIs(t, executeBatch(&BatchOptions{Input: empty, Concurrency: 0}), ErrInvalidConcurrency)
+	assert.ErrorIs(t, executeBatch(&BatchOptions{Input: empty, Concurrency: 1}), ErrNoBatchInputs)
+	assert.ErrorIs(t, executeBatch(&BatchOptions{Input: "../outside.txt", Concurrency: 1, BaseDir: t.TempDir()}), ErrDirectoryTraversal)
+}
+
+func TestPlanBatchSchedulesRequestsPerHost(t *testing.T) {
+	t.Parallel()
+
+	sources := []string{
+		"https://a.example/1", "https://a.example/2", "https://b.example/1",
+		"https://a.example/3", "page.html", "https://a.example/4",
+	}
+	plan := planBatch(sources, &BatchOptions{Concurrency: 2, RateLimit: 0.5, RespectRobots: true})
+
+	assert.Equal(t, 1, plan.Files)
+	assert.Equal(t, 5, plan.URLs)
+	assert.Equal(t, 5, plan.Pages)
+	assert.Equal(t, 2, plan.Robots)
+	assert.Equal(t, 9*time.Second, plan.Duration)
+	assert.Equal(t, []hostPlan{
+		{Host: "a.example", URLs: 4, Requests: 5, First: 0, Last: 9 * time.Second},
+		// b.example takes its turn at once instead of queueing behind a.example
+		{Host: "b.example", URLs: 1, Requests: 2, First: 0, Last: 3 * time.Second},
+	}, plan.Hosts)
+}
+
+func TestExecuteBatchPlanDoesNotFetch(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.txt")
+	require.NoError(t, os.WriteFile(list, []byte(server.URL+"/a\n"+server.URL+"/b\n"), 0o600))
+	output := filepath.Join(dir, "plan.txt")
+
+	err := executeBatch(&BatchOptions{Input: list, Concurrency: 1, Output: output, Retries: 2, Plan: true})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	host := strings.TrimPrefix(server.URL, "http://")
+	assert.Contains(t, string(data), "Inputs: 2 (2 URLs on 1 hosts, 0 files)\n")
+	assert.Contains(t, string(data), "Requests: 2 (2 pages, 0 robots.txt), up to 6 with 2 retries each\n")
+	assert.Contains(t, string(data), "Schedule: 1 workers, no rate limit\n")
+	assert.Contains(t, string(data), "Estimated duration: 2s, assuming 1s per request\n")
+	assert.Regexp(t, `(?m)^`+host+`\s+2\s+2\s+0s\s+2s$`, string(data))
+	assert.Zero(t, requests.Load())
+}
+
+func TestExecuteBatchResumesFromCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+	var mu sync.Mutex
+	fetches := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/b" && !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(batchPage(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.txt")
+	require.NoError(t, os.WriteFile(list, []byte(server.URL+"/a\n"+server.URL+"/b\n"+server.URL+"/c\n"), 0o600))
+	checkpointFile := filepath.Join(dir, "progress.ndjson")
+
+	err := executeBatch(&BatchOptions{Input: list, Concurrency: 2, Output: filepath.Join(dir, "first.ndjson"), Timeout: 5 * time.Second, Checkpoint: checkpointFile})
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Len(t, readRecords(t, checkpointFile), 3)
+
+	// A crash can leave the last line cut short
+	f, err := os.OpenFile(checkpointFile, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"source":"` + server.URL + `/c","res`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	healthy.Store(true)
+	output := filepath.Join(dir, "second.ndjson")
+	err = executeBatch(&BatchOptions{Input: list, Concurrency: 2, Output: output, Timeout: 5 * time.Second, Checkpoint: checkpointFile, Resume: true})
+	require.NoError(t, err)
+
+	records := readRecords(t, output)
+	require.Len(t, records, 3)
+	for i, path := range []string{"/a", "/b", "/c"} {
+		assert.Equal(t, server.URL+path, records[i].Source)
+		require.NotNil(t, records[i].Result, path)
+		assert.Equal(t, path, records[i].Result.Title)
+	}
+	assert.Equal(t, map[string]int{"/a": 1, "/b": 2, "/c": 1}, fetches)
+
+	done, err := loadCheckpoint(checkpointFile)
+	require.NoError(t, err)
+	assert.Len(t, done, 3)
+}
+
+func TestExecuteBatchCheckpointNeedsForceOrResume(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.html")
+	require.NoError(t, os.WriteFile(page, []byte(batchPage("Page")), 0o600))
+	list := filepath.Join(dir, "list.txt")
+	require.NoError(t, os.WriteFile(list, []byte(page+"\n"), 0o600))
+	checkpointFile := filepath.Join(dir, "progress.ndjson")
+	require.NoError(t, os.WriteFile(checkpointFile, nil, 0o600))
+
+	err := executeBatch(&BatchOptions{Input: list, Concurrency: 1, Output: filepath.Join(dir, "out.ndjson"), Checkpoint: checkpointFile})
+	require.ErrorIs(t, err, ErrOutputExists)
+
+	err = executeBatch(&BatchOptions{Input: list, Concurrency: 1, Output: filepath.Join(dir, "out.ndjson"), Resume: true})
+	require.ErrorIs(t, err, ErrResumeWithoutCheckpoint)
+}
+
+func TestFailureClass(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"not found", &defuddle.HTTPStatusError{StatusCode: http.StatusNotFound}, failure4xx},
+		{"unavailable", fmt.Errorf("error loading content: %w", &defuddle.HTTPStatusError{StatusCode: http.StatusServiceUnavailable}), failure5xx},
+		{"dns", &url.Error{Op: "Get", URL: "https://nowhere.invalid", Err: &net.DNSError{Err: "no such host", Name: "nowhere.invalid", IsNotFound: true}}, failureDNS},
+		{"tls", &url.Error{Op: "Get", URL: "https://example.com", Err: x509.UnknownAuthorityError{}}, failureTLS},
+		{"timeout", fmt.Errorf("error loading content: %w", context.DeadlineExceeded), failureTimeout},
+		{"refused", &url.Error{Op: "Get", URL: "http://127.0.0.1:1", Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrPermission}}, failureNetwork},
+		{"parse", defuddle.ErrResponseTooLarge, failureParse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, failureClass(tt.err))
+		})
+	}
+}
+
+func TestExecuteBatchFailureReportAndRetry(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+	var mu sync.Mutex
+	fetches := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches[r.URL.Path]++
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+			return
+		case r.URL.Path == "/flaky" && !healthy.Load():
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Article</title></head><body><article><h1>Article</h1><p>` +
+			strings.Repeat("This paragraph carries enough words to read as a real article. ", 5) + `</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	thin := filepath.Join(dir, "thin.html")
+	require.NoError(t, os.WriteFile(thin, []byte(batchPage("Thin")), 0o600))
+	list := filepath.Join(dir, "inputs.txt")
+	require.NoError(t, os.WriteFile(list, []byte(server.URL+"/ok\n"+server.URL+"/missing\n"+server.URL+"/flaky\n"+thin+"\n"), 0o600))
+	reportFile := filepath.Join(dir, "failures.json")
+
+	err := executeBatch(&BatchOptions{Input: list, Concurrency: 2, Output: filepath.Join(dir, "first.ndjson"), Timeout: 5 * time.Second, FailureReport: reportFile})
+	require.ErrorIs(t, err, ErrBatchFailed)
+
+	readReport := func() FailureReport {
+		data, err := os.ReadFile(reportFile)
+		require.NoError(t, err)
+		var report FailureReport
+		require.NoError(t, json.Unmarshal(data, &report))
+		return report
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	report := readReport()
+	assert.Equal(t, 4, report.Inputs)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, map[string]int{failure4xx: 1, failure5xx: 1, failureLowConfidence: 1}, report.Classes)
+	assert.Equal(t, map[string]map[string]int{
+		host: {failure4xx: 1, failure5xx: 1},
+		"":   {failureLowConfidence: 1},
+	}, report.Hosts)
+	require.Len(t, report.Failures, 3)
+	assert.Equal(t, Failure{Source: server.URL + "/missing", Host: host, Class: failure4xx, Error: report.Failures[0].Error}, report.Failures[0])
+	assert.Equal(t, server.URL+"/flaky", report.Failures[1].Source)
+	assert.Equal(t, thin, report.Failures[2].Source)
+
+	// Only the server errors are tried again
+	healthy.Store(true)
+	output := filepath.Join(dir, "retry.ndjson")
+	err = executeBatch(&BatchOptions{Input: list, Concurrency: 2, Output: output, Timeout: 5 * time.Second, FailureReport: reportFile, RetryClasses: []string{"5xx"}})
+	require.NoError(t, err)
+
+	records := readRecords(t, output)
+	require.Len(t, records, 1)
+	assert.Equal(t, server.URL+"/flaky", records[0].Source)
+	assert.Empty(t, records[0].Class)
+	assert.Equal(t, map[string]int{"/ok": 1, "/missing": 1, "/flaky": 2}, fetches)
+
+	report = readReport()
+	assert.Equal(t, 1, report.Inputs)
+	assert.Empty(t, report.Failures)
+}
+
+func TestExecuteBatchRetryNeedsFailureReport(t *testing.T) {
+	t.Parallel()
+
+	err := executeBatch(&BatchOptions{Input: "list.txt", Concurrency: 1, RetryClasses: []string{"5xx"}})
+	require.ErrorIs(t, err, ErrRetryWithoutReport)
+
+	err = executeBatch(&BatchOptions{Input: "list.txt", Concurrency: 1, FailureReport: "failures.json", RetryClasses: []string{"4xx,teapot"}})
+	require.ErrorIs(t, err, ErrUnknownFailureClass)
+}
+
+func TestHostSchedulerTakesHostsInTurn(t *testing.T) {
+	t.Parallel()
+
+	sources := []string{
+		"https://slow.example/1", "https://slow.example/2", "https://slow.example/3",
+		"https://fast.example/1", "page.html", "https://fast.example/2",
+	}
+	assert.Equal(t, []string{
+		"https://slow.example/1", "https://fast.example/1", "page.html",
+		"https://slow.example/2", "https://fast.example/2", "https://slow.example/3",
+	}, interleaveHosts(sources))
+}
+
+func TestHostSchedulerLimitsInputsPerHost(t *testing.T) {
+	t.Parallel()
+
+	var progress bytes.Buffer
+	sched := newHostScheduler([]string{"https://a.example/1", "https://a.example/2", "https://b.example/1"}, 1, &progress)
+
+	first, ok := sched.take()
+	require.True(t, ok)
+	second, ok := sched.take()
+	require.True(t, ok)
+	assert.Equal(t, []int{0, 2}, []int{first, second})
+
+	// a.example is at its limit until its first input finishes
+	taken := make(chan int)
+	go func() {
+		i, _ := sched.take()
+		taken <- i
+	}()
+	select {
+	case i := <-taken:
+		t.Fatalf("take() = %d while a.example was at its limit", i)
+	case <-time.After(20 * time.Millisecond):
+	}
+	sched.done(first)
+	assert.Equal(t, 1, <-taken)
+	sched.done(second)
+	sched.done(1)
+	_, ok = sched.take()
+	assert.False(t, ok)
+
+	var events []ProgressEvent
+	for line := range strings.Lines(progress.String()) {
+		var event ProgressEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 6)
+	assert.Equal(t, ProgressEvent{Event: "start", Source: "https://a.example/1", Host: "a.example", Queued: 2, Total: 3, InFlight: map[string]int{"a.example": 1}}, events[0])
+	assert.Equal(t, ProgressEvent{Event: "start", Source: "https://b.example/1", Host: "b.example", Queued: 1, Total: 3, InFlight: map[string]int{"a.example": 1, "b.example": 1}}, events[1])
+	assert.Equal(t, ProgressEvent{Event: "finish", Source: "https://a.example/1", Host: "a.example", Queued: 1, Finished: 1, Total: 3, InFlight: map[string]int{"b.example": 1}}, events[2])
+	assert.Equal(t, ProgressEvent{Event: "finish", Source: "https://a.example/2", Host: "a.example", Queued: 0, Finished: 3, Total: 3, InFlight: map[string]int{}}, events[5])
+}
+
+func TestExecuteBatchWritesProgressEvents(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var list strings.Builder
+	for _, name := range []string{"a", "b", "c"} {
+		page := filepath.Join(dir, name+".html")
+		require.NoError(t, os.WriteFile(page, []byte(batchPage(name)), 0o600))
+		list.WriteString(page + "\n")
+	}
+	input := filepath.Join(dir, "list.txt")
+	require.NoError(t, os.WriteFile(input, []byte(list.String()), 0o600))
+
+	var progress bytes.Buffer
+	require.NoError(t, executeBatch(&BatchOptions{Input: input, Concurrency: 2, PerHost: 1, Output: filepath.Join(dir, "out.ndjson"), Progress: &progress}))
+
+	starts, finishes := 0, 0
+	for line := range strings.Lines(progress.String()) {
+		var event ProgressEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		assert.LessOrEqual(t, event.InFlight[""], 1, "files share one host and its limit")
+		switch event.Event {
+		case "start":
+			starts++
+		case "finish":
+			finishes++
+		}
+	}
+	assert.Equal(t, 3, starts)
+	assert.Equal(t, 3, finishes)
+}
+
+func TestExecuteBatchLearnsBoilerplate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pages := filepath.Join(dir, "pages")
+	require.NoError(t, os.MkdirAll(pages, 0o750))
+	for _, title := range []string{"First", "Second", "Third"} {
+		page := strings.Replace(batchPage(title), "</article>", "<p>Subscribe to our newsletter for more stories like this one.</p></article>", 1)
+		require.NoError(t, os.WriteFile(filepath.Join(pages, strings.ToLower(title)+".html"), []byte(page), 0o600))
+	}
+
+	for _, learn := range []bool{false, true} {
+		output := filepath.Join(dir, fmt.Sprintf("learn-%v.ndjson", learn))
+		sink := &recordingSink{}
+		err := executeBatch(&BatchOptions{Input: pages, Concurrency: 2, Markdown: true, Output: output, Timeout: 5 * time.Second, Sink: sink, LearnBoilerplate: learn})
+		require.NoError(t, err)
+
+		records := readRecords(t, output)
+		require.Len(t, records, 3)
+		assert.Len(t, sink.sources, 3)
+		for _, record := range records {
+			require.NotNil(t, record.Result, record.Error)
+			require.NotNil(t, record.Result.ContentMarkdown)
+			assert.Equal(t, !learn, strings.Contains(record.Result.Content, "Subscribe"), record.Source)
+			assert.Equal(t, !learn, strings.Contains(*record.Result.ContentMarkdown, "Subscribe"), record.Source)
+			assert.Contains(t, record.Result.Content, "has enough words")
+		}
+	}
+}
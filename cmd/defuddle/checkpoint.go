@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/go-json-experiment/json"
+)
+
+// ErrResumeWithoutCheckpoint is returned when --resume is given without
+// --checkpoint.
+var ErrResumeWithoutCheckpoint = fmt.Errorf("--resume requires --checkpoint")
+
+// checkpoint appends the record of each finished batch input to a file as
+// one JSON line, so an interrupted batch can be resumed. It is safe for
+// concurrent use.
+type checkpoint struct {
+	mu  sync.Mutex
+	f   *os.File
+	err error
+}
+
+// openCheckpoint opens path for appending, or truncates it unless resume
+// is set. A last line cut short by a crash is ended first, so the next
+// record starts a line of its own.
+func openCheckpoint(path string, resume bool) (*checkpoint, error) {
+	flags := os.O_CREATE | os.O_RDWR | os.O_APPEND
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o600) // #nosec G304 - path comes from the user's own flag
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint: %w", err)
+	}
+	c := &checkpoint{f: f}
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := f.Write([]byte{'\n'}); err != nil {
+				_ = f.Close()
+				return nil, fmt.Errorf("error writing checkpoint: %w", err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// add writes record to the checkpoint. The first write error is kept for
+// close to report, and later records are not written.
+func (c *checkpoint) add(record BatchRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	// One write per line keeps a crash from interleaving records
+	if _, err := c.f.Write(append(data, '\n')); err != nil {
+		c.err = fmt.Errorf("error writing checkpoint: %w", err)
+	}
+}
+
+// close closes the file and returns the first error of the checkpoint.
+func (c *checkpoint) close() error {
+	closeErr := c.f.Close()
+	if c.err != nil {
+		return c.err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("error writing checkpoint: %w", closeErr)
+	}
+	return nil
+}
+
+// loadCheckpoint returns the finished records in the checkpoint at path
+// by source: the inputs that succeeded or were skipped by robots.txt.
+// Failed inputs are left out so they are tried again, and a later record
+// of a source replaces an earlier one. A missing file has no records, and
+// lines that do not parse, such as one cut short by a crash, are ignored.
+func loadCheckpoint(path string) (map[string]BatchRecord, error) {
+	done := map[string]BatchRecord{}
+	f, err := os.Open(path) // #nosec G304 - path comes from the user's own flag
+	if errors.Is(err, fs.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var record BatchRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Source == "" {
+			continue
+		}
+		if record.Error == "" || record.Skipped {
+			done[record.Source] = record
+		} else {
+			delete(done, record.Source)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+	return done, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/render"
+)
+
+// properties lists the --property names, as getProperty reads them.
+var properties = []string{
+	"content", "title", "description", "domain", "favicon", "image", "author", "site",
+	"published", "wordcount", "readingtime", "parsetime", "metatags", "schemaorgdata",
+	"extractortype", "contentmarkdown",
+}
+
+// completeValues makes shell completion offer values for the flag name of
+// cmd, without falling back to file names.
+func completeValues(cmd *cobra.Command, name string, values ...string) {
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)))
+}
+
+// completeFiles makes shell completion offer files with one of extensions,
+// or any file without extensions, for the flag name of cmd.
+func completeFiles(cmd *cobra.Command, name string, extensions ...string) {
+	cobra.CheckErr(cmd.MarkFlagFilename(name, extensions...))
+}
+
+// completeDirs makes shell completion offer directories for the flag name
+// of cmd.
+func completeDirs(cmd *cobra.Command, name string) {
+	cobra.CheckErr(cmd.MarkFlagDirname(name))
+}
+
+// completeParseFlags registers the completions of the parse flags.
+func completeParseFlags(cmd *cobra.Command) {
+	completeValues(cmd, "format", formatHTML, formatMarkdown, formatJSON, formatNDJSON, formatReader, formatHTMLPage, formatEPUB, formatLaTeX)
+	completeValues(cmd, "input-format", inputHTML, inputMHTML, inputWARC)
+	completeValues(cmd, "property", properties...)
+	completeValues(cmd, "link-style", string(defuddle.LinkStyleInline), string(defuddle.LinkStyleReference))
+	completeValues(cmd, "wrap", string(defuddle.WrapNone), string(defuddle.WrapSoft), string(defuddle.WrapHard))
+	completeValues(cmd, "html-passthrough", string(defuddle.HTMLPassthroughNone), string(defuddle.HTMLPassthroughSafe), string(defuddle.HTMLPassthroughAll))
+	// A theme is a built-in name or a CSS file
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("theme", cobra.FixedCompletions(slices.Sorted(maps.Keys(render.Themes)), cobra.ShellCompDirectiveDefault)))
+	completeFiles(cmd, "template-file")
+	completeFiles(cmd, "extractors", "json")
+	completeFiles(cmd, "extractor-config", "yaml", "yml", "json")
+	completeDirs(cmd, "output-dir")
+	completeDirs(cmd, "cache-dir")
+	completeDirs(cmd, "base-dir")
+}
+
+// completeBatchFlags registers the completions of the batch flags.
+func completeBatchFlags(cmd *cobra.Command) {
+	completeValues(cmd, "format", formatNDJSON, formatJSON)
+	completeValues(cmd, "retry-failcategory", failureClasses...)
+	completeFiles(cmd, "warc", "warc", "gz")
+	completeFiles(cmd, "extractors", "json")
+	completeFiles(cmd, "extractor-config", "yaml", "yml", "json")
+	completeFiles(cmd, "checkpoint")
+	completeFiles(cmd, "failure-report", "json")
+	completeDirs(cmd, "sink-dir")
+	completeDirs(cmd, "base-dir")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+
+	"github.com/kaptinlin/requests"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/internal/export/epub"
+)
+
+// errImageStatus is returned for images the server answered with an error
+// status; the image is left out of the book.
+var errImageStatus = errors.New("image request failed")
+
+// errImageSource is returned for image addresses that are neither web URLs
+// nor paths beside a local source file.
+var errImageSource = errors.New("unsupported image source")
+
+// writeEPUB packages result as an EPUB file at filename, with the images
+// its content shows.
+func writeEPUB(filename string, result *defuddle.Result, opts *ParseOptions, client *requests.Client) error {
+	book := &epub.Book{
+		Title:       result.Title,
+		Author:      result.Author,
+		Site:        result.Site,
+		Published:   result.Published,
+		Description: result.Description,
+		Cover:       result.Image,
+		Content:     result.Content,
+	}
+	if isHTTPURL(opts.Source) {
+		book.URL = opts.Source
+	}
+
+	fetch := imageFetcher(opts, client)
+	if err := writeFileAtomic(filename, opts.Force, func(w io.Writer) error {
+		return epub.Write(w, book, fetch)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Output written to %s\n", filename)
+	return nil
+}
+
+// imageFetcher returns the epub.Fetcher for opts: web images are requested
+// with client, or a client built from the request flags, and relative
+// paths are read from beside a local source file, within --base-dir.
+func imageFetcher(opts *ParseOptions, client *requests.Client) epub.Fetcher {
+	return func(src string) ([]byte, error) {
+		if isHTTPURL(src) {
+			if client == nil {
+				var err error
+				if client, err = newRequestsClient(opts); err != nil {
+					return nil, err
+				}
+			}
+			ctx, cancel := parseContext(opts.Timeout)
+			defer cancel()
+			resp, err := client.Get(src).Send(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = resp.Close() }()
+			if resp.IsError() {
+				return nil, fmt.Errorf("%w: %s", errImageStatus, resp.Status())
+			}
+			return resp.Body(), nil
+		}
+
+		u, err := url.Parse(src)
+		if err != nil || u.Scheme != "" || u.Host != "" || isHTTPURL(opts.Source) {
+			return nil, fmt.Errorf("%w: %s", errImageSource, src)
+		}
+		path, err := url.PathUnescape(u.Path)
+		if err != nil {
+			return nil, err
+		}
+		content, err := readFile(filepath.Join(filepath.Dir(sourcePath(opts.Source)), filepath.FromSlash(path)), opts.BaseDir)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(content), nil
+	}
+}
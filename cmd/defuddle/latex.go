@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kaptinlin/requests"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/export"
+)
+
+// errImageFormat is returned for images graphicx cannot include; the
+// image's alt text is written instead.
+var errImageFormat = errors.New("unsupported image format for LaTeX")
+
+// latexImageTypes maps the image types pdflatex includes to their file
+// extensions.
+var latexImageTypes = map[string]string{
+	"image/png":       "png",
+	"image/jpeg":      "jpg",
+	"application/pdf": "pdf",
+}
+
+// writeLaTeX writes result as a LaTeX document to filename, or to stdout
+// when it is "". Written to a file, the images its content shows are saved
+// in a directory beside it named after the file, as in "article-images",
+// and like the document, existing images are only replaced with --force.
+func writeLaTeX(filename string, result *defuddle.Result, opts *ParseOptions, client *requests.Client) error {
+	options := &export.LaTeXOptions{}
+	if isHTTPURL(opts.Source) {
+		options.URL = opts.Source
+	}
+	if filename == "" {
+		return export.LaTeX(result, options, os.Stdout)
+	}
+
+	dir := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)) + "-images"
+	fetch := imageFetcher(opts, client)
+	count := 0
+	// An existing image file fails the export like an existing document
+	var existing error
+	options.SaveImage = func(src string) (string, error) {
+		data, err := fetch(src)
+		if err != nil {
+			return "", err
+		}
+		ext, ok := latexImageTypes[http.DetectContentType(data)]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", errImageFormat, src)
+		}
+		count++
+		name := dir + "/image-" + strconv.Itoa(count) + "." + ext
+		path := filepath.Join(filepath.Dir(filename), filepath.FromSlash(name))
+		if err := writeFileAtomic(path, opts.Force, func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}); err != nil {
+			if errors.Is(err, ErrOutputExists) && existing == nil {
+				existing = err
+			}
+			return "", err
+		}
+		return name, nil
+	}
+
+	if err := writeFileAtomic(filename, opts.Force, func(w io.Writer) error {
+		if err := export.LaTeX(result, options, w); err != nil {
+			return err
+		}
+		return existing
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Output written to %s\n", filename)
+	return nil
+}
@@ -3,9 +3,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -19,26 +26,74 @@ import (
 
 	"github.com/kaptinlin/defuddle-go"
 	"github.com/kaptinlin/defuddle-go/extractors"
-)
-
-const (
-	version          = "0.1.3"
-	defaultUserAgent = "Mozilla/5.0 (compatible; Defuddle/1.0; +https://github.com/kaptinlin/defuddle-go)"
+	"github.com/kaptinlin/defuddle-go/sink"
 )
 
 // ErrInvalidHeaderFormat is returned when a header flag is not in Key: Value form.
 var ErrInvalidHeaderFormat = fmt.Errorf("invalid header format (expected 'Key: Value')")
 
-// ErrDirectoryTraversal is returned when a file path contains a parent-directory segment.
-var ErrDirectoryTraversal = fmt.Errorf("invalid file path: directory traversal detected")
+// ErrDirectoryTraversal is returned when a file path resolves outside --base-dir.
+var ErrDirectoryTraversal = fmt.Errorf("invalid file path: outside base directory")
+
+// ErrOutputExists is returned when the output file exists and --force is not set.
+var ErrOutputExists = fmt.Errorf("output file already exists (use --force to overwrite)")
+
+// ErrOutputConflict is returned when both --output and --output-dir are set.
+var ErrOutputConflict = fmt.Errorf("--output and --output-dir cannot be used together")
 
 // ErrPropertyNotFound is returned when a requested output property is missing.
 var ErrPropertyNotFound = fmt.Errorf("property not found in response")
 
+// ErrUnsupportedFormat is returned when --format names an unknown output format.
+var ErrUnsupportedFormat = fmt.Errorf("unsupported output format (expected html, markdown, json, ndjson, reader, html-page, epub, or latex)")
+
+// ErrUnsupportedInputFormat is returned when --input-format names an unknown input format.
+var ErrUnsupportedInputFormat = fmt.Errorf("unsupported input format (expected html, mhtml, or warc)")
+
+// ErrArchiveFromURL is returned when --input-format mhtml or warc is given a URL.
+var ErrArchiveFromURL = fmt.Errorf("--input-format mhtml and warc read local files only")
+
+// ErrEPUBWithoutOutput is returned when --format epub has no file to write to.
+var ErrEPUBWithoutOutput = fmt.Errorf("--format epub requires --output or --output-dir")
+
+// ErrThemeWithoutPage is returned when --theme is set for output that is not a rendered page.
+var ErrThemeWithoutPage = fmt.Errorf("--theme requires --format reader or html-page, or --template-file")
+
+// ErrUnsupportedLinkStyle is returned when --link-style names an unknown link style.
+var ErrUnsupportedLinkStyle = fmt.Errorf("unsupported link style (expected inline or reference)")
+
+// ErrUnsupportedWrap is returned when --wrap names an unknown wrap mode.
+var ErrUnsupportedWrap = fmt.Errorf("unsupported wrap mode (expected none, soft, or hard)")
+
+// ErrUnsupportedHTMLPassthrough is returned when --html-passthrough names an unknown policy.
+var ErrUnsupportedHTMLPassthrough = fmt.Errorf("unsupported HTML passthrough (expected none, safe, or all)")
+
+// Output formats accepted by --format.
+const (
+	formatHTML     = "html"
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+	formatNDJSON   = "ndjson"
+	formatReader   = "reader"
+	formatHTMLPage = "html-page"
+	formatEPUB     = "epub"
+	formatLaTeX    = "latex"
+)
+
+// Input formats accepted by --input-format.
+const (
+	inputHTML  = "html"
+	inputMHTML = "mhtml"
+	inputWARC  = "warc"
+)
+
+// defaultOutputName is the --output-name used when --output-dir is set.
+const defaultOutputName = "{domain}-{slug}.{ext}"
+
 var rootCmd = &cobra.Command{
 	Use:     "defuddle",
 	Short:   "Extract and structure content from web pages",
-	Version: version,
+	Version: defuddle.Version(),
 	Long: `defuddle is a CLI tool for extracting and structuring content from web pages.
 It can parse HTML, extract metadata, and convert content to various formats.`,
 }
@@ -54,31 +109,113 @@ You can output the content in different formats and extract specific properties.
 
 // ParseOptions configures the parse command.
 type ParseOptions struct {
-	Source    string
-	JSON      bool
-	Markdown  bool
-	Property  string
-	Output    string
-	UserAgent string
-	Headers   []string
-	Timeout   time.Duration
-	Debug     bool
-	Proxy     string
+	Source     string
+	JSON       bool
+	Markdown   bool
+	Property   string
+	Output     string
+	UserAgent  string
+	Headers    []string
+	Timeout    time.Duration
+	Retries    int
+	RetryDelay time.Duration
+	RateLimit  float64
+	// CacheDir keeps fetched pages for CacheTTL, then revalidates them.
+	CacheDir string
+	CacheTTL time.Duration
+	// RespectRobots skips URLs that the site's robots.txt disallows.
+	RespectRobots  bool
+	Debug          bool
+	Proxy          string
+	DNS            string
+	UnixSocket     string
+	WholePage      bool
+	Sanitize       bool
+	RecoverBody    bool
+	NormalizeTimes bool
+	Format         string
+	TemplateFile   string
+	Theme          string
+	LinkStyle      string
+	Wrap           string
+	WrapWidth      int
+	Passthrough    string
+	Extractors     string
+	// ExtractorConfig is a YAML or JSON file of declarative extractor rules.
+	ExtractorConfig string
+	// DropCredits leaves photo credits out of Markdown figure captions.
+	DropCredits bool
+	BaseDir     string
+	Force       bool
+	OutputDir   string
+	OutputName  string
+	// InputFormat is "html", or "mhtml" or "warc" for a saved archive.
+	InputFormat        string
+	EmbedArchiveImages bool
+	// MaxRedirects is the number of meta refresh and script redirects
+	// followed for a URL source.
+	MaxRedirects int
+	// ConsentCookies fetches a consent wall again with consent cookies.
+	ConsentCookies bool
+	// TOC reports the content headings in the JSON result.
+	TOC bool
+	// HeadingIDs gives content headings ids, in HTML and Markdown.
+	HeadingIDs bool
+	// PreferAMP parses the AMP version a URL source links instead.
+	PreferAMP bool
+	// NumberFigures numbers captioned figures and tables in Markdown.
+	NumberFigures bool
+	// ImageWidth is the display width srcset candidates are chosen for.
+	ImageWidth int
 }
 
 func init() {
-	extractors.InitializeBuiltins()
-
 	parseCmd.Flags().BoolP("json", "j", false, "Output as JSON with metadata and content")
 	parseCmd.Flags().BoolP("markdown", "m", false, "Convert content to markdown format")
 	parseCmd.Flags().Bool("md", false, "Alias for --markdown")
 	parseCmd.Flags().StringP("property", "p", "", "Extract a specific property (e.g., title, description, domain)")
 	parseCmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	parseCmd.Flags().BoolP("force", "f", false, "Overwrite existing output files")
+	parseCmd.Flags().String("output-dir", "", "Write output into this directory, named by --output-name")
+	parseCmd.Flags().String("output-name", defaultOutputName, "File name template for --output-dir: {domain}, {slug}, {date}, {hash}, and {ext}")
 	parseCmd.Flags().String("user-agent", "", "Custom user agent string")
 	parseCmd.Flags().StringArrayP("header", "H", []string{}, "Custom headers in format 'Key: Value'")
 	parseCmd.Flags().Duration("timeout", 30*time.Second, "Request timeout")
+	parseCmd.Flags().Int("retries", 0, "Retry a URL fetch that failed with a network error, timeout, 429, or 5xx this many times")
+	parseCmd.Flags().Duration("retry-delay", time.Second, "Delay before the first retry, doubled for each retry after it")
+	parseCmd.Flags().Float64("rate-limit", 0, "Most requests per second to each host (0 is unlimited)")
+	parseCmd.Flags().Bool("respect-robots", false, "Check the site's robots.txt and refuse URLs it disallows")
+	parseCmd.Flags().String("cache-dir", "", "Keep fetched pages in this directory and reuse them on later runs")
+	parseCmd.Flags().Duration("cache-ttl", time.Hour, "How long a page in --cache-dir is reused before it is revalidated with the site")
 	parseCmd.Flags().Bool("debug", false, "Enable debug mode")
 	parseCmd.Flags().String("proxy", "", "Proxy URL (e.g., http://localhost:8080, socks5://localhost:1080)")
+	parseCmd.Flags().String("unix-socket", "", "Send every request over this Unix domain socket, such as a sidecar fetcher's")
+	parseCmd.Flags().String("dns", "", "Look up hosts with this DNS server (host[:port]) or DNS-over-HTTPS URL (e.g., https://1.1.1.1/dns-query)")
+	parseCmd.Flags().Int("max-redirects", 0, "Follow up to this many meta refresh and JavaScript redirects of a URL source")
+	parseCmd.Flags().Bool("consent-cookies", false, "Fetch a consent wall again with the cookies of an accepting visitor")
+	parseCmd.Flags().Bool("prefer-amp", false, "Parse the AMP version a URL source links with rel=\"amphtml\" instead of the page")
+	parseCmd.Flags().Bool("whole-page", false, "Clean up the whole page body without selecting main content")
+	parseCmd.Flags().Bool("sanitize", false, "Sanitize the content HTML with a strict allowlist for embedding")
+	parseCmd.Flags().Bool("recover-article-body", false, "Replace a paywall teaser with the full text of the page's schema.org articleBody")
+	parseCmd.Flags().Bool("normalize-times", false, "Show the date of time elements whose text is relative, such as \"3 days ago\"")
+	parseCmd.Flags().Bool("toc", false, "Include a nested table of contents of the content headings in the JSON output")
+	parseCmd.Flags().Bool("heading-ids", false, "Give content headings slug ids, written as {#id} in Markdown")
+	parseCmd.Flags().String("input-format", "", "Input format: html (default), mhtml (saved page), or warc (web archive, plain or gzip)")
+	parseCmd.Flags().Bool("embed-archive-images", false, "Embed images saved in an MHTML or WARC input as data: URLs")
+	parseCmd.Flags().String("format", "", "Output format: html, markdown, json, ndjson (one compact line), reader (standalone reading view), epub (e-book file), or latex (LaTeX document)")
+	parseCmd.Flags().String("template-file", "", "Render the result with a Go html/template file")
+	parseCmd.Flags().String("theme", "", "Style sheet for --format reader or html-page: light, dark, sepia, or a CSS file to embed")
+	parseCmd.Flags().String("link-style", "", "Markdown link style: inline or reference (numbered definitions at the end)")
+	parseCmd.Flags().String("wrap", "", "Markdown line wrapping: none, soft (one sentence per line), or hard (at --wrap-width)")
+	parseCmd.Flags().Int("wrap-width", 0, "Column limit for --wrap hard (default 80)")
+	parseCmd.Flags().String("extractors", "", "JSON manifest of external extractors to use alongside the built-ins")
+	parseCmd.Flags().String("extractor-config", "", "YAML or JSON file of selector-based extractor rules to use alongside the built-ins")
+	parseCmd.Flags().String("base-dir", "", "Only read input, template, and manifest files inside this directory")
+	parseCmd.Flags().String("html-passthrough", "", "Markdown handling of kbd, mark, sub, sup, and details: none, safe (bare HTML tags), or all (original HTML)")
+	parseCmd.Flags().Bool("drop-image-credits", false, "Leave photo credits out of Markdown figure captions")
+	parseCmd.Flags().Bool("number-figures", false, "Number captioned figures and tables in Markdown, as in \"Figure 1: caption\"")
+	parseCmd.Flags().Int("image-width", 0, "Display width in pixels to pick srcset image candidates for (default 1200)")
+	completeParseFlags(parseCmd)
 
 	rootCmd.AddCommand(parseCmd)
 }
@@ -101,24 +238,95 @@ func parseContent(cmd *cobra.Command, args []string) error {
 	userAgent, _ := cmd.Flags().GetString("user-agent")
 	headers, _ := cmd.Flags().GetStringArray("header")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryDelay, _ := cmd.Flags().GetDuration("retry-delay")
+	rateLimit, _ := cmd.Flags().GetFloat64("rate-limit")
+	respectRobots, _ := cmd.Flags().GetBool("respect-robots")
+	cacheDir, _ := cmd.Flags().GetString("cache-dir")
+	cacheTTL, _ := cmd.Flags().GetDuration("cache-ttl")
 	debug, _ := cmd.Flags().GetBool("debug")
 	proxy, _ := cmd.Flags().GetString("proxy")
+	dns, _ := cmd.Flags().GetString("dns")
+	unixSocket, _ := cmd.Flags().GetString("unix-socket")
+	maxRedirects, _ := cmd.Flags().GetInt("max-redirects")
+	consentCookies, _ := cmd.Flags().GetBool("consent-cookies")
+	preferAMP, _ := cmd.Flags().GetBool("prefer-amp")
+	wholePage, _ := cmd.Flags().GetBool("whole-page")
+	sanitize, _ := cmd.Flags().GetBool("sanitize")
+	recoverBody, _ := cmd.Flags().GetBool("recover-article-body")
+	normalizeTimes, _ := cmd.Flags().GetBool("normalize-times")
+	toc, _ := cmd.Flags().GetBool("toc")
+	headingIDs, _ := cmd.Flags().GetBool("heading-ids")
+	inputFormat, _ := cmd.Flags().GetString("input-format")
+	embedArchiveImages, _ := cmd.Flags().GetBool("embed-archive-images")
+	format, _ := cmd.Flags().GetString("format")
+	templateFile, _ := cmd.Flags().GetString("template-file")
+	theme, _ := cmd.Flags().GetString("theme")
+	linkStyle, _ := cmd.Flags().GetString("link-style")
+	wrap, _ := cmd.Flags().GetString("wrap")
+	wrapWidth, _ := cmd.Flags().GetInt("wrap-width")
+	passthrough, _ := cmd.Flags().GetString("html-passthrough")
+	dropCredits, _ := cmd.Flags().GetBool("drop-image-credits")
+	numberFigures, _ := cmd.Flags().GetBool("number-figures")
+	imageWidth, _ := cmd.Flags().GetInt("image-width")
+	extractorManifest, _ := cmd.Flags().GetString("extractors")
+	extractorConfig, _ := cmd.Flags().GetString("extractor-config")
+	baseDir, _ := cmd.Flags().GetString("base-dir")
+	force, _ := cmd.Flags().GetBool("force")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	outputName, _ := cmd.Flags().GetString("output-name")
 
 	if mdAlias {
 		markdown = true
 	}
 
 	opts := &ParseOptions{
-		Source:    source,
-		JSON:      jsonOutput,
-		Markdown:  markdown,
-		Property:  property,
-		Output:    output,
-		UserAgent: userAgent,
-		Headers:   headers,
-		Timeout:   timeout,
-		Debug:     debug,
-		Proxy:     proxy,
+		Source:          source,
+		JSON:            jsonOutput,
+		Markdown:        markdown,
+		Property:        property,
+		Output:          output,
+		UserAgent:       userAgent,
+		Headers:         headers,
+		Timeout:         timeout,
+		Retries:         retries,
+		RetryDelay:      retryDelay,
+		RateLimit:       rateLimit,
+		RespectRobots:   respectRobots,
+		CacheDir:        cacheDir,
+		CacheTTL:        cacheTTL,
+		Debug:           debug,
+		Proxy:           proxy,
+		DNS:             dns,
+		UnixSocket:      unixSocket,
+		WholePage:       wholePage,
+		Sanitize:        sanitize,
+		RecoverBody:     recoverBody,
+		NormalizeTimes:  normalizeTimes,
+		TOC:             toc,
+		HeadingIDs:      headingIDs,
+		Format:          format,
+		TemplateFile:    templateFile,
+		Theme:           theme,
+		LinkStyle:       linkStyle,
+		Wrap:            wrap,
+		WrapWidth:       wrapWidth,
+		Passthrough:     passthrough,
+		DropCredits:     dropCredits,
+		Extractors:      extractorManifest,
+		ExtractorConfig: extractorConfig,
+		BaseDir:         baseDir,
+		Force:           force,
+		OutputDir:       outputDir,
+		OutputName:      outputName,
+
+		InputFormat:        inputFormat,
+		EmbedArchiveImages: embedArchiveImages,
+		MaxRedirects:       maxRedirects,
+		ConsentCookies:     consentCookies,
+		PreferAMP:          preferAMP,
+		NumberFigures:      numberFigures,
+		ImageWidth:         imageWidth,
 	}
 
 	if debug {
@@ -132,45 +340,85 @@ func executeParseContent(opts *ParseOptions) error {
 	if err := validateHeaders(opts.Headers); err != nil {
 		return err
 	}
-
-	defuddleOpts := &defuddle.Options{
-		Debug:            opts.Debug,
-		URL:              opts.Source,
-		Markdown:         opts.Markdown,
-		SeparateMarkdown: opts.Markdown,
+	if err := applyFormat(opts); err != nil {
+		return err
+	}
+	if err := applyInputFormat(opts); err != nil {
+		return err
+	}
+	if opts.Format == formatEPUB && opts.Output == "" && opts.OutputDir == "" {
+		return ErrEPUBWithoutOutput
+	}
+	if err := checkOutput(opts); err != nil {
+		return err
+	}
+	markdownOpts, err := markdownOptions(opts)
+	if err != nil {
+		return err
+	}
+	registry, err := extractorRegistry(opts.Extractors, opts.ExtractorConfig, opts.BaseDir)
+	if err != nil {
+		return err
 	}
 
-	var result *defuddle.Result
-	var err error
-
-	if isHTTPURL(opts.Source) {
-		client, clientErr := newRequestsClient(opts)
-		if clientErr != nil {
-			return clientErr
+	var tmpl *template.Template
+	if opts.TemplateFile != "" {
+		loaded, err := loadTemplate(opts.TemplateFile, opts.BaseDir)
+		if err != nil {
+			return err
 		}
-		defuddleOpts.Client = client
-
-		ctx, cancel := parseContext(opts.Timeout)
-		defer cancel()
-		result, err = defuddle.ParseFromURL(ctx, opts.Source, defuddleOpts)
-	} else {
-		htmlContent, fileErr := readFile(opts.Source)
-		if fileErr != nil {
-			return fmt.Errorf("error reading file: %w", fileErr)
+		tmpl = loaded
+	}
+	var css string
+	if opts.Theme != "" {
+		if tmpl == nil && opts.Format != formatReader {
+			return ErrThemeWithoutPage
 		}
-
-		defuddleInstance, createErr := defuddle.NewDefuddle(htmlContent, defuddleOpts)
-		if createErr != nil {
-			return fmt.Errorf("error creating defuddle instance: %w", createErr)
+		loaded, err := loadTheme(opts.Theme, opts.BaseDir)
+		if err != nil {
+			return err
 		}
+		css = loaded
+	}
 
-		ctx, cancel := parseContext(opts.Timeout)
-		defer cancel()
-		result, err = defuddleInstance.Parse(ctx)
+	defuddleOpts := &defuddle.Options{
+		Debug:                     opts.Debug,
+		URL:                       opts.Source,
+		Markdown:                  opts.Markdown,
+		SeparateMarkdown:          opts.Markdown,
+		MarkdownOptions:           markdownOpts,
+		SkipContentSelection:      opts.WholePage,
+		Sanitize:                  opts.Sanitize,
+		Extractors:                registry,
+		MaxClientRedirects:        opts.MaxRedirects,
+		RecoverFromStructuredData: opts.RecoverBody,
+		NormalizeTimes:            opts.NormalizeTimes,
+		GenerateTOC:               opts.TOC,
+		HeadingIDs:                opts.HeadingIDs,
+		ConsentCookies:            opts.ConsentCookies,
+		PreferAMP:                 opts.PreferAMP,
+		ImageTargetWidth:          opts.ImageWidth,
+	}
+
+	// Files are read by ParseFromURL too, which fetches the pages that
+	// redirects in them lead to
+	client, err := newRequestsClient(opts)
+	if err != nil {
+		return err
 	}
+	defuddleOpts.Client = client
 
+	var result *defuddle.Result
+	if opts.InputFormat == inputHTML {
+		result, err = loadResult(opts.Source, defuddleOpts, opts.Timeout, opts.BaseDir)
+	} else {
+		// The archive records the address the page was saved from
+		defuddleOpts.URL = ""
+		defuddleOpts.EmbedArchiveImages = opts.EmbedArchiveImages
+		result, err = loadArchiveResult(opts.Source, defuddle.ArchiveFormat(opts.InputFormat), defuddleOpts, opts.Timeout, opts.BaseDir)
+	}
 	if err != nil {
-		return fmt.Errorf("error loading content: %w", err)
+		return err
 	}
 
 	if opts.Debug {
@@ -182,24 +430,191 @@ func executeParseContent(opts *ParseOptions) error {
 		if value == "" {
 			return fmt.Errorf("%w: \"%s\"", ErrPropertyNotFound, opts.Property)
 		}
-		return writeOutput(opts.Output, value)
+		return writeOutput(outputPath(opts, result, "txt"), value, opts.Force)
+	}
+
+	if opts.Format == formatEPUB {
+		return writeEPUB(outputPath(opts, result, "epub"), result, opts, defuddleOpts.Client)
+	}
+	if opts.Format == formatLaTeX {
+		return writeLaTeX(outputPath(opts, result, "tex"), result, opts, defuddleOpts.Client)
 	}
 
 	var content string
+	ext := "html"
 	switch {
+	case tmpl != nil, opts.Format == formatReader:
+		content, err = renderHTML(result, tmpl, css)
+		if err != nil {
+			return err
+		}
+	case opts.Format == formatNDJSON:
+		jsonData, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON: %w", err)
+		}
+		content = string(jsonData) + "\n"
+		ext = "ndjson"
 	case opts.JSON:
 		jsonData, err := json.Marshal(result, jsontext.Multiline(true))
 		if err != nil {
 			return fmt.Errorf("error marshaling JSON: %w", err)
 		}
 		content = string(jsonData)
+		ext = "json"
 	case opts.Markdown:
 		content = markdownContent(result, opts)
+		ext = "md"
 	default:
 		content = result.Content
 	}
 
-	return writeOutput(opts.Output, content)
+	return writeOutput(outputPath(opts, result, ext), content, opts.Force)
+}
+
+// checkOutput validates the output flags before any parsing work, failing
+// early when --output names an existing file and --force is not set.
+func checkOutput(opts *ParseOptions) error {
+	if opts.Output != "" && opts.OutputDir != "" {
+		return ErrOutputConflict
+	}
+	if opts.Output != "" && !opts.Force {
+		if _, err := os.Lstat(opts.Output); err == nil {
+			return fmt.Errorf("%w: %s", ErrOutputExists, opts.Output)
+		}
+	}
+	return nil
+}
+
+// outputPath returns the file to write to: --output as given, or
+// --output-name expanded for result under --output-dir. It is "" for stdout.
+func outputPath(opts *ParseOptions, result *defuddle.Result, ext string) string {
+	if opts.OutputDir == "" {
+		return opts.Output
+	}
+	name := opts.OutputName
+	if name == "" {
+		name = defaultOutputName
+	}
+	name = strings.ReplaceAll(name, "{ext}", ext)
+	key := sink.Key(name, opts.Source, result, time.Now())
+	return filepath.Join(opts.OutputDir, filepath.FromSlash(key))
+}
+
+// applyFormat maps --format onto the output flags. An empty format keeps the
+// --json and --markdown flags as given.
+func applyFormat(opts *ParseOptions) error {
+	switch strings.ToLower(opts.Format) {
+	case "":
+		return nil
+	case formatHTML:
+		opts.Format = formatHTML
+		opts.JSON, opts.Markdown = false, false
+	case formatMarkdown, "md":
+		opts.Format = formatMarkdown
+		opts.JSON, opts.Markdown = false, true
+	case formatJSON:
+		opts.Format = formatJSON
+		opts.JSON, opts.Markdown = true, false
+	case formatNDJSON:
+		opts.Format = formatNDJSON
+		opts.JSON, opts.Markdown = true, false
+	case formatReader, formatHTMLPage:
+		opts.Format = formatReader
+		opts.JSON, opts.Markdown = false, false
+	case formatEPUB:
+		opts.Format = formatEPUB
+		opts.JSON, opts.Markdown = false, false
+	case formatLaTeX, "tex":
+		opts.Format = formatLaTeX
+		opts.JSON, opts.Markdown = false, false
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, opts.Format)
+	}
+	return nil
+}
+
+// applyInputFormat normalizes --input-format, rejecting archives given as
+// URLs.
+func applyInputFormat(opts *ParseOptions) error {
+	switch strings.ToLower(opts.InputFormat) {
+	case "", inputHTML:
+		opts.InputFormat = inputHTML
+	case inputMHTML, inputWARC:
+		opts.InputFormat = strings.ToLower(opts.InputFormat)
+		if isHTTPURL(opts.Source) {
+			return ErrArchiveFromURL
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedInputFormat, opts.InputFormat)
+	}
+	return nil
+}
+
+// markdownOptions builds Markdown conversion options from the flags.
+func markdownOptions(opts *ParseOptions) (*defuddle.MarkdownOptions, error) {
+	markdownOpts := defuddle.DefaultMarkdownOptions()
+	switch linkStyle := defuddle.LinkStyle(strings.ToLower(opts.LinkStyle)); linkStyle {
+	case "":
+	case defuddle.LinkStyleInline, defuddle.LinkStyleReference:
+		markdownOpts.LinkStyle = linkStyle
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedLinkStyle, opts.LinkStyle)
+	}
+	switch wrap := defuddle.Wrap(strings.ToLower(opts.Wrap)); wrap {
+	case "":
+	case defuddle.WrapNone, defuddle.WrapSoft, defuddle.WrapHard:
+		markdownOpts.Wrap = wrap
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedWrap, opts.Wrap)
+	}
+	if opts.WrapWidth > 0 {
+		markdownOpts.WrapWidth = opts.WrapWidth
+	}
+	switch passthrough := defuddle.HTMLPassthrough(strings.ToLower(opts.Passthrough)); passthrough {
+	case "":
+	case defuddle.HTMLPassthroughNone, defuddle.HTMLPassthroughSafe, defuddle.HTMLPassthroughAll:
+		markdownOpts.HTMLPassthrough = passthrough
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedHTMLPassthrough, opts.Passthrough)
+	}
+	markdownOpts.DropImageCredits = opts.DropCredits
+	markdownOpts.HeadingIDs = opts.HeadingIDs
+	markdownOpts.NumberFigures = opts.NumberFigures
+	return markdownOpts, nil
+}
+
+// extractorRegistry returns the built-in extractors plus those listed in the
+// external manifest and the rule config, or nil for the default registry
+// when both paths are empty.
+func extractorRegistry(manifest, config, baseDir string) (*extractors.Registry, error) {
+	if manifest == "" && config == "" {
+		return nil, nil
+	}
+	registry := extractors.NewBuiltinRegistry()
+	for _, source := range []struct {
+		path string
+		load func(string) ([]extractors.ExtractorMapping, error)
+	}{
+		{manifest, extractors.LoadExternalManifest},
+		{config, extractors.LoadFromFile},
+	} {
+		if source.path == "" {
+			continue
+		}
+		path, err := validateFilePath(source.path, baseDir)
+		if err != nil {
+			return nil, err
+		}
+		mappings, err := source.load(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, mapping := range mappings {
+			registry.Register(mapping)
+		}
+	}
+	return registry, nil
 }
 
 func markdownContent(result *defuddle.Result, opts *ParseOptions) string {
@@ -207,11 +622,13 @@ func markdownContent(result *defuddle.Result, opts *ParseOptions) string {
 		return *result.ContentMarkdown
 	}
 
+	conversion, _ := markdownOptions(opts)
 	markdownOpts := &defuddle.Options{
 		Debug:            false,
 		URL:              opts.Source,
 		Markdown:         true,
 		SeparateMarkdown: true,
+		MarkdownOptions:  conversion,
 	}
 
 	htmlContent := fmt.Sprintf("<html><body>%s</body></html>", result.Content)
@@ -231,6 +648,56 @@ func markdownContent(result *defuddle.Result, opts *ParseOptions) string {
 	return *markdownResult.ContentMarkdown
 }
 
+// loadResult parses source with ParseFromURL, using options.Client. A
+// source that is not an HTTP URL is a file path or file:// URL inside
+// baseDir, read as the file:// URL of its absolute path.
+func loadResult(source string, options *defuddle.Options, timeout time.Duration, baseDir string) (*defuddle.Result, error) {
+	if !isHTTPURL(source) {
+		path, err := validateFilePath(sourcePath(source), baseDir)
+		if err == nil {
+			path, err = filepath.Abs(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		// The path is already checked against baseDir
+		options.FileRoot = filepath.Dir(path)
+		source = fileURL(path)
+	}
+
+	ctx, cancel := parseContext(timeout)
+	defer cancel()
+	result, err := defuddle.ParseFromURL(ctx, source, options)
+	if pathErr := (*fs.PathError)(nil); errors.As(err, &pathErr) {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading content: %w", err)
+	}
+	return result, nil
+}
+
+// loadArchiveResult parses the page saved in the MHTML or WARC file source.
+func loadArchiveResult(source string, format defuddle.ArchiveFormat, options *defuddle.Options, timeout time.Duration, baseDir string) (*defuddle.Result, error) {
+	filename, err := validateFilePath(sourcePath(source), baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	f, err := os.Open(filename) // #nosec G304 - path validated above
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	ctx, cancel := parseContext(timeout)
+	defer cancel()
+	result, err := defuddle.ParseFromArchive(ctx, f, format, options)
+	if err != nil {
+		return nil, fmt.Errorf("error loading content: %w", err)
+	}
+	return result, nil
+}
+
 func parseContext(timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout > 0 {
 		return context.WithTimeout(context.Background(), timeout)
@@ -239,32 +706,36 @@ func parseContext(timeout time.Duration) (context.Context, context.CancelFunc) {
 }
 
 func newRequestsClient(opts *ParseOptions) (*requests.Client, error) {
-	userAgent := opts.UserAgent
-	if userAgent == "" {
-		userAgent = defaultUserAgent
-	}
-
-	clientOptions := []requests.ClientOption{
-		requests.WithUserAgent(userAgent),
-	}
-	if opts.Timeout > 0 {
-		clientOptions = append(clientOptions, requests.WithTimeout(opts.Timeout))
+	fetch := &defuddle.FetchOptions{
+		UserAgent:         opts.UserAgent,
+		Headers:           http.Header{},
+		Proxy:             opts.Proxy,
+		Timeout:           opts.Timeout,
+		MaxRetries:        opts.Retries,
+		RetryBackoff:      opts.RetryDelay,
+		RequestsPerSecond: opts.RateLimit,
+		RespectRobots:     opts.RespectRobots,
+		CacheDir:          opts.CacheDir,
+		CacheTTL:          opts.CacheTTL,
+		UnixSocket:        opts.UnixSocket,
+	}
+	if isHTTPURL(opts.DNS) {
+		fetch.Resolver = defuddle.NewDoHResolver(opts.DNS, nil)
+	} else if opts.DNS != "" {
+		fetch.Resolver = defuddle.NewDNSResolver(opts.DNS)
+	}
+	if fetch.Timeout <= 0 {
+		// parseContext bounds the whole run instead
+		fetch.Timeout = -1
 	}
 	for _, header := range opts.Headers {
 		key, value, err := parseHeader(header)
 		if err != nil {
 			return nil, err
 		}
-		clientOptions = append(clientOptions, requests.WithHeader(key, value))
+		fetch.Headers.Add(key, value)
 	}
-
-	client := requests.New(clientOptions...)
-	if opts.Proxy != "" {
-		if err := client.SetProxy(opts.Proxy); err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %w", err)
-		}
-	}
-	return client, nil
+	return defuddle.NewFetchClient(fetch)
 }
 
 func isHTTPURL(source string) bool {
@@ -272,6 +743,32 @@ func isHTTPURL(source string) bool {
 	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
 }
 
+// sourcePath returns the local path of a file source given as a file://
+// URL, and any other source as it is.
+func sourcePath(source string) string {
+	if !strings.HasPrefix(strings.ToLower(source), "file:") {
+		return source
+	}
+	u, err := url.Parse(source)
+	if err != nil || (u.Host != "" && !strings.EqualFold(u.Host, "localhost")) || u.Path == "" {
+		return source
+	}
+	// Windows paths are written file:///C:/dir/page.html
+	if filepath.VolumeName(u.Path[1:]) != "" {
+		return filepath.FromSlash(u.Path[1:])
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// fileURL returns the file:// URL of the absolute path.
+func fileURL(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
+
 func validateHeaders(headers []string) error {
 	for _, header := range headers {
 		if _, _, err := parseHeader(header); err != nil {
@@ -294,8 +791,9 @@ func parseHeader(header string) (string, string, error) {
 	return key, strings.TrimSpace(parts[1]), nil
 }
 
-func readFile(filename string) (string, error) {
-	if err := validateFilePath(filename); err != nil {
+func readFile(filename, baseDir string) (string, error) {
+	filename, err := validateFilePath(filename, baseDir)
+	if err != nil {
 		return "", err
 	}
 	content, err := os.ReadFile(filename) // #nosec G304 - path validated above
@@ -305,21 +803,42 @@ func readFile(filename string) (string, error) {
 	return string(content), nil
 }
 
-func validateFilePath(filename string) error {
-	if strings.Contains(filename, "..") {
-		return ErrDirectoryTraversal
+// validateFilePath returns filename cleaned. When baseDir is set, filename
+// is resolved against the working directory and must lie inside baseDir;
+// ".." segments are fine as long as the result does. Symlinks are not
+// resolved.
+func validateFilePath(filename, baseDir string) (string, error) {
+	filename = filepath.Clean(filename)
+	if baseDir == "" {
+		return filename, nil
 	}
-	return nil
+
+	base, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid base directory: %w", err)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", fmt.Errorf("invalid file path: %w", err)
+	}
+	// Rel fails for paths on another volume, which are outside base too
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s", ErrDirectoryTraversal, filename)
+	}
+	return abs, nil
 }
 
-func writeOutput(filename, content string) error {
+func writeOutput(filename, content string, force bool) error {
 	if filename == "" {
 		fmt.Print(content)
 		return nil
 	}
 
-	err := os.WriteFile(filename, []byte(content), 0600) // More secure file permissions
-	if err != nil {
+	if err := writeFileAtomic(filename, force, func(w io.Writer) error {
+		_, err := io.WriteString(w, content)
+		return err
+	}); err != nil {
 		return err
 	}
 
@@ -327,6 +846,61 @@ func writeOutput(filename, content string) error {
 	return nil
 }
 
+// writeFileAtomic streams write to a temporary file beside filename and
+// then moves it into place, so readers never see partial output and a
+// failed write leaves an existing file untouched. Without force, an existing
+// file is never replaced.
+func writeFileAtomic(filename string, force bool, write func(io.Writer) error) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	// Fails harmlessly once the file has been renamed into place
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+
+	if force {
+		if err := os.Rename(tmp.Name(), filename); err != nil {
+			return fmt.Errorf("error writing output: %w", err)
+		}
+		return nil
+	}
+
+	// Link fails when filename exists, so a file created since checkOutput
+	// is not replaced either
+	err = os.Link(tmp.Name(), filename)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrExist):
+		return fmt.Errorf("%w: %s", ErrOutputExists, filename)
+	}
+	// Filesystems without hard links fall back to check-then-rename
+	if _, statErr := os.Lstat(filename); statErr == nil {
+		return fmt.Errorf("%w: %s", ErrOutputExists, filename)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	return nil
+}
+
 func jsonProperty(value any) string {
 	jsonBytes, err := json.Marshal(value)
 	if err != nil {
@@ -366,6 +940,8 @@ func getProperty(result *defuddle.Result, property string) string {
 		return result.Published
 	case "wordcount":
 		return strconv.Itoa(result.WordCount)
+	case "readingtime":
+		return strconv.Itoa(result.ReadingTime)
 	case "parsetime":
 		return strconv.FormatInt(result.ParseTime, 10)
 	case "metatags":
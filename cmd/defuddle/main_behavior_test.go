@@ -1,16 +1,21 @@
 package main
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-json-experiment/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -62,7 +67,7 @@ func TestReadFileValidatesAndReadsContent(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "article.html")
 	require.NoError(t, os.WriteFile(path, []byte("<article>Readable</article>"), 0o600))
 
-	content, err := readFile(path)
+	content, err := readFile(path, "")
 	require.NoError(t, err)
 
 	assert.Equal(t, "<article>Readable</article>", content)
@@ -71,7 +76,7 @@ func TestReadFileValidatesAndReadsContent(t *testing.T) {
 func TestReadFileWrapsFilesystemErrors(t *testing.T) {
 	t.Parallel()
 
-	_, err := readFile(filepath.Join(t.TempDir(), "missing.html"))
+	_, err := readFile(filepath.Join(t.TempDir(), "missing.html"), "")
 
 	require.Error(t, err)
 	assert.NotNil(t, errors.Unwrap(err))
@@ -80,20 +85,157 @@ func TestReadFileWrapsFilesystemErrors(t *testing.T) {
 func TestValidateFilePathAcceptsSafePath(t *testing.T) {
 	t.Parallel()
 
-	require.NoError(t, validateFilePath("articles/example.html"))
+	path, err := validateFilePath("articles/example.html", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("articles", "example.html"), path)
+}
+
+func TestValidateFilePathAllowsParentSegmentsWithoutBaseDir(t *testing.T) {
+	t.Parallel()
+
+	path, err := validateFilePath("../articles/page.html", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", "articles", "page.html"), path)
+}
+
+func TestValidateFilePathScopesToBaseDir(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	tests := []struct {
+		name     string
+		filename string
+		wantErr  bool
+	}{
+		{name: "file in base", filename: filepath.Join(base, "page.html")},
+		{name: "nested file", filename: filepath.Join(base, "articles", "page.html")},
+		{name: "parent segment staying inside", filename: filepath.Join(base, "articles", "..", "page.html")},
+		{name: "parent of base", filename: filepath.Join(base, "..", "page.html"), wantErr: true},
+		{name: "sibling with base as prefix", filename: base + "-other" + string(filepath.Separator) + "page.html", wantErr: true},
+		{name: "unrelated absolute path", filename: filepath.Join(filepath.Dir(base), "elsewhere", "page.html"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, err := validateFilePath(tt.filename, base)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDirectoryTraversal)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, filepath.IsAbs(path))
+			assert.Equal(t, filepath.Clean(tt.filename), path)
+		})
+	}
+}
+
+func TestExecuteParseContentRejectsSourceOutsideBaseDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "sandbox")
+	require.NoError(t, os.Mkdir(base, 0o700))
+	source := filepath.Join(dir, "secret.html")
+	require.NoError(t, os.WriteFile(source, []byte("<p>Secret</p>"), 0o600))
+
+	err := executeParseContent(&ParseOptions{Source: source, BaseDir: base})
+
+	require.ErrorIs(t, err, ErrDirectoryTraversal)
 }
 
 func TestWriteOutputWritesFile(t *testing.T) {
 	t.Parallel()
 
 	path := filepath.Join(t.TempDir(), "result.txt")
-	require.NoError(t, writeOutput(path, "Readable content"))
+	require.NoError(t, writeOutput(path, "Readable content", false))
 
 	content, err := os.ReadFile(path)
 	require.NoError(t, err)
 	assert.Equal(t, "Readable content", string(content))
 }
 
+func TestWriteOutputRefusesToOverwriteWithoutForce(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "result.txt")
+	require.NoError(t, os.WriteFile(path, []byte("Original"), 0o600))
+
+	err := writeOutput(path, "Replacement", false)
+	require.ErrorIs(t, err, ErrOutputExists)
+	content, readErr := os.ReadFile(path)
+	require.NoError(t, readErr)
+	assert.Equal(t, "Original", string(content))
+
+	require.NoError(t, writeOutput(path, "Replacement", true))
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Replacement", string(content))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are cleaned up")
+}
+
+func TestWriteOutputLeavesExistingFileOnFailedWrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "result.txt")
+	require.NoError(t, os.Mkdir(path, 0o700))
+
+	require.Error(t, writeOutput(path, "Replacement", true))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are cleaned up")
+}
+
+func TestExecuteParseContentChecksExistingOutputBeforeParsing(t *testing.T) {
+	t.Parallel()
+
+	output := filepath.Join(t.TempDir(), "out.html")
+	require.NoError(t, os.WriteFile(output, []byte("Original"), 0o600))
+
+	err := executeParseContent(&ParseOptions{Source: "missing.html", Output: output})
+
+	require.ErrorIs(t, err, ErrOutputExists)
+}
+
+func TestExecuteParseContentRejectsOutputWithOutputDir(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "missing.html", Output: "out.md", OutputDir: t.TempDir()})
+
+	require.ErrorIs(t, err, ErrOutputConflict)
+}
+
+func TestExecuteParseContentWritesTemplatedNameInOutputDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "page.html")
+	require.NoError(t, os.WriteFile(source, []byte(`<html><head><title>Release Notes</title>
+<meta property="og:site_name" content="Example"></head>
+<body><article><h1>Release Notes</h1><p>The release adds atomic output writing for the command line.</p></article></body></html>`), 0o600))
+	outputDir := filepath.Join(dir, "out")
+
+	opts := &ParseOptions{Source: source, Format: formatMarkdown, OutputDir: outputDir, OutputName: "{slug}.{ext}"}
+	require.NoError(t, executeParseContent(opts))
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "release-notes.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "atomic output writing")
+
+	opts = &ParseOptions{Source: source, Format: formatMarkdown, OutputDir: outputDir, OutputName: "{slug}.{ext}"}
+	require.ErrorIs(t, executeParseContent(opts), ErrOutputExists)
+}
+
 func TestWriteOutputPrintsToStdout(t *testing.T) {
 	// This test swaps os.Stdout, so it must not run in parallel.
 	stdout := os.Stdout
@@ -104,7 +246,7 @@ func TestWriteOutputPrintsToStdout(t *testing.T) {
 		os.Stdout = stdout
 	})
 
-	require.NoError(t, writeOutput("", "Readable content"))
+	require.NoError(t, writeOutput("", "Readable content", false))
 	require.NoError(t, writer.Close())
 
 	var buf bytes.Buffer
@@ -144,6 +286,7 @@ func TestGetPropertyReturnsScalarMetadata(t *testing.T) {
 		Content:         "HTML content",
 		ContentMarkdown: &markdown,
 		ExtractorType:   &extractorType,
+		ReadingTime:     3,
 	}
 	result.Title = "Title"
 	result.Description = "Description"
@@ -171,6 +314,7 @@ func TestGetPropertyReturnsScalarMetadata(t *testing.T) {
 		{name: "site", property: "site", want: "Site"},
 		{name: "published", property: "published", want: "2026-05-07"},
 		{name: "word count", property: "wordcount", want: "42"},
+		{name: "reading time", property: "readingtime", want: "3"},
 		{name: "parse time", property: "parsetime", want: "17"},
 		{name: "extractor type", property: "extractortype", want: "github"},
 		{name: "content markdown", property: "contentmarkdown", want: "# Markdown"},
@@ -227,6 +371,198 @@ func TestExecuteParseContentReadsFileAndWritesRequestedFormat(t *testing.T) {
 	assert.NotContains(t, string(content), "<article")
 }
 
+func TestExecuteParseContentReadsFileURLInsideBaseDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "result.md")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>File URL Article</title></head><body><article><p>Readable file URL body.</p></article></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:   fileURL(input),
+		Markdown: true,
+		Output:   output,
+		BaseDir:  dir,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Readable file URL body")
+
+	err = executeParseContent(&ParseOptions{Source: fileURL(input), BaseDir: t.TempDir(), Timeout: 5 * time.Second})
+	require.ErrorIs(t, err, ErrDirectoryTraversal)
+}
+
+func TestExecuteParseContentWritesReferenceStyleLinks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "result.md")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Linked</title></head><body><article><h1>Linked</h1><p>See <a href="https://example.com/a">alpha</a> and <a href="https://example.com/a">alpha again</a>.</p></article></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:    input,
+		Markdown:  true,
+		Output:    output,
+		Timeout:   5 * time.Second,
+		LinkStyle: "reference",
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "[alpha][1] and [alpha again][1]")
+	assert.Contains(t, string(content), "[1]: https://example.com/a")
+}
+
+func TestExecuteParseContentRejectsUnknownLinkStyle(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", LinkStyle: "footnote"})
+
+	require.ErrorIs(t, err, ErrUnsupportedLinkStyle)
+}
+
+func TestExecuteParseContentHardWrapsMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "result.md")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Wrapped</title></head><body><article><h1>Wrapped</h1><p>The quick brown fox jumps over the lazy dog and keeps running far away from <a href="https://example.com/home">its comfortable home</a>.</p></article></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:    input,
+		Markdown:  true,
+		Output:    output,
+		Timeout:   5 * time.Second,
+		Wrap:      "hard",
+		WrapWidth: 30,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "The quick brown fox jumps over\nthe lazy dog")
+	assert.Contains(t, string(content), "[its comfortable home](https://example.com/home)")
+}
+
+func TestExecuteParseContentRejectsUnknownWrap(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", Wrap: "column"})
+
+	require.ErrorIs(t, err, ErrUnsupportedWrap)
+}
+
+func TestExecuteParseContentPassesSafeHTMLThrough(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "result.md")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Keys</title></head><body><article><h1>Keys</h1><p>Press <kbd class="key">Ctrl</kbd> to see <mark>highlighted</mark> in the long enough article body text.</p></article></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:      input,
+		Markdown:    true,
+		Output:      output,
+		Timeout:     5 * time.Second,
+		Passthrough: "safe",
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Press <kbd>Ctrl</kbd> to see <mark>highlighted</mark>")
+}
+
+func TestExecuteParseContentRejectsUnknownHTMLPassthrough(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", Passthrough: "raw"})
+
+	require.ErrorIs(t, err, ErrUnsupportedHTMLPassthrough)
+}
+
+func TestExecuteParseContentUsesExternalExtractorManifest(t *testing.T) {
+	t.Parallel()
+
+	extractor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"title":"External Title","contentHtml":"<p>Written by the external extractor.</p>"}`))
+	}))
+	defer extractor.Close()
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Page Title</title></head><body><article><p>Readable page body.</p></article></body></html>`))
+	}))
+	defer page.Close()
+
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "extractors.json")
+	output := filepath.Join(dir, "result.json")
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"extractors":[{"name":"local","domains":["127.0.0.1"],"url":"`+extractor.URL+`"}]}`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:     page.URL,
+		JSON:       true,
+		Output:     output,
+		Timeout:    5 * time.Second,
+		Extractors: manifest,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Written by the external extractor.")
+	assert.Contains(t, string(content), "External Title")
+}
+
+func TestExecuteParseContentReportsInvalidExtractorManifest(t *testing.T) {
+	t.Parallel()
+
+	manifest := filepath.Join(t.TempDir(), "extractors.json")
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"extractors":[{"name":"broken"}]}`), 0o600))
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", Extractors: manifest})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "domains or urlPatterns")
+}
+
+func TestExecuteParseContentUsesExtractorConfig(t *testing.T) {
+	t.Parallel()
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Page Title</title></head><body><h1 class="name">Rule Title</h1><div class="body"><p>Selected by a rule.</p><nav>Skip</nav></div></body></html>`))
+	}))
+	defer page.Close()
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "rules.yml")
+	output := filepath.Join(dir, "result.json")
+	require.NoError(t, os.WriteFile(config, []byte("extractors:\n  - name: local\n    domains: [127.0.0.1]\n    content: .body\n    remove: [nav]\n    title: h1.name\n"), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:          page.URL,
+		JSON:            true,
+		Output:          output,
+		Timeout:         5 * time.Second,
+		ExtractorConfig: config,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Selected by a rule.")
+	assert.Contains(t, string(content), "Rule Title")
+	assert.NotContains(t, string(content), "Skip")
+}
+
 func TestExecuteParseContentReturnsRequestedProperty(t *testing.T) {
 	t.Parallel()
 
@@ -272,6 +608,34 @@ func TestExecuteParseContentAppliesHTTPRequestOptions(t *testing.T) {
 	assert.Contains(t, string(content), "Readable remote CLI body")
 }
 
+func TestExecuteParseContentRetriesFailedFetches(t *testing.T) {
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Retried</title></head><body><article><p>Readable retried body.</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "remote.html")
+	err := executeParseContent(&ParseOptions{
+		Source:     server.URL,
+		Output:     output,
+		Timeout:    5 * time.Second,
+		Retries:    1,
+		RetryDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Readable retried body")
+}
+
 func TestExecuteParseContentTreatsHTTPURLSchemeCaseInsensitively(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -353,3 +717,374 @@ func TestParseContentHonorsMarkdownAlias(t *testing.T) {
 
 	require.ErrorIs(t, err, os.ErrNotExist)
 }
+
+func TestExecuteParseContentWholePageKeepsSectionsOutsideArticle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "export.html")
+	output := filepath.Join(dir, "export-out.html")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Export</title></head><body><article><h1>Export</h1><p>Readable exported article body.</p></article><section><p>Exported appendix section.</p></section></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:    input,
+		Output:    output,
+		Timeout:   5 * time.Second,
+		WholePage: true,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Readable exported article body.")
+	assert.Contains(t, string(content), "Exported appendix section.")
+}
+
+func TestExecuteParseContentSanitize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "article-out.html")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Embed</title></head><body><article><h1>Embed</h1><p>Readable article body with a <a href="javascript:alert(1)">bad link</a>.</p><form><p>Subscribe here.</p></form></article></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:   input,
+		Output:   output,
+		Timeout:  5 * time.Second,
+		Sanitize: true,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Readable article body")
+	assert.NotContains(t, string(content), "javascript:")
+	assert.NotContains(t, string(content), "<form")
+}
+
+func TestExecuteParseContentWritesReaderView(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "reader.html")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Reader Article</title><meta name="author" content="Jane Doe"></head><body><article><h1>Reader Article</h1><p>Readable reader view body content.</p></article></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:  input,
+		Output:  output,
+		Timeout: 5 * time.Second,
+		Format:  "Reader",
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	page := string(content)
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	assert.Contains(t, page, "<title>Reader Article</title>")
+	assert.Contains(t, page, "Jane Doe · 1 min read")
+	assert.Contains(t, page, "Readable reader view body content.")
+	assert.NotContains(t, page, "<script")
+	assert.NotContains(t, page, "http://")
+}
+
+func TestExecuteParseContentWritesHTMLPageWithTheme(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Archived Article</title><meta name="author" content="Jane Doe"><meta property="article:published_time" content="2024-03-05"></head><body><article><h1>Archived Article</h1><p>Archived reading copy body content.</p></article></body></html>`), 0o600))
+	themeFile := filepath.Join(dir, "theme.css")
+	require.NoError(t, os.WriteFile(themeFile, []byte("body { font-family: serif; }"), 0o600))
+
+	for _, theme := range []string{"Sepia", themeFile} {
+		output := filepath.Join(t.TempDir(), "page.html")
+		err := executeParseContent(&ParseOptions{
+			Source:  input,
+			Output:  output,
+			Timeout: 5 * time.Second,
+			Format:  "html-page",
+			Theme:   theme,
+		})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(output)
+		require.NoError(t, err)
+		page := string(content)
+		assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+		assert.Contains(t, page, "<title>Archived Article</title>")
+		assert.Contains(t, page, "Jane Doe")
+		assert.Contains(t, page, "2024-03-05")
+		assert.Contains(t, page, "Archived reading copy body content.")
+		if theme == themeFile {
+			assert.Contains(t, page, "body { font-family: serif; }")
+		} else {
+			assert.Contains(t, page, "#f4ecd8")
+		}
+	}
+}
+
+func TestExecuteParseContentRejectsThemeWithoutPage(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", Format: "markdown", Theme: "dark"})
+	require.ErrorIs(t, err, ErrThemeWithoutPage)
+}
+
+func TestExecuteParseContentWritesEPUB(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "article.epub")
+	png, err := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "img"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "img", "chart.png"), png, 0o600))
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Offline Article</title><meta name="author" content="Jane Doe"></head><body><article><h1>Offline Article</h1><p>Readable e-book body content that is long enough to keep.</p><figure><img src="img/chart.png" alt="Chart"><figcaption>The chart.</figcaption></figure></article></body></html>`), 0o600))
+
+	err = executeParseContent(&ParseOptions{
+		Source:  input,
+		Output:  output,
+		Timeout: 5 * time.Second,
+		Format:  "EPUB",
+		BaseDir: dir,
+	})
+	require.NoError(t, err)
+
+	zr, err := zip.OpenReader(output)
+	require.NoError(t, err)
+	defer func() { _ = zr.Close() }()
+	require.NotEmpty(t, zr.File)
+	assert.Equal(t, "mimetype", zr.File[0].Name)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, rc.Close())
+		require.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+	assert.Contains(t, files["OEBPS/content.opf"], "<dc:title>Offline Article</dc:title>")
+	assert.Contains(t, files["OEBPS/content.opf"], "<dc:creator>Jane Doe</dc:creator>")
+	assert.Contains(t, files["OEBPS/content.xhtml"], "Readable e-book body content")
+	assert.Contains(t, files["OEBPS/content.xhtml"], `<img src="images/image-1.png" alt="Chart"/>`)
+	assert.Equal(t, string(png), files["OEBPS/images/image-1.png"])
+}
+
+func TestExecuteParseContentWritesLaTeX(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "out", "article.tex")
+	png, err := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "img"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "img", "chart.png"), png, 0o600))
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Offline Article</title><meta name="author" content="Jane Doe"></head><body><article><h1>Offline Article</h1><p>Readable paper body content that is long enough to keep.</p><figure><img src="img/chart.png" alt="Chart"><figcaption>The chart.</figcaption></figure></article></body></html>`), 0o600))
+
+	err = executeParseContent(&ParseOptions{
+		Source:  input,
+		Output:  output,
+		Timeout: 5 * time.Second,
+		Format:  "latex",
+		BaseDir: dir,
+	})
+	require.NoError(t, err)
+
+	doc, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(doc), "\\title{Offline Article}\n\\author{Jane Doe}\n")
+	assert.Contains(t, string(doc), "Readable paper body content")
+	assert.Contains(t, string(doc), "\\includegraphics[width=\\linewidth]{article-images/image-1.png}\n\\caption{The chart.}\n")
+	image, err := os.ReadFile(filepath.Join(dir, "out", "article-images", "image-1.png"))
+	require.NoError(t, err)
+	assert.Equal(t, png, image)
+}
+
+func TestExecuteParseContentKeepsExistingLaTeXImages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "article.tex")
+	png, err := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "chart.png"), png, 0o600))
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Offline Article</title></head><body><article><h1>Offline Article</h1><p>Readable paper body content that is long enough to keep.</p><p><img src="chart.png" alt="Chart"></p></article></body></html>`), 0o600))
+	existing := filepath.Join(dir, "article-images", "image-1.png")
+	require.NoError(t, os.MkdirAll(filepath.Dir(existing), 0o750))
+	require.NoError(t, os.WriteFile(existing, []byte("kept"), 0o600))
+
+	opts := &ParseOptions{Source: input, Output: output, Timeout: 5 * time.Second, Format: "latex", BaseDir: dir}
+	err = executeParseContent(opts)
+	require.ErrorIs(t, err, ErrOutputExists)
+	image, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(image))
+	assert.NoFileExists(t, output)
+
+	opts.Force = true
+	require.NoError(t, executeParseContent(opts))
+	image, err = os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, png, image)
+}
+
+func TestExecuteParseContentRejectsEPUBWithoutOutput(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", Format: "epub"})
+	require.ErrorIs(t, err, ErrEPUBWithoutOutput)
+}
+
+func TestExecuteParseContentReadsArchives(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><head><title>Saved Article</title></head><body><article><h1>Saved Article</h1><p>Archived reading copy body content.</p><img src="/lead.png" alt="Lead"></article></body></html>`
+	png := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+	mhtml := "MIME-Version: 1.0\r\nSnapshot-Content-Location: https://example.com/saved\r\nContent-Type: multipart/related; type=\"text/html\"; boundary=\"b\"\r\n\r\n" +
+		"--b\r\nContent-Type: text/html; charset=utf-8\r\nContent-Location: https://example.com/saved\r\n\r\n" + page + "\r\n" +
+		"--b\r\nContent-Type: image/png\r\nContent-Transfer-Encoding: base64\r\nContent-Location: https://example.com/lead.png\r\n\r\n" + png + "\r\n--b--\r\n"
+	block := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: %d\r\n\r\n%s", len(page), page)
+	warc := fmt.Sprintf("WARC/1.0\r\nWARC-Type: response\r\nWARC-Target-URI: https://example.com/saved\r\nContent-Type: application/http; msgtype=response\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n", len(block), block)
+
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		format, data string
+		embed        bool
+	}{
+		{format: "MHTML", data: mhtml, embed: true},
+		{format: "warc", data: warc},
+	} {
+		input := filepath.Join(dir, "saved."+strings.ToLower(tt.format))
+		output := filepath.Join(dir, "saved-"+strings.ToLower(tt.format)+".json")
+		require.NoError(t, os.WriteFile(input, []byte(tt.data), 0o600))
+
+		err := executeParseContent(&ParseOptions{
+			Source:             input,
+			Output:             output,
+			Timeout:            5 * time.Second,
+			Format:             "json",
+			InputFormat:        tt.format,
+			EmbedArchiveImages: tt.embed,
+			BaseDir:            dir,
+		})
+		require.NoError(t, err, tt.format)
+
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		var result defuddle.Result
+		require.NoError(t, json.Unmarshal(data, &result))
+		assert.Equal(t, "Saved Article", result.Title, tt.format)
+		assert.Equal(t, "example.com", result.Domain, tt.format)
+		assert.Contains(t, result.Content, "Archived reading copy body content.", tt.format)
+		assert.Equal(t, tt.embed, strings.Contains(result.Content, "data:image/png;base64,"), tt.format)
+	}
+}
+
+func TestExecuteParseContentRejectsUnknownInputFormat(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", InputFormat: "pdf"})
+	require.ErrorIs(t, err, ErrUnsupportedInputFormat)
+
+	err = executeParseContent(&ParseOptions{Source: "https://example.com/saved.warc", InputFormat: "warc"})
+	require.ErrorIs(t, err, ErrArchiveFromURL)
+}
+
+func TestExecuteParseContentRejectsUnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", Format: "pdf"})
+
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestApplyFormatMapsOntoOutputFlags(t *testing.T) {
+	t.Parallel()
+
+	opts := &ParseOptions{JSON: true, Format: "md"}
+	require.NoError(t, applyFormat(opts))
+	assert.Equal(t, formatMarkdown, opts.Format)
+	assert.True(t, opts.Markdown)
+	assert.False(t, opts.JSON)
+
+	opts = &ParseOptions{Markdown: true, Format: "json"}
+	require.NoError(t, applyFormat(opts))
+	assert.True(t, opts.JSON)
+	assert.False(t, opts.Markdown)
+
+	opts = &ParseOptions{Markdown: true}
+	require.NoError(t, applyFormat(opts))
+	assert.True(t, opts.Markdown, "empty format keeps explicit flags")
+
+	opts = &ParseOptions{Markdown: true, Format: "NDJSON"}
+	require.NoError(t, applyFormat(opts))
+	assert.Equal(t, formatNDJSON, opts.Format)
+	assert.True(t, opts.JSON)
+	assert.False(t, opts.Markdown)
+}
+
+func TestExecuteParseContentWritesNDJSONLine(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	output := filepath.Join(dir, "result.ndjson")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Streamed</title></head><body><article><h1>Streamed</h1><p>One record per line.</p></article></body></html>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{Source: input, Format: "ndjson", Output: output, Timeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	line, rest, found := strings.Cut(string(content), "\n")
+	require.True(t, found)
+	assert.Empty(t, rest, "exactly one line")
+
+	var result defuddle.Result
+	require.NoError(t, json.Unmarshal([]byte(line), &result))
+	assert.Equal(t, "Streamed", result.Title)
+}
+
+func TestExecuteParseContentRendersTemplateFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "article.html")
+	tmplFile := filepath.Join(dir, "page.tmpl")
+	output := filepath.Join(dir, "archive.html")
+	require.NoError(t, os.WriteFile(input, []byte(`<html><head><title>Archived</title></head><body><article><h1>Archived</h1><p>Archived body content.</p></article></body></html>`), 0o600))
+	require.NoError(t, os.WriteFile(tmplFile, []byte(`<section class="brand"><h1>{{.Title}}</h1>{{.Content}}</section>`), 0o600))
+
+	err := executeParseContent(&ParseOptions{
+		Source:       input,
+		Output:       output,
+		Timeout:      5 * time.Second,
+		TemplateFile: tmplFile,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), `<section class="brand"><h1>Archived</h1>`))
+	assert.Contains(t, string(content), "Archived body content.")
+}
+
+func TestExecuteParseContentReportsInvalidTemplateFile(t *testing.T) {
+	t.Parallel()
+
+	tmplFile := filepath.Join(t.TempDir(), "broken.tmpl")
+	require.NoError(t, os.WriteFile(tmplFile, []byte(`{{.Title`), 0o600))
+
+	err := executeParseContent(&ParseOptions{Source: "unused.html", TemplateFile: tmplFile})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse template")
+}
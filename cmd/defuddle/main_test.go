@@ -32,5 +32,6 @@ func TestMarkdownContentConvertsHTMLContent(t *testing.T) {
 }
 
 func TestValidateFilePathRejectsParentSegments(t *testing.T) {
-	assert.ErrorIs(t, validateFilePath("../article.html"), ErrDirectoryTraversal)
+	_, err := validateFilePath("../article.html", ".")
+	assert.ErrorIs(t, err, ErrDirectoryTraversal)
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFilePathHandlesWindowsPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+		wantErr  bool
+	}{
+		{name: "backslashes", filename: `C:\sandbox\articles\page.html`, want: `C:\sandbox\articles\page.html`},
+		{name: "forward slashes", filename: `C:/sandbox/articles/page.html`, want: `C:\sandbox\articles\page.html`},
+		{name: "drive letter case", filename: `c:\sandbox\page.html`, want: `c:\sandbox\page.html`},
+		{name: "parent segment staying inside", filename: `C:\sandbox\articles\..\page.html`, want: `C:\sandbox\page.html`},
+		{name: "parent of base", filename: `C:\sandbox\..\page.html`, wantErr: true},
+		{name: "other volume", filename: `D:\sandbox\page.html`, wantErr: true},
+		{name: "UNC path", filename: `\\server\share\page.html`, wantErr: true},
+		{name: "reserved device name", filename: `C:\sandbox\NUL`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, err := validateFilePath(tt.filename, `C:\sandbox`)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDirectoryTraversal)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, path)
+		})
+	}
+}
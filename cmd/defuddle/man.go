@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var manCmd = &cobra.Command{
+	Use:   "man [directory]",
+	Short: "Generate man pages for defuddle and its commands",
+	Long: `Write the defuddle(1) man page to stdout, or with a directory, write one page
+per command into it as defuddle.1, defuddle-parse.1, and so on.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: manContent,
+}
+
+func init() {
+	rootCmd.AddCommand(manCmd)
+}
+
+func manContent(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return writeManPage(os.Stdout, rootCmd)
+	}
+	return writeManPages(args[0], rootCmd)
+}
+
+// writeManPages writes the man page of cmd and of each of its commands into
+// dir, named after the command path.
+func writeManPages(dir string, cmd *cobra.Command) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating man page directory: %w", err)
+	}
+	for _, c := range manCommands(cmd) {
+		file, err := os.Create(filepath.Join(dir, manName(c)+".1"))
+		if err != nil {
+			return fmt.Errorf("error creating man page: %w", err)
+		}
+		err = writeManPage(file, c)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manCommands returns cmd and its descendants that get a man page, leaving
+// out help and hidden commands.
+func manCommands(cmd *cobra.Command) []*cobra.Command {
+	commands := []*cobra.Command{cmd}
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() && !c.IsAdditionalHelpTopicCommand() {
+			commands = append(commands, manCommands(c)...)
+		}
+	}
+	return commands
+}
+
+// manName returns the page name of cmd, such as defuddle-parse.
+func manName(cmd *cobra.Command) string {
+	return strings.ReplaceAll(cmd.CommandPath(), " ", "-")
+}
+
+// writeManPage writes the man page of cmd to w in roff.
+func writeManPage(w io.Writer, cmd *cobra.Command) error {
+	var b strings.Builder
+	name := manName(cmd)
+	info := buildInfo()
+	// The build date keeps the page the same for the same build
+	day, _, _ := strings.Cut(info.Date, "T")
+	fmt.Fprintf(&b, ".TH %q \"1\" %q \"defuddle %s\" \"Defuddle Manual\"\n", strings.ToUpper(name), day, info.Version)
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(name), roffEscape(cmd.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, "\\fB%s\\fP\n", roffEscape(cmd.UseLine()))
+
+	b.WriteString(".SH DESCRIPTION\n")
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	writeRoffText(&b, description)
+
+	if cmd.HasAvailableLocalFlags() {
+		b.WriteString(".SH OPTIONS\n")
+		writeRoffFlags(&b, cmd.LocalFlags())
+	}
+	if cmd.HasAvailableInheritedFlags() {
+		b.WriteString(".SH OPTIONS INHERITED FROM PARENT COMMANDS\n")
+		writeRoffFlags(&b, cmd.InheritedFlags())
+	}
+	if cmd.Example != "" {
+		b.WriteString(".SH EXAMPLE\n.nf\n")
+		fmt.Fprintf(&b, "%s\n.fi\n", roffEscape(cmd.Example))
+	}
+
+	var related []string
+	if cmd.HasParent() {
+		related = append(related, manName(cmd.Parent()))
+	}
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() && !c.IsAdditionalHelpTopicCommand() {
+			related = append(related, manName(c))
+		}
+	}
+	if len(related) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, page := range related {
+			separator := ","
+			if i == len(related)-1 {
+				separator = ""
+			}
+			fmt.Fprintf(&b, "\\fB%s\\fP(1)%s\n", roffEscape(page), separator)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeRoffFlags writes a tagged paragraph for each visible flag.
+func writeRoffFlags(b *strings.Builder, flags *pflag.FlagSet) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		b.WriteString(".TP\n")
+		if flag.Shorthand != "" && flag.ShorthandDeprecated == "" {
+			fmt.Fprintf(b, "\\fB\\-%s\\fP, ", flag.Shorthand)
+		}
+		fmt.Fprintf(b, "\\fB\\-\\-%s\\fP", roffEscape(flag.Name))
+		varname, usage := pflag.UnquoteUsage(flag)
+		if varname != "" {
+			fmt.Fprintf(b, "=\\fI%s\\fP", roffEscape(varname))
+		}
+		b.WriteString("\n")
+		text := usage
+		if flag.DefValue != "" && flag.DefValue != "false" && flag.DefValue != "0" && flag.DefValue != "[]" {
+			text += fmt.Sprintf(" (default %s)", flag.DefValue)
+		}
+		fmt.Fprintf(b, "%s\n", roffEscape(text))
+	})
+}
+
+// writeRoffText writes text as filled paragraphs, separated at blank lines.
+func writeRoffText(b *strings.Builder, text string) {
+	for i, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			b.WriteString(".PP\n")
+		}
+		fmt.Fprintf(b, "%s\n", roffEscape(strings.TrimSpace(paragraph)))
+	}
+}
+
+// roffEscape escapes s for roff text: backslashes, hyphens, and lines
+// starting with a control character.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
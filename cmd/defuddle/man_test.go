@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+func TestWriteManPageDescribesCommand(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeManPage(&out, parseCmd))
+	page := out.String()
+	assert.Contains(t, page, `.TH "DEFUDDLE-PARSE" "1"`)
+	assert.Contains(t, page, "defuddle\\-parse \\- Parse and extract content from a URL or HTML file\n")
+	assert.Contains(t, page, ".SH OPTIONS\n")
+	assert.Contains(t, page, "\\fB\\-p\\fP, \\fB\\-\\-property\\fP=\\fIstring\\fP\n")
+	assert.Contains(t, page, "\\fB\\-\\-timeout\\fP=\\fIduration\\fP\nRequest timeout (default 30s)\n")
+	assert.Contains(t, page, ".SH SEE ALSO\n\\fBdefuddle\\fP(1)\n")
+}
+
+func TestWriteManPagesWritesEveryCommand(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, writeManPages(dir, rootCmd))
+	for _, name := range []string{"defuddle.1", "defuddle-parse.1", "defuddle-batch.1", "defuddle-version.1"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+	_, err := os.Stat(filepath.Join(dir, "defuddle-help.1"))
+	assert.True(t, os.IsNotExist(err))
+
+	root, err := os.ReadFile(filepath.Join(dir, "defuddle.1"))
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "\\fBdefuddle\\-parse\\fP(1),\n")
+}
+
+func TestRoffEscape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain text", want: "plain text"},
+		{in: "--format", want: `\-\-format`},
+		{in: `C:\pages`, want: `C:\epages`},
+		{in: "first\n.hidden\n'quoted", want: "first\n\\&.hidden\n\\&'quoted"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, roffEscape(tt.in), tt.in)
+	}
+}
+
+func TestFlagCompletions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cmd       *cobra.Command
+		flag      string
+		want      []string
+		directive cobra.ShellCompDirective
+	}{
+		{cmd: parseCmd, flag: "format", want: []string{"html", "markdown", "json", "ndjson", "reader", "html-page", "epub", "latex"}, directive: cobra.ShellCompDirectiveNoFileComp},
+		{cmd: parseCmd, flag: "input-format", want: []string{"html", "mhtml", "warc"}, directive: cobra.ShellCompDirectiveNoFileComp},
+		{cmd: parseCmd, flag: "link-style", want: []string{string(defuddle.LinkStyleInline), string(defuddle.LinkStyleReference)}, directive: cobra.ShellCompDirectiveNoFileComp},
+		{cmd: parseCmd, flag: "theme", want: []string{"dark", "light", "sepia"}, directive: cobra.ShellCompDirectiveDefault},
+		{cmd: batchCmd, flag: "format", want: []string{"ndjson", "json"}, directive: cobra.ShellCompDirectiveNoFileComp},
+		{cmd: batchCmd, flag: "retry-failcategory", want: failureClasses, directive: cobra.ShellCompDirectiveNoFileComp},
+	}
+	for _, tt := range tests {
+		complete, ok := tt.cmd.GetFlagCompletionFunc(tt.flag)
+		require.True(t, ok, tt.flag)
+		got, directive := complete(tt.cmd, nil, "")
+		assert.Equal(t, tt.want, got, tt.flag)
+		assert.Equal(t, tt.directive, directive, tt.flag)
+	}
+
+	complete, ok := parseCmd.GetFlagCompletionFunc("property")
+	require.True(t, ok)
+	got, _ := complete(parseCmd, nil, "")
+	assert.Subset(t, got, []string{"title", "wordcount", "readingtime", "contentmarkdown"})
+}
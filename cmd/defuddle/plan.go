@@ -0,0 +1,134 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// planFetchTime is how long a plan assumes one request takes, from
+// sending it to parsing the page.
+const planFetchTime = time.Second
+
+// batchPlan estimates the requests and duration of a batch without
+// fetching anything.
+type batchPlan struct {
+	Files int
+	URLs  int
+	// Pages and Robots count the requests for pages and robots.txt files.
+	Pages    int
+	Robots   int
+	Duration time.Duration
+	Hosts    []hostPlan
+}
+
+// hostPlan is the share of a batch plan sent to one host.
+type hostPlan struct {
+	Host     string
+	URLs     int
+	Requests int
+	// First and Last are when the first request to the host is sent and
+	// when the last one is expected to finish.
+	First time.Duration
+	Last  time.Duration
+}
+
+// planBatch schedules sources the way executeBatch would fetch them:
+// round-robin by host, on opts.Concurrency workers, one robots.txt request before
+// the first page of each origin with opts.RespectRobots, and requests to
+// each host spaced by opts.RateLimit. Every request is assumed to take
+// planFetchTime; redirects, consent-wall refetches, retries, and the
+// opts.PerHost limit are not scheduled.
+func planBatch(sources []string, opts *BatchOptions) *batchPlan {
+	plan := &batchPlan{}
+	var interval time.Duration
+	if opts.RateLimit > 0 {
+		interval = time.Duration(float64(time.Second) / opts.RateLimit)
+	}
+	workers := make([]time.Duration, max(opts.Concurrency, 1))
+	nextSlot := map[string]time.Duration{}
+	origins := map[string]bool{}
+	hosts := map[string]*hostPlan{}
+
+	// send schedules one request to host no earlier than at, returning
+	// when it finishes
+	send := func(host string, at time.Duration) time.Duration {
+		start := max(at, nextSlot[host])
+		if interval > 0 {
+			nextSlot[host] = start + interval
+		}
+		h := hosts[host]
+		if h.Requests == 0 {
+			h.First = start
+		}
+		h.Requests++
+		h.Last = start + planFetchTime
+		return h.Last
+	}
+
+	for _, source := range interleaveHosts(sources) {
+		target, err := url.Parse(source)
+		if !isHTTPURL(source) || err != nil || target.Host == "" {
+			plan.Files++
+			continue
+		}
+		plan.URLs++
+		if hosts[target.Host] == nil {
+			hosts[target.Host] = &hostPlan{Host: target.Host}
+		}
+		hosts[target.Host].URLs++
+
+		worker := 0
+		for i, free := range workers {
+			if free < workers[worker] {
+				worker = i
+			}
+		}
+		at := workers[worker]
+		if origin := target.Scheme + "://" + target.Host; opts.RespectRobots && !origins[origin] {
+			origins[origin] = true
+			plan.Robots++
+			at = send(target.Host, at)
+		}
+		plan.Pages++
+		workers[worker] = send(target.Host, at)
+		plan.Duration = max(plan.Duration, workers[worker])
+	}
+
+	for _, h := range hosts {
+		plan.Hosts = append(plan.Hosts, *h)
+	}
+	slices.SortFunc(plan.Hosts, func(a, b hostPlan) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.Host, b.Host))
+	})
+	return plan
+}
+
+// write prints the plan as a summary followed by a table of hosts.
+func (p *batchPlan) write(w io.Writer, opts *BatchOptions) error {
+	requests := p.Pages + p.Robots
+	rate := "no rate limit"
+	if opts.RateLimit > 0 {
+		rate = fmt.Sprintf("%g requests/s per host", opts.RateLimit)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Inputs: %d (%d URLs on %d hosts, %d files)\n", p.URLs+p.Files, p.URLs, len(p.Hosts), p.Files)
+	_, _ = fmt.Fprintf(tw, "Requests: %d (%d pages, %d robots.txt)", requests, p.Pages, p.Robots)
+	if opts.Retries > 0 {
+		_, _ = fmt.Fprintf(tw, ", up to %d with %d retries each", requests*(1+opts.Retries), opts.Retries)
+	}
+	_, _ = fmt.Fprintf(tw, "\nSchedule: %d workers, %s\n", max(opts.Concurrency, 1), rate)
+	_, _ = fmt.Fprintf(tw, "Estimated duration: %s, assuming %s per request\n", p.Duration.Round(time.Second), planFetchTime)
+	if len(p.Hosts) > 0 {
+		_, _ = fmt.Fprintln(tw, "\nHOST\tURLS\tREQUESTS\tFIRST\tLAST")
+		for _, h := range p.Hosts {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", h.Host, h.URLs, h.Requests, h.First.Round(time.Second), h.Last.Round(time.Second))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/render"
+)
+
+// renderHTML renders result with tmpl, or the built-in reader view when tmpl
+// is nil, embedding the css theme.
+func renderHTML(result *defuddle.Result, tmpl *template.Template, css string) (string, error) {
+	var buf bytes.Buffer
+	if err := render.HTMLWithCSS(result, tmpl, css, &buf); err != nil {
+		return "", fmt.Errorf("error rendering HTML: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// loadTemplate reads and parses a --template-file.
+func loadTemplate(filename, baseDir string) (*template.Template, error) {
+	text, err := readFile(filename, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template: %w", err)
+	}
+	return render.Parse(filename, text)
+}
+
+// loadTheme returns the style sheet for --theme: a built-in theme by name,
+// or the contents of a CSS file.
+func loadTheme(theme, baseDir string) (string, error) {
+	if css, ok := render.Themes[strings.ToLower(theme)]; ok {
+		return css, nil
+	}
+	css, err := readFile(theme, baseDir)
+	if err != nil {
+		return "", fmt.Errorf("error reading theme: %w", err)
+	}
+	return css, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io"
+	"maps"
+	"net/url"
+	"sync"
+
+	"github.com/go-json-experiment/json"
+)
+
+// ProgressEvent reports a batch input starting or finishing, with the
+// state of the queue after it.
+type ProgressEvent struct {
+	// Event is "start" or "finish".
+	Event  string `json:"event"`
+	Source string `json:"source"`
+	Host   string `json:"host,omitempty"`
+	// Queued is the number of inputs not yet started, and Finished the
+	// number done, of Total.
+	Queued   int `json:"queued"`
+	Finished int `json:"finished"`
+	Total    int `json:"total"`
+	// InFlight counts the inputs being parsed by host; files are under "".
+	InFlight map[string]int `json:"inFlight"`
+}
+
+// hostScheduler hands batch inputs to workers round-robin by host, so a
+// host with many inputs, or slow ones, takes its turn with the others
+// instead of holding every worker until its inputs run out. Inputs of one
+// host keep their order. It is safe for concurrent use.
+type hostScheduler struct {
+	sources []string
+	// perHost, when positive, is the most inputs of one host in flight.
+	perHost  int
+	progress io.Writer
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	hosts    []string
+	queues   map[string][]int
+	next     int
+	queued   int
+	finished int
+	inFlight map[string]int
+}
+
+// newHostScheduler queues sources by host, in the order each host first
+// appears. progress, when set, receives a ProgressEvent as one JSON line
+// each time an input starts or finishes.
+func newHostScheduler(sources []string, perHost int, progress io.Writer) *hostScheduler {
+	s := &hostScheduler{
+		sources:  sources,
+		perHost:  perHost,
+		progress: progress,
+		queues:   map[string][]int{},
+		queued:   len(sources),
+		inFlight: map[string]int{},
+	}
+	s.cond = sync.NewCond(&s.mu)
+	for i, source := range sources {
+		host := sourceHost(source)
+		if _, ok := s.queues[host]; !ok {
+			s.hosts = append(s.hosts, host)
+		}
+		s.queues[host] = append(s.queues[host], i)
+	}
+	return s
+}
+
+// take returns the index of the next input to parse: the first queued
+// input of the next host in turn that is below perHost. It waits while
+// every host with queued inputs is at the limit, and reports false once
+// no inputs are queued.
+func (s *hostScheduler) take() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.queued > 0 {
+		for n := range s.hosts {
+			turn := (s.next + n) % len(s.hosts)
+			host := s.hosts[turn]
+			queue := s.queues[host]
+			if len(queue) == 0 || (s.perHost > 0 && s.inFlight[host] >= s.perHost) {
+				continue
+			}
+			i := queue[0]
+			s.queues[host] = queue[1:]
+			s.next = turn + 1
+			s.queued--
+			s.inFlight[host]++
+			if s.queued == 0 {
+				// Workers waiting for a slot have nothing left to take
+				s.cond.Broadcast()
+			}
+			s.report("start", i)
+			return i, true
+		}
+		s.cond.Wait()
+	}
+	return 0, false
+}
+
+// done records that the input at index i, returned by take, finished.
+func (s *hostScheduler) done(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	host := sourceHost(s.sources[i])
+	if s.inFlight[host]--; s.inFlight[host] == 0 {
+		delete(s.inFlight, host)
+	}
+	s.finished++
+	s.report("finish", i)
+	s.cond.Broadcast()
+}
+
+// report writes a progress event for the input at index i. It is called
+// with s.mu held, so events are written in the order they happen.
+func (s *hostScheduler) report(event string, i int) {
+	if s.progress == nil {
+		return
+	}
+	data, err := json.Marshal(ProgressEvent{
+		Event:    event,
+		Source:   s.sources[i],
+		Host:     sourceHost(s.sources[i]),
+		Queued:   s.queued,
+		Finished: s.finished,
+		Total:    len(s.sources),
+		InFlight: maps.Clone(s.inFlight),
+	}, json.Deterministic(true))
+	if err != nil {
+		return
+	}
+	_, _ = s.progress.Write(append(data, '\n'))
+}
+
+// interleaveHosts returns sources in the order a hostScheduler without a
+// per-host limit starts them: round-robin by host, each host's inputs in
+// their own order.
+func interleaveHosts(sources []string) []string {
+	s := newHostScheduler(sources, 0, nil)
+	order := make([]string, 0, len(sources))
+	for {
+		i, ok := s.take()
+		if !ok {
+			return order
+		}
+		order = append(order, sources[i])
+	}
+}
+
+// sourceHost returns the host[:port] of a URL source, or "" for a file.
+func sourceHost(source string) string {
+	if !isHTTPURL(source) {
+		return ""
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// ErrRetryWithoutReport is returned when --retry-failcategory is given
+// without --failure-report.
+var ErrRetryWithoutReport = fmt.Errorf("--retry-failcategory requires --failure-report")
+
+// ErrUnknownFailureClass is returned for a --retry-failcategory value that
+// is not a failure class.
+var ErrUnknownFailureClass = fmt.Errorf("unknown failure class (expected dns, tls, 4xx, 5xx, timeout, network, parse, sink, or low-confidence)")
+
+// Failure classes of a batch input.
+const (
+	failureDNS           = "dns"
+	failureTLS           = "tls"
+	failure4xx           = "4xx"
+	failure5xx           = "5xx"
+	failureTimeout       = "timeout"
+	failureNetwork       = "network"
+	failureParse         = "parse"
+	failureSink          = "sink"
+	failureLowConfidence = "low-confidence"
+)
+
+// failureClasses lists the failure classes in report order.
+var failureClasses = []string{
+	failureDNS, failureTLS, failure4xx, failure5xx, failureTimeout,
+	failureNetwork, failureParse, failureSink, failureLowConfidence,
+}
+
+// lowConfidenceWords is the word count below which a parsed page is
+// reported as low-confidence: too short to be the article it was fetched
+// for.
+const lowConfidenceWords = 25
+
+// failureClass classifies an error from loading a batch input.
+func failureClass(err error) string {
+	var statusErr *defuddle.HTTPStatusError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var opErr *net.OpError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &statusErr) && statusErr.StatusCode >= 500:
+		return failure5xx
+	case errors.As(err, &statusErr) && statusErr.StatusCode >= 400:
+		return failure4xx
+	case errors.As(err, &dnsErr) && !dnsErr.IsTimeout:
+		return failureDNS
+	case errors.As(err, &certErr), errors.As(err, &alertErr), errors.As(err, &recordErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return failureTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return failureTimeout
+	case errors.As(err, &opErr), errors.As(err, &urlErr):
+		return failureNetwork
+	}
+	return failureParse
+}
+
+// lowConfidence reports whether result was parsed but probably is not the
+// page's content: a consent wall or bot challenge, or too few words.
+func lowConfidence(result *defuddle.Result) bool {
+	return result.Interstitial != nil || result.WordCount < lowConfidenceWords
+}
+
+// FailureReport groups the failed and low-confidence inputs of a batch by
+// failure class and by host.
+type FailureReport struct {
+	Inputs int `json:"inputs"`
+	Failed int `json:"failed"`
+	// Classes counts the inputs of each failure class.
+	Classes map[string]int `json:"classes"`
+	// Hosts counts the inputs of each failure class by host; files are
+	// under "".
+	Hosts    map[string]map[string]int `json:"hosts"`
+	Failures []Failure                 `json:"failures"`
+}
+
+// Failure is one input of a FailureReport.
+type Failure struct {
+	Source string `json:"source"`
+	Host   string `json:"host,omitempty"`
+	Class  string `json:"class"`
+	Error  string `json:"error,omitempty"`
+}
+
+// newFailureReport builds the report of a batch of inputs sources from the
+// records that have a failure class, in input order.
+func newFailureReport(inputs int, records []BatchRecord) *FailureReport {
+	report := &FailureReport{
+		Inputs:   inputs,
+		Classes:  map[string]int{},
+		Hosts:    map[string]map[string]int{},
+		Failures: []Failure{},
+	}
+	for _, record := range records {
+		if record.Class == "" {
+			continue
+		}
+		if record.Class != failureLowConfidence {
+			report.Failed++
+		}
+		host := sourceHost(record.Source)
+		report.Classes[record.Class]++
+		if report.Hosts[host] == nil {
+			report.Hosts[host] = map[string]int{}
+		}
+		report.Hosts[host][record.Class]++
+		report.Failures = append(report.Failures, Failure{Source: record.Source, Host: host, Class: record.Class, Error: record.Error})
+	}
+	return report
+}
+
+// write writes the report as indented JSON.
+func (r *FailureReport) write(w io.Writer) error {
+	data, err := json.Marshal(r, json.Deterministic(true), jsontext.WithIndent("  "))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// parseFailureClasses splits the comma-separated classes of
+// --retry-failcategory values.
+func parseFailureClasses(values []string) (map[string]bool, error) {
+	classes := map[string]bool{}
+	for _, value := range values {
+		for class := range strings.SplitSeq(value, ",") {
+			class = strings.ToLower(strings.TrimSpace(class))
+			if class == "" {
+				continue
+			}
+			if !slices.Contains(failureClasses, class) {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownFailureClass, class)
+			}
+			classes[class] = true
+		}
+	}
+	return classes, nil
+}
+
+// retrySources returns the sources that the failure report at path puts
+// in one of classes, in the order of sources.
+func retrySources(sources []string, path string, classes map[string]bool) ([]string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from the user's own flag
+	if err != nil {
+		return nil, fmt.Errorf("error reading failure report: %w", err)
+	}
+	var report FailureReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("error reading failure report: %w", err)
+	}
+	retry := map[string]bool{}
+	for _, failure := range report.Failures {
+		if classes[failure.Class] {
+			retry[failure.Source] = true
+		}
+	}
+	return slices.DeleteFunc(sources, func(source string) bool { return !retry[source] }), nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/spf13/cobra"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// commit and date are set at build time with -ldflags "-X main.commit=...
+// -X main.date=...". Otherwise they are read from the VCS information the
+// Go toolchain records.
+var (
+	commit string
+	date   string
+)
+
+// latestReleaseURL is the GitHub API endpoint of the newest release.
+const latestReleaseURL = "https://api.github.com/repos/kaptinlin/defuddle-go/releases/latest"
+
+// ErrUpdateCheck is returned when --check-update cannot read the latest release.
+var ErrUpdateCheck = fmt.Errorf("checking for updates failed")
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version, build, and features of defuddle",
+	Args:  cobra.NoArgs,
+	RunE:  versionContent,
+}
+
+// VersionOptions configures the version command.
+type VersionOptions struct {
+	JSON        bool
+	CheckUpdate bool
+	// ReleaseURL is the endpoint --check-update queries.
+	ReleaseURL string
+}
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit,omitempty"`
+	Date      string   `json:"date,omitempty"`
+	Modified  bool     `json:"modified,omitempty"`
+	GoVersion string   `json:"goVersion"`
+	Platform  string   `json:"platform"`
+	Features  []string `json:"features"`
+	Latest    *Release `json:"latest,omitempty"`
+}
+
+// Release is a published release, as reported by --check-update.
+type Release struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	Newer   bool   `json:"newer"`
+}
+
+func init() {
+	versionCmd.Flags().BoolP("json", "j", false, "Print the build information as JSON")
+	versionCmd.Flags().Bool("check-update", false, "Ask GitHub for the latest release and report whether it is newer")
+
+	rootCmd.AddCommand(versionCmd)
+}
+
+func versionContent(cmd *cobra.Command, _ []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	checkUpdate, _ := cmd.Flags().GetBool("check-update")
+	return executeVersion(&VersionOptions{
+		JSON:        jsonOutput,
+		CheckUpdate: checkUpdate,
+		ReleaseURL:  latestReleaseURL,
+	}, os.Stdout)
+}
+
+func executeVersion(opts *VersionOptions, w io.Writer) error {
+	info := buildInfo()
+	if opts.CheckUpdate {
+		latest, err := latestRelease(opts.ReleaseURL)
+		if err != nil {
+			return err
+		}
+		latest.Newer = newerVersion(latest.Version, info.Version)
+		info.Latest = latest
+	}
+
+	if opts.JSON {
+		data, err := json.Marshal(info, jsontext.WithIndent("  "))
+		if err != nil {
+			return fmt.Errorf("error converting to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "defuddle %s\n", info.Version)
+	if info.Commit != "" {
+		modified := ""
+		if info.Modified {
+			modified = " (modified)"
+		}
+		fmt.Fprintf(&b, "commit:   %s%s\n", info.Commit, modified)
+	}
+	if info.Date != "" {
+		fmt.Fprintf(&b, "built:    %s\n", info.Date)
+	}
+	fmt.Fprintf(&b, "go:       %s\n", info.GoVersion)
+	fmt.Fprintf(&b, "platform: %s\n", info.Platform)
+	fmt.Fprintf(&b, "features: %s\n", strings.Join(info.Features, ", "))
+	if info.Latest != nil {
+		if info.Latest.Newer {
+			fmt.Fprintf(&b, "update:   %s is available at %s\n", info.Latest.Version, info.Latest.URL)
+		} else {
+			fmt.Fprintf(&b, "update:   none, %s is the latest release\n", info.Latest.Version)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// buildInfo reports the version, the build, and the optional features
+// compiled into this binary.
+func buildInfo() *BuildInfo {
+	info := &BuildInfo{
+		Version:   defuddle.Version(),
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Features: []string{
+			"input:" + strings.Join([]string{inputHTML, inputMHTML, inputWARC}, ","),
+			"format:" + strings.Join([]string{formatHTML, formatMarkdown, formatJSON, formatNDJSON, formatReader, formatHTMLPage, formatEPUB, formatLaTeX}, ","),
+			"sink:file",
+			"warc-output",
+		},
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// latestRelease reads the newest release from the GitHub API at url.
+func latestRelease(url string) (*Release, error) {
+	client, err := defuddle.NewFetchClient(&defuddle.FetchOptions{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(url).Header("Accept", "application/vnd.github+json").Send(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUpdateCheck, err)
+	}
+	defer func() { _ = resp.Close() }()
+	if resp.IsError() {
+		return nil, fmt.Errorf("%w: %s", ErrUpdateCheck, resp.Status())
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(resp.Body(), &release); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUpdateCheck, err)
+	}
+	if release.TagName == "" {
+		return nil, fmt.Errorf("%w: no release tag", ErrUpdateCheck)
+	}
+	return &Release{Version: release.TagName, URL: release.HTMLURL}, nil
+}
+
+// newerVersion reports whether release is a later version than current.
+// Development builds are older than every release.
+func newerVersion(release, current string) bool {
+	if current == "devel" {
+		return true
+	}
+	r, c := versionParts(release), versionParts(current)
+	for i := range max(len(r), len(c)) {
+		var rp, cp int
+		if i < len(r) {
+			rp = r[i]
+		}
+		if i < len(c) {
+			cp = c[i]
+		}
+		if rp != cp {
+			return rp > cp
+		}
+	}
+	// A release is newer than a pre-release of the same version
+	return !strings.Contains(release, "-") && strings.Contains(current, "-")
+}
+
+// versionParts returns the numeric parts of a version such as v1.2.3-rc.1,
+// before any pre-release or build suffix.
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for field := range strings.SplitSeq(v, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
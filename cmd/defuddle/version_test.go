@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+func TestExecuteVersionReportsBuildInfo(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, executeVersion(&VersionOptions{}, &out))
+	assert.Contains(t, out.String(), "defuddle "+defuddle.Version()+"\n")
+	assert.Contains(t, out.String(), "go:       "+runtime.Version()+"\n")
+	assert.Contains(t, out.String(), "input:html,mhtml,warc")
+	assert.Contains(t, out.String(), "format:html,markdown,json,ndjson,reader,html-page,epub,latex")
+
+	out.Reset()
+	require.NoError(t, executeVersion(&VersionOptions{JSON: true}, &out))
+	var info BuildInfo
+	require.NoError(t, json.Unmarshal(out.Bytes(), &info))
+	assert.Equal(t, defuddle.Version(), info.Version)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info.Platform)
+	assert.Nil(t, info.Latest)
+}
+
+func TestExecuteVersionChecksForUpdates(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag_name":"v9.0.0","html_url":"https://github.com/kaptinlin/defuddle-go/releases/tag/v9.0.0"}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	require.NoError(t, executeVersion(&VersionOptions{CheckUpdate: true, ReleaseURL: server.URL + "/releases/latest"}, &out))
+	assert.Contains(t, out.String(), "update:   v9.0.0 is available at https://github.com/kaptinlin/defuddle-go/releases/tag/v9.0.0\n")
+
+	err := executeVersion(&VersionOptions{CheckUpdate: true, ReleaseURL: server.URL + "/missing"}, &out)
+	require.ErrorIs(t, err, ErrUpdateCheck)
+}
+
+func TestNewerVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		release, current string
+		want             bool
+	}{
+		{"v0.2.0", "v0.1.3", true},
+		{"v0.1.10", "v0.1.9", true},
+		{"v0.1.3", "v0.1.3", false},
+		{"v0.1.3", "v0.2.0", false},
+		{"v0.2.0", "v0.2.0-rc.1", true},
+		{"v0.2.0-rc.1", "v0.2.0", false},
+		{"v0.1.0", "devel", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, newerVersion(tt.release, tt.current), "%s over %s", tt.release, tt.current)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kaptinlin/requests"
+
+	"github.com/kaptinlin/defuddle-go/internal/webarchive"
+)
+
+// warcMiddleware records every response the client fetches, with the
+// request that fetched it, to ww. The body is read in full and handed on
+// unchanged; write errors stay in ww for the caller to check at the end.
+func warcMiddleware(ww *webarchive.WARCWriter) requests.Middleware {
+	return func(next requests.MiddlewareHandlerFunc) requests.MiddlewareHandlerFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err != nil || resp == nil {
+				return resp, err
+			}
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("error reading response: %w", err)
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			_ = ww.WriteExchange(resp, body, time.Now())
+			return resp, nil
+		}
+	}
+}
@@ -56,3 +56,27 @@ func TestParseFallsBackToBodyWhenNoContentCandidateQualifies(t *testing.T) {
 	assert.Contains(t, result.Content, "Short body")
 	assert.Equal(t, 2, result.WordCount)
 }
+
+func TestParseSkipContentSelectionKeepsSectionsOutsideArticle(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Exported article paragraph with plenty of readable words. ", 10)
+	html := `<html><head><title>CMS Export</title></head><body>
+		<article><h1>CMS Export</h1><p>` + body + `</p></article>
+		<section><h2>Appendix</h2><p>Appendix text exported alongside the article body.</p></section>
+	</body></html>`
+
+	selected, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, selected.Content, "Appendix text")
+
+	whole, err := ParseFromString(context.Background(), html, &Options{
+		RemoveExactSelectors:   true,
+		RemovePartialSelectors: true,
+		SkipContentSelection:   true,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, whole.Content, "Exported article paragraph")
+	assert.Contains(t, whole.Content, "Appendix text exported alongside the article body.")
+	assert.NotContains(t, whole.Content, "<body")
+}
@@ -0,0 +1,51 @@
+package defuddle
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultWordsPerMinute is the reading speed Result.ReadingTime assumes
+// when Options.WordsPerMinute is zero, the average for silent reading of
+// English non-fiction
+const DefaultWordsPerMinute = 238
+
+// readingTime returns the minutes needed to read words at wordsPerMinute,
+// rounded up, or 0 for no words.
+func readingTime(words, wordsPerMinute int) int {
+	if words <= 0 {
+		return 0
+	}
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = DefaultWordsPerMinute
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// countContent sets the content counts of stats from the elements of
+// content.
+func countContent(stats *Stats, content string) {
+	stats.Paragraphs, stats.Headings, stats.Images, stats.Links, stats.CodeBlocks = 0, 0, 0, 0, 0
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return
+	}
+	stats.Paragraphs = doc.Find("p").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return strings.TrimSpace(s.Text()) != ""
+	}).Length()
+	stats.Headings = doc.Find("h1, h2, h3, h4, h5, h6").Length()
+	stats.Images = doc.Find("img").Length()
+	stats.Links = doc.Find("a[href]").Length()
+	stats.CodeBlocks = doc.Find("pre").Length()
+}
+
+// describeContent sets result.ReadingTime and the content counts of
+// result.Stats from the final content.
+func describeContent(result *Result, wordsPerMinute int) {
+	result.ReadingTime = readingTime(result.WordCount, wordsPerMinute)
+	if result.Stats == nil {
+		result.Stats = &Stats{}
+	}
+	countContent(result.Stats, result.Content)
+}
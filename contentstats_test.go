@@ -0,0 +1,59 @@
+package defuddle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadingTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		words          int
+		wordsPerMinute int
+		want           int
+	}{
+		{name: "no words", words: 0, want: 0},
+		{name: "under a minute", words: 10, want: 1},
+		{name: "exact minutes", words: 2 * DefaultWordsPerMinute, want: 2},
+		{name: "rounded up", words: 2*DefaultWordsPerMinute + 1, want: 3},
+		{name: "custom speed", words: 1000, wordsPerMinute: 100, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, readingTime(tt.words, tt.wordsPerMinute))
+		})
+	}
+}
+
+func TestParseReportsReadingTimeAndContentStats(t *testing.T) {
+	t.Parallel()
+
+	paragraph := `<p>` + strings.Repeat("The council published the transit plan after a year of hearings. ", 10) + `<a href="/plan">Read the plan</a>.</p>`
+	html := `<html><body><article><h1>Transit plan</h1>` +
+		strings.Repeat(paragraph, 6) +
+		`<h2>Timetable</h2><p> </p><figure><img src="/map.png" alt="Route map"></figure>` +
+		`<pre><code>route 7: 06:00-23:00</code></pre></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	require.NotNil(t, result.Stats)
+	assert.Equal(t, 6, result.Stats.Paragraphs)
+	assert.Equal(t, 2, result.Stats.Headings)
+	assert.Equal(t, 1, result.Stats.Images)
+	assert.Equal(t, 6, result.Stats.Links)
+	assert.Equal(t, 1, result.Stats.CodeBlocks)
+	assert.Positive(t, result.Stats.NodeCount)
+	assert.Equal(t, readingTime(result.WordCount, DefaultWordsPerMinute), result.ReadingTime)
+
+	slow, err := ParseFromString(context.Background(), html, &Options{WordsPerMinute: 50})
+	require.NoError(t, err)
+	assert.Equal(t, (result.WordCount+49)/50, slow.ReadingTime)
+}
@@ -3,17 +3,26 @@ package defuddle
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-json-experiment/json"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 	"golang.org/x/net/html/charset"
 
 	"github.com/PuerkitoBio/goquery"
@@ -23,10 +32,18 @@ import (
 	"github.com/kaptinlin/defuddle-go/extractors"
 	"github.com/kaptinlin/defuddle-go/internal/constants"
 	"github.com/kaptinlin/defuddle-go/internal/debug"
+	"github.com/kaptinlin/defuddle-go/internal/elements"
+	"github.com/kaptinlin/defuddle-go/internal/jsonld"
+	"github.com/kaptinlin/defuddle-go/internal/langdetect"
 	"github.com/kaptinlin/defuddle-go/internal/markdown"
 	"github.com/kaptinlin/defuddle-go/internal/metadata"
+	"github.com/kaptinlin/defuddle-go/internal/nlp"
+	"github.com/kaptinlin/defuddle-go/internal/sanitize"
 	"github.com/kaptinlin/defuddle-go/internal/scoring"
 	"github.com/kaptinlin/defuddle-go/internal/standardize"
+	"github.com/kaptinlin/defuddle-go/internal/typography"
+	"github.com/kaptinlin/defuddle-go/internal/webarchive"
+	"github.com/kaptinlin/defuddle-go/metrics"
 )
 
 // Pre-compiled regex patterns for JSON-LD content cleaning.
@@ -42,6 +59,15 @@ var (
 // ErrHTTPStatus indicates that ParseFromURL received an HTTP error status.
 var ErrHTTPStatus = errors.New("unexpected HTTP status")
 
+// ErrResponseTooLarge indicates that a response body exceeded
+// FetchOptions.MaxBodySize.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ErrFileOutsideRoot indicates that ParseFromURL was given a file:// URL of a
+// file outside Options.FileRoot, or on another host, or that FileRoot is
+// not set.
+var ErrFileOutsideRoot = errors.New("file outside file root")
+
 // HTTPStatusError reports the non-success HTTP response returned by ParseFromURL.
 type HTTPStatusError struct {
 	// URL is the fetched URL that returned the status.
@@ -70,13 +96,276 @@ func (e *HTTPStatusError) Unwrap() error {
 	return ErrHTTPStatus
 }
 
+// ErrDisallowedByRobots indicates that FetchOptions.RespectRobots kept a URL
+// from being fetched.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// RobotsDisallowedError reports a URL that the site's robots.txt disallows
+// for the client's user agent.
+type RobotsDisallowedError struct {
+	// URL is the disallowed URL.
+	URL string
+
+	// Rule is the Disallow pattern that matched, or "*" when robots.txt
+	// could not be fetched because of a server error.
+	Rule string
+}
+
+// Error returns a readable robots.txt failure message.
+func (e *RobotsDisallowedError) Error() string {
+	return fmt.Sprintf("%s: %s matches %q", ErrDisallowedByRobots, e.URL, e.Rule)
+}
+
+// Unwrap returns ErrDisallowedByRobots for errors.Is checks.
+func (e *RobotsDisallowedError) Unwrap() error {
+	return ErrDisallowedByRobots
+}
+
+// ErrUnsupportedScoringStrategy indicates that Options.ScoringStrategy names
+// no known strategy.
+var ErrUnsupportedScoringStrategy = errors.New("unsupported scoring strategy")
+
+// ErrUnsupportedRetryStrategy indicates that Options.RetryStrategy names no
+// known strategy.
+var ErrUnsupportedRetryStrategy = errors.New("unsupported retry strategy")
+
+// ErrUnsupportedSchemaOrgMode indicates that Options.SchemaOrgMode names no
+// known mode.
+var ErrUnsupportedSchemaOrgMode = errors.New("unsupported schema.org mode")
+
+// ErrUnsupportedPrefilterMode indicates that Options.Prefilter names no
+// known mode.
+var ErrUnsupportedPrefilterMode = errors.New("unsupported prefilter mode")
+
+// ErrUnsupportedQuoteStyle indicates that Options.Typography.Quotes names no
+// known style.
+var ErrUnsupportedQuoteStyle = errors.New("unsupported quote style")
+
+// ErrUnsupportedArchiveFormat indicates that ParseFromArchive was given no
+// known ArchiveFormat.
+var ErrUnsupportedArchiveFormat = errors.New("unsupported archive format")
+
+// ErrNoArchiveDocument indicates that the archive read by ParseFromArchive
+// holds no HTML document to parse.
+var ErrNoArchiveDocument = webarchive.ErrNoDocument
+
+// ErrNoSiteResults indicates that AggregateSite was given no results.
+var ErrNoSiteResults = errors.New("no results to aggregate")
+
+// ErrMixedDomains indicates that AggregateSite was given results from more
+// than one domain.
+var ErrMixedDomains = errors.New("results from more than one domain")
+
+// ErrMemoryLimit indicates that a parse exceeded Options.Limits.MaxMemoryBytes.
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+// MemoryLimitError reports the memory estimate that exceeded Options.Limits.MaxMemoryBytes.
+type MemoryLimitError struct {
+	// Stage is the parse step at which the limit was exceeded: "input",
+	// "document", "extractor", "content", or "markdown".
+	Stage string
+
+	// Estimate is the estimated memory in use, in bytes.
+	Estimate int64
+
+	// Limit is the configured MaxMemoryBytes.
+	Limit int64
+}
+
+// Error returns a readable memory limit failure message.
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("%s: estimated %d bytes at %s stage, limit %d", ErrMemoryLimit, e.Estimate, e.Stage, e.Limit)
+}
+
+// Unwrap returns ErrMemoryLimit for errors.Is checks.
+func (e *MemoryLimitError) Unwrap() error {
+	return ErrMemoryLimit
+}
+
+// ErrClientRenderedPage indicates that the HTML is the empty shell of a page
+// that scripts build in the browser, so there is no content to extract
+// without rendering it.
+var ErrClientRenderedPage = errors.New("client-rendered page")
+
+// ClientRenderedPageError describes the empty application shell Parse found
+// in place of content. Options.Renderer, when set, is used instead of
+// returning it.
+type ClientRenderedPageError struct {
+	// URL is Options.URL, if set.
+	URL string
+
+	// Mount is the empty element the application mounts into, such as
+	// "div#root".
+	Mount string
+
+	// TextLength is the number of characters of visible body text.
+	TextLength int
+
+	// ScriptBytes is the size of the inline scripts, in bytes.
+	ScriptBytes int
+
+	// ExternalScripts is the number of scripts loaded by src.
+	ExternalScripts int
+}
+
+// Error returns a readable client-rendered page failure message.
+func (e *ClientRenderedPageError) Error() string {
+	target := ""
+	if e.URL != "" {
+		target = " for " + e.URL
+	}
+	return fmt.Sprintf("%s%s: empty %s with %d characters of text, %d bytes of inline script, and %d external scripts",
+		ErrClientRenderedPage, target, e.Mount, e.TextLength, e.ScriptBytes, e.ExternalScripts)
+}
+
+// Unwrap returns ErrClientRenderedPage for errors.Is checks.
+func (e *ClientRenderedPageError) Unwrap() error {
+	return ErrClientRenderedPage
+}
+
+// estimatedNodeBytes approximates the heap size of one parsed html.Node,
+// including its attribute slice and goquery bookkeeping.
+const estimatedNodeBytes = 256
+
+// memoryUsage estimates the memory held by one parse. The estimate is the
+// parsed document, counted as the input HTML plus estimatedNodeBytes per
+// node, plus whatever buffers the current stage has built from it.
+type memoryUsage struct {
+	limit    int64
+	nodes    int
+	document int64
+	peak     int64
+}
+
+// observe records the document estimate plus extra buffer bytes, failing
+// with a *MemoryLimitError when the total exceeds the limit.
+func (m *memoryUsage) observe(stage string, extra int) error {
+	estimate := m.document + int64(extra)
+	m.peak = max(m.peak, estimate)
+	if m.limit > 0 && estimate > m.limit {
+		return &MemoryLimitError{Stage: stage, Estimate: estimate, Limit: m.limit}
+	}
+	return nil
+}
+
+// stats returns the usage as Result.Stats.
+func (m *memoryUsage) stats() *Stats {
+	return &Stats{NodeCount: m.nodes, PeakMemoryBytes: m.peak}
+}
+
+// countNodes returns the number of nodes in the tree rooted at n.
+func countNodes(n *html.Node) int {
+	count := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		count += countNodes(c)
+	}
+	return count
+}
+
+// truncateTextNodes cuts text nodes longer than the limit, outside scripts
+// and styles, so a megabyte of minified JSON or base64 dumped into the body
+// cannot dominate whitespace cleanup, word counting, and Markdown
+// conversion. It returns an Issue describing the cut, or nil.
+func (d *Defuddle) truncateTextNodes(limits *Limits) []Issue {
+	limit := DefaultMaxTextNodeBytes
+	if limits != nil && limits.MaxTextNodeBytes != 0 {
+		limit = limits.MaxTextNodeBytes
+	}
+	if limit < 0 {
+		return nil
+	}
+
+	var count, longest int
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style) {
+			return
+		}
+		if n.Type == html.TextNode && len(n.Data) > limit {
+			count++
+			longest = max(longest, len(n.Data))
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(n.Data[cut]) {
+				cut--
+			}
+			n.Data = n.Data[:cut] + "…"
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range d.doc.Nodes {
+		walk(n)
+	}
+
+	if count == 0 {
+		return nil
+	}
+	return []Issue{{
+		Code:    IssueTextNodeTruncated,
+		Message: fmt.Sprintf("text nodes longer than %d bytes were truncated: %d cut, the longest %d bytes", limit, count, longest),
+	}}
+}
+
+// assetSelector matches the elements stripAssets removes
+var assetSelector = strings.Join(constants.AssetSelectors, ", ")
+
+// assetTags are the opening tags that may hold an asset element, checked
+// before parsing extractor output
+var assetTags = []string{"<noscript", "<script", "<style", "<template", "<link"}
+
+// stripAssets removes scripts, styles, templates, and links within sel
+func stripAssets(sel *goquery.Selection) {
+	sel.Find(assetSelector).Remove()
+}
+
+// stripAssetsHTML removes scripts, styles, templates, and links from an
+// HTML fragment. Fragments without any are returned unchanged.
+func stripAssetsHTML(content string) string {
+	lower := strings.ToLower(content)
+	if !slices.ContainsFunc(assetTags, func(tag string) bool { return strings.Contains(lower, tag) }) {
+		return content
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return content
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	sel := goquery.NewDocumentFromNode(body).Selection
+	stripAssets(sel)
+	stripped, err := sel.Html()
+	if err != nil {
+		return content
+	}
+	return stripped
+}
+
 // Defuddle represents a document parser instance
 type Defuddle struct {
-	doc      *goquery.Document
-	html     string
+	doc  *goquery.Document
+	html string
+	// pristine is an unmodified copy of the document for the retry pass,
+	// kept when the input was read from a stream instead of html.
+	pristine *html.Node
+	// digest is the SHA-256 of streamed input, set when caching is enabled.
+	digest   []byte
 	options  *Options
 	debug    bool
 	debugger *debug.Debugger
+	memory   memoryUsage
+	// droppedScriptBytes is the inline script the prefilter dropped, and
+	// inputIssues the issues it recorded, both carried to a fork.
+	droppedScriptBytes int
+	inputIssues        []Issue
+	// fetchedAt is when ParseFromURL received the page.
+	fetchedAt time.Time
+	// altText caches ImageOptions.AltTextProvider results across parses
+	// by this parser and its forks.
+	altText *elements.AltTextCache
 }
 
 // NewDefuddle creates a new Defuddle instance from HTML content
@@ -87,24 +376,66 @@ type Defuddle struct {
 //	  this.options = options;
 //	}
 func NewDefuddle(html string, options *Options) (*Defuddle, error) {
+	prefilter, err := prefilterEnabled(options, false)
+	if err != nil {
+		return nil, err
+	}
+	return newDefuddleFromHTML(html, options, prefilter)
+}
+
+// newDefuddleFromHTML creates a parser from html, prefiltering it first
+// when prefilter is set.
+func newDefuddleFromHTML(html string, options *Options, prefilter bool) (*Defuddle, error) {
+	var memory memoryUsage
+	if options != nil && options.Limits != nil {
+		memory.limit = options.Limits.MaxMemoryBytes
+	}
+	// The input alone over the limit means parsing it would be too
+	if err := memory.observe("input", len(html)); err != nil {
+		return nil, err
+	}
+
+	var stats prefilterStats
+	if prefilter {
+		var err error
+		if html, stats, err = prefilterString(html, prefilterMaxBytes(options)); err != nil {
+			return nil, err
+		}
+	}
+
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
+	for _, root := range doc.Nodes {
+		memory.nodes += countNodes(root)
+	}
+	memory.document = int64(len(html)) + int64(memory.nodes)*estimatedNodeBytes
+	if err := memory.observe("document", 0); err != nil {
+		return nil, err
+	}
+
+	d := newDefuddle(doc, options, memory)
+	d.html = html
+	d.prefiltered(stats, prefilterMaxBytes(options))
+	return d, nil
+}
+
+// newDefuddle creates a parser for an already parsed document.
+func newDefuddle(doc *goquery.Document, options *Options, memory memoryUsage) *Defuddle {
 	debugEnabled := false
 	if options != nil {
 		debugEnabled = options.Debug
 	}
-	debugger := debug.NewDebugger(debugEnabled)
-
 	return &Defuddle{
 		doc:      doc,
-		html:     html,
 		options:  options,
 		debug:    debugEnabled,
-		debugger: debugger,
-	}, nil
+		debugger: debug.NewDebugger(debugEnabled),
+		memory:   memory,
+		altText:  elements.NewAltTextCache(),
+	}
 }
 
 // Parse extracts content from the configured document and returns a normalized result.
@@ -130,30 +461,161 @@ func NewDefuddle(html string, options *Options) (*Defuddle, error) {
 //
 //	  return result;
 //	}
-func (d *Defuddle) Parse(ctx context.Context) (*Result, error) {
+func (d *Defuddle) Parse(ctx context.Context) (result *Result, err error) {
+	var cached, retried bool
+	if d.options != nil && d.options.Metrics != nil {
+		collector := d.options.Metrics
+		start := time.Now()
+		collector.ParseStarted()
+		defer func() {
+			duration := time.Since(start)
+			collector.ParseFinished(duration, err)
+			if reporter, ok := collector.(metrics.Reporter); ok && !cached {
+				reporter.ParseReported(d.parseReport(result, duration, retried, err))
+			}
+		}()
+	}
+
+	if d.options != nil && d.options.Cache != nil {
+		keyOptions := d.mergeOptions(nil)
+		// The same HTML fetched at another time normalizes to other dates
+		if keyOptions.NormalizeTimes {
+			keyOptions.FetchTime = d.referenceTime(keyOptions)
+		}
+		key, ok := cacheKey(d.inputDigest(), keyOptions)
+		if ok {
+			if hit, ok := d.options.Cache.Get(ctx, key); ok {
+				if d.debug {
+					slog.Debug("Returning cached result", "key", key)
+				}
+				cached = true
+				return hit, nil
+			}
+			defer func() {
+				if err == nil {
+					d.options.Cache.Set(ctx, key, result)
+				}
+			}()
+		}
+	}
+
+	switch strategy := d.mergeOptions(nil).RetryStrategy; strategy {
+	case "", RetryNone, RetryRelaxPartialSelectors, RetryRelaxAll:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedRetryStrategy, strategy)
+	}
+
+	// The document as given, before parsing changes it, shows a shell, a
+	// redirect, or an interstitial
+	var shell *ClientRenderedPageError
+	var interstitial *Interstitial
+	redirect, amp := "", ""
+	if len(d.doc.Nodes) > 0 {
+		shell = detectClientRendered(d.doc.Nodes[0], d.droppedScriptBytes)
+		redirect = clientRedirect(d.doc.Nodes[0], d.mergeOptions(nil).URL)
+		amp = ampURL(d.doc.Nodes[0], d.mergeOptions(nil).URL)
+		interstitial = detectInterstitial(d.doc.Nodes[0])
+	}
+
+	result, retried, err = d.parseWithRetry(ctx)
+	if err != nil {
+		return result, err
+	}
+	options := d.mergeOptions(nil)
+	if options.RecoverFromStructuredData {
+		if err := d.recoverArticleBody(ctx, result, options); err != nil {
+			return nil, err
+		}
+	}
+	if options.KeywordExtractor != nil {
+		mergeDeclaredKeywords(result, options.MaxKeywords)
+	}
+	if result.Language == "" {
+		result.Language = langdetect.Detect(strings.Join(nlp.Blocks(result.Content), "\n"))
+	}
+	describeContent(result, options.WordsPerMinute)
+	sparse := result.WordCount < cmp.Or(options.MinContentWords, DefaultMinContentWords)
+	if shell != nil && sparse {
+		return d.renderShell(ctx, shell)
+	}
+	result.ClientRedirect = redirect
+	result.AMPURL = amp
+	if result.Interstitial = interstitialAt(options.URL); result.Interstitial == nil && sparse {
+		result.Interstitial = interstitial
+	}
+	return result, nil
+}
+
+// canExtract reports whether extractor handles the page, passing ctx to a
+// extractors.ContextExtractor. Why one failed is logged in debug mode and
+// added to issues as IssueExtractorFailed.
+func (d *Defuddle) canExtract(ctx context.Context, extractor extractors.BaseExtractor, issues *[]Issue) bool {
+	withContext, ok := extractor.(extractors.ContextExtractor)
+	if !ok {
+		return extractor.CanExtract()
+	}
+	if withContext.CanExtractContext(ctx) {
+		return true
+	}
+	if err := withContext.Err(); err != nil {
+		if d.debug {
+			slog.Debug("Extractor failed, using generic extraction", "extractor", extractor.Name(), "error", err)
+		}
+		*issues = append(*issues, Issue{Code: IssueExtractorFailed, Message: extractor.Name() + ": " + err.Error()})
+	}
+	return false
+}
+
+// altTextIssues returns one IssueAltTextFailed per image in the error
+// elements.GenerateAltText returned.
+func altTextIssues(err error) []Issue {
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	issues := make([]Issue, 0, len(errs))
+	for _, failure := range errs {
+		issues = append(issues, Issue{Code: IssueAltTextFailed, Message: failure.Error()})
+	}
+	return issues
+}
+
+// parseWithRetry parses the document and, when that finds very little
+// content, parses it again with relaxed clutter removal as
+// Options.RetryStrategy allows, returning the result with more content.
+func (d *Defuddle) parseWithRetry(ctx context.Context) (result *Result, retried bool, err error) {
 	// Try first with default settings
-	result, err := d.parseInternal(ctx, nil)
+	result, err = d.parseInternal(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// If result has very little content, try again without clutter removal
-	if result.WordCount < 200 {
+	options := d.mergeOptions(nil)
+	minWords := cmp.Or(options.MinContentWords, DefaultMinContentWords)
+	if options.RetryStrategy != RetryNone && result.WordCount < minWords {
 		if d.debug {
-			slog.Debug("Initial parse returned very little content, trying again")
+			slog.Debug("Initial parse returned very little content, trying again", "strategy", options.RetryStrategy)
 		}
 
+		retried = true
 		retryOptions := d.mergeOptions(nil)
 		retryOptions.RemovePartialSelectors = false
+		if options.RetryStrategy == RetryRelaxAll {
+			retryOptions.RemoveExactSelectors = false
+		}
 
-		retryParser, retryCreateErr := NewDefuddle(d.html, retryOptions)
+		retryParser, retryCreateErr := d.fork(retryOptions)
 		if retryCreateErr != nil {
-			return result, retryCreateErr
+			return result, retried, retryCreateErr
 		}
 
 		retryResult, retryErr := retryParser.parseInternal(ctx, nil)
 		if retryErr != nil {
-			return result, retryErr
+			return result, retried, retryErr
 		}
 
 		// Return the result with more content
@@ -161,74 +623,203 @@ func (d *Defuddle) Parse(ctx context.Context) (*Result, error) {
 			if d.debug {
 				slog.Debug("Retry produced more content", "originalWordCount", result.WordCount, "retryWordCount", retryResult.WordCount)
 			}
-			return retryResult, nil
+			return retryResult, retried, nil
 		}
 	}
 
-	return result, nil
+	return result, retried, nil
 }
 
-// ParseFromURL fetches content from a URL and parses it
+// parseReport describes a finished Parse for metrics.Reporter collectors.
+func (d *Defuddle) parseReport(result *Result, duration time.Duration, retried bool, err error) metrics.ParseReport {
+	report := metrics.ParseReport{Duration: duration, Retried: retried, Err: err}
+	if result != nil {
+		report.Domain = result.Domain
+		report.WordCount = result.WordCount
+		if result.ExtractorType != nil {
+			report.Extractor = *result.ExtractorType
+		}
+	}
+	if report.Domain == "" && d.options.URL != "" {
+		if u, parseErr := url.Parse(d.options.URL); parseErr == nil {
+			report.Domain = strings.TrimPrefix(u.Hostname(), "www.")
+		}
+	}
+	return report
+}
+
+// ParseFromURL fetches content from a URL and parses it. With
+// Options.Cache, a result stored for the same URL and options is returned
+// without fetching the page. The caller's options are not modified.
 // JavaScript original code:
 // // This corresponds to Node.js usage: Defuddle(htmlOrDom, url?, options?)
-func ParseFromURL(ctx context.Context, url string, options *Options) (*Result, error) {
+func ParseFromURL(ctx context.Context, url string, options *Options) (result *Result, err error) {
 	useResponseURL := options == nil || options.URL == ""
 	if options == nil {
-		options = &Options{
-			URL:                    url,
-			RemoveExactSelectors:   true,
-			RemovePartialSelectors: true,
-		}
-	} else if options.URL == "" {
-		// Set URL in options if not already set.
+		options = DefaultOptions()
+	} else {
+		// A shallow copy, so the page URLs set below never reach the
+		// caller's options
+		copied := *options
+		options = &copied
+	}
+	if options.URL == "" {
 		options.URL = url
 	}
 
+	if options.Cache != nil {
+		if key, ok := cacheKey(urlDigest(url), options); ok {
+			if hit, ok := options.Cache.Get(ctx, key); ok {
+				if options.Debug {
+					slog.Debug("Returning cached result", "url", url, "key", key)
+				}
+				return hit, nil
+			}
+			defer func() {
+				if err == nil {
+					options.Cache.Set(ctx, key, result)
+				}
+			}()
+		}
+	}
+
+	prefilter, err := prefilterEnabled(options, true)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create HTTP client and make request
 	client := options.Client
 	if client == nil {
-		client = requests.New(
-			requests.WithUserAgent("Mozilla/5.0 (compatible; Defuddle/1.0; +https://github.com/kaptinlin/defuddle-go)"),
-			requests.WithTimeout(30*time.Second),
-		)
+		var err error
+		if client, err = NewFetchClient(options.Fetch); err != nil {
+			return nil, err
+		}
+	}
+
+	pageURL := url
+	seen := map[string]bool{}
+	var cookies map[string]string
+	for hops := 0; ; {
+		requestURL := pageURL
+		html, responseURL, err := fetchHTML(ctx, client, pageURL, cookies, options)
+		if err != nil {
+			return nil, err
+		}
+		fetchedAt := time.Now()
+		if responseURL != "" {
+			pageURL = responseURL
+		}
+		seen[pageURL] = true
+		// An explicit URL names the page asked for, not the one a client
+		// redirect led to
+		if useResponseURL || hops > 0 {
+			options.URL = pageURL
+		}
+
+		// Create Defuddle instance and parse
+		defuddle, err := newDefuddleFromHTML(html, options, prefilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Defuddle instance: %w", err)
+		}
+		defuddle.fetchedAt = fetchedAt
+
+		// Follow a meta refresh or script redirect to the page it names
+		if hops < options.MaxClientRedirects && len(defuddle.doc.Nodes) > 0 {
+			if target := clientRedirect(defuddle.doc.Nodes[0], pageURL); target != "" && !seen[target] {
+				if defuddle.debug {
+					slog.Debug("Following client-side redirect", "from", pageURL, "to", target)
+				}
+				pageURL = target
+				hops++
+				continue
+			}
+		}
+
+		// Parse the AMP version instead when the page links one. Options.URL
+		// stays the page's, so metadata names the article, not its AMP copy
+		amp := ""
+		if options.PreferAMP && len(defuddle.doc.Nodes) > 0 {
+			if target := ampURL(defuddle.doc.Nodes[0], pageURL); target != "" && !seen[target] {
+				if defuddle.debug {
+					slog.Debug("Fetching AMP version", "from", pageURL, "to", target)
+				}
+				if ampDefuddle, err := fetchAMP(ctx, client, target, cookies, options, prefilter); err == nil {
+					defuddle, amp = ampDefuddle, target
+				} else if defuddle.debug {
+					slog.Debug("Failed to fetch AMP version", "url", target, "error", err)
+				}
+			}
+		}
+
+		result, err := defuddle.Parse(ctx)
+		if err == nil && amp != "" {
+			// A copy, as Parse may have returned or cached a shared result
+			withAMP := *result
+			withAMP.AMPURL = amp
+			result = &withAMP
+		}
+		if err != nil || !options.ConsentCookies || cookies != nil || result.Interstitial == nil {
+			return result, err
+		}
+		preset := consentCookies[result.Interstitial.Vendor]
+		if preset == nil {
+			return result, nil
+		}
+		// Ask again as a visitor who already consented
+		if defuddle.debug {
+			slog.Debug("Fetching consent wall again with consent cookies", "url", requestURL, "vendor", result.Interstitial.Vendor)
+		}
+		cookies = preset
+		pageURL = requestURL
+	}
+}
+
+// fetchHTML fetches url with client, sending cookies, and returns the
+// decoded page and the URL it was served from after HTTP redirects.
+func fetchHTML(ctx context.Context, client *requests.Client, url string, cookies map[string]string, options *Options) (html, responseURL string, err error) {
+	if isFileURL(url) {
+		return readFileURL(url, options)
 	}
-	resp, err := client.Get(url).Send(ctx)
+	fetchStart := time.Now()
+	resp, err := client.Get(url).Cookies(cookies).Send(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL %s: %w", url, err)
+		err = fmt.Errorf("failed to fetch URL %s: %w", url, err)
+		if options.Metrics != nil {
+			options.Metrics.FetchFinished(time.Since(fetchStart), 0, err)
+		}
+		return "", "", err
 	}
 	defer func() {
 		if closeErr := resp.Close(); closeErr != nil {
 			slog.Warn("Failed to close response", "error", closeErr)
 		}
 	}()
-	responseURL := responseURLString(resp)
+	responseURL = responseURLString(resp)
 	if resp.IsError() {
 		statusURL := url
 		if responseURL != "" {
 			statusURL = responseURL
 		}
-		return nil, &HTTPStatusError{
+		statusErr := &HTTPStatusError{
 			URL:        statusURL,
 			Status:     resp.Status(),
 			StatusCode: resp.StatusCode(),
 		}
+		if options.Metrics != nil {
+			options.Metrics.FetchFinished(time.Since(fetchStart), resp.StatusCode(), statusErr)
+		}
+		return "", "", statusErr
 	}
-	if useResponseURL && responseURL != "" {
-		options.URL = responseURL
+	if options.Metrics != nil {
+		options.Metrics.FetchFinished(time.Since(fetchStart), resp.StatusCode(), nil)
 	}
 
-	html, err := decodeResponseHTML(resp)
+	html, err = decodeResponseHTML(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read URL %s: %w", url, err)
+		return "", "", fmt.Errorf("failed to read URL %s: %w", url, err)
 	}
-
-	// Create Defuddle instance and parse
-	defuddle, err := NewDefuddle(html, options)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Defuddle instance: %w", err)
-	}
-
-	return defuddle.Parse(ctx)
+	return html, responseURL, nil
 }
 
 func responseURLString(resp *requests.Response) string {
@@ -239,12 +830,17 @@ func responseURLString(resp *requests.Response) string {
 }
 
 func decodeResponseHTML(resp *requests.Response) (string, error) {
-	body := resp.Body()
+	return decodeHTML(resp.Body(), resp.ContentType())
+}
+
+// decodeHTML decodes body to UTF-8 from the charset named by contentType,
+// a byte order mark, or a meta tag, or else the one it looks like.
+func decodeHTML(body []byte, contentType string) (string, error) {
 	if len(body) == 0 {
 		return "", nil
 	}
 
-	reader, err := charset.NewReader(bytes.NewReader(body), resp.ContentType())
+	reader, err := charset.NewReader(bytes.NewReader(body), contentType)
 	if err != nil {
 		return "", fmt.Errorf("detect response charset: %w", err)
 	}
@@ -390,14 +986,37 @@ func ParseFromString(ctx context.Context, html string, options *Options) (*Resul
 //	    };
 //	  }
 //	}
-func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*Result, error) {
+func (d *Defuddle) parseInternal(ctx context.Context, overrideOptions *Options) (*Result, error) {
 	startTime := time.Now()
 
 	// Merge options with defaults
 	options := d.mergeOptions(overrideOptions)
+	switch options.ScoringStrategy {
+	case "", ScoringDefuddle, ScoringReadability:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedScoringStrategy, options.ScoringStrategy)
+	}
+	switch options.SchemaOrgMode {
+	case "", SchemaOrgNormalized, SchemaOrgRaw:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedSchemaOrgMode, options.SchemaOrgMode)
+	}
+	if options.Typography != nil && !options.Typography.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedQuoteStyle, options.Typography.Quotes)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Cut absurdly long text nodes before anything scans them
+	issues := append(slices.Clone(d.inputIssues), d.truncateTextNodes(options.Limits)...)
+
+	// Number the elements so content blocks can be traced to their source
+	sources := d.stampSources(options.SourceMap)
+	var sourceMap []SourceRange
 
 	// Extract schema.org data
-	schemaOrgData := d.extractSchemaOrgData()
+	schemaOrgData := d.extractSchemaOrgData(options)
 
 	// Collect meta tags
 	metaTags := d.collectMetaTags()
@@ -407,6 +1026,14 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 
 	// Extract metadata
 	extractedMetadata := metadata.Extract(d.doc, schemaOrgData, metaTags, baseURL)
+	series := metadata.ExtractSeries(d.doc, schemaOrgData, extractedMetadata.Title, baseURL)
+	authorBio := metadata.SchemaAuthorBio(schemaOrgData)
+	corrections := metadata.SchemaCorrections(schemaOrgData)
+	audio := cmp.Or(metadata.ExtractAudioVersion(d.doc, schemaOrgData, metaTags, baseURL), &metadata.AudioVersion{})
+	observeStage(options, metrics.StageMetadata, startTime)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Initialize debug tracking
 	if d.debugger.IsEnabled() {
@@ -418,10 +1045,32 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 
 	// Try site-specific extractor first, if there is one
 	url := options.URL
-	extractor := extractors.FindExtractor(d.doc, url, schemaOrgData)
-	if extractor != nil && extractor.CanExtract() {
+	extractorStart := time.Now()
+	registry := options.Extractors
+	if registry == nil {
+		registry = extractors.DefaultRegistry
+	}
+	extractor := registry.FindExtractor(d.doc, url, schemaOrgData)
+	if extractor != nil && d.canExtract(ctx, extractor, &issues) {
 		d.debugger.SetExtractorUsed(extractor.Name())
 		extracted := extractor.Extract()
+		extracted.ContentHTML = stripAssetsHTML(extracted.ContentHTML)
+		if options.SourceMap {
+			extracted.ContentHTML, sourceMap = collectSourceMap(extracted.ContentHTML, sources, -1)
+		}
+		extracted.ContentHTML = typography.HTML(extracted.ContentHTML, options.Typography)
+		if options.Sanitize {
+			extracted.ContentHTML = sanitize.HTML(extracted.ContentHTML)
+		}
+		var toc []TOCEntry
+		extracted.ContentHTML, toc = outline(extracted.ContentHTML, options)
+		if err := d.memory.observe("extractor", len(extracted.Content)+len(extracted.ContentHTML)); err != nil {
+			return nil, err
+		}
+		if options.Metrics != nil {
+			options.Metrics.ExtractorHit(extractor.Name())
+		}
+		observeStage(options, metrics.StageExtractor, extractorStart)
 		parseTime := time.Since(startTime).Milliseconds()
 
 		// Get site name from extractor variables or use metadata
@@ -437,24 +1086,40 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 
 		result := &Result{
 			Metadata: Metadata{
-				Title:         extractedMetadata.Title,
-				Description:   extractedMetadata.Description,
-				Domain:        extractedMetadata.Domain,
-				Favicon:       extractedMetadata.Favicon,
-				Image:         extractedMetadata.Image,
-				ParseTime:     parseTime,
-				Published:     extractedMetadata.Published,
-				Author:        extractedMetadata.Author,
-				Site:          siteName,
-				SchemaOrgData: schemaOrgData,
-				WordCount:     d.countWords(extracted.ContentHTML),
+				Title:           extractedMetadata.Title,
+				Description:     extractedMetadata.Description,
+				Domain:          extractedMetadata.Domain,
+				Favicon:         extractedMetadata.Favicon,
+				Image:           extractedMetadata.Image,
+				ParseTime:       parseTime,
+				Published:       extractedMetadata.Published,
+				PublishedSource: extractedMetadata.PublishedSource,
+				Modified:        extractedMetadata.Modified,
+				Author:          extractedMetadata.Author,
+				Site:            siteName,
+				Section:         extractedMetadata.Section,
+				Tags:            extractedMetadata.Tags,
+				Language:        extractedMetadata.Language,
+				SchemaOrgData:   schemaOrgData,
+				WordCount:       d.countWords(extracted.ContentHTML),
 			},
-			Content:       extracted.ContentHTML,
-			ExtractorType: &extractorType,
-			MetaTags:      metaTags,
+			Content:         extracted.ContentHTML,
+			ExtractorType:   &extractorType,
+			MetaTags:        metaTags,
+			Series:          series,
+			AuthorBio:       authorBio,
+			Corrections:     corrections,
+			AudioVersionURL: audio.URL,
+			AudioDuration:   audio.Duration,
+			SourceMap:       sourceMap,
+			Issues:          issues,
+			TOC:             toc,
 		}
 
 		// Override metadata from extractor if available
+		if len(extracted.Variables) > 0 {
+			result.Variables = ExtractorVariables(maps.Clone(extracted.Variables))
+		}
 		if extracted.Variables != nil {
 			if title, exists := extracted.Variables["title"]; exists && title != "" {
 				result.Title = title
@@ -464,6 +1129,7 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 			}
 			if published, exists := extracted.Variables["published"]; exists && published != "" {
 				result.Published = published
+				result.PublishedSource = "extractor"
 			}
 			if description, exists := extracted.Variables["description"]; exists && description != "" {
 				result.Description = description
@@ -474,12 +1140,22 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 		}
 
 		if options.Markdown || options.SeparateMarkdown {
-			if markdownContent, err := d.convertHTMLToMarkdown(result.Content); err == nil {
+			markdownStart := time.Now()
+			if markdownContent, err := d.convertHTMLToMarkdown(result.Content, options.MarkdownOptions); err == nil {
 				result.ContentMarkdown = &markdownContent
 			} else if d.debug {
 				slog.Debug("Failed to convert extractor content to Markdown", "error", err)
 			}
+			observeStage(options, metrics.StageMarkdown, markdownStart)
+			if err := d.memory.observe("markdown", markdownBufferBytes(result.Content, result.ContentMarkdown)); err != nil {
+				return nil, err
+			}
 		}
+		result.Stats = d.memory.stats()
+
+		result.Summary = d.summarize(ctx, options, result.Content)
+		result.Keywords = d.extractKeywords(ctx, options, result.Content)
+		result.Images = contentImages(result.Content)
 
 		// Add debug info if enabled
 		if d.debugger.IsEnabled() {
@@ -488,10 +1164,12 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 			result.DebugInfo = d.debugger.GetInfo()
 		}
 
+		typesetMetadata(result, options.Typography)
 		return result, nil
 	}
 
 	// Evaluate mobile styles and sizes on original document
+	selectionStart := time.Now()
 	mobileStyles := d.evaluateMediaQueries()
 
 	// Find small images in original document, excluding lazy-loaded ones
@@ -504,30 +1182,79 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 	// Apply mobile styles to document
 	d.applyMobileStyles(workingDoc, mobileStyles)
 
-	// Find main content
-	mainContent := d.findMainContent(workingDoc)
+	// Find main content, or use the whole body when selection is skipped
+	var mainContent *goquery.Selection
+	if options.SkipContentSelection {
+		if body := workingDoc.Find("body").First(); body.Length() > 0 {
+			mainContent = body
+		}
+	} else if options.ScoringStrategy == ScoringReadability {
+		var err error
+		if mainContent, err = scoring.FindReadabilityCandidate(ctx, workingDoc); err != nil {
+			return nil, err
+		}
+		if mainContent == nil {
+			mainContent = workingDoc.Find("body").First()
+		}
+	} else {
+		mainContent = d.findMainContent(workingDoc)
+	}
+	observeStage(options, metrics.StageSelection, selectionStart)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if mainContent == nil {
-		// Fallback to body content
-		content, _ := d.doc.Find("body").Html()
+		// Fallback to body content, without its scripts and styles
+		body := d.doc.Find("body")
+		stripAssets(body)
+		content, _ := body.Html()
+		if options.SourceMap {
+			content, sourceMap = collectSourceMap(content, sources, sourceStamp(body.Get(0)))
+		}
+		content = typography.HTML(content, options.Typography)
+		if options.Sanitize {
+			content = sanitize.HTML(content)
+		}
+		content, toc := outline(content, options)
+		if err := d.memory.observe("content", len(content)); err != nil {
+			return nil, err
+		}
 		wordCount := d.countWords(content)
 		parseTime := time.Since(startTime).Milliseconds()
 
 		result := &Result{
 			Metadata: Metadata{
-				Title:         extractedMetadata.Title,
-				Description:   extractedMetadata.Description,
-				Domain:        extractedMetadata.Domain,
-				Favicon:       extractedMetadata.Favicon,
-				Image:         extractedMetadata.Image,
-				ParseTime:     parseTime,
-				Published:     extractedMetadata.Published,
-				Author:        extractedMetadata.Author,
-				Site:          extractedMetadata.Site,
-				SchemaOrgData: schemaOrgData,
-				WordCount:     wordCount,
+				Title:           extractedMetadata.Title,
+				Description:     extractedMetadata.Description,
+				Domain:          extractedMetadata.Domain,
+				Favicon:         extractedMetadata.Favicon,
+				Image:           extractedMetadata.Image,
+				ParseTime:       parseTime,
+				Published:       extractedMetadata.Published,
+				PublishedSource: extractedMetadata.PublishedSource,
+				Modified:        extractedMetadata.Modified,
+				Author:          extractedMetadata.Author,
+				Site:            extractedMetadata.Site,
+				Section:         extractedMetadata.Section,
+				Tags:            extractedMetadata.Tags,
+				Language:        extractedMetadata.Language,
+				SchemaOrgData:   schemaOrgData,
+				WordCount:       wordCount,
 			},
-			Content:  content,
-			MetaTags: metaTags,
+			Content:         content,
+			Images:          contentImages(content),
+			Summary:         d.summarize(ctx, options, content),
+			Keywords:        d.extractKeywords(ctx, options, content),
+			MetaTags:        metaTags,
+			Stats:           d.memory.stats(),
+			Series:          series,
+			AuthorBio:       authorBio,
+			Corrections:     corrections,
+			AudioVersionURL: audio.URL,
+			AudioDuration:   audio.Duration,
+			SourceMap:       sourceMap,
+			Issues:          issues,
+			TOC:             toc,
 		}
 
 		// Add debug info if enabled (fallback case)
@@ -537,10 +1264,43 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 			result.DebugInfo = d.debugger.GetInfo()
 		}
 
+		typesetMetadata(result, options.Typography)
 		return result, nil
 	}
 
+	// Move the author bio box out of the content, before clutter removal
+	// drops it
+	if bio := metadata.FindAuthorBio(mainContent); bio.Length() > 0 {
+		authorBio = metadata.AuthorBioText(bio)
+		bio.Remove()
+	}
+
+	// Collect corrections and editor's notes, ahead of any schema.org ones
+	if found := metadata.FindCorrections(mainContent); found.Length() > 0 {
+		visible := metadata.CorrectionTexts(found)
+		for _, correction := range corrections {
+			if !slices.Contains(visible, correction) {
+				visible = append(visible, correction)
+			}
+		}
+		corrections = visible
+		if options.RemoveCorrections {
+			found.Remove()
+		}
+	}
+
+	// Collect key points and fact boxes while sidebar-like boxes are still
+	// there to find
+	var keyPoints []string
+	if found := metadata.FindKeyPoints(mainContent); found.Length() > 0 {
+		keyPoints = metadata.KeyPointTexts(found)
+		if options.InsertKeyPoints && len(keyPoints) > 0 {
+			found.Remove()
+		}
+	}
+
 	// Remove small images
+	cleanupStart := time.Now()
 	d.removeSmallImages(workingDoc, smallImages)
 
 	// Remove all images if removeImages option is enabled
@@ -551,48 +1311,144 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 	// Remove hidden elements using computed styles
 	d.removeHiddenElements(workingDoc)
 
-	// Remove non-content blocks by scoring
-	scoring.ScoreAndRemove(workingDoc, d.debug)
+	// Remove "Updated on ..." notes, whose date is kept in Result.Modified
+	if options.RemoveUpdateNotes {
+		d.removeUpdateNotes(mainContent, metadata.DocumentLanguage(d.doc, metaTags))
+	}
+
+	// Remove inline forms with their labels, while the controls still mark them
+	if options.Cleanup == nil || options.Cleanup.RemoveInlineForms {
+		if removed := standardize.RemoveInlineForms(mainContent); d.debug && removed > 0 {
+			slog.Debug("Removed inline forms", "count", removed)
+		}
+	}
+
+	// Remove non-content blocks by scoring, unless the whole body is kept
+	if !options.SkipContentSelection {
+		if err := scoring.ScoreAndRemove(ctx, workingDoc, d.debug, scoring.Options{
+			KeepLinkLists: options.KeepLinkLists,
+			Content:       mainContent,
+		}); err != nil {
+			return nil, err
+		}
+	}
 
 	// Remove clutter using selectors
 	if options.RemoveExactSelectors || options.RemovePartialSelectors {
-		d.removeBySelector(workingDoc, options.RemoveExactSelectors, options.RemovePartialSelectors)
+		var keep func(*goquery.Selection) bool
+		if options.KeepLinkLists {
+			keep = func(element *goquery.Selection) bool { return scoring.ContainsLinkList(element, mainContent) }
+		}
+		d.removeBySelector(workingDoc, options.RemoveExactSelectors, options.RemovePartialSelectors, keep)
+	}
+
+	observeStage(options, metrics.StageCleanup, cleanupStart)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	// Normalize the main content
-	standardize.Content(mainContent, extractedMetadata, workingDoc, d.debug)
+	standardizeStart := time.Now()
+	cleanup := options.Cleanup
+	if options.HeadingIDs || options.ImageTargetWidth > 0 {
+		withOptions := *cmp.Or(cleanup, standardize.DefaultCleanupOptions())
+		withOptions.HeadingIDs = withOptions.HeadingIDs || options.HeadingIDs
+		if options.ImageTargetWidth > 0 {
+			withOptions.ImageTargetWidth = options.ImageTargetWidth
+		}
+		cleanup = &withOptions
+	}
+	if err := standardize.Content(ctx, mainContent, extractedMetadata, workingDoc, d.debug, cleanup); err != nil {
+		return nil, err
+	}
+
+	// Fill missing alt text through the configured captioning provider
+	if options.ImageOptions != nil && options.ImageOptions.AltTextProvider != nil {
+		updated, err := elements.GenerateAltText(ctx, mainContent, options.ImageOptions, options.URL, d.altText)
+		if d.debug {
+			slog.Debug("Generated alt text", "images", updated, "error", err)
+		}
+		issues = append(issues, altTextIssues(err)...)
+	}
+	if options.NormalizeTimes {
+		normalizeTimes(mainContent, d.referenceTime(options))
+	}
+	if options.InsertKeyPoints && len(keyPoints) > 0 {
+		mainContent.PrependHtml(keyPointsHTML(keyPoints))
+	}
+	observeStage(options, metrics.StageStandardize, standardizeStart)
 
+	// Scripts and styles never reach the content, whatever the options
+	stripAssets(mainContent)
 	content, _ := mainContent.Html()
+	if options.SourceMap {
+		content, sourceMap = collectSourceMap(content, sources, sourceStamp(mainContent.Get(0)))
+	}
+	content = typography.HTML(content, options.Typography)
+	if options.Sanitize {
+		content = sanitize.HTML(content)
+	}
+	content, toc := outline(content, options)
+	if err := d.memory.observe("content", len(content)); err != nil {
+		return nil, err
+	}
 	wordCount := d.countWords(content)
 	parseTime := time.Since(startTime).Milliseconds()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Convert to Markdown if requested
 	var contentMarkdown *string
 	if options.Markdown || options.SeparateMarkdown {
-		if markdownContent, err := d.convertHTMLToMarkdown(content); err == nil {
+		markdownStart := time.Now()
+		if markdownContent, err := d.convertHTMLToMarkdown(content, options.MarkdownOptions); err == nil {
 			contentMarkdown = &markdownContent
 		} else if d.debug {
 			slog.Debug("Failed to convert to Markdown", "error", err)
 		}
+		observeStage(options, metrics.StageMarkdown, markdownStart)
+		if err := d.memory.observe("markdown", markdownBufferBytes(content, contentMarkdown)); err != nil {
+			return nil, err
+		}
 	}
 
 	result := &Result{
 		Metadata: Metadata{
-			Title:         extractedMetadata.Title,
-			Description:   extractedMetadata.Description,
-			Domain:        extractedMetadata.Domain,
-			Favicon:       extractedMetadata.Favicon,
-			Image:         extractedMetadata.Image,
-			ParseTime:     parseTime,
-			Published:     extractedMetadata.Published,
-			Author:        extractedMetadata.Author,
-			Site:          extractedMetadata.Site,
-			SchemaOrgData: schemaOrgData,
-			WordCount:     wordCount,
+			Title:           extractedMetadata.Title,
+			Description:     extractedMetadata.Description,
+			Domain:          extractedMetadata.Domain,
+			Favicon:         extractedMetadata.Favicon,
+			Image:           extractedMetadata.Image,
+			ParseTime:       parseTime,
+			Published:       extractedMetadata.Published,
+			PublishedSource: extractedMetadata.PublishedSource,
+			Modified:        extractedMetadata.Modified,
+			Author:          extractedMetadata.Author,
+			Site:            extractedMetadata.Site,
+			Section:         extractedMetadata.Section,
+			Tags:            extractedMetadata.Tags,
+			Language:        extractedMetadata.Language,
+			SchemaOrgData:   schemaOrgData,
+			WordCount:       wordCount,
 		},
 		Content:         content,
 		ContentMarkdown: contentMarkdown,
+		Images:          contentImages(content),
+		Summary:         d.summarize(ctx, options, content),
+		Keywords:        d.extractKeywords(ctx, options, content),
 		MetaTags:        metaTags,
+		Stats:           d.memory.stats(),
+		Series:          series,
+		AuthorBio:       authorBio,
+		Corrections:     corrections,
+		KeyPoints:       keyPoints,
+		AudioVersionURL: audio.URL,
+		AudioDuration:   audio.Duration,
+		SourceMap:       sourceMap,
+		Issues:          issues,
+		TOC:             toc,
 	}
 
 	// Add debug info if enabled
@@ -615,6 +1471,7 @@ func (d *Defuddle) parseInternal(_ context.Context, overrideOptions *Options) (*
 		result.DebugInfo = d.debugger.GetInfo()
 	}
 
+	typesetMetadata(result, options.Typography)
 	return result, nil
 }
 
@@ -748,7 +1605,9 @@ func (d *Defuddle) findContentByScoring(doc *goquery.Document) *goquery.Selectio
 	return scoring.FindBestElement(candidates, 50)
 }
 
-// removeBySelector removes elements by exact and partial selectors
+// removeBySelector removes elements by exact and partial selectors, sparing
+// those keep reports true for when keep is set. Each removed element leaves
+// a gap marker for the repair pass of standardize.Content.
 // JavaScript original code:
 //
 //	private removeBySelector(doc: Document, removeExact: boolean = true, removePartial: boolean = true) {
@@ -777,11 +1636,19 @@ func (d *Defuddle) findContentByScoring(doc *goquery.Document) *goquery.Selectio
 //	    });
 //	  }
 //	}
-func (d *Defuddle) removeBySelector(doc *goquery.Document, removeExact, removePartial bool) {
+func (d *Defuddle) removeBySelector(doc *goquery.Document, removeExact, removePartial bool, keep func(*goquery.Selection) bool) {
+	// Time elements in running text are content, unlike datelines
+	if keepOther := keep; keepOther != nil {
+		keep = func(element *goquery.Selection) bool { return inTextTime(element) || keepOther(element) }
+	} else {
+		keep = inTextTime
+	}
+
 	if removeExact {
 		exactSelectors := constants.GetExactSelectors()
 		for _, selector := range exactSelectors {
-			doc.Find(selector).Remove()
+			matches := doc.Find(selector).FilterFunction(func(_ int, element *goquery.Selection) bool { return !keep(element) })
+			standardize.RemoveLeavingGap(matches)
 		}
 	}
 
@@ -796,7 +1663,9 @@ func (d *Defuddle) removeBySelector(doc *goquery.Document, removeExact, removePa
 					lowerValue := strings.ToLower(value)
 					for _, pattern := range partialSelectors {
 						if strings.Contains(lowerValue, strings.ToLower(pattern)) {
-							element.Remove()
+							if !keep(element) {
+								standardize.RemoveLeavingGap(element)
+							}
 							return
 						}
 					}
@@ -816,10 +1685,7 @@ func (d *Defuddle) removeBySelector(doc *goquery.Document, removeExact, removePa
 //	  ...overrideOptions
 //	};
 func (d *Defuddle) mergeOptions(overrideOptions *Options) *Options {
-	options := &Options{
-		RemoveExactSelectors:   true,
-		RemovePartialSelectors: true,
-	}
+	options := DefaultOptions()
 
 	applyOptions(options, d.options)
 	applyOptions(options, overrideOptions)
@@ -841,6 +1707,37 @@ func applyOptions(options, source *Options) {
 	options.RemoveExactSelectors = source.RemoveExactSelectors
 	options.RemovePartialSelectors = source.RemovePartialSelectors
 	options.RemoveImages = source.RemoveImages
+	options.RemoveUpdateNotes = source.RemoveUpdateNotes
+	options.RemoveCorrections = source.RemoveCorrections
+	options.SkipContentSelection = source.SkipContentSelection
+	options.KeepLinkLists = source.KeepLinkLists
+	options.Sanitize = source.Sanitize
+	options.SourceMap = source.SourceMap
+	options.GenerateTOC = source.GenerateTOC
+	options.InsertKeyPoints = source.InsertKeyPoints
+	options.HeadingIDs = source.HeadingIDs
+	options.ImageTargetWidth = source.ImageTargetWidth
+	options.MinContentWords = source.MinContentWords
+	options.WordsPerMinute = source.WordsPerMinute
+	options.NormalizeTimes = source.NormalizeTimes
+	if !source.FetchTime.IsZero() {
+		options.FetchTime = source.FetchTime
+	}
+	if source.RetryStrategy != "" {
+		options.RetryStrategy = source.RetryStrategy
+	}
+	options.RecoverFromStructuredData = source.RecoverFromStructuredData
+	if source.SchemaOrgMode != "" {
+		options.SchemaOrgMode = source.SchemaOrgMode
+	}
+	options.AllowRemoteContexts = source.AllowRemoteContexts
+	if source.ScoringStrategy != "" {
+		options.ScoringStrategy = source.ScoringStrategy
+	}
+	options.SummaryLength = source.SummaryLength
+	options.MaxKeywords = source.MaxKeywords
+	options.FastFirstN = source.FastFirstN
+	options.FastBackground = source.FastBackground
 	options.ProcessCode = source.ProcessCode
 	options.ProcessImages = source.ProcessImages
 	options.ProcessHeadings = source.ProcessHeadings
@@ -848,6 +1745,36 @@ func applyOptions(options, source *Options) {
 	options.ProcessFootnotes = source.ProcessFootnotes
 	options.ProcessRoles = source.ProcessRoles
 
+	if source.Cleanup != nil {
+		options.Cleanup = source.Cleanup
+	}
+	if source.MarkdownOptions != nil {
+		options.MarkdownOptions = source.MarkdownOptions
+	}
+	if source.Typography != nil {
+		options.Typography = source.Typography
+	}
+	if source.Summarizer != nil {
+		options.Summarizer = source.Summarizer
+	}
+	if source.KeywordExtractor != nil {
+		options.KeywordExtractor = source.KeywordExtractor
+	}
+	if source.Metrics != nil {
+		options.Metrics = source.Metrics
+	}
+	if source.Limits != nil {
+		options.Limits = source.Limits
+	}
+	if source.Prefilter != "" {
+		options.Prefilter = source.Prefilter
+	}
+	if source.Cache != nil {
+		options.Cache = source.Cache
+	}
+	if source.Extractors != nil {
+		options.Extractors = source.Extractors
+	}
 	if source.CodeOptions != nil {
 		options.CodeOptions = source.CodeOptions
 	}
@@ -868,6 +1795,104 @@ func applyOptions(options, source *Options) {
 	}
 }
 
+// markdownBufferBytes estimates the buffers held while converting content to
+// Markdown: the content, the converter's intermediate output, and the result.
+func markdownBufferBytes(content string, markdown *string) int {
+	if markdown == nil {
+		return len(content)
+	}
+	return len(content) + 2*len(*markdown)
+}
+
+// cacheKey returns the Options.Cache key for parsing HTML with the SHA-256
+// digest with options: a SHA-256 hash of the digest, the JSON-encoded
+// options, and the types of the summarizer, keyword extractor, and alt-text
+// provider. ok is false when the options cannot be encoded, and the result
+// is then not cached. Options.Extractors is not part of the key, so parsers
+// with different registries should not share a cache.
+func cacheKey(digest []byte, options *Options) (key string, ok bool) {
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return "", false
+	}
+
+	var altTextProvider AltTextProvider
+	if options.ImageOptions != nil {
+		altTextProvider = options.ImageOptions.AltTextProvider
+	}
+	hash := sha256.New()
+	_, _ = hash.Write(digest)
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write(encoded)
+	_, _ = fmt.Fprintf(hash, "\x00%T\x00%T\x00%T", options.Summarizer, options.KeywordExtractor, altTextProvider)
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// urlDigest is the cacheKey digest of the page at url, fetched by
+// ParseFromURL. The URL is hashed with a prefix, so the page's URL key
+// differs from the key Parse stores its HTML under.
+func urlDigest(url string) []byte {
+	digest := sha256.Sum256([]byte("url\x00" + url))
+	return digest[:]
+}
+
+// observeStage reports the time since start for stage to the configured collector
+func observeStage(options *Options, stage metrics.Stage, start time.Time) {
+	if options.Metrics != nil {
+		options.Metrics.StageObserved(stage, time.Since(start))
+	}
+}
+
+// summarize produces Result.Summary from the extracted content blocks when a
+// summarizer is configured. Summarizer errors are logged in debug mode and
+// leave the summary empty, matching Markdown conversion failures.
+func (d *Defuddle) summarize(ctx context.Context, options *Options, content string) string {
+	if options.Summarizer == nil {
+		return ""
+	}
+
+	targetWords := options.SummaryLength
+	if targetWords <= 0 {
+		targetWords = nlp.DefaultSummaryLength
+	}
+
+	summary, err := options.Summarizer.Summarize(ctx, nlp.Blocks(content), targetWords)
+	if err != nil {
+		if d.debug {
+			slog.Debug("Failed to summarize content", "error", err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(summary)
+}
+
+// extractKeywords produces Result.Keywords from the cleaned content text when
+// a keyword extractor is configured. Extractor errors are logged in debug mode
+// and leave the keywords empty.
+func (d *Defuddle) extractKeywords(ctx context.Context, options *Options, content string) []Keyword {
+	if options.KeywordExtractor == nil {
+		return nil
+	}
+
+	limit := options.MaxKeywords
+	if limit <= 0 {
+		limit = nlp.DefaultKeywordLimit
+	}
+
+	text := strings.Join(nlp.Blocks(content), "\n")
+	keywords, err := options.KeywordExtractor.ExtractKeywords(ctx, text, limit)
+	if err != nil {
+		if d.debug {
+			slog.Debug("Failed to extract keywords", "error", err)
+		}
+		return nil
+	}
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
+
 // countWords counts words in HTML content
 // JavaScript original code:
 //
@@ -887,6 +1912,12 @@ func applyOptions(options, source *Options) {
 //	  return words.length;
 //	}
 func (d *Defuddle) countWords(content string) int {
+	return countContentWords(content)
+}
+
+// countContentWords counts words in HTML content for every Result.WordCount,
+// including those set outside a parse.
+func countContentWords(content string) int {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 	if err != nil {
 		return len(strings.Fields(content))
@@ -896,6 +1927,9 @@ func (d *Defuddle) countWords(content string) int {
 }
 
 // extractSchemaOrgData extracts and processes schema.org structured data using JSON-LD processor
+// In SchemaOrgRaw mode the scripts are parsed as written, skipping expansion.
+// Expansion loads the schema.org context from a bundled copy and other
+// contexts only when options.AllowRemoteContexts is set.
 // JavaScript original code:
 //
 //	private _extractSchemaOrgData(document: Document) {
@@ -917,10 +1951,12 @@ func (d *Defuddle) countWords(content string) int {
 //
 //	  return schemaItems;
 //	}
-func (d *Defuddle) extractSchemaOrgData() any {
+func (d *Defuddle) extractSchemaOrgData(parseOptions *Options) any {
+	mode := parseOptions.SchemaOrgMode
 	processor := ld.NewJsonLdProcessor()
 	options := ld.NewJsonLdOptions("")
 	options.ProcessingMode = ld.JsonLd_1_1
+	options.DocumentLoader = jsonld.Default(parseOptions.AllowRemoteContexts)
 
 	var allSchemaItems []any
 
@@ -944,7 +1980,15 @@ func (d *Defuddle) extractSchemaOrgData() any {
 		}
 
 		// Parse and process JSON-LD using json-gold
-		processedData, err := d.processSchemaOrgData(processor, options, cleanedContent)
+		var processedData any
+		var err error
+		if mode == SchemaOrgRaw {
+			if err = json.Unmarshal([]byte(cleanedContent), &processedData); err != nil {
+				err = fmt.Errorf("invalid JSON syntax: %w", err)
+			}
+		} else {
+			processedData, err = d.processSchemaOrgData(processor, options, cleanedContent)
+		}
 		if err != nil {
 			if d.debug {
 				slog.Debug("Failed to process schema.org JSON-LD",
@@ -1598,7 +2642,33 @@ func (d *Defuddle) removeSmallImages(doc *goquery.Document, smallImages map[stri
 	}
 }
 
+// typesetMetadata applies Options.Typography to the title and description.
+// Content is typeset before Markdown conversion and word counting.
+func typesetMetadata(result *Result, opts *TypographyOptions) {
+	result.Title = typography.Text(result.Title, opts)
+	result.Description = typography.Text(result.Description, opts)
+}
+
 // removeAllImages removes all images from the document
+// removeUpdateNotes removes elements inside content whose whole text is an
+// update notice such as "Updated on 5 March 2024". The content root itself
+// is kept.
+func (d *Defuddle) removeUpdateNotes(content *goquery.Selection, lang string) {
+	if content == nil {
+		return
+	}
+	removed := 0
+	content.Find("p, div, aside, section, span, small, em, strong, time").Each(func(_ int, s *goquery.Selection) {
+		if metadata.IsUpdateNote(s.Text(), lang) {
+			s.Remove()
+			removed++
+		}
+	})
+	if d.debug && removed > 0 {
+		slog.Debug("Removed update notes", "count", removed)
+	}
+}
+
 // Implements the removeImages option from TypeScript version
 func (d *Defuddle) removeAllImages(doc *goquery.Document) {
 	removedCount := 0
@@ -1686,6 +2756,6 @@ func (d *Defuddle) getElementIdentifier(element *goquery.Selection, tagName stri
 }
 
 // convertHTMLToMarkdown converts HTML content to Markdown
-func (d *Defuddle) convertHTMLToMarkdown(htmlContent string) (string, error) {
-	return markdown.ConvertHTML(htmlContent)
+func (d *Defuddle) convertHTMLToMarkdown(htmlContent string, options *MarkdownOptions) (string, error) {
+	return markdown.Convert(htmlContent, options)
 }
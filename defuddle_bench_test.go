@@ -2,6 +2,7 @@ package defuddle
 
 import (
 	"context"
+	"strings"
 	"testing"
 )
 
@@ -161,10 +162,12 @@ func BenchmarkExtractSchemaOrgData(b *testing.B) {
 		b.Fatalf("Failed to create Defuddle instance: %v", err)
 	}
 
-	b.ResetTimer()
-
-	for b.Loop() {
-		_ = defuddle.extractSchemaOrgData()
+	for _, mode := range []SchemaOrgMode{SchemaOrgNormalized, SchemaOrgRaw} {
+		b.Run(string(mode), func(b *testing.B) {
+			for b.Loop() {
+				_ = defuddle.extractSchemaOrgData(&Options{SchemaOrgMode: mode})
+			}
+		})
 	}
 }
 
@@ -214,7 +217,7 @@ func BenchmarkRemoveBySelector(b *testing.B) {
 		if err != nil {
 			b.Fatalf("Failed to create Defuddle instance: %v", err)
 		}
-		defuddle.removeBySelector(defuddle.doc, true, true)
+		defuddle.removeBySelector(defuddle.doc, true, true, nil)
 	}
 }
 
@@ -242,3 +245,42 @@ func BenchmarkCollectMetaTags(b *testing.B) {
 		_ = defuddle.collectMetaTags()
 	}
 }
+
+// BenchmarkParseFast benchmarks previewing a very long page
+func BenchmarkParseFast(b *testing.B) {
+	html := `<html><head><title>Long Page</title></head><body><article>` +
+		strings.Repeat(`<p>A paragraph of a very long page with <a href="/more">a link</a>.</p>`, 50000) +
+		`</article></body></html>`
+
+	ctx := context.Background()
+	options := &Options{FastFirstN: 10}
+	b.ResetTimer()
+
+	for b.Loop() {
+		if _, err := ParseFast(ctx, html, options); err != nil {
+			b.Fatalf("ParseFast failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkParseBloatedPage benchmarks a page mostly made of inline JSON and
+// SVG, parsed whole and prefiltered
+func BenchmarkParseBloatedPage(b *testing.B) {
+	html := `<html><head><title>Test Article</title>
+		<script id="__NEXT_DATA__" type="application/json">` + strings.Repeat(`{"id":1,"name":"item"},`, 20000) + `</script>
+	</head><body><article><h1>Main Article Title</h1>` +
+		strings.Repeat(`<svg viewBox="0 0 24 24"><path d="M12 2L2 7l10 5 10-5-10-5z"/></svg><p>This is a paragraph with some content.</p>`, 500) +
+		`</article></body></html>`
+
+	ctx := context.Background()
+	for _, mode := range []PrefilterMode{PrefilterOff, PrefilterOn} {
+		b.Run(string(mode), func(b *testing.B) {
+			options := &Options{Prefilter: mode}
+			for b.Loop() {
+				if _, err := ParseFromString(ctx, html, options); err != nil {
+					b.Fatalf("ParseFromString failed: %v", err)
+				}
+			}
+		})
+	}
+}
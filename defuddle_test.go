@@ -2,13 +2,20 @@ package defuddle
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/kaptinlin/defuddle-go/internal/scoring"
+	"github.com/kaptinlin/defuddle-go/metrics"
 )
 
 func TestNewDefuddle(t *testing.T) {
@@ -873,6 +880,85 @@ func TestSchemaOrgDataIgnoresInvalidJSON(t *testing.T) {
 	assert.Equal(t, "Document Title", result.Title)
 }
 
+func TestSchemaOrgRawModeKeepsScriptsAsWritten(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head>
+		<script type="application/ld+json">
+		{
+			"@context": "https://schema.org",
+			"@graph": [
+				{"@type": "Organization", "name": "Example Publisher"},
+				{"@type": "Article", "headline": "Graph Headline", "author": {"@type": "Person", "name": "Raw Author"}}
+			]
+		}
+		</script>
+		<script type="application/ld+json">{invalid json</script>
+	</head><body><article><h1>Graph Headline</h1><p>Readable article body for raw schema.</p></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{SchemaOrgMode: SchemaOrgRaw})
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"@type": "Organization", "name": "Example Publisher"},
+		map[string]any{"@type": "Article", "headline": "Graph Headline", "author": map[string]any{"@type": "Person", "name": "Raw Author"}},
+	}, result.SchemaOrgData)
+	assert.Equal(t, "Graph Headline", result.Title)
+	assert.Equal(t, "Raw Author", result.Author)
+
+	_, err = ParseFromString(context.Background(), html, &Options{SchemaOrgMode: "expanded"})
+	require.ErrorIs(t, err, ErrUnsupportedSchemaOrgMode)
+}
+
+func TestParseDetectsLanguageWhenUndeclared(t *testing.T) {
+	t.Parallel()
+
+	body := `<body><article><h1>Neues Gesetz</h1>
+		<p>Die Regierung hat angekündigt, dass das neue Gesetz nicht vor dem Sommer in Kraft treten wird.</p>
+		<p>Auch die Länder sind mit dem Entwurf nicht einverstanden, und die Beratungen werden sich hinziehen.</p>
+	</article></body>`
+
+	result, err := ParseFromString(context.Background(), "<html><head></head>"+body+"</html>", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "de", result.Language)
+
+	result, err = ParseFromString(context.Background(), `<html lang="de-CH"><head></head>`+body+"</html>", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "de-CH", result.Language)
+}
+
+func TestSchemaOrgContextsLoadOnlyWhenAllowed(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/ld+json")
+		_, _ = w.Write([]byte(`{"@context": {"@vocab": "http://schema.org/", "title": "headline"}}`))
+	}))
+	defer server.Close()
+
+	html := `<html><head>
+		<script type="application/ld+json">{"@context": "` + server.URL + `/context.jsonld", "@type": "Article", "title": "Remote Headline"}</script>
+	</head><body><article><p>Readable article body for a remote context.</p></article></body></html>`
+
+	// The schema.org context used to compact is bundled, and the remote one
+	// is not fetched, so the script is kept as written
+	result, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"@context": server.URL + "/context.jsonld", "@type": "Article", "title": "Remote Headline"}}, result.SchemaOrgData)
+	assert.Zero(t, requests.Load())
+
+	for range 2 {
+		result, err = ParseFromString(context.Background(), html, &Options{AllowRemoteContexts: true})
+		require.NoError(t, err)
+		items, ok := result.SchemaOrgData.([]any)
+		require.True(t, ok, "SchemaOrgData = %T, want []any", result.SchemaOrgData)
+		require.Len(t, items, 1)
+		assert.Equal(t, "Remote Headline", items[0].(map[string]any)["headline"])
+	}
+	assert.Equal(t, int32(1), requests.Load())
+}
+
 func TestParseFromString(t *testing.T) {
 	html := `
 <!DOCTYPE html>
@@ -953,6 +1039,43 @@ func TestParseFromStringSeparateMarkdownAddsMarkdownWithoutReplacingHTML(t *test
 	assert.Contains(t, *result.ContentMarkdown, "Readable markdown body")
 }
 
+func TestParseFromStringMarkdownReferenceLinks(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Links</title></head><body><article><h1>Links</h1><p>Read <a href="https://example.com/guide">the guide</a>, then <a href="https://example.com/faq">the FAQ</a>, then <a href="https://example.com/guide">the guide again</a>.</p></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{
+		SeparateMarkdown: true,
+		MarkdownOptions:  &MarkdownOptions{LinkStyle: LinkStyleReference},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.ContentMarkdown)
+
+	markdown := *result.ContentMarkdown
+	assert.Contains(t, markdown, "[the guide][1], then [the FAQ][2], then [the guide again][1]")
+	assert.True(t, strings.HasSuffix(markdown, "[1]: https://example.com/guide\n[2]: https://example.com/faq"), markdown)
+	assert.Contains(t, result.Content, `href="https://example.com/guide"`)
+}
+
+func TestParseFromStringMarkdownFootnotes(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Readable paragraph text for the footnote article. ", 8)
+	html := `<html><head><title>Notes</title></head><body><article><h1>Notes</h1><p>` + body + `</p>
+		<p>A sourced claim<sup id="fnref:1"><a href="#fn:1" class="footnote">1</a></sup>.</p>
+		<div class="footnotes"><ol><li id="fn:1"><p>The source. <a href="#fnref:1" class="reversefootnote">↩</a></p></li></ol></div>
+	</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{SeparateMarkdown: true})
+	require.NoError(t, err)
+	require.NotNil(t, result.ContentMarkdown)
+
+	assert.Contains(t, result.Content, `<sup id="fnref:1"><a href="#fn:1">1</a></sup>`)
+	assert.Contains(t, result.Content, `<li id="fn:1">`)
+	assert.Contains(t, *result.ContentMarkdown, "A sourced claim [^1].")
+	assert.True(t, strings.HasSuffix(*result.ContentMarkdown, "[^1]: The source."), *result.ContentMarkdown)
+}
+
 func TestSchemaItemsKeepURLIdentifiedAndCommonPropertyItems(t *testing.T) {
 	t.Parallel()
 
@@ -1003,3 +1126,735 @@ func TestParseRemovesSmallSVGByStableIdentifier(t *testing.T) {
 	assert.NotContains(t, result.Content, `viewBox="0 0 20 80"`)
 	assert.Contains(t, result.Content, `viewBox="0 0 120 80"`)
 }
+
+func TestParseCleanupOptionsKeepAttributesWhenStripDisabled(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Cleanup</title></head><body><article><p data-source="cms">Readable article body with cleanup disabled.</p></article></body></html>`
+
+	cleanup := DefaultCleanupOptions()
+	cleanup.StripAttributes = false
+	result, err := ParseFromString(context.Background(), html, &Options{
+		RemoveExactSelectors:   true,
+		RemovePartialSelectors: true,
+		Cleanup:                cleanup,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, `data-source="cms"`)
+
+	defaults, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, defaults.Content, `data-source="cms"`)
+}
+
+func TestParseAltTextProviderFillsMissingAltText(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Article paragraph describing the figure in plenty of detail. ", 10)
+	html := `<html><head><title>Figures</title></head><body><article><h1>Figures</h1><p>` + body + `</p><img src="/img/diagram.png" width="600" height="400"><p>` + body + `</p></article></body></html>`
+
+	imageOptions := DefaultImageProcessingOptions()
+	imageOptions.AltTextProvider = func(_ context.Context, imageURL, _ string) (string, error) {
+		return "Caption for " + imageURL, nil
+	}
+
+	result, err := ParseFromString(context.Background(), html, &Options{
+		URL:                    "https://example.com/post",
+		RemoveExactSelectors:   true,
+		RemovePartialSelectors: true,
+		ImageOptions:           imageOptions,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, `alt="Caption for https://example.com/img/diagram.png"`)
+	assert.Empty(t, result.Issues)
+}
+
+func TestParseReportsFailedAltTextProvider(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Article paragraph describing the figure in plenty of detail. ", 10)
+	html := `<html><head><title>Figures</title></head><body><article><h1>Figures</h1><p>` + body + `</p><img src="/img/diagram.png" width="600" height="400"><p>` + body + `</p></article></body></html>`
+
+	imageOptions := DefaultImageProcessingOptions()
+	imageOptions.AltTextProvider = func(context.Context, string, string) (string, error) {
+		return "", errors.New("captioning service unavailable")
+	}
+
+	result, err := ParseFromString(context.Background(), html, &Options{URL: "https://example.com/post", ImageOptions: imageOptions})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content, "alt=")
+	assert.Equal(t, []Issue{{
+		Code:    IssueAltTextFailed,
+		Message: "https://example.com/img/diagram.png: captioning service unavailable",
+	}}, result.Issues)
+}
+
+type staticSummarizer struct {
+	blocks      []string
+	targetWords int
+}
+
+func (s *staticSummarizer) Summarize(_ context.Context, blocks []string, targetWords int) (string, error) {
+	s.blocks = blocks
+	s.targetWords = targetWords
+	return "  Remote summary.  ", nil
+}
+
+func TestParseSummarizerProducesSummary(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Compilers translate source code into machine instructions quickly. ", 12)
+	html := `<html><head><title>Compilers</title></head><body><article><h1>Compilers</h1><p>` + body + `</p><p>Linkers combine compiled objects into executables.</p></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Summary)
+
+	summarizer := &staticSummarizer{}
+	result, err = ParseFromString(context.Background(), html, &Options{Summarizer: summarizer, SummaryLength: 25})
+	require.NoError(t, err)
+	assert.Equal(t, "Remote summary.", result.Summary)
+	assert.Equal(t, 25, summarizer.targetWords)
+	assert.Contains(t, summarizer.blocks, "Linkers combine compiled objects into executables.")
+
+	result, err = ParseFromString(context.Background(), html, &Options{Summarizer: NewExtractiveSummarizer()})
+	require.NoError(t, err)
+	assert.Contains(t, result.Summary, "Compilers translate source code")
+}
+
+type entityExtractor struct{ text string }
+
+func (e *entityExtractor) ExtractKeywords(_ context.Context, text string, _ int) ([]Keyword, error) {
+	e.text = text
+	return []Keyword{{Text: "Ada Lovelace", Score: 0.9}, {Text: "Analytical Engine", Score: 0.8}, {Text: "London", Score: 0.5}}, nil
+}
+
+func TestParseKeywordExtractorProducesKeywords(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Garbage collection pauses are a source of latency in managed runtimes. ", 12)
+	html := `<html><head><title>GC</title></head><body><article><h1>GC</h1><p>` + body + `</p><p>Tuning garbage collection reduces tail latency.</p></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{KeywordExtractor: NewKeywordExtractor(), MaxKeywords: 5})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Keywords)
+	assert.LessOrEqual(t, len(result.Keywords), 5)
+	assert.InDelta(t, 1.0, result.Keywords[0].Score, 1e-9)
+	assert.Contains(t, result.Keywords[0].Text, "garbage collection")
+
+	ner := &entityExtractor{}
+	result, err = ParseFromString(context.Background(), html, &Options{KeywordExtractor: ner, MaxKeywords: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []Keyword{{Text: "Ada Lovelace", Score: 0.9}, {Text: "Analytical Engine", Score: 0.8}}, result.Keywords)
+	assert.Contains(t, ner.text, "Tuning garbage collection reduces tail latency.")
+}
+
+type mapCache struct {
+	mu      sync.Mutex
+	results map[string]*Result
+}
+
+func (c *mapCache) Get(_ context.Context, key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[key]
+	return result, ok
+}
+
+func (c *mapCache) Set(_ context.Context, key string, result *Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[key] = result
+}
+
+func TestParseReturnsCachedResultForSameHTMLAndOptions(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Cached</title></head><body><article><h1>Cached</h1><p>Duplicate submissions of this page are extracted once.</p></article></body></html>`
+	cache := &mapCache{results: make(map[string]*Result)}
+	collector := metrics.NewMemory()
+
+	first, err := ParseFromString(context.Background(), html, &Options{Cache: cache, Metrics: collector})
+	require.NoError(t, err)
+	selections := collector.Snapshot().Stages[metrics.StageSelection].Count
+
+	second, err := ParseFromString(context.Background(), html, &Options{Cache: cache, Metrics: collector})
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, selections, collector.Snapshot().Stages[metrics.StageSelection].Count, "cache hit must skip extraction")
+
+	markdown, err := ParseFromString(context.Background(), html, &Options{Cache: cache, Markdown: true})
+	require.NoError(t, err)
+	assert.NotSame(t, first, markdown, "different options must not share a cache entry")
+
+	changed, err := ParseFromString(context.Background(), strings.Replace(html, "once", "twice", 1), &Options{Cache: cache})
+	require.NoError(t, err)
+	assert.Contains(t, changed.Content, "twice")
+	assert.Len(t, cache.results, 3)
+}
+
+func TestParseReportsToDomainCollector(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Short</title></head><body><article><h1>Short</h1><p>A short page that needs the retry pass.</p></article></body></html>`
+	cache := &mapCache{results: make(map[string]*Result)}
+	domains := metrics.NewDomains(0)
+
+	for range 2 {
+		_, err := ParseFromString(context.Background(), html, &Options{URL: "https://www.example.com/short", Cache: cache, Metrics: domains})
+		require.NoError(t, err)
+	}
+
+	stats := domains.Snapshot()["example.com"]
+	assert.Equal(t, 1, stats.Parses, "cache hits are not reported")
+	assert.Equal(t, 1, stats.Retries)
+	assert.Equal(t, 1, stats.Fallbacks)
+	assert.Positive(t, stats.Words)
+}
+
+func TestCacheKeyDistinguishesHooks(t *testing.T) {
+	t.Parallel()
+
+	plain, ok := cacheKey(htmlDigest("<p>x</p>"), &Options{})
+	require.True(t, ok)
+	summarized, ok := cacheKey(htmlDigest("<p>x</p>"), &Options{Summarizer: NewExtractiveSummarizer()})
+	require.True(t, ok)
+	again, ok := cacheKey(htmlDigest("<p>x</p>"), &Options{})
+	require.True(t, ok)
+
+	assert.NotEqual(t, plain, summarized)
+	assert.Equal(t, plain, again)
+}
+
+func TestParseReportsPeakMemoryEstimate(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Stats</title></head><body><article><h1>Stats</h1><p>Small page body.</p></article></body></html>`
+	result, err := ParseFromString(context.Background(), html, &Options{Markdown: true})
+	require.NoError(t, err)
+	require.NotNil(t, result.Stats)
+	assert.Positive(t, result.Stats.NodeCount)
+	assert.Greater(t, result.Stats.PeakMemoryBytes, int64(len(html)+result.Stats.NodeCount*estimatedNodeBytes))
+}
+
+func TestParseAbortsAboveMaxMemoryBytes(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Huge</title></head><body><article>` + strings.Repeat(`<p>Paragraph of a very large page.</p>`, 2000) + `</article></body></html>`
+
+	tests := []struct {
+		name  string
+		limit int64
+		stage string
+	}{
+		{name: "input larger than limit", limit: int64(len(html)) - 1, stage: "input"},
+		{name: "parsed nodes over limit", limit: int64(len(html)) + 1, stage: "document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseFromString(context.Background(), html, &Options{Limits: &Limits{MaxMemoryBytes: tt.limit}})
+			require.ErrorIs(t, err, ErrMemoryLimit)
+			var limitErr *MemoryLimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tt.stage, limitErr.Stage)
+			assert.Equal(t, tt.limit, limitErr.Limit)
+			assert.Greater(t, limitErr.Estimate, tt.limit)
+		})
+	}
+
+	unlimited, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	_, err = ParseFromString(context.Background(), html, &Options{Limits: &Limits{MaxMemoryBytes: unlimited.Stats.PeakMemoryBytes - 1}})
+	var limitErr *MemoryLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, "content", limitErr.Stage)
+
+	_, err = ParseFromString(context.Background(), html, &Options{Limits: &Limits{MaxMemoryBytes: unlimited.Stats.PeakMemoryBytes}})
+	require.NoError(t, err)
+}
+
+func TestMergeOptionsKeepsLimits(t *testing.T) {
+	t.Parallel()
+
+	limits := &Limits{MaxMemoryBytes: 1 << 20}
+	parser, err := NewDefuddle(`<html><body><p>x</p></body></html>`, &Options{Limits: limits})
+	require.NoError(t, err)
+
+	assert.Same(t, limits, parser.mergeOptions(nil).Limits)
+}
+
+func TestParseReportsModifiedAndRemovesUpdateNotes(t *testing.T) {
+	t.Parallel()
+
+	html := `<html lang="en"><head><title>Changes</title>
+		<meta property="article:published_time" content="2024-01-05T09:00:00Z">
+	</head><body><article>
+		<h1>Changes</h1>
+		<p class="update-note"><em>Updated on March 9, 2024 at 4:15 pm UTC</em></p>
+		<p>The first paragraph of the article has enough words to be selected as the main content.</p>
+		<p>Update: the company has since responded, saying the change will ship on 12 March 2024.</p>
+	</article></body></html>`
+
+	kept, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-05T09:00:00Z", kept.Published)
+	assert.Equal(t, "2024-03-09T16:15:00Z", kept.Modified)
+	assert.Contains(t, kept.Content, "Updated on March 9, 2024")
+
+	stripped, err := ParseFromString(context.Background(), html, &Options{RemoveUpdateNotes: true})
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-09T16:15:00Z", stripped.Modified)
+	assert.NotContains(t, stripped.Content, "Updated on March 9, 2024")
+	assert.Contains(t, stripped.Content, "the company has since responded")
+}
+
+func TestParseReportsSeries(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Rust Internals, Part 2 of 3</title>
+		<link rel="prev" href="/rust/part-1"><link rel="next" href="/rust/part-3">
+	</head><body><article>
+		<h1>Rust Internals, Part 2 of 3</h1>
+		<p>The second part of the series explains borrow checking in enough detail to be selected.</p>
+	</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{URL: "https://example.com/rust/part-2"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Series)
+	assert.Equal(t, Series{
+		Name:     "Rust Internals",
+		Position: 2,
+		Total:    3,
+		PrevURL:  "https://example.com/rust/part-1",
+		NextURL:  "https://example.com/rust/part-3",
+	}, *result.Series)
+}
+
+func TestParseReportsAudioVersion(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Rates rise</title></head><body><article><h1>Rates rise</h1>
+		<div class="listen-player"><span>Listen to this article</span> <span>4:05</span>
+			<audio src="/audio/rates.mp3"></audio></div>
+		<p>` + strings.Repeat("The central bank raised its benchmark rate on Thursday. ", 8) + `</p>
+	</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{URL: "https://example.com/news/rates"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/audio/rates.mp3", result.AudioVersionURL)
+	assert.Equal(t, 245, result.AudioDuration)
+}
+
+func TestParseResolvesLazyImageSources(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Harbor</title></head><body><article><h1>Harbor</h1>
+		<p>` + strings.Repeat("Boats came back into the harbor before the storm arrived. ", 8) + `</p>
+		<figure><img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-srcset="/harbor-640.jpg 640w, /harbor-1280.jpg 1280w" alt="Harbor at dawn"><figcaption>The harbor.</figcaption></figure>
+		<p>` + strings.Repeat("The fleet stayed in port until the wind dropped overnight. ", 8) + `</p>
+	</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{URL: "https://example.com/harbor"})
+	require.NoError(t, err)
+	require.Len(t, result.Images, 1)
+	assert.Equal(t, "/harbor-1280.jpg", result.Images[0].Src)
+	assert.NotContains(t, result.Content, "srcset")
+
+	result, err = ParseFromString(context.Background(), html, &Options{URL: "https://example.com/harbor", ImageTargetWidth: 600})
+	require.NoError(t, err)
+	require.Len(t, result.Images, 1)
+	assert.Equal(t, "/harbor-640.jpg", result.Images[0].Src)
+}
+
+func TestParseTruncatesLongTextNodes(t *testing.T) {
+	t.Parallel()
+
+	blob := strings.Repeat("QUJD", 100)
+	html := `<html><body><article><h1>Blobs</h1>
+<p>The article body explains why pages sometimes embed huge data blobs in their markup.</p>
+<div>` + blob + `</div></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{Limits: &Limits{MaxTextNodeBytes: 200}})
+	require.NoError(t, err)
+
+	assert.Contains(t, result.Content, blob[:200]+"…")
+	assert.NotContains(t, result.Content, blob[:201])
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, IssueTextNodeTruncated, result.Issues[0].Code)
+	assert.Contains(t, result.Issues[0].Message, "1 cut, the longest 400 bytes")
+
+	full, err := ParseFromString(context.Background(), html, &Options{Limits: &Limits{MaxTextNodeBytes: -1}})
+	require.NoError(t, err)
+	assert.Contains(t, full.Content, blob)
+	assert.Empty(t, full.Issues)
+
+	plain, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Empty(t, plain.Issues, "the default limit leaves ordinary pages alone")
+}
+
+func TestTruncateTextNodesKeepsUTF8AndScripts(t *testing.T) {
+	t.Parallel()
+
+	script := `{"description":"` + strings.Repeat("long ", 10) + `"}`
+	d, err := NewDefuddle(`<script type="application/ld+json">`+script+`</script><p>`+strings.Repeat("é", 10)+`</p>`, nil)
+	require.NoError(t, err)
+
+	issues := d.truncateTextNodes(&Limits{MaxTextNodeBytes: 5})
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, "éé…", d.doc.Find("p").Text())
+	assert.Equal(t, script, d.doc.Find("script").Text(), "scripts are not truncated")
+}
+
+func TestParseStripsAssetsWhateverTheOptions(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Assets</title></head><body><article><h1>Assets</h1>
+<p>The article body explains how pages mix their content with scripts and styles.</p>
+<script>window.track("leak-script")</script><style>.leak-style{color:red}</style>
+<template><p>leak-template</p></template><link rel="stylesheet" href="/leak-link.css">
+<noscript><img src="/leak-noscript.png"></noscript>
+<script type="math/tex">x^2</script></article></body></html>`
+
+	tests := []struct {
+		name    string
+		options *Options
+	}{
+		{name: "no selector removal", options: &Options{}},
+		{name: "defaults", options: &Options{RemoveExactSelectors: true, RemovePartialSelectors: true}},
+		{name: "whole page", options: &Options{SkipContentSelection: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := ParseFromString(context.Background(), html, tt.options)
+			require.NoError(t, err)
+
+			assert.Contains(t, result.Content, "mix their content")
+			for _, leak := range []string{"leak-script", "leak-style", "leak-template", "leak-link", "leak-noscript", "<script>", "<style"} {
+				assert.NotContains(t, result.Content, leak)
+			}
+		})
+	}
+}
+
+func TestStripAssetsHTML(t *testing.T) {
+	t.Parallel()
+
+	plain := `<p>Plain <b>text</b></p><tr><td>kept as written</td></tr>`
+	assert.Equal(t, plain, stripAssetsHTML(plain), "fragments without assets are not reserialized")
+
+	stripped := stripAssetsHTML(`<link rel="stylesheet" href="a.css"><p>Body<SCRIPT>alert(1)</SCRIPT></p><style>p{}</style><template><i>t</i></template><script type="math/tex">x</script>`)
+	assert.Equal(t, `<p>Body</p><script type="math/tex">x</script>`, stripped)
+}
+
+func TestStripAssetsCleansFallbackBody(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDefuddle(`<body><p>Body text</p><script>leak()</script><div><style>p{}</style><link rel="preload" href="x"></div></body>`, nil)
+	require.NoError(t, err)
+
+	body := d.doc.Find("body")
+	stripAssets(body)
+	content, err := body.Html()
+	require.NoError(t, err)
+	assert.Equal(t, `<p>Body text</p><div></div>`, content)
+}
+
+func TestParseKeepLinkListsKeepsRoundupsAndTablesOfContents(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Weekly links</title></head><body>
+<nav><ul><li><a href="/">Home</a></li><li><a href="/about">About</a></li><li><a href="/tags">Tags</a></li></ul></nav>
+<article><h1>Weekly links #42</h1><p>Here are the most interesting things I read this week, with a short note on each one.</p>
+<div class="links"><ul>
+<li><a href="https://a.example/post">Why Go generics took a decade</a> – a history of the design discussions.</li>
+<li><a href="https://b.example/post">Building a tiny database from scratch</a> – storage engines explained.</li>
+<li><a href="https://c.example/post">The art of reading error messages</a> – practical debugging advice.</li>
+<li><a href="https://d.example/post">Rust and Go side by side</a> – trade-offs for services.</li>
+</ul></div>
+<div class="toc"><ol><li><a href="#one">One</a></li><li><a href="#two">Two</a></li><li><a href="#three">Three</a></li></ol></div>
+<h2 id="one">One</h2><p>Section one text.</p><h2 id="two">Two</h2><p>Section two text.</p><h2 id="three">Three</h2><p>Section three text.</p>
+</article></body></html>`
+
+	dropped, err := ParseFromString(context.Background(), html, &Options{RemoveExactSelectors: true, RemovePartialSelectors: true})
+	require.NoError(t, err)
+	assert.NotContains(t, dropped.Content, "Building a tiny database")
+	assert.NotContains(t, dropped.Content, `href="#two"`)
+
+	kept, err := ParseFromString(context.Background(), html, &Options{RemoveExactSelectors: true, RemovePartialSelectors: true, KeepLinkLists: true})
+	require.NoError(t, err)
+	assert.Contains(t, kept.Content, "Building a tiny database from scratch</a> – storage engines explained.")
+	assert.Contains(t, kept.Content, `<a href="#two">Two</a>`)
+	assert.NotContains(t, kept.Content, "About", "navigation stays out")
+}
+
+func TestParseScoringStrategySelectsMainContent(t *testing.T) {
+	t.Parallel()
+
+	paragraph := `<p>` + strings.Repeat(`The full story continues here, with commas, detail, and context. `, 3) + `</p>`
+	html := `<html><head><title>Strategies</title></head><body>
+<article class="teaser"><p>A teaser that an entry-point selector picks first.</p></article>
+<div class="story-text">` + strings.Repeat(paragraph, 5) + `</div></body></html>`
+
+	defaults, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Contains(t, defaults.Content, "A teaser")
+	assert.NotContains(t, defaults.Content, "The full story")
+
+	readability, err := ParseFromString(context.Background(), html, &Options{ScoringStrategy: ScoringReadability})
+	require.NoError(t, err)
+	assert.Contains(t, readability.Content, "The full story")
+	assert.NotContains(t, readability.Content, "A teaser")
+
+	_, err = ParseFromString(context.Background(), html, &Options{ScoringStrategy: "mystery"})
+	require.ErrorIs(t, err, ErrUnsupportedScoringStrategy)
+}
+
+func TestParseMovesAuthorBioOutOfContent(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Compilers</title></head><body><article><h1>Compilers</h1>
+<p>` + strings.Repeat("The article explains how compilers lower code. ", 10) + `</p>
+<div class="box"><h3>About the author</h3><p>Jane Doe writes about compilers and runtimes.</p></div>
+</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe writes about compilers and runtimes.", result.AuthorBio)
+	assert.NotContains(t, result.Content, "Jane Doe")
+	assert.NotContains(t, result.Content, "About the author")
+	assert.Contains(t, result.Content, "lower code")
+
+	schema := `<script type="application/ld+json">{"@context":"https://schema.org","@type":"Article","author":{"@type":"Person","name":"Jane","description":"Jane writes about compilers."}}</script>`
+	result, err = ParseFromString(context.Background(), strings.Replace(html, "</head>", schema+"</head>", 1), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe writes about compilers and runtimes.", result.AuthorBio, "the visible bio wins")
+}
+
+func TestParseRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Short</title></head><body><article><h1>Short</h1><p>A short page about retries.</p>
+<div class="meta">Filed under retry policies and parse passes.</div></article></body></html>`
+
+	tests := []struct {
+		name        string
+		options     Options
+		wantRetries int
+		wantMeta    bool
+	}{
+		{name: "default", wantRetries: 1},
+		{name: "none", options: Options{RetryStrategy: RetryNone}},
+		{name: "threshold met", options: Options{MinContentWords: 5}},
+		{name: "relax all", options: Options{RetryStrategy: RetryRelaxAll}, wantRetries: 1, wantMeta: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			domains := metrics.NewDomains(0)
+			options := tt.options
+			options.RemoveExactSelectors, options.RemovePartialSelectors = true, true
+			options.URL = "https://example.com/short"
+			options.Metrics = domains
+
+			result, err := ParseFromString(context.Background(), html, &options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRetries, domains.Snapshot()["example.com"].Retries)
+			assert.Equal(t, tt.wantMeta, strings.Contains(result.Content, "Filed under"))
+		})
+	}
+
+	_, err := ParseFromString(context.Background(), html, &Options{RetryStrategy: "sometimes"})
+	require.ErrorIs(t, err, ErrUnsupportedRetryStrategy)
+}
+
+func TestParseReportsCorrections(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Council vote</title></head><body><article><h1>Council vote</h1>
+<p>` + strings.Repeat("The council met on Tuesday to vote on the budget. ", 8) + `</p>
+<p><em>Correction: An earlier version misstated the vote count.</em></p>
+</article></body></html>`
+
+	kept, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Correction: An earlier version misstated the vote count."}, kept.Corrections)
+	assert.Contains(t, kept.Content, "misstated the vote count")
+
+	removed, err := ParseFromString(context.Background(), html, &Options{RemoveCorrections: true})
+	require.NoError(t, err)
+	assert.Equal(t, kept.Corrections, removed.Corrections)
+	assert.NotContains(t, removed.Content, "misstated the vote count")
+	assert.Contains(t, removed.Content, "vote on the budget")
+}
+
+func TestParseSanitize(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Release notes</title></head><body><article><h1>Release notes</h1>
+<p>` + strings.Repeat("This release speeds up parsing of large documents. ", 8) + `</p>
+<p>Read the <a href="javascript:alert(1)">full changelog</a> or the <a href="https://example.com/docs">docs</a>.</p>
+<form action="/subscribe"><p>Subscribe for updates</p><input name="email"></form>
+</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{
+		Sanitize: true,
+		Cleanup:  &CleanupOptions{StripAttributes: false},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content, "javascript:")
+	assert.NotContains(t, result.Content, "<form")
+	assert.NotContains(t, result.Content, "<input")
+	assert.Contains(t, result.Content, `<a href="https://example.com/docs">docs</a>`)
+	assert.Contains(t, result.Content, "full changelog")
+}
+
+func TestParseRepairsAdSlotGaps(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Budget vote</title></head><body><article><h1>Budget vote</h1>
+<p>` + strings.Repeat("The council met on Tuesday to discuss the budget. ", 8) + `After a long debate the committee voted to</p>
+<p>Advertisement</p>
+<div class="ad-container"><img src="banner.jpg" alt="">Buy now</div>
+<p>approve the budget for next year.</p>
+<hr>
+<div id="ad-bottom">Sponsored</div>
+<hr>
+<p>The next meeting is in March.</p>
+</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, "the committee voted to approve the budget for next year.")
+	assert.NotContains(t, result.Content, "Advertisement")
+	assert.Equal(t, 1, strings.Count(result.Content, "<hr"))
+	assert.NotContains(t, result.Content, "defuddle-gap")
+
+	cleanup := DefaultCleanupOptions()
+	cleanup.RepairRemovalGaps = false
+	unrepaired, err := ParseFromString(context.Background(), html, &Options{Cleanup: cleanup})
+	require.NoError(t, err)
+	assert.Contains(t, unrepaired.Content, "Advertisement")
+	assert.NotContains(t, unrepaired.Content, "defuddle-gap")
+}
+
+func TestParseRemovesInlineForms(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Budget vote</title></head><body><article><h1>Budget vote</h1>
+<p>` + strings.Repeat("The council met on Tuesday to discuss the budget. ", 8) + `After a long debate <span class="inline-capture"><span>Enter your email</span><input type="email" placeholder="you@example.com"><button>Subscribe</button></span> the committee voted to approve it.</p>
+<p>The next meeting is in March.</p>
+</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, "After a long debate the committee voted to approve it.")
+	assert.NotContains(t, result.Content, "Enter your email")
+
+	cleanup := DefaultCleanupOptions()
+	cleanup.RemoveInlineForms = false
+	kept, err := ParseFromString(context.Background(), html, &Options{Cleanup: cleanup})
+	require.NoError(t, err)
+	assert.Contains(t, kept.Content, "Enter your email")
+}
+
+func TestParseKeepsLinkedAnchors(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("The council met on Tuesday to discuss the budget. ", 8)
+	html := `<html><head><title>Guide</title></head><body><article><h1>Guide</h1>
+<p>Read the <a href="#setup">setup</a>, <a href="#usage">usage</a>, and <a href="#faq">FAQ</a> sections.</p>
+<h2 id="intro">Intro</h2><p id="lead">` + body + `</p>
+<div id="setup"><div class="section-body"><h2>Setup</h2><p>` + body + `</p></div></div>
+<h2><span id="usage">Usage</span></h2><p>` + body + `</p>
+<a name="faq"></a><h2>FAQ</h2><p>` + body + `</p>
+</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, `<h2 id="setup">Setup</h2>`)
+	assert.Contains(t, result.Content, `<h2 id="usage">`)
+	assert.Contains(t, result.Content, `<h2 id="faq">FAQ</h2>`)
+	assert.NotContains(t, result.Content, `id="intro"`)
+	assert.NotContains(t, result.Content, `id="lead"`)
+}
+
+func TestParseTypography(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>"Quoted" title -- draft</title></head><body><article>
+<p>` + strings.Repeat("The council met on Tuesday to discuss the budget. ", 8) + `The mayor said "we're done" -- finally... Really!!</p>
+<pre><code>x = "a--b"</code></pre>
+</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{Typography: &TypographyOptions{
+		Quotes:               QuotesCurly,
+		Dashes:               true,
+		Ellipses:             true,
+		DuplicatePunctuation: true,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "“Quoted” title — draft", result.Title)
+	assert.Contains(t, result.Content, "The mayor said “we’re done” — finally… Really!")
+	assert.NotContains(t, result.Content, "Really!!")
+	assert.Contains(t, result.Content, "a--b")
+
+	plain, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Contains(t, plain.Content, "-- finally...")
+
+	_, err = ParseFromString(context.Background(), html, &Options{Typography: &TypographyOptions{Quotes: "fancy"}})
+	require.ErrorIs(t, err, ErrUnsupportedQuoteStyle)
+}
+
+// countdownContext reports cancellation once Err has been called calls
+// times, so a test can cancel a parse part-way through.
+type countdownContext struct {
+	context.Context
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countdownContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls <= 0 {
+		return context.Canceled
+	}
+	c.calls--
+	return nil
+}
+
+func TestParseStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	var page strings.Builder
+	page.WriteString(`<html><body><nav><a href="/">Home</a></nav><article>`)
+	for i := range 300 {
+		fmt.Fprintf(&page, `<div class="wrapper"><div><p>Paragraph %d of a long report, with commas, clauses, and detail.</p></div></div>`, i)
+	}
+	page.WriteString(`</article></body></html>`)
+
+	// Cancel after ever more checks until the parse gets to finish, so every
+	// check along the way is seen to stop it
+	for _, strategy := range []ScoringStrategy{ScoringDefuddle, ScoringReadability} {
+		calls := 0
+		for ; ; calls += 3 {
+			ctx := &countdownContext{Context: context.Background(), calls: calls}
+			result, err := ParseFromString(ctx, page.String(), &Options{ScoringStrategy: strategy, RetryStrategy: RetryNone})
+			if err == nil {
+				assert.Contains(t, result.Content, "Paragraph 299")
+				break
+			}
+			require.ErrorIs(t, err, context.Canceled, "strategy %q, cancelled after %d checks", strategy, calls)
+			assert.Nil(t, result)
+		}
+		assert.Greater(t, calls, 20, "strategy %q checked the context only %d times", strategy, calls)
+	}
+}
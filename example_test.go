@@ -0,0 +1,217 @@
+package defuddle_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/extractors"
+)
+
+const examplePage = `<html>
+<head>
+	<title>Field Notes on Rivers</title>
+	<meta name="author" content="Ada Lovelace">
+	<meta property="og:site_name" content="Field Notes">
+</head>
+<body>
+	<nav><a href="/">Home</a> <a href="/archive">Archive</a></nav>
+	<article>
+		<h1>Field Notes on Rivers</h1>
+		<p>Rivers carve their valleys slowly, moving sand and stone downstream with every flood.</p>
+		<p>Read the <a href="/sources">sources</a> behind these notes.</p>
+		<div class="related-posts"><p>More stories from the archive</p></div>
+	</article>
+	<footer>Copyright Field Notes</footer>
+</body>
+</html>`
+
+func ExampleNewDefuddle() {
+	d, err := defuddle.NewDefuddle(examplePage, &defuddle.Options{
+		URL:                    "https://notes.example/rivers",
+		RemoveExactSelectors:   true,
+		RemovePartialSelectors: true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := d.Parse(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.Title)
+	fmt.Println(result.Author)
+	fmt.Println(result.Site)
+	// Output:
+	// Field Notes on Rivers
+	// Ada Lovelace
+	// Field Notes
+}
+
+func ExampleParseFromString() {
+	result, err := defuddle.ParseFromString(context.Background(), examplePage, defuddle.DefaultOptions())
+	if err != nil {
+		log.Fatal(err)
+	}
+	// A page under DefaultMinContentWords is parsed again without partial
+	// selector removal, which here keeps the related-posts block
+	fmt.Println(result.Title)
+	fmt.Println(result.Content)
+	// Output:
+	// Field Notes on Rivers
+	// <p>Rivers carve their valleys slowly, moving sand and stone downstream with every flood.</p><p>Read the <a href="/sources">sources</a> behind these notes.</p><p>More stories from the archive</p>
+}
+
+func ExampleParseFromURL() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(w, examplePage)
+	}))
+	defer server.Close()
+
+	// With nil options, ParseFromURL uses DefaultOptions and the URL the
+	// page was served from
+	result, err := defuddle.ParseFromURL(context.Background(), server.URL+"/rivers", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.Title)
+	fmt.Println(result.Domain)
+	// Output:
+	// Field Notes on Rivers
+	// 127.0.0.1
+}
+
+func ExampleParseURLs() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(w, examplePage)
+	}))
+	defer server.Close()
+
+	urls := []string{server.URL + "/rivers", server.URL + "/missing"}
+	results, err := defuddle.ParseURLs(context.Background(), urls, nil, 2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Results follow the order of urls, whichever finished first
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Println(strings.TrimPrefix(r.URL, server.URL), "failed:", r.Err.(*defuddle.HTTPStatusError).StatusCode)
+			continue
+		}
+		fmt.Println(strings.TrimPrefix(r.URL, server.URL), r.Result.Title)
+	}
+	// Output:
+	// /rivers Field Notes on Rivers
+	// /missing failed: 404
+}
+
+func ExampleDefaultOptions() {
+	// Options are applied whole: a literal &Options{} leaves selector
+	// removal off, while DefaultOptions keeps it on
+	bare, err := defuddle.ParseFromString(context.Background(), examplePage, &defuddle.Options{RetryStrategy: defuddle.RetryNone})
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := defuddle.DefaultOptions()
+	opts.RetryStrategy = defuddle.RetryNone
+	defaults, err := defuddle.ParseFromString(context.Background(), examplePage, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(strings.Contains(bare.Content, "More stories"))
+	fmt.Println(strings.Contains(defaults.Content, "More stories"))
+	// Output:
+	// true
+	// false
+}
+
+func ExampleMarkdownOptions() {
+	opts := defuddle.DefaultOptions()
+	opts.URL = "https://notes.example/rivers"
+	opts.Markdown = true
+	opts.MarkdownOptions = defuddle.DefaultMarkdownOptions()
+	opts.MarkdownOptions.LinkStyle = defuddle.LinkStyleReference
+
+	result, err := defuddle.ParseFromString(context.Background(), examplePage, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(*result.ContentMarkdown)
+	// Output:
+	// Rivers carve their valleys slowly, moving sand and stone downstream with every flood.
+	//
+	// Read the [sources][1] behind these notes.
+	//
+	// More stories from the archive
+	//
+	// [1]: /sources
+}
+
+// recipeExtractor reads the recipe pages of one site, reporting the serving
+// count as an extractor variable.
+type recipeExtractor struct {
+	*extractors.ExtractorBase
+}
+
+func (e *recipeExtractor) Name() string { return "RecipeExtractor" }
+
+func (e *recipeExtractor) CanExtract() bool {
+	return e.GetDocument().Find(".recipe").Length() > 0
+}
+
+func (e *recipeExtractor) Extract() *extractors.ExtractorResult {
+	recipe := e.GetDocument().Find(".recipe")
+	content, _ := recipe.Find(".steps").Html()
+	return &extractors.ExtractorResult{
+		ContentHTML: content,
+		Variables: map[string]string{
+			"title":    recipe.Find("h2").Text(),
+			"author":   recipe.Find(".cook").Text(),
+			"servings": recipe.AttrOr("data-servings", ""),
+		},
+	}
+}
+
+func Example_customExtractor() {
+	// A registry of its own keeps the extractor away from other parsers
+	registry := extractors.NewBuiltinRegistry().Register(extractors.ExtractorMapping{
+		Name:     "recipes",
+		Patterns: []any{"recipes.example"},
+		Extractor: func(doc *goquery.Document, url string, schemaOrgData any) extractors.BaseExtractor {
+			return &recipeExtractor{ExtractorBase: extractors.NewExtractorBase(doc, url, schemaOrgData)}
+		},
+	})
+	page := `<html><head><title>Recipes | Site</title></head><body>
+		<div class="recipe" data-servings="4">
+			<h2>Tomato Soup</h2><span class="cook">Grace</span>
+			<ol class="steps"><li>Roast the tomatoes.</li><li>Blend with stock.</li></ol>
+		</div></body></html>`
+
+	opts := defuddle.DefaultOptions()
+	opts.URL = "https://recipes.example/tomato-soup"
+	opts.Extractors = registry
+	result, err := defuddle.ParseFromString(context.Background(), page, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// title and author replace the page's metadata; every variable is kept
+	fmt.Println(*result.ExtractorType)
+	fmt.Println(result.Title, "by", result.Author)
+	fmt.Println(result.Variables["servings"])
+	// Output:
+	// recipe
+	// Tomato Soup by Grace
+	// 4
+}
@@ -0,0 +1,537 @@
+// Package export writes a defuddle.Result in document formats for other
+// toolchains.
+//
+// LaTeX writes a standalone article: title, author, and date from the
+// result's metadata, sections from its headings, listings for code, and
+// graphicx includes for the images the caller saved beside the document.
+package export
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kaptinlin/defuddle-go"
+	"github.com/kaptinlin/defuddle-go/internal/dom"
+)
+
+// ImageSaver stores the image at src, resolved against LaTeXOptions.URL
+// when that is a web address, and returns the path \includegraphics names
+// it by, relative to the document. An error leaves the image out.
+type ImageSaver func(src string) (path string, err error)
+
+// LaTeXOptions configures LaTeX.
+type LaTeXOptions struct {
+	// DocumentClass is the \documentclass of the document.
+	// Defaults to "article" when empty.
+	DocumentClass string
+	// URL is where the article was read from. Relative links and images are
+	// resolved against it when it is an http or https URL.
+	URL string
+	// SaveImage stores each image of the content for \includegraphics.
+	// Defaults to nil, which writes each image's alt text instead.
+	SaveImage ImageSaver
+}
+
+// headingCommands are the sectioning commands of h1 to h6. Content keeps
+// h1 only when the article has several, so it shares \section with h2.
+var headingCommands = map[atom.Atom]string{
+	atom.H1: "section", atom.H2: "section", atom.H3: "subsection",
+	atom.H4: "subsubsection", atom.H5: "paragraph", atom.H6: "subparagraph",
+}
+
+// latexEscapes are the replacements of characters LaTeX treats specially.
+var latexEscapes = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `$`, `\$`, `&`, `\&`,
+	`#`, `\#`, `^`, `\textasciicircum{}`, `_`, `\_`, `%`, `\%`, `~`, `\textasciitilde{}`,
+)
+
+// LaTeX writes result to w as a LaTeX document for pdflatex, XeLaTeX, or
+// LuaLaTeX. Headings become \section to \subparagraph, labelled with their
+// ids; code blocks become lstlisting environments and inline code \texttt;
+// figures and images become figure environments with \includegraphics and
+// their caption; tables become tabular; and links become \href. A nil
+// options uses the defaults.
+func LaTeX(result *defuddle.Result, options *LaTeXOptions, w io.Writer) error {
+	if result == nil {
+		result = &defuddle.Result{}
+	}
+	if options == nil {
+		options = &LaTeXOptions{}
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(result.Content), body)
+	if err != nil {
+		return fmt.Errorf("latex: parsing content: %w", err)
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	c := &latexConverter{options: options, base: webBase(options.URL)}
+	c.blocks(body)
+
+	var b strings.Builder
+	class := cmp.Or(strings.TrimSpace(options.DocumentClass), "article")
+	fmt.Fprintf(&b, "\\documentclass{%s}\n", class)
+	b.WriteString("\\usepackage[utf8]{inputenc}\n\\usepackage[T1]{fontenc}\n")
+	b.WriteString("\\usepackage{graphicx}\n\\usepackage{listings}\n\\usepackage{hyperref}\n")
+	b.WriteString("\\lstset{basicstyle=\\ttfamily\\small,breaklines=true,columns=fullflexible}\n\n")
+	fmt.Fprintf(&b, "\\title{%s}\n", escapeText(strings.TrimSpace(result.Title)))
+	fmt.Fprintf(&b, "\\author{%s}\n", escapeText(strings.TrimSpace(result.Author)))
+	fmt.Fprintf(&b, "\\date{%s}\n\n", escapeText(documentDate(result.Published)))
+	b.WriteString("\\begin{document}\n\n\\maketitle\n\n")
+	b.WriteString(strings.TrimSpace(c.out.String()))
+	b.WriteString("\n\n\\end{document}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("latex: %w", err)
+	}
+	return nil
+}
+
+// latexConverter writes the content as LaTeX.
+type latexConverter struct {
+	options *LaTeXOptions
+	base    *url.URL
+	out     strings.Builder
+}
+
+// blocks writes the children of n as paragraphs and environments.
+func (c *latexConverter) blocks(n *html.Node) {
+	var inline strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(inline.String()); text != "" {
+			c.paragraph(text)
+		}
+		inline.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && c.isBlock(child) {
+			flush()
+			c.block(child)
+			continue
+		}
+		inline.WriteString(c.inline(child))
+	}
+	flush()
+}
+
+// isBlock reports whether n is written as its own paragraph or environment.
+func (c *latexConverter) isBlock(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer, atom.Aside,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Ul, atom.Ol, atom.Dl, atom.Blockquote, atom.Pre, atom.Figure, atom.Table, atom.Hr, atom.Img, atom.Picture:
+		return true
+	case atom.Math:
+		return dom.AttrOr(n, "display", "") == "block"
+	case atom.A, atom.Span:
+		return wrapsImage(n)
+	}
+	return false
+}
+
+// wrapsImage reports whether n holds an image and no text, as a link around
+// a photo does, so the image gets a figure rather than its alt text.
+func wrapsImage(n *html.Node) bool {
+	return firstElement(n, atom.Img) != nil && strings.TrimSpace(dom.Text(n)) == ""
+}
+
+// block writes the block element n.
+func (c *latexConverter) block(n *html.Node) {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.TrimSpace(c.inlineChildren(n))
+		if text == "" {
+			return
+		}
+		label := ""
+		if id := dom.AttrOr(n, "id", ""); id != "" {
+			label = "\\label{" + labelName(id) + "}"
+		}
+		c.paragraph("\\" + headingCommands[n.DataAtom] + "{" + text + "}" + label)
+	case atom.P:
+		// Images in the paragraph become figures between its runs of text
+		c.blocks(n)
+	case atom.Ul, atom.Ol:
+		c.list(n)
+	case atom.Dl:
+		c.descriptionList(n)
+	case atom.Blockquote:
+		c.out.WriteString("\\begin{quote}\n")
+		c.blocks(n)
+		c.out.WriteString("\\end{quote}\n\n")
+	case atom.Pre:
+		c.listing(n)
+	case atom.Figure:
+		c.figure(n)
+	case atom.Img, atom.Picture:
+		c.figureImages([]*html.Node{n}, "")
+	case atom.Table:
+		c.table(n, "")
+	case atom.Hr:
+		c.paragraph("\\noindent\\rule{\\linewidth}{0.4pt}")
+	case atom.Math:
+		c.paragraph("\\[" + mathSource(n) + "\\]")
+	default:
+		c.blocks(n)
+	}
+}
+
+// paragraph writes text as a paragraph.
+func (c *latexConverter) paragraph(text string) {
+	c.out.WriteString(text)
+	c.out.WriteString("\n\n")
+}
+
+// list writes a ul or ol as itemize or enumerate.
+func (c *latexConverter) list(n *html.Node) {
+	env := "itemize"
+	if n.DataAtom == atom.Ol {
+		env = "enumerate"
+	}
+	c.out.WriteString("\\begin{" + env + "}\n")
+	for item := range dom.Children(n) {
+		if item.DataAtom != atom.Li {
+			continue
+		}
+		c.out.WriteString("\\item ")
+		c.item(item)
+	}
+	c.out.WriteString("\\end{" + env + "}\n\n")
+}
+
+// item writes the content of a list item, with nested blocks after its text.
+func (c *latexConverter) item(n *html.Node) {
+	var inline strings.Builder
+	var blocks []*html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && c.isBlock(child) {
+			if child.DataAtom == atom.P && len(blocks) == 0 {
+				inline.WriteString(c.inlineChildren(child) + " ")
+				continue
+			}
+			blocks = append(blocks, child)
+			continue
+		}
+		inline.WriteString(c.inline(child))
+	}
+	c.out.WriteString(strings.TrimSpace(inline.String()))
+	c.out.WriteString("\n")
+	for _, block := range blocks {
+		c.block(block)
+	}
+}
+
+// descriptionList writes a dl as a description environment.
+func (c *latexConverter) descriptionList(n *html.Node) {
+	c.out.WriteString("\\begin{description}\n")
+	for child := range dom.Children(n) {
+		switch child.DataAtom {
+		case atom.Dt:
+			c.out.WriteString("\\item[" + strings.TrimSpace(c.inlineChildren(child)) + "] ")
+		case atom.Dd:
+			c.out.WriteString(strings.TrimSpace(c.inlineChildren(child)) + "\n")
+		}
+	}
+	c.out.WriteString("\\end{description}\n\n")
+}
+
+// listing writes a pre block verbatim in a lstlisting environment.
+func (c *latexConverter) listing(n *html.Node) {
+	code := strings.Trim(dom.Text(n), "\n")
+	// The environment ends at the first \end{lstlisting}, so one in the
+	// code is broken up
+	code = strings.ReplaceAll(code, `\end{lstlisting}`, `\end {lstlisting}`)
+	c.out.WriteString("\\begin{lstlisting}\n")
+	c.out.WriteString(code)
+	c.out.WriteString("\n\\end{lstlisting}\n\n")
+}
+
+// figure writes a figure with its images, or its table, and its caption.
+func (c *latexConverter) figure(n *html.Node) {
+	caption := ""
+	var images []*html.Node
+	var table *html.Node
+	for el := range dom.Elements(n) {
+		switch el.DataAtom {
+		case atom.Figcaption:
+			caption = strings.TrimSpace(c.inlineChildren(el))
+		case atom.Img:
+			images = append(images, el)
+		case atom.Table:
+			if table == nil {
+				table = el
+			}
+		}
+	}
+	switch {
+	case table != nil:
+		c.table(table, caption)
+	case len(images) > 0:
+		c.figureImages(images, caption)
+	case caption != "":
+		c.paragraph(caption)
+	}
+}
+
+// figureImages writes images as one figure environment with caption. Images
+// that cannot be saved are replaced by their alt text.
+func (c *latexConverter) figureImages(images []*html.Node, caption string) {
+	var includes, alts []string
+	for _, n := range images {
+		img := n
+		if n.DataAtom == atom.Picture {
+			if img = firstElement(n, atom.Img); img == nil {
+				continue
+			}
+		}
+		if path := c.saveImage(dom.AttrOr(img, "src", "")); path != "" {
+			includes = append(includes, "\\includegraphics[width=\\linewidth]{"+path+"}")
+		} else if alt := strings.TrimSpace(dom.AttrOr(img, "alt", "")); alt != "" {
+			alts = append(alts, escapeText(alt))
+		}
+	}
+	if len(includes) == 0 {
+		if text := strings.TrimSpace(strings.Join(append(alts, caption), " ")); text != "" {
+			c.paragraph(text)
+		}
+		return
+	}
+	c.out.WriteString("\\begin{figure}[htbp]\n\\centering\n")
+	c.out.WriteString(strings.Join(includes, "\n"))
+	c.out.WriteString("\n")
+	if caption != "" {
+		c.out.WriteString("\\caption{" + caption + "}\n")
+	}
+	c.out.WriteString("\\end{figure}\n\n")
+}
+
+// saveImage returns the path of the saved image at src, or "" when it is
+// not saved.
+func (c *latexConverter) saveImage(src string) string {
+	src = strings.TrimSpace(src)
+	if src == "" || c.options.SaveImage == nil {
+		return ""
+	}
+	if !strings.HasPrefix(strings.ToLower(src), "data:") {
+		src = c.resolve(src)
+	}
+	path, err := c.options.SaveImage(src)
+	if err != nil {
+		return ""
+	}
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// table writes a table as a tabular, in a table environment when it has a
+// caption, with a rule below its header row.
+func (c *latexConverter) table(n *html.Node, caption string) {
+	var rows [][]string
+	header := 0
+	columns := 0
+	for el := range dom.Elements(n) {
+		switch el.DataAtom {
+		case atom.Caption:
+			if caption == "" {
+				caption = strings.TrimSpace(c.inlineChildren(el))
+			}
+		case atom.Tr:
+			if dom.Closest(el.Parent, "table") != n {
+				continue
+			}
+			var cells []string
+			allHeaders := true
+			for cell := range dom.Children(el) {
+				if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+					continue
+				}
+				allHeaders = allHeaders && cell.DataAtom == atom.Th
+				cells = append(cells, strings.TrimSpace(c.inlineChildren(cell)))
+			}
+			if len(cells) == 0 {
+				continue
+			}
+			if allHeaders && header == len(rows) {
+				header++
+			}
+			rows = append(rows, cells)
+			columns = max(columns, len(cells))
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	if caption != "" {
+		c.out.WriteString("\\begin{table}[htbp]\n\\centering\n\\caption{" + caption + "}\n")
+	}
+	c.out.WriteString("\\begin{tabular}{" + strings.Repeat("l", columns) + "}\n\\hline\n")
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		c.out.WriteString(strings.Join(row, " & ") + " \\\\\n")
+		if i+1 == header {
+			c.out.WriteString("\\hline\n")
+		}
+	}
+	c.out.WriteString("\\hline\n\\end{tabular}\n")
+	if caption != "" {
+		c.out.WriteString("\\end{table}\n")
+	}
+	c.out.WriteString("\n")
+}
+
+// inlineChildren returns the children of n as inline LaTeX.
+func (c *latexConverter) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return b.String()
+}
+
+// inline returns n as inline LaTeX. Block elements inside inline content
+// are written as their text.
+func (c *latexConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeText(collapseSpace(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	content := func() string { return c.inlineChildren(n) }
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		return "\\textbf{" + content() + "}"
+	case atom.Em, atom.I, atom.Cite:
+		return "\\emph{" + content() + "}"
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return "\\texttt{" + escapeText(dom.Text(n)) + "}"
+	case atom.Sup:
+		return "\\textsuperscript{" + content() + "}"
+	case atom.Sub:
+		return "\\textsubscript{" + content() + "}"
+	case atom.Br:
+		return "\\newline\n"
+	case atom.A:
+		href := strings.TrimSpace(dom.AttrOr(n, "href", ""))
+		text := content()
+		if href == "" || strings.HasPrefix(href, "#") {
+			return text
+		}
+		return "\\href{" + escapeURL(c.resolve(href)) + "}{" + text + "}"
+	case atom.Img:
+		return escapeText(dom.AttrOr(n, "alt", ""))
+	case atom.Math:
+		return "$" + mathSource(n) + "$"
+	case atom.Script, atom.Style, atom.Template:
+		return ""
+	}
+	return content()
+}
+
+// resolve returns ref resolved against the base URL, if there is one.
+func (c *latexConverter) resolve(ref string) string {
+	if c.base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return c.base.ResolveReference(u).String()
+}
+
+// mathSource returns the TeX source standardization kept for a math
+// element, or its escaped text when there is none.
+func mathSource(n *html.Node) string {
+	if source := strings.TrimSpace(dom.AttrOr(n, "data-latex", "")); source != "" {
+		return source
+	}
+	if source := strings.TrimSpace(dom.AttrOr(n, "alttext", "")); source != "" {
+		return source
+	}
+	return escapeText(collapseSpace(dom.Text(n)))
+}
+
+// firstElement returns the first element below n with tag a, or nil.
+func firstElement(n *html.Node, a atom.Atom) *html.Node {
+	for el := range dom.Elements(n) {
+		if el.DataAtom == a {
+			return el
+		}
+	}
+	return nil
+}
+
+// documentDate returns published as a date such as "March 9, 2024", the
+// value as written when it is not an ISO 8601 date, or "" when empty.
+func documentDate(published string) string {
+	published = strings.TrimSpace(published)
+	if len(published) >= 10 {
+		if t, err := time.Parse("2006-01-02", published[:10]); err == nil {
+			return t.Format("January 2, 2006")
+		}
+	}
+	return published
+}
+
+// labelName returns id with the characters \label does not take replaced.
+func labelName(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\\', '{', '}', '%', '#', '~', '$', '&', '^', '_':
+			return '-'
+		}
+		return r
+	}, id)
+}
+
+// escapeText returns s with the characters LaTeX treats specially escaped.
+func escapeText(s string) string {
+	return latexEscapes.Replace(s)
+}
+
+// escapeURL returns u for \href, escaping the characters hyperref needs.
+func escapeURL(u string) string {
+	return strings.NewReplacer(`\`, `\\`, `#`, `\#`, `%`, `\%`, `{`, `\{`, `}`, `\}`).Replace(u)
+}
+
+// collapseSpace returns s with each run of whitespace replaced by a space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// webBase returns raw as a URL when it is an http or https address.
+func webBase(raw string) *url.URL {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil
+	}
+	return u
+}
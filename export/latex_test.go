@@ -0,0 +1,105 @@
+package export
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+func latex(t *testing.T, result *defuddle.Result, options *LaTeXOptions) string {
+	t.Helper()
+
+	var out strings.Builder
+	require.NoError(t, LaTeX(result, options, &out))
+	return out.String()
+}
+
+func TestLaTeXWritesPreambleFromMetadata(t *testing.T) {
+	t.Parallel()
+
+	doc := latex(t, &defuddle.Result{
+		Metadata: defuddle.Metadata{Title: "Costs & Benefits of 100% Uptime", Author: "Jane Doe", Published: "2024-03-09T10:00:00Z"},
+		Content:  "<p>Body</p>",
+	}, nil)
+
+	assert.True(t, strings.HasPrefix(doc, "\\documentclass{article}\n"))
+	assert.Contains(t, doc, "\\usepackage{graphicx}\n\\usepackage{listings}\n\\usepackage{hyperref}\n")
+	assert.Contains(t, doc, "\\title{Costs \\& Benefits of 100\\% Uptime}\n\\author{Jane Doe}\n\\date{March 9, 2024}\n")
+	assert.Contains(t, doc, "\\begin{document}\n\n\\maketitle\n\nBody\n\n\\end{document}\n")
+}
+
+func TestLaTeXConvertsContent(t *testing.T) {
+	t.Parallel()
+
+	doc := latex(t, &defuddle.Result{Content: `<h2 id="setup">Set_up</h2>` +
+		`<p>Run <code>make $TARGET</code> with <strong>care</strong>, see <a href="/docs?a=1#b">the docs</a> and <a href="#setup">above</a>.</p>` +
+		`<h3>Steps</h3><ol><li>One</li><li>Two<ul><li>Nested</li></ul></li></ol>` +
+		`<pre><code>if a &lt; b {
+	return "{}"
+}</code></pre>` +
+		`<blockquote><p>Quoted</p></blockquote>` +
+		`<table><caption>Rates</caption><tr><th>Year</th><th>Rate</th></tr><tr><td>2024</td><td>5%</td></tr></table>` +
+		`<p>Energy <math data-latex="E = mc^2"><mi>E</mi></math></p>`},
+		&LaTeXOptions{URL: "https://example.com/post/1"})
+
+	assert.Contains(t, doc, "\\section{Set\\_up}\\label{setup}\n\n")
+	assert.Contains(t, doc, "Run \\texttt{make \\$TARGET} with \\textbf{care}, see \\href{https://example.com/docs?a=1\\#b}{the docs} and above.\n\n")
+	assert.Contains(t, doc, "\\subsection{Steps}\n\n")
+	assert.Contains(t, doc, "\\begin{enumerate}\n\\item One\n\\item Two\n\\begin{itemize}\n\\item Nested\n\\end{itemize}\n\n\\end{enumerate}\n\n")
+	assert.Contains(t, doc, "\\begin{lstlisting}\nif a < b {\n\treturn \"{}\"\n}\n\\end{lstlisting}\n\n")
+	assert.Contains(t, doc, "\\begin{quote}\nQuoted\n\n\\end{quote}\n\n")
+	assert.Contains(t, doc, "\\begin{table}[htbp]\n\\centering\n\\caption{Rates}\n\\begin{tabular}{ll}\n\\hline\nYear & Rate \\\\\n\\hline\n2024 & 5\\% \\\\\n\\hline\n\\end{tabular}\n\\end{table}\n\n")
+	assert.Contains(t, doc, "Energy $E = mc^2$\n\n")
+}
+
+func TestLaTeXIncludesSavedImages(t *testing.T) {
+	t.Parallel()
+
+	var saved []string
+	options := &LaTeXOptions{
+		URL: "https://example.com/post/1",
+		SaveImage: func(src string) (string, error) {
+			saved = append(saved, src)
+			if strings.HasSuffix(src, ".gif") {
+				return "", errors.New("unsupported")
+			}
+			return "article-images/image-1.png", nil
+		},
+	}
+	doc := latex(t, &defuddle.Result{Content: `<figure><img src="/chart.png" alt="Chart"><figcaption>Sales by <em>year</em></figcaption></figure>` +
+		`<p>Between.</p><img src="spinner.gif" alt="Spinner">`}, options)
+
+	assert.Equal(t, []string{"https://example.com/chart.png", "https://example.com/post/spinner.gif"}, saved)
+	assert.Contains(t, doc, "\\begin{figure}[htbp]\n\\centering\n\\includegraphics[width=\\linewidth]{article-images/image-1.png}\n\\caption{Sales by \\emph{year}}\n\\end{figure}\n\n")
+	assert.Contains(t, doc, "Between.\n\nSpinner\n\n")
+}
+
+func TestLaTeXIncludesImagesInParagraphs(t *testing.T) {
+	t.Parallel()
+
+	var saved []string
+	options := &LaTeXOptions{SaveImage: func(src string) (string, error) {
+		saved = append(saved, src)
+		return "images/" + src, nil
+	}}
+	doc := latex(t, &defuddle.Result{Content: `<p>Intro <img src="a.png" alt="A"></p><p><a href="https://example.com/b.png"><img src="b.png" alt="B"></a></p>`}, options)
+
+	assert.Equal(t, []string{"a.png", "b.png"}, saved)
+	assert.Contains(t, doc, "Intro\n\n\\begin{figure}[htbp]\n\\centering\n\\includegraphics[width=\\linewidth]{images/a.png}\n\\end{figure}\n\n")
+	assert.Contains(t, doc, "\\includegraphics[width=\\linewidth]{images/b.png}\n")
+}
+
+func TestLaTeXWritesAltTextWithoutImageSaver(t *testing.T) {
+	t.Parallel()
+
+	doc := latex(t, &defuddle.Result{Content: `<figure><img src="chart.png" alt="Chart"><figcaption>Sales</figcaption></figure>`}, nil)
+
+	assert.NotContains(t, doc, "\\includegraphics{")
+	assert.NotContains(t, doc, "\\begin{figure}")
+	assert.Contains(t, doc, "Chart Sales\n\n")
+}
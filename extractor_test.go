@@ -2,8 +2,11 @@ package defuddle
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -12,9 +15,6 @@ import (
 
 func TestExtractors(t *testing.T) {
 	t.Run("GitHub extractor registration and detection", func(t *testing.T) {
-		// Initialize extractors
-		extractors.InitializeBuiltins()
-
 		// Test GitHub URL detection
 		githubHTML := `<html>
 			<head>
@@ -154,3 +154,137 @@ func TestParseWithExtractorHonorsMarkdownOption(t *testing.T) {
 	assert.Contains(t, result.Content, "Extractor markdown body")
 	assert.Contains(t, *result.ContentMarkdown, "Extractor markdown body")
 }
+
+type isolatedRegistryExtractor struct {
+	*extractors.ExtractorBase
+}
+
+func (e *isolatedRegistryExtractor) CanExtract() bool { return true }
+
+func (e *isolatedRegistryExtractor) Extract() *extractors.ExtractorResult {
+	return &extractors.ExtractorResult{Content: "from isolated registry", ContentHTML: "<p>from isolated registry</p>"}
+}
+
+func (e *isolatedRegistryExtractor) Name() string { return "IsolatedRegistryExtractor" }
+
+func TestParseUsesOptionsExtractorsRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := extractors.NewBuiltinRegistry().Register(extractors.ExtractorMapping{
+		Patterns: []any{"isolated.example"},
+		Extractor: func(doc *goquery.Document, url string, schemaOrgData any) extractors.BaseExtractor {
+			return &isolatedRegistryExtractor{ExtractorBase: extractors.NewExtractorBase(doc, url, schemaOrgData)}
+		},
+	})
+	html := `<html><head><title>Isolated</title></head><body><article><p>Generic page body.</p></article></body></html>`
+
+	isolated, err := ParseFromString(context.Background(), html, &Options{URL: "https://isolated.example/post", Extractors: registry})
+	require.NoError(t, err)
+	assert.Contains(t, isolated.Content, "from isolated registry")
+
+	shared, err := ParseFromString(context.Background(), html, &Options{URL: "https://isolated.example/post"})
+	require.NoError(t, err)
+	assert.NotContains(t, shared.Content, "from isolated registry")
+	assert.Contains(t, shared.Content, "Generic page body")
+}
+
+func TestParseKeepsExtractorVariables(t *testing.T) {
+	t.Parallel()
+
+	registry := extractors.NewRegistry().Register(extractors.ExtractorMapping{
+		Patterns: []any{"variables.example"},
+		Extractor: func(doc *goquery.Document, url string, schemaOrgData any) extractors.BaseExtractor {
+			return &variablesExtractor{ExtractorBase: extractors.NewExtractorBase(doc, url, schemaOrgData)}
+		},
+	})
+	html := `<html><head><title>Page Title</title></head><body><article><p>Body.</p></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{URL: "https://variables.example/post", Extractors: registry})
+	require.NoError(t, err)
+	assert.Equal(t, "Extracted Title", result.Title)
+	assert.Equal(t, "Variables Site", result.Site)
+	assert.Equal(t, ExtractorVariables{"title": "Extracted Title", "site": "Variables Site", "points": "42"}, result.Variables)
+
+	generic, err := ParseFromString(context.Background(), html, &Options{URL: "https://other.example/post", Extractors: registry})
+	require.NoError(t, err)
+	assert.Nil(t, generic.Variables)
+}
+
+type variablesExtractor struct {
+	*extractors.ExtractorBase
+}
+
+func (e *variablesExtractor) CanExtract() bool { return true }
+
+func (e *variablesExtractor) Extract() *extractors.ExtractorResult {
+	return &extractors.ExtractorResult{
+		ContentHTML: "<p>Extracted body.</p>",
+		Variables:   map[string]string{"title": "Extracted Title", "site": "Variables Site", "points": "42"},
+	}
+}
+
+func (e *variablesExtractor) Name() string { return "VariablesExtractor" }
+
+func TestParseStripsAssetsFromExtractorContent(t *testing.T) {
+	t.Parallel()
+
+	rules := extractors.RuleSet{Extractors: []extractors.Rule{{
+		Name:    "widgets",
+		Domains: []string{"widgets.example"},
+		Content: "#post",
+	}}}
+	mappings, err := rules.Mappings()
+	require.NoError(t, err)
+	registry := extractors.NewRegistry()
+	for _, mapping := range mappings {
+		registry.Register(mapping)
+	}
+	html := `<html><body><div id="post"><p>Widget post body.</p><script>leak()</script><style>.leak{}</style></div></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{URL: "https://widgets.example/post", Extractors: registry})
+	require.NoError(t, err)
+	require.NotNil(t, result.ExtractorType)
+	assert.Equal(t, `<div id="post"><p>Widget post body.</p></div>`, result.Content)
+}
+
+// transportFunc adapts a function to extractors.ExternalTransport.
+type transportFunc func(ctx context.Context, request []byte) ([]byte, error)
+
+func (f transportFunc) Call(ctx context.Context, request []byte) ([]byte, error) {
+	return f(ctx, request)
+}
+
+func TestParseReportsFailedExternalExtractor(t *testing.T) {
+	t.Parallel()
+
+	transport := transportFunc(func(context.Context, []byte) ([]byte, error) {
+		return nil, fmt.Errorf("%w: connection refused", extractors.ErrExternalExtractor)
+	})
+	registry := extractors.NewRegistry().Register(extractors.NewExternalMapping("remote", []any{"remote.example"}, transport, 0))
+	html := `<html><head><title>Remote</title></head><body><article><p>Generic page body.</p></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{URL: "https://remote.example/post", Extractors: registry})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, "Generic page body")
+	require.NotEmpty(t, result.Issues)
+	assert.Equal(t, IssueExtractorFailed, result.Issues[0].Code)
+	assert.Contains(t, result.Issues[0].Message, "remote: external extractor failed: connection refused")
+}
+
+func TestParseCancelsExternalExtractor(t *testing.T) {
+	t.Parallel()
+
+	transport := transportFunc(func(ctx context.Context, _ []byte) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	registry := extractors.NewRegistry().Register(extractors.NewExternalMapping("slow", []any{"slow.example"}, transport, time.Minute))
+	html := `<html><body><article><p>Generic page body.</p></article></body></html>`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := ParseFromString(ctx, html, &Options{URL: "https://slow.example/post", Extractors: registry})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
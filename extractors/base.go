@@ -1,4 +1,27 @@
 // Package extractors provides site-specific content extraction functionality.
+//
+// Built-in extractors are registered in DefaultRegistry when the package is
+// initialized. Extractors that live in other packages follow the same
+// pattern: register from the package's init function and have programs
+// enable them with a blank import, so registration is complete before any
+// parsing starts.
+//
+//	package blogextractor
+//
+//	func init() {
+//		extractors.Register(extractors.ExtractorMapping{
+//			Patterns:  []any{"blog.example.com"},
+//			Extractor: NewBlogExtractor,
+//		})
+//	}
+//
+//	import _ "example.com/blogextractor"
+//
+// Registries are safe for concurrent use, so registering at run time is also
+// fine, as is removing a mapping by name with Unregister. Sites without
+// recognizable URLs can be routed on the parsed document with
+// RegisterMatcher. A server that needs different extractors per tenant can build one
+// registry each with NewBuiltinRegistry and pass it in defuddle.Options.Extractors.
 package extractors
 
 import (
@@ -44,6 +67,7 @@ type ExtractorBase struct {
 	document      *goquery.Document
 	url           string
 	schemaOrgData any
+	routeParams   map[string]string
 }
 
 // NewExtractorBase creates a new base extractor
@@ -1,6 +1,7 @@
 package extractors
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -288,6 +289,72 @@ func TestHackerNewsExtractorExtractsPostAndNestedComments(t *testing.T) {
 	}
 }
 
+func TestHackerNewsExtractorNestsThreadsWithoutChrome(t *testing.T) {
+	t.Parallel()
+
+	comment := func(id, indent, author, text string) string {
+		return `<tr class="athing comtr" id="` + id + `"><td><table><tr>
+			<td class="ind" indent="` + indent + `"><img src="s.gif" height="1" width="` + indent + `0"></td>
+			<td class="votelinks"><center><a id="up_` + id + `" href="vote?id=` + id + `&how=up"><div class="votearrow" title="upvote"></div></a></center></td>
+			<td class="default"><div><span class="comhead"><a class="hnuser">` + author + `</a> <span class="age" title="2026-04-22T12:00:00Z"><a>1 hour ago</a></span> <a class="togg clicky" n="2">[–]</a></span></div>
+			<div class="comment"><div class="commtext c00">` + text + `</div>
+			<div class="reply"><p><font size="1"><u><a href="reply?id=` + id + `&goto=item%3Fid%3D1">reply</a></u></font></p></div></div></td>
+		</tr></table></td></tr>`
+	}
+	doc := newTestDocument(t, `<html><body><table class="fatitem">
+		<tr class="athing submission" id="1"><td class="votelinks"><a href="vote?id=1&how=up"><div class="votearrow"></div></a></td><td class="title"><span class="titleline"><a href="item?id=1">Ask HN: How do rivers carve valleys?</a></span></td></tr>
+		<tr><td class="subtext"><span class="score">12 points</span> by <a class="hnuser">alice</a> <span class="age" title="2026-04-21T12:00:00Z"></span></td></tr>
+		<tr><td><div class="toptext">Asking for a friend.</div></td></tr>
+	</table>
+	<table class="comment-tree">`+
+		comment("10", "0", "bob", "Top A")+
+		comment("11", "1", "carol", "Reply A1")+
+		comment("12", "2", "dave", "Reply A1a")+
+		comment("13", "1", "erin", "Reply A2")+
+		comment("14", "0", "frank", "Top B")+
+		`</table></body></html>`)
+	result := NewHackerNewsExtractor(doc, "https://news.ycombinator.com/item?id=1", nil).Extract()
+
+	comments := result.ContentHTML[strings.Index(result.ContentHTML, `<div class="hackernews-comments">`):]
+	var shape strings.Builder
+	for _, token := range regexp.MustCompile(`</?blockquote>|Top \w|Reply \w+`).FindAllString(comments, -1) {
+		shape.WriteString(token + " ")
+	}
+	want := "<blockquote> Top A <blockquote> Reply A1 <blockquote> Reply A1a </blockquote> Reply A2 </blockquote> </blockquote> <blockquote> Top B </blockquote> "
+	if shape.String() != want {
+		t.Fatalf("comment nesting = %q, want %q", shape.String(), want)
+	}
+	for _, unwanted := range []string{"votearrow", "vote?id", "reply?id", ">reply<", "[–]", "• •"} {
+		if strings.Contains(result.ContentHTML, unwanted) {
+			t.Fatalf("ContentHTML = %q, want no %q", result.ContentHTML, unwanted)
+		}
+	}
+	if got := result.Variables["submission"]; got != "Ask HN: How do rivers carve valleys?" {
+		t.Fatalf("Variables[submission] = %q, want the story title", got)
+	}
+	if got := result.Variables["url"]; got != "https://news.ycombinator.com/item?id=1" {
+		t.Fatalf("Variables[url] = %q, want the absolute item URL", got)
+	}
+}
+
+func TestHackerNewsExtractorKeepsStoryOfCommentPage(t *testing.T) {
+	t.Parallel()
+
+	doc := newTestDocument(t, `<html><body><table class="fatitem">
+		<tr class="athing" id="456"><td class="default"><span class="comhead"><a class="hnuser">commenter</a> <span class="age" title="2026-04-22T12:00:00Z"></span>
+			<span class="navs"> | <a href="item?id=100">parent</a></span><span class="onstory"> | on: <a href="item?id=100">Example article</a></span></span>
+			<div class="comment"><div class="commtext c00">A comment worth its own page.</div></div></td></tr>
+	</table></body></html>`)
+	result := NewHackerNewsExtractor(doc, "https://news.ycombinator.com/item?id=456", nil).Extract()
+
+	if got := result.Variables["submission"]; got != "Example article" {
+		t.Fatalf("Variables[submission] = %q, want the story title", got)
+	}
+	if got := result.Variables["url"]; got != "https://news.ycombinator.com/item?id=100" {
+		t.Fatalf("Variables[url] = %q, want the story URL", got)
+	}
+}
+
 func TestHackerNewsExtractorExtractsCommentPage(t *testing.T) {
 	t.Parallel()
 
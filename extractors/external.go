@@ -0,0 +1,385 @@
+package extractors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-json-experiment/json"
+)
+
+// DefaultExternalTimeout bounds one call to an external extractor.
+const DefaultExternalTimeout = 10 * time.Second
+
+// maxExternalResponse caps the size of an external extractor's response.
+const maxExternalResponse = 32 << 20
+
+// ErrExternalExtractor is wrapped by errors from external extractor calls.
+var ErrExternalExtractor = errors.New("external extractor failed")
+
+// ExternalRequest is the JSON document sent to an external extractor
+//
+//	{"html": "<html>...", "url": "https://...", "schemaOrgData": [...]}
+type ExternalRequest struct {
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	SchemaOrgData any    `json:"schemaOrgData,omitempty"`
+}
+
+// ExternalResponse is the JSON document an external extractor answers with
+// It has the shape of defuddle.ExtractedContent. A response with neither
+// content nor contentHtml declines the page, and generic extraction runs.
+type ExternalResponse struct {
+	Title       string            `json:"title,omitempty"`
+	Author      string            `json:"author,omitempty"`
+	Published   string            `json:"published,omitempty"`
+	Content     string            `json:"content,omitempty"`
+	ContentHTML string            `json:"contentHtml,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty"`
+}
+
+// ContextExtractor is implemented by extractors whose CanExtract does work
+// the parse should be able to cancel, such as ExternalExtractor. The parser
+// calls CanExtractContext with its context instead of CanExtract, and
+// reports Err when it returns false.
+type ContextExtractor interface {
+	BaseExtractor
+	CanExtractContext(ctx context.Context) bool
+	// Err returns why CanExtractContext returned false, or nil when the
+	// extractor declined the page.
+	Err() error
+}
+
+// ExternalTransport delivers one request to an external extractor
+type ExternalTransport interface {
+	Call(ctx context.Context, request []byte) ([]byte, error)
+}
+
+// ProcessTransport runs a command per request, writing the request to its
+// stdin and reading the response from its stdout. A non-zero exit fails the
+// call, with stderr in the error, and so does stdout longer than 32 MiB,
+// which stops the command.
+type ProcessTransport struct {
+	// Command is the program to run, looked up in PATH.
+	Command string
+	// Args are passed to Command.
+	Args []string
+	// Dir is the working directory. Defaults to the current directory.
+	Dir string
+	// Env holds extra "KEY=value" variables on top of the current environment.
+	Env []string
+}
+
+// Call implements ExternalTransport.
+func (t *ProcessTransport) Call(ctx context.Context, request []byte) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, t.Command, t.Args...)
+	cmd.Dir = t.Dir
+	if len(t.Env) > 0 {
+		cmd.Env = append(os.Environ(), t.Env...)
+	}
+	cmd.Stdin = bytes.NewReader(request)
+	stdout := &limitedBuffer{limit: maxExternalResponse, exceeded: cancel}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if stdout.full {
+		return nil, fmt.Errorf("%w: %s: response longer than %d bytes", ErrExternalExtractor, t.Command, maxExternalResponse)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w: %s", ErrExternalExtractor, t.Command, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// limitedBuffer keeps up to limit bytes written to it. Past that it
+// discards writes, sets full, and calls exceeded once. The buffer is a
+// field, not embedded, so io.Copy cannot bypass Write through ReadFrom.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	full     bool
+	exceeded func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.full {
+		return len(p), nil
+	}
+	if b.buf.Len()+len(p) > b.limit {
+		b.full = true
+		b.exceeded()
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// HTTPTransport POSTs each request as JSON to URL and reads the response body.
+// Responses other than 200, and bodies longer than 32 MiB, fail the call.
+type HTTPTransport struct {
+	// URL is the extractor endpoint.
+	URL string
+	// Headers are added to every request, e.g. an API key.
+	Headers map[string]string
+	// Client is the HTTP client used for calls. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Call implements ExternalTransport.
+func (t *HTTPTransport) Call(ctx context.Context, request []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(request))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalExtractor, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalExtractor, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxExternalResponse+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalExtractor, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s: HTTP %d", ErrExternalExtractor, t.URL, resp.StatusCode)
+	}
+	if len(body) > maxExternalResponse {
+		return nil, fmt.Errorf("%w: %s: response longer than %d bytes", ErrExternalExtractor, t.URL, maxExternalResponse)
+	}
+	return body, nil
+}
+
+// ExternalExtractor runs extraction in another process or service through an
+// ExternalTransport, so site extractors can be written in any language.
+// The call is made by CanExtractContext, bounded by the timeout and the
+// parse context; when it fails or the response declines the page, it
+// returns false and the parser falls back to generic extraction.
+type ExternalExtractor struct {
+	*ExtractorBase
+	name      string
+	transport ExternalTransport
+	timeout   time.Duration
+
+	called bool
+	result *ExtractorResult
+	err    error
+}
+
+// NewExternalExtractor creates an extractor that delegates to transport.
+// A zero timeout means DefaultExternalTimeout.
+func NewExternalExtractor(document *goquery.Document, url string, schemaOrgData any, name string, transport ExternalTransport, timeout time.Duration) *ExternalExtractor {
+	if timeout <= 0 {
+		timeout = DefaultExternalTimeout
+	}
+	return &ExternalExtractor{
+		ExtractorBase: NewExtractorBase(document, url, schemaOrgData),
+		name:          name,
+		transport:     transport,
+		timeout:       timeout,
+	}
+}
+
+// NewExternalMapping returns a mapping that routes URLs matching patterns to
+// an ExternalExtractor. Patterns follow ExtractorMapping.
+func NewExternalMapping(name string, patterns []any, transport ExternalTransport, timeout time.Duration) ExtractorMapping {
+	return ExtractorMapping{
+		Name:     name,
+		Patterns: patterns,
+		Extractor: func(document *goquery.Document, url string, schemaOrgData any) BaseExtractor {
+			return NewExternalExtractor(document, url, schemaOrgData, name, transport, timeout)
+		},
+	}
+}
+
+// CanExtract calls the external extractor and reports whether it returned content.
+func (e *ExternalExtractor) CanExtract() bool {
+	return e.CanExtractContext(context.Background())
+}
+
+// CanExtractContext implements ContextExtractor. The call is made once;
+// later calls report its outcome.
+func (e *ExternalExtractor) CanExtractContext(ctx context.Context) bool {
+	if !e.called {
+		e.called = true
+		e.result, e.err = e.call(ctx)
+	}
+	return e.err == nil && e.result != nil
+}
+
+// Extract returns the external extractor's result, or an empty result when
+// CanExtract would return false.
+func (e *ExternalExtractor) Extract() *ExtractorResult {
+	if !e.CanExtract() {
+		return &ExtractorResult{}
+	}
+	return e.result
+}
+
+// Name returns the name the extractor was registered with.
+func (e *ExternalExtractor) Name() string {
+	return e.name
+}
+
+// Err returns the error from the external call, if any.
+func (e *ExternalExtractor) Err() error {
+	return e.err
+}
+
+// call sends the page to the transport and converts the response.
+func (e *ExternalExtractor) call(ctx context.Context) (*ExtractorResult, error) {
+	html, err := e.GetDocument().Html()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalExtractor, err)
+	}
+	request, err := json.Marshal(ExternalRequest{HTML: html, URL: e.GetURL(), SchemaOrgData: e.GetSchemaOrgData()})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalExtractor, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	body, err := e.transport.Call(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response ExternalResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %s: invalid response: %w", ErrExternalExtractor, e.name, err)
+	}
+	if response.Content == "" && response.ContentHTML == "" {
+		return nil, nil
+	}
+
+	variables := make(map[string]string, len(response.Variables)+3)
+	maps.Copy(variables, response.Variables)
+	for key, value := range map[string]string{"title": response.Title, "author": response.Author, "published": response.Published} {
+		if value != "" {
+			variables[key] = value
+		}
+	}
+	contentHTML := response.ContentHTML
+	if contentHTML == "" {
+		contentHTML = response.Content
+	}
+	return &ExtractorResult{Content: response.Content, ContentHTML: contentHTML, Variables: variables}, nil
+}
+
+// ExternalManifest lists external extractors to register, typically loaded
+// from a JSON file with LoadExternalManifest:
+//
+//	{"extractors": [
+//	  {"name": "blog", "domains": ["blog.example.com"], "command": ["python3", "blog.py"]},
+//	  {"name": "shop", "urlPatterns": ["^https://shop\\.example\\.com/p/"], "url": "http://127.0.0.1:9000/extract", "timeout": "5s"}
+//	]}
+type ExternalManifest struct {
+	Extractors []ExternalManifestEntry `json:"extractors"`
+}
+
+// ExternalManifestEntry describes one external extractor. Exactly one of
+// Command and URL must be set.
+type ExternalManifestEntry struct {
+	// Name identifies the extractor in results and metrics.
+	Name string `json:"name"`
+	// Domains match hostnames and their subdomains.
+	Domains []string `json:"domains,omitempty"`
+	// URLPatterns are regular expressions matched against the full URL.
+	URLPatterns []string `json:"urlPatterns,omitempty"`
+	// Command is the program and arguments of a ProcessTransport.
+	Command []string `json:"command,omitempty"`
+	// URL is the endpoint of an HTTPTransport.
+	URL string `json:"url,omitempty"`
+	// Timeout bounds each call, e.g. "5s". Defaults to DefaultExternalTimeout.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// LoadExternalManifest reads an ExternalManifest from path and returns one
+// mapping per entry, in file order.
+func LoadExternalManifest(path string) ([]ExtractorMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read extractor manifest: %w", err)
+	}
+	var manifest ExternalManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse extractor manifest %s: %w", path, err)
+	}
+	return manifest.Mappings()
+}
+
+// Mappings validates the manifest and returns one mapping per entry.
+func (m *ExternalManifest) Mappings() ([]ExtractorMapping, error) {
+	mappings := make([]ExtractorMapping, 0, len(m.Extractors))
+	for i, entry := range m.Extractors {
+		mapping, err := entry.mapping()
+		if err != nil {
+			return nil, fmt.Errorf("extractor manifest entry %d (%q): %w", i, entry.Name, err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// mapping validates the entry and builds its mapping.
+func (e ExternalManifestEntry) mapping() (ExtractorMapping, error) {
+	if e.Name == "" {
+		return ExtractorMapping{}, errors.New("name is required")
+	}
+	if len(e.Domains) == 0 && len(e.URLPatterns) == 0 {
+		return ExtractorMapping{}, errors.New("domains or urlPatterns is required")
+	}
+
+	var transport ExternalTransport
+	switch {
+	case len(e.Command) > 0 && e.URL != "":
+		return ExtractorMapping{}, errors.New("command and url are mutually exclusive")
+	case len(e.Command) > 0:
+		transport = &ProcessTransport{Command: e.Command[0], Args: e.Command[1:]}
+	case e.URL != "":
+		transport = &HTTPTransport{URL: e.URL}
+	default:
+		return ExtractorMapping{}, errors.New("command or url is required")
+	}
+
+	var timeout time.Duration
+	if e.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(e.Timeout); err != nil {
+			return ExtractorMapping{}, fmt.Errorf("timeout: %w", err)
+		}
+	}
+
+	patterns := make([]any, 0, len(e.Domains)+len(e.URLPatterns))
+	for _, domain := range e.Domains {
+		patterns = append(patterns, domain)
+	}
+	for _, pattern := range e.URLPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return ExtractorMapping{}, fmt.Errorf("urlPatterns: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return NewExternalMapping(e.Name, patterns, transport, timeout), nil
+}
@@ -0,0 +1,261 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+)
+
+// TestExternalExtractorHelperProcess is not a real test. ProcessTransport
+// tests run the test binary itself as the external extractor.
+func TestExternalExtractorHelperProcess(t *testing.T) {
+	if os.Getenv("DEFUDDLE_EXTERNAL_HELPER") != "1" {
+		return
+	}
+
+	var request ExternalRequest
+	data, _ := io.ReadAll(os.Stdin)
+	if err := json.Unmarshal(data, &request); err != nil {
+		os.Exit(2)
+	}
+	if strings.Contains(request.URL, "huge") {
+		// Keeps writing until stopped
+		chunk := []byte(strings.Repeat(" ", 1<<20))
+		for {
+			if _, err := os.Stdout.Write(chunk); err != nil {
+				os.Exit(1)
+			}
+		}
+	}
+	if strings.Contains(request.URL, "fail") {
+		_, _ = os.Stderr.WriteString("boom")
+		os.Exit(1)
+	}
+	response, _ := json.Marshal(ExternalResponse{
+		Title:       "From process",
+		ContentHTML: "<p>" + request.URL + "</p>",
+		Variables:   map[string]string{"site": "Helper"},
+	})
+	_, _ = os.Stdout.Write(response)
+	os.Exit(0)
+}
+
+func helperTransport() *ProcessTransport {
+	return &ProcessTransport{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestExternalExtractorHelperProcess$"},
+		Env:     []string{"DEFUDDLE_EXTERNAL_HELPER=1"},
+	}
+}
+
+func TestExternalExtractorCallsProcess(t *testing.T) {
+	t.Parallel()
+
+	doc := newTestDocument(t, `<html><body><p>page</p></body></html>`)
+	extractor := NewExternalExtractor(doc, "https://blog.example.com/post", nil, "blog", helperTransport(), 0)
+
+	if !extractor.CanExtract() {
+		t.Fatalf("CanExtract() = false, err = %v", extractor.Err())
+	}
+	result := extractor.Extract()
+	if result.ContentHTML != "<p>https://blog.example.com/post</p>" {
+		t.Fatalf("ContentHTML = %q", result.ContentHTML)
+	}
+	if result.Variables["title"] != "From process" || result.Variables["site"] != "Helper" {
+		t.Fatalf("Variables = %v, want title and site from the response", result.Variables)
+	}
+	if extractor.Name() != "blog" {
+		t.Fatalf("Name() = %q, want %q", extractor.Name(), "blog")
+	}
+}
+
+func TestExternalExtractorProcessFailureFallsBack(t *testing.T) {
+	t.Parallel()
+
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	extractor := NewExternalExtractor(doc, "https://blog.example.com/fail", nil, "blog", helperTransport(), 0)
+
+	if extractor.CanExtract() {
+		t.Fatal("CanExtract() = true, want false when the process exits non-zero")
+	}
+	if err := extractor.Err(); !errors.Is(err, ErrExternalExtractor) || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("Err() = %v, want ErrExternalExtractor with stderr", err)
+	}
+	if got := extractor.Extract(); got.ContentHTML != "" {
+		t.Fatalf("Extract() = %+v, want empty result", got)
+	}
+}
+
+func TestExternalExtractorRejectsOversizedResponses(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat(" ", maxExternalResponse+1)))
+	}))
+	defer server.Close()
+
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	for name, transport := range map[string]ExternalTransport{"process": helperTransport(), "http": &HTTPTransport{URL: server.URL}} {
+		extractor := NewExternalExtractor(doc, "https://blog.example.com/huge", nil, name, transport, 0)
+		if extractor.CanExtract() {
+			t.Fatalf("%s: CanExtract() = true, want false for an oversized response", name)
+		}
+		if err := extractor.Err(); !errors.Is(err, ErrExternalExtractor) || !strings.Contains(err.Error(), "response longer than") {
+			t.Fatalf("%s: Err() = %v, want ErrExternalExtractor for the size", name, err)
+		}
+	}
+}
+
+func TestExternalExtractorStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	transport := stubTransport(func(ctx context.Context, _ []byte) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	extractor := NewExternalExtractor(doc, "https://x.example/", nil, "x", transport, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if extractor.CanExtractContext(ctx) || !errors.Is(extractor.Err(), context.Canceled) {
+		t.Fatalf("Err() = %v, want context.Canceled", extractor.Err())
+	}
+}
+
+func TestExternalExtractorCallsHTTPEndpoint(t *testing.T) {
+	t.Parallel()
+
+	var got ExternalRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		_, _ = w.Write([]byte(`{"title":"Remote","content":"plain text"}`))
+	}))
+	defer server.Close()
+
+	doc := newTestDocument(t, `<html><body><p>page</p></body></html>`)
+	transport := &HTTPTransport{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer key"}}
+	extractor := NewExternalExtractor(doc, "https://shop.example.com/p/1", map[string]any{"@type": "Product"}, "shop", transport, 0)
+
+	if !extractor.CanExtract() {
+		t.Fatalf("CanExtract() = false, err = %v", extractor.Err())
+	}
+	if result := extractor.Extract(); result.ContentHTML != "plain text" || result.Variables["title"] != "Remote" {
+		t.Fatalf("Extract() = %+v, want content used as HTML and title variable", result)
+	}
+	if got.URL != "https://shop.example.com/p/1" || !strings.Contains(got.HTML, "<p>page</p>") || got.SchemaOrgData == nil {
+		t.Fatalf("request = %+v, want page HTML, URL, and schema.org data", got)
+	}
+}
+
+func TestExternalExtractorDeclinesEmptyResponse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	extractor := NewExternalExtractor(doc, "https://shop.example.com/", nil, "shop", &HTTPTransport{URL: server.URL}, 0)
+	if extractor.CanExtract() || extractor.Err() != nil {
+		t.Fatalf("CanExtract() = true or Err() = %v, want a silent decline", extractor.Err())
+	}
+}
+
+type stubTransport func(ctx context.Context, request []byte) ([]byte, error)
+
+func (f stubTransport) Call(ctx context.Context, request []byte) ([]byte, error) {
+	return f(ctx, request)
+}
+
+func TestExternalExtractorRejectsInvalidResponse(t *testing.T) {
+	t.Parallel()
+
+	transport := stubTransport(func(context.Context, []byte) ([]byte, error) { return []byte("not json"), nil })
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	extractor := NewExternalExtractor(doc, "https://x.example/", nil, "x", transport, 0)
+	if extractor.CanExtract() || !errors.Is(extractor.Err(), ErrExternalExtractor) {
+		t.Fatalf("Err() = %v, want ErrExternalExtractor", extractor.Err())
+	}
+}
+
+func TestLoadExternalManifestRegistersMappings(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "extractors.json")
+	manifest := `{"extractors": [
+		{"name": "blog", "domains": ["blog.example.com"], "command": ["blog-extractor", "--json"]},
+		{"name": "shop", "urlPatterns": ["^https://shop\\.example\\.com/p/"], "url": "http://127.0.0.1:1/extract", "timeout": "2s"}
+	]}`
+	if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mappings, err := LoadExternalManifest(path)
+	if err != nil {
+		t.Fatalf("LoadExternalManifest() error = %v", err)
+	}
+	registry := NewRegistry()
+	for _, mapping := range mappings {
+		registry.Register(mapping)
+	}
+
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	blog, ok := registry.FindExtractor(doc, "https://www.blog.example.com/post", nil).(*ExternalExtractor)
+	if !ok || blog.Name() != "blog" {
+		t.Fatalf("FindExtractor() = %v, want the blog external extractor", blog)
+	}
+	if transport, ok := blog.transport.(*ProcessTransport); !ok || transport.Command != "blog-extractor" || transport.Args[0] != "--json" {
+		t.Fatalf("blog transport = %#v, want the manifest command", blog.transport)
+	}
+	if got := registry.FindExtractor(doc, "https://shop.example.com/cart", nil); got != nil {
+		t.Fatalf("FindExtractor() = %v, want nil outside urlPatterns", got)
+	}
+	shop, ok := registry.FindExtractor(doc, "https://shop.example.com/p/9", nil).(*ExternalExtractor)
+	if !ok || shop.timeout.String() != "2s" {
+		t.Fatalf("FindExtractor() = %v, want the shop external extractor with a 2s timeout", shop)
+	}
+}
+
+func TestExternalManifestValidatesEntries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		entry ExternalManifestEntry
+		want  string
+	}{
+		{name: "missing name", entry: ExternalManifestEntry{Domains: []string{"a.example"}, URL: "http://x"}, want: "name is required"},
+		{name: "missing patterns", entry: ExternalManifestEntry{Name: "a", URL: "http://x"}, want: "domains or urlPatterns"},
+		{name: "missing transport", entry: ExternalManifestEntry{Name: "a", Domains: []string{"a.example"}}, want: "command or url"},
+		{name: "both transports", entry: ExternalManifestEntry{Name: "a", Domains: []string{"a.example"}, URL: "http://x", Command: []string{"x"}}, want: "mutually exclusive"},
+		{name: "bad pattern", entry: ExternalManifestEntry{Name: "a", URLPatterns: []string{"("}, URL: "http://x"}, want: "urlPatterns"},
+		{name: "bad timeout", entry: ExternalManifestEntry{Name: "a", Domains: []string{"a.example"}, URL: "http://x", Timeout: "soon"}, want: "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			manifest := ExternalManifest{Extractors: []ExternalManifestEntry{tt.entry}}
+			if _, err := manifest.Mappings(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Mappings() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
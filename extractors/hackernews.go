@@ -3,6 +3,7 @@ package extractors
 import (
 	"fmt"
 	"log/slog"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,6 +14,13 @@ import (
 // Pre-compiled regex pattern for Hacker News extraction.
 var hnPostIDRe = regexp.MustCompile(`id=(\d+)`)
 
+// hnBaseURL resolves the relative links of Hacker News pages.
+var hnBaseURL = &url.URL{Scheme: "https", Host: "news.ycombinator.com", Path: "/"}
+
+// hnChrome matches the voting arrows, reply links, and collapse toggles
+// that Hacker News puts around and inside comment text.
+const hnChrome = `.votelinks, .votearrow, .reply, .togg, a[href^="vote?"], a[href^="reply?"]`
+
 // HackerNewsExtractor handles Hacker News content extraction
 // TypeScript original code:
 // import { BaseExtractor } from './_base';
@@ -80,13 +88,25 @@ func (h *HackerNewsExtractor) detectCommentPage() bool {
 		return false
 	}
 
-	// Check if we're on a comment page by looking for a parent link in the navigation
-	parentLink := h.mainPost.Find(`.navs a[href*="parent"]`)
-	isCommentPage := parentLink.Length() > 0
+	// Check if we're on a comment page by looking for a parent link in the
+	// navigation or the story the comment is on
+	isCommentPage := h.parentLink().Length() > 0 || h.mainPost.Find(".onstory").Length() > 0
 	slog.Debug("HackerNews extractor: checking for parent link", "parentLinkFound", isCommentPage)
 	return isCommentPage
 }
 
+// parentLink returns the link of a comment page to the parent item, named
+// by its href on older pages and by its text on current ones.
+func (h *HackerNewsExtractor) parentLink() *goquery.Selection {
+	links := h.mainPost.Find(".navs a")
+	if parent := links.Filter(`[href*="parent"]`); parent.Length() > 0 {
+		return parent.First()
+	}
+	return links.FilterFunction(func(_ int, link *goquery.Selection) bool {
+		return strings.TrimSpace(link.Text()) == "parent"
+	}).First()
+}
+
 // findMainComment finds the main comment on a comment page
 // TypeScript original code:
 //
@@ -170,6 +190,7 @@ func (h *HackerNewsExtractor) Extract() *ExtractorResult {
 	description := h.createDescription()
 	published := h.getPostDate()
 	postID := h.getPostID()
+	submission, submissionURL := h.getSubmission()
 
 	slog.Debug("HackerNews extraction completed",
 		"postTitle", postTitle,
@@ -178,6 +199,21 @@ func (h *HackerNewsExtractor) Extract() *ExtractorResult {
 		"hasComments", comments != "",
 		"published", published)
 
+	variables := map[string]string{
+		"title":       postTitle,
+		"author":      postAuthor,
+		"site":        "Hacker News",
+		"description": description,
+		"published":   published,
+	}
+	// The story a page belongs to, so comment pages keep it too
+	if submission != "" {
+		variables["submission"] = submission
+	}
+	if submissionURL != "" {
+		variables["url"] = submissionURL
+	}
+
 	return &ExtractorResult{
 		Content:     contentHTML,
 		ContentHTML: contentHTML,
@@ -185,13 +221,7 @@ func (h *HackerNewsExtractor) Extract() *ExtractorResult {
 			"postId":     postID,
 			"postAuthor": postAuthor,
 		},
-		Variables: map[string]string{
-			"title":       postTitle,
-			"author":      postAuthor,
-			"site":        "Hacker News",
-			"description": description,
-			"published":   published,
-		},
+		Variables: variables,
 	}
 }
 
@@ -292,7 +322,7 @@ func (h *HackerNewsExtractor) getPostContent() string {
 			author = "[deleted]"
 		}
 
-		commentText, _ := h.mainComment.Find(".commtext").Html()
+		commentText := hnCommentHTML(h.mainComment.Find(".commtext"))
 
 		timeElement := h.mainComment.Find(".age")
 		timestamp, _ := timeElement.Attr("title")
@@ -305,7 +335,7 @@ func (h *HackerNewsExtractor) getPostContent() string {
 		}
 
 		points := strings.TrimSpace(h.mainComment.Find(".score").Text())
-		parentURL, _ := h.mainPost.Find(`.navs a[href*="parent"]`).Attr("href")
+		parentURL, _ := h.parentLink().Attr("href")
 
 		var content strings.Builder
 		content.WriteString(`<div class="comment main-comment">`)
@@ -340,8 +370,7 @@ func (h *HackerNewsExtractor) getPostContent() string {
 
 	text := h.mainPost.Find(".toptext")
 	if text.Length() > 0 {
-		textHTML, _ := text.Html()
-		fmt.Fprintf(&content, `<div class="post-text">%s</div>`, textHTML)
+		fmt.Fprintf(&content, `<div class="post-text">%s</div>`, hnCommentHTML(text))
 	}
 
 	slog.Debug("HackerNews extractor: extracted regular post content", "hasUrl", url != "", "hasText", text.Length() > 0)
@@ -446,7 +475,6 @@ func (h *HackerNewsExtractor) extractComments() string {
 func (h *HackerNewsExtractor) processComments(comments []*goquery.Selection) string {
 	var html strings.Builder
 	processedIDs := make(map[string]bool)
-	currentDepth := -1
 	var blockquoteStack []int
 
 	slog.Debug("HackerNews extractor: processing comments", "totalComments", len(comments))
@@ -458,10 +486,7 @@ func (h *HackerNewsExtractor) processComments(comments []*goquery.Selection) str
 		}
 		processedIDs[id] = true
 
-		indentImg := comment.Find(".ind img")
-		indentWidth, _ := indentImg.Attr("width")
-		indent, _ := strconv.Atoi(indentWidth)
-		depth := indent / 40
+		depth := hnCommentDepth(comment)
 
 		commentText := comment.Find(".commtext")
 		if commentText.Length() == 0 {
@@ -499,28 +524,26 @@ func (h *HackerNewsExtractor) processComments(comments []*goquery.Selection) str
 			html.WriteString("<blockquote>")
 			blockquoteStack = []int{0}
 		} else {
-			// For nested comments
-			// If we're moving back up the tree
-			if depth < currentDepth {
-				// Close blockquotes until we reach the current depth
-				for len(blockquoteStack) > 0 && blockquoteStack[len(blockquoteStack)-1] >= depth {
-					html.WriteString("</blockquote>")
-					blockquoteStack = blockquoteStack[:len(blockquoteStack)-1]
-				}
-			} else if depth > currentDepth {
-				// If we're going deeper
+			// For nested comments, close the replies deeper than this one,
+			// which also returns to a sibling's blockquote when moving back
+			// up the tree
+			for len(blockquoteStack) > 0 && blockquoteStack[len(blockquoteStack)-1] > depth {
+				html.WriteString("</blockquote>")
+				blockquoteStack = blockquoteStack[:len(blockquoteStack)-1]
+			}
+			// If we're going deeper, or back to a level with no open blockquote
+			if len(blockquoteStack) == 0 || blockquoteStack[len(blockquoteStack)-1] < depth {
 				html.WriteString("<blockquote>")
 				blockquoteStack = append(blockquoteStack, depth)
 			}
-			// If we're at the same depth, no need to close or open blockquotes
 		}
 
-		commentContent, _ := commentText.Html()
+		commentContent := hnCommentHTML(commentText)
 
 		html.WriteString(`<div class="comment">`)
 		html.WriteString(`<div class="comment-metadata">`)
 		fmt.Fprintf(&html, `<span class="comment-author"><strong>%s</strong></span> •`, author)
-		fmt.Fprintf(&html, ` <a href=%q class="comment-link">%s</a> •`, commentURL, date)
+		fmt.Fprintf(&html, ` <a href=%q class="comment-link">%s</a>`, commentURL, date)
 
 		if points != "" {
 			fmt.Fprintf(&html, ` • <span class="comment-points">%s</span>`, points)
@@ -529,8 +552,6 @@ func (h *HackerNewsExtractor) processComments(comments []*goquery.Selection) str
 		html.WriteString(`</div>`)
 		fmt.Fprintf(&html, `<div class="comment-content">%s</div>`, commentContent)
 		html.WriteString(`</div>`)
-
-		currentDepth = depth
 	}
 
 	// Close any remaining blockquotes
@@ -543,6 +564,56 @@ func (h *HackerNewsExtractor) processComments(comments []*goquery.Selection) str
 	return html.String()
 }
 
+// hnCommentDepth returns how deeply comment is nested, from the indent
+// attribute of its indentation cell or, on older pages, the width of the
+// spacer image in it, 40 pixels per level.
+func hnCommentDepth(comment *goquery.Selection) int {
+	cell := comment.Find("td.ind").First()
+	if indent, err := strconv.Atoi(cell.AttrOr("indent", "")); err == nil {
+		return indent
+	}
+	width, _ := strconv.Atoi(comment.Find(".ind img").AttrOr("width", ""))
+	return width / 40
+}
+
+// hnCommentHTML returns the inner HTML of text without the voting arrows
+// and reply links Hacker News puts inside it.
+func hnCommentHTML(text *goquery.Selection) string {
+	clone := text.First().Clone()
+	clone.Find(hnChrome).Remove()
+	content, _ := clone.Html()
+	return strings.TrimSpace(content)
+}
+
+// hnAbsoluteURL resolves href against news.ycombinator.com, where Ask HN
+// and other text posts link to their own item page.
+func hnAbsoluteURL(href string) string {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return hnBaseURL.ResolveReference(ref).String()
+}
+
+// getSubmission returns the title and URL of the story the page belongs
+// to: the post itself, or the story a comment page names after "on:".
+func (h *HackerNewsExtractor) getSubmission() (title, link string) {
+	if h.mainPost.Length() == 0 {
+		return "", ""
+	}
+	story := h.mainPost.Find(".titleline a").First()
+	if h.isCommentPage {
+		story = h.mainPost.Find(".onstory a").First()
+	}
+	if story.Length() == 0 {
+		return "", ""
+	}
+	if href := story.AttrOr("href", ""); href != "" {
+		link = hnAbsoluteURL(href)
+	}
+	return strings.TrimSpace(story.Text()), link
+}
+
 // getPostID extracts the post ID from the URL
 // TypeScript original code:
 //
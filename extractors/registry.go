@@ -4,7 +4,6 @@ import (
 	"net/url"
 	"regexp"
 	"slices"
-	"strings"
 	"sync"
 
 	"github.com/PuerkitoBio/goquery"
@@ -31,6 +30,12 @@ var (
 //	type ExtractorConstructor = new (document: Document, url: string, schemaOrgData?: any) => BaseExtractor;
 type ExtractorConstructor func(document *goquery.Document, url string, schemaOrgData any) BaseExtractor
 
+// Matcher decides whether a mapping applies to a page from its URL and
+// parsed document, for sites that patterns cannot recognize, such as a
+// self-hosted CMS identified by its generator meta tag.
+// The document is nil when the registry only explains a route.
+type Matcher func(url string, doc *goquery.Document) bool
+
 // ExtractorMapping represents the mapping configuration for an extractor
 // TypeScript original code:
 //
@@ -39,11 +44,20 @@ type ExtractorConstructor func(document *goquery.Document, url string, schemaOrg
 //	  extractor: ExtractorConstructor;
 //	}
 type ExtractorMapping struct {
-	Patterns  []any // Can be string or *regexp.Regexp
+	// Name identifies the mapping in RouteExplanation.
+	Name string
+	// Patterns are host strings, *regexp.Regexp, or URLPattern values;
+	// the mapping matches when any of them does.
+	Patterns []any
+	// Matcher, when set, is tried after Patterns on every URL; the mapping
+	// also matches when it returns true.
+	Matcher   Matcher
 	Extractor ExtractorConstructor
 }
 
 // Registry manages site-specific extractors with a clean, extensible API
+// A Registry is safe for concurrent use: mappings may be registered while
+// other goroutines are finding extractors.
 // TypeScript original code:
 //
 //	export class ExtractorRegistry {
@@ -51,8 +65,9 @@ type ExtractorMapping struct {
 //	  private static domainCache: Map<string, ExtractorConstructor | null> = new Map();
 //	}
 type Registry struct {
+	mu          sync.RWMutex // Guards mappings and writes to domainCache
 	mappings    []ExtractorMapping
-	domainCache sync.Map // Cache for domain -> constructor mappings
+	domainCache sync.Map // Cache for domain -> indexes of candidate mappings
 }
 
 // NewRegistry creates a new extractor registry
@@ -65,18 +80,72 @@ func NewRegistry() *Registry {
 	}
 }
 
+// NewBuiltinRegistry creates a registry holding only the built-in extractors
+// Use it to give a parser its own set of extractors, isolated from
+// DefaultRegistry and from other parsers.
+func NewBuiltinRegistry() *Registry {
+	r := NewRegistry()
+	r.initializeBuiltins()
+	return r
+}
+
 // Register adds a new extractor mapping to the registry
+// The domain cache is cleared so the mapping applies to domains already looked up.
 // TypeScript original code:
 //
 //	static register(mapping: ExtractorMapping) {
 //	  this.mappings.push(mapping);
 //	}
 func (r *Registry) Register(mapping ExtractorMapping) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.mappings = append(r.mappings, mapping)
+	r.domainCache.Clear()
 	return r // Enable method chaining
 }
 
+// RegisterMatcher adds a named mapping that applies wherever matcher
+// returns true
+// This is a Go-specific method for extractors routed by page content
+func (r *Registry) RegisterMatcher(name string, matcher Matcher, extractor ExtractorConstructor) *Registry {
+	return r.Register(ExtractorMapping{Name: name, Matcher: matcher, Extractor: extractor})
+}
+
+// Unregister removes the mappings named name, reporting whether there were any
+// The domain cache is cleared so the removal applies to domains already looked up.
+// This is a Go-specific method for removing custom and built-in extractors
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.mappings)
+	r.mappings = slices.DeleteFunc(r.mappings, func(mapping ExtractorMapping) bool {
+		return mapping.Name == name
+	})
+	if len(r.mappings) == n {
+		return false
+	}
+	r.domainCache.Clear()
+	return true
+}
+
+// Names returns the names of the registered mappings in registration order
+// Unnamed mappings are skipped and each name is listed once.
+// This is a Go-specific method for introspection
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.mappings))
+	for _, mapping := range r.mappings {
+		if mapping.Name != "" && !slices.Contains(names, mapping.Name) {
+			names = append(names, mapping.Name)
+		}
+	}
+	return names
+}
+
 // FindExtractor finds the appropriate extractor for the given URL
+// The first mapping with a matching pattern or matcher wins. Route parameters captured by
+// the pattern are available from the extractor's ExtractorBase.RouteParams.
 // TypeScript original code:
 //
 //	static findExtractor(document: Document, url: string, schemaOrgData?: any): BaseExtractor | null {
@@ -114,59 +183,37 @@ func (r *Registry) Register(mapping ExtractorMapping) *Registry {
 //	  }
 //	}
 func (r *Registry) FindExtractor(document *goquery.Document, urlStr string, schemaOrgData any) BaseExtractor {
-	if urlStr == "" {
-		return nil
-	}
-
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return nil
-	}
-
-	domain := parsedURL.Hostname()
-
-	// Check cache first
-	if cached, ok := r.domainCache.Load(domain); ok {
-		if constructor, ok := cached.(ExtractorConstructor); ok && constructor != nil {
-			return constructor(document, urlStr, schemaOrgData)
-		}
+	explanation, constructor := r.explain(urlStr, document)
+	if constructor == nil {
 		return nil
 	}
 
-	// Find matching extractor
-	for _, mapping := range r.mappings {
-		if r.matchesPatterns(urlStr, domain, mapping.Patterns) {
-			// Cache the result
-			r.domainCache.Store(domain, mapping.Extractor)
-			return mapping.Extractor(document, urlStr, schemaOrgData)
-		}
+	extractor := constructor(document, urlStr, schemaOrgData)
+	if setter, ok := extractor.(routeParamsSetter); ok && explanation.Params != nil {
+		setter.setRouteParams(explanation.Params)
 	}
+	return extractor
+}
 
-	// Cache the negative result
-	r.domainCache.Store(domain, nil)
-	return nil
+// FindExtractorExplain reports which mapping FindExtractor would choose for
+// urlStr, the pattern that matched, and why, without constructing an extractor.
+// Matchers are called with a nil document.
+// This is a Go-specific method for debugging routing
+func (r *Registry) FindExtractorExplain(urlStr string) RouteExplanation {
+	explanation, _ := r.explain(urlStr, nil)
+	return explanation
 }
 
 // matchesPatterns checks if the URL matches any of the patterns
 // TypeScript original code: pattern matching logic in findExtractor
 func (r *Registry) matchesPatterns(urlStr, domain string, patterns []any) bool {
+	urlPath := ""
+	if parsedURL, err := url.Parse(urlStr); err == nil {
+		urlPath = parsedURL.Path
+	}
 	for _, pattern := range patterns {
-		switch p := pattern.(type) {
-		case string:
-			// Simple domain matching - check if domain ends with the pattern
-			// This handles cases like "reddit.com" matching "www.reddit.com"
-			if domain == p || strings.HasSuffix(domain, "."+p) {
-				return true
-			}
-			// Also check if the pattern is contained in the domain for backwards compatibility
-			if strings.Contains(domain, p) {
-				return true
-			}
-		case *regexp.Regexp:
-			// Regex pattern matching
-			if p.MatchString(urlStr) {
-				return true
-			}
+		if _, _, ok := matchPattern(pattern, urlStr, domain, urlPath); ok {
+			return true
 		}
 	}
 	return false
@@ -186,24 +233,26 @@ func (r *Registry) ClearCache() *Registry {
 // GetMappings returns a copy of current mappings (read-only access)
 // This is a Go-specific method for introspection
 func (r *Registry) GetMappings() []ExtractorMapping {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return slices.Clone(r.mappings)
 }
 
 // Global registry instance and convenience functions
 // TypeScript original code initializes extractors automatically
 
-var (
-	// DefaultRegistry is the global registry instance that can be extended by users
-	DefaultRegistry = NewRegistry()
+// DefaultRegistry is the global registry instance that can be extended by users
+// It holds the built-in extractors from package initialization on.
+var DefaultRegistry = NewBuiltinRegistry()
 
-	// InitializeBuiltins initializes all built-in extractors
-	// TypeScript original code:
-	//
-	//	ExtractorRegistry.initialize();
-	InitializeBuiltins = sync.OnceFunc(func() {
-		DefaultRegistry.initializeBuiltins()
-	})
-)
+// InitializeBuiltins initializes all built-in extractors
+// TypeScript original code:
+//
+//	ExtractorRegistry.initialize();
+//
+// Deprecated: DefaultRegistry registers the built-ins when the package is
+// initialized, so calling InitializeBuiltins is no longer needed. It does nothing.
+func InitializeBuiltins() {}
 
 // initializeBuiltins registers all built-in extractors
 // TypeScript original code: initialize() method with all extractor registrations
@@ -215,6 +264,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: TwitterExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "twitter",
 		Patterns: []any{
 			"twitter.com",
 			"x.com",
@@ -233,6 +283,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: YoutubeExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "youtube",
 		Patterns: []any{
 			"youtube.com",
 			"youtu.be",
@@ -251,6 +302,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: RedditExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "reddit",
 		Patterns: []any{
 			"reddit.com",
 			"old.reddit.com",
@@ -269,6 +321,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: HackerNewsExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "hackernews",
 		Patterns: []any{
 			hackerNewsItemPattern,
 		},
@@ -284,6 +337,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: ChatGPTExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "chatgpt",
 		Patterns: []any{
 			chatGPTSharePattern,
 		},
@@ -299,6 +353,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: ClaudeExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "claude",
 		Patterns: []any{
 			claudeSharePattern,
 		},
@@ -314,6 +369,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: GrokExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "grok",
 		Patterns: []any{
 			"grok.x.ai",
 			"x.ai",
@@ -332,6 +388,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: GeminiExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "gemini",
 		Patterns: []any{
 			"gemini.google.com",
 			geminiSharePattern,
@@ -348,6 +405,7 @@ func (r *Registry) initializeBuiltins() {
 	//     extractor: GitHubExtractor
 	//   });
 	r.Register(ExtractorMapping{
+		Name: "github",
 		Patterns: []any{
 			"github.com",
 			githubIssueOrPullPattern,
@@ -363,17 +421,35 @@ func (r *Registry) initializeBuiltins() {
 // Register adds a mapping to the default registry
 // TypeScript original code: ExtractorRegistry.register (static method)
 func Register(mapping ExtractorMapping) {
-	InitializeBuiltins() // Ensure built-ins are initialized
 	DefaultRegistry.Register(mapping)
 }
 
+// RegisterMatcher adds a named matcher mapping to the default registry
+func RegisterMatcher(name string, matcher Matcher, extractor ExtractorConstructor) {
+	DefaultRegistry.RegisterMatcher(name, matcher, extractor)
+}
+
+// Unregister removes the named mappings from the default registry
+func Unregister(name string) bool {
+	return DefaultRegistry.Unregister(name)
+}
+
+// Names lists the mapping names of the default registry
+func Names() []string {
+	return DefaultRegistry.Names()
+}
+
 // FindExtractor finds an extractor using the default registry
 // TypeScript original code: ExtractorRegistry.findExtractor (static method)
 func FindExtractor(document *goquery.Document, url string, schemaOrgData any) BaseExtractor {
-	InitializeBuiltins() // Ensure built-ins are initialized
 	return DefaultRegistry.FindExtractor(document, url, schemaOrgData)
 }
 
+// FindExtractorExplain explains routing using the default registry
+func FindExtractorExplain(url string) RouteExplanation {
+	return DefaultRegistry.FindExtractorExplain(url)
+}
+
 // ClearCache clears the cache of the default registry
 func ClearCache() {
 	DefaultRegistry.ClearCache()
@@ -1,6 +1,9 @@
 package extractors
 
 import (
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/PuerkitoBio/goquery"
@@ -95,3 +98,129 @@ func TestRegistryFindExtractorHandlesRegexMissAndInvalidURL(t *testing.T) {
 		t.Fatalf("FindExtractor() = %#v, want nil for invalid URL", got)
 	}
 }
+
+func TestRegistryRegisterAppliesToCachedDomains(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	if got := registry.FindExtractor(doc, "https://late.example/post", nil); got != nil {
+		t.Fatalf("FindExtractor() = %v, want nil before registration", got)
+	}
+
+	registry.Register(ExtractorMapping{
+		Patterns: []any{"late.example"},
+		Extractor: func(doc *goquery.Document, url string, schemaOrgData any) BaseExtractor {
+			return &stubRegistryExtractor{ExtractorBase: NewExtractorBase(doc, url, schemaOrgData)}
+		},
+	})
+
+	if got := registry.FindExtractor(doc, "https://late.example/post", nil); got == nil {
+		t.Fatal("FindExtractor() = nil, want the mapping registered after a cached miss")
+	}
+}
+
+func TestNewBuiltinRegistryIsIsolated(t *testing.T) {
+	t.Parallel()
+
+	registry := NewBuiltinRegistry()
+	if len(registry.GetMappings()) == 0 {
+		t.Fatal("NewBuiltinRegistry() did not register built-in extractors")
+	}
+
+	registry.Register(ExtractorMapping{Patterns: []any{"isolated.example"}})
+	for _, mapping := range DefaultRegistry.GetMappings() {
+		if slices.Contains(mapping.Patterns, any("isolated.example")) {
+			t.Fatal("Register() on a builtin registry changed DefaultRegistry")
+		}
+	}
+}
+
+func TestRegistryConcurrentRegisterAndFind(t *testing.T) {
+	t.Parallel()
+
+	registry := NewBuiltinRegistry()
+	doc := newTestDocument(t, `<html><body></body></html>`)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			registry.Register(ExtractorMapping{
+				Patterns: []any{fmt.Sprintf("site%d.example", i)},
+				Extractor: func(doc *goquery.Document, url string, schemaOrgData any) BaseExtractor {
+					return &stubRegistryExtractor{ExtractorBase: NewExtractorBase(doc, url, schemaOrgData)}
+				},
+			})
+		})
+		wg.Go(func() {
+			for j := range 8 {
+				registry.FindExtractor(doc, fmt.Sprintf("https://site%d.example/post", j), nil)
+				_ = registry.GetMappings()
+			}
+		})
+	}
+	wg.Wait()
+
+	for i := range 8 {
+		if got := registry.FindExtractor(doc, fmt.Sprintf("https://site%d.example/post", i), nil); got == nil {
+			t.Fatalf("FindExtractor() = nil for site%d.example after concurrent registration", i)
+		}
+	}
+}
+
+func TestRegistryRegisterMatcherRoutesByDocument(t *testing.T) {
+	t.Parallel()
+
+	registry := NewBuiltinRegistry().RegisterMatcher("wiki", func(url string, doc *goquery.Document) bool {
+		return doc != nil && doc.Find(`meta[name="generator"][content^="WikiSuite"]`).Length() > 0
+	}, stubConstructor)
+
+	wiki := newTestDocument(t, `<html><head><meta name="generator" content="WikiSuite 4.2"></head><body></body></html>`)
+	if got := registry.FindExtractor(wiki, "https://intranet.corp/pages/onboarding", nil); got == nil {
+		t.Fatal("FindExtractor() = nil, want the matcher mapping for a WikiSuite page")
+	}
+	plain := newTestDocument(t, `<html><body></body></html>`)
+	if got := registry.FindExtractor(plain, "https://intranet.corp/pages/onboarding", nil); got != nil {
+		t.Fatalf("FindExtractor() = %v, want nil on the same host without the generator tag", got)
+	}
+	if got := registry.FindExtractor(wiki, "https://github.com/owner/repo/issues/1", nil); got == nil || got.Name() == "StubRegistryExtractor" {
+		t.Fatalf("FindExtractor() = %v, want built-ins registered earlier to win", got)
+	}
+	if got := registry.FindExtractorExplain("https://intranet.corp/pages/onboarding"); got.Matched {
+		t.Fatalf("FindExtractorExplain() = %+v, want no match from a matcher given no document", got)
+	}
+}
+
+func TestRegistryUnregisterAndNames(t *testing.T) {
+	t.Parallel()
+
+	registry := NewBuiltinRegistry().
+		Register(ExtractorMapping{Name: "blog", Patterns: []any{"blog.example"}, Extractor: stubConstructor}).
+		Register(ExtractorMapping{Name: "blog", Patterns: []any{"news.example"}, Extractor: stubConstructor})
+
+	names := registry.Names()
+	if names[0] != "twitter" || names[len(names)-1] != "blog" || slices.Index(names, "blog") != len(names)-1 {
+		t.Fatalf("Names() = %v, want built-ins first and blog listed once", names)
+	}
+
+	doc := newTestDocument(t, `<html><body></body></html>`)
+	if got := registry.FindExtractor(doc, "https://news.example/post", nil); got == nil {
+		t.Fatal("FindExtractor() = nil, want the blog mapping before Unregister")
+	}
+	if !registry.Unregister("blog") {
+		t.Fatal("Unregister(blog) = false, want true")
+	}
+	if registry.Unregister("blog") {
+		t.Fatal("Unregister(blog) = true after removal, want false")
+	}
+	if got := registry.FindExtractor(doc, "https://news.example/post", nil); got != nil {
+		t.Fatalf("FindExtractor() = %v after Unregister, want nil despite the cached host", got)
+	}
+
+	if !registry.Unregister("github") || slices.Contains(registry.Names(), "github") {
+		t.Fatal("Unregister(github) did not remove the built-in")
+	}
+	if got := registry.FindExtractorExplain("https://github.com/owner/repo/issues/1"); got.Matched {
+		t.Fatalf("FindExtractorExplain() = %+v, want no match after removing github", got)
+	}
+}
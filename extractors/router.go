@@ -0,0 +1,265 @@
+package extractors
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// URLPattern matches URLs by host and path. It can be used in
+// ExtractorMapping.Patterns alongside host strings and regular expressions.
+//
+//	extractors.URLPattern{Host: "github.com", Path: "/{owner}/{repo}/issues/{number}"}
+type URLPattern struct {
+	// Host matches the hostname and its subdomains. Empty matches any host.
+	Host string
+	// Path is matched against the URL path one segment at a time. A segment
+	// may be a path.Match glob such as "*" or "*.html", "**" for any number of
+	// segments, "{name}" to capture one segment as a route parameter, or a
+	// final "{name...}" to capture the rest. Empty matches any path.
+	Path string
+}
+
+// String returns the pattern as host followed by path.
+func (p URLPattern) String() string {
+	return p.Host + p.Path
+}
+
+// RouteExplanation describes how a registry resolved a URL, for debugging
+// which mapping was chosen and why. See Registry.FindExtractorExplain.
+type RouteExplanation struct {
+	URL  string `json:"url"`
+	Host string `json:"host"`
+	// Matched reports whether a mapping matched. The parser still only uses
+	// the extractor when its CanExtract returns true.
+	Matched bool `json:"matched"`
+	// Mapping is the index of the matched mapping in GetMappings, or -1.
+	Mapping int `json:"mapping"`
+	// Name is the Name of the matched mapping.
+	Name string `json:"name,omitempty"`
+	// Pattern is the pattern that matched.
+	Pattern string `json:"pattern,omitempty"`
+	// Reason says why the pattern matched, or why nothing did.
+	Reason string `json:"reason"`
+	// Params holds the route parameters captured by the pattern.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// routeParamsSetter is implemented by extractors that embed *ExtractorBase,
+// which receive the parameters captured by the matching pattern.
+type routeParamsSetter interface {
+	setRouteParams(params map[string]string)
+}
+
+// RouteParams returns the parameters captured by the pattern the extractor
+// was routed by: "{name}" segments of a URLPattern and named groups of a
+// regular expression. It is nil when the pattern captured nothing.
+func (e *ExtractorBase) RouteParams() map[string]string {
+	return e.routeParams
+}
+
+// RouteParam returns the route parameter name, or "" when it was not captured.
+func (e *ExtractorBase) RouteParam(name string) string {
+	return e.routeParams[name]
+}
+
+// setRouteParams implements routeParamsSetter.
+func (e *ExtractorBase) setRouteParams(params map[string]string) {
+	e.routeParams = params
+}
+
+// matchPattern reports whether pattern matches the URL, returning the route
+// parameters it captured and the reason it matched.
+// Host strings match the host and its subdomains, so "x.com" matches
+// "mobile.x.com" but not "box.com". Regular expressions are matched against
+// the full URL. Unsupported pattern types never match.
+func matchPattern(pattern any, urlStr, host, urlPath string) (map[string]string, string, bool) {
+	switch p := pattern.(type) {
+	case string:
+		if matchHost(host, p) {
+			return nil, fmt.Sprintf("host %q is %q or a subdomain of it", host, p), true
+		}
+	case *regexp.Regexp:
+		match := p.FindStringSubmatch(urlStr)
+		if match == nil {
+			return nil, "", false
+		}
+		var params map[string]string
+		for i, name := range p.SubexpNames() {
+			if name != "" && i < len(match) {
+				if params == nil {
+					params = make(map[string]string)
+				}
+				params[name] = match[i]
+			}
+		}
+		return params, fmt.Sprintf("URL matches regular expression %q", p.String()), true
+	case URLPattern:
+		if p.Host != "" && !matchHost(host, p.Host) {
+			return nil, "", false
+		}
+		if p.Path == "" {
+			return nil, fmt.Sprintf("host %q is %q or a subdomain of it", host, p.Host), true
+		}
+		params, ok := matchSegments(splitPath(p.Path), splitPath(urlPath), nil)
+		if !ok {
+			return nil, "", false
+		}
+		return params, fmt.Sprintf("host %q and path %q match %q", host, urlPath, p.String()), true
+	}
+	return nil, "", false
+}
+
+// canMatchHost reports whether pattern might match a URL on host. Only host
+// strings are decided by the host alone.
+func canMatchHost(pattern any, host string) bool {
+	switch p := pattern.(type) {
+	case string:
+		return matchHost(host, p)
+	case URLPattern:
+		return p.Host == "" || matchHost(host, p.Host)
+	case *regexp.Regexp:
+		return true
+	}
+	return false
+}
+
+// matchHost reports whether host is suffix or one of its subdomains.
+func matchHost(host, suffix string) bool {
+	host, suffix = strings.ToLower(host), strings.ToLower(suffix)
+	return suffix != "" && (host == suffix || strings.HasSuffix(host, "."+suffix))
+}
+
+// splitPath splits a URL path into its non-empty segments.
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// matchSegments matches path segments against pattern segments, returning
+// params extended with the captured route parameters.
+func matchSegments(pattern, segments []string, params map[string]string) (map[string]string, bool) {
+	if len(pattern) == 0 {
+		return params, len(segments) == 0
+	}
+
+	seg := pattern[0]
+	if seg == "**" {
+		for skip := 0; skip <= len(segments); skip++ {
+			if got, ok := matchSegments(pattern[1:], segments[skip:], params); ok {
+				return got, true
+			}
+		}
+		return nil, false
+	}
+	if name, ok := strings.CutPrefix(seg, "{"); ok && strings.HasSuffix(name, "}") {
+		name = strings.TrimSuffix(name, "}")
+		if rest, ok := strings.CutSuffix(name, "..."); ok && len(pattern) == 1 {
+			return withParam(params, rest, strings.Join(segments, "/")), true
+		}
+		if len(segments) == 0 {
+			return nil, false
+		}
+		return matchSegments(pattern[1:], segments[1:], withParam(params, name, segments[0]))
+	}
+	if len(segments) == 0 {
+		return nil, false
+	}
+	if ok, err := path.Match(seg, segments[0]); err != nil || !ok {
+		return nil, false
+	}
+	return matchSegments(pattern[1:], segments[1:], params)
+}
+
+// withParam returns a copy of params with name set to value, so a failed
+// "**" branch never leaks captures into the next one.
+func withParam(params map[string]string, name, value string) map[string]string {
+	out := maps.Clone(params)
+	if out == nil {
+		out = make(map[string]string, 1)
+	}
+	out[name] = value
+	return out
+}
+
+// explain resolves urlStr and doc against the registry, returning the
+// explanation and the constructor of the matched mapping.
+func (r *Registry) explain(urlStr string, doc *goquery.Document) (RouteExplanation, ExtractorConstructor) {
+	explanation := RouteExplanation{URL: urlStr, Mapping: -1}
+	if urlStr == "" {
+		explanation.Reason = "URL is empty"
+		return explanation, nil
+	}
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		explanation.Reason = fmt.Sprintf("URL cannot be parsed: %v", err)
+		return explanation, nil
+	}
+	host := parsedURL.Hostname()
+	explanation.Host = host
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, index := range r.candidates(host) {
+		mapping := r.mappings[index]
+		for _, pattern := range mapping.Patterns {
+			params, reason, ok := matchPattern(pattern, urlStr, host, parsedURL.Path)
+			if !ok {
+				continue
+			}
+			explanation.Matched = true
+			explanation.Mapping = index
+			explanation.Name = mapping.Name
+			explanation.Pattern = fmt.Sprint(pattern)
+			explanation.Reason = reason
+			explanation.Params = params
+			return explanation, mapping.Extractor
+		}
+		if mapping.Matcher != nil && mapping.Matcher(urlStr, doc) {
+			explanation.Matched = true
+			explanation.Mapping = index
+			explanation.Name = mapping.Name
+			explanation.Reason = "matcher returned true"
+			return explanation, mapping.Extractor
+		}
+	}
+	explanation.Reason = fmt.Sprintf("no pattern of %d mappings matched", len(r.mappings))
+	return explanation, nil
+}
+
+// candidates returns the indexes of the mappings that might match a URL on
+// host, in registration order, caching them per host. Mappings with a
+// Matcher are candidates on every host. It must be called
+// with r.mu held.
+func (r *Registry) candidates(host string) []int {
+	if cached, ok := r.domainCache.Load(host); ok {
+		if indexes, ok := cached.([]int); ok {
+			return indexes
+		}
+	}
+
+	var indexes []int
+	for i, mapping := range r.mappings {
+		if mapping.Matcher != nil {
+			indexes = append(indexes, i)
+			continue
+		}
+		for _, pattern := range mapping.Patterns {
+			if canMatchHost(pattern, host) {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	r.domainCache.Store(host, indexes)
+	return indexes
+}
@@ -0,0 +1,143 @@
+package extractors
+
+import (
+	"maps"
+	"regexp"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func stubConstructor(doc *goquery.Document, url string, schemaOrgData any) BaseExtractor {
+	return &stubRegistryExtractor{ExtractorBase: NewExtractorBase(doc, url, schemaOrgData)}
+}
+
+func TestMatchPatternHostStringsMatchSubdomainsOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "x.com", want: true},
+		{host: "mobile.X.com", want: true},
+		{host: "box.com", want: false},
+		{host: "x.com.evil.example", want: false},
+	}
+	for _, tt := range tests {
+		if _, _, got := matchPattern("x.com", "https://"+tt.host+"/", tt.host, "/"); got != tt.want {
+			t.Fatalf("matchPattern(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestMatchPatternURLPatternCapturesPathSegments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pattern URLPattern
+		path    string
+		want    map[string]string
+		matched bool
+	}{
+		{name: "named segments", pattern: URLPattern{Host: "github.com", Path: "/{owner}/{repo}/issues/{number}"}, path: "/golang/go/issues/42", want: map[string]string{"owner": "golang", "repo": "go", "number": "42"}, matched: true},
+		{name: "segment glob", pattern: URLPattern{Path: "/posts/*.html"}, path: "/posts/hello.html", matched: true},
+		{name: "double star", pattern: URLPattern{Path: "/docs/**/{page}"}, path: "/docs/a/b/intro", want: map[string]string{"page": "intro"}, matched: true},
+		{name: "double star matches nothing", pattern: URLPattern{Path: "/docs/**/{page}"}, path: "/docs/intro", want: map[string]string{"page": "intro"}, matched: true},
+		{name: "rest capture", pattern: URLPattern{Path: "/wiki/{title...}"}, path: "/wiki/Go/History", want: map[string]string{"title": "Go/History"}, matched: true},
+		{name: "too short", pattern: URLPattern{Path: "/{owner}/{repo}/issues/{number}"}, path: "/golang/go/issues", matched: false},
+		{name: "too long", pattern: URLPattern{Path: "/posts/*"}, path: "/posts/a/b", matched: false},
+		{name: "wrong host", pattern: URLPattern{Host: "gitlab.com", Path: "/**"}, path: "/a", matched: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			params, _, ok := matchPattern(tt.pattern, "https://github.com"+tt.path, "github.com", tt.path)
+			if ok != tt.matched {
+				t.Fatalf("matchPattern() matched = %v, want %v", ok, tt.matched)
+			}
+			if ok && !maps.Equal(params, tt.want) {
+				t.Fatalf("matchPattern() params = %v, want %v", params, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryFindExtractorPassesRouteParams(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry().
+		Register(ExtractorMapping{
+			Name:      "issues",
+			Patterns:  []any{URLPattern{Host: "tracker.example", Path: "/{project}/issues/{id}"}},
+			Extractor: stubConstructor,
+		}).
+		Register(ExtractorMapping{
+			Name:      "articles",
+			Patterns:  []any{regexp.MustCompile(`^https://tracker\.example/articles/(?P<slug>[\w-]+)$`)},
+			Extractor: stubConstructor,
+		})
+	doc := newTestDocument(t, `<html><body></body></html>`)
+
+	issue, ok := registry.FindExtractor(doc, "https://tracker.example/core/issues/7", nil).(*stubRegistryExtractor)
+	if !ok || issue.RouteParam("project") != "core" || issue.RouteParam("id") != "7" {
+		t.Fatalf("FindExtractor() = %v, want route params project and id", issue)
+	}
+	article, ok := registry.FindExtractor(doc, "https://tracker.example/articles/hello-world", nil).(*stubRegistryExtractor)
+	if !ok || article.RouteParam("slug") != "hello-world" {
+		t.Fatalf("FindExtractor() = %v, want the slug captured by the named group", article)
+	}
+	if got := registry.FindExtractor(doc, "https://tracker.example/core/wiki", nil); got != nil {
+		t.Fatalf("FindExtractor() = %v, want nil for a path no pattern matches on a cached host", got)
+	}
+}
+
+func TestRegistryFindExtractorExplain(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry().
+		Register(ExtractorMapping{Name: "blog", Patterns: []any{"blog.example"}, Extractor: stubConstructor}).
+		Register(ExtractorMapping{Name: "docs", Patterns: []any{URLPattern{Path: "/docs/{page}"}}, Extractor: stubConstructor})
+
+	got := registry.FindExtractorExplain("https://www.blog.example/post")
+	if !got.Matched || got.Mapping != 0 || got.Name != "blog" || got.Pattern != "blog.example" || got.Host != "www.blog.example" {
+		t.Fatalf("FindExtractorExplain() = %+v, want the blog mapping", got)
+	}
+	if got.Reason != `host "www.blog.example" is "blog.example" or a subdomain of it` {
+		t.Fatalf("Reason = %q", got.Reason)
+	}
+
+	got = registry.FindExtractorExplain("https://other.example/docs/setup")
+	if !got.Matched || got.Mapping != 1 || got.Params["page"] != "setup" || got.Pattern != "/docs/{page}" {
+		t.Fatalf("FindExtractorExplain() = %+v, want the docs mapping with page captured", got)
+	}
+
+	for _, url := range []string{"", "://bad-url", "https://other.example/"} {
+		if got := registry.FindExtractorExplain(url); got.Matched || got.Mapping != -1 || got.Reason == "" {
+			t.Fatalf("FindExtractorExplain(%q) = %+v, want an unmatched explanation with a reason", url, got)
+		}
+	}
+}
+
+func TestBuiltinRegistryRoutesByHostSuffix(t *testing.T) {
+	t.Parallel()
+
+	registry := NewBuiltinRegistry()
+	tests := []struct {
+		url  string
+		name string
+	}{
+		{url: "https://old.reddit.com/r/golang/comments/abc/title/", name: "reddit"},
+		{url: "https://x.com/golang/status/1", name: "twitter"},
+		{url: "https://news.ycombinator.com/item?id=1", name: "hackernews"},
+		{url: "https://www.dropbox.com/s/file", name: ""},
+		{url: "https://example.ai/", name: ""},
+	}
+	for _, tt := range tests {
+		if got := registry.FindExtractorExplain(tt.url); got.Name != tt.name {
+			t.Fatalf("FindExtractorExplain(%q).Name = %q (%s), want %q", tt.url, got.Name, got.Reason, tt.name)
+		}
+	}
+}
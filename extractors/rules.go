@@ -0,0 +1,239 @@
+package extractors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/go-json-experiment/json"
+	"github.com/goccy/go-yaml"
+)
+
+// attributeSuffix matches the "@name" that ends a selector reading an
+// attribute instead of text, as in "time@datetime".
+var attributeSuffix = regexp.MustCompile(`@([A-Za-z_:][-A-Za-z0-9_:.]*)$`)
+
+// RuleSet lists declarative extractors, typically loaded from a YAML or
+// JSON file with LoadFromFile:
+//
+//	extractors:
+//	  - name: wiki
+//	    domains: [wiki.example.com]
+//	    content: "#page-content"
+//	    remove: [".edit-section", ".toc"]
+//	    title: "h1.page-title"
+//	    author: ".last-edited-by a"
+//	    published: "time.created@datetime"
+//	    variables:
+//	      site: "meta[property='og:site_name']@content"
+type RuleSet struct {
+	Extractors []Rule `json:"extractors"`
+}
+
+// Rule describes one declarative extractor: where it applies and the CSS
+// selectors that find its content and metadata. A selector ending in
+// "@name" reads that attribute of the first match; otherwise the text of
+// the first match is used.
+type Rule struct {
+	// Name identifies the extractor in results and metrics.
+	Name string `json:"name"`
+	// Domains match hostnames and their subdomains.
+	Domains []string `json:"domains,omitempty"`
+	// URLPatterns are regular expressions matched against the full URL.
+	URLPatterns []string `json:"urlPatterns,omitempty"`
+	// Content selects the main content. Every match is kept, in document
+	// order, and the rule declines pages where nothing matches.
+	Content string `json:"content"`
+	// Remove selects elements to drop from the content.
+	Remove []string `json:"remove,omitempty"`
+	// Title, Author, Published, Description, and Image select metadata that
+	// overrides the page's own.
+	Title       string `json:"title,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Published   string `json:"published,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	// Variables maps further variable names, such as "site", to selectors.
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// LoadFromFile reads a RuleSet from path and returns one mapping per rule,
+// in file order. Files ending in .yaml or .yml are read as YAML, others as
+// JSON.
+func LoadFromFile(path string) ([]ExtractorMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read extractor rules: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("parse extractor rules %s: %w", path, err)
+		}
+	}
+	var rules RuleSet
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse extractor rules %s: %w", path, err)
+	}
+	return rules.Mappings()
+}
+
+// Mappings validates the rules and returns one mapping per rule.
+func (s *RuleSet) Mappings() ([]ExtractorMapping, error) {
+	mappings := make([]ExtractorMapping, 0, len(s.Extractors))
+	for i, rule := range s.Extractors {
+		mapping, err := rule.mapping()
+		if err != nil {
+			return nil, fmt.Errorf("extractor rule %d (%q): %w", i, rule.Name, err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// mapping validates the rule and builds its mapping.
+func (r Rule) mapping() (ExtractorMapping, error) {
+	if r.Name == "" {
+		return ExtractorMapping{}, errors.New("name is required")
+	}
+	if len(r.Domains) == 0 && len(r.URLPatterns) == 0 {
+		return ExtractorMapping{}, errors.New("domains or urlPatterns is required")
+	}
+	if r.Content == "" {
+		return ExtractorMapping{}, errors.New("content is required")
+	}
+
+	selectors := map[string]string{
+		"content":     r.Content,
+		"title":       r.Title,
+		"author":      r.Author,
+		"published":   r.Published,
+		"description": r.Description,
+		"image":       r.Image,
+	}
+	for i, selector := range r.Remove {
+		selectors[fmt.Sprintf("remove[%d]", i)] = selector
+	}
+	for name, selector := range r.Variables {
+		selectors["variables."+name] = selector
+	}
+	for field, selector := range selectors {
+		if selector == "" {
+			continue
+		}
+		selector, _ = splitAttribute(selector)
+		if _, err := cascadia.ParseGroup(selector); err != nil {
+			return ExtractorMapping{}, fmt.Errorf("%s: invalid selector %q: %w", field, selector, err)
+		}
+	}
+
+	patterns := make([]any, 0, len(r.Domains)+len(r.URLPatterns))
+	for _, domain := range r.Domains {
+		patterns = append(patterns, domain)
+	}
+	for _, pattern := range r.URLPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return ExtractorMapping{}, fmt.Errorf("urlPatterns: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	return ExtractorMapping{
+		Name:     r.Name,
+		Patterns: patterns,
+		Extractor: func(document *goquery.Document, url string, schemaOrgData any) BaseExtractor {
+			return NewRuleExtractor(document, url, schemaOrgData, r)
+		},
+	}, nil
+}
+
+// RuleExtractor applies a Rule to a page.
+type RuleExtractor struct {
+	*ExtractorBase
+	rule Rule
+}
+
+// NewRuleExtractor creates an extractor that applies rule, which should
+// already be validated by RuleSet.Mappings.
+func NewRuleExtractor(document *goquery.Document, url string, schemaOrgData any, rule Rule) *RuleExtractor {
+	return &RuleExtractor{
+		ExtractorBase: NewExtractorBase(document, url, schemaOrgData),
+		rule:          rule,
+	}
+}
+
+// CanExtract reports whether the content selector matches the page.
+func (e *RuleExtractor) CanExtract() bool {
+	return e.GetDocument().Find(e.rule.Content).Length() > 0
+}
+
+// Extract returns the selected content without the removed elements, and
+// the selected metadata as variables. The document is left unchanged.
+func (e *RuleExtractor) Extract() *ExtractorResult {
+	content := e.GetDocument().Find(e.rule.Content).Clone()
+	for _, selector := range e.rule.Remove {
+		content.Find(selector).Remove()
+	}
+
+	var html strings.Builder
+	content.Each(func(_ int, s *goquery.Selection) {
+		if outer, err := goquery.OuterHtml(s); err == nil {
+			html.WriteString(outer)
+		}
+	})
+
+	variables := make(map[string]string, len(e.rule.Variables)+5)
+	for name, selector := range e.rule.Variables {
+		e.setVariable(variables, name, selector)
+	}
+	e.setVariable(variables, "title", e.rule.Title)
+	e.setVariable(variables, "author", e.rule.Author)
+	e.setVariable(variables, "published", e.rule.Published)
+	e.setVariable(variables, "description", e.rule.Description)
+	e.setVariable(variables, "image", e.rule.Image)
+
+	return &ExtractorResult{
+		Content:     e.GetTextContent(content),
+		ContentHTML: html.String(),
+		Variables:   variables,
+	}
+}
+
+// Name returns the name of the rule.
+func (e *RuleExtractor) Name() string {
+	return e.rule.Name
+}
+
+// setVariable stores the value selector reads under name, skipping empty
+// selectors and values.
+func (e *RuleExtractor) setVariable(variables map[string]string, name, selector string) {
+	if selector == "" {
+		return
+	}
+	selector, attr := splitAttribute(selector)
+	match := e.GetDocument().Find(selector).First()
+	var value string
+	if attr != "" {
+		value = e.GetAttribute(match, attr)
+	} else {
+		value = e.GetTextContent(match)
+	}
+	if value = strings.Join(strings.Fields(value), " "); value != "" {
+		variables[name] = value
+	}
+}
+
+// splitAttribute splits "selector@attr" into the selector and attribute
+// name. Selectors without the suffix return an empty attribute.
+func splitAttribute(selector string) (string, string) {
+	if m := attributeSuffix.FindStringSubmatchIndex(selector); m != nil && m[0] > 0 {
+		return strings.TrimSpace(selector[:m[0]]), selector[m[2]:m[3]]
+	}
+	return selector, ""
+}
@@ -0,0 +1,144 @@
+package extractors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const ruleTestHTML = `<html><head>
+<meta property="og:site_name" content="Team Wiki">
+</head><body>
+<h1 class="page-title">  Onboarding   Guide </h1>
+<span class="editor"><a href="/u/ana">Ana Lima</a></span>
+<time class="created" datetime="2025-03-04T10:00:00Z">March 4</time>
+<div id="page-content"><p>Welcome to the team.</p><span class="edit-section">[edit]</span><p>Read this first.</p></div>
+</body></html>`
+
+const ruleTestYAML = `extractors:
+  - name: wiki
+    domains: [wiki.example.com]
+    content: "#page-content"
+    remove: [".edit-section"]
+    title: "h1.page-title"
+    author: ".editor a"
+    published: "time.created@datetime"
+    variables:
+      site: "meta[property='og:site_name']@content"
+`
+
+func writeRules(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFromFileAppliesYAMLRules(t *testing.T) {
+	t.Parallel()
+
+	mappings, err := LoadFromFile(writeRules(t, "rules.yaml", ruleTestYAML))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	registry := NewRegistry()
+	for _, mapping := range mappings {
+		registry.Register(mapping)
+	}
+
+	doc := newTestDocument(t, ruleTestHTML)
+	extractor := registry.FindExtractor(doc, "https://wiki.example.com/onboarding", nil)
+	if extractor == nil || extractor.Name() != "wiki" || !extractor.CanExtract() {
+		t.Fatalf("FindExtractor() = %v, want the wiki rule extractor", extractor)
+	}
+
+	result := extractor.Extract()
+	if result.ContentHTML != `<div id="page-content"><p>Welcome to the team.</p><p>Read this first.</p></div>` {
+		t.Fatalf("ContentHTML = %q", result.ContentHTML)
+	}
+	want := map[string]string{
+		"title":     "Onboarding Guide",
+		"author":    "Ana Lima",
+		"published": "2025-03-04T10:00:00Z",
+		"site":      "Team Wiki",
+	}
+	for name, value := range want {
+		if result.Variables[name] != value {
+			t.Fatalf("Variables[%q] = %q, want %q", name, result.Variables[name], value)
+		}
+	}
+	if doc.Find(".edit-section").Length() != 1 {
+		t.Fatal("Extract() removed elements from the document, want only from the content")
+	}
+}
+
+func TestLoadFromFileReadsJSON(t *testing.T) {
+	t.Parallel()
+
+	path := writeRules(t, "rules.json", `{"extractors":[{"name":"docs","urlPatterns":["^https://docs\\.example\\.com/"],"content":"main"}]}`)
+	mappings, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if len(mappings) != 1 || mappings[0].Name != "docs" {
+		t.Fatalf("LoadFromFile() = %+v, want the docs mapping", mappings)
+	}
+}
+
+func TestRuleExtractorDeclinesPagesWithoutContent(t *testing.T) {
+	t.Parallel()
+
+	extractor := NewRuleExtractor(newTestDocument(t, `<html><body><p>Other</p></body></html>`), "", nil, Rule{Name: "wiki", Content: "#page-content"})
+	if extractor.CanExtract() {
+		t.Fatal("CanExtract() = true, want false when the content selector matches nothing")
+	}
+}
+
+func TestRuleSetValidatesRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{name: "missing name", rule: Rule{Domains: []string{"a.example"}, Content: "main"}, want: "name is required"},
+		{name: "missing patterns", rule: Rule{Name: "a", Content: "main"}, want: "domains or urlPatterns"},
+		{name: "missing content", rule: Rule{Name: "a", Domains: []string{"a.example"}}, want: "content is required"},
+		{name: "bad pattern", rule: Rule{Name: "a", URLPatterns: []string{"("}, Content: "main"}, want: "urlPatterns"},
+		{name: "bad selector", rule: Rule{Name: "a", Domains: []string{"a.example"}, Content: "main", Title: "h1[@"}, want: "title: invalid selector"},
+		{name: "bad variable", rule: Rule{Name: "a", Domains: []string{"a.example"}, Content: "main", Variables: map[string]string{"site": "::"}}, want: "variables.site"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rules := RuleSet{Extractors: []Rule{tt.rule}}
+			if _, err := rules.Mappings(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Mappings() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitAttribute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, selector, attr string
+	}{
+		{in: "time@datetime", selector: "time", attr: "datetime"},
+		{in: "meta[name='x'] @content", selector: "meta[name='x']", attr: "content"},
+		{in: `a[href="mailto:me@example.com"]`, selector: `a[href="mailto:me@example.com"]`},
+		{in: "h1", selector: "h1"},
+	}
+	for _, tt := range tests {
+		if selector, attr := splitAttribute(tt.in); selector != tt.selector || attr != tt.attr {
+			t.Fatalf("splitAttribute(%q) = %q, %q, want %q, %q", tt.in, selector, attr, tt.selector, tt.attr)
+		}
+	}
+}
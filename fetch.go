@@ -0,0 +1,161 @@
+package defuddle
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kaptinlin/requests"
+)
+
+// DefaultUserAgent is the User-Agent clients built by NewFetchClient send
+// unless FetchOptions sets another. It names the release from Version.
+var DefaultUserAgent = "Mozilla/5.0 (compatible; Defuddle/" + strings.TrimPrefix(Version(), "v") + "; +https://github.com/kaptinlin/defuddle-go)"
+
+const (
+	// defaultFetchTimeout bounds each request of a client built without
+	// FetchOptions.Timeout.
+	defaultFetchTimeout = 30 * time.Second
+
+	// defaultRetryBackoff is the delay before the first retry without
+	// FetchOptions.RetryBackoff.
+	defaultRetryBackoff = time.Second
+
+	// maxRetryBackoff caps the doubling delay between retries.
+	maxRetryBackoff = 30 * time.Second
+
+	// retryJitter is the fraction each retry delay varies by, so clients
+	// that failed together do not retry together.
+	retryJitter = 0.25
+)
+
+// NewFetchClient returns the HTTP client ParseFromURL uses when
+// Options.Client is nil, configured by fetch. A nil fetch selects the
+// defaults. An invalid proxy URL fails.
+func NewFetchClient(fetch *FetchOptions) (*requests.Client, error) {
+	if fetch == nil {
+		fetch = &FetchOptions{}
+	}
+
+	var clientOptions []requests.ClientOption
+	if fetch.HTTPClient != nil {
+		httpClient := fetch.HTTPClient
+		if fetch.RequestsPerSecond > 0 {
+			// The rate limit wraps the transport of a copy, not the caller's
+			clone := *fetch.HTTPClient
+			httpClient = &clone
+		}
+		clientOptions = append(clientOptions, requests.WithHTTPClient(httpClient))
+	} else {
+		timeout := fetch.Timeout
+		if timeout == 0 {
+			timeout = defaultFetchTimeout
+		}
+		if timeout > 0 {
+			clientOptions = append(clientOptions, requests.WithTimeout(timeout))
+		}
+	}
+	userAgent := fetch.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	clientOptions = append(clientOptions, requests.WithUserAgent(userAgent))
+	for key, values := range fetch.Headers {
+		for _, value := range values {
+			clientOptions = append(clientOptions, requests.WithHeader(key, value))
+		}
+	}
+
+	if fetch.MaxRetries > 0 {
+		backoff := cmp.Or(fetch.RetryBackoff, defaultRetryBackoff)
+		clientOptions = append(clientOptions,
+			requests.WithMaxRetries(fetch.MaxRetries),
+			requests.WithRetryStrategy(requests.JitterBackoffStrategy(
+				requests.ExponentialBackoffStrategy(backoff, 2, max(backoff, maxRetryBackoff)), retryJitter)),
+			requests.WithRetryIf(requests.DefaultRetryIf),
+		)
+	}
+
+	client := requests.New(clientOptions...)
+	if fetch.Proxy != "" && fetch.HTTPClient == nil {
+		if err := client.SetProxy(fetch.Proxy); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+	}
+	if fetch.Resolver != nil && fetch.HTTPClient == nil {
+		client.SetResolver(fetch.Resolver)
+	}
+	if fetch.UnixSocket != "" && fetch.HTTPClient == nil {
+		socket := fetch.UnixSocket
+		client.SetDialContext(func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		})
+	}
+	// Outermost, so fresh cached responses skip robots.txt and rate limits
+	if fetch.CacheDir != "" {
+		client.AddMiddleware(httpCacheMiddleware(&httpCache{dir: fetch.CacheDir, ttl: fetch.CacheTTL, now: time.Now}))
+	}
+	if fetch.RespectRobots {
+		client.AddMiddleware(robotsMiddleware(userAgent))
+	}
+	if fetch.MaxBodySize > 0 {
+		client.AddMiddleware(maxBodyMiddleware(fetch.MaxBodySize))
+	}
+	// In the transport, below the retry loop, so retries after a 429 or
+	// 5xx are spaced like first attempts, and robots.txt requests too. Last,
+	// so it wraps the transport the proxy and dial options configured.
+	if fetch.RequestsPerSecond > 0 {
+		rateLimitClient(client, newHostLimiter(fetch.RequestsPerSecond))
+	}
+	return client, nil
+}
+
+// maxBodyMiddleware fails responses whose body is longer than limit bytes,
+// before they are read when Content-Length says so.
+func maxBodyMiddleware(limit int64) requests.Middleware {
+	return func(next requests.MiddlewareHandlerFunc) requests.MiddlewareHandlerFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err != nil || resp == nil || resp.Body == nil {
+				return resp, err
+			}
+			if resp.ContentLength > limit {
+				_ = resp.Body.Close()
+				return nil, fmt.Errorf("%w: %d bytes exceeds %d", ErrResponseTooLarge, resp.ContentLength, limit)
+			}
+			resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: limit, limit: limit}
+			return resp, nil
+		}
+	}
+}
+
+// limitedBody is a response body that fails with ErrResponseTooLarge once
+// more than limit bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: over %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	// Read one byte past the limit to tell a body of exactly limit bytes
+	// from a longer one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), fmt.Errorf("%w: over %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	return n, err
+}
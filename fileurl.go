@@ -0,0 +1,72 @@
+package defuddle
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isFileURL reports whether rawURL has the file scheme.
+func isFileURL(rawURL string) bool {
+	scheme, _, ok := strings.Cut(rawURL, ":")
+	return ok && strings.EqualFold(scheme, "file")
+}
+
+// readFileURL reads the page at a file:// URL inside options.FileRoot and
+// returns it decoded, with the URL as the one it was served from. A file
+// larger than options.Fetch.MaxBodySize fails with ErrResponseTooLarge.
+func readFileURL(rawURL string, options *Options) (html, responseURL string, err error) {
+	path, err := fileURLPath(rawURL, options.FileRoot)
+	if err != nil {
+		return "", "", err
+	}
+	if options.Fetch != nil && options.Fetch.MaxBodySize > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		if info.Size() > options.Fetch.MaxBodySize {
+			return "", "", fmt.Errorf("%w: %d bytes exceeds %d", ErrResponseTooLarge, info.Size(), options.Fetch.MaxBodySize)
+		}
+	}
+	body, err := os.ReadFile(path) // #nosec G304 - path checked against FileRoot
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	html, err = decodeHTML(body, "")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	return html, rawURL, nil
+}
+
+// fileURLPath returns the absolute local path of a file:// URL on this host,
+// which must lie inside root after cleaning. It does not resolve symlinks.
+func fileURLPath(rawURL, root string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid file URL %s: %w", rawURL, err)
+	}
+	if root == "" || (u.Host != "" && !strings.EqualFold(u.Host, "localhost")) || u.Path == "" {
+		return "", fmt.Errorf("%w: %s", ErrFileOutsideRoot, rawURL)
+	}
+	path := u.Path
+	// Windows paths are written file:///C:/dir/page.html
+	if filepath.VolumeName(path[1:]) != "" {
+		path = path[1:]
+	}
+	path = filepath.Clean(filepath.FromSlash(path))
+
+	base, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid file root: %w", err)
+	}
+	// Rel fails for paths on another volume, which are outside base too
+	rel, err := filepath.Rel(base, path)
+	if err != nil || !filepath.IsAbs(path) || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s", ErrFileOutsideRoot, rawURL)
+	}
+	return path, nil
+}
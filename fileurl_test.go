@@ -0,0 +1,78 @@
+package defuddle
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFileURL returns the file:// URL of path.
+func testFileURL(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
+
+func TestParseFromURLReadsFileURLsInsideFileRoot(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	page := filepath.Join(root, "articles", "page.html")
+	require.NoError(t, os.MkdirAll(filepath.Dir(page), 0o755))
+	require.NoError(t, os.WriteFile(page, []byte(fetchTestPage), 0o600))
+	outside := filepath.Join(t.TempDir(), "secret.html")
+	require.NoError(t, os.WriteFile(outside, []byte(fetchTestPage), 0o600))
+
+	result, err := ParseFromURL(context.Background(), testFileURL(page), &Options{FileRoot: root})
+	require.NoError(t, err)
+	assert.Equal(t, "Fetched", result.Title)
+
+	// ".." is resolved before the path is checked against the root
+	escape := testFileURL(filepath.Join(root, "articles")) + "/../../" + filepath.Base(filepath.Dir(outside)) + "/secret.html"
+	for _, rawURL := range []string{testFileURL(outside), escape, "file://example.com" + filepath.ToSlash(page)} {
+		_, err = ParseFromURL(context.Background(), rawURL, &Options{FileRoot: root})
+		require.ErrorIs(t, err, ErrFileOutsideRoot, rawURL)
+	}
+	_, err = ParseFromURL(context.Background(), testFileURL(page), nil)
+	require.ErrorIs(t, err, ErrFileOutsideRoot)
+
+	_, err = ParseFromURL(context.Background(), testFileURL(filepath.Join(root, "missing.html")), &Options{FileRoot: root})
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = ParseFromURL(context.Background(), testFileURL(page), &Options{FileRoot: root, Fetch: &FetchOptions{MaxBodySize: 32}})
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+}
+
+func TestParseFromURLDialsUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "sock")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	listener, err := net.Listen("unix", filepath.Join(dir, "fetch.sock"))
+	require.NoError(t, err)
+
+	server := &httptest.Server{Listener: listener, Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>` + r.Host + r.URL.Path + `</title></head><body><p>Over the socket.</p></body></html>`))
+	})}}
+	server.Start()
+	defer server.Close()
+
+	result, err := ParseFromURL(context.Background(), "http://sidecar.internal/page", &Options{Fetch: &FetchOptions{
+		UnixSocket: filepath.Join(dir, "fetch.sock"),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "sidecar.internal/page", result.Title)
+}
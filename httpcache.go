@@ -0,0 +1,164 @@
+package defuddle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/kaptinlin/requests"
+)
+
+// httpCache keeps successful responses as JSON files under dir, keyed by
+// request URL. Failures to read or write the directory are treated as
+// misses.
+type httpCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// httpCacheEntry is one stored response.
+type httpCacheEntry struct {
+	// URL is the final URL of the response, after HTTP redirects.
+	URL     string      `json:"url"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body"`
+	Fetched time.Time   `json:"fetched"`
+}
+
+// path returns the file of the entry for rawURL, sharded by the first two
+// characters of its hash.
+func (c *httpCache) path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// load returns the entry stored for rawURL.
+func (c *httpCache) load(rawURL string) (*httpCacheEntry, bool) {
+	data, err := os.ReadFile(c.path(rawURL))
+	if err != nil {
+		return nil, false
+	}
+	var entry httpCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// store writes entry for rawURL to a temporary file and renames it into
+// place, so readers never see a partial entry.
+func (c *httpCache) store(rawURL string, entry *httpCacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	path := c.path(rawURL)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// response builds a 200 response to req from the entry.
+func (e *httpCacheEntry) response(req *http.Request) *http.Response {
+	final := req
+	if e.URL != req.URL.String() {
+		if u, err := url.Parse(e.URL); err == nil {
+			final = req.Clone(req.Context())
+			final.URL = u
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       final,
+	}
+}
+
+// httpCacheMiddleware answers GET requests from cache while their entry is
+// younger than the cache's ttl. An older entry is revalidated with
+// If-None-Match and If-Modified-Since from its ETag and Last-Modified, and
+// served again, renewed, when the server answers 304 Not Modified. Other
+// 200 responses are read in full and stored.
+func httpCacheMiddleware(cache *httpCache) requests.Middleware {
+	return func(next requests.MiddlewareHandlerFunc) requests.MiddlewareHandlerFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet {
+				return next(req)
+			}
+			key := req.URL.String()
+			entry, cached := cache.load(key)
+			if cached && cache.now().Sub(entry.Fetched) < cache.ttl {
+				return entry.response(req), nil
+			}
+
+			send := req
+			if cached {
+				send = req.Clone(req.Context())
+				if etag := entry.Header.Get("ETag"); etag != "" {
+					send.Header.Set("If-None-Match", etag)
+				}
+				if modified := entry.Header.Get("Last-Modified"); modified != "" {
+					send.Header.Set("If-Modified-Since", modified)
+				}
+			}
+			resp, err := next(send)
+			if err != nil || resp == nil {
+				return resp, err
+			}
+
+			if cached && resp.StatusCode == http.StatusNotModified {
+				_ = resp.Body.Close()
+				// A 304 carries the validators to use next time
+				for _, name := range []string{"ETag", "Last-Modified", "Date", "Expires", "Cache-Control"} {
+					if value := resp.Header.Get(name); value != "" {
+						entry.Header.Set(name, value)
+					}
+				}
+				entry.Fetched = cache.now()
+				cache.store(key, entry)
+				return entry.response(req), nil
+			}
+			if resp.StatusCode != http.StatusOK {
+				return resp, nil
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			final := key
+			if resp.Request != nil && resp.Request.URL != nil {
+				final = resp.Request.URL.String()
+			}
+			cache.store(key, &httpCacheEntry{URL: final, Header: resp.Header, Body: body, Fetched: cache.now()})
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			resp.ContentLength = int64(len(body))
+			return resp, nil
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCachedPageServer serves fetchTestPage at /page with an ETag,
+// answering a matching If-None-Match with 304, and redirects /moved to
+// /page. It counts the full and the not-modified responses.
+func newCachedPageServer(t *testing.T) (server *httptest.Server, full, notModified *atomic.Int32) {
+	t.Helper()
+	full, notModified = &atomic.Int32{}, &atomic.Int32{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(fetchTestPage))
+	}))
+	t.Cleanup(server.Close)
+	return server, full, notModified
+}
+
+func TestFetchClientServesFreshResponsesFromCache(t *testing.T) {
+	t.Parallel()
+
+	server, full, notModified := newCachedPageServer(t)
+	dir := t.TempDir()
+	for range 2 {
+		// Each run builds its own client, as repeated CLI runs do
+		client, err := NewFetchClient(&FetchOptions{CacheDir: dir, CacheTTL: time.Hour})
+		require.NoError(t, err)
+
+		html, responseURL, err := fetchHTML(context.Background(), client, server.URL+"/moved", nil, &Options{})
+		require.NoError(t, err)
+		assert.Equal(t, fetchTestPage, html)
+		assert.Equal(t, server.URL+"/page", responseURL)
+	}
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(0), notModified.Load())
+}
+
+func TestFetchClientRevalidatesStaleResponses(t *testing.T) {
+	t.Parallel()
+
+	server, full, notModified := newCachedPageServer(t)
+	client, err := NewFetchClient(&FetchOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+
+	for range 3 {
+		result, err := ParseFromURL(context.Background(), server.URL+"/page", &Options{Client: client})
+		require.NoError(t, err)
+		assert.Equal(t, "Fetched", result.Title)
+	}
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(2), notModified.Load())
+}
+
+func TestFetchClientDoesNotCacheErrors(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newCachedPageServer(t)
+	dir := t.TempDir()
+	client, err := NewFetchClient(&FetchOptions{CacheDir: dir, CacheTTL: time.Hour})
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = ParseFromURL(context.Background(), server.URL+"/missing", &Options{Client: client})
+		require.ErrorIs(t, err, ErrHTTPStatus)
+	}
+	_, cached := (&httpCache{dir: dir}).load(server.URL + "/missing")
+	assert.False(t, cached)
+}
@@ -0,0 +1,36 @@
+package defuddle
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/kaptinlin/defuddle-go/internal/constants"
+)
+
+// contentImages lists the images of content HTML in document order, with
+// the caption and credit of the figure each one is in.
+func contentImages(content string) []ContentImage {
+	if !strings.Contains(content, "<img") {
+		return nil
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+
+	var images []ContentImage
+	doc.Find("img").Each(func(_ int, img *goquery.Selection) {
+		src := strings.TrimSpace(img.AttrOr("src", ""))
+		if src == "" {
+			return
+		}
+		image := ContentImage{Src: src, Alt: strings.TrimSpace(img.AttrOr("alt", ""))}
+		if figure := img.Closest("figure"); figure.Length() > 0 {
+			image.Caption = strings.Join(strings.Fields(figure.Find("figcaption").First().Text()), " ")
+			image.Credit = figure.AttrOr(constants.CreditAttribute, "")
+		}
+		images = append(images, image)
+	})
+	return images
+}
@@ -0,0 +1,53 @@
+package defuddle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const creditedFigureHTML = `<html><head><title>Protest</title></head><body><article>
+<h1>Protest</h1>
+<p>Thousands of people gathered in the city centre on Saturday to call for new elections, in one of the largest demonstrations the country has seen in a decade.</p>
+<figure><img src="https://example.com/crowd.jpg" alt="A crowd"><figcaption>Crowds gather outside parliament. Photo: Jane Doe/Reuters</figcaption></figure>
+<p>Organisers said the march would continue every weekend until the government agreed to their demands, and police reported no arrests during the day.</p>
+</article></body></html>`
+
+func TestParseSeparatesImageCredits(t *testing.T) {
+	t.Parallel()
+
+	result, err := ParseFromString(context.Background(), creditedFigureHTML, &Options{SeparateMarkdown: true})
+	require.NoError(t, err)
+
+	assert.Contains(t, result.Content, `data-credit="Photo: Jane Doe/Reuters"`)
+	assert.Contains(t, result.Content, "<figcaption>Crowds gather outside parliament.</figcaption>")
+	require.Len(t, result.Images, 1)
+	assert.Equal(t, ContentImage{
+		Src:     "https://example.com/crowd.jpg",
+		Alt:     "A crowd",
+		Caption: "Crowds gather outside parliament.",
+		Credit:  "Photo: Jane Doe/Reuters",
+	}, result.Images[0])
+	require.NotNil(t, result.ContentMarkdown)
+	assert.Contains(t, *result.ContentMarkdown, "Crowds gather outside parliament. Photo: Jane Doe/Reuters")
+}
+
+func TestParseDropsImageCreditsFromMarkdown(t *testing.T) {
+	t.Parallel()
+
+	markdownOptions := DefaultMarkdownOptions()
+	markdownOptions.DropImageCredits = true
+	result, err := ParseFromString(context.Background(), creditedFigureHTML, &Options{
+		SeparateMarkdown: true,
+		MarkdownOptions:  markdownOptions,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, result.ContentMarkdown)
+	assert.Contains(t, *result.ContentMarkdown, "Crowds gather outside parliament.")
+	assert.NotContains(t, *result.ContentMarkdown, "Jane Doe")
+	require.Len(t, result.Images, 1)
+	assert.Equal(t, "Photo: Jane Doe/Reuters", result.Images[0].Credit)
+}
@@ -94,6 +94,17 @@ var InlineElements = map[string]bool{
 	"font": true,
 }
 
+// AssetSelectors are removed from every content path, including extractor
+// output and the body fallback, whatever the options. Math scripts are kept
+// for the math standardization.
+var AssetSelectors = []string{
+	"noscript",
+	`script:not([type^="math/"])`,
+	"style",
+	"template",
+	"link",
+}
+
 // ExactSelectors are selectors to be removed exactly
 // JavaScript original code: (first part of EXACT_SELECTORS array)
 var ExactSelectors = []string{
@@ -1077,8 +1088,25 @@ var AllowedAttributes = map[string]bool{
 	"movablelimits": true, "notation": true, "rowalign": true, "rowlines": true, "rowspacing": true,
 	"rspace": true, "scriptlevel": true, "separator": true, "stretchy": true, "symmetric": true,
 	"voffset": true, "xmlns": true,
+
+	// Go-only: source-map stamps, removed before the content is returned
+	SourceAttribute: true,
+
+	// Go-only: photo credits split from figure captions
+	CreditAttribute: true,
+
+	// Go-only: the machine-readable value of time elements kept in text
+	"datetime": true,
 }
 
+// SourceAttribute numbers the elements of the original document when
+// Options.SourceMap is set, so blocks can be traced back through cleanup
+const SourceAttribute = "data-defuddle-src"
+
+// CreditAttribute holds the photo credit of a figure, split from its
+// caption so clients can show or drop it separately
+const CreditAttribute = "data-credit"
+
 // AllowedAttributesDebug are additional attributes to keep in debug mode
 // JavaScript original code:
 // export const ALLOWED_ATTRIBUTES_DEBUG = new Set([
@@ -0,0 +1,155 @@
+// Package dom provides traversal and mutation helpers over *html.Node so the
+// parse pipeline can work on the parsed tree directly instead of through
+// goquery selections. The helpers follow DOM semantics: Text matches
+// Node.textContent and Elements matches querySelectorAll('*').
+//
+// goquery stays the adapter for code that needs CSS selectors, such as the
+// extractors; a selection's nodes are reached with Selection.Nodes or Get.
+package dom
+
+import (
+	"iter"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// IsElement reports whether n is an element node.
+func IsElement(n *html.Node) bool {
+	return n != nil && n.Type == html.ElementNode
+}
+
+// TagName returns the lowercase tag name of an element, or "" for other nodes.
+func TagName(n *html.Node) string {
+	if !IsElement(n) {
+		return ""
+	}
+	return strings.ToLower(n.Data)
+}
+
+// Attr returns the value of the named attribute and whether it is present.
+func Attr(n *html.Node, name string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// AttrOr returns the value of the named attribute, or fallback when absent.
+func AttrOr(n *html.Node, name, fallback string) string {
+	if v, ok := Attr(n, name); ok {
+		return v
+	}
+	return fallback
+}
+
+// SetAttr sets the named attribute, replacing any existing value.
+func SetAttr(n *html.Node, name, value string) {
+	for i, a := range n.Attr {
+		if a.Key == name {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: name, Val: value})
+}
+
+// RemoveAttr removes every attribute with the given name.
+func RemoveAttr(n *html.Node, name string) {
+	n.Attr = slices.DeleteFunc(n.Attr, func(a html.Attribute) bool {
+		return a.Key == name
+	})
+}
+
+// Descendants yields every node below n in document order. Children of a
+// node removed during iteration are not visited.
+func Descendants(n *html.Node) iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		walk(n, yield)
+	}
+}
+
+func walk(n *html.Node, yield func(*html.Node) bool) bool {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if !yield(c) {
+			return false
+		}
+		if c.Parent == n && !walk(c, yield) {
+			return false
+		}
+		c = next
+	}
+	return true
+}
+
+// Elements yields every element below n in document order.
+func Elements(n *html.Node) iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for c := range Descendants(n) {
+			if c.Type == html.ElementNode && !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// Children yields the element children of n.
+func Children(n *html.Node) iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == html.ElementNode && !yield(c) {
+				return
+			}
+			c = next
+		}
+	}
+}
+
+// NextElementSibling returns the next element sibling of n, or nil.
+func NextElementSibling(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// Closest returns the nearest ancestor of n, or n itself, with the given tag.
+func Closest(n *html.Node, tag string) *html.Node {
+	for ; n != nil; n = n.Parent {
+		if TagName(n) == tag {
+			return n
+		}
+	}
+	return nil
+}
+
+// Text returns the concatenated text of n and its descendants.
+func Text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := range Descendants(n) {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
+// Remove detaches n from its parent. Detached nodes are left as they are.
+func Remove(n *html.Node) {
+	if n.Parent != nil {
+		n.Parent.RemoveChild(n)
+	}
+}
@@ -0,0 +1,93 @@
+package dom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func parseBody(t *testing.T, src string) *html.Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(src))
+	require.NoError(t, err)
+	for n := range Elements(doc) {
+		if TagName(n) == "body" {
+			return n
+		}
+	}
+	t.Fatal("no body")
+	return nil
+}
+
+func TestElementsAndText(t *testing.T) {
+	t.Parallel()
+
+	body := parseBody(t, `<div id="a"><p>One <b>two</b></p><!-- c --><p>three</p></div>`)
+
+	var tags []string
+	for el := range Elements(body) {
+		tags = append(tags, TagName(el))
+	}
+	assert.Equal(t, []string{"div", "p", "b", "p"}, tags)
+	assert.Equal(t, "One twothree", Text(body))
+}
+
+func TestElementsSkipsRemovedSubtrees(t *testing.T) {
+	t.Parallel()
+
+	body := parseBody(t, `<div><span>gone</span></div><p>kept</p>`)
+
+	var tags []string
+	for el := range Elements(body) {
+		tags = append(tags, TagName(el))
+		if TagName(el) == "div" {
+			Remove(el)
+		}
+	}
+	assert.Equal(t, []string{"div", "p"}, tags)
+	assert.Equal(t, "kept", Text(body))
+}
+
+func TestAttributes(t *testing.T) {
+	t.Parallel()
+
+	body := parseBody(t, `<p class="x" id="y">text</p>`)
+	p := body.FirstChild
+
+	v, ok := Attr(p, "class")
+	assert.True(t, ok)
+	assert.Equal(t, "x", v)
+	assert.Equal(t, "none", AttrOr(p, "title", "none"))
+
+	SetAttr(p, "class", "z")
+	SetAttr(p, "title", "t")
+	RemoveAttr(p, "id")
+	assert.Equal(t, []html.Attribute{{Key: "class", Val: "z"}, {Key: "title", Val: "t"}}, p.Attr)
+}
+
+func TestClosestAndSiblings(t *testing.T) {
+	t.Parallel()
+
+	body := parseBody(t, `<table><tr><td>a</td> <td>b</td></tr></table>`)
+
+	var cells []*html.Node
+	for el := range Elements(body) {
+		if TagName(el) == "td" {
+			cells = append(cells, el)
+		}
+	}
+	require.Len(t, cells, 2)
+	assert.Equal(t, "table", TagName(Closest(cells[0], "table")))
+	assert.Nil(t, Closest(cells[0], "section"))
+	assert.Equal(t, cells[1], NextElementSibling(cells[0]))
+	assert.Nil(t, NextElementSibling(cells[1]))
+
+	var children int
+	for range Children(cells[0].Parent) {
+		children++
+	}
+	assert.Equal(t, 2, children)
+}
@@ -0,0 +1,185 @@
+package elements
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultAltTextConcurrency is the number of concurrent AltTextProvider calls
+// used when ImageProcessingOptions.AltTextConcurrency is not positive.
+const DefaultAltTextConcurrency = 4
+
+// maxAltTextCacheEntries bounds an AltTextCache.
+const maxAltTextCacheEntries = 1024
+
+// maxAltTextContextLength bounds the surrounding text, in runes, passed to a provider.
+const maxAltTextContextLength = 300
+
+// AltTextProvider generates alt text for an image, typically by calling an
+// external captioning service. imageURL is resolved against the page URL when
+// possible, and imageContext carries nearby caption or heading text.
+// Returning an empty string or an error keeps the existing alt text.
+type AltTextProvider func(ctx context.Context, imageURL, imageContext string) (string, error)
+
+// AltTextCache memoizes AltTextProvider results by image URL. It is safe for
+// concurrent use.
+type AltTextCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+// NewAltTextCache creates an empty AltTextCache.
+func NewAltTextCache() *AltTextCache {
+	return &AltTextCache{entries: make(map[string]string)}
+}
+
+func (c *AltTextCache) get(imageURL string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	alt, ok := c.entries[imageURL]
+	return alt, ok
+}
+
+func (c *AltTextCache) put(imageURL, alt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxAltTextCacheEntries {
+		clear(c.entries)
+	}
+	c.entries[imageURL] = alt
+}
+
+// altTextJob is one image URL awaiting provider output.
+type altTextJob struct {
+	imageURL string
+	context  string
+	images   []*goquery.Selection
+	alt      string
+	err      error
+}
+
+// GenerateAltText fills missing or generic alt text in content using
+// options.AltTextProvider. Each distinct image URL is sent to the provider at
+// most once, calls run with at most options.AltTextConcurrency in flight, and
+// results are kept in cache, when not nil, so later calls reuse them.
+// It returns the number of images whose alt text was updated, and the
+// provider errors, one per image URL, joined with errors.Join.
+func GenerateAltText(ctx context.Context, content *goquery.Selection, options *ImageProcessingOptions, baseURL string, cache *AltTextCache) (int, error) {
+	if content == nil || options == nil || options.AltTextProvider == nil {
+		return 0, nil
+	}
+	if cache == nil {
+		cache = NewAltTextCache()
+	}
+
+	p := &ImageProcessor{}
+	base, _ := url.Parse(baseURL)
+
+	var jobs []*altTextJob
+	byURL := make(map[string]*altTextJob)
+	content.Find("img").Each(func(_ int, img *goquery.Selection) {
+		alt := img.AttrOr("alt", "")
+		if alt != "" && !p.isGenericAltText(alt) {
+			return
+		}
+		imageURL := resolveAltTextImageURL(base, img.AttrOr("src", ""))
+		if imageURL == "" || strings.HasPrefix(imageURL, "data:") {
+			return
+		}
+		if job, ok := byURL[imageURL]; ok {
+			job.images = append(job.images, img)
+			return
+		}
+		job := &altTextJob{imageURL: imageURL, context: altTextContext(p, img), images: []*goquery.Selection{img}}
+		byURL[imageURL] = job
+		jobs = append(jobs, job)
+	})
+
+	concurrency := options.AltTextConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultAltTextConcurrency
+	}
+
+	// Provider calls run concurrently; DOM updates happen afterwards on this
+	// goroutine because goquery selections are not safe for concurrent mutation.
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, job := range jobs {
+		if alt, ok := cache.get(job.imageURL); ok {
+			job.alt = alt
+			continue
+		}
+		wg.Go(func() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			alt, err := options.AltTextProvider(ctx, job.imageURL, job.context)
+			if err != nil {
+				job.err = fmt.Errorf("%s: %w", job.imageURL, err)
+				return
+			}
+			job.alt = strings.TrimSpace(alt)
+			cache.put(job.imageURL, job.alt)
+		})
+	}
+	wg.Wait()
+
+	updated := 0
+	var errs []error
+	for _, job := range jobs {
+		if job.err != nil {
+			errs = append(errs, job.err)
+		}
+		if job.alt == "" {
+			continue
+		}
+		for _, img := range job.images {
+			img.SetAttr("alt", job.alt)
+			updated++
+		}
+	}
+	return updated, errors.Join(errs...)
+}
+
+// resolveAltTextImageURL resolves src against base, returning src unchanged
+// when either cannot be parsed.
+func resolveAltTextImageURL(base *url.URL, src string) string {
+	src = strings.TrimSpace(src)
+	if src == "" || base == nil {
+		return src
+	}
+	ref, err := url.Parse(src)
+	if err != nil {
+		return src
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// altTextContext collects the caption, title, or nearby text for an image.
+func altTextContext(p *ImageProcessor, img *goquery.Selection) string {
+	var text string
+	if caption := img.Closest("figure").Find("figcaption").First(); caption.Length() > 0 {
+		text = strings.TrimSpace(caption.Text())
+	}
+	if text == "" {
+		text = strings.TrimSpace(img.AttrOr("title", ""))
+	}
+	if text == "" {
+		text = p.getContextualAltText(img)
+	}
+	text = imgWhitespaceRe.ReplaceAllString(text, " ")
+	if runes := []rune(text); len(runes) > maxAltTextContextLength {
+		text = string(runes[:maxAltTextContextLength])
+	}
+	return text
+}
@@ -0,0 +1,91 @@
+package elements
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAltTextCallsProviderOncePerImageAndCaches(t *testing.T) {
+	t.Parallel()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`
+		<article>
+			<figure><img src="/chart.png"><figcaption>Quarterly revenue</figcaption></figure>
+			<img src="/chart.png" alt="image">
+			<img src="https://cdn.example.com/team.jpg" alt="The team at the offsite">
+		</article>`))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var calls []string
+	options := DefaultImageProcessingOptions()
+	options.AltTextProvider = func(_ context.Context, imageURL, imageContext string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, imageURL+"|"+imageContext)
+		return "Bar chart of revenue", nil
+	}
+
+	cache := NewAltTextCache()
+	updated, err := GenerateAltText(context.Background(), doc.Find("article"), options, "https://example.com/posts/1", cache)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, updated)
+	assert.Equal(t, []string{"https://example.com/chart.png|Quarterly revenue"}, calls)
+	assert.Equal(t, 2, doc.Find(`img[alt="Bar chart of revenue"]`).Length())
+	assert.Equal(t, "The team at the offsite", doc.Find(`img[src*="team"]`).AttrOr("alt", ""))
+
+	again, err := goquery.NewDocumentFromReader(strings.NewReader(`<article><img src="https://example.com/chart.png"></article>`))
+	require.NoError(t, err)
+	updated, err = GenerateAltText(context.Background(), again.Find("article"), options, "", cache)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	assert.Len(t, calls, 1, "cached alt text should not call the provider again")
+}
+
+func TestGenerateAltTextLimitsConcurrencyAndKeepsAltOnError(t *testing.T) {
+	t.Parallel()
+
+	var html strings.Builder
+	html.WriteString("<article>")
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		html.WriteString(`<img src="/` + name + `.png">`)
+	}
+	html.WriteString(`<img src="/broken.png" alt="x"></article>`)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html.String()))
+	require.NoError(t, err)
+
+	var inFlight, peak atomic.Int32
+	options := &ImageProcessingOptions{
+		AltTextConcurrency: 2,
+		AltTextProvider: func(_ context.Context, imageURL, _ string) (string, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				current := peak.Load()
+				if n <= current || peak.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			if strings.HasSuffix(imageURL, "broken.png") {
+				return "", errors.New("captioning failed")
+			}
+			return "Generated", nil
+		},
+	}
+
+	updated, err := GenerateAltText(context.Background(), doc.Find("article"), options, "", nil)
+
+	require.EqualError(t, err, "/broken.png: captioning failed")
+	assert.Equal(t, 6, updated)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, "x", doc.Find(`img[src="/broken.png"]`).AttrOr("alt", ""))
+}
@@ -0,0 +1,172 @@
+package elements
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"github.com/kaptinlin/defuddle-go/internal/constants"
+)
+
+// creditSelectors find elements of a figure that hold only its credit.
+const creditSelectors = `[class*="credit" i], [class*="copyright" i], [class*="attribution" i], ` +
+	`[itemprop="creditText"], [itemprop="copyrightHolder"]`
+
+// captionCreditPattern matches a credit line at the end of a caption, such
+// as "Photo: Jane Doe/Reuters", "(AP Photo/Evan Vucci)", or "© Jane Doe".
+// Labels must be capitalized, so "the image: ..." in running text is left
+// alone. Group 1 is the credit without brackets.
+var captionCreditPattern = regexp.MustCompile(`(?:^|[\s|–—,;]+)[(\[]?\s*(` +
+	`(?:(?:AP|AFP|EPA|Reuters|Getty)\s+)?` +
+	`(?:Photo(?:graph)?s?|PHOTOS?|Image|IMAGE|Picture|Illustration|ILLUSTRATION|Graphic|Composite|Foto|Bild|Credit|CREDIT)` +
+	`(?:\s+[Cc]redit)?\s*(?:by\s|:|/)\s*[^()\[\]\n]+?` +
+	`|(?:Courtesy\s+of|©|\([Cc]\)|Copyright)\s*[^()\[\]\n]+?` +
+	`)\s*[)\]]?\s*\.?\s*$`)
+
+// maxCaptionCreditWords is the longest credit split from caption text;
+// longer matches are more likely sentences that happen to start with a label.
+const maxCaptionCreditWords = 8
+
+// maxCreditElementWords is the longest text of a credit element kept as a credit.
+const maxCreditElementWords = 20
+
+// SeparateCredits moves photo credits out of the figures under root into
+// the constants.CreditAttribute attribute of each figure, leaving the
+// figcaption with the caption alone. A credit is an element whose class
+// names a credit, copyright, or attribution, or else a labeled credit line
+// at the end of the figcaption text. A figcaption left empty is removed.
+// It must run before class attributes are stripped, and returns the number
+// of credits moved.
+func SeparateCredits(root *goquery.Selection) int {
+	moved := 0
+	root.Find("figure").Each(func(_ int, figure *goquery.Selection) {
+		if _, ok := figure.Attr(constants.CreditAttribute); ok {
+			return
+		}
+
+		credit := ""
+		figure.Find(creditSelectors).EachWithBreak(func(_ int, element *goquery.Selection) bool {
+			if element.Is("figcaption") || element.Find("img, picture, video, figcaption").Length() > 0 {
+				return true
+			}
+			text := normalizeCredit(element.Text())
+			if text == "" || len(strings.Fields(text)) > maxCreditElementWords {
+				return true
+			}
+			credit = text
+			element.Remove()
+			return false
+		})
+
+		caption := figure.Find("figcaption").First()
+		if credit == "" && caption.Length() > 0 {
+			credit = splitCaptionCredit(caption.Get(0))
+		}
+		if credit == "" {
+			return
+		}
+		if caption.Length() > 0 && strings.TrimSpace(caption.Text()) == "" && caption.Find("img").Length() == 0 {
+			caption.Remove()
+		}
+		figure.SetAttr(constants.CreditAttribute, credit)
+		moved++
+	})
+	return moved
+}
+
+// splitCaptionCredit removes a credit line matched by captionCreditPattern
+// from the end of the text of caption and returns it. Text nodes after the
+// start of the credit are cut, and elements left empty are removed, so the
+// markup of the caption itself is kept.
+func splitCaptionCredit(caption *html.Node) string {
+	var texts []*html.Node
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.TextNode:
+				texts = append(texts, c)
+			case html.ElementNode:
+				collect(c)
+			}
+		}
+	}
+	collect(caption)
+
+	var full strings.Builder
+	for _, text := range texts {
+		full.WriteString(text.Data)
+	}
+	m := captionCreditPattern.FindStringSubmatchIndex(full.String())
+	if m == nil {
+		return ""
+	}
+	credit := normalizeCredit(full.String()[m[2]:m[3]])
+	if len(strings.Fields(credit)) > maxCaptionCreditWords || utf8.RuneCountInString(credit) < 3 {
+		return ""
+	}
+
+	cut, offset := m[0], 0
+	var last *html.Node
+	for _, text := range texts {
+		end := offset + len(text.Data)
+		switch {
+		case offset >= cut:
+			text.Data = ""
+		case end > cut:
+			text.Data = text.Data[:cut-offset]
+		}
+		if text.Data != "" {
+			last = text
+		}
+		offset = end
+	}
+	if last != nil {
+		last.Data = strings.TrimRight(last.Data, " \t\r\n|–—-,;:/")
+	}
+	removeEmptyElements(caption)
+	return credit
+}
+
+// removeEmptyElements removes the descendants of n that hold no text and
+// no media.
+func removeEmptyElements(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.ElementNode:
+			removeEmptyElements(c)
+			if c.FirstChild == nil && !isVoidMedia(c.Data) {
+				n.RemoveChild(c)
+			}
+		case html.TextNode:
+			if c.Data == "" {
+				n.RemoveChild(c)
+			}
+		}
+		c = next
+	}
+}
+
+// isVoidMedia reports whether tag is an element that is content without
+// children.
+func isVoidMedia(tag string) bool {
+	switch tag {
+	case "img", "br", "source", "video", "audio", "iframe", "math":
+		return true
+	}
+	return false
+}
+
+// normalizeCredit collapses whitespace and trims the brackets and trailing
+// period around a credit.
+func normalizeCredit(text string) string {
+	text = imgWhitespaceRe.ReplaceAllString(strings.TrimSpace(text), " ")
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") || strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		text = strings.TrimSpace(text[1 : len(text)-1])
+	}
+	return strings.TrimSpace(strings.TrimSuffix(text, "."))
+}
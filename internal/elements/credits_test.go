@@ -0,0 +1,93 @@
+package elements
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeparateCreditsSplitsCaptionCredits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		figure      string
+		wantCaption string
+		wantCredit  string
+	}{
+		{
+			name:        "labeled suffix",
+			figure:      `<figcaption>Crowds gather in the square on Sunday. Photo: Jane Doe/Reuters</figcaption>`,
+			wantCaption: `Crowds gather in the square on Sunday.`,
+			wantCredit:  `Photo: Jane Doe/Reuters`,
+		},
+		{
+			name:        "agency in parentheses",
+			figure:      `<figcaption>The president speaks in the Rose Garden. (AP Photo/Evan Vucci)</figcaption>`,
+			wantCaption: `The president speaks in the Rose Garden.`,
+			wantCredit:  `AP Photo/Evan Vucci`,
+		},
+		{
+			name:        "separator and markup",
+			figure:      `<figcaption>A <em>rare</em> snow leopard | Photograph: <a href="/jane">Jane Doe</a></figcaption>`,
+			wantCaption: `A <em>rare</em> snow leopard`,
+			wantCredit:  `Photograph: Jane Doe`,
+		},
+		{
+			name:        "copyright",
+			figure:      `<figcaption>Sunrise over the bay © Jane Doe</figcaption>`,
+			wantCaption: `Sunrise over the bay`,
+			wantCredit:  `© Jane Doe`,
+		},
+		{
+			name:        "credit element",
+			figure:      `<figcaption><span class="caption-text">Flooded streets in Valencia.</span> <span class="image-credit">Getty Images</span></figcaption>`,
+			wantCaption: `<span class="caption-text">Flooded streets in Valencia.</span>`,
+			wantCredit:  `Getty Images`,
+		},
+		{
+			name:       "credit only",
+			figure:     `<figcaption>Photo by Jane Doe on Unsplash</figcaption>`,
+			wantCredit: `Photo by Jane Doe on Unsplash`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<article><figure><img src="/a.jpg">` + tt.figure + `</figure></article>`))
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, SeparateCredits(doc.Find("article")))
+			figure := doc.Find("figure")
+			assert.Equal(t, tt.wantCredit, figure.AttrOr("data-credit", ""))
+			caption, _ := figure.Find("figcaption").Html()
+			assert.Equal(t, tt.wantCaption, strings.TrimSpace(caption))
+			if tt.wantCaption == "" {
+				assert.Equal(t, 0, figure.Find("figcaption").Length())
+			}
+		})
+	}
+}
+
+func TestSeparateCreditsLeavesPlainCaptions(t *testing.T) {
+	t.Parallel()
+
+	for _, caption := range []string{
+		`The image: a crowd at dawn.`,
+		`Photo: the crowd gathers in the square before the march begins on a cold Sunday morning`,
+		`Revenue grew 40% (chart by quarter)`,
+	} {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<figure><img src="/a.jpg"><figcaption>` + caption + `</figcaption></figure>`))
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, SeparateCredits(doc.Selection), caption)
+		assert.Equal(t, caption, doc.Find("figcaption").Text())
+		_, ok := doc.Find("figure").Attr("data-credit")
+		assert.False(t, ok, caption)
+	}
+}
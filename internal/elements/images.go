@@ -107,6 +107,13 @@ type ImageProcessingOptions struct {
 	MinImageHeight    int
 	MaxImageWidth     int
 	MaxImageHeight    int
+
+	// AltTextProvider, when set, generates alt text for images whose alt is
+	// missing or generic, for example by calling a captioning service.
+	AltTextProvider AltTextProvider `json:"-"`
+	// AltTextConcurrency limits concurrent AltTextProvider calls.
+	// Defaults to DefaultAltTextConcurrency when not positive.
+	AltTextConcurrency int
 }
 
 // DefaultImageProcessingOptions returns default options for image processing
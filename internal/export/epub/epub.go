@@ -0,0 +1,586 @@
+// Package epub packages an extracted article as an EPUB 3 publication: a
+// single XHTML chapter with the title, byline, and content, the images it
+// shows, a navigation document, and the metadata in the package document.
+package epub
+
+import (
+	"archive/zip"
+	"crypto/sha1" // #nosec G505 - name-based UUIDs are defined over SHA-1
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kaptinlin/defuddle-go/internal/sanitize"
+)
+
+// Namespaces of the documents in the package
+const (
+	containerNS = "urn:oasis:names:tc:opendocument:xmlns:container"
+	opfNS       = "http://www.idpf.org/2007/opf"
+	dcNS        = "http://purl.org/dc/elements/1.1/"
+	xhtmlNS     = "http://www.w3.org/1999/xhtml"
+	opsNS       = "http://www.idpf.org/2007/ops"
+	mathMLNS    = "http://www.w3.org/1998/Math/MathML"
+)
+
+// MediaType is the mimetype of an EPUB file.
+const MediaType = "application/epub+zip"
+
+// chapterFile is the content document, relative to the package document.
+const chapterFile = "content.xhtml"
+
+// Book is the article to package.
+type Book struct {
+	// Title is the book title; "Untitled" when empty.
+	Title string
+	// Author, Site, Published, and Description fill the byline and the
+	// Dublin Core metadata when set.
+	Author      string
+	Site        string
+	Published   string
+	Description string
+	// Language is the BCP 47 language tag of the content, "en" when empty.
+	Language string
+	// URL is where the article was read from. Relative links and images in
+	// Content are resolved against it when it is an http or https URL, and
+	// it seeds the book identifier.
+	URL string
+	// Cover is the address of an image to package as the cover, if any.
+	Cover string
+	// Content is the article HTML.
+	Content string
+	// Modified is the last-modified time of the package, now when zero.
+	Modified time.Time
+}
+
+// Fetcher returns the bytes of the image at src, which is resolved against
+// Book.URL when that is a web address and passed as written otherwise.
+type Fetcher func(src string) ([]byte, error)
+
+// resource is a file packaged beside the chapter.
+type resource struct {
+	id, href, mediaType, properties string
+	data                            []byte
+}
+
+// heading is a section of the chapter listed in the navigation document.
+type heading struct {
+	id, text string
+}
+
+// Write packages book as an EPUB 3 file to w. Images are packaged through
+// fetch; one that cannot be fetched, or that is not a JPEG, PNG, GIF, WebP,
+// or SVG image, is replaced by its alt text, since readers do not load
+// remote images. A nil fetch packages only data: URL images.
+func Write(w io.Writer, book *Book, fetch Fetcher) error {
+	if book == nil {
+		book = &Book{}
+	}
+	title := strings.TrimSpace(book.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+	lang := strings.TrimSpace(book.Language)
+	if lang == "" {
+		lang = "en"
+	}
+	modified := book.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	p := &packager{base: webBase(book.URL), fetch: fetch, fetched: make(map[string]*resource), ids: make(map[string]bool)}
+	body, err := p.chapter(book.Content)
+	if err != nil {
+		return err
+	}
+	var cover *resource
+	if src := strings.TrimSpace(book.Cover); src != "" {
+		if cover = p.image(src); cover != nil {
+			cover.properties = "cover-image"
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	// The mimetype entry comes first and uncompressed, so readers can
+	// identify the file from its first bytes
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("epub: %w", err)
+	}
+	if _, err := io.WriteString(mimetype, MediaType); err != nil {
+		return fmt.Errorf("epub: %w", err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"META-INF/container.xml", []byte(containerXML())},
+		{"OEBPS/content.opf", []byte(p.packageDocument(book, title, lang, modified))},
+		{"OEBPS/nav.xhtml", []byte(p.navDocument(title, lang))},
+		{"OEBPS/" + chapterFile, []byte(chapterDocument(book, title, lang, body))},
+	}
+	for _, res := range p.resources {
+		files = append(files, struct {
+			name string
+			data []byte
+		}{"OEBPS/" + res.href, res.data})
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return fmt.Errorf("epub: %w", err)
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return fmt.Errorf("epub: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("epub: %w", err)
+	}
+	return nil
+}
+
+// packager collects what the chapter needs as it is converted.
+type packager struct {
+	base      *url.URL
+	fetch     Fetcher
+	fetched   map[string]*resource
+	resources []*resource
+	headings  []heading
+	ids       map[string]bool
+	mathML    bool
+}
+
+// chapter returns content as the XHTML body of the chapter: sanitized,
+// with images packaged, links made absolute, and ids fit for XML.
+func (p *packager) chapter(content string) (string, error) {
+	content = sanitize.HTML(content)
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return "", fmt.Errorf("epub: parsing content: %w", err)
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	p.convert(body)
+
+	var b strings.Builder
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&b, n); err != nil {
+			return "", fmt.Errorf("epub: rendering content: %w", err)
+		}
+	}
+	return b.String(), nil
+}
+
+// convert rewrites the children of n for the package.
+func (p *packager) convert(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+			c.Data = xmlText(c.Data)
+		case html.ElementNode:
+			p.convertElement(c)
+		default:
+			// Comments may hold "--", which XML does not allow
+			n.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+// convertElement rewrites n and its children for the package.
+func (p *packager) convertElement(n *html.Node) {
+	switch n.Data {
+	case "audio", "video", "source", "track":
+		// Readers do not play remote media, and picture falls back to img
+		n.Parent.RemoveChild(n)
+		return
+	case "img":
+		if !p.convertImage(n) {
+			return
+		}
+	case "math":
+		p.mathML = true
+		setAttr(n, "xmlns", mathMLNS)
+	}
+
+	for i := range n.Attr {
+		n.Attr[i].Val = xmlText(n.Attr[i].Val)
+	}
+	if id := attr(n, "id"); id != "" {
+		if !isNCName(id) || p.ids[id] {
+			removeAttr(n, "id")
+		} else {
+			p.ids[id] = true
+		}
+	}
+	if n.Data == "a" {
+		if href := attr(n, "href"); href != "" && !strings.HasPrefix(href, "#") {
+			setAttr(n, "href", p.resolve(href))
+		}
+	}
+
+	p.convert(n)
+
+	if n.Data == "h2" {
+		text := strings.Join(strings.Fields(textContent(n)), " ")
+		if text != "" {
+			id := attr(n, "id")
+			if id == "" {
+				id = p.uniqueID("section-" + strconv.Itoa(len(p.headings)+1))
+				setAttr(n, "id", id)
+			}
+			p.headings = append(p.headings, heading{id: id, text: text})
+		}
+	}
+}
+
+// convertImage packages the image of n and reports whether it did, or
+// replaces n by its alt text.
+func (p *packager) convertImage(n *html.Node) bool {
+	removeAttr(n, "srcset")
+	removeAttr(n, "sizes")
+	if res := p.image(attr(n, "src")); res != nil {
+		setAttr(n, "src", res.href)
+		// alt is required in XHTML
+		setAttr(n, "alt", attr(n, "alt"))
+		return true
+	}
+	if alt := strings.TrimSpace(xmlText(attr(n, "alt"))); alt != "" {
+		n.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: alt}, n)
+	}
+	n.Parent.RemoveChild(n)
+	return false
+}
+
+// image packages the image at src once and returns it, or nil when it
+// cannot be fetched or is not an EPUB core image type.
+func (p *packager) image(src string) *resource {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return nil
+	}
+	if !strings.HasPrefix(strings.ToLower(src), "data:") {
+		src = p.resolve(src)
+	}
+	if res, ok := p.fetched[src]; ok {
+		return res
+	}
+
+	var data []byte
+	if strings.HasPrefix(strings.ToLower(src), "data:") {
+		data = dataURL(src)
+	} else if p.fetch != nil {
+		if fetched, err := p.fetch(src); err == nil {
+			data = fetched
+		}
+	}
+	var res *resource
+	if mediaType, ext := imageType(data); mediaType != "" {
+		n := len(p.resources) + 1
+		res = &resource{
+			id:        "image-" + strconv.Itoa(n),
+			href:      "images/image-" + strconv.Itoa(n) + "." + ext,
+			mediaType: mediaType,
+			data:      data,
+		}
+		p.resources = append(p.resources, res)
+	}
+	p.fetched[src] = res
+	return res
+}
+
+// resolve returns ref resolved against the base URL, if there is one.
+func (p *packager) resolve(ref string) string {
+	if p.base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return p.base.ResolveReference(u).String()
+}
+
+// uniqueID returns id, with a number appended if the chapter already has it.
+func (p *packager) uniqueID(id string) string {
+	candidate := id
+	for i := 2; p.ids[candidate]; i++ {
+		candidate = id + "-" + strconv.Itoa(i)
+	}
+	p.ids[candidate] = true
+	return candidate
+}
+
+// packageDocument returns content.opf.
+func (p *packager) packageDocument(book *Book, title, lang string, modified time.Time) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<package xmlns="%s" version="3.0" unique-identifier="book-id" xml:lang="%s">`+"\n", opfNS, escape(lang))
+	fmt.Fprintf(&b, `<metadata xmlns:dc="%s">`+"\n", dcNS)
+	fmt.Fprintf(&b, `<dc:identifier id="book-id">%s</dc:identifier>`+"\n", identifier(book, title))
+	fmt.Fprintf(&b, "<dc:title>%s</dc:title>\n", escape(title))
+	fmt.Fprintf(&b, "<dc:language>%s</dc:language>\n", escape(lang))
+	if author := strings.TrimSpace(book.Author); author != "" {
+		fmt.Fprintf(&b, "<dc:creator>%s</dc:creator>\n", escape(author))
+	}
+	if site := strings.TrimSpace(book.Site); site != "" {
+		fmt.Fprintf(&b, "<dc:publisher>%s</dc:publisher>\n", escape(site))
+	}
+	if date := publishedDate(book.Published); date != "" {
+		fmt.Fprintf(&b, "<dc:date>%s</dc:date>\n", date)
+	}
+	if description := strings.TrimSpace(book.Description); description != "" {
+		fmt.Fprintf(&b, "<dc:description>%s</dc:description>\n", escape(description))
+	}
+	if p.base != nil {
+		fmt.Fprintf(&b, "<dc:source>%s</dc:source>\n", escape(p.base.String()))
+	}
+	fmt.Fprintf(&b, `<meta property="dcterms:modified">%s</meta>`+"\n", modified.UTC().Format("2006-01-02T15:04:05Z"))
+	b.WriteString("</metadata>\n<manifest>\n")
+	b.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	chapterProperties := ""
+	if p.mathML {
+		chapterProperties = ` properties="mathml"`
+	}
+	fmt.Fprintf(&b, `<item id="content" href="%s" media-type="application/xhtml+xml"%s/>`+"\n", chapterFile, chapterProperties)
+	for _, res := range p.resources {
+		properties := ""
+		if res.properties != "" {
+			properties = ` properties="` + res.properties + `"`
+		}
+		fmt.Fprintf(&b, `<item id="%s" href="%s" media-type="%s"%s/>`+"\n", res.id, res.href, res.mediaType, properties)
+	}
+	b.WriteString("</manifest>\n<spine>\n")
+	b.WriteString(`<itemref idref="content"/>` + "\n")
+	b.WriteString("</spine>\n</package>\n")
+	return b.String()
+}
+
+// navDocument returns nav.xhtml, listing the chapter and its h2 sections.
+func (p *packager) navDocument(title, lang string) string {
+	var b strings.Builder
+	b.WriteString(xhtmlHeader(title, lang))
+	b.WriteString("<body>\n")
+	fmt.Fprintf(&b, `<nav epub:type="toc" id="toc">`+"\n<h1>%s</h1>\n<ol>\n", escape(title))
+	fmt.Fprintf(&b, `<li><a href="%s">%s</a>`, chapterFile, escape(title))
+	if len(p.headings) > 0 {
+		b.WriteString("\n<ol>\n")
+		for _, h := range p.headings {
+			fmt.Fprintf(&b, `<li><a href="%s#%s">%s</a></li>`+"\n", chapterFile, escape(h.id), escape(h.text))
+		}
+		b.WriteString("</ol>\n")
+	}
+	b.WriteString("</li>\n</ol>\n</nav>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// chapterDocument returns the chapter with its title and byline.
+func chapterDocument(book *Book, title, lang, body string) string {
+	var b strings.Builder
+	b.WriteString(xhtmlHeader(title, lang))
+	b.WriteString("<body>\n<article>\n<header>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", escape(title))
+	var byline []string
+	for _, part := range []string{book.Author, book.Site, book.Published} {
+		if part = strings.TrimSpace(part); part != "" {
+			byline = append(byline, escape(part))
+		}
+	}
+	if len(byline) > 0 {
+		fmt.Fprintf(&b, `<p class="byline">%s</p>`+"\n", strings.Join(byline, " · "))
+	}
+	b.WriteString("</header>\n")
+	b.WriteString(body)
+	b.WriteString("\n</article>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// xhtmlHeader opens an XHTML content document through its head.
+func xhtmlHeader(title, lang string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="%s" xmlns:epub="%s" lang="%s" xml:lang="%s">
+<head>
+<meta charset="utf-8"/>
+<title>%s</title>
+</head>
+`, xhtmlNS, opsNS, escape(lang), escape(lang), escape(title))
+}
+
+// containerXML returns META-INF/container.xml, which points readers at the
+// package document.
+func containerXML() string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="%s">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`, containerNS)
+}
+
+// identifier returns a urn:uuid identifier named by the book URL, or by
+// its title and content when there is none, so exporting the same article
+// twice gives the same identifier.
+func identifier(book *Book, title string) string {
+	name := book.URL
+	if name == "" {
+		name = title + "\x00" + book.Content
+	}
+	// A version 5 UUID in the URL namespace (RFC 9562)
+	namespace := []byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	h := sha1.New() // #nosec G401 - not used for security
+	h.Write(namespace)
+	h.Write([]byte(name))
+	sum := h.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// publishedDate returns the date of published as YYYY-MM-DD, or "" when it
+// does not start with one.
+func publishedDate(published string) string {
+	published = strings.TrimSpace(published)
+	if len(published) < 10 {
+		return ""
+	}
+	if _, err := time.Parse("2006-01-02", published[:10]); err != nil {
+		return ""
+	}
+	return published[:10]
+}
+
+// imageType returns the media type and file extension of an image in an
+// EPUB core media type, or "" for anything else.
+func imageType(data []byte) (mediaType, ext string) {
+	if len(data) == 0 {
+		return "", ""
+	}
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return "image/jpeg", "jpg"
+	case "image/png":
+		return "image/png", "png"
+	case "image/gif":
+		return "image/gif", "gif"
+	case "image/webp":
+		return "image/webp", "webp"
+	}
+	head := data[:min(len(data), 512)]
+	if strings.Contains(string(head), "<svg") {
+		return "image/svg+xml", "svg"
+	}
+	return "", ""
+}
+
+// dataURL returns the bytes of a base64 data: URL, or nil.
+func dataURL(src string) []byte {
+	meta, payload, ok := strings.Cut(src, ",")
+	if !ok || !strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// webBase returns raw as a URL when it is an http or https address.
+func webBase(raw string) *url.URL {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// isNCName reports whether id is usable as an XML id: a letter or
+// underscore, then letters, digits, and ".-_".
+func isNCName(id string) bool {
+	for i, r := range id {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return id != ""
+}
+
+// xmlText returns s without the characters XML does not allow.
+func xmlText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != 0xfffe && r != 0xffff && (r < 0xd800 || r > 0xdfff)) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// escape returns s as XML text.
+func escape(s string) string {
+	return html.EscapeString(xmlText(s))
+}
+
+// textContent returns the text inside n.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Key != key {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+}
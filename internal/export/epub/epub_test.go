@@ -0,0 +1,177 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pngImage is a 1x1 transparent PNG.
+var pngImage, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==")
+
+func readEPUB(t *testing.T, data []byte) (*zip.Reader, map[string]string) {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%s) error = %v", f.Name, err)
+		}
+		files[f.Name] = string(content)
+	}
+	return zr, files
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	var fetched []string
+	fetch := func(src string) ([]byte, error) {
+		fetched = append(fetched, src)
+		if strings.HasSuffix(src, "/chart.png") {
+			return pngImage, nil
+		}
+		return nil, errors.New("not found")
+	}
+	book := &Book{
+		Title:     "Rivers & Roads",
+		Author:    "Jane Doe",
+		Site:      "Example News",
+		Published: "2024-03-05T10:00:00Z",
+		URL:       "https://example.com/news/rivers",
+		Cover:     "https://example.com/news/img/chart.png",
+		Content: `<p>Intro with a <a href="/about">link</a> and a <a href="#notes">jump</a>.</p>
+<!-- tracking -- comment -->
+<h2 id="notes">Notes</h2>
+<p><img src="img/chart.png" srcset="big.png 2x" alt="Chart"> <img src="missing.jpg" alt="A missing photo"></p>
+<h2>More <em>notes</em></h2>
+<p id="1bad">Inline <img src="data:image/png;base64,` + base64.StdEncoding.EncodeToString(pngImage) + `" alt=""> image.<br></p>
+<script>alert(1)</script>
+<math><mi>x</mi></math>`,
+		Modified: time.Date(2024, 3, 6, 8, 0, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, book, fetch); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	zr, files := readEPUB(t, buf.Bytes())
+
+	if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store || files["mimetype"] != MediaType {
+		t.Fatalf("first entry = %s (method %d), want stored mimetype", first.Name, first.Method)
+	}
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".xml") && !strings.HasSuffix(name, ".opf") && !strings.HasSuffix(name, ".xhtml") {
+			continue
+		}
+		d := xml.NewDecoder(strings.NewReader(content))
+		for {
+			_, err := d.Token()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v\n%s", name, err, content)
+			}
+		}
+	}
+
+	if got := fetched; len(got) != 2 || got[0] != "https://example.com/news/img/chart.png" || got[1] != "https://example.com/news/missing.jpg" {
+		t.Fatalf("fetched = %v", got)
+	}
+
+	chapter := files["OEBPS/content.xhtml"]
+	for _, want := range []string{
+		`<h1>Rivers &amp; Roads</h1>`,
+		`Jane Doe · Example News · 2024-03-05T10:00:00Z`,
+		`<a href="https://example.com/about">link</a>`,
+		`<a href="#notes">jump</a>`,
+		`<h2 id="notes">Notes</h2>`,
+		`<img src="images/image-1.png" alt="Chart"/>`,
+		`A missing photo`,
+		`<h2 id="section-2">More <em>notes</em></h2>`,
+		`<p>Inline <img src="images/image-2.png" alt=""/> image.<br/></p>`,
+		`<math xmlns="http://www.w3.org/1998/Math/MathML">`,
+	} {
+		if !strings.Contains(chapter, want) {
+			t.Fatalf("chapter missing %q:\n%s", want, chapter)
+		}
+	}
+	for _, unwanted := range []string{"missing.jpg", "srcset", "<script", "tracking", "1bad"} {
+		if strings.Contains(chapter, unwanted) {
+			t.Fatalf("chapter contains %q:\n%s", unwanted, chapter)
+		}
+	}
+
+	opf := files["OEBPS/content.opf"]
+	for _, want := range []string{
+		`<dc:title>Rivers &amp; Roads</dc:title>`,
+		`<dc:creator>Jane Doe</dc:creator>`,
+		`<dc:publisher>Example News</dc:publisher>`,
+		`<dc:date>2024-03-05</dc:date>`,
+		`<dc:language>en</dc:language>`,
+		`<dc:source>https://example.com/news/rivers</dc:source>`,
+		`<meta property="dcterms:modified">2024-03-06T08:00:00Z</meta>`,
+		`<item id="content" href="content.xhtml" media-type="application/xhtml+xml" properties="mathml"/>`,
+		`<item id="image-1" href="images/image-1.png" media-type="image/png" properties="cover-image"/>`,
+		`<item id="image-2" href="images/image-2.png" media-type="image/png"/>`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Fatalf("content.opf missing %q:\n%s", want, opf)
+		}
+	}
+	if _, ok := files["OEBPS/images/image-1.png"]; !ok {
+		t.Fatal("image-1.png not packaged")
+	}
+
+	nav := files["OEBPS/nav.xhtml"]
+	for _, want := range []string{`<a href="content.xhtml#notes">Notes</a>`, `<a href="content.xhtml#section-2">More notes</a>`} {
+		if !strings.Contains(nav, want) {
+			t.Fatalf("nav.xhtml missing %q:\n%s", want, nav)
+		}
+	}
+}
+
+func TestWriteIdentifierIsStable(t *testing.T) {
+	t.Parallel()
+
+	opf := func(book *Book) string {
+		var buf bytes.Buffer
+		if err := Write(&buf, book, nil); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		_, files := readEPUB(t, buf.Bytes())
+		return files["OEBPS/content.opf"]
+	}
+	id := func(content string) string {
+		_, rest, _ := strings.Cut(content, `<dc:identifier id="book-id">`)
+		value, _, _ := strings.Cut(rest, "<")
+		return value
+	}
+
+	first := opf(&Book{URL: "https://example.com/a", Content: "<p>One</p>"})
+	second := opf(&Book{URL: "https://example.com/a", Content: "<p>Two</p>"})
+	other := opf(&Book{URL: "https://example.com/b", Content: "<p>One</p>"})
+	if id(first) == "" || id(first) != id(second) || id(first) == id(other) {
+		t.Fatalf("identifiers = %q, %q, %q", id(first), id(second), id(other))
+	}
+	if !strings.Contains(first, "<dc:title>Untitled</dc:title>") {
+		t.Fatalf("content.opf missing default title:\n%s", first)
+	}
+}
@@ -0,0 +1,120 @@
+// Package jsonld loads the @context documents that JSON-LD processing
+// needs without touching the network: the schema.org context is bundled,
+// and other contexts are fetched only when a caller allows it.
+package jsonld
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// ErrRemoteContext is returned for a context that is not bundled when
+// remote contexts are not allowed.
+var ErrRemoteContext = errors.New("remote JSON-LD context not allowed")
+
+// SchemaOrgContextURL is the URL the bundled schema.org context is
+// published at.
+const SchemaOrgContextURL = "https://schema.org/docs/jsonldcontext.jsonld"
+
+// remoteTimeout bounds the fetch of one remote context.
+const remoteTimeout = 10 * time.Second
+
+// maxCachedContexts caps the remote contexts kept for the life of the
+// process. Contexts past the cap are fetched every time they are used.
+const maxCachedContexts = 64
+
+//go:embed schemaorg-context.json
+var schemaOrgContext []byte
+
+// schemaOrgDocument parses the bundled context once.
+var schemaOrgDocument = sync.OnceValues(func() (any, error) {
+	return ld.DocumentFromReader(bytes.NewReader(schemaOrgContext))
+})
+
+// Loader is an ld.DocumentLoader that serves the schema.org context from
+// the bundled copy. Other contexts fail with ErrRemoteContext, or with
+// remote set are fetched over HTTP and cached. Loaders are safe for
+// concurrent use.
+type Loader struct {
+	remote ld.DocumentLoader
+
+	mu    sync.Mutex
+	cache map[string]*ld.RemoteDocument
+}
+
+var (
+	offline = &Loader{}
+	online  = &Loader{
+		remote: ld.NewDefaultDocumentLoader(&http.Client{Timeout: remoteTimeout}),
+		cache:  make(map[string]*ld.RemoteDocument),
+	}
+)
+
+// Default returns the process-wide loader that fetches contexts that are
+// not bundled only when remote is true. The two loaders share no cache, so
+// an offline parse never sees a context an online one fetched.
+func Default(remote bool) *Loader {
+	if remote {
+		return online
+	}
+	return offline
+}
+
+// LoadDocument returns the context document at u.
+func (l *Loader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	if IsSchemaOrgContext(u) {
+		doc, err := schemaOrgDocument()
+		if err != nil {
+			return nil, fmt.Errorf("bundled schema.org context: %w", err)
+		}
+		return &ld.RemoteDocument{DocumentURL: SchemaOrgContextURL, Document: doc}, nil
+	}
+	if l.remote == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRemoteContext, u)
+	}
+
+	l.mu.Lock()
+	doc, ok := l.cache[u]
+	l.mu.Unlock()
+	if ok {
+		return doc, nil
+	}
+	doc, err := l.remote.LoadDocument(u)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	if len(l.cache) < maxCachedContexts {
+		l.cache[u] = doc
+	}
+	l.mu.Unlock()
+	return doc, nil
+}
+
+// IsSchemaOrgContext reports whether u names the schema.org context: the
+// site root or its published context file, over http or https, with or
+// without www and a trailing slash.
+func IsSchemaOrgContext(u string) bool {
+	scheme, rest, ok := strings.Cut(u, "://")
+	if !ok || (!strings.EqualFold(scheme, "http") && !strings.EqualFold(scheme, "https")) {
+		return false
+	}
+	host, path, _ := strings.Cut(rest, "/")
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host != "schema.org" {
+		return false
+	}
+	switch strings.TrimSuffix(path, "/") {
+	case "", "docs/jsonldcontext.json", "docs/jsonldcontext.jsonld":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package jsonld
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// countingLoader serves an empty context and counts the loads.
+type countingLoader struct{ loads int }
+
+func (c *countingLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	c.loads++
+	return &ld.RemoteDocument{DocumentURL: u, Document: map[string]any{"@context": map[string]any{}}}, nil
+}
+
+func TestIsSchemaOrgContext(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"https://schema.org":                           true,
+		"http://schema.org/":                           true,
+		"https://www.schema.org/":                      true,
+		"HTTPS://Schema.org/docs/jsonldcontext.json":   true,
+		"https://schema.org/docs/jsonldcontext.jsonld": true,
+		"https://schema.org/Article":                   false,
+		"https://schema.org.example.com/":              false,
+		"https://example.com/context.jsonld":           false,
+		"ftp://schema.org/":                            false,
+		"schema.org":                                   false,
+	}
+	for u, want := range tests {
+		if got := IsSchemaOrgContext(u); got != want {
+			t.Errorf("IsSchemaOrgContext(%q) = %v, want %v", u, got, want)
+		}
+	}
+}
+
+func TestLoaderServesBundledContextOffline(t *testing.T) {
+	t.Parallel()
+
+	doc, err := Default(false).LoadDocument("https://schema.org")
+	if err != nil {
+		t.Fatalf("LoadDocument() error = %v", err)
+	}
+	context, ok := doc.Document.(map[string]any)["@context"].(map[string]any)
+	if !ok || context["@vocab"] != "http://schema.org/" {
+		t.Fatalf("bundled context = %v, want the schema.org @vocab", doc.Document)
+	}
+
+	if _, err := Default(false).LoadDocument("https://example.com/context.jsonld"); !errors.Is(err, ErrRemoteContext) {
+		t.Fatalf("LoadDocument() error = %v, want ErrRemoteContext", err)
+	}
+}
+
+func TestLoaderCachesRemoteContexts(t *testing.T) {
+	t.Parallel()
+
+	remote := &countingLoader{}
+	loader := &Loader{remote: remote, cache: make(map[string]*ld.RemoteDocument)}
+	for range 3 {
+		if _, err := loader.LoadDocument("https://example.com/context.jsonld"); err != nil {
+			t.Fatalf("LoadDocument() error = %v", err)
+		}
+	}
+	if _, err := loader.LoadDocument("http://schema.org/"); err != nil {
+		t.Fatalf("LoadDocument() error = %v", err)
+	}
+	if remote.loads != 1 {
+		t.Fatalf("remote loads = %d, want 1", remote.loads)
+	}
+}
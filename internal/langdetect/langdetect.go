@@ -0,0 +1,159 @@
+// Package langdetect guesses the language of plain text without external
+// models: from its script for scripts that one language dominates, and from
+// the frequency of common function words for text in Latin script.
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLetters is the fewest letters Detect guesses from.
+const minLetters = 20
+
+// minFunctionWords is the fewest function words of the best language that
+// Latin-script text must contain.
+const minFunctionWords = 3
+
+// scriptLanguages maps scripts that one language dominates to it.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Tamil, "ta"},
+	{unicode.Georgian, "ka"},
+	{unicode.Armenian, "hy"},
+}
+
+// functionWords are frequent short words of each Latin-script language,
+// chosen to overlap little between languages.
+var functionWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "with", "for", "was", "this", "are", "have", "from", "which", "would", "their"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "mit", "sich", "auch", "auf", "dem", "den", "wird", "sind"},
+	"fr": {"le", "les", "et", "des", "est", "une", "dans", "pour", "qui", "pas", "sur", "avec", "sont", "mais", "cette", "aux"},
+	"es": {"el", "los", "las", "y", "del", "es", "una", "por", "con", "para", "que", "como", "pero", "más", "está", "sus"},
+	"it": {"il", "di", "che", "della", "sono", "una", "per", "con", "non", "gli", "nel", "anche", "è", "più", "alla", "questo"},
+	"pt": {"o", "os", "as", "do", "da", "não", "uma", "com", "para", "em", "que", "mais", "foi", "dos", "são", "também"},
+	"nl": {"de", "het", "een", "van", "en", "is", "niet", "dat", "zijn", "voor", "met", "ook", "maar", "wordt", "naar", "bij"},
+	"sv": {"och", "att", "det", "som", "är", "för", "med", "inte", "till", "av", "på", "har", "den", "ett", "var", "jag"},
+	"da": {"og", "at", "det", "som", "er", "til", "med", "ikke", "af", "på", "har", "den", "et", "var", "jeg", "der"},
+	"pl": {"i", "w", "nie", "na", "się", "jest", "że", "do", "to", "z", "jak", "ale", "po", "tak", "oraz", "który"},
+	"tr": {"ve", "bir", "bu", "için", "ile", "da", "de", "olarak", "çok", "daha", "gibi", "ama", "olan", "ne", "kadar", "değil"},
+	"id": {"dan", "yang", "di", "ini", "itu", "dengan", "untuk", "tidak", "dari", "dalam", "akan", "pada", "adalah", "juga", "ke", "karena"},
+}
+
+// functionWordLanguages inverts functionWords.
+var functionWordLanguages = func() map[string][]string {
+	languages := make(map[string][]string)
+	for language, words := range functionWords {
+		for _, word := range words {
+			languages[word] = append(languages[word], language)
+		}
+	}
+	return languages
+}()
+
+// Detect returns the ISO 639-1 code of the language text is most likely
+// written in, or "" when the text is too short or the guess too weak.
+// Han text with kana is Japanese, and without it Chinese; Cyrillic text is
+// Ukrainian when it uses letters only Ukrainian has, and Russian otherwise;
+// Arabic-script text is Persian when it uses Persian letters, including
+// the Persian forms of kaf and yeh.
+func Detect(text string) string {
+	var letters, latin, cyrillic, arabic, han, kana int
+	var ukrainian, persian bool
+	scripts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+			persian = persian || strings.ContainsRune("پچژگکی", r)
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.script, r) {
+					scripts[s.language]++
+					break
+				}
+			}
+		}
+	}
+	if letters < minLetters {
+		return ""
+	}
+
+	// A script holding most letters decides, except Latin, which many
+	// languages share
+	majority := letters / 2
+	switch {
+	case kana > 0 && han+kana > majority:
+		return "ja"
+	case han > majority:
+		return "zh"
+	case cyrillic > majority:
+		if ukrainian {
+			return "uk"
+		}
+		return "ru"
+	case arabic > majority:
+		if persian {
+			return "fa"
+		}
+		return "ar"
+	}
+	for language, count := range scripts {
+		if count > majority {
+			return language
+		}
+	}
+	if latin <= majority {
+		return ""
+	}
+	return detectLatin(text)
+}
+
+// detectLatin returns the language whose function words are most frequent
+// in text, or "" when none has enough or two tie.
+func detectLatin(text string) string {
+	counts := make(map[string]int)
+	for word := range strings.FieldsFuncSeq(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for _, language := range functionWordLanguages[word] {
+			counts[language]++
+		}
+	}
+
+	var best string
+	var bestCount, secondCount int
+	for language, count := range counts {
+		switch {
+		case count > bestCount:
+			best, bestCount, secondCount = language, count, bestCount
+		case count > secondCount:
+			secondCount = count
+		}
+	}
+	if bestCount < minFunctionWords || bestCount == secondCount {
+		return ""
+	}
+	return best
+}
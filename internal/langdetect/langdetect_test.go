@@ -0,0 +1,42 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "The committee said that the proposal was sent to the council, which would decide on it this year.", "en"},
+		{"german", "Die Regierung hat angekündigt, dass das neue Gesetz nicht vor dem Sommer in Kraft treten wird.", "de"},
+		{"french", "Le gouvernement a annoncé que la nouvelle loi est entrée en vigueur dans les régions pour les familles.", "fr"},
+		{"spanish", "El gobierno anunció que la nueva ley entrará en vigor para los ciudadanos del país y las empresas.", "es"},
+		{"italian", "Il governo ha annunciato che la nuova legge non entrerà in vigore per gli abitanti della regione.", "it"},
+		{"portuguese", "O governo anunciou que a nova lei não vai entrar em vigor para os moradores da cidade e também dos bairros.", "pt"},
+		{"dutch", "De regering heeft aangekondigd dat het nieuwe wetsvoorstel niet voor de zomer van kracht wordt.", "nl"},
+		{"swedish", "Regeringen har meddelat att den nya lagen inte kommer att träda i kraft före sommaren och det är klart.", "sv"},
+		{"polish", "Rząd ogłosił, że nowa ustawa nie wejdzie w życie przed latem, ale jest to tylko plan na razie.", "pl"},
+		{"russian", "Правительство объявило, что новый закон вступит в силу летом этого года.", "ru"},
+		{"ukrainian", "Уряд оголосив, що новий закон набуде чинності влітку цього року і діятиме скрізь.", "uk"},
+		{"japanese", "政府は新しい法律が今年の夏に施行されると発表しました。これは重要な変更です。", "ja"},
+		{"chinese", "政府宣布新法律将于今年夏天生效，这是一项重要的变化，影响很多人。", "zh"},
+		{"korean", "정부는 새로운 법이 올해 여름에 시행될 것이라고 발표했습니다.", "ko"},
+		{"arabic", "أعلنت الحكومة أن القانون الجديد سيدخل حيز التنفيذ هذا الصيف.", "ar"},
+		{"persian", "دولت اعلام کرد که قانون جدید از تابستان امسال اجرا می‌شود و همه باید آن را بدانند.", "fa"},
+		{"greek", "Η κυβέρνηση ανακοίνωσε ότι ο νέος νόμος θα τεθεί σε ισχύ το καλοκαίρι.", "el"},
+		{"too short", "The end.", ""},
+		{"no function words", "Lorem ipsum dolor sit amet consectetur adipiscing elit sed eiusmod tempor.", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Detect(tt.text); got != tt.want {
+				t.Fatalf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,21 +3,233 @@
 package markdown
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 
-	htmltomarkdown "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/marker"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/strikethrough"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
+	"golang.org/x/net/html"
 )
 
+// defaultConverter renders CommonMark plus GFM tables and strikethrough.
+// Converters are safe for concurrent use.
+var defaultConverter = newConverter()
+
+func newConverter() *converter.Converter {
+	conv := converter.NewConverter(converter.WithPlugins(
+		base.NewBasePlugin(),
+		commonmark.NewCommonmarkPlugin(),
+		strikethrough.NewStrikethroughPlugin(),
+		table.NewTablePlugin(),
+	))
+	conv.Register.PreRenderer(escapeTableCodePipes, converter.PriorityStandard)
+	conv.Register.PreRenderer(minimizeEscapes, converter.PriorityStandard)
+	conv.Register.PreRenderer(appendImageCredits, converter.PriorityStandard)
+	conv.Register.PreRenderer(numberFigures, converter.PriorityStandard)
+	conv.Register.PostRenderer(restoreEscapeSentinels, converter.PriorityLate)
+	conv.Register.RendererFor("blockquote", converter.TagTypeBlock, renderBlockquote, converter.PriorityEarly)
+	conv.Register.RendererFor("sup", converter.TagTypeInline, renderFootnoteReference, converter.PriorityEarly)
+	conv.Register.RendererFor("div", converter.TagTypeBlock, renderFootnoteSection, converter.PriorityEarly)
+	conv.Register.RendererFor("a", converter.TagTypeInline, renderFootnoteBackRef, converter.PriorityEarly)
+	conv.Register.RendererFor("a", converter.TagTypeInline, renderReferenceLink, converter.PriorityEarly)
+	for _, tag := range []string{"kbd", "mark", "sub", "sup"} {
+		conv.Register.RendererFor(tag, converter.TagTypeInline, renderPassthroughInline, converter.PriorityEarly)
+	}
+	conv.Register.RendererFor("details", converter.TagTypeBlock, renderPassthroughDetails, converter.PriorityEarly)
+	for _, tag := range []string{"h1", "h2", "h3", "h4", "h5", "h6"} {
+		conv.Register.RendererFor(tag, converter.TagTypeBlock, renderHeadingID, converter.PriorityEarly)
+	}
+	return conv
+}
+
+// LinkStyle selects how links are written in Markdown output.
+type LinkStyle string
+
+const (
+	// LinkStyleInline writes links as [text](url)
+	LinkStyleInline LinkStyle = "inline"
+	// LinkStyleReference writes links as [text][n] with numbered definitions
+	// collected at the end of the document, one per distinct URL
+	LinkStyleReference LinkStyle = "reference"
+)
+
+// Options configures HTML to Markdown conversion.
+type Options struct {
+	// LinkStyle is LinkStyleInline or LinkStyleReference
+	// Defaults to LinkStyleInline when empty.
+	LinkStyle LinkStyle `json:"linkStyle,omitempty"`
+
+	// Wrap is WrapNone, WrapSoft, or WrapHard
+	// Defaults to WrapNone when empty.
+	Wrap Wrap `json:"wrap,omitempty"`
+
+	// WrapWidth is the maximum line length in columns for WrapHard
+	// Defaults to 80 when zero.
+	WrapWidth int `json:"wrapWidth,omitempty"`
+
+	// HTMLPassthrough is HTMLPassthroughNone, HTMLPassthroughSafe, or HTMLPassthroughAll
+	// Defaults to HTMLPassthroughNone when empty.
+	HTMLPassthrough HTMLPassthrough `json:"htmlPassthrough,omitempty"`
+
+	// DropImageCredits leaves out the photo credits that standardization
+	// moved from figure captions into data-credit attributes. By default
+	// each credit is written back after its caption.
+	// Defaults to false.
+	DropImageCredits bool `json:"dropImageCredits,omitempty"`
+
+	// HeadingIDs writes the id of each heading that has one after its text,
+	// as in "## Setup {#setup}", the attribute syntax of Pandoc, kramdown,
+	// and other extended Markdown renderers.
+	// Defaults to false.
+	HeadingIDs bool `json:"headingIDs,omitempty"`
+
+	// NumberFigures numbers captioned figures and tables in document order,
+	// as in "Figure 1: caption" and "Table 1: caption", for print and LaTeX
+	// workflows. A label the source gave a caption is replaced, and text
+	// references to it such as "see Fig. 3" follow the new number.
+	// Defaults to false.
+	NumberFigures bool `json:"numberFigures,omitempty"`
+}
+
+// DefaultOptions returns the default Markdown conversion options
+func DefaultOptions() *Options {
+	return &Options{
+		LinkStyle:       LinkStyleInline,
+		Wrap:            WrapNone,
+		WrapWidth:       DefaultWrapWidth,
+		HTMLPassthrough: HTMLPassthroughNone,
+	}
+}
+
 // ConvertHTML converts HTML content to Markdown with default settings
 func ConvertHTML(htmlContent string) (string, error) {
-	markdownContent, err := htmltomarkdown.ConvertString(htmlContent)
+	return Convert(htmlContent, nil)
+}
+
+// Convert converts HTML content to Markdown. A nil options uses DefaultOptions.
+func Convert(htmlContent string, options *Options) (string, error) {
+	if options == nil {
+		options = DefaultOptions()
+	}
+
+	ctx := context.Background()
+	var refs *linkReferences
+	switch options.LinkStyle {
+	case "", LinkStyleInline:
+	case LinkStyleReference:
+		refs = &linkReferences{labels: make(map[string]int)}
+		ctx = context.WithValue(ctx, linkReferencesKey{}, refs)
+	default:
+		return "", fmt.Errorf("failed to convert HTML to Markdown: unsupported link style %q", options.LinkStyle)
+	}
+	switch options.Wrap {
+	case "", WrapNone, WrapSoft, WrapHard:
+	default:
+		return "", fmt.Errorf("failed to convert HTML to Markdown: unsupported wrap mode %q", options.Wrap)
+	}
+	switch options.HTMLPassthrough {
+	case "", HTMLPassthroughNone, HTMLPassthroughSafe, HTMLPassthroughAll:
+		ctx = context.WithValue(ctx, htmlPassthroughKey{}, options.HTMLPassthrough)
+	default:
+		return "", fmt.Errorf("failed to convert HTML to Markdown: unsupported HTML passthrough %q", options.HTMLPassthrough)
+	}
+
+	ctx = context.WithValue(ctx, dropImageCreditsKey{}, options.DropImageCredits)
+	ctx = context.WithValue(ctx, headingIDsKey{}, options.HeadingIDs)
+	ctx = context.WithValue(ctx, numberFiguresKey{}, options.NumberFigures)
+
+	markdownContent, err := defaultConverter.ConvertString(htmlContent, converter.WithContext(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to convert HTML to Markdown: %w", err)
 	}
 
 	markdownContent = strings.TrimSpace(markdownContent)
 	markdownContent = strings.ReplaceAll(markdownContent, "\n\n\n", "\n\n")
+	markdownContent = wrapMarkdown(markdownContent, options.Wrap, options.WrapWidth)
+
+	if refs != nil && len(refs.links) > 0 {
+		markdownContent += "\n\n" + refs.definitions()
+	}
 
 	return markdownContent, nil
 }
+
+// blockquoteKey marks the blockquote whose rendering is delegated to the
+// commonmark plugin.
+type blockquoteKey struct{}
+
+// renderBlockquote wraps the commonmark blockquote renderer. That renderer
+// prefixes "> " only on regular lines, but fenced code keeps its newlines
+// behind marker.MarkerCodeBlockNewline until the end of conversion, so code
+// lines after the first would fall out of the quote. The prefix is added to
+// those lines here; nested quotes and lists each add their own on the way out.
+func renderBlockquote(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	if ctx.Value(blockquoteKey{}) == n {
+		return converter.RenderTryNext
+	}
+
+	var buf bytes.Buffer
+	ctx.RenderNodes(ctx.WithValue(blockquoteKey{}, n), &buf, n)
+
+	prefixed := append(slices.Clone(marker.BytesMarkerCodeBlockNewline), '>', ' ')
+	_, _ = w.Write(bytes.ReplaceAll(buf.Bytes(), marker.BytesMarkerCodeBlockNewline, prefixed))
+	return converter.RenderSuccess
+}
+
+// escapeTableCodePipes escapes "|" inside code spans in table cells. Code
+// span content is emitted verbatim, so an unescaped pipe would end the GFM
+// cell early; GFM strips the backslash again when rendering the cell.
+// Tables with line breaks or preformatted blocks are left alone because the
+// table plugin does not render them as GFM tables.
+func escapeTableCodePipes(_ converter.Context, doc *html.Node) {
+	var walk func(n *html.Node, inCode bool)
+	walk = func(n *html.Node, inCode bool) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "table":
+				if containsElement(n, "br", "pre") {
+					return
+				}
+			case "code":
+				inCode = true
+			}
+		}
+		if n.Type == html.TextNode && inCode && hasAncestor(n, "td", "th") {
+			n.Data = strings.ReplaceAll(n.Data, "|", `\|`)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, inCode)
+		}
+	}
+	walk(doc, false)
+}
+
+// containsElement reports whether any descendant of n has one of the tag names.
+func containsElement(n *html.Node, tags ...string) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && slices.Contains(tags, c.Data) {
+			return true
+		}
+		if containsElement(c, tags...) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAncestor reports whether an ancestor of n has one of the tag names.
+func hasAncestor(n *html.Node, tags ...string) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && slices.Contains(tags, p.Data) {
+			return true
+		}
+	}
+	return false
+}
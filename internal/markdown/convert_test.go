@@ -65,3 +65,135 @@ func TestConvertHTMLPreservesReadableMarkdown(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertHTMLIndentsCodeInsideBlockquotes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "blockquote",
+			html: "<blockquote><pre><code>a\nb</code></pre></blockquote>",
+			want: "> ```\n> a\n> b\n> ```",
+		},
+		{
+			name: "nested blockquote",
+			html: "<blockquote><blockquote><pre><code>a\nb</code></pre></blockquote></blockquote>",
+			want: "> > ```\n> > a\n> > b\n> > ```",
+		},
+		{
+			name: "list inside blockquote",
+			html: "<blockquote><ul><li><pre><code>a\nb</code></pre></li></ul></blockquote>",
+			want: "> - ```\n>   a\n>   b\n>   ```",
+		},
+		{
+			name: "blockquote inside list",
+			html: "<ul><li><blockquote><pre><code>a\nb</code></pre></blockquote></li></ul>",
+			want: "- > ```\n  > a\n  > b\n  > ```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertHTML(tt.html)
+			if err != nil {
+				t.Fatalf("ConvertHTML() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ConvertHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertReferenceLinksDeduplicateTargets(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p><a href="https://a.example" title="First">One</a> <a href="https://b.example">Two</a> <a href="https://a.example">Three</a> <a href="https://c.example/a b">Four</a></p>`, &Options{LinkStyle: LinkStyleReference})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := "[One][1] [Two][2] [Three][1] [Four][3]\n\n" +
+		"[1]: https://a.example \"First\"\n" +
+		"[2]: https://b.example\n" +
+		"[3]: https://c.example/a%20b"
+	if got != want {
+		t.Fatalf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertReferenceLinksFallBackToInlineWithoutTarget(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p><a href="">Nowhere</a> <a href="https://a.example"><img src="/i.png" alt="Icon"></a></p>`, &Options{LinkStyle: LinkStyleReference})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := "[Nowhere]() [![Icon](/i.png)][1]\n\n[1]: https://a.example"
+	if got != want {
+		t.Fatalf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertReferenceLinksWithoutLinksAddsNoDefinitions(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p>Plain text</p>`, &Options{LinkStyle: LinkStyleReference})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if got != "Plain text" {
+		t.Fatalf("Convert() = %q, want %q", got, "Plain text")
+	}
+}
+
+func TestConvertRejectsUnknownLinkStyle(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Convert(`<p>x</p>`, &Options{LinkStyle: "footnote"}); err == nil {
+		t.Fatal("Convert() error = nil, want unsupported link style error")
+	}
+}
+
+func TestConvertHTMLWritesGFMFootnotes(t *testing.T) {
+	t.Parallel()
+
+	got, err := ConvertHTML(`<p>Claim<sup id="fnref:1"><a href="#fn:1">1</a></sup> and again<sup><a href="#fn:1">1</a></sup>, then<sup id="fnref:2"><a href="#fn:2">2</a></sup>.</p>` +
+		`<div id="footnotes"><ol>` +
+		`<li id="fn:1"><p>First note with <a href="https://example.com">a link</a>.</p> <a href="#fnref:1" class="footnote-backref">↩</a></li>` +
+		`<li id="fn:2"><p>Para one.</p><p>Para two.</p><pre><code>x
+y</code></pre> <a href="#fnref:2" class="footnote-backref">↩</a></li>` +
+		`</ol></div>`)
+	if err != nil {
+		t.Fatalf("ConvertHTML() error = %v", err)
+	}
+
+	want := "Claim[^1] and again[^1], then[^2].\n\n" +
+		"[^1]: First note with [a link](https://example.com).\n\n" +
+		"[^2]: Para one.\n\n    Para two.\n\n    ```\n    x\n    y\n    ```"
+	if got != want {
+		t.Fatalf("ConvertHTML() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertHTMLKeepsOrdinarySuperscripts(t *testing.T) {
+	t.Parallel()
+
+	got, err := ConvertHTML(`<p>E = mc<sup>2</sup> and <sup><a href="#section">see</a></sup></p><div id="other"><p>Plain div</p></div>`)
+	if err != nil {
+		t.Fatalf("ConvertHTML() error = %v", err)
+	}
+	if strings.Contains(got, "[^") {
+		t.Fatalf("ConvertHTML() = %q, want no footnote syntax", got)
+	}
+	if !strings.Contains(got, "[see](#section)") || !strings.Contains(got, "Plain div") {
+		t.Fatalf("ConvertHTML() = %q, want ordinary content kept", got)
+	}
+}
@@ -0,0 +1,61 @@
+package markdown
+
+import (
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"golang.org/x/net/html"
+)
+
+// creditAttribute holds the photo credit of a figure, split from its
+// caption during standardization.
+const creditAttribute = "data-credit"
+
+// dropImageCreditsKey carries Options.DropImageCredits of a conversion.
+type dropImageCreditsKey struct{}
+
+// appendImageCredits writes the credit of each figure back after its
+// caption, since Markdown has no attribute to keep it in. With
+// Options.DropImageCredits the credits are left out.
+func appendImageCredits(ctx converter.Context, doc *html.Node) {
+	if drop, _ := ctx.Value(dropImageCreditsKey{}).(bool); drop {
+		return
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == "figure" {
+				if credit := attr(c, creditAttribute); credit != "" {
+					appendCredit(c, credit)
+				}
+			}
+			walk(c)
+		}
+	}
+	walk(doc)
+}
+
+// appendCredit adds credit to the end of the figcaption of figure,
+// creating one when the credit was all the caption had.
+func appendCredit(figure *html.Node, credit string) {
+	caption := findElement(figure, "figcaption")
+	if caption == nil {
+		caption = &html.Node{Type: html.ElementNode, Data: "figcaption"}
+		figure.AppendChild(caption)
+	} else if caption.FirstChild != nil {
+		credit = " " + credit
+	}
+	caption.AppendChild(&html.Node{Type: html.TextNode, Data: credit})
+}
+
+// findElement returns the first descendant of n with the tag name, or nil.
+func findElement(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertAppendsImageCreditsToCaptions(t *testing.T) {
+	t.Parallel()
+
+	input := `<figure data-credit="Photo: Jane Doe/Reuters"><img src="https://example.com/a.jpg" alt="A"><figcaption>Crowds gather.</figcaption></figure>`
+
+	got, err := Convert(input, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if !strings.Contains(got, "Crowds gather. Photo: Jane Doe/Reuters") {
+		t.Fatalf("Convert() = %q, want caption followed by credit", got)
+	}
+
+	options := DefaultOptions()
+	options.DropImageCredits = true
+	got, err = Convert(input, options)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if strings.Contains(got, "Jane Doe") || !strings.Contains(got, "Crowds gather.") {
+		t.Fatalf("Convert() = %q, want caption without credit", got)
+	}
+}
+
+func TestConvertAddsCaptionForCreditOnlyFigures(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<figure data-credit="© Jane Doe"><img src="https://example.com/a.jpg" alt="A"></figure>`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if !strings.Contains(got, "© Jane Doe") {
+		t.Fatalf("Convert() = %q, want credit as caption", got)
+	}
+}
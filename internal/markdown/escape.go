@@ -0,0 +1,194 @@
+package markdown
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"golang.org/x/net/html"
+)
+
+// The commonmark plugin escapes a character whenever it could have a Markdown
+// meaning somewhere, so snake_case becomes snake\_case and a table cell
+// "1. one" becomes "1\. one". minimizeEscapes looks at each character in its
+// text node and replaces the ones that cannot change rendering with
+// sentinels, which the escaper does not recognize; restoreEscapeSentinels
+// turns them back into the plain characters once rendering is done.
+//
+// Anything the text node alone cannot prove safe is left to the escaper.
+
+// escapeSentinelBase offsets ASCII characters into Supplementary Private Use
+// Area-A, which does not occur in extracted text.
+const escapeSentinelBase = 0xF0000
+
+// verbatimTags render their text as code, which is never escaped.
+var verbatimTags = []string{"pre", "code", "var", "samp", "tt"}
+
+// minimizeEscapes replaces characters that need no escaping with sentinels.
+//
+//   - "_" runs between letters or digits cannot open or close emphasis.
+//   - "\" before a character that is not ASCII punctuation is literal.
+//   - "<" not followed by a letter, "/", "!", or "?" cannot start a tag or
+//     autolink, so it needs no "&lt;".
+//   - ">" after other text on the line cannot start a blockquote, and no tag
+//     is open for it to close.
+//   - List, heading, and blockquote markers at the start of a table cell
+//     stay literal, since cells hold inline content only.
+func minimizeEscapes(ctx converter.Context, doc *html.Node) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (slices.Contains(verbatimTags, n.Data) || writesHTMLText(ctx, n)) {
+			return
+		}
+		if n.Type == html.TextNode {
+			n.Data = minimizeTextEscapes(n.Data, startsTableCell(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+}
+
+// minimizeTextEscapes returns text with the characters that need no escaping
+// replaced by sentinels. cellStart reports whether text opens a table cell.
+func minimizeTextEscapes(text string, cellStart bool) string {
+	runes := []rune(text)
+	safe := make([]bool, len(runes))
+
+	if cellStart {
+		markCellMarkers(runes, safe)
+	}
+
+	lineStart := true
+	for i, r := range runes {
+		switch r {
+		case '_':
+			if i > 0 && isWordRune(runes[i-1]) {
+				end := i
+				for end < len(runes) && runes[end] == '_' {
+					end++
+				}
+				if end < len(runes) && isWordRune(runes[end]) {
+					for j := i; j < end; j++ {
+						safe[j] = true
+					}
+				}
+			}
+		case '\\':
+			if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && !isASCIIPunct(runes[i+1]) {
+				safe[i] = true
+			}
+		case '<':
+			if i+1 < len(runes) && !isTagStart(runes[i+1]) {
+				safe[i] = true
+			}
+		case '>':
+			if !lineStart {
+				safe[i] = true
+			}
+		}
+		if !unicode.IsSpace(r) {
+			lineStart = false
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i, r := range runes {
+		if safe[i] {
+			r += escapeSentinelBase
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// markCellMarkers marks a leading list, heading, or blockquote marker of a
+// table cell as safe.
+func markCellMarkers(runes []rune, safe []bool) {
+	i := 0
+	for i < len(runes) && unicode.IsSpace(runes[i]) {
+		i++
+	}
+	switch {
+	case i == len(runes):
+	case runes[i] == '-' || runes[i] == '+' || runes[i] == '*':
+		if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+			safe[i] = true
+		}
+	case runes[i] == '#' || runes[i] == '>':
+		for marker := runes[i]; i < len(runes) && runes[i] == marker; i++ {
+			safe[i] = true
+		}
+	case runes[i] >= '0' && runes[i] <= '9':
+		for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+			i++
+		}
+		if i < len(runes) && (runes[i] == '.' || runes[i] == ')') {
+			safe[i] = true
+		}
+	}
+}
+
+// startsTableCell reports whether n is the first content of a td or th in a
+// table rendered as a GFM table; see escapeTableCodePipes.
+func startsTableCell(n *html.Node) bool {
+	for p := n; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && (p.Data == "td" || p.Data == "th") {
+			for table := p.Parent; table != nil; table = table.Parent {
+				if table.Type == html.ElementNode && table.Data == "table" {
+					return !containsElement(table, "br", "pre")
+				}
+			}
+			return false
+		}
+		for s := p.PrevSibling; s != nil; s = s.PrevSibling {
+			if s.Type == html.ElementNode || strings.TrimSpace(s.Data) != "" {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// restoreEscapeSentinels turns sentinels back into the characters they stand for.
+func restoreEscapeSentinels(_ converter.Context, result []byte) []byte {
+	if !strings.ContainsFunc(string(result), isEscapeSentinel) {
+		return result
+	}
+	out := make([]byte, 0, len(result))
+	for len(result) > 0 {
+		r, size := utf8.DecodeRune(result)
+		if isEscapeSentinel(r) {
+			r -= escapeSentinelBase
+		}
+		out = utf8.AppendRune(out, r)
+		result = result[size:]
+	}
+	return out
+}
+
+// isEscapeSentinel reports whether r stands for an unescaped ASCII character.
+func isEscapeSentinel(r rune) bool {
+	return r >= escapeSentinelBase && r < escapeSentinelBase+utf8.RuneSelf
+}
+
+// isWordRune reports whether r is a letter or digit.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isASCIIPunct reports whether r is ASCII punctuation, which a backslash escapes.
+func isASCIIPunct(r rune) bool {
+	return r < utf8.RuneSelf && unicode.IsPunct(r) || strings.ContainsRune("$+<=>^`|~", r)
+}
+
+// isTagStart reports whether r after "<" could begin an HTML tag, comment,
+// processing instruction, declaration, or autolink.
+func isTagStart(r rune) bool {
+	return r < utf8.RuneSelf && unicode.IsLetter(r) || r == '/' || r == '!' || r == '?'
+}
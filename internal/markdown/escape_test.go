@@ -0,0 +1,148 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+)
+
+// commonMarkRenderer renders Markdown with no extensions, the strictest
+// consumer of escaping decisions.
+var commonMarkRenderer = goldmark.New()
+
+func TestConvertHTMLOmitsUnneededEscapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "underscores inside words",
+			html: `<p>Call snake_case_name or MAX__LIMIT.</p>`,
+			want: `Call snake_case_name or MAX__LIMIT.`,
+		},
+		{
+			name: "underscores in bare URLs and link text",
+			html: `<p>See https://example.com/some_path/file_name.html or <a href="https://example.com/x_y">x_y</a>.</p>`,
+			want: `See https://example.com/some_path/file_name.html or [x_y](https://example.com/x_y).`,
+		},
+		{
+			name: "comparison operators",
+			html: `<p>When a &lt; b and b &gt; c, 1 &lt;= 2.</p>`,
+			want: `When a < b and b > c, 1 <= 2.`,
+		},
+		{
+			name: "backslashes in paths",
+			html: `<p>Open C:\Users\guest\notes.txt.</p>`,
+			want: `Open C:\Users\guest\notes.txt.`,
+		},
+		{
+			name: "block markers at the start of table cells",
+			html: `<table><tr><th>Step</th><th>Note</th></tr><tr><td>1. Start</td><td>- dash</td></tr><tr><td># tag</td><td>&gt; quote</td></tr></table>`,
+			want: "| Step     | Note    |\n|----------|---------|\n| 1. Start | - dash  |\n| # tag    | > quote |",
+		},
+		{
+			name: "numbers inside sentences",
+			html: `<p>In 2019. Version 1.2.3 shipped.</p>`,
+			want: `In 2019. Version 1.2.3 shipped.`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertHTML(tt.html)
+			if err != nil {
+				t.Fatalf("ConvertHTML() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ConvertHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLKeepsNeededEscapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "emphasis-like underscores", html: `<p>Define __init__ and _private_ names.</p>`, want: `\_\_init\__ and \_private_`},
+		{name: "intraword asterisks", html: `<p>Compute 2*3*4.</p>`, want: `2\*3\*4`},
+		{name: "ordered list marker at line start", html: `<p>1. Not a list</p>`, want: `1\. Not a list`},
+		{name: "list marker inside list item", html: `<ul><li>- dash</li></ul>`, want: `- \- dash`},
+		{name: "blockquote marker at line start", html: `<p>&gt; not a quote</p>`, want: `&gt; not a quote`},
+		{name: "tag-like text", html: `<p>Use &lt;div&gt; here.</p>`, want: `&lt;div>`},
+		{name: "backslash before punctuation", html: `<p>Type \*a* to match.</p>`, want: `\\\*a*`},
+		{name: "backticks", html: "<p>Quote `a` here.</p>", want: "\\`a\\`"},
+		{name: "table-like markers in tables with line breaks", html: `<table><tr><td>1. one<br>two</td></tr></table>`, want: `1\. one`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertHTML(tt.html)
+			if err != nil {
+				t.Fatalf("ConvertHTML() error = %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("ConvertHTML() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLEscapingRoundTripsThroughCommonMark(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		html  string
+		table bool
+	}{
+		{name: "words and URLs", html: `<p>snake_case, MAX__LIMIT, /usr/local_bin/go_1 and <a href="https://example.com">a_b</a></p>`},
+		{name: "emphasis lookalikes", html: `<p>__init__ and _x_ and *y* and 2*3*4 and **z**</p>`},
+		{name: "operators", html: `<p>a &lt; b, b &gt; c, x&lt;y, 1 &lt;= 2, a-&gt;b, &lt;div&gt; and &lt;!-- note --&gt;</p>`},
+		{name: "line start markers", html: `<p>1. one</p><p>2) two</p><p>- dash</p><p>+ plus</p><p># hash</p><p>&gt; quote</p>`},
+		{name: "backslashes", html: `<p>C:\Users\x, \* star, \_ under, trailing \</p>`},
+		{name: "backticks", html: "<p>it`s, ``double`` and <code>real</code></p>"},
+		{name: "inside emphasis and links", html: `<p><em>snake_case &lt; x</em> and <a href="/x">a &gt; b_c</a></p>`},
+		{name: "list items", html: `<ul><li>1. first</li><li>a_b &gt; c</li><li>&gt; quoted</li></ul>`},
+		{name: "table cells", html: `<table><tr><th>1. head</th><th>- h</th></tr><tr><td># cell_x</td><td>&gt; a &lt; b</td></tr></table>`, table: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			md, err := ConvertHTML(tt.html)
+			if err != nil {
+				t.Fatalf("ConvertHTML() error = %v", err)
+			}
+
+			renderers := map[string]goldmark.Markdown{"gfm": roundTripRenderer}
+			if !tt.table {
+				renderers["commonmark"] = commonMarkRenderer
+			}
+			want := strings.Join(skeleton(t, tt.html), "\n")
+			for name, renderer := range renderers {
+				var out bytes.Buffer
+				if err := renderer.Convert([]byte(md), &out); err != nil {
+					t.Fatalf("%s Convert() error = %v", name, err)
+				}
+				if got := strings.Join(skeleton(t, out.String()), "\n"); got != want {
+					t.Fatalf("%s rendering drifted\nmarkdown:\n%s\n\ngot:\n%s\n\nwant:\n%s", name, md, got, want)
+				}
+			}
+		})
+	}
+}
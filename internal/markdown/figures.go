@@ -0,0 +1,150 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"golang.org/x/net/html"
+)
+
+// numberFiguresKey carries Options.NumberFigures of a conversion.
+type numberFiguresKey struct{}
+
+var (
+	// figureLabelPattern matches the label a caption opens with, as in
+	// "Figure 3:", "Fig. 3.", or "Table 2 –".
+	figureLabelPattern = regexp.MustCompile(`(?i)^\s*(fig(?:ure)?\.?|image|photo|chart|exhibit|table)\s+(\d+)\s*(?:[:.|–—-]\s*)?`)
+	// figureReferencePattern matches in-text references such as "Figure 3",
+	// "Fig. 3", and "Table 2".
+	figureReferencePattern = regexp.MustCompile(`\b((?:[Ff]ig(?:ure)?\.?|FIG(?:URE)?\.?|[Tt]able|TABLE)\s+)(\d+)\b`)
+)
+
+// figureNumbers maps the numbers the source gave its figures or tables to
+// the ones NumberFigures gives them.
+type figureNumbers struct {
+	figures map[string]int
+	tables  map[string]int
+}
+
+// numberFigures numbers the captioned figures and tables of doc in document
+// order for Options.NumberFigures, as in "Figure 1: Harbor at dawn" and
+// "Table 1: Rates". A label the source gave the caption is replaced, and
+// references to it in the text, such as "see Fig. 3", are renumbered to
+// match. References to numbers no caption had are left alone.
+func numberFigures(ctx converter.Context, doc *html.Node) {
+	if number, _ := ctx.Value(numberFiguresKey{}).(bool); !number {
+		return
+	}
+
+	numbers := figureNumbers{figures: map[string]int{}, tables: map[string]int{}}
+	var figureCount, tableCount int
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "figure":
+				caption := findElement(c, "figcaption")
+				if caption == nil {
+					break
+				}
+				if findElement(c, "table") != nil {
+					tableCount++
+					labelCaption(caption, "Table", tableCount, numbers.tables)
+					continue
+				}
+				if containsElement(c, "img", "picture", "svg", "video", "iframe") {
+					figureCount++
+					labelCaption(caption, "Figure", figureCount, numbers.figures)
+					continue
+				}
+			case "table":
+				if caption := childElement(c, "caption"); caption != nil {
+					tableCount++
+					labelCaption(caption, "Table", tableCount, numbers.tables)
+				}
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if len(numbers.figures) > 0 || len(numbers.tables) > 0 {
+		renumberReferences(doc, numbers)
+	}
+}
+
+// labelCaption replaces the label caption opens with by "<kind> <number>:"
+// and records the number the label had in renumbered.
+func labelCaption(caption *html.Node, kind string, number int, renumbered map[string]int) {
+	label := kind + " " + strconv.Itoa(number)
+	if first := firstText(caption); first != nil {
+		if m := figureLabelPattern.FindStringSubmatchIndex(first.Data); m != nil {
+			renumbered[first.Data[m[4]:m[5]]] = number
+			first.Data = first.Data[m[1]:]
+		}
+	}
+	if strings.TrimSpace(textContent(caption)) != "" {
+		label += ": "
+	}
+	caption.InsertBefore(&html.Node{Type: html.TextNode, Data: label}, caption.FirstChild)
+}
+
+// renumberReferences rewrites the figure and table numbers in the text of
+// doc outside captions and code.
+func renumberReferences(doc *html.Node, numbers figureNumbers) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				c.Data = figureReferencePattern.ReplaceAllStringFunc(c.Data, func(ref string) string {
+					m := figureReferencePattern.FindStringSubmatch(ref)
+					renumbered := numbers.figures
+					if strings.EqualFold(strings.TrimSpace(m[1]), "table") {
+						renumbered = numbers.tables
+					}
+					if number, ok := renumbered[m[2]]; ok {
+						return m[1] + strconv.Itoa(number)
+					}
+					return ref
+				})
+			case c.Type == html.ElementNode:
+				switch c.Data {
+				case "figcaption", "caption", "code", "pre":
+					continue
+				}
+				walk(c)
+			}
+		}
+	}
+	walk(doc)
+}
+
+// firstText returns the first non-blank text node below n, or nil.
+func firstText(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) != "" {
+			return c
+		}
+		if found := firstText(c); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// childElement returns the first child of n with the tag name, or nil.
+func childElement(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+	}
+	return nil
+}
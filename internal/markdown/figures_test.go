@@ -0,0 +1,35 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertNumberFigures(t *testing.T) {
+	t.Parallel()
+
+	input := `<p>As Figure 3 shows, boats return at dawn; see also <a href="#map">Fig. 4</a>, Table 7, and Figure 9.</p>` +
+		`<figure><img src="harbor.png" alt="Harbor"><figcaption><strong>Figure 3.</strong> Harbor at dawn.</figcaption></figure>` +
+		`<figure id="map"><img src="map.png" alt="Map"><figcaption>Fig. 4: The route.</figcaption></figure>` +
+		`<figure><img src="boats.png" alt="Boats"><figcaption>Boats</figcaption></figure>` +
+		`<figure><img src="uncaptioned.png" alt="Uncaptioned"></figure>` +
+		`<table><caption>Table 7 – Catch by month</caption><tr><th>Month</th></tr><tr><td>May</td></tr></table>` +
+		`<pre><code>Figure 3 stays in code</code></pre>`
+
+	got, err := Convert(input, &Options{NumberFigures: true})
+	require.NoError(t, err)
+	assert.Equal(t, "As Figure 1 shows, boats return at dawn; see also [Fig. 2](#map), Table 1, and Figure 9.\n\n"+
+		"![Harbor](harbor.png)\n\nFigure 1: Harbor at dawn.\n\n"+
+		"![Map](map.png)\n\nFigure 2: The route.\n\n"+
+		"![Boats](boats.png)\n\nFigure 3: Boats\n\n"+
+		"![Uncaptioned](uncaptioned.png)\n\n"+
+		"| Month |\n|-------|\n| May   |\n\nTable 1: Catch by month\n\n"+
+		"```\nFigure 3 stays in code\n```", got)
+
+	got, err = Convert(input, nil)
+	require.NoError(t, err)
+	assert.Contains(t, got, "As Figure 3 shows")
+	assert.Contains(t, got, "Fig. 4: The route.")
+}
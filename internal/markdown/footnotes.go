@@ -0,0 +1,123 @@
+package markdown
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/marker"
+	"golang.org/x/net/html"
+)
+
+// Footnotes arrive in the structure built by standardization:
+//
+//	<sup id="fnref:1"><a href="#fn:1">1</a></sup>
+//	<div id="footnotes"><ol><li id="fn:1">... <a class="footnote-backref">↩</a></li></ol></div>
+//
+// They are written as GFM footnotes, [^1] in the text and [^1]: definitions
+// where the footnote section was, rather than links to anchors that do not
+// exist in Markdown.
+
+// footnoteLabel returns the label of a footnote reference sup, or "" when n is
+// not one.
+func footnoteLabel(n *html.Node) string {
+	var link *html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.ElementNode && c.Data == "a" && link == nil:
+			link = c
+		case c.Type == html.TextNode && strings.TrimSpace(c.Data) == "":
+		default:
+			return ""
+		}
+	}
+	if link == nil {
+		return ""
+	}
+	label, ok := strings.CutPrefix(attr(link, "href"), "#fn:")
+	if !ok || !validFootnoteLabel(label) {
+		return ""
+	}
+	return label
+}
+
+// validFootnoteLabel reports whether label can be used in [^label].
+func validFootnoteLabel(label string) bool {
+	return label != "" && !strings.ContainsAny(label, " \t\n[]^")
+}
+
+// renderFootnoteReference writes [^n] for footnote reference sups.
+func renderFootnoteReference(_ converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	label := footnoteLabel(n)
+	if label == "" {
+		return converter.RenderTryNext
+	}
+	_, _ = w.WriteString("[^" + label + "]")
+	return converter.RenderSuccess
+}
+
+// indentedCodeBlockNewline indents fenced code lines inside definitions.
+var indentedCodeBlockNewline = append(slices.Clone(marker.BytesMarkerCodeBlockNewline), "    "...)
+
+// renderFootnoteSection writes [^n]: definitions for the footnote section.
+// Continuation lines are indented four spaces so multi-paragraph footnotes
+// stay inside their definition.
+func renderFootnoteSection(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	if attr(n, "id") != "footnotes" {
+		return converter.RenderTryNext
+	}
+
+	var items []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if c.Data != "ol" {
+			return converter.RenderTryNext
+		}
+		for li := c.FirstChild; li != nil; li = li.NextSibling {
+			if li.Type == html.ElementNode && li.Data == "li" {
+				items = append(items, li)
+			}
+		}
+	}
+
+	_, _ = w.WriteString("\n\n")
+	for _, item := range items {
+		label, ok := strings.CutPrefix(attr(item, "id"), "fn:")
+		if !ok || !validFootnoteLabel(label) {
+			continue
+		}
+
+		var buf bytes.Buffer
+		ctx.RenderChildNodes(ctx, &buf, item)
+		content := bytes.TrimSpace(buf.Bytes())
+		if len(content) == 0 {
+			continue
+		}
+
+		content = bytes.ReplaceAll(content, marker.BytesMarkerCodeBlockNewline, indentedCodeBlockNewline)
+		_, _ = w.WriteString("[^" + label + "]: ")
+		for i, line := range bytes.Split(content, []byte("\n")) {
+			if i > 0 {
+				_, _ = w.WriteString("\n")
+				if len(bytes.TrimSpace(line)) > 0 {
+					_, _ = w.WriteString("    ")
+				}
+			}
+			_, _ = w.Write(line)
+		}
+		_, _ = w.WriteString("\n\n")
+	}
+	return converter.RenderSuccess
+}
+
+// renderFootnoteBackRef drops footnote back-references, which have no
+// Markdown equivalent.
+func renderFootnoteBackRef(_ converter.Context, _ converter.Writer, n *html.Node) converter.RenderStatus {
+	if !strings.Contains(" "+attr(n, "class")+" ", " footnote-backref ") {
+		return converter.RenderTryNext
+	}
+	return converter.RenderSuccess
+}
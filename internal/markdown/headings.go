@@ -0,0 +1,43 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"golang.org/x/net/html"
+)
+
+// headingIDsKey carries Options.HeadingIDs of a conversion.
+type headingIDsKey struct{}
+
+// headingKey marks the heading renderHeadingID is rendering, so the
+// commonmark renderer handles it on the second pass.
+type headingKey struct{}
+
+// renderHeadingID writes a heading with its id appended as {#id} when
+// Options.HeadingIDs is set, and otherwise leaves it to the commonmark
+// renderer.
+func renderHeadingID(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	enabled, _ := ctx.Value(headingIDsKey{}).(bool)
+	if !enabled || ctx.Value(headingKey{}) == n {
+		return converter.RenderTryNext
+	}
+	id := ""
+	for _, attr := range n.Attr {
+		if attr.Key == "id" {
+			id = attr.Val
+		}
+	}
+	if id == "" {
+		return converter.RenderTryNext
+	}
+
+	var buf bytes.Buffer
+	ctx.RenderNodes(ctx.WithValue(headingKey{}, n), &buf, n)
+	heading := bytes.TrimRight(buf.Bytes(), " \t\n")
+	trailing := buf.Bytes()[len(heading):]
+	_, _ = w.Write(heading)
+	_, _ = w.WriteString(" {#" + id + "}")
+	_, _ = w.Write(trailing)
+	return converter.RenderSuccess
+}
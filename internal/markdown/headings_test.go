@@ -0,0 +1,22 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertHeadingIDs(t *testing.T) {
+	t.Parallel()
+
+	input := `<h2 id="setup">Set <em>up</em></h2><p>Text</p><h3>No id</h3>`
+
+	got, err := Convert(input, &Options{HeadingIDs: true})
+	require.NoError(t, err)
+	assert.Equal(t, "## Set *up* {#setup}\n\nText\n\n### No id", got)
+
+	got, err = Convert(input, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "## Set *up*\n\nText\n\n### No id", got)
+}
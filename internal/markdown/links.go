@@ -0,0 +1,112 @@
+package markdown
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"golang.org/x/net/html"
+)
+
+// linkReferencesKey carries the *linkReferences of a reference-style conversion.
+type linkReferencesKey struct{}
+
+// insideLinkKey is the context key the commonmark plugin checks to escape "]"
+// in link text.
+var insideLinkKey any = "is_inside_link"
+
+// referenceLink is one numbered link definition.
+type referenceLink struct {
+	url   string
+	title string
+}
+
+// linkReferences numbers link targets in order of first appearance.
+// Conversion renders nodes sequentially, so no locking is needed.
+type linkReferences struct {
+	links  []referenceLink
+	labels map[string]int
+}
+
+// label returns the reference number for url, adding a definition the first
+// time url is seen. Repeated targets keep the title of their first link.
+func (r *linkReferences) label(url, title string) int {
+	if n, ok := r.labels[url]; ok {
+		if r.links[n-1].title == "" {
+			r.links[n-1].title = title
+		}
+		return n
+	}
+	r.links = append(r.links, referenceLink{url: url, title: title})
+	r.labels[url] = len(r.links)
+	return len(r.links)
+}
+
+// definitions renders the collected link definitions, one per line.
+func (r *linkReferences) definitions() string {
+	var b strings.Builder
+	for i, link := range r.links {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("[" + strconv.Itoa(i+1) + "]: " + linkDestination(link.url))
+		if link.title != "" {
+			b.WriteString(` "` + strings.ReplaceAll(link.title, `"`, `\"`) + `"`)
+		}
+	}
+	return b.String()
+}
+
+// linkDestination wraps destinations that contain spaces or angle brackets
+// in <> so they stay a single destination.
+func linkDestination(url string) string {
+	if strings.ContainsAny(url, " \t<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+// renderReferenceLink writes [text][n] for links when the conversion runs in
+// reference style. Links without a target or without text, and every link in
+// inline style, fall through to the commonmark renderer.
+func renderReferenceLink(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	refs, ok := ctx.Value(linkReferencesKey{}).(*linkReferences)
+	if !ok {
+		return converter.RenderTryNext
+	}
+
+	href := strings.TrimSpace(attr(n, "href"))
+	href = ctx.AssembleAbsoluteURL(ctx, "a", href)
+	if href == "" {
+		return converter.RenderTryNext
+	}
+
+	var buf bytes.Buffer
+	ctx.RenderChildNodes(ctx.WithValue(insideLinkKey, true), &buf, n)
+	content := buf.Bytes()
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return converter.RenderTryNext
+	}
+
+	before := content[:bytes.Index(content, trimmed)]
+	after := content[len(before)+len(trimmed):]
+	text := strings.Join(strings.Fields(string(trimmed)), " ")
+	title := strings.Join(strings.Fields(attr(n, "title")), " ")
+
+	_, _ = w.Write(before)
+	_, _ = w.WriteString("[" + text + "][" + strconv.Itoa(refs.label(href, title)) + "]")
+	_, _ = w.Write(after)
+	return converter.RenderSuccess
+}
+
+// attr returns the value of the named attribute, or "" when it is absent.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
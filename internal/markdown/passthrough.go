@@ -0,0 +1,134 @@
+package markdown
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"golang.org/x/net/html"
+)
+
+// HTMLPassthrough selects how elements without a Markdown equivalent, such as
+// kbd, mark, sub, sup, and details, are written.
+type HTMLPassthrough string
+
+const (
+	// HTMLPassthroughNone drops the element and writes only its content
+	HTMLPassthroughNone HTMLPassthrough = "none"
+	// HTMLPassthroughSafe writes the element as an attribute-free HTML tag
+	// around its converted content
+	HTMLPassthroughSafe HTMLPassthrough = "safe"
+	// HTMLPassthroughAll writes the element's original HTML, attributes included
+	HTMLPassthroughAll HTMLPassthrough = "all"
+)
+
+// passthroughTags are the elements HTMLPassthrough applies to. Footnote
+// reference sups are always written as [^n].
+var passthroughTags = []string{"kbd", "mark", "sub", "sup", "details"}
+
+// htmlPassthroughKey carries the HTMLPassthrough of a conversion.
+type htmlPassthroughKey struct{}
+
+// passthroughMode returns the HTMLPassthrough of the conversion in ctx.
+func passthroughMode(ctx converter.Context) HTMLPassthrough {
+	if mode, ok := ctx.Value(htmlPassthroughKey{}).(HTMLPassthrough); ok && mode != "" {
+		return mode
+	}
+	return HTMLPassthroughNone
+}
+
+// renderPassthroughInline writes kbd, mark, sub, and sup according to the
+// conversion's HTMLPassthrough. With HTMLPassthroughNone, kbd is plain text
+// like the others rather than a code span.
+func renderPassthroughInline(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	if n.Data == "sup" && footnoteLabel(n) != "" {
+		return converter.RenderTryNext
+	}
+
+	switch passthroughMode(ctx) {
+	case HTMLPassthroughSafe:
+		_, _ = w.WriteString("<" + n.Data + ">")
+		ctx.RenderChildNodes(ctx, w, n)
+		_, _ = w.WriteString("</" + n.Data + ">")
+	case HTMLPassthroughAll:
+		_ = html.Render(w, n)
+	default:
+		ctx.RenderChildNodes(ctx, w, n)
+	}
+	return converter.RenderSuccess
+}
+
+// renderPassthroughDetails writes details according to the conversion's
+// HTMLPassthrough. With HTMLPassthroughSafe the summary becomes escaped text
+// in a summary tag, and the remaining content is converted to Markdown
+// between blank lines so renderers parse it as Markdown:
+//
+//	<details>
+//	<summary>More</summary>
+//
+//	Hidden *text*
+//
+//	</details>
+func renderPassthroughDetails(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	switch passthroughMode(ctx) {
+	case HTMLPassthroughSafe:
+	case HTMLPassthroughAll:
+		var buf bytes.Buffer
+		_ = html.Render(&buf, n)
+		_, _ = w.WriteString("\n\n")
+		_, _ = w.Write(buf.Bytes())
+		_, _ = w.WriteString("\n\n")
+		return converter.RenderSuccess
+	default:
+		return converter.RenderTryNext
+	}
+
+	var summary string
+	var content bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "summary" {
+			if summary == "" {
+				summary = strings.Join(strings.Fields(textContent(c)), " ")
+			}
+			continue
+		}
+		ctx.RenderNodes(ctx, &content, c)
+	}
+
+	_, _ = w.WriteString("\n\n<details>\n")
+	if summary != "" {
+		_, _ = w.WriteString("<summary>" + html.EscapeString(summary) + "</summary>\n")
+	}
+	if body := bytes.TrimSpace(content.Bytes()); len(body) > 0 {
+		_, _ = w.WriteString("\n")
+		_, _ = w.Write(body)
+		_, _ = w.WriteString("\n\n")
+	}
+	_, _ = w.WriteString("</details>\n\n")
+	return converter.RenderSuccess
+}
+
+// writesHTMLText reports whether the conversion in ctx writes the text of n as
+// HTML rather than Markdown, so it must not be touched by minimizeEscapes.
+func writesHTMLText(ctx converter.Context, n *html.Node) bool {
+	switch passthroughMode(ctx) {
+	case HTMLPassthroughAll:
+		return slices.Contains(passthroughTags, n.Data)
+	case HTMLPassthroughSafe:
+		return n.Data == "summary"
+	}
+	return false
+}
+
+// textContent returns the concatenated text of n and its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+const passthroughInput = `<p>Press <kbd>Ctrl</kbd>+<kbd>C</kbd> to copy <mark class="hl">marked <em>text</em></mark>, H<sub>2</sub>O, and x<sup>2</sup>.</p>`
+
+func TestConvertHTMLPassthroughModes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mode HTMLPassthrough
+		want string
+	}{
+		{mode: "", want: `Press Ctrl+C to copy marked *text*, H2O, and x2.`},
+		{mode: HTMLPassthroughNone, want: `Press Ctrl+C to copy marked *text*, H2O, and x2.`},
+		{mode: HTMLPassthroughSafe, want: `Press <kbd>Ctrl</kbd>+<kbd>C</kbd> to copy <mark>marked *text*</mark>, H<sub>2</sub>O, and x<sup>2</sup>.`},
+		{mode: HTMLPassthroughAll, want: `Press <kbd>Ctrl</kbd>+<kbd>C</kbd> to copy <mark class="hl">marked <em>text</em></mark>, H<sub>2</sub>O, and x<sup>2</sup>.`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Convert(passthroughInput, &Options{HTMLPassthrough: tt.mode})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLPassthroughDetails(t *testing.T) {
+	t.Parallel()
+
+	input := `<details open class="faq"><summary>Why <b>this</b> &lt; that?</summary><p>Because <em>reasons</em>.</p><ul><li>One</li></ul></details><p>After</p>`
+
+	tests := []struct {
+		mode HTMLPassthrough
+		want string
+	}{
+		{mode: HTMLPassthroughNone, want: "Why **this** < that?\n\nBecause *reasons*.\n\n- One\n\nAfter"},
+		{mode: HTMLPassthroughSafe, want: "<details>\n<summary>Why this &lt; that?</summary>\n\nBecause *reasons*.\n\n- One\n\n</details>\n\nAfter"},
+		{mode: HTMLPassthroughAll, want: `<details open="" class="faq"><summary>Why <b>this</b> &lt; that?</summary><p>Because <em>reasons</em>.</p><ul><li>One</li></ul></details>` + "\n\nAfter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Convert(input, &Options{HTMLPassthrough: tt.mode})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLPassthroughKeepsFootnoteReferences(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []HTMLPassthrough{HTMLPassthroughNone, HTMLPassthroughSafe, HTMLPassthroughAll} {
+		got, err := Convert(`<p>Claim<sup id="fnref:1"><a href="#fn:1">1</a></sup></p>`, &Options{HTMLPassthrough: mode})
+		if err != nil {
+			t.Fatalf("Convert() error = %v", err)
+		}
+		if got != "Claim[^1]" {
+			t.Fatalf("Convert() with %s = %q, want %q", mode, got, "Claim[^1]")
+		}
+	}
+}
+
+func TestConvertHTMLSafePassthroughRendersBack(t *testing.T) {
+	t.Parallel()
+
+	input := passthroughInput + `<details><summary>More</summary><p>Hidden <strong>detail</strong></p></details>`
+	md, err := Convert(input, &Options{HTMLPassthrough: HTMLPassthroughSafe})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	renderer := goldmark.New(goldmark.WithExtensions(extension.GFM), goldmark.WithRendererOptions(gmhtml.WithUnsafe()))
+	var out bytes.Buffer
+	if err := renderer.Convert([]byte(md), &out); err != nil {
+		t.Fatalf("goldmark Convert() error = %v", err)
+	}
+	for _, want := range []string{
+		"<kbd>Ctrl</kbd>",
+		"<mark>marked <em>text</em></mark>",
+		"H<sub>2</sub>O",
+		"x<sup>2</sup>",
+		"<summary>More</summary>",
+		"<p>Hidden <strong>detail</strong></p>",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("rendered %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestConvertRejectsUnknownHTMLPassthrough(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Convert(`<p>x</p>`, &Options{HTMLPassthrough: "some"}); err == nil {
+		t.Fatal("Convert() error = nil, want unsupported HTML passthrough error")
+	}
+}
@@ -0,0 +1,222 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"golang.org/x/net/html"
+)
+
+// roundTripRenderer renders Markdown back to HTML the way a GFM consumer would.
+var roundTripRenderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// structuralTags are the elements whose nesting must survive a round trip.
+// Wrappers that Markdown cannot express, such as div, span, tbody, or the
+// paragraphs of loose list items, are ignored.
+var structuralTags = map[string]bool{
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "ul": true, "ol": true, "li": true,
+	"pre": true, "code": true, "table": true, "tr": true, "th": true, "td": true,
+	"a": true, "em": true, "strong": true, "del": true,
+}
+
+// skeleton flattens htmlContent into one line per text run or image,
+// prefixed with the path of structural ancestors, e.g.
+// "blockquote>ul>li>ul>li: Nested".
+func skeleton(t *testing.T, htmlContent string) []string {
+	t.Helper()
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+
+	var lines []string
+	var walk func(n *html.Node, path []string)
+	walk = func(n *html.Node, path []string) {
+		switch n.Type {
+		case html.TextNode:
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+				lines = append(lines, strings.Join(path, ">")+": "+text)
+			}
+			return
+		case html.ElementNode:
+			switch {
+			case n.Data == "img":
+				lines = append(lines, strings.Join(path, ">")+fmt.Sprintf(": img(%s %s)", attr(n, "src"), attr(n, "alt")))
+				return
+			case n.Data == "a":
+				path = append(path, "a("+attr(n, "href")+")")
+			case n.Data == "s":
+				path = append(path, "del")
+			case structuralTags[n.Data]:
+				path = append(path, n.Data)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path)
+		}
+	}
+	walk(doc, nil)
+	return lines
+}
+
+// roundTrip converts htmlContent to Markdown and renders it back to HTML.
+func roundTrip(t *testing.T, htmlContent string) (string, string) {
+	t.Helper()
+
+	md, err := ConvertHTML(htmlContent)
+	if err != nil {
+		t.Fatalf("ConvertHTML() error = %v", err)
+	}
+	var out bytes.Buffer
+	if err := roundTripRenderer.Convert([]byte(md), &out); err != nil {
+		t.Fatalf("goldmark Convert() error = %v", err)
+	}
+	return md, out.String()
+}
+
+func TestConvertHTMLRoundTripPreservesStructure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+	}{
+		{
+			name: "nested list inside blockquote",
+			html: `<blockquote><p>Intro</p><ul><li>One<ul><li>Nested</li></ul></li><li>Two</li></ul></blockquote>`,
+		},
+		{
+			name: "ordered list inside blockquote",
+			html: `<blockquote><ol><li>First</li><li>Second<ol><li>Inner</li></ol></li></ol></blockquote>`,
+		},
+		{
+			name: "table with inline code",
+			html: `<table><thead><tr><th>Name</th><th>Code</th></tr></thead><tbody><tr><td>Print</td><td><code>fmt.Println("a|b")</code></td></tr><tr><td><em>Join</em></td><td><code>strings.Join</code></td></tr></tbody></table>`,
+		},
+		{
+			name: "image inside link",
+			html: `<p><a href="https://example.com/full.png"><img src="https://example.com/thumb.png" alt="Thumbnail"></a></p>`,
+		},
+		{
+			name: "inline formatting",
+			html: `<p>Some <strong>bold</strong>, <em>italic</em>, <del>removed</del>, and <a href="https://example.com/docs">linked</a> text with <code>code</code>.</p>`,
+		},
+		{
+			name: "list nested four levels deep",
+			html: `<ul><li>L1<ul><li>L2<ul><li>L3<ul><li>L4<ol><li>L5</li></ol></li></ul></li></ul></li></ul></li><li>Back</li></ul>`,
+		},
+		{
+			name: "ordered lists nested three levels deep",
+			html: `<ol><li>One<ol><li>Two<ol><li>Three</li><li>Three again</li></ol></li></ol></li><li>Back</li></ol>`,
+		},
+		{
+			name: "blockquote containing fenced code",
+			html: `<blockquote><p>Quote</p><pre><code>line1
+line2
+
+line4</code></pre></blockquote>`,
+		},
+		{
+			name: "blockquote containing list with code",
+			html: `<blockquote><ul><li>Item<pre><code>a
+b</code></pre><ul><li>Nested</li></ul></li></ul></blockquote>`,
+		},
+		{
+			name: "nested blockquotes containing code",
+			html: `<blockquote><p>Outer</p><blockquote><p>Inner</p><pre><code>x
+y</code></pre></blockquote></blockquote>`,
+		},
+		{
+			name: "list containing blockquote with list",
+			html: `<ul><li>Item<blockquote><p>Quoted</p><ul><li>Deep<pre><code>p
+q</code></pre></li></ul></blockquote></li></ul>`,
+		},
+		{
+			name: "headings and fenced code",
+			html: `<h2>Setup</h2><p>Run this:</p><pre><code class="language-sh">go test ./...
+go vet ./...</code></pre><h3>Next</h3><p>Done.</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			md, rendered := roundTrip(t, tt.html)
+			want := skeleton(t, tt.html)
+			got := skeleton(t, rendered)
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Fatalf("round trip drifted\nmarkdown:\n%s\n\ngot:\n%s\n\nwant:\n%s",
+					md, strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestConvertHTMLRoundTripIsStable(t *testing.T) {
+	t.Parallel()
+
+	input := `<blockquote><p>Quote</p><ul><li>A<ul><li>B</li></ul></li></ul></blockquote><table><tr><th>K</th><th>V</th></tr><tr><td><code>x|y</code></td><td>1</td></tr></table>`
+
+	first, rendered := roundTrip(t, input)
+	second, err := ConvertHTML(rendered)
+	if err != nil {
+		t.Fatalf("ConvertHTML() error = %v", err)
+	}
+	if second != first {
+		t.Fatalf("ConvertHTML() not stable across round trip\nfirst:\n%s\n\nsecond:\n%s", first, second)
+	}
+}
+
+func TestConvertReferenceLinksRoundTripToSameTargets(t *testing.T) {
+	t.Parallel()
+
+	input := `<p>See <a href="https://example.com/a" title="A">alpha</a> and <a href="https://example.com/b"><em>beta</em></a>.</p><ul><li><a href="https://example.com/a">alpha again</a></li></ul>`
+
+	md, err := Convert(input, &Options{LinkStyle: LinkStyleReference})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	var out bytes.Buffer
+	if err := roundTripRenderer.Convert([]byte(md), &out); err != nil {
+		t.Fatalf("goldmark Convert() error = %v", err)
+	}
+
+	want := skeleton(t, input)
+	got := skeleton(t, out.String())
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("reference links drifted\nmarkdown:\n%s\n\ngot:\n%s\n\nwant:\n%s",
+			md, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestConvertWrappedOutputRoundTripsToSameStructure(t *testing.T) {
+	t.Parallel()
+
+	input := `<p>Some <strong>bold words</strong> and <a href="https://example.com/docs">a linked phrase</a> with <code>inline code</code> in a long paragraph.</p>` +
+		`<blockquote><p>Quoted text that is long enough to wrap.</p><ul><li>List item text that also wraps.<ul><li>Nested item text that wraps too.</li></ul></li></ul></blockquote>`
+
+	for _, mode := range []Wrap{WrapHard, WrapSoft} {
+		md, err := Convert(input, &Options{Wrap: mode, WrapWidth: 24})
+		if err != nil {
+			t.Fatalf("Convert() error = %v", err)
+		}
+		var out bytes.Buffer
+		if err := roundTripRenderer.Convert([]byte(md), &out); err != nil {
+			t.Fatalf("goldmark Convert() error = %v", err)
+		}
+
+		want := skeleton(t, input)
+		got := skeleton(t, out.String())
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("%s wrap drifted\nmarkdown:\n%s\n\ngot:\n%s\n\nwant:\n%s",
+				mode, md, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
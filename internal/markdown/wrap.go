@@ -0,0 +1,222 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Wrap selects how paragraph lines are broken in Markdown output.
+type Wrap string
+
+const (
+	// WrapNone keeps each paragraph on one line
+	WrapNone Wrap = "none"
+	// WrapSoft puts each sentence on its own line
+	WrapSoft Wrap = "soft"
+	// WrapHard breaks lines at Options.WrapWidth columns
+	WrapHard Wrap = "hard"
+)
+
+// DefaultWrapWidth is the WrapHard line length used when Options.WrapWidth is not positive.
+const DefaultWrapWidth = 80
+
+var (
+	// containerPrefixRe matches one blockquote marker, list marker, or footnote
+	// definition label at the start of a line, after optional indentation.
+	containerPrefixRe = regexp.MustCompile(`^ *(?:> ?|[-*+] +|\d{1,9}[.)] +|\[\^[^\]\s]+\]: )`)
+	// unwrappableLineRe matches line content that must stay on one line:
+	// headings, fences, tables, rules, link definitions, and HTML.
+	unwrappableLineRe = regexp.MustCompile("^(?:#{1,6}(?: |$)|```|~~~|\\||(?:[-*_] *){3,}$|=+ *$|\\[[^\\]]+\\]: |<)")
+	// blockStartRe matches words that would start a block if a wrap put them
+	// at the beginning of a line.
+	blockStartRe = regexp.MustCompile(`^(?:[-*+]|\d{1,9}[.)]|#{1,6}|>.*|=+|-+|\|.*)$`)
+)
+
+// wrapMarkdown rewraps paragraph lines of markdownContent. Headings, tables,
+// fenced code, link definitions, and HTML pass through unchanged, and words
+// are never split inside code spans, links, images, or autolinks.
+func wrapMarkdown(markdownContent string, mode Wrap, width int) string {
+	if mode == "" || mode == WrapNone {
+		return markdownContent
+	}
+	if width <= 0 {
+		width = DefaultWrapWidth
+	}
+
+	lines := strings.Split(markdownContent, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	for _, line := range lines {
+		prefix, content := splitContainerPrefix(line)
+
+		if fence != "" {
+			if strings.HasPrefix(content, fence) {
+				fence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(content, "```") || strings.HasPrefix(content, "~~~") {
+			fence = content[:3]
+			out = append(out, line)
+			continue
+		}
+		if content == "" || unwrappableLineRe.MatchString(content) {
+			out = append(out, line)
+			continue
+		}
+
+		out = append(out, wrapLine(prefix, content, mode, width)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// splitContainerPrefix splits line into its blockquote, list, and footnote
+// markers plus indentation, and the paragraph text after them.
+func splitContainerPrefix(line string) (string, string) {
+	end := 0
+	for {
+		loc := containerPrefixRe.FindStringIndex(line[end:])
+		if loc == nil {
+			end += len(line[end:]) - len(strings.TrimLeft(line[end:], " "))
+			return line[:end], line[end:]
+		}
+		end += loc[1]
+	}
+}
+
+// continuationPrefix turns the prefix of a first line into the prefix of the
+// lines wrapped after it: blockquote markers repeat, while list markers and
+// footnote labels become indentation up to the content column.
+func continuationPrefix(prefix string) string {
+	var b strings.Builder
+	rest := prefix
+	for rest != "" {
+		loc := containerPrefixRe.FindStringIndex(rest)
+		if loc == nil {
+			b.WriteString(rest)
+			break
+		}
+		part := rest[:loc[1]]
+		if marker := strings.TrimLeft(part, " "); strings.HasPrefix(marker, ">") {
+			b.WriteString(part)
+		} else if strings.HasPrefix(marker, "[^") {
+			b.WriteString(strings.Repeat(" ", len(part)-len(marker)) + "    ")
+		} else {
+			b.WriteString(strings.Repeat(" ", utf8.RuneCountInString(part)))
+		}
+		rest = rest[loc[1]:]
+	}
+	return b.String()
+}
+
+// wrapLine breaks one paragraph line into lines of at most width columns for
+// WrapHard, or into one sentence per line for WrapSoft.
+func wrapLine(prefix, content string, mode Wrap, width int) []string {
+	hardBreak := ""
+	if strings.HasSuffix(content, "  ") {
+		hardBreak = "  "
+	} else if strings.HasSuffix(content, `\`) {
+		hardBreak = `\`
+	}
+	words := wrapWords(strings.TrimRight(strings.TrimSuffix(content, hardBreak), " "))
+	if len(words) == 0 {
+		return []string{prefix + content}
+	}
+
+	next := continuationPrefix(prefix)
+	var lines []string
+	current := prefix + words[0]
+	for i, word := range words[1:] {
+		var breakHere bool
+		switch mode {
+		case WrapSoft:
+			breakHere = endsSentence(words[i], word)
+		default:
+			breakHere = utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width
+		}
+		if breakHere && !blockStartRe.MatchString(word) {
+			lines = append(lines, current)
+			current = next + word
+			continue
+		}
+		current += " " + word
+	}
+	return append(lines, current+hardBreak)
+}
+
+// wrapWords splits content on spaces that are outside code spans, link and
+// image brackets and destinations, and autolinks.
+func wrapWords(content string) []string {
+	var words []string
+	var word strings.Builder
+	brackets, parens := 0, 0
+	inAutolink := false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content):
+			word.WriteByte(c)
+			i++
+			word.WriteByte(content[i])
+			continue
+		case c == '`':
+			run := backtickRun(content[i:])
+			if end := strings.Index(content[i+run:], content[i:i+run]); end >= 0 {
+				word.WriteString(content[i : i+run+end+run])
+				i += run + end + run - 1
+				continue
+			}
+			word.WriteString(content[i : i+run])
+			i += run - 1
+			continue
+		case c == '[':
+			brackets++
+		case c == ']' && brackets > 0:
+			brackets--
+		case c == '(' && (parens > 0 || (i > 0 && content[i-1] == ']')):
+			parens++
+		case c == ')' && parens > 0:
+			parens--
+		case c == '<' && !inAutolink && strings.IndexByte(content[i:], '>') > 0:
+			inAutolink = true
+		case c == '>' && inAutolink:
+			inAutolink = false
+		case c == ' ' && brackets == 0 && parens == 0 && !inAutolink:
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+			continue
+		}
+		word.WriteByte(c)
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// backtickRun returns the length of the backtick run at the start of s.
+func backtickRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// endsSentence reports whether word ends a sentence and next starts one.
+func endsSentence(word, next string) bool {
+	if i := strings.LastIndex(word, "]("); i >= 0 && strings.HasSuffix(word, ")") {
+		word = word[:i]
+	}
+	word = strings.TrimRight(word, `"')]*_`)
+	if word == "" || !strings.ContainsAny(word[len(word)-1:], ".!?") {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(strings.TrimLeft(next, `"'([*_`))
+	return unicode.IsUpper(first) || unicode.IsDigit(first)
+}
@@ -0,0 +1,125 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestConvertHardWrapsParagraphsAtWidth(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p>The quick brown fox jumps over the lazy dog and keeps running far away.</p>`, &Options{Wrap: WrapHard, WrapWidth: 20})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := "The quick brown fox\njumps over the lazy\ndog and keeps\nrunning far away."
+	if got != want {
+		t.Fatalf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertHardWrapKeepsLinksCodeAndTablesWhole(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p>See <a href="https://example.com/docs">the full reference guide</a> or run <code>go test ./... -run Wrap</code> now.</p>`+
+		`<table><tr><th>Column with a long header</th></tr><tr><td>cell text that is longer than the width</td></tr></table>`+
+		`<h2>Heading longer than twenty columns</h2>`,
+		&Options{Wrap: WrapHard, WrapWidth: 20})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	for _, whole := range []string{
+		"[the full reference guide](https://example.com/docs)",
+		"`go test ./... -run Wrap`",
+		"| cell text that is longer than the width |",
+		"## Heading longer than twenty columns",
+	} {
+		if !strings.Contains(got, whole) {
+			t.Fatalf("Convert() = %q, want %q kept on one line", got, whole)
+		}
+	}
+}
+
+func TestConvertHardWrapIndentsContinuationLines(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<ol><li>seven eight nine ten eleven</li></ol>`+
+		`<blockquote><p>alpha beta gamma delta epsilon</p><ul><li>one two three four five six</li></ul></blockquote>`,
+		&Options{Wrap: WrapHard, WrapWidth: 16})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := "1. seven eight\n   nine ten\n   eleven\n\n" +
+		"> alpha beta\n> gamma delta\n> epsilon\n> \n> - one two\n>   three four\n>   five six"
+	if got != want {
+		t.Fatalf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertHardWrapNeverStartsLineWithBlockMarker(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p>aaaa bbbb - cccc 12. dddd # eeee</p>`, &Options{Wrap: WrapHard, WrapWidth: 9})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	for line := range strings.SplitSeq(got, "\n") {
+		if blockStartRe.MatchString(strings.Fields(line)[0]) {
+			t.Fatalf("Convert() = %q, line %q starts with a block marker", got, line)
+		}
+	}
+}
+
+func TestConvertHardWrapLeavesFencedCodeAlone(t *testing.T) {
+	t.Parallel()
+
+	code := strings.Repeat("word ", 30)
+	got, err := Convert(`<pre><code>`+code+`</code></pre>`, &Options{Wrap: WrapHard, WrapWidth: 20})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if !strings.Contains(got, strings.TrimSpace(code)) {
+		t.Fatalf("Convert() = %q, want code block unwrapped", got)
+	}
+}
+
+func TestConvertSoftWrapPutsSentencesOnLines(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p>First sentence here. Second one, e.g. with an abbreviation! Third? <a href="/x">Fourth link.</a> Done.</p>`, &Options{Wrap: WrapSoft})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := "First sentence here.\nSecond one, e.g. with an abbreviation!\nThird?\n[Fourth link.](/x)\nDone."
+	if got != want {
+		t.Fatalf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertWrapDefaultsToEightyColumns(t *testing.T) {
+	t.Parallel()
+
+	got, err := Convert(`<p>`+strings.Repeat("wrap ", 60)+`</p>`, &Options{Wrap: WrapHard})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	for line := range strings.SplitSeq(got, "\n") {
+		if n := utf8.RuneCountInString(line); n > DefaultWrapWidth {
+			t.Fatalf("Convert() line %q has %d columns, want at most %d", line, n, DefaultWrapWidth)
+		}
+	}
+}
+
+func TestConvertRejectsUnknownWrapMode(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Convert(`<p>x</p>`, &Options{Wrap: "column"}); err == nil {
+		t.Fatal("Convert() error = nil, want unsupported wrap mode error")
+	}
+}
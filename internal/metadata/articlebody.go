@@ -0,0 +1,59 @@
+package metadata
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SchemaArticleBody returns the paragraphs of the longest schema.org
+// articleBody of the page's items. Paragraphs are the lines of a plain text
+// body, or the p elements of one written as HTML, with their whitespace
+// collapsed.
+func SchemaArticleBody(schemaOrgData any) []string {
+	var items []any
+	switch data := schemaOrgData.(type) {
+	case []any:
+		items = data
+	case map[string]any:
+		items = []any{data}
+	}
+
+	var longest string
+	for _, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		body := schemaString(object["articleBody"])
+		if parts, ok := object["articleBody"].([]any); ok {
+			var texts []string
+			for _, part := range parts {
+				texts = append(texts, schemaString(part))
+			}
+			body = strings.Join(texts, "\n")
+		}
+		if len(body) > len(longest) {
+			longest = body
+		}
+	}
+	return bodyParagraphs(longest)
+}
+
+// bodyParagraphs splits an articleBody into paragraphs.
+func bodyParagraphs(body string) []string {
+	lines := strings.Split(body, "\n")
+	if strings.Contains(body, "</p>") {
+		if doc, err := goquery.NewDocumentFromReader(strings.NewReader(body)); err == nil {
+			lines = doc.Find("p").Map(func(_ int, p *goquery.Selection) string { return p.Text() })
+		}
+	}
+
+	var paragraphs []string
+	for _, line := range lines {
+		if text := strings.Join(strings.Fields(line), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return paragraphs
+}
@@ -0,0 +1,48 @@
+package metadata
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSchemaArticleBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data any
+		want []string
+	}{
+		{
+			name: "lines of the longest body",
+			data: []any{
+				map[string]any{"@type": "WebPage", "articleBody": "Short."},
+				map[string]any{"@type": "NewsArticle", "articleBody": "First  paragraph.\n\n  Second paragraph.\r\n"},
+			},
+			want: []string{"First paragraph.", "Second paragraph."},
+		},
+		{
+			name: "HTML body",
+			data: map[string]any{"articleBody": "<p>One <b>bold</b> line.</p><div>skip</div><p>Two.</p>"},
+			want: []string{"One bold line.", "Two."},
+		},
+		{
+			name: "list of strings",
+			data: map[string]any{"articleBody": []any{"Part one.", "Part two."}},
+			want: []string{"Part one.", "Part two."},
+		},
+		{
+			name: "none",
+			data: map[string]any{"@type": "NewsArticle", "headline": "Headline"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := SchemaArticleBody(tt.data); !slices.Equal(got, tt.want) {
+				t.Fatalf("SchemaArticleBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// AudioVersion is a recording of the article, such as a narrated version or
+// the podcast episode it accompanies
+type AudioVersion struct {
+	// URL is the absolute URL of the audio file or stream.
+	URL string
+	// Duration is the length in seconds, or 0 when unknown.
+	Duration int
+}
+
+// audioPlayerSelectors find "Listen to this article" players by their class,
+// id, or data attributes.
+const audioPlayerSelectors = `[class*="listen"], [id*="listen"], [class*="audio-player"], [id*="audio-player"], ` +
+	`[class*="article-audio"], [class*="audio-article"], [class*="text-to-speech"], [class*="tts-player"], ` +
+	`[class*="narration"], [data-audio-url], [data-audio-src]`
+
+// audioURLAttributes hold the audio URL inside a player.
+var audioURLAttributes = []string{"src", "href", "data-src", "data-url", "data-audio", "data-audio-url", "data-audio-src", "data-mp3", "data-file", "content"}
+
+// maxAudioPlayerText is the longest text read from a player for its
+// duration, so a wrapper whose class happens to match is not searched.
+const maxAudioPlayerText = 200
+
+var (
+	// audioFilePattern matches URLs of common audio files.
+	audioFilePattern = regexp.MustCompile(`(?i)\.(?:mp3|m4a|aac|oga|ogg|opus|wav|flac)(?:[?#]|$)`)
+	// audioLabelPattern matches the label of an article's audio player, as
+	// in "Listen to this article" or "Audio version".
+	audioLabelPattern = regexp.MustCompile(`(?i)^\s*(?:listen\s+to\s+(?:this\s+|the\s+)?(?:article|story|post)|hear\s+this\s+(?:article|story)|audio\s+version|listen\s+now)\b`)
+	// isoDurationPattern matches ISO 8601 durations such as "PT12M34S".
+	isoDurationPattern = regexp.MustCompile(`(?i)^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+	// clockDurationPattern matches player times such as "12:34" and
+	// "1:02:03", also when run into a button label as in "Play12:34".
+	clockDurationPattern = regexp.MustCompile(`(?:^|[^\d:])(?:(\d{1,2}):)?(\d{1,2}):(\d{2})\b`)
+	// minutesDurationPattern matches "5 min listen" and "12 minutes".
+	minutesDurationPattern = regexp.MustCompile(`(?i)\b(\d{1,3})\s*(?:min|mins|minutes?)\b`)
+)
+
+// ExtractAudioVersion finds an audio version of the article: the schema.org
+// audio or associated AudioObject, og:audio, an enclosure link to an audio
+// file, a "Listen to this article" player, or the page's only <audio>
+// element. The duration comes from the schema.org item or from the player's
+// data-duration attribute or time text. It returns nil when the page has no
+// audio version.
+func ExtractAudioVersion(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag, baseURL string) *AudioVersion {
+	if audio := schemaAudio(schemaOrgData, baseURL); audio != nil {
+		return audio
+	}
+	for _, property := range []string{"og:audio:secure_url", "og:audio:url", "og:audio"} {
+		if href := resolveURL(baseURL, getMetaContent(metaTags, "property", property)); href != "" {
+			return &AudioVersion{URL: href}
+		}
+	}
+
+	var audio *AudioVersion
+	doc.Find("link[href], a[href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		href := s.AttrOr("href", "")
+		if !hasRel(s.AttrOr("rel", ""), "enclosure") ||
+			(!strings.HasPrefix(strings.ToLower(s.AttrOr("type", "")), "audio/") && !audioFilePattern.MatchString(href)) {
+			return true
+		}
+		if href = resolveURL(baseURL, href); href != "" {
+			audio = &AudioVersion{URL: href}
+		}
+		return audio == nil
+	})
+	if audio != nil {
+		return audio
+	}
+
+	body := doc.Find("body")
+	body.Find(audioPlayerSelectors).EachWithBreak(func(_ int, player *goquery.Selection) bool {
+		audio = playerAudio(player, baseURL)
+		return audio == nil
+	})
+	if audio != nil {
+		return audio
+	}
+
+	// A labelled player sits beside its label, so the nearest of a few
+	// wrappers around the label holds it
+	body.Find("h2, h3, h4, h5, h6, p, span, div, button, strong").EachWithBreak(func(_ int, label *goquery.Selection) bool {
+		if text := label.Text(); utf8.RuneCountInString(text) > maxAudioPlayerText || !audioLabelPattern.MatchString(text) {
+			return true
+		}
+		for player, i := label, 0; i < 4 && player.Length() > 0 && !player.Is("body"); player, i = player.Parent(), i+1 {
+			if audio = playerAudio(player, baseURL); audio != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if audio != nil {
+		return audio
+	}
+
+	if elements := body.Find("audio"); elements.Length() == 1 {
+		return playerAudio(elements, baseURL)
+	}
+	return nil
+}
+
+// playerAudio returns the audio of a player element: the source of an
+// <audio> inside it, or the first audio file one of its elements names.
+func playerAudio(player *goquery.Selection, baseURL string) *AudioVersion {
+	var href string
+	player.Find("audio[src], audio source[src]").AddSelection(player.Filter("audio[src]")).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		href = resolveURL(baseURL, s.AttrOr("src", ""))
+		return href == ""
+	})
+	if href == "" {
+		player.Find("*").AddBack().EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			for _, attr := range audioURLAttributes {
+				if value := s.AttrOr(attr, ""); audioFilePattern.MatchString(value) {
+					href = resolveURL(baseURL, value)
+					break
+				}
+			}
+			return href == ""
+		})
+	}
+	if href == "" {
+		return nil
+	}
+	return &AudioVersion{URL: href, Duration: playerDuration(player)}
+}
+
+// playerDuration reads a player's length from a data-duration attribute on
+// it or inside it, or from a time such as "12:34" or "5 min listen" in its
+// text.
+func playerDuration(player *goquery.Selection) int {
+	if value, ok := player.Find("[data-duration]").AddBack().Filter("[data-duration]").Attr("data-duration"); ok {
+		if seconds := parseAudioDuration(value); seconds > 0 {
+			return seconds
+		}
+	}
+	text := strings.Join(strings.Fields(player.Text()), " ")
+	if utf8.RuneCountInString(text) > maxAudioPlayerText {
+		return 0
+	}
+	if m := clockDurationPattern.FindStringSubmatch(text); m != nil {
+		return clockSeconds(m)
+	}
+	if m := minutesDurationPattern.FindStringSubmatch(text); m != nil {
+		minutes, _ := strconv.Atoi(m[1])
+		return minutes * 60
+	}
+	return 0
+}
+
+// parseAudioDuration parses a duration given as seconds, an ISO 8601
+// duration, or a clock time, returning 0 for anything else.
+func parseAudioDuration(value string) int {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return max(0, int(seconds))
+	}
+	if m := isoDurationPattern.FindStringSubmatch(value); m != nil && value != "P" && !strings.HasSuffix(strings.ToUpper(value), "T") {
+		days, _ := strconv.Atoi(m[1])
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		seconds, _ := strconv.ParseFloat(m[4], 64)
+		return days*86400 + hours*3600 + minutes*60 + int(seconds)
+	}
+	if m := clockDurationPattern.FindStringSubmatch(value); m != nil && m[0] == value {
+		return clockSeconds(m)
+	}
+	return 0
+}
+
+// clockSeconds returns the seconds of a clockDurationPattern match.
+func clockSeconds(m []string) int {
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, _ := strconv.Atoi(m[3])
+	return hours*3600 + minutes*60 + seconds
+}
+
+// schemaAudio reads the audio version from schema.org items: an audio
+// property, or an associatedMedia that is an AudioObject or an audio file.
+func schemaAudio(schemaOrgData any, baseURL string) *AudioVersion {
+	var items []any
+	switch data := schemaOrgData.(type) {
+	case []any:
+		items = data
+	case map[string]any:
+		items = []any{data}
+	}
+
+	for _, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if audio := schemaAudioObject(object["audio"], true, baseURL); audio != nil {
+			return audio
+		}
+		if audio := schemaAudioObject(object["associatedMedia"], false, baseURL); audio != nil {
+			return audio
+		}
+	}
+	return nil
+}
+
+// schemaAudioObject returns value as an audio version: any URL or media
+// object when explicit, otherwise only an AudioObject, a media object with
+// an audio encodingFormat, or an audio file URL.
+func schemaAudioObject(value any, explicit bool, baseURL string) *AudioVersion {
+	if list, ok := value.([]any); ok && len(list) > 0 {
+		value = list[0]
+	}
+	if href := schemaString(value); href != "" {
+		if explicit || audioFilePattern.MatchString(href) {
+			return &AudioVersion{URL: resolveURL(baseURL, href)}
+		}
+		return nil
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	href := schemaString(object["contentUrl"])
+	if href == "" {
+		href = schemaString(object["url"])
+	}
+	if href == "" {
+		return nil
+	}
+	if !explicit && !strings.Contains(fmt.Sprint(object["@type"]), "AudioObject") &&
+		!strings.HasPrefix(strings.ToLower(schemaString(object["encodingFormat"])), "audio/") &&
+		!audioFilePattern.MatchString(href) {
+		return nil
+	}
+	return &AudioVersion{URL: resolveURL(baseURL, href), Duration: parseAudioDuration(schemaString(object["duration"]))}
+}
@@ -0,0 +1,140 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractAudioVersionFromPage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want *AudioVersion
+	}{
+		{
+			name: "enclosure link",
+			html: `<html><head><link rel="enclosure" type="audio/mpeg" href="/audio/episode-3"></head><body></body></html>`,
+			want: &AudioVersion{URL: "https://example.com/audio/episode-3"},
+		},
+		{
+			name: "listen player with clock time",
+			html: `<html><body><div class="article-listen-player"><button>Play</button><span>12:34</span>
+				<audio preload="none"><source src="narration.mp3" type="audio/mpeg"></audio></div>
+				<audio src="/clips/other.mp3"></audio></body></html>`,
+			want: &AudioVersion{URL: "https://example.com/news/narration.mp3", Duration: 754},
+		},
+		{
+			name: "labelled player with data attributes",
+			html: `<html><body><article><div><p>Listen to this article</p>
+				<div data-player="tts" data-src="https://cdn.example.com/a.m4a?v=2" data-duration="PT5M"></div></div>
+				<p>Body text.</p></article></body></html>`,
+			want: &AudioVersion{URL: "https://cdn.example.com/a.m4a?v=2", Duration: 300},
+		},
+		{
+			name: "labelled player with minutes",
+			html: `<html><body><div><span>Listen to this story</span> <span>· 6 min listen</span>
+				<a href="/story.mp3">Download</a></div></body></html>`,
+			want: &AudioVersion{URL: "https://example.com/story.mp3", Duration: 360},
+		},
+		{
+			name: "only audio element",
+			html: `<html><body><p>Text.</p><audio src="/podcast/ep.ogg"></audio></body></html>`,
+			want: &AudioVersion{URL: "https://example.com/podcast/ep.ogg"},
+		},
+		{
+			name: "several unlabelled audio clips",
+			html: `<html><body><audio src="/a.mp3"></audio><audio src="/b.mp3"></audio></body></html>`,
+			want: nil,
+		},
+		{
+			name: "download links alone",
+			html: `<html><body><a href="/song.mp3">Song</a></body></html>`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := mustMetadataDocument(t, tt.html)
+			got := ExtractAudioVersion(doc, nil, nil, "https://example.com/news/story")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ExtractAudioVersion() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractAudioVersionFromMetadata(t *testing.T) {
+	t.Parallel()
+
+	doc := mustMetadataDocument(t, `<html><body><audio src="/page.mp3"></audio></body></html>`)
+	ogAudio := "og:audio"
+	ogAudioURL := "https://example.com/og.mp3"
+
+	tests := []struct {
+		name     string
+		schema   any
+		metaTags []MetaTag
+		want     *AudioVersion
+	}{
+		{
+			name: "audio object",
+			schema: map[string]any{
+				"@type": "NewsArticle",
+				"audio": map[string]any{"@type": "AudioObject", "contentUrl": "/audio/story.mp3", "duration": "PT1H2M3S"},
+			},
+			want: &AudioVersion{URL: "https://example.com/audio/story.mp3", Duration: 3723},
+		},
+		{
+			name: "podcast episode media",
+			schema: []any{map[string]any{
+				"@type":           "PodcastEpisode",
+				"associatedMedia": map[string]any{"@type": "MediaObject", "contentUrl": "https://cdn.example.com/ep3", "encodingFormat": "audio/mpeg"},
+			}},
+			want: &AudioVersion{URL: "https://cdn.example.com/ep3"},
+		},
+		{
+			name: "associated image",
+			schema: map[string]any{
+				"@type":           "Article",
+				"associatedMedia": map[string]any{"@type": "ImageObject", "contentUrl": "/photo.jpg"},
+			},
+			metaTags: []MetaTag{{Property: &ogAudio, Content: &ogAudioURL}},
+			want:     &AudioVersion{URL: "https://example.com/og.mp3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ExtractAudioVersion(doc, tt.schema, tt.metaTags, "https://example.com/news/story")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ExtractAudioVersion() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAudioDuration(t *testing.T) {
+	t.Parallel()
+
+	for value, want := range map[string]int{
+		"754":     754,
+		"93.6":    93,
+		"PT12M5S": 725,
+		"P1DT1H":  90000,
+		"1:02:03": 3723,
+		"4:05":    245,
+		"PT":      0,
+		"soon":    0,
+	} {
+		if got := parseAudioDuration(value); got != want {
+			t.Errorf("parseAudioDuration(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// authorBioSelectors find author bio boxes by their class, id, or microdata.
+const authorBioSelectors = `.author-bio, .author-box, .author-info, .about-author, .about-the-author, .author-description, .author-mini-bio, #author-bio, [itemprop="author"] [itemprop="description"]`
+
+// maxAuthorBioText is the longest text accepted as a bio, so a
+// misclassified wrapper never takes the article with it.
+const maxAuthorBioText = 1500
+
+// aboutAuthorPattern matches headings that introduce an author bio.
+var aboutAuthorPattern = regexp.MustCompile(`(?i)^\s*about\s+(?:the\s+)?authors?\s*:?\s*$`)
+
+// FindAuthorBio returns the author bio box inside content: an element
+// matched by class, id, or microdata, or an "About the author" heading with
+// its section. It returns an empty selection when there is none. The
+// content root itself is never returned.
+func FindAuthorBio(content *goquery.Selection) *goquery.Selection {
+	root := content.Get(0)
+	bio := content.Find(authorBioSelectors).FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.Get(0) != root && isAuthorBioLength(s)
+	}).First()
+	if bio.Length() > 0 {
+		return bio
+	}
+
+	content.Find("h2, h3, h4, h5, h6, strong").EachWithBreak(func(_ int, heading *goquery.Selection) bool {
+		if !aboutAuthorPattern.MatchString(heading.Text()) {
+			return true
+		}
+		// A wrapper that opens with the heading, holds no other heading, and
+		// has a bio's worth of text besides it is the bio box
+		parent := heading.Parent()
+		opens := heading.PrevAllFiltered("p, ul, ol, blockquote, pre, table").Length() == 0
+		if parent.Get(0) != root && opens && parent.Find("h1, h2, h3, h4, h5, h6").NotSelection(heading).Length() == 0 &&
+			len(strings.TrimSpace(parent.Text())) > len(strings.TrimSpace(heading.Text())) && isAuthorBioLength(parent) {
+			bio = parent
+			return false
+		}
+		// Otherwise a heading's section runs to the next heading
+		if goquery.NodeName(heading) != "strong" {
+			section := heading.AddSelection(heading.NextUntil("h1, h2, h3, h4, h5, h6"))
+			if isAuthorBioLength(section) {
+				bio = section
+				return false
+			}
+		}
+		return true
+	})
+	return bio
+}
+
+// isAuthorBioLength reports whether sel has text, but no more than a bio.
+func isAuthorBioLength(sel *goquery.Selection) bool {
+	n := utf8.RuneCountInString(strings.TrimSpace(sel.Text()))
+	return n > 0 && n <= maxAuthorBioText
+}
+
+// AuthorBioText returns the whitespace-collapsed text of bio without a
+// leading "About the author" label.
+func AuthorBioText(bio *goquery.Selection) string {
+	var parts []string
+	bio.Each(func(_ int, s *goquery.Selection) {
+		clone := s.Clone()
+		clone.Find("h2, h3, h4, h5, h6, strong").Each(func(_ int, heading *goquery.Selection) {
+			if aboutAuthorPattern.MatchString(heading.Text()) {
+				heading.Remove()
+			}
+		})
+		if text := strings.Join(strings.Fields(clone.Text()), " "); text != "" && !aboutAuthorPattern.MatchString(text) {
+			parts = append(parts, text)
+		}
+	})
+	return strings.Join(parts, " ")
+}
+
+// SchemaAuthorBio returns the description of the first schema.org author
+// that has one.
+func SchemaAuthorBio(schemaOrgData any) string {
+	var items []any
+	switch data := schemaOrgData.(type) {
+	case []any:
+		items = data
+	case map[string]any:
+		items = []any{data}
+	}
+
+	for _, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		authors, ok := object["author"].([]any)
+		if !ok {
+			authors = []any{object["author"]}
+		}
+		for _, author := range authors {
+			if person, ok := author.(map[string]any); ok {
+				if bio := schemaString(person["description"]); bio != "" {
+					return strings.Join(strings.Fields(bio), " ")
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestFindAuthorBio(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "class",
+			html: `<article><p>Body text.</p><div class="author-bio"><img src="a.jpg"><p>Jane Doe writes about compilers.</p></div></article>`,
+			want: "Jane Doe writes about compilers.",
+		},
+		{
+			name: "heading wrapper",
+			html: `<article><p>Body text.</p><div class="box"><h3>About the Author</h3><p>Jane Doe writes about compilers.</p></div></article>`,
+			want: "Jane Doe writes about compilers.",
+		},
+		{
+			name: "heading section",
+			html: `<article><p>Body text.</p><h2>About the author:</h2><p>Jane Doe writes about compilers.</p><p>She lives in Oslo.</p><h2>Comments</h2><p>Kept.</p></article>`,
+			want: "Jane Doe writes about compilers. She lives in Oslo.",
+		},
+		{
+			name: "strong label",
+			html: `<article><p>Body text.</p><aside><strong>About the author</strong> Jane Doe writes about compilers.</aside></article>`,
+			want: "Jane Doe writes about compilers.",
+		},
+		{
+			name: "none",
+			html: `<article><p>Body text about the author of a famous novel.</p></article>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("NewDocumentFromReader() error = %v", err)
+			}
+			content := doc.Find("article")
+			bio := FindAuthorBio(content)
+			if got := AuthorBioText(bio); got != tt.want {
+				t.Fatalf("AuthorBioText(FindAuthorBio()) = %q, want %q", got, tt.want)
+			}
+			bio.Remove()
+			if !strings.Contains(content.Text(), "Body text") {
+				t.Fatal("removing the bio removed the article body")
+			}
+		})
+	}
+}
+
+func TestFindAuthorBioKeepsArticleWrappers(t *testing.T) {
+	t.Parallel()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<article><section><p>The article starts here.</p><h2>About the author</h2><p>Jane Doe.</p></section></article>`))
+	if err != nil {
+		t.Fatalf("NewDocumentFromReader() error = %v", err)
+	}
+	bio := FindAuthorBio(doc.Find("article"))
+	if got := AuthorBioText(bio); got != "Jane Doe." {
+		t.Fatalf("AuthorBioText() = %q, want the section after the heading only", got)
+	}
+}
+
+func TestSchemaAuthorBio(t *testing.T) {
+	t.Parallel()
+
+	data := []any{
+		map[string]any{"@type": "WebSite"},
+		map[string]any{"@type": "Article", "author": []any{
+			map[string]any{"name": "No Bio"},
+			map[string]any{"name": "Jane", "description": "  Jane writes\n about compilers. "},
+		}},
+	}
+	if got := SchemaAuthorBio(data); got != "Jane writes about compilers." {
+		t.Fatalf("SchemaAuthorBio() = %q", got)
+	}
+	if got := SchemaAuthorBio(map[string]any{"author": "Jane"}); got != "" {
+		t.Fatalf("SchemaAuthorBio(string author) = %q, want empty", got)
+	}
+}
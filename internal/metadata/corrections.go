@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// correctionSelectors find correction and editor's note boxes by their
+// class or microdata.
+const correctionSelectors = `.correction, .corrections, .editors-note, .editor-note, .editorsnote, [itemprop="correction"]`
+
+// correctionBlocks are the elements whose text may open with a correction
+// label.
+const correctionBlocks = "p, div, aside, section, blockquote, li"
+
+// maxCorrectionText is the longest text accepted as one correction, so a
+// wrapper that happens to open with a label never takes the article.
+const maxCorrectionText = 1000
+
+// correctionPattern matches the label that opens a correction or editor's
+// note, as in "Correction: An earlier version..." or "Editor's Note —".
+var correctionPattern = regexp.MustCompile(`(?i)^(?:corrections?|clarifications?|editor(?:['’]s|s)?\s+note)\s*[:.—–-]`)
+
+// FindCorrections returns the correction and editor's note blocks inside
+// content in document order: elements matched by class or microdata, and
+// blocks whose text opens with a correction label. Of nested matches only
+// the outermost is returned, and never the content root itself.
+func FindCorrections(content *goquery.Selection) *goquery.Selection {
+	root := content.Get(0)
+	var kept []*goquery.Selection
+	content.Find(correctionSelectors + ", " + correctionBlocks).Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		n := utf8.RuneCountInString(text)
+		if s.Get(0) == root || n == 0 || n > maxCorrectionText {
+			return
+		}
+		if !s.Is(correctionSelectors) && !correctionPattern.MatchString(text) {
+			return
+		}
+		// Ancestors come first, so a block inside a kept box is skipped
+		if slices.ContainsFunc(kept, func(k *goquery.Selection) bool { return k.Contains(s.Get(0)) }) {
+			return
+		}
+		kept = append(kept, s)
+	})
+
+	nodes := make([]*html.Node, 0, len(kept))
+	for _, s := range kept {
+		nodes = append(nodes, s.Get(0))
+	}
+	return content.FindNodes(nodes...)
+}
+
+// CorrectionTexts returns the whitespace-collapsed text of each correction.
+func CorrectionTexts(corrections *goquery.Selection) []string {
+	var texts []string
+	corrections.Each(func(_ int, s *goquery.Selection) {
+		if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+			texts = append(texts, text)
+		}
+	})
+	return texts
+}
+
+// SchemaCorrections returns the schema.org correction values of the page's
+// items: plain text, or the text or description of a CorrectionComment.
+func SchemaCorrections(schemaOrgData any) []string {
+	var items []any
+	switch data := schemaOrgData.(type) {
+	case []any:
+		items = data
+	case map[string]any:
+		items = []any{data}
+	}
+
+	var corrections []string
+	for _, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		values, ok := object["correction"].([]any)
+		if !ok {
+			values = []any{object["correction"]}
+		}
+		for _, value := range values {
+			text := schemaString(value)
+			if comment, ok := value.(map[string]any); ok {
+				text = schemaString(comment["text"])
+				if text == "" {
+					text = schemaString(comment["description"])
+				}
+			}
+			if text = strings.Join(strings.Fields(text), " "); text != "" {
+				corrections = append(corrections, text)
+			}
+		}
+	}
+	return corrections
+}
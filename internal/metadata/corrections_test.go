@@ -0,0 +1,55 @@
+package metadata
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestFindCorrections(t *testing.T) {
+	t.Parallel()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<article>
+		<p><em>Editor’s note — This story was updated with the mayor's response.</em></p>
+		<p>The council met on Tuesday. A correction: the vote was close.</p>
+		<div class="correction"><p>Correction: An earlier version misstated the vote count.</p></div>
+		<p>Clarification. The budget figure excludes grants.</p>
+		<p>Corrections are published weekly in print.</p>
+	</article>`))
+	if err != nil {
+		t.Fatalf("NewDocumentFromReader() error = %v", err)
+	}
+
+	got := CorrectionTexts(FindCorrections(doc.Find("article")))
+	want := []string{
+		"Editor’s note — This story was updated with the mayor's response.",
+		"Correction: An earlier version misstated the vote count.",
+		"Clarification. The budget figure excludes grants.",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("CorrectionTexts(FindCorrections()) = %q, want %q", got, want)
+	}
+}
+
+func TestSchemaCorrections(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"@type": "NewsArticle",
+		"correction": []any{
+			"Corrected the date.",
+			map[string]any{"@type": "CorrectionComment", "text": "Fixed  a\nname."},
+			map[string]any{"@type": "CorrectionComment", "description": "Updated a quote."},
+		},
+	}
+	got := SchemaCorrections(data)
+	want := []string{"Corrected the date.", "Fixed a name.", "Updated a quote."}
+	if !slices.Equal(got, want) {
+		t.Fatalf("SchemaCorrections() = %q, want %q", got, want)
+	}
+	if got := SchemaCorrections([]any{map[string]any{"@type": "Article"}}); got != nil {
+		t.Fatalf("SchemaCorrections(no correction) = %q, want nil", got)
+	}
+}
@@ -0,0 +1,357 @@
+package metadata
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// visibleDateSelectors find elements likely to hold a byline or dateline.
+// Parents of author elements are tried as well, since the date often sits
+// next to the name.
+const visibleDateSelectors = `[itemprop="datePublished"], [itemprop="dateModified"], [class*="byline"], ` +
+	`[class*="dateline"], [class*="date"], [class*="posted"], [class*="published"], [class*="modified"], ` +
+	`[class*="post-meta"], [class*="entry-meta"], [class*="article-meta"]`
+
+// visibleDateAuthorSelectors find author elements whose parent may hold the date.
+const visibleDateAuthorSelectors = `[itemprop="author"], [rel="author"], .author`
+
+// maxVisibleDateText is the longest element text searched for a date, so
+// whole articles are never scanned.
+const maxVisibleDateText = 200
+
+// maxVisibleDateCandidates bounds the elements searched per document.
+const maxVisibleDateCandidates = 50
+
+// dateLocale holds the month names of a language.
+type dateLocale struct {
+	months map[string]time.Month
+	// dayFirst matches day-month-year dates such as "5 January 2024".
+	dayFirst *regexp.Regexp
+	// monthFirst matches month-day-year dates such as "January 5, 2024".
+	monthFirst *regexp.Regexp
+	// dotted matches numeric day-first dates such as "05.01.2024".
+	dotted bool
+}
+
+// dateLocales maps primary language subtags to their date patterns.
+var dateLocales = map[string]*dateLocale{
+	"en": newDateLocale(false,
+		"january", "february", "march", "april", "may", "june",
+		"july", "august", "september", "october", "november", "december",
+		"jan", "feb", "mar", "apr", "", "jun", "jul", "aug", "sep", "oct", "nov", "dec",
+		"", "", "", "", "", "", "", "", "sept", "", "", ""),
+	"de": newDateLocale(true,
+		"januar", "februar", "märz", "april", "mai", "juni",
+		"juli", "august", "september", "oktober", "november", "dezember",
+		"jan", "feb", "mär", "apr", "", "jun", "jul", "aug", "sep", "okt", "nov", "dez",
+		"jänner", "", "maerz", "", "", "", "", "", "sept", "", "", ""),
+	"fr": newDateLocale(false,
+		"janvier", "février", "mars", "avril", "mai", "juin",
+		"juillet", "août", "septembre", "octobre", "novembre", "décembre",
+		"janv", "févr", "", "avr", "", "", "juil", "", "sept", "oct", "nov", "déc"),
+	"es": newDateLocale(false,
+		"enero", "febrero", "marzo", "abril", "mayo", "junio",
+		"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+		"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic",
+		"", "", "", "", "", "", "", "", "setiembre", "", "", ""),
+	"it": newDateLocale(false,
+		"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno",
+		"luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre",
+		"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"),
+	"pt": newDateLocale(false,
+		"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+		"julho", "agosto", "setembro", "outubro", "novembro", "dezembro",
+		"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"),
+	"nl": newDateLocale(true,
+		"januari", "februari", "maart", "april", "mei", "juni",
+		"juli", "augustus", "september", "oktober", "november", "december",
+		"jan", "feb", "mrt", "apr", "", "jun", "jul", "aug", "sep", "okt", "nov", "dec"),
+}
+
+// newDateLocale builds a locale from month names given in groups of twelve,
+// January first. Empty names are skipped.
+func newDateLocale(dotted bool, names ...string) *dateLocale {
+	locale := &dateLocale{months: make(map[string]time.Month), dotted: dotted}
+	var alternatives []string
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		locale.months[name] = time.Month(i%12 + 1)
+		alternatives = append(alternatives, regexp.QuoteMeta(name))
+	}
+	// Longest first, so "juni" is not matched as "jun"
+	slices.SortFunc(alternatives, func(a, b string) int {
+		return cmp.Compare(utf8.RuneCountInString(b), utf8.RuneCountInString(a))
+	})
+	month := `(` + strings.Join(alternatives, "|") + `)\.?`
+	locale.dayFirst = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th|er|º|\.)?\s+(?:de\s+)?` + month + `,?\s+(?:de\s+)?(\d{4})\b`)
+	locale.monthFirst = regexp.MustCompile(`(?i)\b` + month + `\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})\b`)
+	return locale
+}
+
+var (
+	isoDatePattern    = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	dottedDatePattern = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.(\d{4})\b`)
+	cjkDatePattern    = regexp.MustCompile(`(\d{4})\s*[年년]\s*(\d{1,2})\s*[月월]\s*(\d{1,2})\s*[日일]`)
+	// timePattern matches a time of day right after a date, such as
+	// "at 10:00 GMT", ", 3:45 p.m.", or "um 10:00 Uhr".
+	timePattern = regexp.MustCompile(`(?i)^[\s,]*(?:at|um|à|a las|alle|às|om|-|–|\|)?\s*(\d{1,2})[:h](\d{2})(?::(\d{2}))?\s*([ap]\.?m\.?)?(?:\s*uhr)?(?:\s*(gmt|utc|z|[+-]\d{2}:?\d{2})\b)?`)
+	// updatePattern marks a date as the last update rather than the
+	// publication.
+	updatePattern = regexp.MustCompile(`(?i)(updated|modified|last edited|aktualisiert|geändert|mis à jour|modifié|actualizado|modificado|aggiornato|atualizado|bijgewerkt|更新|修改|업데이트)\W*(on|am|le|el|il|em|op)?\W*$`)
+	// updateNotePattern matches text that starts with an update marker.
+	updateNotePattern = regexp.MustCompile(`(?i)^\W*(?:last\s+|zuletzt\s+|dernière\s+|última\s+|ultimo\s+)?(updated|modified|last edited|aktualisiert|geändert|mise? à jour|modifié|actualizado|actualización|modificado|aggiornato|aggiornamento|atualizado|atualização|bijgewerkt|更新|修改|업데이트)`)
+)
+
+// maxUpdateNoteGap and maxUpdateNoteTail bound the text around the date of
+// an update note, so an editor's note that merely starts with "Update" and
+// goes on to say more is kept.
+const (
+	maxUpdateNoteGap  = 16
+	maxUpdateNoteTail = 30
+)
+
+// visibleDate is a date found in page text.
+type visibleDate struct {
+	start, end int
+	value      string
+}
+
+// getVisibleDate finds a date written in the text of bylines and
+// datelines, using the month names of lang and of English. With updated, it
+// returns the first date marked as an update, such as "Updated on 5 March
+// 2024"; otherwise it returns the first date not so marked. The result is
+// "YYYY-MM-DD", or RFC 3339 when a time of day follows the date.
+func getVisibleDate(doc *goquery.Document, lang string, updated bool) string {
+	locales := localesFor(lang)
+	candidates := doc.Find("body").Find(visibleDateSelectors)
+	candidates = candidates.AddSelection(doc.Find("body").Find(visibleDateAuthorSelectors).Parent())
+
+	var found string
+	searched := 0
+	candidates.EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if text == "" || utf8.RuneCountInString(text) > maxVisibleDateText {
+			return true
+		}
+		searched++
+		for _, date := range findVisibleDates(text, locales) {
+			if updatePattern.MatchString(text[:date.start]) == updated {
+				found = date.value
+				return false
+			}
+		}
+		return searched < maxVisibleDateCandidates
+	})
+	return found
+}
+
+// IsUpdateNote reports whether text is only an update notice, such as
+// "Updated on 5 March 2024 at 10:00 GMT": an update marker, a date in the
+// language lang or English shortly after it, and little else.
+func IsUpdateNote(text, lang string) bool {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" || utf8.RuneCountInString(text) > maxVisibleDateText {
+		return false
+	}
+	marker := updateNotePattern.FindStringIndex(text)
+	if marker == nil {
+		return false
+	}
+	dates := findVisibleDates(text, localesFor(lang))
+	if len(dates) == 0 {
+		return false
+	}
+	date := dates[0]
+	return date.start >= marker[1] &&
+		utf8.RuneCountInString(text[marker[1]:date.start]) <= maxUpdateNoteGap &&
+		utf8.RuneCountInString(text[date.end:]) <= maxUpdateNoteTail
+}
+
+// localesFor returns the locales to try for lang: its own, then English.
+func localesFor(lang string) []*dateLocale {
+	if locale, ok := dateLocales[lang]; ok && lang != "en" {
+		return []*dateLocale{locale, dateLocales["en"]}
+	}
+	return []*dateLocale{dateLocales["en"]}
+}
+
+// findVisibleDates returns the dates in text in order of appearance.
+func findVisibleDates(text string, locales []*dateLocale) []visibleDate {
+	var dates []visibleDate
+	add := func(match []int, year, month, day int) {
+		if date, ok := formatVisibleDate(text, match[1], year, month, day); ok {
+			dates = append(dates, visibleDate{start: match[0], end: date.end, value: date.value})
+		}
+	}
+
+	for _, locale := range locales {
+		for _, m := range locale.dayFirst.FindAllStringSubmatchIndex(text, -1) {
+			add(m, atoi(text[m[6]:m[7]]), int(locale.months[strings.ToLower(text[m[4]:m[5]])]), atoi(text[m[2]:m[3]]))
+		}
+		for _, m := range locale.monthFirst.FindAllStringSubmatchIndex(text, -1) {
+			add(m, atoi(text[m[6]:m[7]]), int(locale.months[strings.ToLower(text[m[2]:m[3]])]), atoi(text[m[4]:m[5]]))
+		}
+		if locale.dotted {
+			for _, m := range dottedDatePattern.FindAllStringSubmatchIndex(text, -1) {
+				add(m, atoi(text[m[6]:m[7]]), atoi(text[m[4]:m[5]]), atoi(text[m[2]:m[3]]))
+			}
+		}
+	}
+	for _, m := range cjkDatePattern.FindAllStringSubmatchIndex(text, -1) {
+		add(m, atoi(text[m[2]:m[3]]), atoi(text[m[4]:m[5]]), atoi(text[m[6]:m[7]]))
+	}
+	for _, m := range isoDatePattern.FindAllStringSubmatchIndex(text, -1) {
+		add(m, atoi(text[m[2]:m[3]]), atoi(text[m[4]:m[5]]), atoi(text[m[6]:m[7]]))
+	}
+
+	slices.SortStableFunc(dates, func(a, b visibleDate) int { return cmp.Compare(a.start, b.start) })
+	return dates
+}
+
+// formatVisibleDate validates a date and formats it with the time of day
+// that follows it at text[end:], if any.
+func formatVisibleDate(text string, end, year, month, day int) (visibleDate, bool) {
+	if year < 1900 || year > 2100 || month < 1 || month > 12 {
+		return visibleDate{}, false
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return visibleDate{}, false
+	}
+
+	m := timePattern.FindStringSubmatchIndex(text[end:])
+	if m == nil {
+		return visibleDate{end: end, value: date.Format(time.DateOnly)}, true
+	}
+	group := func(i int) string {
+		if m[2*i] < 0 {
+			return ""
+		}
+		return text[end+m[2*i] : end+m[2*i+1]]
+	}
+
+	hour, minute, second := atoi(group(1)), atoi(group(2)), atoi(group(3))
+	switch meridiem := strings.ToLower(strings.ReplaceAll(group(4), ".", "")); {
+	case meridiem == "pm" && hour < 12:
+		hour += 12
+	case meridiem == "am" && hour == 12:
+		hour = 0
+	}
+	if hour > 23 || minute > 59 || second > 59 {
+		return visibleDate{end: end, value: date.Format(time.DateOnly)}, true
+	}
+
+	value := fmt.Sprintf("%sT%02d:%02d:%02d", date.Format(time.DateOnly), hour, minute, second)
+	switch zone := strings.ToUpper(group(5)); {
+	case zone == "GMT", zone == "UTC", zone == "Z":
+		value += "Z"
+	case zone != "":
+		value += zone[:3] + ":" + strings.TrimPrefix(zone[3:], ":")
+	}
+	return visibleDate{end: end + m[1], value: value}, true
+}
+
+// publishedLayouts are the machine-readable layouts tried before falling
+// back to findVisibleDates.
+var publishedLayouts = []string{
+	time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05", "2006-01-02T15:04", time.DateTime,
+	time.DateOnly, time.RFC1123Z, time.RFC1123, time.RFC850, time.ANSIC, "20060102",
+}
+
+// copyrightPattern matches copyright notices, whose year is not a
+// publication date.
+var copyrightPattern = regexp.MustCompile(`(?i)©|&copy;|\(c\)\s*\d|copyright`)
+
+// bareYearPattern matches a year on its own, as left by footer scraping.
+var bareYearPattern = regexp.MustCompile(`^\d{4}$`)
+
+// maxPublishedSkew is how far in the future a published date may lie, to
+// allow for time zones and embargoed articles going live.
+const maxPublishedSkew = 48 * time.Hour
+
+// plausiblePublished reports whether value looks like a real publication
+// date: not a copyright year, not more than maxPublishedSkew after now, and
+// not a zero or Unix-epoch placeholder. Values in formats it cannot parse
+// are accepted, as before.
+func plausiblePublished(value string, now time.Time) bool {
+	value = strings.TrimSpace(value)
+	if copyrightPattern.MatchString(value) || bareYearPattern.MatchString(value) {
+		return false
+	}
+	t, ok := parsePublished(value)
+	if !ok {
+		return true
+	}
+	epoch := time.Unix(0, 0)
+	if t.Year() < 1900 || (t.After(epoch.Add(-24*time.Hour)) && t.Before(epoch.Add(24*time.Hour))) {
+		return false
+	}
+	return !t.After(now.Add(maxPublishedSkew))
+}
+
+// ParseDate parses a published or modified date as found in Result
+// metadata, reporting false when value is in no known format.
+func ParseDate(value string) (time.Time, bool) {
+	return parsePublished(strings.TrimSpace(value))
+}
+
+// parsePublished parses a published date in a machine-readable layout, as
+// a Unix timestamp in seconds or milliseconds, or as a date written in text.
+func parsePublished(value string) (time.Time, bool) {
+	for _, layout := range publishedLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if n > 1e11 || n < -1e11 {
+			return time.UnixMilli(n), true
+		}
+		return time.Unix(n, 0), true
+	}
+	if dates := findVisibleDates(value, localesFor("")); len(dates) > 0 {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", time.DateOnly} {
+			if t, err := time.Parse(layout, dates[0].value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// DocumentLanguage returns the primary language subtag of the document,
+// such as "en" or "de", from the language getLanguage finds.
+func DocumentLanguage(doc *goquery.Document, metaTags []MetaTag) string {
+	primary, _, _ := strings.Cut(getLanguage(doc, metaTags), "-")
+	return strings.ToLower(primary)
+}
+
+// getLanguage returns the language tag of the document, such as "en-US",
+// from the html lang attribute, the Content-Language meta tag, og:locale,
+// or DC.language. Only the first of a list is kept, and underscores, as
+// in og:locale's "en_US", become hyphens.
+func getLanguage(doc *goquery.Document, metaTags []MetaTag) string {
+	lang := cmp.Or(
+		strings.TrimSpace(doc.Find("html").AttrOr("lang", "")),
+		strings.TrimSpace(doc.Find(`meta[http-equiv="content-language" i]`).AttrOr("content", "")),
+		strings.TrimSpace(getMetaContent(metaTags, "property", "og:locale")),
+		strings.TrimSpace(getDublinCore(metaTags, "language")),
+	)
+	lang, _, _ = strings.Cut(lang, ",")
+	return strings.ReplaceAll(strings.TrimSpace(lang), "_", "-")
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,261 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestGetVisibleDateParsesLocaleBylines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "english day first with time and zone",
+			html: `<html lang="en-GB"><body><p class="byline">By Jane Doe. Published 5 January 2024 at 10:00 GMT</p></body></html>`,
+			want: "2024-01-05T10:00:00Z",
+		},
+		{
+			name: "english month first",
+			html: `<html><body><div class="post-meta">Posted on Jan. 5th, 2024 by admin</div></body></html>`,
+			want: "2024-01-05",
+		},
+		{
+			name: "english twelve hour time with offset",
+			html: `<html><body><span class="date">March 3, 2021, 3:45 p.m. +0100</span></body></html>`,
+			want: "2021-03-03T15:45:00+01:00",
+		},
+		{
+			name: "german dotted date",
+			html: `<html lang="de"><body><div class="article-meta">Veröffentlicht am 05.01.2024 um 10:00 Uhr</div></body></html>`,
+			want: "2024-01-05T10:00:00",
+		},
+		{
+			name: "german month name",
+			html: `<html lang="de-AT"><body><div class="dateline">5. Jänner 2024</div></body></html>`,
+			want: "2024-01-05",
+		},
+		{
+			name: "french from og locale",
+			html: `<html><head><meta property="og:locale" content="fr_FR"></head><body><p class="byline">Publié le 1er février 2023 à 9h30</p></body></html>`,
+			want: "2023-02-01T09:30:00",
+		},
+		{
+			name: "spanish",
+			html: `<html lang="es"><body><p class="byline">Publicado el 5 de enero de 2024</p></body></html>`,
+			want: "2024-01-05",
+		},
+		{
+			name: "japanese",
+			html: `<html lang="ja"><body><div class="date">2024年1月5日 10:00</div></body></html>`,
+			want: "2024-01-05T10:00:00",
+		},
+		{
+			name: "korean",
+			html: `<html lang="ko"><body><span class="published">2024년 1월 5일</span></body></html>`,
+			want: "2024-01-05",
+		},
+		{
+			name: "date beside author",
+			html: `<html><body><div><a rel="author" href="/jane">Jane</a> · 12 June 2019</div></body></html>`,
+			want: "2019-06-12",
+		},
+		{
+			name: "update date skipped",
+			html: `<html><body><p class="byline">Updated: 9 February 2024. Published 5 January 2024</p></body></html>`,
+			want: "2024-01-05",
+		},
+		{
+			name: "dotted dates ignored for english",
+			html: `<html lang="en"><body><p class="byline">Version 05.01.2024</p></body></html>`,
+			want: "",
+		},
+		{
+			name: "invalid day",
+			html: `<html><body><p class="byline">31 February 2024</p></body></html>`,
+			want: "",
+		},
+		{
+			name: "no byline",
+			html: `<html><body><p>The meeting on 5 January 2024 went well.</p></body></html>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := mustMetadataDocument(t, tt.html)
+			if got := getVisibleDate(doc, DocumentLanguage(doc, extractTestMetaTags(doc)), false); got != tt.want {
+				t.Fatalf("getVisibleDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentLanguageReadsPrimarySubtag(t *testing.T) {
+	t.Parallel()
+
+	doc := mustMetadataDocument(t, `<html><head><meta http-equiv="Content-Language" content="pt-BR, en"></head><body></body></html>`)
+	if got := DocumentLanguage(doc, nil); got != "pt" {
+		t.Fatalf("DocumentLanguage() = %q, want %q", got, "pt")
+	}
+}
+
+func TestExtractFallsBackToVisibleDate(t *testing.T) {
+	t.Parallel()
+
+	doc := mustMetadataDocument(t, `<html lang="it"><body><p class="byline">Pubblicato il 3 marzo 2020</p></body></html>`)
+	if got := Extract(doc, nil, nil, "").Published; got != "2020-03-03" {
+		t.Fatalf("Published = %q, want %q", got, "2020-03-03")
+	}
+}
+
+func TestGetPublishedSkipsImplausibleDates(t *testing.T) {
+	t.Parallel()
+
+	doc := mustMetadataDocument(t, `<html lang="en"><head>
+		<meta property="article:published_time" content="1970-01-01T00:00:00Z">
+	</head><body><time datetime="2021-06-15">June 15</time><footer>© 2023 Example</footer></body></html>`)
+	metaTags := extractTestMetaTags(doc)
+	future := time.Now().AddDate(1, 0, 0).Format(time.DateOnly)
+
+	published, source := getPublished(doc, map[string]any{"datePublished": future}, metaTags)
+	if published != "2021-06-15" || source != "time" {
+		t.Fatalf("getPublished() = %q, %q, want %q, %q", published, source, "2021-06-15", "time")
+	}
+
+	published, source = getPublished(doc, map[string]any{"datePublished": "2020-02-03"}, metaTags)
+	if published != "2020-02-03" || source != "schema.org" {
+		t.Fatalf("getPublished() = %q, %q, want %q, %q", published, source, "2020-02-03", "schema.org")
+	}
+}
+
+func TestPlausiblePublished(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"2024-04-30T09:00:00+02:00", true},
+		{"2024-05-02", true},
+		{"April 3, 2019", true},
+		{"1714564800", true},
+		{"yesterday", true},
+		{"2024-06-01", false},
+		{"1970-01-01T00:00:00Z", false},
+		{"1969-12-31T19:00:00-05:00", false},
+		{"0", false},
+		{"0001-01-01T00:00:00Z", false},
+		{"2023", false},
+		{"© 2023 Example Media", false},
+		{"Copyright 2019", false},
+	}
+	for _, tt := range tests {
+		if got := plausiblePublished(tt.value, now); got != tt.want {
+			t.Fatalf("plausiblePublished(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// extractTestMetaTags collects property meta tags the way the parser does.
+func extractTestMetaTags(doc *goquery.Document) []MetaTag {
+	var tags []MetaTag
+	doc.Find("meta[property]").Each(func(_ int, s *goquery.Selection) {
+		property, content := s.AttrOr("property", ""), s.AttrOr("content", "")
+		tags = append(tags, MetaTag{Property: &property, Content: &content})
+	})
+	return tags
+}
+
+func TestGetModifiedPrefersStructuredSources(t *testing.T) {
+	t.Parallel()
+
+	doc := mustMetadataDocument(t, `<html lang="en"><head>
+		<meta property="article:modified_time" content="2024-03-01T08:00:00Z">
+	</head><body><p class="byline">Updated on 9 March 2024</p></body></html>`)
+	metaTags := extractTestMetaTags(doc)
+
+	if got := getModified(doc, map[string]any{"dateModified": "2024-03-02"}, metaTags); got != "2024-03-02" {
+		t.Fatalf("getModified() with schema = %q, want %q", got, "2024-03-02")
+	}
+	if got := getModified(doc, nil, metaTags); got != "2024-03-01T08:00:00Z" {
+		t.Fatalf("getModified() with meta tag = %q, want %q", got, "2024-03-01T08:00:00Z")
+	}
+}
+
+func TestGetModifiedFallsBackToVisibleUpdateNote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "microdata element",
+			html: `<html><body><time itemprop="dateModified" datetime="2024-02-10">Feb 10</time></body></html>`,
+			want: "2024-02-10",
+		},
+		{
+			name: "update banner",
+			html: `<html><body><div class="update-banner">Updated on March 9, 2024 at 4:15 pm UTC</div></body></html>`,
+			want: "2024-03-09T16:15:00Z",
+		},
+		{
+			name: "byline with both dates",
+			html: `<html lang="de"><body><p class="byline">Veröffentlicht am 5. Januar 2024, aktualisiert am 7. Januar 2024</p></body></html>`,
+			want: "2024-01-07",
+		},
+		{
+			name: "published only",
+			html: `<html><body><p class="byline">Published 5 January 2024</p></body></html>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := mustMetadataDocument(t, tt.html)
+			if got := getModified(doc, nil, nil); got != tt.want {
+				t.Fatalf("getModified() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUpdateNoteRequiresMarkerDateAndLittleElse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		lang string
+		want bool
+	}{
+		{text: "Updated on 5 March 2024", lang: "en", want: true},
+		{text: "  Last updated: Jan 5, 2024, 10:00 a.m. GMT ", lang: "en", want: true},
+		{text: "Aktualisiert am 05.01.2024 um 10:00 Uhr", lang: "de", want: true},
+		{text: "Mis à jour le 3 mars 2024", lang: "fr", want: true},
+		{text: "更新：2024年3月5日", lang: "ja", want: true},
+		{text: "Published 5 March 2024", lang: "en", want: false},
+		{text: "Updated on 5 March 2024: the company has since responded with a statement.", lang: "en", want: false},
+		{text: "Update: the company responded on 5 March 2024.", lang: "en", want: false},
+		{text: "Updated regularly with the latest news", lang: "en", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUpdateNote(tt.text, tt.lang); got != tt.want {
+			t.Errorf("IsUpdateNote(%q, %q) = %v, want %v", tt.text, tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package metadata
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// keyPointSelectors find fact boxes and key-point sidebars by their class
+// or id.
+const keyPointSelectors = `[class*="key-points"], [class*="keypoints"], [class*="key-takeaways"], [class*="takeaways"], ` +
+	`[class*="at-a-glance"], [class*="fact-box"], [class*="factbox"], [class*="story-highlights"], [class*="tldr"], ` +
+	`[id*="key-points"], [id*="key-takeaways"], [id*="at-a-glance"], [id*="factbox"]`
+
+// maxKeyPointsText is the longest text accepted as one box, so a wrapper
+// whose class happens to match never takes the article with it.
+const maxKeyPointsText = 2000
+
+// keyPointsLabelPattern matches the label that heads a key-points box, as in
+// "Key takeaways" or "At a glance:".
+var keyPointsLabelPattern = regexp.MustCompile(`(?i)^\s*(?:key\s+(?:points|takeaways|facts)|(?:the\s+)?takeaways|at\s+a\s+glance|fast\s+facts|the\s+facts|in\s+brief|tl;?\s*dr|story\s+highlights|what\s+you\s+need\s+to\s+know)\s*[:.]?\s*$`)
+
+// FindKeyPoints returns the fact boxes and key-point sidebars inside content
+// in document order: elements whose class or id names one, and lists headed
+// by a label such as "Key takeaways" or "At a glance". A label's box is its
+// wrapper when the wrapper opens with it and holds no other heading, and
+// otherwise the label with the list after it. Of nested matches only the
+// outermost is returned, and never the content root itself.
+func FindKeyPoints(content *goquery.Selection) *goquery.Selection {
+	root := content.Get(0)
+	var boxes []*html.Node
+	add := func(box *goquery.Selection) {
+		for _, n := range box.Nodes {
+			if n == root || !isKeyPointsLength(box) {
+				return
+			}
+		}
+		boxes = append(boxes, box.Nodes...)
+	}
+
+	content.Find(keyPointSelectors).Each(func(_ int, s *goquery.Selection) {
+		if s.Find("li, p").Length() > 0 {
+			add(s)
+		}
+	})
+
+	content.Find("h2, h3, h4, h5, h6, p, strong").Each(func(_ int, label *goquery.Selection) {
+		if !keyPointsLabelPattern.MatchString(label.Text()) {
+			return
+		}
+		// A bold label sits in its own paragraph
+		if goquery.NodeName(label) == "strong" {
+			parent := label.Parent()
+			if goquery.NodeName(parent) != "p" || strings.TrimSpace(parent.Text()) != strings.TrimSpace(label.Text()) {
+				return
+			}
+			label = parent
+		}
+		list := label.NextAllFiltered("*").First()
+		if !list.Is("ul, ol") {
+			return
+		}
+		parent := label.Parent()
+		if parent.Get(0) != root && label.PrevAll().Length() == 0 &&
+			parent.Find("h1, h2, h3, h4, h5, h6").NotSelection(label).Length() == 0 &&
+			parent.Children().Length() == 2 {
+			add(parent)
+			return
+		}
+		add(label.AddSelection(list))
+	})
+
+	// Keep the outermost of nested matches, in document order
+	var kept []*html.Node
+	content.Find("*").Each(func(_ int, s *goquery.Selection) {
+		n := s.Get(0)
+		if !slices.Contains(boxes, n) || slices.Contains(kept, n) {
+			return
+		}
+		if slices.ContainsFunc(kept, func(k *html.Node) bool { return isAncestor(k, n) }) {
+			return
+		}
+		kept = append(kept, n)
+	})
+	return content.FindNodes(kept...)
+}
+
+// isKeyPointsLength reports whether sel has text, but no more than a box.
+func isKeyPointsLength(sel *goquery.Selection) bool {
+	n := utf8.RuneCountInString(strings.TrimSpace(sel.Text()))
+	return n > 0 && n <= maxKeyPointsText
+}
+
+// isAncestor reports whether ancestor is an ancestor of n.
+func isAncestor(ancestor, n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyPointTexts returns the whitespace-collapsed text of each point in
+// boxes: the list items of a box, or its paragraphs when it has no list.
+// Labels such as "Key takeaways" are left out.
+func KeyPointTexts(boxes *goquery.Selection) []string {
+	var points []string
+	addText := func(s *goquery.Selection) {
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if text != "" && !keyPointsLabelPattern.MatchString(text) {
+			points = append(points, text)
+		}
+	}
+	boxes.Each(func(_ int, box *goquery.Selection) {
+		if items := box.Find("li").Not("li li"); items.Length() > 0 {
+			items.Each(func(_ int, item *goquery.Selection) { addText(item) })
+			return
+		}
+		if paragraphs := box.Find("p"); paragraphs.Length() > 0 {
+			paragraphs.Each(func(_ int, p *goquery.Selection) { addText(p) })
+			return
+		}
+		if !box.Is("h2, h3, h4, h5, h6, p") {
+			addText(box)
+		}
+	})
+	return points
+}
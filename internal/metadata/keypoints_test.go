@@ -0,0 +1,59 @@
+package metadata
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestFindKeyPoints(t *testing.T) {
+	t.Parallel()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<article>
+		<aside class="article-key-points"><h3>Key points</h3><ul><li>Rates rise  by 0.25%</li><li>Inflation eases <ul><li>slowly</li></ul></li></ul></aside>
+		<p>The central bank raised rates on Thursday.</p>
+		<div><p><strong>At a glance:</strong></p><ul><li>Vote was 7–2</li></ul></div>
+		<h2>Background</h2>
+		<p>Officials met for two days.</p>
+		<h3>Key takeaways</h3>
+		<ol><li>Markets rallied</li></ol>
+		<p>More reporting follows.</p>
+		<div class="factbox"><p>Founded: 1913</p><p>Members: 12</p></div>
+		<h3>In brief</h3>
+		<p>Not a list, so not a box.</p>
+	</article>`))
+	if err != nil {
+		t.Fatalf("NewDocumentFromReader() error = %v", err)
+	}
+
+	found := FindKeyPoints(doc.Find("article"))
+	if found.Length() != 5 {
+		t.Fatalf("FindKeyPoints() found %d elements, want 5", found.Length())
+	}
+	got := KeyPointTexts(found)
+	want := []string{
+		"Rates rise by 0.25%",
+		"Inflation eases slowly",
+		"Vote was 7–2",
+		"Markets rallied",
+		"Founded: 1913",
+		"Members: 12",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("KeyPointTexts(FindKeyPoints()) = %q, want %q", got, want)
+	}
+}
+
+func TestFindKeyPointsSkipsContentRoot(t *testing.T) {
+	t.Parallel()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div class="key-takeaways"><p>The whole article.</p></div>`))
+	if err != nil {
+		t.Fatalf("NewDocumentFromReader() error = %v", err)
+	}
+	if found := FindKeyPoints(doc.Find("div.key-takeaways")); found.Length() != 0 {
+		t.Fatalf("FindKeyPoints() found %d elements, want 0", found.Length())
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"cmp"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -45,17 +48,31 @@ type MetaTag struct {
 //	  wordCount: number;
 //	}
 type Metadata struct {
-	Title         string `json:"title"`
-	Description   string `json:"description"`
-	Domain        string `json:"domain"`
-	Favicon       string `json:"favicon"`
-	Image         string `json:"image"`
-	ParseTime     int64  `json:"parseTime"`
-	Published     string `json:"published"`
-	Author        string `json:"author"`
-	Site          string `json:"site"`
-	SchemaOrgData any    `json:"schemaOrgData"`
-	WordCount     int    `json:"wordCount"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Domain      string `json:"domain"`
+	Favicon     string `json:"favicon"`
+	Image       string `json:"image"`
+	ParseTime   int64  `json:"parseTime"`
+	Published   string `json:"published"`
+	// PublishedSource names where Published came from, such as "schema.org"
+	// or "meta:article:published_time"; it has no counterpart in the
+	// original.
+	PublishedSource string `json:"publishedSource,omitempty"`
+	// Modified is the last-modified date; it has no counterpart in the
+	// original.
+	Modified string `json:"modified,omitempty"`
+	Author   string `json:"author"`
+	Site     string `json:"site"`
+	// Section, Tags, and Language come from article:section,
+	// article:tag, and the document's language; they have no counterpart
+	// in the original. Parse detects Language from the content when the
+	// document declares none.
+	Section       string   `json:"section,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Language      string   `json:"language,omitempty"`
+	SchemaOrgData any      `json:"schemaOrgData"`
+	WordCount     int      `json:"wordCount"`
 }
 
 // Extract builds metadata from the document, structured data, meta tags, and base URL.
@@ -150,18 +167,25 @@ func Extract(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag, baseU
 		}
 	}
 
+	published, publishedSource := getPublished(doc, schemaOrgData, metaTags)
+
 	return &Metadata{
-		Title:         getTitle(doc, schemaOrgData, metaTags),
-		Description:   getDescription(doc, schemaOrgData, metaTags),
-		Domain:        domain,
-		Favicon:       getFavicon(doc, documentURL, metaTags),
-		Image:         getImage(doc, schemaOrgData, metaTags),
-		Published:     getPublished(doc, schemaOrgData, metaTags),
-		Author:        getAuthor(doc, schemaOrgData, metaTags),
-		Site:          getSite(doc, schemaOrgData, metaTags),
-		SchemaOrgData: schemaOrgData,
-		WordCount:     0,
-		ParseTime:     0,
+		Title:           getTitle(doc, schemaOrgData, metaTags),
+		Description:     getDescription(doc, schemaOrgData, metaTags),
+		Domain:          domain,
+		Favicon:         getFavicon(doc, documentURL, metaTags),
+		Image:           getImage(doc, schemaOrgData, metaTags),
+		Published:       published,
+		PublishedSource: publishedSource,
+		Modified:        getModified(doc, schemaOrgData, metaTags),
+		Author:          getAuthor(doc, schemaOrgData, metaTags),
+		Site:            getSite(doc, schemaOrgData, metaTags),
+		Section:         getSection(schemaOrgData, metaTags),
+		Tags:            getTags(metaTags),
+		Language:        getLanguage(doc, metaTags),
+		SchemaOrgData:   schemaOrgData,
+		WordCount:       0,
+		ParseTime:       0,
 	}
 }
 
@@ -260,6 +284,7 @@ func getAuthor(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag) str
 		getMetaContent(metaTags, "name", "author"),
 		getMetaContent(metaTags, "name", "byl"),
 		getMetaContent(metaTags, "name", "authorList"),
+		getDublinCore(metaTags, "creator"),
 	)
 	if authors != "" {
 		return authors
@@ -367,6 +392,7 @@ func getTitle(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag) stri
 		getSchemaProperty(schemaOrgData, "headline"),
 		getMetaContent(metaTags, "name", "title"),
 		getMetaContent(metaTags, "name", "sailthru.title"),
+		getDublinCore(metaTags, "title"),
 	)
 	if rawTitle == "" {
 		titleEl := doc.Find("title").First()
@@ -378,6 +404,16 @@ func getTitle(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag) stri
 	return cleanTitle(rawTitle, getSite(doc, schemaOrgData, metaTags))
 }
 
+// titleSeparator matches the separator between a title and a site name.
+const titleSeparator = `(?:::|[|\-–—·•»«｜－／])`
+
+// titleSiteOpen and titleSiteClose match the brackets a site name may be
+// wrapped in.
+const (
+	titleSiteOpen  = `[「『【\[]`
+	titleSiteClose = `[」』】\]]`
+)
+
 // cleanTitle removes site name from title
 // JavaScript original code:
 //
@@ -401,15 +437,24 @@ func getTitle(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag) stri
 //
 //	  return title.trim();
 //	}
+//
+// Beyond the original, separators include the middle dot, bullet,
+// guillemets, "::", and fullwidth bar, hyphen, and slash; a site name may
+// be wrapped in brackets such as 「」 or 【】, with or without a separator;
+// and a site name between two separators splits the title, keeping the
+// longer side.
 func cleanTitle(title, siteName string) string {
 	if title == "" || siteName == "" {
 		return title
 	}
 
-	siteNameEscaped := regexp.QuoteMeta(siteName)
+	site := titleSiteOpen + `?\s*` + regexp.QuoteMeta(siteName) + `\s*` + titleSiteClose + `?`
+	bracketed := titleSiteOpen + `\s*` + regexp.QuoteMeta(siteName) + `\s*` + titleSiteClose
 	patterns := []string{
-		`\s*[\|\-–—]\s*` + siteNameEscaped + `\s*$`, // Title | Site Name
-		`^\s*` + siteNameEscaped + `\s*[\|\-–—]\s*`, // Site Name | Title
+		`\s*` + titleSeparator + `\s*` + site + `\s*$`, // Title | Site Name
+		`^\s*` + site + `\s*` + titleSeparator + `\s*`, // Site Name | Title
+		`\s*` + bracketed + `\s*$`,                     // Title【Site Name】
+		`^\s*` + bracketed + `\s*`,                     // 【Site Name】Title
 	}
 
 	for _, pattern := range patterns {
@@ -418,8 +463,23 @@ func cleanTitle(title, siteName string) string {
 			continue
 		}
 		if regex.MatchString(title) {
-			title = regex.ReplaceAllString(title, "")
-			break
+			if cleaned := strings.TrimSpace(regex.ReplaceAllString(title, "")); cleaned != "" {
+				return cleaned
+			}
+			return strings.TrimSpace(title)
+		}
+	}
+
+	// Title | Site Name | Section
+	middle, err := regexp.Compile(`(?i)\s*` + titleSeparator + `\s*` + site + `\s*` + titleSeparator + `\s*`)
+	if err == nil {
+		if loc := middle.FindStringIndex(title); loc != nil {
+			before := strings.TrimSpace(title[:loc[0]])
+			after := strings.TrimSpace(title[loc[1]:])
+			if utf8.RuneCountInString(after) > utf8.RuneCountInString(before) {
+				return after
+			}
+			return before
 		}
 	}
 
@@ -531,7 +591,10 @@ func getFavicon(doc *goquery.Document, baseURL string, metaTags []MetaTag) strin
 	return favicon
 }
 
-// getPublished extracts publication date
+// getPublished extracts publication date and the name of the source it came
+// from. Unlike the original, it falls back to dates written in visible
+// bylines, such as "Published 5 January 2024", and skips candidates that fail
+// plausiblePublished, so a bogus schema.org date gives way to the meta tags.
 // JavaScript original code:
 //
 //	private static getPublished(doc: Document, schemaOrgData: any, metaTags: MetaTagItem[]): string {
@@ -544,16 +607,50 @@ func getFavicon(doc *goquery.Document, baseURL string, metaTags []MetaTag) strin
 //	    ''
 //	  );
 //	}
-func getPublished(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag) string {
+func getPublished(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag) (string, string) {
+	candidates := []struct {
+		source string
+		value  func() string
+	}{
+		{"schema.org", func() string { return getSchemaProperty(schemaOrgData, "datePublished") }},
+		{"meta:article:published_time", func() string { return getMetaContent(metaTags, "property", "article:published_time") }},
+		{"meta:sailthru.date", func() string { return getMetaContent(metaTags, "name", "sailthru.date") }},
+		{"meta:date", func() string { return getMetaContent(metaTags, "name", "date") }},
+		{"meta:dc.date", func() string { return getDublinCore(metaTags, "date", "issued") }},
+		{"time", func() string { return getTimeElement(doc) }},
+		{"byline", func() string { return getVisibleDate(doc, DocumentLanguage(doc, metaTags), false) }},
+	}
+	now := time.Now()
+	for _, candidate := range candidates {
+		if value := candidate.value(); value != "" && plausiblePublished(value, now) {
+			return value, candidate.source
+		}
+	}
+	return "", ""
+}
+
+// getModified extracts the last-modified date from schema.org dateModified,
+// modified-time meta tags, a dateModified microdata element, or a visible
+// "Updated on ..." note.
+func getModified(doc *goquery.Document, schemaOrgData any, metaTags []MetaTag) string {
 	return cmp.Or(
-		getSchemaProperty(schemaOrgData, "datePublished"),
-		getMetaContent(metaTags, "property", "article:published_time"),
-		getMetaContent(metaTags, "name", "sailthru.date"),
-		getMetaContent(metaTags, "name", "date"),
-		getTimeElement(doc),
+		getSchemaProperty(schemaOrgData, "dateModified"),
+		getMetaContent(metaTags, "property", "article:modified_time"),
+		getMetaContent(metaTags, "property", "og:updated_time"),
+		getMetaContent(metaTags, "name", "last-modified"),
+		getDublinCore(metaTags, "modified"),
+		getDateModifiedElement(doc),
+		getVisibleDate(doc, DocumentLanguage(doc, metaTags), true),
 	)
 }
 
+// getDateModifiedElement reads the machine-readable date of an
+// itemprop="dateModified" element.
+func getDateModifiedElement(doc *goquery.Document) string {
+	element := doc.Find(`[itemprop="dateModified"]`).First()
+	return cmp.Or(element.AttrOr("datetime", ""), element.AttrOr("content", ""))
+}
+
 // getMetaContent finds meta tag content by attribute and value
 // JavaScript original code:
 //
@@ -577,6 +674,46 @@ func getMetaContent(metaTags []MetaTag, attr, value string) string {
 	return ""
 }
 
+// getDublinCore returns the content of the first Dublin Core meta tag for
+// one of elements, named like "DC.title" or "dcterms.title" in any case.
+func getDublinCore(metaTags []MetaTag, elements ...string) string {
+	for _, element := range elements {
+		for _, tag := range metaTags {
+			if tag.Name == nil || tag.Content == nil {
+				continue
+			}
+			prefix, name, ok := strings.Cut(*tag.Name, ".")
+			if ok && (strings.EqualFold(prefix, "dc") || strings.EqualFold(prefix, "dcterms")) && strings.EqualFold(name, element) {
+				return *tag.Content
+			}
+		}
+	}
+	return ""
+}
+
+// getSection extracts the section the article was published in from
+// article:section or schema.org articleSection.
+func getSection(schemaOrgData any, metaTags []MetaTag) string {
+	return strings.TrimSpace(cmp.Or(
+		getMetaContent(metaTags, "property", "article:section"),
+		getSchemaProperty(schemaOrgData, "articleSection"),
+	))
+}
+
+// getTags returns the distinct article:tag values in document order.
+func getTags(metaTags []MetaTag) []string {
+	var tags []string
+	for _, tag := range metaTags {
+		if tag.Property == nil || *tag.Property != "article:tag" || tag.Content == nil {
+			continue
+		}
+		if value := strings.TrimSpace(*tag.Content); value != "" && !slices.Contains(tags, value) {
+			tags = append(tags, value)
+		}
+	}
+	return tags
+}
+
 // getTimeElement extracts time from time elements
 // JavaScript original code:
 //
@@ -45,6 +45,66 @@ func TestCleanTitleRemovesSiteName(t *testing.T) {
 			siteName: "Test (Site)+",
 			want:     "Advanced Test Article",
 		},
+		{
+			name:     "middle dot",
+			title:    "Advanced Test Article · Test Site",
+			siteName: "Test Site",
+			want:     "Advanced Test Article",
+		},
+		{
+			name:     "guillemet before title",
+			title:    "Test Site » Advanced Test Article",
+			siteName: "Test Site",
+			want:     "Advanced Test Article",
+		},
+		{
+			name:     "double colon",
+			title:    "Advanced Test Article :: Test Site",
+			siteName: "Test Site",
+			want:     "Advanced Test Article",
+		},
+		{
+			name:     "fullwidth bar",
+			title:    "新しい記事のタイトル｜テストサイト",
+			siteName: "テストサイト",
+			want:     "新しい記事のタイトル",
+		},
+		{
+			name:     "corner brackets without separator",
+			title:    "新しい記事のタイトル 「テストサイト」",
+			siteName: "テストサイト",
+			want:     "新しい記事のタイトル",
+		},
+		{
+			name:     "lenticular brackets before title",
+			title:    "【测试网站】新文章标题",
+			siteName: "测试网站",
+			want:     "新文章标题",
+		},
+		{
+			name:     "bracketed site name after separator",
+			title:    "Advanced Test Article - [Test Site]",
+			siteName: "Test Site",
+			want:     "Advanced Test Article",
+		},
+		{
+			name:     "site name in the middle",
+			title:    "Advanced Test Article | Test Site | Science",
+			siteName: "Test Site",
+			want:     "Advanced Test Article",
+		},
+		{
+			name:     "site name in the middle after a section",
+			title:    "Wissen « Test Site » Ein neuer Artikel über Physik",
+			siteName: "Test Site",
+			want:     "Ein neuer Artikel über Physik",
+		},
+		{
+			name:     "title that is only the site name",
+			title:    "| Test Site",
+			siteName: "Test Site",
+			want:     "| Test Site",
+		},
 		{
 			name:     "no match keeps title",
 			title:    "Advanced Test Article",
@@ -231,3 +291,56 @@ func TestExtractUsesCanonicalURLAndDOMAuthorFallback(t *testing.T) {
 		t.Fatalf("Favicon = %q, want canonical favicon fallback", metadata.Favicon)
 	}
 }
+
+func TestExtractReadsArticleAndDublinCoreMeta(t *testing.T) {
+	t.Parallel()
+
+	doc := mustMetadataDocument(t, `<html><head></head><body><p>Body</p></body></html>`)
+	meta := func(attr, key, content string) MetaTag {
+		if attr == "name" {
+			return MetaTag{Name: &key, Content: &content}
+		}
+		return MetaTag{Property: &key, Content: &content}
+	}
+	metaTags := []MetaTag{
+		meta("name", "DC.title", "Dublin Core Title"),
+		meta("name", "dc.creator", "Dublin Core Creator"),
+		meta("name", "DCTERMS.date", "2024-03-01"),
+		meta("name", "dcterms.modified", "2024-03-05"),
+		meta("property", "og:locale", "en_GB"),
+		meta("property", "article:section", " Science "),
+		meta("property", "article:tag", "Physics"),
+		meta("property", "article:tag", "Space"),
+		meta("property", "article:tag", "Physics"),
+	}
+
+	metadata := Extract(doc, nil, metaTags, "https://example.com/article")
+	if metadata.Title != "Dublin Core Title" {
+		t.Fatalf("Title = %q, want DC.title", metadata.Title)
+	}
+	if metadata.Author != "Dublin Core Creator" {
+		t.Fatalf("Author = %q, want DC.creator", metadata.Author)
+	}
+	if metadata.Published != "2024-03-01" || metadata.PublishedSource != "meta:dc.date" {
+		t.Fatalf("Published = %q from %q, want DC.date", metadata.Published, metadata.PublishedSource)
+	}
+	if metadata.Modified != "2024-03-05" {
+		t.Fatalf("Modified = %q, want dcterms.modified", metadata.Modified)
+	}
+	if metadata.Section != "Science" {
+		t.Fatalf("Section = %q, want %q", metadata.Section, "Science")
+	}
+	if strings.Join(metadata.Tags, ",") != "Physics,Space" {
+		t.Fatalf("Tags = %q, want distinct article:tag values", metadata.Tags)
+	}
+	if metadata.Language != "en-GB" {
+		t.Fatalf("Language = %q, want %q", metadata.Language, "en-GB")
+	}
+
+	doc = mustMetadataDocument(t, `<html lang="de-AT"><head></head><body></body></html>`)
+	schema := map[string]any{"@type": "NewsArticle", "articleSection": "Wirtschaft"}
+	metadata = Extract(doc, schema, metaTags[:1], "")
+	if metadata.Language != "de-AT" || metadata.Section != "Wirtschaft" {
+		t.Fatalf("Language, Section = %q, %q, want html lang and articleSection", metadata.Language, metadata.Section)
+	}
+}
@@ -0,0 +1,250 @@
+package metadata
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Series places an article within a multi-part series
+type Series struct {
+	// Name is the series name, when known.
+	Name string `json:"name,omitempty"`
+	// Position is the 1-based part number, or 0 when unknown.
+	Position int `json:"position,omitempty"`
+	// Total is the number of parts, or 0 when unknown.
+	Total int `json:"total,omitempty"`
+	// PrevURL and NextURL link to the neighboring parts.
+	PrevURL string `json:"prevUrl,omitempty"`
+	NextURL string `json:"nextUrl,omitempty"`
+}
+
+// seriesNumber matches a part number written as digits, an English word,
+// or a Roman numeral.
+const seriesNumber = `(\d{1,3}|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|[ivx]{1,4})`
+
+// seriesSeparators are trimmed from the text before a part marker to leave
+// the series name.
+const seriesSeparators = " \t-–—:|,()[]"
+
+var (
+	// partPattern matches "Part 2 of 5", "Part II", "part 3/4", and "Pt. 2".
+	partPattern = regexp.MustCompile(`(?i)\b(?:part|pt\.?|chapter|episode)\s+` + seriesNumber + `\b(?:\s*(?:of|/)\s*` + seriesNumber + `\b)?`)
+	// fractionPattern matches a trailing "(2/5)" in titles.
+	fractionPattern = regexp.MustCompile(`\((\d{1,3})\s*/\s*(\d{1,3})\)\s*$`)
+)
+
+// seriesWords maps English number words and Roman numerals to their values.
+var seriesWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"i": 1, "ii": 2, "iii": 3, "iv": 4, "v": 5, "vi": 6,
+	"vii": 7, "viii": 8, "ix": 9, "x": 10, "xi": 11, "xii": 12,
+}
+
+// seriesNavSelectors find series navigation boxes and notices on the page.
+const seriesNavSelectors = `[class*="series"], [id*="series"]`
+
+// ExtractSeries detects whether the article is part of a series, from
+// schema.org isPartOf or partOfSeries, "Part 2 of 5" markers in the title,
+// headings, and series boxes, and the page's prev and next links. The links
+// are only reported once the page is known to be part of a series, since
+// blogs link neighboring posts the same way. It returns nil when no series
+// is found.
+func ExtractSeries(doc *goquery.Document, schemaOrgData any, title, baseURL string) *Series {
+	series := schemaSeries(schemaOrgData)
+
+	// Titles and headings name the series before the marker, as in
+	// "Rust Internals, Part 2"; series boxes are only read for numbers
+	headings := []string{title}
+	doc.Find("h1").Each(func(_ int, s *goquery.Selection) {
+		headings = append(headings, s.Text())
+	})
+	nav := doc.Find("body").Find(seriesNavSelectors)
+	var boxes []string
+	nav.Each(func(_ int, s *goquery.Selection) {
+		if text := strings.Join(strings.Fields(s.Text()), " "); utf8.RuneCountInString(text) <= maxVisibleDateText {
+			boxes = append(boxes, text)
+		}
+	})
+
+	for i, text := range append(headings, boxes...) {
+		name, position, total, ok := parsePartMarker(text)
+		if !ok {
+			continue
+		}
+		if series == nil {
+			series = &Series{}
+		}
+		if i < len(headings) {
+			series.Name = cmp.Or(series.Name, name)
+		}
+		series.Position = cmp.Or(series.Position, position)
+		series.Total = cmp.Or(series.Total, total)
+	}
+
+	if series == nil && nav.Length() == 0 {
+		return nil
+	}
+	prev, next := adjacentLinks(doc, baseURL)
+	if series == nil {
+		if prev == "" && next == "" {
+			return nil
+		}
+		series = &Series{}
+	}
+	series.PrevURL, series.NextURL = prev, next
+	return series
+}
+
+// schemaSeries reads the series from schema.org items: a partOfSeries, or
+// an isPartOf whose type is a series. The item's position is its part.
+func schemaSeries(schemaOrgData any) *Series {
+	var items []any
+	switch data := schemaOrgData.(type) {
+	case []any:
+		items = data
+	case map[string]any:
+		items = []any{data}
+	}
+
+	for _, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		parent := seriesObject(object["partOfSeries"], true)
+		if parent == nil {
+			parent = seriesObject(object["isPartOf"], false)
+		}
+		if parent == nil {
+			continue
+		}
+		return &Series{
+			Name:     schemaString(parent["name"]),
+			Position: schemaInt(cmp.Or(object["position"], object["episodeNumber"], object["issueNumber"])),
+			Total:    schemaInt(cmp.Or(parent["numberOfItems"], parent["numberOfEpisodes"], parent["numberOfParts"])),
+		}
+	}
+	return nil
+}
+
+// seriesObject returns value as a series object: any object when
+// explicit, otherwise only one whose @type names a series.
+func seriesObject(value any, explicit bool) map[string]any {
+	if list, ok := value.([]any); ok && len(list) > 0 {
+		value = list[0]
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if explicit || strings.Contains(fmt.Sprint(object["@type"]), "Series") {
+		return object
+	}
+	return nil
+}
+
+func schemaString(value any) string {
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s)
+	}
+	return ""
+}
+
+func schemaInt(value any) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+// parsePartMarker finds a part marker in text, returning the text around it
+// as the series name.
+func parsePartMarker(text string) (name string, position, total int, ok bool) {
+	text = strings.Join(strings.Fields(text), " ")
+	if m := partPattern.FindStringSubmatchIndex(text); m != nil {
+		position = partNumber(text[m[2]:m[3]])
+		if m[4] >= 0 {
+			total = partNumber(text[m[4]:m[5]])
+		}
+		if position == 0 || (total > 0 && position > total) {
+			return "", 0, 0, false
+		}
+		return strings.Trim(text[:m[0]], seriesSeparators), position, total, true
+	}
+	if m := fractionPattern.FindStringSubmatchIndex(text); m != nil {
+		position, total = partNumber(text[m[2]:m[3]]), partNumber(text[m[4]:m[5]])
+		if position == 0 || position > total {
+			return "", 0, 0, false
+		}
+		return strings.Trim(text[:m[0]], seriesSeparators), position, total, true
+	}
+	return "", 0, 0, false
+}
+
+// partNumber parses digits, English number words, and Roman numerals up to
+// twelve, returning 0 for anything else.
+func partNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return seriesWords[strings.ToLower(s)]
+}
+
+// adjacentLinks returns the page's rel="prev" and rel="next" targets,
+// resolved against baseURL.
+func adjacentLinks(doc *goquery.Document, baseURL string) (prev, next string) {
+	find := func(rel string) string {
+		var href string
+		doc.Find("link[href], a[href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			if !hasRel(s.AttrOr("rel", ""), rel) {
+				return true
+			}
+			href = resolveURL(baseURL, s.AttrOr("href", ""))
+			return href == ""
+		})
+		return href
+	}
+	return cmp.Or(find("prev"), find("previous")), find("next")
+}
+
+// hasRel reports whether the space-separated rel attribute contains value.
+func hasRel(rel, value string) bool {
+	for token := range strings.FieldsSeq(strings.ToLower(rel)) {
+		if token == value {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveURL resolves href against base, returning href unchanged when
+// base is empty or invalid.
+func resolveURL(base, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil || base == "" {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return baseURL.ResolveReference(ref).String()
+}
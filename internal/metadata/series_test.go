@@ -0,0 +1,130 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractSeriesFromPartMarkers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		title string
+		html  string
+		want  *Series
+	}{
+		{
+			name:  "title with total",
+			title: "Rust Internals, Part 2 of 5",
+			html:  `<html><body><h1>Rust Internals, Part 2 of 5</h1></body></html>`,
+			want:  &Series{Name: "Rust Internals", Position: 2, Total: 5},
+		},
+		{
+			name:  "roman numeral and word total",
+			title: "The Long Road – Part III of five",
+			html:  `<html><body></body></html>`,
+			want:  &Series{Name: "The Long Road", Position: 3, Total: 5},
+		},
+		{
+			name:  "title fraction",
+			title: "Building a compiler (3/4)",
+			html:  `<html><body></body></html>`,
+			want:  &Series{Name: "Building a compiler", Position: 3, Total: 4},
+		},
+		{
+			name:  "series box numbers with heading name",
+			title: "Borrow checking",
+			html: `<html><head><link rel="prev" href="/rust/part-1"></head><body>
+				<div class="series-nav">This article is part 2 of 3 in a series.
+				<a rel="next" href="part-3">Next</a></div></body></html>`,
+			want: &Series{Position: 2, Total: 3, PrevURL: "https://example.com/rust/part-1", NextURL: "https://example.com/rust/part-3"},
+		},
+		{
+			name:  "series box without numbers",
+			title: "Borrow checking",
+			html:  `<html><body><nav id="post-series"><a rel="prev" href="https://example.com/one">Previous</a></nav></body></html>`,
+			want:  &Series{PrevURL: "https://example.com/one"},
+		},
+		{
+			name:  "neighboring posts alone are not a series",
+			title: "Standalone post",
+			html:  `<html><head><link rel="next" href="/next-post"></head><body><h1>Standalone post</h1></body></html>`,
+			want:  nil,
+		},
+		{
+			name:  "part higher than total",
+			title: "Something, Part 6 of 5",
+			html:  `<html><body></body></html>`,
+			want:  nil,
+		},
+		{
+			name:  "part of a phrase",
+			title: "Being part of the team",
+			html:  `<html><body></body></html>`,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := mustMetadataDocument(t, tt.html)
+			got := ExtractSeries(doc, nil, tt.title, "https://example.com/rust/part-2")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ExtractSeries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSeriesFromSchema(t *testing.T) {
+	t.Parallel()
+
+	doc := mustMetadataDocument(t, `<html><head><link rel="next" href="/ep-4"></head><body></body></html>`)
+
+	tests := []struct {
+		name   string
+		schema any
+		want   *Series
+	}{
+		{
+			name: "partOfSeries",
+			schema: []any{map[string]any{
+				"@type":         "PodcastEpisode",
+				"episodeNumber": "3",
+				"partOfSeries":  map[string]any{"@type": "PodcastSeries", "name": "Go Time", "numberOfEpisodes": float64(10)},
+			}},
+			want: &Series{Name: "Go Time", Position: 3, Total: 10, NextURL: "https://example.com/ep-4"},
+		},
+		{
+			name: "isPartOf series",
+			schema: map[string]any{
+				"@type":    "Article",
+				"position": float64(2),
+				"isPartOf": []any{map[string]any{"@type": "CreativeWorkSeries", "name": "Deep Dives"}},
+			},
+			want: &Series{Name: "Deep Dives", Position: 2, NextURL: "https://example.com/ep-4"},
+		},
+		{
+			name: "isPartOf website",
+			schema: map[string]any{
+				"@type":    "Article",
+				"isPartOf": map[string]any{"@type": "WebSite", "name": "Example"},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ExtractSeries(doc, tt.schema, "Episode", "https://example.com/ep-3")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ExtractSeries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
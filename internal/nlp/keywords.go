@@ -0,0 +1,127 @@
+package nlp
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultKeywordLimit is the number of keywords returned when a caller does
+// not set a limit.
+const DefaultKeywordLimit = 10
+
+// maxPhraseWords drops long stopword-free runs that are rarely useful keywords.
+const maxPhraseWords = 4
+
+// Keyword is a ranked keyword or entity found in the content.
+type Keyword struct {
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+}
+
+// KeywordExtractor extracts ranked keywords from the cleaned text of
+// extracted content, one block per line. External named-entity recognizers
+// can implement it to replace or complement the built-in extractor.
+type KeywordExtractor interface {
+	ExtractKeywords(ctx context.Context, text string, limit int) ([]Keyword, error)
+}
+
+// RAKEExtractor ranks stopword-delimited phrases with the RAKE algorithm:
+// each word scores degree/frequency and a phrase scores the sum of its words.
+// Scores are normalized so the best keyword scores 1.
+type RAKEExtractor struct{}
+
+// NewRAKEExtractor creates the built-in keyword extractor.
+func NewRAKEExtractor() *RAKEExtractor {
+	return &RAKEExtractor{}
+}
+
+// ExtractKeywords implements KeywordExtractor.
+func (e *RAKEExtractor) ExtractKeywords(ctx context.Context, text string, limit int) ([]Keyword, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultKeywordLimit
+	}
+
+	phrases := candidatePhrases(text)
+
+	frequency := make(map[string]int)
+	degree := make(map[string]int)
+	for _, phrase := range phrases {
+		for _, word := range phrase {
+			frequency[word]++
+			degree[word] += len(phrase) - 1
+		}
+	}
+
+	scores := make(map[string]float64)
+	var order []string
+	for _, phrase := range phrases {
+		key := strings.Join(phrase, " ")
+		if _, seen := scores[key]; seen {
+			// Repeated phrases rank higher than one-off phrases of equal weight.
+			scores[key] += 0.5
+			continue
+		}
+		var score float64
+		for _, word := range phrase {
+			score += float64(degree[word]+frequency[word]) / float64(frequency[word])
+		}
+		scores[key] = score
+		order = append(order, key)
+	}
+
+	keywords := make([]Keyword, 0, len(order))
+	for _, key := range order {
+		keywords = append(keywords, Keyword{Text: key, Score: scores[key]})
+	}
+	slices.SortStableFunc(keywords, func(a, b Keyword) int { return cmp.Compare(b.Score, a.Score) })
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	if len(keywords) > 0 && keywords[0].Score > 0 {
+		top := keywords[0].Score
+		for i := range keywords {
+			keywords[i].Score /= top
+		}
+	}
+	return keywords, nil
+}
+
+// candidatePhrases splits text at punctuation and stopwords into runs of
+// content words.
+func candidatePhrases(text string) [][]string {
+	var phrases [][]string
+	var current []string
+	flush := func() {
+		if len(current) > 0 && len(current) <= maxPhraseWords {
+			phrases = append(phrases, current)
+		}
+		current = nil
+	}
+
+	for _, fragment := range strings.FieldsFunc(text, isPhraseDelimiter) {
+		for _, word := range Words(fragment) {
+			word = strings.Trim(word, "'")
+			if word == "" || IsStopword(word) || len([]rune(word)) < 2 || isNumber(word) {
+				flush()
+				continue
+			}
+			current = append(current, word)
+		}
+		flush()
+	}
+	return phrases
+}
+
+func isPhraseDelimiter(r rune) bool {
+	return r == '\n' || (unicode.IsPunct(r) && r != '\'')
+}
+
+func isNumber(word string) bool {
+	return strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
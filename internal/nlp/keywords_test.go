@@ -0,0 +1,45 @@
+package nlp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRAKEExtractorRanksRepeatedPhrases(t *testing.T) {
+	t.Parallel()
+
+	text := "Static site generators are popular.\n" +
+		"Many teams use static site generators for documentation.\n" +
+		"The output is plain HTML, and deploys are fast."
+
+	keywords, err := NewRAKEExtractor().ExtractKeywords(context.Background(), text, 3)
+	if err != nil {
+		t.Fatalf("ExtractKeywords() error = %v", err)
+	}
+	if len(keywords) != 3 {
+		t.Fatalf("ExtractKeywords() returned %d keywords, want 3", len(keywords))
+	}
+	if keywords[0].Text != "static site generators" || keywords[0].Score != 1 {
+		t.Fatalf("ExtractKeywords()[0] = %+v, want static site generators with score 1", keywords[0])
+	}
+	for i := 1; i < len(keywords); i++ {
+		if keywords[i].Score > keywords[i-1].Score {
+			t.Fatalf("ExtractKeywords() not sorted by score: %+v", keywords)
+		}
+		if keywords[i].Text == "the" || keywords[i].Text == "is" {
+			t.Fatalf("ExtractKeywords() returned stopword %q", keywords[i].Text)
+		}
+	}
+}
+
+func TestRAKEExtractorSkipsNumbersAndHonorsDefaultLimit(t *testing.T) {
+	t.Parallel()
+
+	keywords, err := NewRAKEExtractor().ExtractKeywords(context.Background(), "2024. 42, 7", 0)
+	if err != nil {
+		t.Fatalf("ExtractKeywords() error = %v", err)
+	}
+	if len(keywords) != 0 {
+		t.Fatalf("ExtractKeywords() = %+v, want no numeric keywords", keywords)
+	}
+}
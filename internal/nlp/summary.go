@@ -0,0 +1,127 @@
+package nlp
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+)
+
+// DefaultSummaryLength is the target summary length, in words, used when a
+// caller does not set one.
+const DefaultSummaryLength = 60
+
+// minSentenceWords skips fragments such as captions and bylines when ranking.
+const minSentenceWords = 4
+
+// Summarizer produces a summary from the text blocks of extracted content.
+// targetWords is the desired summary length in words; implementations may
+// return somewhat more or less. Implementations may call remote services and
+// should honor ctx cancellation.
+type Summarizer interface {
+	Summarize(ctx context.Context, blocks []string, targetWords int) (string, error)
+}
+
+// ExtractiveSummarizer ranks sentences by TF-IDF cosine similarity to the
+// document centroid and returns the best ones in document order.
+type ExtractiveSummarizer struct{}
+
+// NewExtractiveSummarizer creates a built-in extractive summarizer.
+func NewExtractiveSummarizer() *ExtractiveSummarizer {
+	return &ExtractiveSummarizer{}
+}
+
+// rankedSentence is a candidate sentence with its position and score.
+type rankedSentence struct {
+	text  string
+	index int
+	words int
+	score float64
+}
+
+// Summarize implements Summarizer.
+func (s *ExtractiveSummarizer) Summarize(ctx context.Context, blocks []string, targetWords int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if targetWords <= 0 {
+		targetWords = DefaultSummaryLength
+	}
+
+	var sentences []rankedSentence
+	var terms []map[string]float64
+	documentFrequency := make(map[string]int)
+	for _, block := range blocks {
+		for _, sentence := range Sentences(block) {
+			words := Words(sentence)
+			if len(words) < minSentenceWords {
+				continue
+			}
+			tf := make(map[string]float64)
+			for _, word := range words {
+				if !IsStopword(word) {
+					tf[word]++
+				}
+			}
+			for term := range tf {
+				documentFrequency[term]++
+			}
+			sentences = append(sentences, rankedSentence{text: sentence, index: len(sentences), words: len(words)})
+			terms = append(terms, tf)
+		}
+	}
+	if len(sentences) == 0 {
+		return "", nil
+	}
+
+	// Weight each sentence vector by inverse sentence frequency and build the
+	// centroid of the whole document.
+	centroid := make(map[string]float64)
+	count := float64(len(sentences))
+	for _, tf := range terms {
+		for term, freq := range tf {
+			weight := freq * (math.Log(count/float64(documentFrequency[term])) + 1)
+			tf[term] = weight
+			centroid[term] += weight
+		}
+	}
+	for i, tf := range terms {
+		sentences[i].score = cosine(tf, centroid)
+	}
+
+	ranked := slices.Clone(sentences)
+	slices.SortStableFunc(ranked, func(a, b rankedSentence) int { return cmp.Compare(b.score, a.score) })
+
+	var selected []rankedSentence
+	words := 0
+	for _, sentence := range ranked {
+		if words >= targetWords {
+			break
+		}
+		selected = append(selected, sentence)
+		words += sentence.words
+	}
+	slices.SortFunc(selected, func(a, b rankedSentence) int { return cmp.Compare(a.index, b.index) })
+
+	parts := make([]string, len(selected))
+	for i, sentence := range selected {
+		parts[i] = sentence.text
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, weight := range a {
+		dot += weight * b[term]
+		normA += weight * weight
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,46 @@
+package nlp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractiveSummarizerPicksCentralSentencesInOrder(t *testing.T) {
+	t.Parallel()
+
+	blocks := []string{
+		"Solar panels convert sunlight into electricity for homes. The weather was pleasant on Tuesday afternoon.",
+		"Rooftop solar panels lower electricity bills for homes. My cousin enjoys knitting scarves in winter.",
+		"Battery storage lets homes keep solar electricity for the evening.",
+	}
+
+	summary, err := NewExtractiveSummarizer().Summarize(context.Background(), blocks, 20)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if strings.Contains(summary, "knitting") || strings.Contains(summary, "weather") {
+		t.Fatalf("Summarize() = %q, want off-topic sentences dropped", summary)
+	}
+	first := strings.Index(summary, "Solar panels convert")
+	second := strings.Index(summary, "Rooftop solar panels")
+	if first < 0 || second < 0 || first > second {
+		t.Fatalf("Summarize() = %q, want central sentences in document order", summary)
+	}
+}
+
+func TestExtractiveSummarizerHandlesEmptyInputAndCancellation(t *testing.T) {
+	t.Parallel()
+
+	summary, err := NewExtractiveSummarizer().Summarize(context.Background(), []string{"Too short."}, 0)
+	if err != nil || summary != "" {
+		t.Fatalf("Summarize() = %q, %v, want empty summary", summary, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewExtractiveSummarizer().Summarize(ctx, []string{"Some text here to rank."}, 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Summarize() error = %v, want context.Canceled", err)
+	}
+}
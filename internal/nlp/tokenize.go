@@ -0,0 +1,108 @@
+// Package nlp provides lightweight text analysis over extracted content,
+// including block and sentence splitting, extractive summarization, and
+// keyword extraction.
+package nlp
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// blockSelector matches the elements whose text forms a content block.
+const blockSelector = "p, li, blockquote, dd, figcaption, td"
+
+// Blocks returns the trimmed text of each leaf block element in htmlContent.
+// Nested blocks are reported once, by the innermost element. When the content
+// has no block elements, its whole text is returned as a single block.
+func Blocks(htmlContent string) []string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil
+	}
+
+	var blocks []string
+	doc.Find(blockSelector).Each(func(_ int, s *goquery.Selection) {
+		if s.Find(blockSelector).Length() > 0 {
+			return
+		}
+		if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+			blocks = append(blocks, text)
+		}
+	})
+
+	if len(blocks) == 0 {
+		if text := strings.Join(strings.Fields(doc.Text()), " "); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+	return blocks
+}
+
+// Sentences splits text into sentences at '.', '!', or '?' followed by
+// whitespace and an upper-case letter, digit, or opening quote.
+func Sentences(text string) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var sentences []string
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '.' && runes[i] != '!' && runes[i] != '?' {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(`.!?"'”’)`, runes[end]) {
+			end++
+		}
+		next := end
+		for next < len(runes) && unicode.IsSpace(runes[next]) {
+			next++
+		}
+		if next == end || next >= len(runes) {
+			continue
+		}
+		if r := runes[next]; !unicode.IsUpper(r) && !unicode.IsDigit(r) && !strings.ContainsRune(`"'“‘(`, r) {
+			continue
+		}
+		if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = next
+		i = next - 1
+	}
+	if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// Words returns the lower-cased letter and digit runs in text.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// IsStopword reports whether word is a common English function word.
+func IsStopword(word string) bool {
+	_, ok := stopwords[word]
+	return ok
+}
+
+var stopwords = func() map[string]struct{} {
+	words := strings.Fields(`a about above after again against all also am an and any are as at
+		be because been before being below between both but by can could did do does doing down
+		during each either even ever every few for from further get gets got had has have having
+		he her here hers herself him himself his how however i if in into is it it's its itself
+		just like made make makes many may me might more most much must my myself no nor not now
+		of off often on once one only or other our ours ourselves out over own per same she should
+		since so some still such than that the their theirs them themselves then there these they
+		this those though through to too under until up upon us use used uses using very via was
+		we well were what when where whether which while who whom whose why will with within
+		without would yet you your yours yourself yourselves`)
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		set[word] = struct{}{}
+	}
+	return set
+}()
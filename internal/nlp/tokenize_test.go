@@ -0,0 +1,43 @@
+package nlp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBlocksReturnsInnermostBlockText(t *testing.T) {
+	t.Parallel()
+
+	got := Blocks(`<div><p>First   paragraph.</p><ul><li>Item <p>nested</p></li><li>Second item</li></ul><blockquote>Quote</blockquote></div>`)
+	want := []string{"First paragraph.", "nested", "Second item", "Quote"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Blocks() = %q, want %q", got, want)
+	}
+
+	if got := Blocks(`<div>Plain <b>text</b></div>`); !slices.Equal(got, []string{"Plain text"}) {
+		t.Fatalf("Blocks() without block elements = %q, want whole text", got)
+	}
+}
+
+func TestSentencesSplitsOnTerminalPunctuation(t *testing.T) {
+	t.Parallel()
+
+	got := Sentences(`Go 1.22 shipped range-over-int. Did it help? "Yes," they said! version 2.0 is next.`)
+	want := []string{"Go 1.22 shipped range-over-int.", "Did it help?", `"Yes," they said! version 2.0 is next.`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Sentences() = %q, want %q", got, want)
+	}
+}
+
+func TestWordsLowercasesAndDropsPunctuation(t *testing.T) {
+	t.Parallel()
+
+	got := Words("Hello, World! It's 2024.")
+	want := []string{"hello", "world", "it's", "2024"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Words() = %q, want %q", got, want)
+	}
+	if !IsStopword("the") || IsStopword("world") {
+		t.Fatal("IsStopword() misclassified a word")
+	}
+}
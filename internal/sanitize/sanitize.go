@@ -0,0 +1,215 @@
+// Package sanitize cleans extracted HTML against a strict allowlist so it
+// can be embedded directly into another page.
+package sanitize
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// droppedElements are removed together with everything inside them.
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "noscript": true, "template": true,
+	"link": true, "meta": true, "base": true, "title": true, "form": true,
+	"input": true, "button": true, "select": true, "option": true, "textarea": true,
+	"svg": true, "canvas": true, "dialog": true, "portal": true, "xmp": true,
+	"plaintext": true, "noembed": true, "noframes": true, "annotation-xml": true,
+}
+
+// allowedElements are kept; any other element is replaced by its children.
+var allowedElements = map[string]bool{
+	"a": true, "abbr": true, "article": true, "aside": true, "audio": true,
+	"b": true, "bdi": true, "bdo": true, "blockquote": true, "br": true,
+	"caption": true, "cite": true, "code": true, "col": true, "colgroup": true,
+	"dd": true, "del": true, "details": true, "dfn": true, "div": true,
+	"dl": true, "dt": true, "em": true, "figcaption": true, "figure": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "header": true, "hr": true, "i": true, "img": true, "ins": true,
+	"kbd": true, "li": true, "main": true, "mark": true, "ol": true, "p": true,
+	"picture": true, "pre": true, "q": true, "rp": true, "rt": true,
+	"ruby": true, "s": true, "samp": true, "section": true, "small": true,
+	"source": true, "span": true, "strong": true, "sub": true, "summary": true,
+	"sup": true, "table": true, "tbody": true, "td": true, "tfoot": true,
+	"th": true, "thead": true, "time": true, "tr": true, "track": true,
+	"u": true, "ul": true, "var": true, "video": true, "wbr": true,
+
+	// MathML presentation elements
+	"math": true, "annotation": true, "menclose": true, "merror": true,
+	"mfrac": true, "mi": true, "mmultiscripts": true, "mn": true, "mo": true,
+	"mover": true, "mpadded": true, "mphantom": true, "mprescripts": true,
+	"mroot": true, "mrow": true, "ms": true, "mspace": true, "msqrt": true,
+	"mstyle": true, "msub": true, "msubsup": true, "msup": true, "mtable": true,
+	"mtd": true, "mtext": true, "mtr": true, "munder": true, "munderover": true,
+	"semantics": true,
+}
+
+// allowedAttributes are kept on any allowed element.
+var allowedAttributes = map[string]bool{
+	"alt": true, "colspan": true, "datetime": true, "dir": true, "headers": true,
+	"height": true, "id": true, "lang": true, "rowspan": true, "scope": true,
+	"title": true, "width": true,
+
+	// MathML presentation attributes
+	"accent": true, "accentunder": true, "align": true, "columnalign": true,
+	"columnlines": true, "columnspacing": true, "columnspan": true, "depth": true,
+	"display": true, "displaystyle": true, "encoding": true, "fence": true,
+	"frame": true, "framespacing": true, "linethickness": true, "lspace": true,
+	"mathsize": true, "mathvariant": true, "maxsize": true, "minsize": true,
+	"movablelimits": true, "notation": true, "rowalign": true, "rowlines": true,
+	"rowspacing": true, "rspace": true, "scriptlevel": true, "separator": true,
+	"stretchy": true, "symmetric": true, "voffset": true,
+}
+
+// elementAttributes are kept only on the named element.
+var elementAttributes = map[string]map[string]bool{
+	"a":          {"href": true},
+	"audio":      {"src": true, "controls": true},
+	"blockquote": {"cite": true},
+	"del":        {"cite": true},
+	"details":    {"open": true},
+	"figure":     {"data-credit": true},
+	"img":        {"src": true, "srcset": true, "sizes": true},
+	"ins":        {"cite": true},
+	"li":         {"value": true},
+	"ol":         {"start": true, "reversed": true, "type": true},
+	"q":          {"cite": true},
+	"source":     {"src": true, "srcset": true, "sizes": true, "type": true, "media": true},
+	"track":      {"src": true, "kind": true, "srclang": true, "label": true},
+	"video":      {"src": true, "controls": true, "poster": true},
+}
+
+// urlAttributes hold a URL whose scheme must be safe.
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "poster": true,
+}
+
+// safeSchemes are the URL schemes kept in URL attributes. Relative URLs
+// have no scheme and are always kept.
+var safeSchemes = map[string]bool{
+	"http": true, "https": true, "mailto": true, "tel": true,
+}
+
+// HTML returns content with every element, attribute, and URL outside the
+// allowlist removed. Scripts, styles, frames, embeds, and forms are dropped
+// with their contents; other unknown elements are replaced by their
+// children. Event handlers, inline styles, and classes are stripped, and so
+// are URLs with a scheme other than http, https, mailto, or tel, except
+// raster data: images on img. Comments are removed.
+//
+// HTML fails closed: content that cannot be parsed yields "".
+func HTML(content string) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return ""
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	sanitizeChildren(body)
+
+	var b strings.Builder
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&b, n); err != nil {
+			return ""
+		}
+	}
+	return b.String()
+}
+
+// sanitizeChildren cleans the subtree below n in place.
+func sanitizeChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			name := strings.ToLower(c.Data)
+			if droppedElements[name] {
+				n.RemoveChild(c)
+				break
+			}
+			sanitizeChildren(c)
+			if !allowedElements[name] {
+				// Unwrap: the children are already clean, so the loop moves
+				// on past them
+				for child := c.FirstChild; child != nil; child = c.FirstChild {
+					c.RemoveChild(child)
+					n.InsertBefore(child, c)
+				}
+				n.RemoveChild(c)
+				break
+			}
+			c.Attr = sanitizeAttributes(name, c.Attr)
+		default:
+			n.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+// sanitizeAttributes returns the allowed attributes of an element.
+func sanitizeAttributes(element string, attrs []html.Attribute) []html.Attribute {
+	kept := attrs[:0]
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || (!allowedAttributes[key] && !elementAttributes[element][key]) {
+			continue
+		}
+		switch {
+		case urlAttributes[key]:
+			if !isSafeURL(attr.Val, element == "img" && key == "src") {
+				continue
+			}
+		case key == "srcset":
+			if !isSafeSrcset(attr.Val) {
+				continue
+			}
+		}
+		kept = append(kept, html.Attribute{Key: key, Val: attr.Val})
+	}
+	return kept
+}
+
+// SafeURL reports whether value is relative or uses the http, https,
+// mailto, or tel scheme, and so may be kept as a link.
+func SafeURL(value string) bool {
+	return isSafeURL(value, false)
+}
+
+// isSafeURL reports whether value is relative or uses a safe scheme. With
+// allowImageData, data: URLs of raster images are safe too.
+func isSafeURL(value string, allowImageData bool) bool {
+	// Browsers ignore whitespace and control characters inside a scheme
+	normalized := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.ToLower(value))
+
+	colon := strings.IndexByte(normalized, ':')
+	if colon < 0 || strings.ContainsAny(normalized[:colon], "/?#") {
+		return true
+	}
+	scheme := normalized[:colon]
+	if safeSchemes[scheme] {
+		return true
+	}
+	return allowImageData && scheme == "data" &&
+		strings.HasPrefix(normalized, "data:image/") && !strings.HasPrefix(normalized, "data:image/svg")
+}
+
+// isSafeSrcset reports whether every candidate URL in a srcset is safe.
+func isSafeSrcset(value string) bool {
+	for candidate := range strings.SplitSeq(value, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 && !isSafeURL(fields[0], false) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package sanitize
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "dropped elements",
+			html: `<p>Text</p><script>alert(1)</script><style>p{}</style><iframe src="https://example.com"></iframe><form><input name="q"><button>Go</button></form>`,
+			want: `<p>Text</p>`,
+		},
+		{
+			name: "unknown elements unwrapped",
+			html: `<custom-card><p>Kept <font color="red">text</font></p></custom-card>`,
+			want: `<p>Kept text</p>`,
+		},
+		{
+			name: "event handlers, styles, and classes",
+			html: `<p onclick="alert(1)" style="color:red" class="lead" id="intro" title="Intro">Text</p>`,
+			want: `<p id="intro" title="Intro">Text</p>`,
+		},
+		{
+			name: "javascript URLs",
+			html: `<a href="javascript:alert(1)">a</a><a href=" JaVa&#x09;script:alert(1)">b</a><a href="/docs?x=a:b">c</a><a href="https://example.com">d</a>`,
+			want: `<a>a</a><a>b</a><a href="/docs?x=a:b">c</a><a href="https://example.com">d</a>`,
+		},
+		{
+			name: "image URLs",
+			html: `<img src="data:image/png;base64,AAAA" alt="dot"><img src="data:image/svg+xml,<svg onload=alert(1)>"><img srcset="a.jpg 1x, javascript:alert(1) 2x" src="a.jpg"><a href="data:text/html,x">x</a>`,
+			want: `<img src="data:image/png;base64,AAAA" alt="dot"/><img/><img src="a.jpg"/><a>x</a>`,
+		},
+		{
+			name: "element-specific attributes",
+			html: `<p href="https://example.com" src="x.jpg">Text</p><ol start="3"><li value="3">Item</li></ol>`,
+			want: `<p>Text</p><ol start="3"><li value="3">Item</li></ol>`,
+		},
+		{
+			name: "comments and svg",
+			html: `<p>A<!-- note -->B</p><svg><script>alert(1)</script></svg>`,
+			want: `<p>AB</p>`,
+		},
+		{
+			name: "math kept",
+			html: `<math display="block"><mi mathvariant="bold" onclick="x">x</mi></math>`,
+			want: `<math display="block"><mi mathvariant="bold">x</mi></math>`,
+		},
+		{
+			name: "escaped text stays escaped",
+			html: `<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>`,
+			want: `<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := HTML(tt.html); got != tt.want {
+				t.Fatalf("HTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
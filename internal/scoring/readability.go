@@ -0,0 +1,147 @@
+package scoring
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Strategy selects how the main content is found.
+type Strategy string
+
+const (
+	// StrategyDefuddle tries entry-point selectors, then table heuristics,
+	// then ScoreElement over block candidates
+	StrategyDefuddle Strategy = "defuddle"
+	// StrategyReadability picks the ancestor of the densest paragraphs,
+	// scored like Mozilla Readability
+	StrategyReadability Strategy = "readability"
+)
+
+// Class and id weights from Mozilla Readability
+var (
+	readabilityPositive = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	readabilityNegative = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|footer|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|widget`)
+)
+
+// readabilityBlocks are the children that keep a div from being scored as
+// a paragraph
+const readabilityBlocks = "a, blockquote, dl, div, img, ol, p, pre, table, ul, section, article, header, footer, aside, nav"
+
+// FindReadabilityCandidate returns the element Mozilla Readability would
+// pick as the article, or nil when no paragraph has enough text. It returns
+// ctx.Err() when ctx is cancelled during scoring.
+//
+// Every p, pre, td, and div without block children that holds at least 25
+// characters scores one point, one per comma, and one per 100 characters
+// up to three. Each of its first five ancestors receives that score divided
+// by 1, 2, then three times the level, on top of a base score from its
+// tag name and class and id. The candidate with the highest score after
+// scaling by one minus its link density wins.
+func FindReadabilityCandidate(ctx context.Context, doc *goquery.Document) (*goquery.Selection, error) {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+
+	var err error
+	doc.Find("p, pre, td, div").EachWithBreak(func(i int, element *goquery.Selection) bool {
+		if i%cancelCheckInterval == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		if goquery.NodeName(element) == "div" && element.Children().Filter(readabilityBlocks).Length() > 0 {
+			return true
+		}
+		text := strings.TrimSpace(element.Text())
+		if len(text) < 25 {
+			return true
+		}
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+
+		level := 0
+		for n := element.Get(0).Parent; n != nil && n.Type == html.ElementNode && level < 5; n = n.Parent {
+			if n.Data == "body" || n.Data == "html" {
+				break
+			}
+			if _, ok := scores[n]; !ok {
+				scores[n] = readabilityBaseScore(n)
+				order = append(order, n)
+			}
+			divider := 1.0
+			switch level {
+			case 0:
+			case 1:
+				divider = 2
+			default:
+				divider = float64(level) * 3
+			}
+			scores[n] += score / divider
+			level++
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var best *html.Node
+	bestScore := 0.0
+	for i, n := range order {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		score := scores[n] * (1 - linkDensity(doc.FindNodes(n)))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	return doc.FindNodes(best), nil
+}
+
+// readabilityBaseScore scores an element by its tag name, class, and id
+func readabilityBaseScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.Data {
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	for _, attr := range n.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if readabilityNegative.MatchString(attr.Val) {
+			score -= 25
+		}
+		if readabilityPositive.MatchString(attr.Val) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// linkDensity is the share of the element's text inside links
+func linkDensity(element *goquery.Selection) float64 {
+	textLength := len(strings.TrimSpace(element.Text()))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	element.Find("a").Each(func(_ int, link *goquery.Selection) {
+		linkLength += len(strings.TrimSpace(link.Text()))
+	})
+	return float64(linkLength) / float64(textLength)
+}
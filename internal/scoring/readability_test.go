@@ -0,0 +1,61 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFindReadabilityCandidatePicksDenseParagraphs(t *testing.T) {
+	t.Parallel()
+
+	paragraph := `<p>` + strings.Repeat(`Readability rewards long paragraphs, with commas, clauses, and detail. `, 3) + `</p>`
+	doc := newScoringDocument(t, `<html><body>
+		<div id="menu" class="sidebar"><p><a href="/one">A link with more than twenty-five characters</a></p></div>
+		<div id="story">`+strings.Repeat(paragraph, 4)+`</div>
+		<div id="comments" class="comment"><p>A reader comment that is long enough to score, barely.</p></div>
+	</body></html>`)
+
+	got, err := FindReadabilityCandidate(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("FindReadabilityCandidate() error = %v", err)
+	}
+	if got == nil || got.AttrOr("id", "") != "story" {
+		t.Fatalf("FindReadabilityCandidate() = %v, want #story", got)
+	}
+}
+
+func TestFindReadabilityCandidateNeedsText(t *testing.T) {
+	t.Parallel()
+
+	doc := newScoringDocument(t, `<html><body><div><p>Too short.</p></div></body></html>`)
+	if got, err := FindReadabilityCandidate(context.Background(), doc); got != nil || err != nil {
+		t.Fatalf("FindReadabilityCandidate() = %v, %v, want nil", got, err)
+	}
+}
+
+func TestFindReadabilityCandidateStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	paragraph := `<p>` + strings.Repeat(`Enough text, with commas, to score as a paragraph. `, 2) + `</p>`
+	doc := newScoringDocument(t, `<html><body><div id="story">`+strings.Repeat(paragraph, 10)+`</div></body></html>`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if got, err := FindReadabilityCandidate(ctx, doc); got != nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("FindReadabilityCandidate() = %v, %v, want context.Canceled", got, err)
+	}
+}
+
+func TestReadabilityBaseScoreWeighsTagAndClass(t *testing.T) {
+	t.Parallel()
+
+	doc := newScoringDocument(t, `<html><body><div id="a" class="post-content"></div><ul id="b" class="related"></ul></body></html>`)
+	if got := readabilityBaseScore(doc.Find("#a").Get(0)); got != 30 {
+		t.Errorf("readabilityBaseScore(div.post-content) = %v, want 30", got)
+	}
+	if got := readabilityBaseScore(doc.Find("#b").Get(0)); got != -28 {
+		t.Errorf("readabilityBaseScore(ul.related) = %v, want -28", got)
+	}
+}
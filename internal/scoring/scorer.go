@@ -3,16 +3,21 @@
 package scoring
 
 import (
+	"context"
 	"log/slog"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
 
 	"github.com/kaptinlin/defuddle-go/internal/constants"
+	"github.com/kaptinlin/defuddle-go/internal/dom"
 )
 
 // Pre-compiled regex patterns for content scoring.
@@ -22,6 +27,10 @@ var (
 	contentRoles = []string{"article", "main", "contentinfo"}
 )
 
+// cancelCheckInterval is the number of elements the scoring loops visit
+// between checks for a cancelled context.
+const cancelCheckInterval = 64
+
 // ContentScore represents a scored element
 // JavaScript original code:
 //
@@ -323,30 +332,43 @@ var nonContentPatterns = []string{
 //		return score;
 //	}
 func ScoreElement(element *goquery.Selection) float64 {
+	node := element.Get(0)
 	score := 0.0
 
+	// Count descendants in one walk instead of one selector query each
+	var paragraphs, links, images, nestedTables int
+	for el := range dom.Elements(node) {
+		switch dom.TagName(el) {
+		case "p":
+			paragraphs++
+		case "a":
+			links++
+		case "img":
+			images++
+		case "table":
+			nestedTables++
+		}
+	}
+
 	// Text density
-	text := strings.TrimSpace(element.Text())
+	text := strings.TrimSpace(dom.Text(node))
 	words := len(strings.Fields(text))
 	score += float64(words)
 
 	// Paragraph ratio
-	paragraphs := element.Find("p").Length()
 	score += float64(paragraphs) * 10
 
 	// Link density (penalize high link density)
-	links := element.Find("a").Length()
 	linkDensity := float64(links) / float64(max(words, 1))
 	score -= linkDensity * 5
 
 	// Image ratio (penalize high image density)
-	images := element.Find("img").Length()
 	imageDensity := float64(images) / float64(max(words, 1))
 	score -= imageDensity * 3
 
 	// Position bonus (center/right elements)
-	style, _ := element.Attr("style")
-	align, _ := element.Attr("align")
+	style, _ := dom.Attr(node, "style")
+	align, _ := dom.Attr(node, "align")
 	isRightSide := strings.Contains(style, "float: right") ||
 		strings.Contains(style, "text-align: right") ||
 		align == "right"
@@ -364,7 +386,7 @@ func ScoreElement(element *goquery.Selection) float64 {
 	}
 
 	// Check for common content classes/attributes
-	className := strings.ToLower(element.AttrOr("class", ""))
+	className := strings.ToLower(dom.AttrOr(node, "class", ""))
 	if strings.Contains(className, "content") ||
 		strings.Contains(className, "article") ||
 		strings.Contains(className, "post") {
@@ -372,40 +394,26 @@ func ScoreElement(element *goquery.Selection) float64 {
 	}
 
 	// Check for footnotes/references
-	footnoteSelectors := constants.GetFootnoteInlineReferences()
-	for _, selector := range footnoteSelectors {
-		if element.Find(selector).Length() > 0 {
-			score += 10
-			break
-		}
+	if containsMatch(node, footnoteInlineMatchers()) {
+		score += 10
 	}
-
-	footnoteListSelectors := constants.GetFootnoteListSelectors()
-	for _, selector := range footnoteListSelectors {
-		if element.Find(selector).Length() > 0 {
-			score += 10
-			break
-		}
+	if containsMatch(node, footnoteListMatchers()) {
+		score += 10
 	}
 
 	// Check for nested tables (penalize)
-	nestedTables := element.Find("table").Length()
 	score -= float64(nestedTables) * 5
 
 	// Additional scoring for table cells
-	if goquery.NodeName(element) == "td" {
-		parentTable := element.Closest("table")
-		if parentTable.Length() > 0 {
+	if dom.TagName(node) == "td" {
+		if parentTable := dom.Closest(node, "table"); parentTable != nil {
 			// Only favor cells in tables that look like old-style content layouts
-			widthStr, _ := parentTable.Attr("width")
 			tableWidth := 0
-			if widthStr != "" {
-				if w, err := strconv.Atoi(widthStr); err == nil {
-					tableWidth = w
-				}
+			if w, err := strconv.Atoi(dom.AttrOr(parentTable, "width", "")); err == nil {
+				tableWidth = w
 			}
-			tableAlign, _ := parentTable.Attr("align")
-			tableClass := strings.ToLower(parentTable.AttrOr("class", ""))
+			tableAlign, _ := dom.Attr(parentTable, "align")
+			tableClass := strings.ToLower(dom.AttrOr(parentTable, "class", ""))
 
 			isTableLayout := tableWidth > 400 || // Common width for main content tables
 				tableAlign == "center" ||
@@ -414,15 +422,18 @@ func ScoreElement(element *goquery.Selection) float64 {
 
 			if isTableLayout {
 				// Additional checks to ensure this is likely the main content cell
-				allCells := parentTable.Find("td")
-				cellIndex := -1
-				allCells.Each(func(i int, cell *goquery.Selection) {
-					if cell.Get(0) == element.Get(0) {
-						cellIndex = i
+				cellIndex, cellCount := -1, 0
+				for cell := range dom.Elements(parentTable) {
+					if dom.TagName(cell) != "td" {
+						continue
 					}
-				})
+					if cell == node {
+						cellIndex = cellCount
+					}
+					cellCount++
+				}
 
-				isCenterCell := cellIndex > 0 && cellIndex < allCells.Length()-1
+				isCenterCell := cellIndex > 0 && cellIndex < cellCount-1
 				if isCenterCell {
 					score += 10
 				}
@@ -433,6 +444,39 @@ func ScoreElement(element *goquery.Selection) float64 {
 	return score
 }
 
+// footnoteInlineMatchers and footnoteListMatchers compile the footnote
+// selectors once; selectors cascadia cannot parse never match, as in goquery.
+var (
+	footnoteInlineMatchers = sync.OnceValue(func() []cascadia.Matcher {
+		return compileMatchers(constants.GetFootnoteInlineReferences())
+	})
+	footnoteListMatchers = sync.OnceValue(func() []cascadia.Matcher {
+		return compileMatchers(constants.GetFootnoteListSelectors())
+	})
+)
+
+func compileMatchers(selectors []string) []cascadia.Matcher {
+	matchers := make([]cascadia.Matcher, 0, len(selectors))
+	for _, selector := range selectors {
+		if m, err := cascadia.Compile(selector); err == nil {
+			matchers = append(matchers, m)
+		}
+	}
+	return matchers
+}
+
+// containsMatch reports whether any descendant of n matches one of matchers.
+func containsMatch(n *html.Node, matchers []cascadia.Matcher) bool {
+	for el := range dom.Elements(n) {
+		for _, m := range matchers {
+			if m.Match(el) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // FindBestElement finds the best scoring element from a list
 // JavaScript original code:
 //
@@ -468,6 +512,103 @@ func FindBestElement(elements []*goquery.Selection, minScore float64) *goquery.S
 	return nil
 }
 
+// Options tunes ScoreAndRemove.
+type Options struct {
+	// KeepLinkLists keeps blocks that hold an intentional link list, such as
+	// a table of contents or a link roundup.
+	KeepLinkLists bool
+	// Content, when set, limits KeepLinkLists to lists inside it.
+	Content *goquery.Selection
+}
+
+// linkListChrome matches the page chrome whose link lists are navigation
+const linkListChrome = `nav, header, footer, aside, [role="navigation"], [role="menu"]`
+
+// linkListExcludes are class and id patterns of link lists that point
+// away from the page, such as related-post boxes
+var linkListExcludes = []string{
+	"related",
+	"recommended",
+	"popular",
+	"trending",
+	"more-",
+	"sidebar",
+	"share",
+	"social",
+	"widget",
+}
+
+// ContainsLinkList reports whether element is or holds an intentional link
+// list inside content, or anywhere when content is nil.
+func ContainsLinkList(element, content *goquery.Selection) bool {
+	found := false
+	lists := element.Filter("ul, ol").AddSelection(element.Find("ul, ol"))
+	lists.EachWithBreak(func(_ int, list *goquery.Selection) bool {
+		found = isLinkList(list, content)
+		return !found
+	})
+	return found
+}
+
+// isLinkList reports whether list is an intentional link list rather than
+// navigation: at least three items, nearly all of them linked, outside the
+// page chrome and related-post boxes, and either linking within the page
+// like a table of contents or carrying descriptive text like a link roundup
+func isLinkList(list, content *goquery.Selection) bool {
+	if list.Closest(linkListChrome).Length() > 0 {
+		return false
+	}
+	if content != nil && !containsNode(content, list) {
+		return false
+	}
+	for n := list; n.Length() > 0 && !n.Is("body"); n = n.Parent() {
+		className := strings.ToLower(n.AttrOr("class", ""))
+		id := strings.ToLower(n.AttrOr("id", ""))
+		if slices.ContainsFunc(linkListExcludes, func(pattern string) bool {
+			return strings.Contains(className, pattern) || strings.Contains(id, pattern)
+		}) {
+			return false
+		}
+	}
+
+	items := list.ChildrenFiltered("li")
+	if items.Length() < 3 {
+		return false
+	}
+	var linked, fragments, anchorWords, otherWords int
+	items.Each(func(_ int, item *goquery.Selection) {
+		anchor := item.Find("a[href]").First()
+		if anchor.Length() == 0 {
+			return
+		}
+		linked++
+		if strings.HasPrefix(anchor.AttrOr("href", ""), "#") {
+			fragments++
+		}
+		words := len(strings.Fields(anchor.Text()))
+		anchorWords += words
+		otherWords += max(len(strings.Fields(item.Text()))-words, 0)
+	})
+	if linked*5 < items.Length()*4 {
+		return false
+	}
+
+	isTableOfContents := fragments*5 >= linked*4
+	isDescriptive := anchorWords >= linked*3 || otherWords >= linked*3
+	return isTableOfContents || isDescriptive
+}
+
+// containsNode reports whether sel is inside, or is, the first node of container
+func containsNode(container, sel *goquery.Selection) bool {
+	root := container.Get(0)
+	for n := sel.Get(0); n != nil; n = n.Parent {
+		if n == root {
+			return true
+		}
+	}
+	return false
+}
+
 // ScoreAndRemove scores blocks and removes those that are likely not content
 // JavaScript original code:
 //
@@ -514,7 +655,7 @@ func FindBestElement(elements []*goquery.Selection, minScore float64) *goquery.S
 //			});
 //		}
 //	}
-func ScoreAndRemove(doc *goquery.Document, debug bool) {
+func ScoreAndRemove(ctx context.Context, doc *goquery.Document, debug bool, opts Options) error {
 	startTime := time.Now()
 	removedCount := 0
 
@@ -525,11 +666,23 @@ func ScoreAndRemove(doc *goquery.Document, debug bool) {
 	blockElements := constants.GetBlockElements()
 	blockSelector := strings.Join(blockElements, ",")
 
-	// Process each block element
-	doc.Find(blockSelector).Each(func(_ int, element *goquery.Selection) {
+	// Process each block element, removing nothing when cancelled
+	var err error
+	doc.Find(blockSelector).EachWithBreak(func(i int, element *goquery.Selection) bool {
+		if i%cancelCheckInterval == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+
 		// Skip elements that are likely to be content
 		if isLikelyContent(element) {
-			return
+			return true
+		}
+
+		// Skip intentional link lists, which score like navigation
+		if opts.KeepLinkLists && ContainsLinkList(element, opts.Content) {
+			return true
 		}
 
 		// Score the element based on various criteria
@@ -540,7 +693,11 @@ func ScoreAndRemove(doc *goquery.Document, debug bool) {
 			elementsToRemove = append(elementsToRemove, element)
 			removedCount++
 		}
+		return true
 	})
+	if err != nil {
+		return err
+	}
 
 	// Remove all collected elements in a single pass
 	for _, el := range elementsToRemove {
@@ -553,6 +710,7 @@ func ScoreAndRemove(doc *goquery.Document, debug bool) {
 			"count", removedCount,
 			"processingTime", processingTime)
 	}
+	return nil
 }
 
 // isLikelyContent determines if an element is likely to be content
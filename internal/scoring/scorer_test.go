@@ -1,6 +1,8 @@
 package scoring
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -86,7 +88,9 @@ func TestScoreAndRemoveRemovesNavigationButKeepsContent(t *testing.T) {
 		</article>
 	</body></html>`)
 
-	ScoreAndRemove(doc, false)
+	if err := ScoreAndRemove(context.Background(), doc, false, Options{}); err != nil {
+		t.Fatalf("ScoreAndRemove() error = %v", err)
+	}
 
 	if doc.Find("#nav").Length() != 0 {
 		t.Fatalf("ScoreAndRemove() did not remove navigation block: %q", doc.Find("body").Text())
@@ -96,6 +100,23 @@ func TestScoreAndRemoveRemovesNavigationButKeepsContent(t *testing.T) {
 	}
 }
 
+func TestScoreAndRemoveStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	doc := newScoringDocument(t, `<html><body><div id="nav" class="sidebar navigation"><ul>
+		<li><a href="/home">home</a></li><li><a href="/popular">popular</a></li>
+	</ul><p>menu navigation newsletter related trending popular subscribe privacy</p></div></body></html>`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := ScoreAndRemove(ctx, doc, false, Options{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ScoreAndRemove() error = %v, want context.Canceled", err)
+	}
+	if doc.Find("#nav").Length() != 1 {
+		t.Fatal("ScoreAndRemove() removed blocks after cancellation")
+	}
+}
+
 func TestScoreAndRemoveKeepsFootnotesAndOldTableContent(t *testing.T) {
 	t.Parallel()
 
@@ -115,7 +136,9 @@ func TestScoreAndRemoveKeepsFootnotesAndOldTableContent(t *testing.T) {
 		t.Fatalf("ScoreElement(story) = %v, left nav = %v, want center table content favored", storyScore, leftScore)
 	}
 
-	ScoreAndRemove(doc, false)
+	if err := ScoreAndRemove(context.Background(), doc, false, Options{}); err != nil {
+		t.Fatalf("ScoreAndRemove() error = %v", err)
+	}
 
 	if doc.Find("#story").Length() != 1 {
 		t.Fatal("ScoreAndRemove() removed center table story content")
@@ -147,3 +170,76 @@ func TestIsLikelyContentMatchesClassOrIDIndicator(t *testing.T) {
 		t.Fatal("isLikelyContent() matched element without content indicators")
 	}
 }
+
+func TestContainsLinkListDetectsIntentionalLists(t *testing.T) {
+	t.Parallel()
+
+	doc := newScoringDocument(t, `<html><body>
+		<nav id="menu"><ul><li><a href="/">Home</a></li><li><a href="/a">About</a></li><li><a href="/t">Tags</a></li></ul></nav>
+		<main>
+			<div id="roundup"><ul>
+				<li><a href="https://a.example">Why generics took a decade</a> – design history</li>
+				<li><a href="https://b.example">A tiny database from scratch</a> – storage engines</li>
+				<li><a href="https://c.example">Reading error messages well</a> – debugging advice</li>
+			</ul></div>
+			<div id="toc"><ol><li><a href="#one">One</a></li><li><a href="#two">Two</a></li><li><a href="#three">Three</a></li></ol></div>
+			<div id="terse"><ul><li><a href="/x">X</a></li><li><a href="/y">Y</a></li><li><a href="/z">Z</a></li></ul></div>
+			<div id="related-posts"><ul>
+				<li><a href="/p1">Another long post title here</a></li>
+				<li><a href="/p2">Yet another long post title</a></li>
+				<li><a href="/p3">One more long post title</a></li>
+			</ul></div>
+		</main>
+		<div id="outside"><ol><li><a href="#a">A</a></li><li><a href="#b">B</a></li><li><a href="#c">C</a></li></ol></div>
+	</body></html>`)
+	content := doc.Find("main")
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "roundup", want: true},
+		{id: "toc", want: true},
+		{id: "terse", want: false},
+		{id: "related-posts", want: false},
+		{id: "menu", want: false},
+		{id: "outside", want: false},
+	}
+	for _, tt := range tests {
+		if got := ContainsLinkList(doc.Find("#"+tt.id), content); got != tt.want {
+			t.Errorf("ContainsLinkList(#%s) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+	if !ContainsLinkList(doc.Find("#outside ol"), nil) {
+		t.Error("ContainsLinkList(list, nil) = false, want lists anywhere to count")
+	}
+}
+
+func TestScoreAndRemoveKeepLinkLists(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><body><article><p>` + strings.Repeat(`roundup introduction words `, 10) + `</p>
+		<div id="roundup"><ul>
+			<li><a href="https://a.example">Why generics took a decade</a></li>
+			<li><a href="https://b.example">A tiny database from scratch</a></li>
+			<li><a href="https://c.example">Reading error messages well</a></li>
+			<li><a href="https://d.example">Services in Rust and Go</a></li>
+		</ul></div>
+	</article></body></html>`
+
+	doc := newScoringDocument(t, html)
+	if err := ScoreAndRemove(context.Background(), doc, false, Options{}); err != nil {
+		t.Fatalf("ScoreAndRemove() error = %v", err)
+	}
+	if doc.Find("#roundup").Length() != 0 {
+		t.Fatal("ScoreAndRemove() kept the link list without KeepLinkLists")
+	}
+
+	doc = newScoringDocument(t, html)
+	if err := ScoreAndRemove(context.Background(), doc, false, Options{KeepLinkLists: true, Content: doc.Find("article")}); err != nil {
+		t.Fatalf("ScoreAndRemove() error = %v", err)
+	}
+	if doc.Find("#roundup").Length() != 1 {
+		t.Fatal("ScoreAndRemove() removed the link list with KeepLinkLists")
+	}
+}
@@ -0,0 +1,100 @@
+package standardize
+
+import (
+	"net/url"
+	"slices"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kaptinlin/defuddle-go/internal/dom"
+)
+
+// ampElements maps AMP components to the standard element that replaces
+// them, with the attributes carried over.
+var ampElements = map[string]struct {
+	tag   atom.Atom
+	attrs []string
+}{
+	"amp-img":    {atom.Img, []string{"src", "srcset", "sizes", "alt", "title", "width", "height"}},
+	"amp-anim":   {atom.Img, []string{"src", "srcset", "sizes", "alt", "title", "width", "height"}},
+	"amp-video":  {atom.Video, []string{"src", "poster", "width", "height", "title", "controls", "loop", "muted"}},
+	"amp-audio":  {atom.Audio, []string{"src", "title", "controls", "loop", "muted"}},
+	"amp-iframe": {atom.Iframe, []string{"src", "width", "height", "title", "allow", "allowfullscreen", "frameborder"}},
+}
+
+// convertAMPElements replaces the AMP components of element with the
+// standard tags they stand for, so AMP pages keep their media: amp-img and
+// amp-anim become img, amp-video video, amp-audio audio, amp-iframe iframe,
+// and amp-youtube a YouTube embed. Videos and audio keep their source and
+// track children; placeholders and fallbacks are dropped.
+func convertAMPElements(element *goquery.Selection) int {
+	var found []*html.Node
+	for _, root := range element.Nodes {
+		for el := range dom.Elements(root) {
+			if _, ok := ampElements[dom.TagName(el)]; ok || dom.TagName(el) == "amp-youtube" {
+				found = append(found, el)
+			}
+		}
+	}
+
+	// Innermost first, so a placeholder inside a component is dropped with
+	// it rather than converted after it
+	converted := 0
+	for _, el := range slices.Backward(found) {
+		if replacement := ampReplacement(el); replacement != nil {
+			el.Parent.InsertBefore(replacement, el)
+			dom.Remove(el)
+			converted++
+		}
+	}
+	return converted
+}
+
+// ampReplacement returns the standard element for an AMP component, or nil
+// when it names no media.
+func ampReplacement(el *html.Node) *html.Node {
+	tag := dom.TagName(el)
+	if tag == "amp-youtube" {
+		videoID := dom.AttrOr(el, "data-videoid", "")
+		if videoID == "" {
+			return nil
+		}
+		iframe := &html.Node{Type: html.ElementNode, Data: "iframe", DataAtom: atom.Iframe}
+		dom.SetAttr(iframe, "src", "https://www.youtube.com/embed/"+url.PathEscape(videoID))
+		dom.SetAttr(iframe, "title", dom.AttrOr(el, "title", "YouTube video player"))
+		for _, name := range []string{"width", "height"} {
+			if v, ok := dom.Attr(el, name); ok {
+				dom.SetAttr(iframe, name, v)
+			}
+		}
+		dom.SetAttr(iframe, "allowfullscreen", "")
+		return iframe
+	}
+
+	spec := ampElements[tag]
+	replacement := &html.Node{Type: html.ElementNode, Data: spec.tag.String(), DataAtom: spec.tag}
+	for _, name := range spec.attrs {
+		if v, ok := dom.Attr(el, name); ok {
+			dom.SetAttr(replacement, name, v)
+		}
+	}
+	if spec.tag == atom.Video || spec.tag == atom.Audio {
+		for child := range dom.Children(el) {
+			if name := dom.TagName(child); name == "source" || name == "track" {
+				dom.Remove(child)
+				replacement.AppendChild(child)
+			}
+		}
+		if _, ok := dom.Attr(replacement, "src"); !ok && replacement.FirstChild == nil {
+			return nil
+		}
+		dom.SetAttr(replacement, "controls", "")
+		return replacement
+	}
+	if _, ok := dom.Attr(replacement, "src"); !ok {
+		return nil
+	}
+	return replacement
+}
@@ -0,0 +1,52 @@
+package standardize
+
+import (
+	"context"
+	"testing"
+
+	internalmetadata "github.com/kaptinlin/defuddle-go/internal/metadata"
+)
+
+func TestContentConvertsAMPElements(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article>
+		<p>Lead paragraph of the story.</p>
+		<figure><amp-img src="/photo.jpg" srcset="/photo-2x.jpg 2x" alt="Harbor at dawn" width="800" height="600" layout="responsive">
+			<amp-img fallback src="/fallback.jpg" layout="fill"></amp-img></amp-img><figcaption>The harbor.</figcaption></figure>
+		<amp-video src="/clip.mp4" poster="/poster.jpg" width="640" height="360" autoplay>
+			<source src="/clip.webm" type="video/webm"><div fallback>Your browser does not play video.</div></amp-video>
+		<amp-youtube data-videoid="abc123" width="480" height="270" layout="responsive"></amp-youtube>
+		<p>Closing paragraph of the story.</p>
+	</article></body></html>`)
+	article := doc.Find("article").First()
+
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	if got := article.Find("amp-img, amp-video, amp-youtube").Length(); got != 0 {
+		t.Fatalf("AMP elements left = %d, want 0", got)
+	}
+	img := article.Find("figure img")
+	// The carried-over srcset is resolved: 2x of 800 pixels is the candidate
+	// for the default target width
+	if img.Length() != 1 || img.AttrOr("src", "") != "/photo-2x.jpg" || img.AttrOr("alt", "") != "Harbor at dawn" {
+		html, _ := article.Html()
+		t.Fatalf("figure img not converted from amp-img: %s", html)
+	}
+	if _, ok := img.Attr("layout"); ok {
+		t.Fatal("converted img kept the AMP layout attribute")
+	}
+	video := article.Find("video")
+	if video.AttrOr("src", "") != "/clip.mp4" || video.Find("source").AttrOr("src", "") != "/clip.webm" {
+		html, _ := video.Html()
+		t.Fatalf("amp-video not converted with its sources: %s", html)
+	}
+	if got := video.Text(); got != "" {
+		t.Fatalf("video kept fallback text %q", got)
+	}
+	if got := article.Find("iframe").AttrOr("src", ""); got != "https://www.youtube.com/embed/abc123" {
+		t.Fatalf("amp-youtube iframe src = %q, want YouTube embed URL", got)
+	}
+}
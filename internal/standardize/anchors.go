@@ -0,0 +1,123 @@
+package standardize
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// anchorHolders are the elements an anchor id is moved onto when its own
+// element is a wrapper or marker that cleanup may unwrap or drop.
+const anchorHolders = "h1, h2, h3, h4, h5, h6, p, li, dt, dd, td, th, figure, blockquote, pre, table"
+
+// anchorWrappers are the elements whose id moves to their first child.
+const anchorWrappers = "div, section, article, main, header"
+
+// anchorTargets returns the ids that links inside element point to, such
+// as the entries of a table of contents, and moves each onto an element
+// that survives cleanup. An empty anchor passes its id to the heading,
+// paragraph, or other block holding it, or else to the next element; an
+// inline element holding all the text of such a block passes it to the
+// block; and a div or section passes it to its first child. An <a name>
+// target gets the name as its id first.
+func anchorTargets(element *goquery.Selection) map[string]bool {
+	targets := map[string]bool{}
+	element.Find(`a[href^="#"]`).Each(func(_ int, link *goquery.Selection) {
+		fragment := strings.TrimPrefix(link.AttrOr("href", ""), "#")
+		if unescaped, err := url.PathUnescape(fragment); err == nil {
+			fragment = unescaped
+		}
+		if fragment != "" {
+			targets[fragment] = true
+		}
+	})
+	if len(targets) == 0 {
+		return targets
+	}
+
+	element.Find("a[name]:not([id])").Each(func(_ int, anchor *goquery.Selection) {
+		if name := anchor.AttrOr("name", ""); targets[name] {
+			anchor.SetAttr("id", name)
+		}
+	})
+
+	ids := map[string]bool{}
+	var holders []*goquery.Selection
+	element.Find("[id]").Each(func(_ int, target *goquery.Selection) {
+		id := target.AttrOr("id", "")
+		ids[id] = true
+		if targets[id] {
+			holders = append(holders, target)
+		}
+	})
+	for _, target := range holders {
+		moveAnchor(target)
+	}
+
+	// Links to ids that are not in the content have nothing to keep
+	for id := range targets {
+		if !ids[id] {
+			delete(targets, id)
+		}
+	}
+	return targets
+}
+
+// moveAnchor moves the id of target onto the element that will carry it
+// through cleanup, when target itself may not survive.
+func moveAnchor(target *goquery.Selection) {
+	n := target.Get(0)
+	var holder *html.Node
+	switch {
+	case target.Is(anchorHolders):
+		return
+	case target.Is(anchorWrappers):
+		holder = firstElementChild(n)
+		for holder != nil && goquery.NewDocumentFromNode(holder).Is(anchorWrappers) {
+			holder = firstElementChild(holder)
+		}
+	case strings.TrimSpace(target.Text()) == "" && target.Find("img, picture, video, svg").Length() == 0:
+		if parent := target.Parent(); parent.Is(anchorHolders) {
+			holder = n.Parent
+		} else {
+			holder = nextElementSibling(n)
+		}
+	default:
+		// Only an anchor that is the whole of its block, like a heading's
+		// text, may be unwrapped
+		if parent := target.Parent(); parent.Is(anchorHolders) && strings.TrimSpace(parent.Text()) == strings.TrimSpace(target.Text()) {
+			holder = n.Parent
+		}
+	}
+	if holder == nil {
+		return
+	}
+	if _, exists := goquery.NewDocumentFromNode(holder).Attr("id"); exists {
+		return
+	}
+	id := target.AttrOr("id", "")
+	target.RemoveAttr("id")
+	goquery.NewDocumentFromNode(holder).SetAttr("id", id)
+}
+
+// firstElementChild returns the first child of n that is an element.
+func firstElementChild(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// nextElementSibling returns the next sibling of n that is an element.
+func nextElementSibling(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package standardize
+
+import (
+	"testing"
+)
+
+func TestAnchorTargets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "block target kept in place",
+			html: `<a href="#intro">Intro</a><h2 id="intro">Intro</h2>`,
+			want: `<a href="#intro">Intro</a><h2 id="intro">Intro</h2>`,
+		},
+		{
+			name: "wrapper passes id to first child",
+			html: `<a href="#setup">Setup</a><div id="setup"><div><h2>Setup</h2><p>Text</p></div></div>`,
+			want: `<a href="#setup">Setup</a><div><div><h2 id="setup">Setup</h2><p>Text</p></div></div>`,
+		},
+		{
+			name: "inline anchor passes id to heading",
+			html: `<a href="#usage">Usage</a><h2><span id="usage">Usage</span></h2>`,
+			want: `<a href="#usage">Usage</a><h2 id="usage"><span>Usage</span></h2>`,
+		},
+		{
+			name: "named marker passes name to next element",
+			html: `<a href="#faq">FAQ</a><a name="faq"></a><h2>FAQ</h2>`,
+			want: `<a href="#faq">FAQ</a><a name="faq"></a><h2 id="faq">FAQ</h2>`,
+		},
+		{
+			name: "holder with its own id keeps it",
+			html: `<a href="#usage">Usage</a><h2 id="other"><span id="usage">Usage</span></h2>`,
+			want: `<a href="#usage">Usage</a><h2 id="other"><span id="usage">Usage</span></h2>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newStandardizeDocument(t, `<html><body><article>`+tt.html+`</article></body></html>`)
+			article := doc.Find("article").First()
+			anchorTargets(article)
+
+			got, err := article.Html()
+			if err != nil {
+				t.Fatalf("Html() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("anchorTargets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripUnwantedAttributesKeepsLinkedIDs(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article><p><a href="#s%C3%A9ance">Jump</a></p><h2 id="séance">Séance</h2><h2 id="unlinked">Other</h2><a href="#missing">Gone</a></article></body></html>`)
+	article := doc.Find("article").First()
+
+	anchors := anchorTargets(article)
+	if len(anchors) != 1 || !anchors["séance"] {
+		t.Fatalf("anchorTargets() = %v, want only séance", anchors)
+	}
+	stripUnwantedAttributes(article, false, anchors)
+
+	if got := article.Find("h2").First().AttrOr("id", ""); got != "séance" {
+		t.Fatalf("linked id = %q, want séance", got)
+	}
+	if _, exists := article.Find("h2").Last().Attr("id"); exists {
+		t.Fatal("unlinked id kept")
+	}
+}
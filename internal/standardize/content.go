@@ -4,6 +4,7 @@ package standardize
 
 import (
 	"cmp"
+	"context"
 	"log/slog"
 	"regexp"
 	"slices"
@@ -14,9 +15,15 @@ import (
 	"golang.org/x/net/html"
 
 	"github.com/kaptinlin/defuddle-go/internal/constants"
+	"github.com/kaptinlin/defuddle-go/internal/dom"
+	"github.com/kaptinlin/defuddle-go/internal/elements"
 	"github.com/kaptinlin/defuddle-go/internal/metadata"
 )
 
+// cancelCheckInterval is the number of elements the long cleanup loops
+// process between checks for a cancelled context.
+const cancelCheckInterval = 64
+
 // Pre-compiled regex patterns used across standardization functions.
 var (
 	nbspRe             = regexp.MustCompile(`\xA0+`)
@@ -128,7 +135,62 @@ var elementStandardizationRules = []StandardizationRule{
 	},
 }
 
-// Content standardizes and cleans up the main content element
+// CleanupOptions toggles the individual cleanup passes run by Content.
+// Start from DefaultCleanupOptions and disable only the passes that misbehave.
+type CleanupOptions struct {
+	// FlattenWrappers unwraps layout-only wrapper elements
+	FlattenWrappers bool
+	// RemoveEmptyElements drops elements without meaningful content
+	RemoveEmptyElements bool
+	// StripAttributes removes attributes outside the allowlist
+	StripAttributes bool
+	// RemoveTrailingHeadings drops headings with no content after them
+	RemoveTrailingHeadings bool
+	// NormalizeWhitespace normalizes spaces, consecutive br elements, and empty lines
+	NormalizeWhitespace bool
+	// RepairRemovalGaps drops orphaned ad labels, merges doubled separators,
+	// and rejoins split paragraphs where clutter removal took an element out
+	RepairRemovalGaps bool
+	// SeparateCredits moves photo credits out of figure captions into the
+	// figure's data-credit attribute
+	SeparateCredits bool
+	// RemoveInlineForms removes form controls with their labels and prompts
+	// from the content, ahead of clutter removal
+	RemoveInlineForms bool
+	// HeadingIDs gives every h2–h6 an id that attribute stripping keeps: the
+	// page's own, or a slug of the heading text. It adds rather than cleans,
+	// so DefaultCleanupOptions leaves it off.
+	HeadingIDs bool
+	// ResolveImageSources promotes the srcset candidate best suited to
+	// ImageTargetWidth, or the real address of a lazy-loaded image, to src,
+	// replacing placeholders such as 1x1 GIFs, and drops srcset, sizes, and
+	// lazy-loading attributes
+	ResolveImageSources bool
+	// ImageTargetWidth is the display width, in CSS pixels, srcset
+	// candidates are chosen for. Defaults to DefaultImageTargetWidth when
+	// not positive.
+	ImageTargetWidth int
+}
+
+// DefaultCleanupOptions returns cleanup options with every cleanup pass
+// enabled
+func DefaultCleanupOptions() *CleanupOptions {
+	return &CleanupOptions{
+		FlattenWrappers:        true,
+		RemoveEmptyElements:    true,
+		StripAttributes:        true,
+		RemoveTrailingHeadings: true,
+		NormalizeWhitespace:    true,
+		RepairRemovalGaps:      true,
+		SeparateCredits:        true,
+		RemoveInlineForms:      true,
+		ResolveImageSources:    true,
+	}
+}
+
+// Content standardizes and cleans up the main content element. It returns
+// ctx.Err() as soon as it sees ctx cancelled, leaving element part-way
+// cleaned.
 // JavaScript original code:
 //
 //	export function standardizeContent(element: Element, metadata: DefuddleMetadata, doc: Document, debug: boolean = false): void {
@@ -176,47 +238,115 @@ var elementStandardizationRules = []StandardizationRule{
 //			logDebug('Debug mode: Skipping div flattening to preserve structure');
 //		}
 //	}
-func Content(element *goquery.Selection, metadata *metadata.Metadata, doc *goquery.Document, debug bool) {
-	standardizeSpaces(element)
+func Content(ctx context.Context, element *goquery.Selection, metadata *metadata.Metadata, doc *goquery.Document, debug bool, cleanup *CleanupOptions) error {
+	if cleanup == nil {
+		cleanup = DefaultCleanupOptions()
+	}
+
+	// Repair what clutter removal broke, then drop its gap markers
+	if cleanup.RepairRemovalGaps {
+		repairGaps(element)
+	}
+	removeGaps(element)
+
+	if cleanup.NormalizeWhitespace {
+		standardizeSpaces(element)
+	}
 
 	// Handle H1 elements - remove first one and convert others to H2
 	standardizeHeadings(element, metadata.Title, doc)
 
 	// Standardize footnotes and citations
 	standardizeFootnotes(element)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Split photo credits from captions while class names still mark them
+	if cleanup.SeparateCredits {
+		if moved := elements.SeparateCredits(element); debug && moved > 0 {
+			slog.Debug("Separated photo credits", "figures", moved)
+		}
+	}
 
 	// Convert embedded content to standard formats
 	standardizeElements(element, doc)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Give images their real address, after AMP images became img
+	if cleanup.ResolveImageSources {
+		if resolved := resolveImageSources(element, cleanup.ImageTargetWidth); debug && resolved > 0 {
+			slog.Debug("Resolved image sources", "images", resolved)
+		}
+	}
 
 	// If not debug mode, do the full cleanup
 	if !debug {
+		// Find the ids in-content links point to, and move them where
+		// flattening keeps them
+		anchors := anchorTargets(element)
+		if cleanup.HeadingIDs {
+			assignHeadingIDs(element, anchors)
+		}
+
 		// First pass of div flattening
-		flattenWrapperElements(element, doc)
+		if cleanup.FlattenWrappers {
+			if err := flattenWrapperElements(ctx, element, doc); err != nil {
+				return err
+			}
+		}
 
 		// Strip unwanted attributes
-		stripUnwantedAttributes(element, debug)
+		if cleanup.StripAttributes {
+			stripUnwantedAttributes(element, debug, anchors)
+		}
 
 		// Remove empty elements
-		removeEmptyElements(element)
+		if cleanup.RemoveEmptyElements {
+			removeEmptyElements(element)
+		}
 
 		// Remove trailing headings
-		removeTrailingHeadings(element)
+		if cleanup.RemoveTrailingHeadings {
+			removeTrailingHeadings(element)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// Final pass of div flattening after cleanup operations
-		flattenWrapperElements(element, doc)
+		if cleanup.FlattenWrappers {
+			if err := flattenWrapperElements(ctx, element, doc); err != nil {
+				return err
+			}
+		}
 
-		// Standardize consecutive br elements
-		stripExtraBrElements(element)
+		if cleanup.NormalizeWhitespace {
+			// Standardize consecutive br elements
+			stripExtraBrElements(element)
 
-		// Clean up empty lines
-		removeEmptyLines(element, doc)
+			// Clean up empty lines
+			removeEmptyLines(element, doc)
+		}
 	} else {
 		// In debug mode, still do basic cleanup but preserve structure
-		stripUnwantedAttributes(element, debug)
-		removeTrailingHeadings(element)
-		stripExtraBrElements(element)
+		if cleanup.HeadingIDs {
+			assignHeadingIDs(element, map[string]bool{})
+		}
+		if cleanup.StripAttributes {
+			stripUnwantedAttributes(element, debug, nil)
+		}
+		if cleanup.RemoveTrailingHeadings {
+			removeTrailingHeadings(element)
+		}
+		if cleanup.NormalizeWhitespace {
+			stripExtraBrElements(element)
+		}
 		// Debug mode: Skipping div flattening to preserve structure
 	}
+	return nil
 }
 
 // standardizeSpaces normalizes whitespace in text content
@@ -379,11 +509,11 @@ func standardizeHeadings(element *goquery.Selection, title string, _ *goquery.Do
 		var newH2 strings.Builder
 		newH2.WriteString("<h2")
 
-		// Copy allowed attributes
+		// Copy allowed attributes, and the id links to the heading may use
 		if h1.Length() > 0 {
 			node := h1.Get(0)
 			for _, attr := range node.Attr {
-				if constants.IsAllowedAttribute(attr.Key) {
+				if constants.IsAllowedAttribute(attr.Key) || attr.Key == "id" {
 					newH2.WriteString(` ` + attr.Key + `="` + attr.Val + `"`)
 				}
 			}
@@ -424,6 +554,9 @@ func standardizeHeadings(element *goquery.Selection, title string, _ *goquery.Do
 //		});
 //	}
 func standardizeFootnotes(element *goquery.Selection) {
+	// Collect referenced footnote lists before the passes below strip their links
+	footnotes := collectFootnotes(element)
+
 	// Remove footnote back-references
 	backRefSelectors := []string{
 		`a[href^="#"][class*="anchor"]`,
@@ -447,6 +580,9 @@ func standardizeFootnotes(element *goquery.Selection) {
 			}
 		})
 	}
+
+	// Rebuild collected footnotes as linked references and a footnote section
+	restoreFootnotes(element, footnotes)
 }
 
 // standardizeElements converts embedded content to standard formats
@@ -542,6 +678,9 @@ func standardizeElements(element *goquery.Selection, doc *goquery.Document) {
 		processedCount++
 	})
 
+	// Convert AMP components
+	processedCount += convertAMPElements(element)
+
 	slog.Debug("Converted embedded elements", "count", processedCount)
 }
 
@@ -649,10 +788,19 @@ func standardizeElements(element *goquery.Selection, doc *goquery.Document) {
 //
 //		// ... (complex processing logic continues)
 //	}
-func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
+func flattenWrapperElements(ctx context.Context, element *goquery.Selection, _ *goquery.Document) error {
 	processedCount := 0
 	startTime := time.Now()
 
+	// err is set when a pass sees ctx cancelled, and stops all passes
+	var err error
+	cancelled := func(i int) bool {
+		if i%cancelCheckInterval == 0 && err == nil {
+			err = ctx.Err()
+		}
+		return err != nil
+	}
+
 	// Process in batches to maintain performance
 	keepProcessing := true
 
@@ -926,13 +1074,17 @@ func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
 		modified := false
 		blockElements := constants.GetBlockElements()
 
-		element.Children().Each(func(_ int, el *goquery.Selection) {
+		element.Children().EachWithBreak(func(i int, el *goquery.Selection) bool {
+			if cancelled(i) {
+				return false
+			}
 			tag := goquery.NodeName(el)
 			isBlock := slices.Contains(blockElements, tag)
 
 			if isBlock && processElement(el) {
 				modified = true
 			}
+			return true
 		})
 
 		return modified
@@ -955,7 +1107,10 @@ func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
 			return cmp.Compare(b.Parents().Length(), a.Parents().Length())
 		})
 
-		for _, el := range allElements {
+		for i, el := range allElements {
+			if cancelled(i) {
+				break
+			}
 			if processElement(el) {
 				modified = true
 			}
@@ -970,7 +1125,10 @@ func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
 		blockElements := constants.GetBlockElements()
 		blockSelector := strings.Join(blockElements, ",")
 
-		element.Find(blockSelector).Each(func(_ int, el *goquery.Selection) {
+		element.Find(blockSelector).EachWithBreak(func(i int, el *goquery.Selection) bool {
+			if cancelled(i) {
+				return false
+			}
 			// Check if element only contains paragraphs
 			children := el.Children()
 			onlyParagraphs := children.Length() > 0
@@ -988,6 +1146,7 @@ func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
 				processedCount++
 				modified = true
 			}
+			return true
 		})
 
 		return modified
@@ -995,6 +1154,9 @@ func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
 
 	// Execute all passes until no more changes
 	for keepProcessing {
+		if cancelled(0) {
+			return err
+		}
 		keepProcessing = false
 		if processTopLevelElements() {
 			keepProcessing = true
@@ -1012,6 +1174,7 @@ func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
 	slog.Debug("Flattened wrapper elements",
 		"count", processedCount,
 		"processingTime", processingTime)
+	return err
 }
 
 // stripUnwantedAttributes removes unwanted attributes from elements
@@ -1073,76 +1236,54 @@ func flattenWrapperElements(element *goquery.Selection, _ *goquery.Document) {
 //
 //		logDebug('Stripped attributes:', attributeCount);
 //	}
-func stripUnwantedAttributes(element *goquery.Selection, debug bool) {
+func stripUnwantedAttributes(element *goquery.Selection, debug bool, anchors map[string]bool) {
 	attributeCount := 0
 
-	processElement := func(el *goquery.Selection) {
-		if el.Length() == 0 {
-			return
-		}
-
-		node := el.Get(0)
-
+	processElement := func(node *html.Node) {
 		// Skip SVG elements - preserve all their attributes
-		tagName := strings.ToLower(node.Data)
+		tagName := dom.TagName(node)
 		if tagName == "svg" || node.Namespace == "http://www.w3.org/2000/svg" {
 			return
 		}
 
-		// Get all attributes and process them
-		var attributesToRemove []string
-		for _, attr := range node.Attr {
+		node.Attr = slices.DeleteFunc(node.Attr, func(attr html.Attribute) bool {
 			attrName := strings.ToLower(attr.Key)
 			attrValue := attr.Val
 
-			// Special cases for preserving specific attributes
-			preserveAttribute := false
-
-			// Preserve footnote IDs
+			// Preserve footnote IDs and the targets of in-content links
 			if attrName == "id" && (strings.HasPrefix(attrValue, "fnref:") || // Footnote reference
 				strings.HasPrefix(attrValue, "fn:") || // Footnote content
-				attrValue == "footnotes") { // Footnotes container
-				preserveAttribute = true
+				attrValue == "footnotes" || // Footnotes container
+				anchors[attrValue]) { // Link target
+				return false
 			}
 
 			// Preserve code block language classes and footnote backref class
 			if attrName == "class" && ((tagName == "code" && strings.HasPrefix(attrValue, "language-")) ||
 				attrValue == "footnote-backref") {
-				preserveAttribute = true
-			}
-
-			if preserveAttribute {
-				continue
+				return false
 			}
 
 			// In debug mode, allow debug attributes and data- attributes
+			remove := !constants.IsAllowedAttribute(attrName)
 			if debug {
-				if !constants.IsAllowedAttribute(attrName) &&
-					!constants.IsAllowedAttributeDebug(attrName) &&
-					!strings.HasPrefix(attrName, "data-") {
-					attributesToRemove = append(attributesToRemove, attr.Key)
-					attributeCount++
-				}
-			} else {
-				// In normal mode, only allow standard attributes
-				if !constants.IsAllowedAttribute(attrName) {
-					attributesToRemove = append(attributesToRemove, attr.Key)
-					attributeCount++
-				}
+				remove = remove && !constants.IsAllowedAttributeDebug(attrName) &&
+					!strings.HasPrefix(attrName, "data-")
 			}
-		}
+			if remove {
+				attributeCount++
+			}
+			return remove
+		})
+	}
 
-		// Remove unwanted attributes
-		for _, attrName := range attributesToRemove {
-			el.RemoveAttr(attrName)
+	for _, node := range element.Nodes {
+		processElement(node)
+		for el := range dom.Elements(node) {
+			processElement(el)
 		}
 	}
 
-	processElement(element)
-	element.Find("*").Each(func(_ int, el *goquery.Selection) {
-		processElement(el)
-	})
-
 	slog.Debug("Stripped attributes", "count", attributeCount)
 }
 
@@ -1213,73 +1354,19 @@ func removeEmptyElements(element *goquery.Selection) {
 		keepRemoving = false
 
 		// Get all elements and filter for empty ones, working from deepest first
-		var emptyElements []*goquery.Selection
-
-		element.Find("*").Each(func(_ int, el *goquery.Selection) {
-			tagName := strings.ToLower(goquery.NodeName(el))
-
-			// Skip allowed empty elements
-			if constants.IsAllowedEmptyElement(tagName) {
-				return
-			}
-
-			// Check if element has only whitespace or &nbsp;
-			textContent := el.Text()
-			hasOnlyWhitespace := strings.TrimSpace(textContent) == ""
-			hasNbsp := strings.Contains(textContent, "\u00A0") // Unicode non-breaking space
-
-			// Check if element has no meaningful children
-			hasNoChildren := true
-			el.Contents().Each(func(_ int, child *goquery.Selection) {
-				if goquery.NodeName(child) == "#text" {
-					nodeText := child.Text()
-					if strings.TrimSpace(nodeText) != "" || strings.Contains(nodeText, "\u00A0") {
-						hasNoChildren = false
-					}
-				} else {
-					hasNoChildren = false
+		var emptyElements []*html.Node
+		for _, root := range element.Nodes {
+			for el := range dom.Elements(root) {
+				if isEmptyElement(el) {
+					emptyElements = append(emptyElements, el)
 				}
-			})
-
-			// If no child nodes at all, it's definitely empty
-			if el.Contents().Length() == 0 {
-				hasNoChildren = true
 			}
-
-			// Special case: Check for divs that only contain spans with commas
-			if tagName == "div" {
-				children := el.Children()
-				if children.Length() > 0 {
-					hasOnlyCommaSpans := true
-					children.Each(func(_ int, child *goquery.Selection) {
-						childTag := strings.ToLower(goquery.NodeName(child))
-						if childTag != "span" {
-							hasOnlyCommaSpans = false
-							return
-						}
-						content := strings.TrimSpace(child.Text())
-						if content != "," && content != "" {
-							hasOnlyCommaSpans = false
-							return
-						}
-					})
-					if hasOnlyCommaSpans {
-						emptyElements = append(emptyElements, el)
-						return
-					}
-				}
-			}
-
-			// Element is empty if it has only whitespace, no &nbsp;, and no meaningful children
-			if hasOnlyWhitespace && !hasNbsp && hasNoChildren {
-				emptyElements = append(emptyElements, el)
-			}
-		})
+		}
 
 		// Remove empty elements
 		if len(emptyElements) > 0 {
 			for _, el := range emptyElements {
-				el.Remove()
+				dom.Remove(el)
 				removedCount++
 			}
 			keepRemoving = true
@@ -1291,6 +1378,51 @@ func removeEmptyElements(element *goquery.Selection) {
 		"iterations", iterations)
 }
 
+// isEmptyElement reports whether removeEmptyElements should drop el.
+func isEmptyElement(el *html.Node) bool {
+	tagName := dom.TagName(el)
+
+	// Skip allowed empty elements
+	if constants.IsAllowedEmptyElement(tagName) {
+		return false
+	}
+
+	// Check if element has only whitespace or &nbsp;
+	textContent := dom.Text(el)
+	hasOnlyWhitespace := strings.TrimSpace(textContent) == ""
+	hasNbsp := strings.Contains(textContent, "\u00A0") // Unicode non-breaking space
+
+	// Check if element has no meaningful children
+	hasNoChildren := true
+	for child := el.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.TextNode ||
+			strings.TrimSpace(child.Data) != "" || strings.Contains(child.Data, "\u00A0") {
+			hasNoChildren = false
+			break
+		}
+	}
+
+	// Special case: Check for divs that only contain spans with commas
+	if tagName == "div" {
+		hasChildren := false
+		hasOnlyCommaSpans := true
+		for child := range dom.Children(el) {
+			hasChildren = true
+			content := strings.TrimSpace(dom.Text(child))
+			if dom.TagName(child) != "span" || (content != "," && content != "") {
+				hasOnlyCommaSpans = false
+				break
+			}
+		}
+		if hasChildren && hasOnlyCommaSpans {
+			return true
+		}
+	}
+
+	// Element is empty if it has only whitespace, no &nbsp;, and no meaningful children
+	return hasOnlyWhitespace && !hasNbsp && hasNoChildren
+}
+
 // removeTrailingHeadings removes headings at the end of content
 // JavaScript original code:
 //
@@ -1315,23 +1447,29 @@ func removeEmptyElements(element *goquery.Selection) {
 //		});
 //	}
 func removeTrailingHeadings(element *goquery.Selection) {
-	hasContentAfter := func(el *goquery.Selection) bool {
-		siblings := el.NextAll()
-		hasContent := false
-		siblings.Each(func(_ int, sibling *goquery.Selection) {
-			text := strings.TrimSpace(sibling.Text())
-			if text != "" {
-				hasContent = true
+	hasContentAfter := func(el *html.Node) bool {
+		for sibling := dom.NextElementSibling(el); sibling != nil; sibling = dom.NextElementSibling(sibling) {
+			if strings.TrimSpace(dom.Text(sibling)) != "" {
+				return true
 			}
-		})
-		return hasContent
+		}
+		return false
 	}
 
-	element.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, heading *goquery.Selection) {
+	var headings []*html.Node
+	for _, root := range element.Nodes {
+		for el := range dom.Elements(root) {
+			switch dom.TagName(el) {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				headings = append(headings, el)
+			}
+		}
+	}
+	for _, heading := range headings {
 		if !hasContentAfter(heading) {
-			heading.Remove()
+			dom.Remove(heading)
 		}
-	})
+	}
 }
 
 // stripExtraBrElements removes excessive br elements
@@ -1,6 +1,7 @@
 package standardize
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -18,7 +19,9 @@ func TestContentPreservesSemanticContainersAndInlineSpacing(t *testing.T) {
 	</article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if !strings.Contains(article.Text(), "Preserved semantic content") {
 		t.Fatalf("Content() removed semantic content: %s", article.Text())
@@ -40,7 +43,9 @@ func TestContentConvertsUnorderedRoleListsAndBareListItems(t *testing.T) {
 	</article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if article.Find("ul > li").Length() != 2 {
 		t.Fatalf("Content() did not convert unordered role list: %s", article.Text())
@@ -71,7 +76,9 @@ func TestContentConvertsNestedRoleLists(t *testing.T) {
 	</article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if article.Find("ol > li").Length() == 0 {
 		t.Fatalf("Content() did not create ordered parent list")
@@ -92,7 +99,9 @@ func TestContentRemovesOnlyHeadingsWithoutFollowingContent(t *testing.T) {
 	</article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if !strings.Contains(article.Text(), "Section with body") {
 		t.Fatalf("Content() removed heading that had following content: %s", article.Text())
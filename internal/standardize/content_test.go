@@ -1,6 +1,8 @@
 package standardize
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -37,7 +39,9 @@ func TestContentStandardizesSemanticStructure(t *testing.T) {
 	</article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{Title: "Example Title"}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{Title: "Example Title"}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if article.Find("h1, h2, h3").Length() != 0 {
 		t.Fatalf("Content() left headings behind: %q", article.Text())
@@ -65,7 +69,9 @@ func TestContentDebugModePreservesWrapperDivs(t *testing.T) {
 	doc := newStandardizeDocument(t, `<html><body><article id="content" class="root" data-score="17"><div class="wrapper" data-step="keep"><p>Wrapped text</p></div></article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, true)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, true, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if article.Find("div").Length() == 0 {
 		t.Fatal("Content() in debug mode removed wrapper divs")
@@ -81,13 +87,34 @@ func TestContentDebugModePreservesWrapperDivs(t *testing.T) {
 	}
 }
 
+func TestContentStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article><div class="wrapper"><div><p>Wrapped text</p></div></div></article></body></html>`)
+	article := doc.Find("article").First()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := Content(ctx, article, &internalmetadata.Metadata{}, doc, false, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Content() error = %v, want context.Canceled", err)
+	}
+	if err := flattenWrapperElements(ctx, article, doc); !errors.Is(err, context.Canceled) {
+		t.Fatalf("flattenWrapperElements() error = %v, want context.Canceled", err)
+	}
+	if article.Find("div.wrapper").Length() != 1 {
+		t.Fatal("flattenWrapperElements() flattened wrappers after cancellation")
+	}
+}
+
 func TestContentStripsUnwantedAttributesAndPreservesSpecialCases(t *testing.T) {
 	t.Parallel()
 
 	doc := newStandardizeDocument(t, `<html><body><article class="root" data-score="17"><p id="fn:1" data-extra="removed"><a href="https://example.com" onclick="evil()" data-extra="removed">source</a><code class="language-go" onclick="evil()">fmt.Println()</code></p></article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if _, exists := article.Attr("class"); exists {
 		t.Fatal("Content() kept class on article in normal mode")
@@ -123,7 +150,9 @@ func TestContentConvertsLiteYouTubeAndLimitsConsecutiveBreaks(t *testing.T) {
 	doc := newStandardizeDocument(t, `<html><body><article><p>Before</p><lite-youtube videoid="abc123" videotitle="Demo video"></lite-youtube><p>After<br><br><br><br>Breaks</p></article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if article.Find("lite-youtube").Length() != 0 {
 		t.Fatal("Content() left lite-youtube element behind")
@@ -149,7 +178,9 @@ func TestContentNormalizesTextButPreservesPreAndCode(t *testing.T) {
 	doc := newStandardizeDocument(t, `<html><body><article><p>Alpha   beta&#8204; gamma   , done</p><pre>one&nbsp;&nbsp; two</pre><code>fmt  .Println</code></article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if got := article.Find("p").First().Text(); got != "Alpha beta gamma, done" {
 		t.Fatalf("Content() paragraph text = %q, want normalized text", got)
@@ -173,7 +204,9 @@ func TestContentFlattensWrappersWhilePreservingReadableText(t *testing.T) {
 	</article></body></html>`)
 	article := doc.Find("article").First()
 
-	Content(article, &internalmetadata.Metadata{}, doc, false)
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
 
 	if article.Find(".outer, .inner, .punctuation").Length() != 0 {
 		t.Fatalf("Content() kept removable wrappers: %s", article.Text())
@@ -187,3 +220,31 @@ func TestContentFlattensWrappersWhilePreservingReadableText(t *testing.T) {
 		t.Fatalf("Content() removed readable text: %q", article.Text())
 	}
 }
+
+func TestContentCleanupOptionsDisableIndividualPasses(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article><div class="wrapper" data-step="keep"><figure><img src="/a.png"></figure><p>Wrapped text</p></div><span></span><h3>Trailing heading</h3></article></body></html>`)
+	article := doc.Find("article").First()
+
+	cleanup := DefaultCleanupOptions()
+	cleanup.FlattenWrappers = false
+	cleanup.StripAttributes = false
+	cleanup.RemoveTrailingHeadings = false
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, cleanup); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	if article.Find("div.wrapper").Length() != 1 {
+		t.Fatalf("Content() flattened wrapper with FlattenWrappers disabled: %s", article.Text())
+	}
+	if got := article.Find("div").AttrOr("data-step", ""); got != "keep" {
+		t.Fatalf("Content() data-step = %q, want attribute kept with StripAttributes disabled", got)
+	}
+	if article.Find("h3").Length() != 1 {
+		t.Fatal("Content() removed trailing heading with RemoveTrailingHeadings disabled")
+	}
+	if article.Find("span").Length() != 0 {
+		t.Fatal("Content() kept empty span with RemoveEmptyElements enabled")
+	}
+}
@@ -0,0 +1,198 @@
+package standardize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/kaptinlin/defuddle-go/internal/constants"
+)
+
+// footnoteContainerSelector matches wrappers that hold nothing but a footnote
+// list, such as a section with a rule or "Notes" heading above the list.
+const footnoteContainerSelector = `.footnotes, .footnote, #footnotes, [role="doc-endnotes"], [role="doc-footnotes"]`
+
+// footnoteBackRefSelector matches back-references inside footnote definitions.
+const footnoteBackRefSelector = `a[href^="#fnref"], a[href*="cite_ref"], a.footnote-backref, a.footnote-back, a.reversefootnote, .mw-cite-backlink`
+
+// footnoteRefAttr marks placeholders for references until the other footnote
+// passes have run.
+const footnoteRefAttr = "data-defuddle-footnote"
+
+// footnoteSet holds footnotes collected from the source footnote lists.
+type footnoteSet struct {
+	// numbers maps source ids of definitions to footnote numbers.
+	numbers map[string]int
+	// contents holds the inner HTML of each definition, indexed by number-1.
+	contents []string
+}
+
+// collectFootnotes finds footnote lists and the in-text references that point
+// at them. Referenced lists are removed, and their references are replaced with
+// placeholders that restoreFootnotes turns into the canonical structure:
+//
+//	<sup id="fnref:1"><a href="#fn:1">1</a></sup>
+//	<div id="footnotes"><ol><li id="fn:1">...</li></ol></div>
+//
+// Lists nothing links to, such as a standalone bibliography, are left alone.
+func collectFootnotes(element *goquery.Selection) *footnoteSet {
+	set := &footnoteSet{numbers: make(map[string]int)}
+
+	var containers []*goquery.Selection
+	element.Find(strings.Join(constants.GetFootnoteListSelectors(), ", ")).Each(func(_ int, list *goquery.Selection) {
+		if insideAny(list, containers) {
+			return
+		}
+
+		items := footnoteItems(list)
+		ids := make(map[string]string, len(items))
+		referenced := false
+		for _, item := range items {
+			id := footnoteItemID(item)
+			if id == "" || !hasFootnoteReference(element, list, id) {
+				continue
+			}
+			referenced = true
+			ids[id] = footnoteItemContent(item)
+		}
+		if !referenced {
+			return
+		}
+
+		for _, item := range items {
+			id := footnoteItemID(item)
+			content, ok := ids[id]
+			if !ok || content == "" {
+				continue
+			}
+			set.contents = append(set.contents, content)
+			set.numbers[id] = len(set.contents)
+		}
+
+		container := list
+		if parent := list.Parent(); parent.Is(footnoteContainerSelector) {
+			container = parent
+		}
+		containers = append(containers, container)
+	})
+
+	if len(set.contents) == 0 {
+		return set
+	}
+
+	element.Find(`a[href^="#"]`).Each(func(_ int, ref *goquery.Selection) {
+		number, ok := set.numbers[strings.TrimPrefix(ref.AttrOr("href", ""), "#")]
+		if !ok {
+			return
+		}
+		if insideAny(ref, containers) {
+			return
+		}
+		target := ref
+		if sup := ref.Closest("sup"); sup.Length() > 0 {
+			target = sup
+		}
+		target.ReplaceWithHtml(fmt.Sprintf(`<sup %s="%d"></sup>`, footnoteRefAttr, number))
+	})
+
+	for _, container := range containers {
+		container.Remove()
+	}
+	return set
+}
+
+// restoreFootnotes fills the reference placeholders and appends the footnote
+// section to element.
+func restoreFootnotes(element *goquery.Selection, set *footnoteSet) {
+	if set == nil || len(set.contents) == 0 {
+		return
+	}
+
+	labeled := make(map[string]bool)
+	element.Find("sup[" + footnoteRefAttr + "]").Each(func(_ int, sup *goquery.Selection) {
+		number := sup.AttrOr(footnoteRefAttr, "")
+		sup.RemoveAttr(footnoteRefAttr)
+		if !labeled[number] {
+			sup.SetAttr("id", "fnref:"+number)
+			labeled[number] = true
+		}
+		sup.SetHtml(`<a href="#fn:` + number + `">` + number + `</a>`)
+	})
+
+	var section strings.Builder
+	section.WriteString(`<div id="footnotes"><ol>`)
+	for i, content := range set.contents {
+		number := strconv.Itoa(i + 1)
+		fmt.Fprintf(&section, `<li id="fn:%s">%s`, number, content)
+		if labeled[number] {
+			fmt.Fprintf(&section, ` <a href="#fnref:%s" class="footnote-backref">↩</a>`, number)
+		}
+		section.WriteString(`</li>`)
+	}
+	section.WriteString(`</ol></div>`)
+	element.AppendHtml(section.String())
+}
+
+// footnoteItems returns the top-level items of a footnote list. Lists without
+// li elements, such as Substack footnote divs, are a single item.
+func footnoteItems(list *goquery.Selection) []*goquery.Selection {
+	var items []*goquery.Selection
+	list.Find("li").Each(func(_ int, item *goquery.Selection) {
+		if item.ParentsUntilSelection(list).Filter("li").Length() == 0 {
+			items = append(items, item)
+		}
+	})
+	if len(items) == 0 {
+		items = append(items, list)
+	}
+	return items
+}
+
+// footnoteItemID returns the id references use to point at item.
+func footnoteItemID(item *goquery.Selection) string {
+	if id := item.AttrOr("id", ""); id != "" {
+		return id
+	}
+	return item.Find("[id]").Not("a").First().AttrOr("id", "")
+}
+
+// hasFootnoteReference reports whether element links to id from outside list.
+func hasFootnoteReference(element, list *goquery.Selection, id string) bool {
+	found := false
+	element.Find(`a[href="#` + cssEscape(id) + `"]`).EachWithBreak(func(_ int, ref *goquery.Selection) bool {
+		found = !ref.Parents().IsSelection(list)
+		return !found
+	})
+	return found
+}
+
+// insideAny reports whether sel is one of containers or inside one of them.
+func insideAny(sel *goquery.Selection, containers []*goquery.Selection) bool {
+	for _, container := range containers {
+		if sel.IsSelection(container) || sel.Parents().IsSelection(container) {
+			return true
+		}
+	}
+	return false
+}
+
+// footnoteItemContent returns the definition HTML of item without back-references.
+func footnoteItemContent(item *goquery.Selection) string {
+	clone := item.Clone()
+	clone.Find(footnoteBackRefSelector).Remove()
+	if strings.TrimSpace(clone.Text()) == "" && clone.Find("img").Length() == 0 {
+		return ""
+	}
+	content, err := clone.Html()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(content)
+}
+
+// cssEscape quotes s for use inside a double-quoted CSS attribute value.
+func cssEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,99 @@
+package standardize
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	internalmetadata "github.com/kaptinlin/defuddle-go/internal/metadata"
+)
+
+func TestContentRebuildsReferencedFootnotes(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article>
+		<p>Claim<sup id="fnref:a"><a href="#fn:a" class="footnote">1</a></sup> and more<sup class="footnote-ref"><a href="#fn-b">2</a></sup> and again<sup><a href="#fn:a">1</a></sup>.</p>
+		<div class="footnotes"><hr><ol>
+			<li id="fn:a"><p>First note with <a href="https://example.com">a link</a>. <a href="#fnref:a" class="reversefootnote">↩</a></p></li>
+			<li id="fn-b"><p>Second note.</p></li>
+		</ol></div>
+	</article></body></html>`)
+	article := doc.Find("article").First()
+
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	refs := article.Find("p sup")
+	if refs.Length() != 3 {
+		t.Fatalf("Content() kept %d footnote references, want 3", refs.Length())
+	}
+	if got := refs.Eq(0).AttrOr("id", ""); got != "fnref:1" {
+		t.Fatalf("Content() first reference id = %q, want %q", got, "fnref:1")
+	}
+	if got := refs.Eq(1).Find("a").AttrOr("href", ""); got != "#fn:2" {
+		t.Fatalf("Content() second reference href = %q, want %q", got, "#fn:2")
+	}
+	if _, exists := refs.Eq(2).Attr("id"); exists {
+		t.Fatal("Content() gave a repeated reference a duplicate id")
+	}
+
+	items := article.Find("#footnotes ol li")
+	if items.Length() != 2 {
+		t.Fatalf("Content() footnote section has %d items, want 2", items.Length())
+	}
+	if got := items.Eq(0).AttrOr("id", ""); got != "fn:1" {
+		t.Fatalf("Content() first footnote id = %q, want %q", got, "fn:1")
+	}
+	if items.Eq(0).Find(".footnote-backref").Length() != 1 {
+		t.Fatalf("Content() first footnote = %q, want one back-reference", items.Eq(0).Text())
+	}
+	if got := items.Eq(0).Find(`a[href="https://example.com"]`).Length(); got != 1 {
+		t.Fatal("Content() dropped links inside the footnote")
+	}
+	if article.Find("hr").Length() != 0 {
+		t.Fatal("Content() kept the original footnote container")
+	}
+}
+
+func TestContentRebuildsWikipediaCitations(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article>
+		<p>Wiki claim<sup id="cite_ref-1" class="reference"><a href="#cite_note-1">[1]</a></sup>.</p>
+		<ol class="references"><li id="cite_note-1"><span class="mw-cite-backlink"><a href="#cite_ref-1">^</a></span> <span class="reference-text">Wiki source.</span></li></ol>
+	</article></body></html>`)
+	article := doc.Find("article").First()
+
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	if got := article.Find(`sup#fnref\:1 a`).AttrOr("href", ""); got != "#fn:1" {
+		t.Fatalf("Content() citation href = %q, want %q", got, "#fn:1")
+	}
+	if got := strings.TrimSpace(strings.TrimSuffix(article.Find(`li#fn\:1`).Text(), "↩")); got != "Wiki source." {
+		t.Fatalf("Content() citation text = %q, want %q", got, "Wiki source.")
+	}
+}
+
+func TestContentLeavesUnreferencedFootnoteListsAlone(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article>
+		<p>Body text without citations.</p>
+		<ol class="references"><li id="ref-1">Standalone bibliography entry.</li></ol>
+	</article></body></html>`)
+	article := doc.Find("article").First()
+
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	if article.Find("#footnotes").Length() != 0 {
+		t.Fatal("Content() built a footnote section without references")
+	}
+	if !strings.Contains(article.Find("ol li").Text(), "Standalone bibliography entry.") {
+		t.Fatalf("Content() removed the unreferenced list: %q", article.Text())
+	}
+}
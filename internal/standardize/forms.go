@@ -0,0 +1,263 @@
+package standardize
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// formControls are the controls that ask the reader to type or pick
+// something. Checkboxes and radio buttons outside a form are left alone,
+// since task lists and quizzes use them as content.
+const formControls = `input:not([type="hidden"]):not([type="checkbox"]):not([type="radio"]):not([type="submit"]):not([type="button"]):not([type="image"]):not([type="reset"]), textarea, select`
+
+// formButtons are the buttons that submit a control's value.
+const formButtons = `button, input[type="submit"], input[type="button"], input[type="image"]`
+
+// formPromptPattern matches the whole text of a prompt that labels an
+// inline signup control, such as "Enter your email" or "Subscribe".
+const formPromptPattern = `(?:(?:enter|type|add|drop)\s+(?:in\s+)?your\s+e-?mail(?:\s+address)?|(?:your\s+)?e-?mail(?:\s+address)?|sign\s*up(?:\s+(?:now|free|here))?|subscribe(?:\s+now)?|join(?:\s+(?:now|us|free))?)`
+
+var (
+	// formPromptRe matches the whole text of an element that only prompts
+	formPromptRe = regexp.MustCompile(`(?i)^\W*` + formPromptPattern + `\W*$`)
+	// trailingPromptRe matches a prompt closing the text before a control,
+	// at the start of the text or after the end of a sentence or clause
+	trailingPromptRe = regexp.MustCompile(`(?i)(?:^|[.!?:;|–—]\s+)(` + formPromptPattern + `)\s*[:.…]*\s*$`)
+)
+
+// formFragmentElements are the elements next to a control that may hold
+// only its label.
+const formFragmentElements = "span, small, em, strong, b, i, div, p"
+
+// RemoveInlineForms removes the form controls inside content, such as an
+// email capture embedded mid-paragraph, together with the text that only
+// served them: labels, elements whose text is a placeholder, button label,
+// or prompt like "Enter your email", and such a prompt ending the text
+// right before the control. A control inside a form goes with the whole
+// form, and a paragraph the parser split around the form is rejoined. It
+// must run before clutter removal takes the controls out, and returns the
+// number of forms and controls removed.
+func RemoveInlineForms(content *goquery.Selection) int {
+	removed := 0
+	seen := map[*html.Node]bool{}
+	content.Find(formControls).Each(func(_ int, control *goquery.Selection) {
+		unit := control
+		if form := control.Closest("form"); form.Length() > 0 && isInside(form.Get(0), content.Get(0)) {
+			unit = form
+		}
+		n := unit.Get(0)
+		if seen[n] || n.Parent == nil {
+			return
+		}
+		seen[n] = true
+
+		labels := formLabels(content, unit)
+		// A label wrapping the control goes with it
+		if label := unit.Closest("label"); label.Length() > 0 && isInside(label.Get(0), content.Get(0)) {
+			n = label.Get(0)
+		}
+		removeFormFragments(n, labels)
+
+		prev, next := n.PrevSibling, n.NextSibling
+		parent := n.Parent
+		parent.RemoveChild(n)
+		if n.Data == "form" {
+			rejoinSplitParagraph(prev, next)
+		}
+		// Take wrappers that held only the form along with it
+		for parent != content.Get(0) && parent.Parent != nil && isEmptyWrapper(parent) {
+			prev, next = parent.PrevSibling, parent.NextSibling
+			grandparent := parent.Parent
+			grandparent.RemoveChild(parent)
+			parent = grandparent
+		}
+		joinTextAround(prev, next)
+		removed++
+	})
+	return removed
+}
+
+// isEmptyWrapper reports whether n is a span or div with no text or media
+// left in it.
+func isEmptyWrapper(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Data != "span" && n.Data != "div" {
+		return false
+	}
+	sel := goquery.NewDocumentFromNode(n).Selection
+	return strings.TrimSpace(sel.Text()) == "" && sel.Find("img, picture, video, audio, iframe, svg, math").Length() == 0
+}
+
+// isInside reports whether n is a descendant of root.
+func isInside(n, root *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == root {
+			return true
+		}
+	}
+	return false
+}
+
+// formLabels removes the elements under content that label the controls
+// and buttons of unit, and returns the label texts along with their
+// placeholders, ARIA labels, and button texts, in lower case.
+func formLabels(content, unit *goquery.Selection) []string {
+	var labels []string
+	add := func(text string) {
+		text = strings.ToLower(strings.Trim(strings.TrimSpace(text), ":.…"))
+		if text != "" {
+			labels = append(labels, text)
+		}
+	}
+
+	controls := unit.Find(formControls + ", " + formButtons).AddSelection(unit.Filter(formControls))
+	controls.Each(func(_ int, control *goquery.Selection) {
+		for _, name := range []string{"placeholder", "aria-label", "title"} {
+			add(control.AttrOr(name, ""))
+		}
+		if control.Is(formButtons) {
+			add(control.Text())
+			add(control.AttrOr("value", ""))
+		}
+		if id := control.AttrOr("id", ""); id != "" {
+			content.Find("label").Each(func(_ int, label *goquery.Selection) {
+				if label.AttrOr("for", "") == id && !isInside(label.Get(0), unit.Get(0)) {
+					add(label.Text())
+					label.Remove()
+				}
+			})
+		}
+		for id := range strings.FieldsSeq(control.AttrOr("aria-labelledby", "")) {
+			content.Find("[id]").EachWithBreak(func(_ int, element *goquery.Selection) bool {
+				if element.AttrOr("id", "") != id {
+					return true
+				}
+				if !isInside(element.Get(0), unit.Get(0)) && len(strings.Fields(element.Text())) <= 10 {
+					add(element.Text())
+					element.Remove()
+				}
+				return false
+			})
+		}
+	})
+	return labels
+}
+
+// removeFormFragments removes what is left of the labels of n beside it:
+// sibling elements whose whole text is a label, a sibling button, or a
+// prompt, and a label or prompt closing the text just before n.
+func removeFormFragments(n *html.Node, labels []string) {
+	isLabel := func(text string) bool {
+		text = strings.ToLower(strings.Trim(strings.TrimSpace(text), ":.…"))
+		for _, label := range labels {
+			if text == label {
+				return true
+			}
+		}
+		return formPromptRe.MatchString(text)
+	}
+
+	for _, step := range []func(*html.Node) *html.Node{previousSibling, nextSibling} {
+		for s := step(n); s != nil; {
+			following := step(s)
+			switch {
+			case s.Type == html.CommentNode, isWhitespaceText(s):
+			case s.Type == html.ElementNode && isFormFragment(s, isLabel):
+				s.Parent.RemoveChild(s)
+			default:
+				following = nil
+			}
+			s = following
+		}
+	}
+
+	prev := n.PrevSibling
+	if prev == nil || prev.Type != html.TextNode {
+		return
+	}
+	text := strings.TrimRight(prev.Data, " \t\r\n")
+	for _, label := range labels {
+		if len(text) < len(label) || !strings.EqualFold(text[len(text)-len(label):], label) {
+			continue
+		}
+		rest := text[:len(text)-len(label)]
+		if before := strings.TrimRight(rest, " \t\r\n"); before == "" || before != rest && strings.ContainsAny(before[len(before)-1:], ".!?:;|") {
+			prev.Data = rest
+			return
+		}
+	}
+	if m := trailingPromptRe.FindStringSubmatchIndex(text); m != nil {
+		prev.Data = text[:m[2]]
+	}
+}
+
+// isFormFragment reports whether n is a button or an element holding only
+// a label of a control, as decided by isLabel.
+func isFormFragment(n *html.Node, isLabel func(string) bool) bool {
+	sel := goquery.NewDocumentFromNode(n).Selection
+	if sel.Is(formButtons) || sel.Is("label") {
+		return true
+	}
+	if !sel.Is(formFragmentElements) || sel.Find("img, picture, video, iframe, a, "+formControls).Length() > 0 {
+		return false
+	}
+	return isLabel(sel.Text())
+}
+
+// rejoinSplitParagraph undoes the split of a paragraph by a form, which
+// the parser closes the paragraph for: the inline content after the form,
+// prev's sibling next, moves into prev when prev is a paragraph.
+func rejoinSplitParagraph(prev, next *html.Node) {
+	if prev == nil || prev.Type != html.ElementNode || prev.Data != "p" {
+		return
+	}
+	boundary := prev.LastChild
+	for next != nil && (next.Type == html.TextNode || next.Type == html.ElementNode && isInlineElement(next.Data)) {
+		following := next.NextSibling
+		next.Parent.RemoveChild(next)
+		prev.AppendChild(next)
+		next = following
+	}
+	// The paragraph's own end tag leaves an empty one behind
+	if next != nil && next.Type == html.ElementNode && next.Data == "p" && next.FirstChild == nil {
+		next.Parent.RemoveChild(next)
+	}
+	joinTextAround(boundary, nil)
+}
+
+// isInlineElement reports whether tag is phrasing content that can follow
+// text inside a paragraph.
+func isInlineElement(tag string) bool {
+	switch tag {
+	case "a", "abbr", "b", "bdi", "bdo", "br", "cite", "code", "data", "dfn", "em", "i", "kbd",
+		"mark", "q", "s", "samp", "small", "span", "strong", "sub", "sup", "time", "u", "var", "wbr":
+		return true
+	}
+	return false
+}
+
+// joinTextAround collapses the whitespace where a removal left prev and
+// next text nodes meeting, so the sentence reads on with one space.
+func joinTextAround(prev, next *html.Node) {
+	if prev == nil || prev.Type != html.TextNode {
+		return
+	}
+	following := prev.NextSibling
+	for following != nil && isWhitespaceText(following) && following != next {
+		after := following.NextSibling
+		following.Parent.RemoveChild(following)
+		following = after
+	}
+	if following == nil || following.Type != html.TextNode {
+		return
+	}
+	trimmed := strings.TrimRight(prev.Data, " \t\r\n")
+	rest := strings.TrimLeft(following.Data, " \t\r\n")
+	prev.Data = trimmed
+	following.Data = rest
+	if trimmed != "" && rest != "" && !strings.ContainsAny(rest[:1], ".,;:!?)") {
+		prev.Data += " "
+	}
+}
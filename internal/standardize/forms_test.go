@@ -0,0 +1,64 @@
+package standardize
+
+import (
+	"testing"
+)
+
+func TestRemoveInlineForms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "prompt before control",
+			html: `<p>Like this story? Enter your email <input type="email" placeholder="you@example.com"><button>Subscribe</button> and we will send the next one.</p>`,
+			want: `<p>Like this story? and we will send the next one.</p>`,
+		},
+		{
+			name: "label elements",
+			html: `<p>The council met on Monday. <span class="signup"><label for="e">Email address</label><input id="e" type="email"><button>Sign up</button></span> It voted to approve the budget.</p>`,
+			want: `<p>The council met on Monday. It voted to approve the budget.</p>`,
+		},
+		{
+			name: "aria and placeholder fragments",
+			html: `<p>Before the vote, <span id="lbl">Get our newsletter</span><input aria-labelledby="lbl"> <small>Your email</small> the mayor spoke.</p>`,
+			want: `<p>Before the vote, the mayor spoke.</p>`,
+		},
+		{
+			name: "form splitting a paragraph",
+			html: `<p>The committee voted to <form action="/s">Enter your email <input type="email"><button>Go</button></form> approve the budget.</p>`,
+			want: `<p>The committee voted to approve the budget.</p>`,
+		},
+		{
+			name: "mentions of email kept",
+			html: `<p>She answered by email.</p><p>Reply to your email <input type="text"> today.</p>`,
+			want: `<p>She answered by email.</p><p>Reply to your email today.</p>`,
+		},
+		{
+			name: "task list checkboxes kept",
+			html: `<ul><li><input type="checkbox" checked> Done</li></ul>`,
+			want: `<ul><li><input type="checkbox" checked=""/> Done</li></ul>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newStandardizeDocument(t, `<html><body><article>`+tt.html+`</article></body></html>`)
+			article := doc.Find("article").First()
+			RemoveInlineForms(article)
+
+			got, err := article.Html()
+			if err != nil {
+				t.Fatalf("Html() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("RemoveInlineForms() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package standardize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// gapElement marks where clutter removal took an element out, so the
+// repair pass only touches places the removal changed.
+const gapElement = "defuddle-gap"
+
+var (
+	// adLabelRe matches the whole text of a label left behind by an ad slot
+	adLabelRe = regexp.MustCompile(`(?i)^\W*(?:advertisements?|adverts?|ads?|sponsored(?: content)?|paid content|(?:story|article) continues below(?: (?:this )?(?:advertisement|ad))?|continue reading below|scroll to continue(?: with content)?)\W*$`)
+	// sentenceEndRe matches text that ends a sentence
+	sentenceEndRe = regexp.MustCompile(`[.!?:;…"”’)\]]\s*$`)
+)
+
+// adLabelElements are the elements that may hold an ad label
+const adLabelElements = "p, div, span, small, strong, em, aside, section, h1, h2, h3, h4, h5, h6"
+
+// RemoveLeavingGap removes the elements in sel like Remove, leaving a gap
+// marker in place of each so RepairGaps can fix what the removal broke.
+func RemoveLeavingGap(sel *goquery.Selection) {
+	for _, n := range sel.Nodes {
+		if n.Parent != nil {
+			n.Parent.InsertBefore(&html.Node{Type: html.ElementNode, Data: gapElement}, n)
+		}
+	}
+	sel.Remove()
+}
+
+// repairGaps fixes the artifacts clutter removal leaves around each gap
+// inside element: it drops orphaned ad labels such as "Advertisement",
+// merges separators that became adjacent, and rejoins a paragraph the
+// removed slot had split mid-sentence.
+func repairGaps(element *goquery.Selection) {
+	element.Find(gapElement).Each(func(_ int, gap *goquery.Selection) {
+		n := gap.Get(0)
+		if n.Parent == nil {
+			return
+		}
+
+		prev := adjacentElement(n, previousSibling)
+		for prev != nil && isAdLabel(prev) {
+			n.Parent.RemoveChild(prev)
+			prev = adjacentElement(n, previousSibling)
+		}
+		next := adjacentElement(n, nextSibling)
+		for next != nil && isAdLabel(next) {
+			n.Parent.RemoveChild(next)
+			next = adjacentElement(n, nextSibling)
+		}
+		if prev == nil || next == nil || prev.Data != next.Data {
+			return
+		}
+
+		switch prev.Data {
+		case "hr":
+			n.Parent.RemoveChild(next)
+		case "p":
+			if isSplitParagraph(prev, next) {
+				joinParagraphs(prev, next)
+			}
+		}
+	})
+}
+
+// removeGaps removes the gap markers inside element, along with one of the
+// two whitespace runs each leaves between its neighbors.
+func removeGaps(element *goquery.Selection) {
+	element.Find(gapElement).Each(func(_ int, gap *goquery.Selection) {
+		n := gap.Get(0)
+		if n.Parent == nil {
+			return
+		}
+		if isWhitespaceText(n.PrevSibling) && isWhitespaceText(n.NextSibling) {
+			n.Parent.RemoveChild(n.NextSibling)
+		}
+		n.Parent.RemoveChild(n)
+	})
+}
+
+// previousSibling and nextSibling are the steps adjacentElement walks by
+func previousSibling(n *html.Node) *html.Node { return n.PrevSibling }
+func nextSibling(n *html.Node) *html.Node     { return n.NextSibling }
+
+// adjacentElement returns the first element reached from n by step,
+// skipping whitespace, comments, and other gaps. It returns nil when text
+// or the end of the parent comes first.
+func adjacentElement(n *html.Node, step func(*html.Node) *html.Node) *html.Node {
+	for s := step(n); s != nil; s = step(s) {
+		switch {
+		case s.Type == html.CommentNode, isWhitespaceText(s):
+		case s.Type == html.ElementNode && s.Data != gapElement:
+			return s
+		case s.Type != html.ElementNode:
+			return nil
+		}
+	}
+	return nil
+}
+
+// isWhitespaceText reports whether n is a text node of only whitespace
+func isWhitespaceText(n *html.Node) bool {
+	return n != nil && n.Type == html.TextNode && strings.TrimSpace(n.Data) == ""
+}
+
+// isAdLabel reports whether n is a label such as "Advertisement" with
+// nothing else in it
+func isAdLabel(n *html.Node) bool {
+	sel := goquery.NewDocumentFromNode(n).Selection
+	if !sel.Is(adLabelElements) || sel.Find("img, picture, video, iframe, a").Length() > 0 {
+		return false
+	}
+	return adLabelRe.MatchString(strings.TrimSpace(sel.Text()))
+}
+
+// isSplitParagraph reports whether next continues the sentence prev breaks
+// off: prev ends without closing punctuation and next opens in lowercase.
+func isSplitParagraph(prev, next *html.Node) bool {
+	before := strings.TrimSpace(goquery.NewDocumentFromNode(prev).Text())
+	after := strings.TrimSpace(goquery.NewDocumentFromNode(next).Text())
+	if before == "" || after == "" || sentenceEndRe.MatchString(before) {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(after)
+	return unicode.IsLower(first)
+}
+
+// joinParagraphs moves the children of next onto the end of prev, with a
+// single space between, and removes next.
+func joinParagraphs(prev, next *html.Node) {
+	prevText := goquery.NewDocumentFromNode(prev).Text()
+	nextText := goquery.NewDocumentFromNode(next).Text()
+	if !strings.HasSuffix(prevText, " ") && !strings.HasPrefix(nextText, " ") {
+		// Whitespace-only text nodes do not survive space normalization, so
+		// the space joins a neighboring text node where there is one
+		switch {
+		case prev.LastChild != nil && prev.LastChild.Type == html.TextNode:
+			prev.LastChild.Data += " "
+		case next.FirstChild != nil && next.FirstChild.Type == html.TextNode:
+			next.FirstChild.Data = " " + next.FirstChild.Data
+		default:
+			prev.AppendChild(&html.Node{Type: html.TextNode, Data: " "})
+		}
+	}
+	for child := next.FirstChild; child != nil; child = next.FirstChild {
+		next.RemoveChild(child)
+		prev.AppendChild(child)
+	}
+	next.Parent.RemoveChild(next)
+}
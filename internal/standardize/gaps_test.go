@@ -0,0 +1,66 @@
+package standardize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepairGaps(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "doubled separators",
+			html: `<hr><div class="ad-slot">Buy now</div><hr><p>Next.</p>`,
+			want: `<hr/><p>Next.</p>`,
+		},
+		{
+			name: "orphaned labels",
+			html: `<p>Before.</p><h4>ADVERTISEMENT</h4><div class="ad-slot">Buy now</div><p>Story continues below advertisement</p><p>After.</p>`,
+			want: `<p>Before.</p><p>After.</p>`,
+		},
+		{
+			name: "split paragraph",
+			html: `<p>The committee voted to</p><div class="ad-slot">Buy now</div><p>approve the <em>budget</em>.</p>`,
+			want: `<p>The committee voted to approve the <em>budget</em>.</p>`,
+		},
+		{
+			name: "finished paragraphs kept apart",
+			html: `<p>The committee voted.</p><div class="ad-slot">Buy now</div><p>then it adjourned.</p><p>Next</p>`,
+			want: `<p>The committee voted.</p><p>then it adjourned.</p><p>Next</p>`,
+		},
+		{
+			name: "separators without a gap kept",
+			html: `<hr><hr><p>Advertisement</p>`,
+			want: `<hr/><hr/><p>Advertisement</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newStandardizeDocument(t, `<html><body><article>`+tt.html+`</article></body></html>`)
+			article := doc.Find("article").First()
+			RemoveLeavingGap(article.Find(".ad-slot"))
+
+			repairGaps(article)
+			removeGaps(article)
+
+			got, err := article.Html()
+			if err != nil {
+				t.Fatalf("Html() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("repairGaps() = %q, want %q", got, tt.want)
+			}
+			if strings.Contains(got, gapElement) {
+				t.Fatalf("removeGaps() left a marker in %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package standardize
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"github.com/kaptinlin/defuddle-go/internal/dom"
+)
+
+// assignHeadingIDs gives every h2–h6 of element an id and adds it to
+// anchors, so attribute stripping keeps it. A heading keeps the id the page
+// gave it, which is what links from elsewhere use, unless an earlier
+// heading or an element whose id survives stripping already has it;
+// otherwise it gets UniqueSlug of its text.
+func assignHeadingIDs(element *goquery.Selection, anchors map[string]bool) {
+	used := map[string]bool{}
+	var headings, others []*html.Node
+	for _, root := range element.Nodes {
+		for el := range dom.Elements(root) {
+			switch dom.TagName(el) {
+			case "h2", "h3", "h4", "h5", "h6":
+				headings = append(headings, el)
+				continue
+			}
+			// Other ids are stripped later unless they are link targets or
+			// footnote ids
+			if id, ok := dom.Attr(el, "id"); ok {
+				if anchors[id] || isFootnoteID(id) {
+					used[id] = true
+				} else {
+					others = append(others, el)
+				}
+			}
+		}
+	}
+
+	// Page ids go first, so a slug never takes one a later heading has
+	var unnamed []*html.Node
+	for _, heading := range headings {
+		id := strings.TrimSpace(dom.AttrOr(heading, "id", ""))
+		if id == "" || used[id] {
+			unnamed = append(unnamed, heading)
+			continue
+		}
+		used[id] = true
+		dom.SetAttr(heading, "id", id)
+		anchors[id] = true
+	}
+	for _, heading := range unnamed {
+		id := UniqueSlug(dom.Text(heading), used)
+		dom.SetAttr(heading, "id", id)
+		anchors[id] = true
+	}
+
+	// Stripping keeps every element with a kept id, so the heading's must
+	// not be left on another element too
+	for _, el := range others {
+		if id := dom.AttrOr(el, "id", ""); anchors[id] {
+			dom.RemoveAttr(el, "id")
+		}
+	}
+}
+
+// isFootnoteID reports whether attribute stripping keeps id as a footnote id.
+func isFootnoteID(id string) bool {
+	return strings.HasPrefix(id, "fnref:") || strings.HasPrefix(id, "fn:") || id == "footnotes"
+}
+
+// UniqueSlug returns Slug of text, with "-1", "-2", and so on appended
+// while used already has it, and records the result in used.
+func UniqueSlug(text string, used map[string]bool) string {
+	base := Slug(text)
+	slug := base
+	for n := 1; used[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	used[slug] = true
+	return slug
+}
+
+// Slug lowercases text and joins its runs of letters and digits with
+// hyphens, so "What's New in Go 1.22?" becomes "whats-new-in-go-122".
+// Apostrophes and periods inside words are dropped. Text without letters or
+// digits becomes "section".
+func Slug(text string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		case r == '\'' || r == '’' || r == '.':
+		default:
+			pendingHyphen = true
+		}
+	}
+	if b.Len() == 0 {
+		return "section"
+	}
+	return b.String()
+}
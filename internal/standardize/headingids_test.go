@@ -0,0 +1,86 @@
+package standardize
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	internalmetadata "github.com/kaptinlin/defuddle-go/internal/metadata"
+)
+
+func TestContentAssignsHeadingIDs(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article>
+		<h1 id="top">Title</h1><p>Lead</p>
+		<h2 id="install">Install</h2><p>Steps</p>
+		<h2>Install</h2><p>Again</p>
+		<h3>What's new in 1.2?</h3><p>Notes</p>
+		<section id="usage"><h2 id="usage">Usage</h2><p>Run it</p><span>!</span></section>
+		<p id="fn:1">Note</p><h2 id="fn:1">Notes</h2><p>More</p>
+	</article></body></html>`)
+	article := doc.Find("article").First()
+
+	cleanup := DefaultCleanupOptions()
+	cleanup.HeadingIDs = true
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{Title: "Other"}, doc, false, cleanup); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	var got []string
+	article.Find("h2, h3").Each(func(_ int, heading *goquery.Selection) {
+		got = append(got, heading.AttrOr("id", ""))
+	})
+	if got := article.Find("#usage").Length(); got != 1 {
+		t.Fatalf("elements with id usage = %d, want 1", got)
+	}
+
+	want := []string{"top", "install", "install-1", "whats-new-in-12", "usage", "notes"}
+	if len(got) != len(want) {
+		t.Fatalf("heading ids = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("heading ids = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestContentStripsHeadingIDsByDefault(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article><h2 id="install">Install</h2><p>Steps</p></article></body></html>`)
+	article := doc.Find("article").First()
+
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+	if _, exists := article.Find("h2").Attr("id"); exists {
+		t.Fatal("Content() kept an unlinked heading id")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"Getting Started":          "getting-started",
+		"  What's New in Go 1.22?": "whats-new-in-go-122",
+		"Über — Straße":            "über-straße",
+		"C++ & Rust":               "c-rust",
+		"!!!":                      "section",
+	}
+	for text, want := range tests {
+		if got := Slug(text); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	used := map[string]bool{"intro": true}
+	for _, want := range []string{"intro-1", "intro-2"} {
+		if got := UniqueSlug("Intro", used); got != want {
+			t.Fatalf("UniqueSlug() = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package standardize
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kaptinlin/defuddle-go/internal/dom"
+)
+
+// DefaultImageTargetWidth is the display width, in CSS pixels, srcset
+// candidates are chosen for when CleanupOptions.ImageTargetWidth is not
+// positive.
+const DefaultImageTargetWidth = 1200
+
+// placeholderDataLimit is the length below which a data URL in src is taken
+// for a lazy-loading placeholder, such as a 1x1 GIF or a blurred preview.
+// Longer ones are images in their own right, such as archived images
+// embedded by Options.EmbedArchiveImages.
+const placeholderDataLimit = 1024
+
+var (
+	// lazySourceAttributes hold the real address of lazy-loaded images, in
+	// order of preference.
+	lazySourceAttributes = []string{"data-src", "data-lazy-src", "data-original", "data-orig-src", "data-actualsrc", "data-hi-res-src"}
+	// lazySrcsetAttributes hold the real srcset of lazy-loaded images.
+	lazySrcsetAttributes = []string{"data-srcset", "data-lazy-srcset"}
+	// placeholderNames are file names lazy-loading scripts serve in src
+	// until the real image loads.
+	placeholderNames = []string{"blank.", "spacer.", "pixel.", "placeholder.", "transparent.", "1x1.", "loading.", "grey.", "gray."}
+)
+
+// srcsetCandidate is one image candidate of a srcset.
+type srcsetCandidate struct {
+	url     string
+	width   int     // w descriptor, 0 when absent
+	density float64 // x descriptor, 1 when neither is given
+}
+
+// resolveImageSources gives each img of element the address of the image it
+// shows: the srcset or data-srcset candidate best suited to targetWidth,
+// else the real address a lazy-loading attribute such as data-src holds in
+// place of its src, else, for a placeholder src, the best source of its
+// picture. Images embedded as data URLs, and those without anything better,
+// keep their src. The srcset, sizes, and lazy-loading attributes are
+// dropped, as are the sources of a picture whose img names a real image. It
+// returns the number of images whose src changed.
+func resolveImageSources(element *goquery.Selection, targetWidth int) int {
+	if targetWidth <= 0 {
+		targetWidth = DefaultImageTargetWidth
+	}
+
+	var images []*html.Node
+	for _, root := range element.Nodes {
+		for el := range dom.Elements(root) {
+			if el.DataAtom == atom.Img {
+				images = append(images, el)
+			}
+		}
+	}
+
+	resolved := 0
+	for _, img := range images {
+		src := strings.TrimSpace(dom.AttrOr(img, "src", ""))
+		best := ""
+		// An image embedded as a data URL is the one the page showed
+		if isPlaceholder(src) || !strings.HasPrefix(strings.ToLower(src), "data:") {
+			best = bestCandidate(imageCandidates(img), targetWidth, displayWidth(img))
+			if best == "" {
+				best = lazySource(img)
+			}
+			if best == "" && isPlaceholder(src) {
+				best = pictureSource(img, targetWidth)
+			}
+		}
+
+		if best != "" && best != src {
+			dom.SetAttr(img, "src", best)
+			resolved++
+			src = best
+		}
+		dom.RemoveAttr(img, "srcset")
+		dom.RemoveAttr(img, "sizes")
+		for _, name := range lazySourceAttributes {
+			dom.RemoveAttr(img, name)
+		}
+		for _, name := range lazySrcsetAttributes {
+			dom.RemoveAttr(img, name)
+		}
+
+		if picture := img.Parent; picture != nil && picture.DataAtom == atom.Picture && !isPlaceholder(src) {
+			for source := range dom.Children(picture) {
+				if source.DataAtom == atom.Source {
+					dom.Remove(source)
+				}
+			}
+		}
+	}
+	return resolved
+}
+
+// imageCandidates returns the candidates of the srcset and lazy srcset
+// attributes of n, an img or a picture source. Data URLs are left out, as
+// placeholders.
+func imageCandidates(n *html.Node) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	for _, name := range append([]string{"srcset"}, lazySrcsetAttributes...) {
+		for _, candidate := range parseSrcset(dom.AttrOr(n, name, "")) {
+			if !strings.HasPrefix(strings.ToLower(candidate.url), "data:") {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	return candidates
+}
+
+// lazySource returns the first real address a lazy-loading attribute of img
+// holds, or "".
+func lazySource(img *html.Node) string {
+	for _, name := range lazySourceAttributes {
+		if value := strings.TrimSpace(dom.AttrOr(img, name, "")); value != "" && !isPlaceholder(value) {
+			return value
+		}
+	}
+	return ""
+}
+
+// pictureSource returns the best candidate of the sources of the picture
+// img is in, for an img whose own attributes name no real image.
+func pictureSource(img *html.Node, targetWidth int) string {
+	picture := img.Parent
+	if picture == nil || picture.DataAtom != atom.Picture {
+		return ""
+	}
+	var candidates []srcsetCandidate
+	for source := range dom.Children(picture) {
+		if source.DataAtom == atom.Source {
+			candidates = append(candidates, imageCandidates(source)...)
+		}
+	}
+	return bestCandidate(candidates, targetWidth, displayWidth(img))
+}
+
+// bestCandidate returns the URL of the smallest candidate at least
+// targetWidth wide, or of the widest when none is. Density candidates are
+// as wide as their density times the img's width attribute, or times
+// targetWidth when it has none, so 1x is picked there. It returns "" when
+// there are no candidates.
+func bestCandidate(candidates []srcsetCandidate, targetWidth, width int) string {
+	if width <= 0 {
+		width = targetWidth
+	}
+	best, bestWidth := "", 0.0
+	for _, candidate := range candidates {
+		w := float64(candidate.width)
+		if candidate.width == 0 {
+			w = candidate.density * float64(width)
+		}
+		switch {
+		case best == "":
+		case w >= float64(targetWidth) && (bestWidth < float64(targetWidth) || w < bestWidth):
+		case w < float64(targetWidth) && bestWidth < float64(targetWidth) && w > bestWidth:
+		default:
+			continue
+		}
+		best, bestWidth = candidate.url, w
+	}
+	return best
+}
+
+// parseSrcset returns the candidates of a srcset value. URLs may contain
+// commas, as in "w_300,h_200/photo.jpg 300w", so candidates are split as
+// browsers split them: a URL runs to the next whitespace, and its
+// descriptors to the next comma outside parentheses.
+func parseSrcset(value string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	for {
+		value = strings.TrimLeft(value, " \t\n\r\f,")
+		if value == "" {
+			return candidates
+		}
+		end := strings.IndexAny(value, " \t\n\r\f")
+		if end < 0 {
+			end = len(value)
+		}
+		candidate := srcsetCandidate{url: value[:end], density: 1}
+		value = value[end:]
+
+		var descriptors string
+		if trimmed := strings.TrimRight(candidate.url, ","); trimmed != candidate.url {
+			candidate.url = trimmed
+		} else {
+			end := descriptorsEnd(value)
+			descriptors, value = value[:end], value[end:]
+		}
+		for _, descriptor := range strings.Fields(descriptors) {
+			number := descriptor[:len(descriptor)-1]
+			switch descriptor[len(descriptor)-1] {
+			case 'w':
+				if w, err := strconv.Atoi(number); err == nil && w > 0 {
+					candidate.width = w
+				}
+			case 'x':
+				if x, err := strconv.ParseFloat(number, 64); err == nil && x > 0 {
+					candidate.density = x
+				}
+			}
+		}
+		if candidate.url != "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+}
+
+// descriptorsEnd returns the index of the comma ending the descriptors at
+// the start of value, or its length.
+func descriptorsEnd(value string) int {
+	depth := 0
+	for i, r := range value {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth = max(0, depth-1)
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(value)
+}
+
+// displayWidth returns the width attribute of img in pixels, or 0.
+func displayWidth(img *html.Node) int {
+	width, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(dom.AttrOr(img, "width", "")), "px"))
+	if err != nil || width <= 0 {
+		return 0
+	}
+	return width
+}
+
+// isPlaceholder reports whether src names no real image: it is empty, a
+// short data URL, or a placeholder file such as spacer.gif or blank.png.
+func isPlaceholder(src string) bool {
+	src = strings.ToLower(strings.TrimSpace(src))
+	switch {
+	case src == "", src == "#", src == "about:blank":
+		return true
+	case strings.HasPrefix(src, "data:"):
+		return len(src) < placeholderDataLimit
+	}
+	name := src
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	name = name[strings.LastIndex(name, "/")+1:]
+	for _, prefix := range placeholderNames {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package standardize
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	internalmetadata "github.com/kaptinlin/defuddle-go/internal/metadata"
+)
+
+func TestContentResolvesImageSources(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article>
+		<p>Lead paragraph of the story.</p>
+		<img src="data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7" data-src="/lazy.jpg" alt="Lazy" class="lazyload">
+		<img src="/small.jpg" srcset="/w400.jpg 400w, /w1600.jpg 1600w, /w1200.jpg 1200w" sizes="100vw" alt="Widths">
+		<img src="/img/spacer.gif" data-srcset="https://cdn.example.com/w_300,h_200/a.jpg 300w, https://cdn.example.com/w_900,h_600/a.jpg 900w" alt="Commas">
+		<img src="/one.jpg" srcset="/one.jpg 1x, /two.jpg 2x" alt="Densities">
+		<picture><source srcset="/hero.webp 1x, /hero-2x.webp 2x" type="image/webp"><img src="/blank.gif" alt="Picture"></picture>
+		<img src="/kept.jpg" alt="Kept">
+		<p>Closing paragraph of the story.</p>
+	</article></body></html>`)
+	article := doc.Find("article").First()
+
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	var got []string
+	article.Find("img").Each(func(_ int, img *goquery.Selection) {
+		got = append(got, img.AttrOr("src", ""))
+	})
+	want := []string{"/lazy.jpg", "/w1200.jpg", "https://cdn.example.com/w_900,h_600/a.jpg", "/one.jpg", "/hero.webp", "/kept.jpg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("img src = %q, want %q", got, want)
+	}
+	html, _ := article.Html()
+	for _, noise := range []string{"srcset", "sizes", "data-src", "<source"} {
+		if strings.Contains(html, noise) {
+			t.Fatalf("content kept %s: %s", noise, html)
+		}
+	}
+}
+
+func TestContentResolvesImageSourcesForTargetWidth(t *testing.T) {
+	t.Parallel()
+
+	doc := newStandardizeDocument(t, `<html><body><article>
+		<p>Lead paragraph of the story.</p>
+		<img src="/small.jpg" srcset="/w400.jpg 400w, /w800.jpg 800w, /w1600.jpg 1600w" alt="Widths">
+		<img src="/one.jpg" srcset="/one.jpg 1x, /two.jpg 2x" width="300" alt="Densities">
+	</article></body></html>`)
+	article := doc.Find("article").First()
+
+	cleanup := DefaultCleanupOptions()
+	cleanup.ImageTargetWidth = 500
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, cleanup); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	var got []string
+	article.Find("img").Each(func(_ int, img *goquery.Selection) {
+		got = append(got, img.AttrOr("src", ""))
+	})
+	if want := []string{"/w800.jpg", "/two.jpg"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("img src = %q, want %q", got, want)
+	}
+}
+
+func TestContentKeepsEmbeddedImageData(t *testing.T) {
+	t.Parallel()
+
+	embedded := "data:image/png;base64," + strings.Repeat("A", placeholderDataLimit)
+	doc := newStandardizeDocument(t, `<html><body><article><p>Lead paragraph of the story.</p>
+		<img src="`+embedded+`" data-src="/lazy.jpg" alt="Archived"></article></body></html>`)
+	article := doc.Find("article").First()
+
+	if err := Content(context.Background(), article, &internalmetadata.Metadata{}, doc, false, nil); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+	img := article.Find("img")
+	if got := img.AttrOr("src", ""); got != embedded {
+		t.Fatalf("img src = %.40q, want the embedded image", got)
+	}
+	if _, ok := img.Attr("data-src"); ok {
+		t.Fatal("embedded image kept data-src")
+	}
+}
+
+func TestParseSrcset(t *testing.T) {
+	t.Parallel()
+
+	got := parseSrcset(" a.jpg 1.5x,b.jpg 300w , c,d.jpg, e.jpg")
+	want := []srcsetCandidate{
+		{url: "a.jpg", density: 1.5},
+		{url: "b.jpg", width: 300, density: 1},
+		{url: "c,d.jpg", density: 1},
+		{url: "e.jpg", density: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseSrcset() = %+v, want %+v", got, want)
+	}
+}
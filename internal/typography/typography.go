@@ -0,0 +1,232 @@
+// Package typography normalizes quotes, dashes, ellipses, and punctuation
+// in extracted text, one opt-in rule at a time.
+package typography
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// QuoteStyle selects how quotation marks and apostrophes are written.
+type QuoteStyle string
+
+const (
+	// QuotesStraight writes curly quotes as ' and "
+	QuotesStraight QuoteStyle = "straight"
+	// QuotesCurly writes straight quotes as ‘ ’ “ ”, choosing each by the
+	// text before it
+	QuotesCurly QuoteStyle = "curly"
+)
+
+// Options selects the typography rules to apply. Every rule is off by
+// default, so publishers can opt in to exactly the transformations their
+// style allows.
+type Options struct {
+	// Quotes is QuotesStraight or QuotesCurly
+	// Defaults to leaving quotes as they are when empty.
+	Quotes QuoteStyle `json:"quotes,omitempty"`
+	// Dashes writes -- and --- as an em dash
+	Dashes bool `json:"dashes,omitempty"`
+	// Ellipses writes ... and . . . as an ellipsis character
+	Ellipses bool `json:"ellipses,omitempty"`
+	// DuplicatePunctuation collapses repeats of !, ?, ",", ;, and : to one
+	DuplicatePunctuation bool `json:"duplicatePunctuation,omitempty"`
+	// SpacedHyphens writes a hyphen with a space on each side, between
+	// words, as an em dash: "word - word" becomes "word — word"
+	SpacedHyphens bool `json:"spacedHyphens,omitempty"`
+}
+
+// Valid reports whether Quotes names a known style.
+func (o *Options) Valid() bool {
+	switch o.Quotes {
+	case "", QuotesStraight, QuotesCurly:
+		return true
+	}
+	return false
+}
+
+// active reports whether any rule is on.
+func (o *Options) active() bool {
+	return o != nil && (o.Quotes != "" || o.Dashes || o.Ellipses || o.DuplicatePunctuation || o.SpacedHyphens)
+}
+
+var (
+	ellipsisRe    = regexp.MustCompile(`\.\.\.|\. \. \.`)
+	dashRe        = regexp.MustCompile(`-{2,}`)
+	duplicateRe   = regexp.MustCompile(`!{2,}|\?{2,}|,{2,}|;{2,}|:{2,}`)
+	straightQuote = strings.NewReplacer("“", `"`, "”", `"`, "„", `"`, "‟", `"`, "‘", "'", "’", "'", "‚", "'", "‛", "'")
+)
+
+// skippedElements hold text whose characters are literal, such as code.
+var skippedElements = map[string]bool{
+	"pre": true, "code": true, "kbd": true, "samp": true, "var": true, "tt": true,
+	"script": true, "style": true, "textarea": true, "math": true, "svg": true,
+}
+
+// inlineElements continue the text around them, so a quote after one is
+// curled by the text before it.
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "cite": true,
+	"del": true, "dfn": true, "em": true, "i": true, "ins": true, "mark": true,
+	"q": true, "s": true, "small": true, "span": true, "strong": true,
+	"sub": true, "sup": true, "time": true, "u": true,
+}
+
+// Text applies the rules in opts to plain text.
+func Text(text string, opts *Options) string {
+	if !opts.active() {
+		return text
+	}
+	text, _ = apply(text, ' ', opts)
+	return text
+}
+
+// HTML applies the rules in opts to the text of an HTML fragment, leaving
+// markup, attributes, and the text of code, pre, kbd, samp, and math
+// untouched. Fragments that cannot be parsed are returned unchanged.
+func HTML(content string, opts *Options) string {
+	if !opts.active() {
+		return content
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return content
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	prev := ' '
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.TextNode:
+				c.Data, prev = apply(c.Data, prev, opts)
+			case html.ElementNode:
+				// Code reads as a word to the quote after it
+				if skippedElements[c.Data] {
+					prev = 'x'
+					continue
+				}
+				if !inlineElements[c.Data] {
+					prev = ' '
+				}
+				walk(c)
+				if !inlineElements[c.Data] {
+					prev = ' '
+				}
+			}
+		}
+	}
+	walk(body)
+
+	var b strings.Builder
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&b, n); err != nil {
+			return content
+		}
+	}
+	return b.String()
+}
+
+// apply runs the rules over one run of text. prev is the character before
+// it, a space at the start of a block, and the returned rune is the last
+// character of the result.
+func apply(text string, prev rune, opts *Options) (string, rune) {
+	if opts.Ellipses {
+		text = ellipsisRe.ReplaceAllString(text, "…")
+	}
+	if opts.Dashes {
+		text = replaceDashes(text)
+	}
+	if opts.DuplicatePunctuation {
+		text = duplicateRe.ReplaceAllStringFunc(text, func(run string) string { return run[:1] })
+	}
+	if opts.Quotes == QuotesStraight {
+		text = straightQuote.Replace(text)
+	}
+
+	runes := []rune(text)
+	if opts.Quotes == QuotesCurly || opts.SpacedHyphens {
+		for i, r := range runes {
+			before := prev
+			if i > 0 {
+				before = runes[i-1]
+			}
+			switch {
+			case opts.Quotes == QuotesCurly && r == '"':
+				runes[i] = '”'
+				if opensQuote(before) {
+					runes[i] = '“'
+				}
+			case opts.Quotes == QuotesCurly && r == '\'':
+				runes[i] = '’'
+				// An apostrophe eliding the start of a number stays closing
+				if opensQuote(before) && (i+1 >= len(runes) || !unicode.IsDigit(runes[i+1])) {
+					runes[i] = '‘'
+				}
+			case opts.SpacedHyphens && r == '-':
+				if isSpacedHyphen(runes, i, prev) {
+					runes[i] = '—'
+				}
+			}
+		}
+	}
+	if len(runes) > 0 {
+		prev = runes[len(runes)-1]
+	}
+	return string(runes), prev
+}
+
+// opensQuote reports whether a quote after r opens rather than closes.
+func opensQuote(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("([{—–-“‘/", r)
+}
+
+// isSpacedHyphen reports whether the hyphen at runes[i] stands between two
+// words with whitespace on both sides. A hyphen that opens a block, like a
+// list marker typed as text, does not.
+func isSpacedHyphen(runes []rune, i int, prev rune) bool {
+	if i+2 > len(runes)-1 || !unicode.IsSpace(runes[i+1]) || unicode.IsSpace(runes[i+2]) {
+		return false
+	}
+	j := i - 1
+	if j < 0 || !unicode.IsSpace(runes[j]) {
+		return false
+	}
+	for j >= 0 && unicode.IsSpace(runes[j]) {
+		j--
+	}
+	if j < 0 {
+		return !unicode.IsSpace(prev)
+	}
+	return !strings.ContainsRune("-—–", runes[j])
+}
+
+// replaceDashes writes runs of two or three hyphens as an em dash, except
+// in arrows such as --> and <--. Longer runs are rules and stay as they are.
+func replaceDashes(text string) string {
+	matches := dashRe.FindAllStringIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[1]-m[0] > 3 || (m[0] > 0 && text[m[0]-1] == '<') || (m[1] < len(text) && text[m[1]] == '>') {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString("—")
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
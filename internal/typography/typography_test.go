@@ -0,0 +1,100 @@
+package typography
+
+import "testing"
+
+func TestText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		opts Options
+		want string
+	}{
+		{
+			name: "curly quotes",
+			text: `She said "it's fine" and left ('twas late) in the '90s.`,
+			opts: Options{Quotes: QuotesCurly},
+			want: `She said “it’s fine” and left (‘twas late) in the ’90s.`,
+		},
+		{
+			name: "straight quotes",
+			text: `“It’s ‘fine’,” she said.`,
+			opts: Options{Quotes: QuotesStraight},
+			want: `"It's 'fine'," she said.`,
+		},
+		{
+			name: "dashes",
+			text: `Wait--no---stop. Arrows --> stay, and rules ------ too.`,
+			opts: Options{Dashes: true},
+			want: `Wait—no—stop. Arrows --> stay, and rules ------ too.`,
+		},
+		{
+			name: "ellipses",
+			text: `Well... maybe. . . later`,
+			opts: Options{Ellipses: true},
+			want: `Well… maybe… later`,
+		},
+		{
+			name: "duplicate punctuation",
+			text: `Really?? Yes!!! Wait,, what;; ok:: fine?! Dots... stay.`,
+			opts: Options{DuplicatePunctuation: true},
+			want: `Really? Yes! Wait, what; ok: fine?! Dots... stay.`,
+		},
+		{
+			name: "spaced hyphens",
+			text: `It rained - hard - all day. Well-known, - 5 and a -- b.`,
+			opts: Options{SpacedHyphens: true},
+			want: `It rained — hard — all day. Well-known, — 5 and a -- b.`,
+		},
+		{
+			name: "leading hyphen kept",
+			text: `- first item`,
+			opts: Options{SpacedHyphens: true},
+			want: `- first item`,
+		},
+		{
+			name: "no rules",
+			text: `"Wait--what..."`,
+			want: `"Wait--what..."`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Text(tt.text, &tt.opts); got != tt.want {
+				t.Fatalf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	t.Parallel()
+
+	opts := &Options{Quotes: QuotesCurly, Dashes: true}
+	content := `<p>He wrote "<em>hello</em>" -- then <code>x = "a--b"</code> ran.</p><p title="a--b">"New" block</p>`
+	want := `<p>He wrote “<em>hello</em>” — then <code>x = &#34;a--b&#34;</code> ran.</p><p title="a--b">“New” block</p>`
+
+	if got := HTML(content, opts); got != want {
+		t.Fatalf("HTML() = %q, want %q", got, want)
+	}
+	if got := HTML(content, nil); got != content {
+		t.Fatalf("HTML(nil) = %q, want content unchanged", got)
+	}
+}
+
+func TestOptionsValid(t *testing.T) {
+	t.Parallel()
+
+	for _, quotes := range []QuoteStyle{"", QuotesStraight, QuotesCurly} {
+		if !(&Options{Quotes: quotes}).Valid() {
+			t.Fatalf("Valid() = false for %q", quotes)
+		}
+	}
+	if (&Options{Quotes: "fancy"}).Valid() {
+		t.Fatal(`Valid() = true for "fancy"`)
+	}
+}
@@ -0,0 +1,176 @@
+package webarchive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// mhtmlPart is one part of an MHTML file.
+type mhtmlPart struct {
+	contentType, location, contentID string
+	data                             []byte
+}
+
+// ReadMHTML reads an MHTML file, such as one saved by a browser's "Save
+// page as", and returns its HTML document: the part the file names as its
+// start, or the part saved from the snapshot location, or else the first
+// HTML part. References to other parts by cid: URL are replaced by the
+// location the part was saved from.
+func ReadMHTML(r io.Reader, opts Options) (*Page, error) {
+	msg, err := mail.ReadMessage(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("reading MHTML headers: %w", err)
+	}
+	snapshot := strings.TrimSpace(msg.Header.Get("Snapshot-Content-Location"))
+
+	contentType := msg.Header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		// A single-part file is the document itself
+		data, err := readPartBody(msg.Body, msg.Header.Get("Content-Transfer-Encoding"))
+		if err != nil {
+			return nil, fmt.Errorf("reading MHTML body: %w", err)
+		}
+		if contentType != "" && !isHTML(contentType) {
+			return nil, ErrNoDocument
+		}
+		document, err := decodeHTML(data, contentType)
+		if err != nil {
+			return nil, fmt.Errorf("decoding MHTML document: %w", err)
+		}
+		return &Page{URL: firstNonEmpty(opts.Target, snapshot, msg.Header.Get("Content-Location")), HTML: document}, nil
+	}
+
+	var parts []*mhtmlPart
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading MHTML part: %w", err)
+		}
+		part := &mhtmlPart{
+			contentType: p.Header.Get("Content-Type"),
+			location:    strings.TrimSpace(p.Header.Get("Content-Location")),
+			contentID:   strings.Trim(strings.TrimSpace(p.Header.Get("Content-Id")), "<>"),
+		}
+		// Only documents, and images when kept, are read into memory
+		if isHTML(part.contentType) || (opts.Images && strings.HasPrefix(part.contentType, "image/")) {
+			// NextPart has already decoded quoted-printable parts
+			part.data, err = readPartBody(p, p.Header.Get("Content-Transfer-Encoding"))
+			if err != nil {
+				return nil, fmt.Errorf("reading MHTML part: %w", err)
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	primary := mainPart(parts, params["start"], firstNonEmpty(opts.Target, snapshot))
+	if primary == nil {
+		return nil, ErrNoDocument
+	}
+	document, err := decodeHTML(primary.data, primary.contentType)
+	if err != nil {
+		return nil, fmt.Errorf("decoding MHTML document: %w", err)
+	}
+
+	page := &Page{URL: firstNonEmpty(primary.location, opts.Target, snapshot), HTML: resolveContentIDs(document, parts)}
+	if opts.Images {
+		total := 0
+		for _, part := range parts {
+			page.addImage(part.location, part.contentType, part.data, &total)
+			if part.contentID != "" {
+				page.addImage("cid:"+part.contentID, part.contentType, part.data, &total)
+			}
+		}
+	}
+	return page, nil
+}
+
+// mainPart returns the HTML part with Content-ID start, else the one saved
+// from location, else the first HTML part.
+func mainPart(parts []*mhtmlPart, start, location string) *mhtmlPart {
+	start = strings.Trim(start, "<>")
+	var first *mhtmlPart
+	for _, part := range parts {
+		if !isHTML(part.contentType) {
+			continue
+		}
+		if start != "" && part.contentID == start {
+			return part
+		}
+		if first == nil {
+			first = part
+		}
+	}
+	if location != "" {
+		for _, part := range parts {
+			if isHTML(part.contentType) && normalizeURL(part.location) == normalizeURL(location) {
+				return part
+			}
+		}
+	}
+	return first
+}
+
+// resolveContentIDs replaces cid: references in src, href, and poster
+// attributes with the location of the part they name, when it has one.
+func resolveContentIDs(document string, parts []*mhtmlPart) string {
+	if !strings.Contains(document, "cid:") {
+		return document
+	}
+	locations := make(map[string]string)
+	for _, part := range parts {
+		if part.contentID != "" && part.location != "" && !strings.HasPrefix(part.location, "cid:") {
+			locations[part.contentID] = part.location
+		}
+	}
+	if len(locations) == 0 {
+		return document
+	}
+	return rewrite(document, func(n *html.Node) {
+		for i, a := range n.Attr {
+			if a.Key != "src" && a.Key != "href" && a.Key != "poster" {
+				continue
+			}
+			if id, ok := strings.CutPrefix(strings.TrimSpace(a.Val), "cid:"); ok {
+				if location, ok := locations[id]; ok {
+					n.Attr[i].Val = location
+				}
+			}
+		}
+	})
+}
+
+// readPartBody reads a part body, decoding its transfer encoding.
+func readPartBody(r io.Reader, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// The decoder skips the line breaks base64 bodies are wrapped in
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+	return io.ReadAll(r)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
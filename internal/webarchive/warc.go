@@ -0,0 +1,163 @@
+package webarchive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidWARC is returned when a WARC file has a malformed record.
+var ErrInvalidWARC = errors.New("invalid WARC record")
+
+// warcRecord is the part of a WARC record header ReadWARC uses.
+type warcRecord struct {
+	typ, target, contentType string
+}
+
+// ReadWARC reads a WARC file, plain or gzip-compressed, and returns the
+// HTML document of a response or resource record: the one for
+// opts.Target when set, or else the first successful one. Request,
+// metadata, revisit, and other records are skipped, as are responses that
+// are not 2xx.
+func ReadWARC(r io.Reader, opts Options) (*Page, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		// Each record is its own gzip member; the reader joins them
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading WARC: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		br = bufio.NewReader(gz)
+	}
+
+	var page *Page
+	var images []*warcImage
+	total := 0
+	tp := textproto.NewReader(br)
+	for {
+		line, err := tp.ReadLine()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading WARC: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// Records are separated by blank lines
+			continue
+		}
+		if !strings.HasPrefix(line, "WARC/") {
+			return nil, fmt.Errorf("%w: expected a version line, got %q", ErrInvalidWARC, line)
+		}
+
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return nil, fmt.Errorf("reading WARC header: %w", err)
+		}
+		length, err := strconv.ParseInt(strings.TrimSpace(header.Get("Content-Length")), 10, 64)
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("%w: bad Content-Length %q", ErrInvalidWARC, header.Get("Content-Length"))
+		}
+		record := warcRecord{
+			typ:         strings.ToLower(strings.TrimSpace(header.Get("Warc-Type"))),
+			target:      strings.Trim(strings.TrimSpace(header.Get("Warc-Target-Uri")), "<>"),
+			contentType: header.Get("Content-Type"),
+		}
+
+		block := io.LimitReader(br, length)
+		wanted := page == nil && (opts.Target == "" || normalizeURL(record.target) == normalizeURL(opts.Target))
+		if record.typ == "response" || record.typ == "resource" {
+			contentType, body := readRecordPayload(record, block, wanted, opts.Images && total < maxImageBytes)
+			switch {
+			case body == nil:
+			case isHTML(contentType) && wanted:
+				document, err := decodeHTML(body, contentType)
+				if err != nil {
+					return nil, fmt.Errorf("decoding WARC document: %w", err)
+				}
+				page = &Page{URL: record.target, HTML: document}
+			case strings.HasPrefix(contentType, "image/"):
+				images = append(images, &warcImage{location: record.target, contentType: contentType, data: body})
+				total += len(body)
+			}
+		}
+		if _, err := io.Copy(io.Discard, block); err != nil {
+			return nil, fmt.Errorf("reading WARC: %w", err)
+		}
+		if page != nil && !opts.Images {
+			break
+		}
+	}
+
+	if page == nil {
+		return nil, ErrNoDocument
+	}
+	total = 0
+	for _, image := range images {
+		page.addImage(image.location, image.contentType, image.data, &total)
+	}
+	return page, nil
+}
+
+// warcImage is an image record kept until the document is known.
+type warcImage struct {
+	location, contentType string
+	data                  []byte
+}
+
+// readRecordPayload returns the content type and payload of a response or
+// resource record, reading the payload only for an HTML document when
+// document is set or an image when images is set. body is nil otherwise,
+// and for captures that are truncated or malformed, which are skipped; read
+// errors of the file itself surface when the caller drains block.
+func readRecordPayload(record warcRecord, block io.Reader, document, images bool) (contentType string, body []byte) {
+	wants := func(contentType string) bool {
+		return (document && isHTML(contentType)) || (images && strings.HasPrefix(contentType, "image/"))
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(record.contentType)
+	if record.typ == "resource" || mediaType != "application/http" || (params["msgtype"] != "" && params["msgtype"] != "response") {
+		if !wants(record.contentType) {
+			return record.contentType, nil
+		}
+		body, err := io.ReadAll(block)
+		if err != nil {
+			return record.contentType, nil
+		}
+		return record.contentType, body
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(block), nil)
+	if err != nil {
+		return "", nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	contentType = resp.Header.Get("Content-Type")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || !wants(contentType) {
+		return contentType, nil
+	}
+
+	var reader io.Reader = resp.Body
+	// The payload is stored as sent, so it may still be compressed
+	if strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return contentType, nil
+		}
+		defer func() { _ = gz.Close() }()
+		reader = gz
+	}
+	if body, err = io.ReadAll(reader); err != nil {
+		return contentType, nil
+	}
+	return contentType, body
+}
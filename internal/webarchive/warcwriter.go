@@ -0,0 +1,180 @@
+package webarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1" // #nosec G505 - WARC digests are defined over SHA-1
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WARCWriter writes WARC 1.1 records, one fetch at a time, from any
+// number of goroutines.
+type WARCWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	compress bool
+	err      error
+}
+
+// NewWARCWriter returns a writer of WARC records to w. With compress, each
+// record is its own gzip member, as in a .warc.gz file.
+func NewWARCWriter(w io.Writer, compress bool) *WARCWriter {
+	return &WARCWriter{w: w, compress: compress}
+}
+
+// WriteInfo writes the warcinfo record that opens a file, naming the
+// software that wrote it.
+func (ww *WARCWriter) WriteInfo(software string, date time.Time) error {
+	fields := "software: " + software + "\r\nformat: WARC File Format 1.1\r\n"
+	return ww.write(warcHeader{
+		"WARC-Type":    "warcinfo",
+		"Content-Type": "application/warc-fields",
+	}, date, []byte(fields))
+}
+
+// WriteExchange writes the response resp, with body as its payload, and
+// the request that fetched it, resp.Request, as a response record and a
+// request record concurrent to it. Transfer and content codings the HTTP
+// client already removed are not restored: the payload is stored decoded,
+// with Content-Length set to match.
+func (ww *WARCWriter) WriteExchange(resp *http.Response, body []byte, date time.Time) error {
+	if resp == nil || resp.Request == nil || resp.Request.URL == nil {
+		return nil
+	}
+	target := resp.Request.URL.String()
+
+	var block bytes.Buffer
+	status := resp.Status
+	if status == "" {
+		status = strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+	}
+	fmt.Fprintf(&block, "%s %s\r\n", protoOrDefault(resp.Proto), status)
+	header := resp.Header.Clone()
+	header.Del("Transfer-Encoding")
+	if resp.Uncompressed {
+		header.Del("Content-Encoding")
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	_ = header.Write(&block)
+	block.WriteString("\r\n")
+	block.Write(body)
+
+	responseID := recordID()
+	if err := ww.write(warcHeader{
+		"WARC-Type":           "response",
+		"WARC-Record-ID":      responseID,
+		"WARC-Target-URI":     target,
+		"WARC-Payload-Digest": digest(body),
+		"Content-Type":        "application/http; msgtype=response",
+	}, date, block.Bytes()); err != nil {
+		return err
+	}
+
+	req := resp.Request
+	block.Reset()
+	fmt.Fprintf(&block, "%s %s %s\r\n", req.Method, req.URL.RequestURI(), protoOrDefault(req.Proto))
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(&block, "Host: %s\r\n", host)
+	_ = req.Header.Write(&block)
+	block.WriteString("\r\n")
+	return ww.write(warcHeader{
+		"WARC-Type":          "request",
+		"WARC-Target-URI":    target,
+		"WARC-Concurrent-To": responseID,
+		"Content-Type":       "application/http; msgtype=request",
+	}, date, block.Bytes())
+}
+
+// Err returns the first error a write returned, so callers that write
+// from many goroutines can check once at the end.
+func (ww *WARCWriter) Err() error {
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	return ww.err
+}
+
+// warcHeader holds the named fields of a record header.
+type warcHeader map[string]string
+
+// warcFieldOrder is the order named header fields are written in.
+var warcFieldOrder = []string{
+	"WARC-Type", "WARC-Record-ID", "WARC-Date", "WARC-Target-URI",
+	"WARC-Concurrent-To", "WARC-Payload-Digest", "WARC-Block-Digest",
+	"Content-Type", "Content-Length",
+}
+
+// write writes one record with header and block.
+func (ww *WARCWriter) write(header warcHeader, date time.Time, block []byte) error {
+	if header["WARC-Record-ID"] == "" {
+		header["WARC-Record-ID"] = recordID()
+	}
+	header["WARC-Date"] = date.UTC().Format(time.RFC3339)
+	header["WARC-Block-Digest"] = digest(block)
+	header["Content-Length"] = strconv.Itoa(len(block))
+
+	var record bytes.Buffer
+	record.WriteString("WARC/1.1\r\n")
+	for _, name := range warcFieldOrder {
+		if value := header[name]; value != "" {
+			record.WriteString(name + ": " + value + "\r\n")
+		}
+	}
+	record.WriteString("\r\n")
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	if ww.err != nil {
+		return ww.err
+	}
+	if ww.compress {
+		gz := gzip.NewWriter(ww.w)
+		if _, err := gz.Write(record.Bytes()); err != nil {
+			ww.err = fmt.Errorf("writing WARC record: %w", err)
+			return ww.err
+		}
+		if err := gz.Close(); err != nil {
+			ww.err = fmt.Errorf("writing WARC record: %w", err)
+		}
+		return ww.err
+	}
+	if _, err := ww.w.Write(record.Bytes()); err != nil {
+		ww.err = fmt.Errorf("writing WARC record: %w", err)
+	}
+	return ww.err
+}
+
+// recordID returns a new urn:uuid record identifier.
+func recordID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// digest returns the WARC digest of data: SHA-1 in base32.
+func digest(data []byte) string {
+	sum := sha1.Sum(data) // #nosec G401 - not used for security
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// protoOrDefault returns proto, or HTTP/1.1 when it is unset.
+func protoOrDefault(proto string) string {
+	if strings.TrimSpace(proto) == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
@@ -0,0 +1,204 @@
+// Package webarchive reads saved pages, MHTML files and WARC records, and
+// returns the HTML document they hold together with the images saved
+// beside it. It also writes WARC files of fetched responses.
+package webarchive
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNoDocument is returned when an archive holds no HTML document.
+var ErrNoDocument = errors.New("no HTML document in archive")
+
+// maxImageBytes bounds the images kept from one archive, so a large WARC
+// file does not have to fit in memory.
+const maxImageBytes = 64 << 20
+
+// Options controls what is read from an archive.
+type Options struct {
+	// Target is the URL of the page to read. Empty selects the document the
+	// archive marks as its main one, or else its first HTML document.
+	Target string
+	// Images keeps the images saved in the archive, for Page.EmbedImages.
+	Images bool
+}
+
+// Page is the HTML document read from an archive.
+type Page struct {
+	// URL is the address the document was saved from, if the archive
+	// records it.
+	URL string
+	// HTML is the document, decoded to UTF-8.
+	HTML string
+	// Images are the images saved with the document, by URL. It is empty
+	// unless Options.Images was set.
+	Images map[string]*Image
+}
+
+// Image is an image saved in an archive.
+type Image struct {
+	MediaType string
+	Data      []byte
+}
+
+// addImage keeps an image saved at location, within maxImageBytes in total.
+func (p *Page) addImage(location, contentType string, data []byte, total *int) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "image/") || len(data) == 0 || location == "" {
+		return
+	}
+	if *total+len(data) > maxImageBytes {
+		return
+	}
+	*total += len(data)
+	if p.Images == nil {
+		p.Images = make(map[string]*Image)
+	}
+	p.Images[normalizeURL(location)] = &Image{MediaType: mediaType, Data: data}
+}
+
+// EmbedImages returns HTML with each image reference to an image in
+// p.Images, in img src and in og:image and twitter:image metadata, replaced
+// by a data: URL, so the page keeps its images without the network.
+func (p *Page) EmbedImages() string {
+	if len(p.Images) == 0 {
+		return p.HTML
+	}
+	return rewrite(p.HTML, func(n *html.Node) {
+		switch n.Data {
+		case "img":
+			if src := p.embed(attr(n, "src")); src != "" {
+				setAttr(n, "src", src)
+				// A srcset candidate would replace the archived image
+				removeAttr(n, "srcset")
+			}
+		case "meta":
+			switch strings.ToLower(attr(n, "property") + attr(n, "name")) {
+			case "og:image", "og:image:url", "twitter:image", "twitter:image:src":
+				if content := p.embed(attr(n, "content")); content != "" {
+					setAttr(n, "content", content)
+				}
+			}
+		}
+	})
+}
+
+// embed returns ref as a data: URL, or "" when it is not an archived image.
+func (p *Page) embed(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+	image, ok := p.Images[normalizeURL(resolve(p.URL, ref))]
+	if !ok {
+		return ""
+	}
+	return "data:" + image.MediaType + ";base64," + base64.StdEncoding.EncodeToString(image.Data)
+}
+
+// rewrite parses document, calls visit for every element, and renders it
+// again. Documents that cannot be parsed are returned unchanged.
+func rewrite(document string, visit func(*html.Node)) string {
+	doc, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return document
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			visit(n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var b strings.Builder
+	if err := html.Render(&b, doc); err != nil {
+		return document
+	}
+	return b.String()
+}
+
+// decodeHTML decodes data to UTF-8 by the charset in contentType, or else
+// by the document's own declaration.
+func decodeHTML(data []byte, contentType string) (string, error) {
+	reader, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// isHTML reports whether contentType is an HTML document type.
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// resolve returns ref resolved against base, when both parse.
+func resolve(base, ref string) string {
+	b, err := url.Parse(base)
+	if err != nil || base == "" {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// normalizeURL returns raw without its fragment, so references to one
+// resource compare equal.
+func normalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return strings.TrimSpace(raw)
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Key != key {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+}
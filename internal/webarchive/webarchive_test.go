@@ -0,0 +1,245 @@
+package webarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pngImage is a 1x1 transparent PNG.
+var pngImage, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==")
+
+const testMHTML = "From: <Saved by Blink>\r\n" +
+	"Snapshot-Content-Location: https://example.com/news/story\r\n" +
+	"Subject: Story\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/related;\r\n" +
+	"\ttype=\"text/html\";\r\n" +
+	"\tboundary=\"----MultipartBoundary--abc----\"\r\n" +
+	"\r\n" +
+	"------MultipartBoundary--abc----\r\n" +
+	"Content-Type: text/css\r\n" +
+	"Content-Location: https://example.com/style.css\r\n" +
+	"\r\n" +
+	"body { color: red; }\r\n" +
+	"------MultipartBoundary--abc----\r\n" +
+	"Content-Type: text/html\r\n" +
+	"Content-ID: <frame-1@mhtml.blink>\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"Content-Location: https://example.com/news/story\r\n" +
+	"\r\n" +
+	"<html><head><meta charset=3D\"utf-8\"><meta property=3D\"og:image\" content=3D\"/img/lead.png\">=\r\n" +
+	"</head><body><article><p>Caf=C3=A9 story text.</p><img src=3D\"cid:image-2@mhtml.blink\"><i=\r\n" +
+	"mg src=3D\"../img/lead.png\" srcset=3D\"lead-2x.png 2x\"></article></body></html>\r\n" +
+	"------MultipartBoundary--abc----\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-ID: <image-2@mhtml.blink>\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"Content-Location: https://example.com/img/lead.png\r\n" +
+	"\r\n"
+
+func mhtmlFile() string {
+	encoded := base64.StdEncoding.EncodeToString(pngImage)
+	return testMHTML + encoded[:40] + "\r\n" + encoded[40:] + "\r\n------MultipartBoundary--abc------\r\n"
+}
+
+func TestReadMHTML(t *testing.T) {
+	t.Parallel()
+
+	page, err := ReadMHTML(strings.NewReader(mhtmlFile()), Options{Images: true})
+	if err != nil {
+		t.Fatalf("ReadMHTML() error = %v", err)
+	}
+	if page.URL != "https://example.com/news/story" {
+		t.Fatalf("URL = %q", page.URL)
+	}
+	for _, want := range []string{"Café story text.", `<img src="https://example.com/img/lead.png"/>`} {
+		if !strings.Contains(page.HTML, want) {
+			t.Fatalf("HTML missing %q:\n%s", want, page.HTML)
+		}
+	}
+	image, ok := page.Images["https://example.com/img/lead.png"]
+	if !ok || image.MediaType != "image/png" || !bytes.Equal(image.Data, pngImage) {
+		t.Fatalf("Images = %v", page.Images)
+	}
+
+	embedded := page.EmbedImages()
+	dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngImage)
+	if got := strings.Count(embedded, `src="`+dataURL+`"`); got != 2 {
+		t.Fatalf("EmbedImages() embedded %d img sources, want 2:\n%s", got, embedded)
+	}
+	if !strings.Contains(embedded, `content="`+dataURL+`"`) {
+		t.Fatalf("EmbedImages() left og:image unresolved:\n%s", embedded)
+	}
+	if strings.Contains(embedded, "srcset") {
+		t.Fatalf("EmbedImages() kept srcset:\n%s", embedded)
+	}
+
+	page, err = ReadMHTML(strings.NewReader(mhtmlFile()), Options{})
+	if err != nil {
+		t.Fatalf("ReadMHTML() error = %v", err)
+	}
+	if len(page.Images) != 0 || page.EmbedImages() != page.HTML {
+		t.Fatalf("Images = %v without Options.Images", page.Images)
+	}
+}
+
+func TestReadMHTMLWithoutDocument(t *testing.T) {
+	t.Parallel()
+
+	file := "MIME-Version: 1.0\r\nContent-Type: multipart/related; boundary=b\r\n\r\n--b\r\nContent-Type: text/css\r\n\r\nbody {}\r\n--b--\r\n"
+	if _, err := ReadMHTML(strings.NewReader(file), Options{}); !errors.Is(err, ErrNoDocument) {
+		t.Fatalf("ReadMHTML() error = %v, want ErrNoDocument", err)
+	}
+}
+
+// warcRecordText returns a WARC record with block as its content.
+func warcRecordText(typ, target, contentType, block string) string {
+	return fmt.Sprintf("WARC/1.1\r\nWARC-Type: %s\r\nWARC-Target-URI: %s\r\nWARC-Record-ID: <urn:uuid:%s>\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n",
+		typ, target, typ, contentType, len(block), block)
+}
+
+func gzipped(t *testing.T, data string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip error = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestReadWARC(t *testing.T) {
+	t.Parallel()
+
+	body := gzipped(t, `<html><head><meta charset="utf-8"></head><body><p>Archived story.</p><img src="/img/lead.png"></body></html>`)
+	file := warcRecordText("warcinfo", "", "application/warc-fields", "software: test\r\n") +
+		warcRecordText("request", "https://example.com/story", "application/http; msgtype=request", "GET /story HTTP/1.1\r\nHost: example.com\r\n\r\n") +
+		warcRecordText("response", "https://example.com/missing", "application/http; msgtype=response", "HTTP/1.1 404 Not Found\r\nContent-Type: text/html\r\nContent-Length: 9\r\n\r\nNot found") +
+		warcRecordText("response", "https://example.com/story", "application/http; msgtype=response", "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Encoding: gzip\r\nTransfer-Encoding: chunked\r\n\r\n"+fmt.Sprintf("%x\r\n%s\r\n0\r\n\r\n", len(body), body)) +
+		warcRecordText("response", "https://example.com/img/lead.png", "application/http; msgtype=response", fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: image/png\r\nContent-Length: %d\r\n\r\n%s", len(pngImage), pngImage)) +
+		warcRecordText("resource", "https://example.com/other", "text/html", "<p>Other page.</p>")
+
+	tests := []struct {
+		name    string
+		data    string
+		opts    Options
+		wantURL string
+		want    string
+		images  int
+	}{
+		{name: "first document", data: file, wantURL: "https://example.com/story", want: "Archived story."},
+		{name: "gzip file", data: gzipped(t, file), wantURL: "https://example.com/story", want: "Archived story."},
+		{name: "target", data: file, opts: Options{Target: "https://example.com/other#top"}, wantURL: "https://example.com/other", want: "Other page."},
+		{name: "images", data: file, opts: Options{Images: true}, wantURL: "https://example.com/story", want: "Archived story.", images: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page, err := ReadWARC(strings.NewReader(tt.data), tt.opts)
+			if err != nil {
+				t.Fatalf("ReadWARC() error = %v", err)
+			}
+			if page.URL != tt.wantURL || !strings.Contains(page.HTML, tt.want) {
+				t.Fatalf("ReadWARC() = %q, %q", page.URL, page.HTML)
+			}
+			if len(page.Images) != tt.images {
+				t.Fatalf("Images = %d, want %d", len(page.Images), tt.images)
+			}
+			if tt.images > 0 && !strings.Contains(page.EmbedImages(), `src="data:image/png;base64,`) {
+				t.Fatalf("EmbedImages() did not embed:\n%s", page.EmbedImages())
+			}
+		})
+	}
+}
+
+func TestReadWARCErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ReadWARC(strings.NewReader("<html>not a warc</html>"), Options{}); !errors.Is(err, ErrInvalidWARC) {
+		t.Fatalf("ReadWARC() error = %v, want ErrInvalidWARC", err)
+	}
+	file := warcRecordText("response", "https://example.com/img.png", "application/http; msgtype=response", "HTTP/1.1 200 OK\r\nContent-Type: image/png\r\nContent-Length: 3\r\n\r\nabc")
+	if _, err := ReadWARC(strings.NewReader(file), Options{}); !errors.Is(err, ErrNoDocument) {
+		t.Fatalf("ReadWARC() error = %v, want ErrNoDocument", err)
+	}
+}
+
+func TestWARCWriterRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, compress := range []bool{false, true} {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/story?id=1", nil)
+		if err != nil {
+			t.Fatalf("NewRequest() error = %v", err)
+		}
+		req.Header.Set("User-Agent", "test-agent")
+		body := []byte(`<html><body><p>Captured story.</p></body></html>`)
+		resp := &http.Response{
+			Status:       "200 OK",
+			StatusCode:   http.StatusOK,
+			Proto:        "HTTP/1.1",
+			Header:       http.Header{"Content-Type": {"text/html"}, "Transfer-Encoding": {"chunked"}},
+			Request:      req,
+			Uncompressed: true,
+		}
+
+		var buf bytes.Buffer
+		ww := NewWARCWriter(&buf, compress)
+		date := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+		if err := ww.WriteInfo("defuddle-test", date); err != nil {
+			t.Fatalf("WriteInfo() error = %v", err)
+		}
+		if err := ww.WriteExchange(resp, body, date); err != nil {
+			t.Fatalf("WriteExchange() error = %v", err)
+		}
+		if err := ww.Err(); err != nil {
+			t.Fatalf("Err() = %v", err)
+		}
+
+		data := buf.String()
+		if compress {
+			if data[0] != 0x1f {
+				t.Fatal("compressed WARC does not start with a gzip member")
+			}
+		} else {
+			for _, want := range []string{
+				"WARC-Type: warcinfo\r\n",
+				"WARC-Date: 2024-03-05T10:00:00Z\r\n",
+				"WARC-Target-URI: https://example.com/story?id=1\r\n",
+				"WARC-Payload-Digest: sha1:",
+				"HTTP/1.1 200 OK\r\n",
+				fmt.Sprintf("Content-Length: %d\r\n", len(body)),
+				"GET /story?id=1 HTTP/1.1\r\nHost: example.com\r\nUser-Agent: test-agent\r\n",
+				"WARC-Concurrent-To: <urn:uuid:",
+			} {
+				if !strings.Contains(data, want) {
+					t.Fatalf("WARC missing %q:\n%s", want, data)
+				}
+			}
+			if strings.Contains(data, "chunked") {
+				t.Fatalf("WARC kept Transfer-Encoding:\n%s", data)
+			}
+		}
+
+		page, err := ReadWARC(strings.NewReader(data), Options{})
+		if err != nil {
+			t.Fatalf("ReadWARC() error = %v", err)
+		}
+		if page.URL != "https://example.com/story?id=1" || !strings.Contains(page.HTML, "Captured story.") {
+			t.Fatalf("ReadWARC() = %q, %q", page.URL, page.HTML)
+		}
+	}
+}
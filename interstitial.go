@@ -0,0 +1,108 @@
+package defuddle
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// interstitialMarker names the platform whose scripts, frames, forms, or
+// element ids and classes contain one of needles.
+type interstitialMarker struct {
+	kind    InterstitialKind
+	vendor  string
+	needles []string
+}
+
+// interstitialMarkers are the traces consent management platforms and bot
+// challenges leave in the pages they stand in front of. Their scripts also
+// run on ordinary pages, so a marker only counts on a page with little
+// content.
+var interstitialMarkers = []interstitialMarker{
+	{InterstitialConsent, "onetrust", []string{"cdn.cookielaw.org", "optanon", "onetrust-"}},
+	{InterstitialConsent, "cookiebot", []string{"consent.cookiebot.com", "cybotcookiebot"}},
+	{InterstitialConsent, "quantcast", []string{"quantcast.mgr.consensu.org", "cmp.quantcast.com", "qc-cmp2"}},
+	{InterstitialConsent, "sourcepoint", []string{"privacy-mgmt.com", "sp_message_container", "sourcepoint"}},
+	{InterstitialConsent, "didomi", []string{"sdk.privacy-center.org", "didomi-"}},
+	{InterstitialConsent, "trustarc", []string{"consent.trustarc.com", "truste-consent"}},
+	{InterstitialConsent, "usercentrics", []string{"usercentrics"}},
+	{InterstitialConsent, "cookieconsent", []string{"cookieconsent.min.js", "cc-window"}},
+	{InterstitialConsent, "google", []string{"consent.google.com", "consent.youtube.com"}},
+	{InterstitialConsent, "yahoo", []string{"consent.yahoo.com", "guce.yahoo.com"}},
+	{InterstitialChallenge, "cloudflare", []string{"challenges.cloudflare.com", "/cdn-cgi/challenge-platform", "cf-browser-verification"}},
+	{InterstitialChallenge, "datadome", []string{"captcha-delivery.com"}},
+	{InterstitialChallenge, "perimeterx", []string{"px-captcha", "perimeterx"}},
+}
+
+// interstitialHosts are the hosts that serve nothing but consent walls,
+// by the vendor of the wall.
+var interstitialHosts = map[string]string{
+	"consent.google.com":  "google",
+	"consent.youtube.com": "google",
+	"consent.yahoo.com":   "yahoo",
+	"guce.yahoo.com":      "yahoo",
+}
+
+// consentCookies are the cookies that record a visitor's consent for each
+// platform they are known for, sent when Options.ConsentCookies fetches a
+// consent wall again.
+var consentCookies = map[string]map[string]string{
+	"onetrust":      {"OptanonAlertBoxClosed": "2024-01-01T00:00:00.000Z"},
+	"cookiebot":     {"CookieConsent": "-1"},
+	"google":        {"SOCS": "CAI", "CONSENT": "YES+"},
+	"cookieconsent": {"cookieconsent_status": "dismiss"},
+}
+
+// detectInterstitial returns the consent wall or bot challenge whose
+// markers root holds, or nil. The caller decides whether the page has
+// little enough content for the markers to count.
+func detectInterstitial(root *html.Node) *Interstitial {
+	var signature strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			signature.WriteString(attrValue(n, "id"))
+			signature.WriteByte(' ')
+			signature.WriteString(attrValue(n, "class"))
+			signature.WriteByte(' ')
+			switch n.DataAtom {
+			case atom.Script, atom.Iframe:
+				signature.WriteString(attrValue(n, "src"))
+			case atom.Link:
+				signature.WriteString(attrValue(n, "href"))
+			case atom.Form:
+				signature.WriteString(attrValue(n, "action"))
+			}
+			signature.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	text := strings.ToLower(signature.String())
+	for _, marker := range interstitialMarkers {
+		for _, needle := range marker.needles {
+			if strings.Contains(text, needle) {
+				return &Interstitial{Kind: marker.kind, Vendor: marker.vendor}
+			}
+		}
+	}
+	return nil
+}
+
+// interstitialAt returns the consent wall pageURL serves whatever its
+// content, or nil.
+func interstitialAt(pageURL string) *Interstitial {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	if vendor := interstitialHosts[strings.ToLower(u.Hostname())]; vendor != "" {
+		return &Interstitial{Kind: InterstitialConsent, Vendor: vendor}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oneTrustWall = `<html><head><title>News</title>
+<script src="https://cdn.cookielaw.org/scripttemplates/otSDKStub.js"></script></head>
+<body><div id="onetrust-banner-sdk"><h2>We value your privacy</h2><p>We and our partners store and access information on your device.</p><button>Accept all</button></div></body></html>`
+
+func TestParseReportsInterstitials(t *testing.T) {
+	t.Parallel()
+
+	article := strings.Repeat("The council published the transit plan after a year of hearings. ", 30)
+
+	tests := []struct {
+		name string
+		html string
+		url  string
+		want *Interstitial
+	}{
+		{name: "consent wall", html: oneTrustWall, want: &Interstitial{Kind: InterstitialConsent, Vendor: "onetrust"}},
+		{
+			name: "bot challenge",
+			html: `<html><head><title>Just a moment...</title></head><body><p>Checking your browser.</p><script src="/cdn-cgi/challenge-platform/h/g/orchestrate/jsch/v1"></script></body></html>`,
+			want: &Interstitial{Kind: InterstitialChallenge, Vendor: "cloudflare"},
+		},
+		{
+			name: "consent host",
+			html: `<html><body><p>Before you continue to Google</p><p>` + article + `</p></body></html>`,
+			url:  "https://consent.google.com/ml?continue=https://www.google.com/",
+			want: &Interstitial{Kind: InterstitialConsent, Vendor: "google"},
+		},
+		{
+			name: "article behind a banner",
+			html: `<html><head><script src="https://cdn.cookielaw.org/scripttemplates/otSDKStub.js"></script></head><body><article><h1>Transit</h1><p>` + article + `</p></article></body></html>`,
+		},
+		{name: "short page", html: `<html><body><p>Short note.</p></body></html>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := ParseFromString(context.Background(), tt.html, &Options{URL: tt.url})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Interstitial)
+		})
+	}
+}
+
+func TestParseFromURLRetriesConsentWalls(t *testing.T) {
+	t.Parallel()
+
+	article := `<html><head><title>Transit plan</title></head><body><article><p>` +
+		strings.Repeat("The council published the transit plan after a year of hearings. ", 30) + `</p></article></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := r.Cookie("OptanonAlertBoxClosed"); err == nil {
+			_, _ = w.Write([]byte(article))
+			return
+		}
+		_, _ = w.Write([]byte(oneTrustWall))
+	}))
+	defer server.Close()
+
+	result, err := ParseFromURL(context.Background(), server.URL+"/story", nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Interstitial{Kind: InterstitialConsent, Vendor: "onetrust"}, result.Interstitial)
+
+	result, err = ParseFromURL(context.Background(), server.URL+"/story", &Options{ConsentCookies: true})
+	require.NoError(t, err)
+	assert.Nil(t, result.Interstitial)
+	assert.Equal(t, "Transit plan", result.Title)
+}
@@ -0,0 +1,18 @@
+package defuddle
+
+import (
+	"html"
+	"strings"
+)
+
+// keyPointsHTML renders key points as the blockquote Options.InsertKeyPoints
+// puts at the top of the content.
+func keyPointsHTML(points []string) string {
+	var b strings.Builder
+	b.WriteString("<blockquote><ul>")
+	for _, point := range points {
+		b.WriteString("<li>" + html.EscapeString(point) + "</li>")
+	}
+	b.WriteString("</ul></blockquote>")
+	return b.String()
+}
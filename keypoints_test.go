@@ -0,0 +1,36 @@
+package defuddle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keyPointsArticle = `<html><head><title>Rates rise</title></head><body><article><h1>Rates rise</h1>` +
+	`<aside class="sidebar key-takeaways"><h3>Key takeaways</h3><ul><li>Rates rose by a quarter point</li><li>Two members dissented</li></ul></aside>` +
+	`<p>` + strings.Repeat("The central bank raised its benchmark rate on Thursday after a two-day meeting. ", 8) + `</p>` +
+	`</article></body></html>`
+
+func TestParseExtractsKeyPoints(t *testing.T) {
+	t.Parallel()
+
+	want := []string{"Rates rose by a quarter point", "Two members dissented"}
+
+	result, err := ParseFromString(context.Background(), keyPointsArticle, nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, result.KeyPoints)
+	assert.NotContains(t, result.Content, "<blockquote>")
+
+	result, err = ParseFromString(context.Background(), keyPointsArticle, &Options{
+		InsertKeyPoints:        true,
+		RemoveExactSelectors:   true,
+		RemovePartialSelectors: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, want, result.KeyPoints)
+	assert.True(t, strings.HasPrefix(result.Content, "<blockquote><ul><li>Rates rose by a quarter point</li><li>Two members dissented</li></ul></blockquote>"), result.Content)
+	assert.Equal(t, 1, strings.Count(result.Content, "Two members dissented"))
+}
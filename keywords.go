@@ -0,0 +1,74 @@
+package defuddle
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kaptinlin/defuddle-go/internal/nlp"
+)
+
+// declaredKeywordNames are the meta names whose content lists keywords.
+var declaredKeywordNames = []string{"keywords", "news_keywords"}
+
+// declaredKeywords returns the keywords the page declares for itself: its
+// article:tag values, then the comma-separated keywords and news_keywords
+// meta tags, in document order without duplicates.
+func declaredKeywords(result *Result) []string {
+	var declared []string
+	add := func(value string) {
+		value = strings.Join(strings.Fields(value), " ")
+		if value != "" && !slices.ContainsFunc(declared, func(d string) bool { return strings.EqualFold(d, value) }) {
+			declared = append(declared, value)
+		}
+	}
+	for _, tag := range result.Tags {
+		add(tag)
+	}
+	for _, tag := range result.MetaTags {
+		if tag.Name == nil || tag.Content == nil || !slices.Contains(declaredKeywordNames, strings.ToLower(*tag.Name)) {
+			continue
+		}
+		for value := range strings.SplitSeq(*tag.Content, ",") {
+			add(value)
+		}
+	}
+	return declared
+}
+
+// mergeDeclaredKeywords puts the declared keywords of result that occur in
+// its content ahead of the extracted ones, with a score of 1, and keeps
+// the first limit. Declared keywords the text never mentions, which are
+// often search-engine filler, are left out.
+func mergeDeclaredKeywords(result *Result, limit int) {
+	declared := declaredKeywords(result)
+	if len(declared) == 0 {
+		return
+	}
+	if limit <= 0 {
+		limit = nlp.DefaultKeywordLimit
+	}
+	text := " " + strings.Join(nlp.Words(strings.Join(nlp.Blocks(result.Content), "\n")), " ") + " "
+
+	var merged []Keyword
+	seen := map[string]bool{}
+	for _, keyword := range declared {
+		words := strings.Join(nlp.Words(keyword), " ")
+		if words == "" || seen[words] || !strings.Contains(text, " "+words+" ") {
+			continue
+		}
+		seen[words] = true
+		merged = append(merged, Keyword{Text: keyword, Score: 1})
+	}
+	for _, keyword := range result.Keywords {
+		words := strings.Join(nlp.Words(keyword.Text), " ")
+		if seen[words] {
+			continue
+		}
+		seen[words] = true
+		merged = append(merged, keyword)
+	}
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	result.Keywords = merged
+}
@@ -0,0 +1,52 @@
+package defuddle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keywordsArticle = `<html><head><title>Transit plan</title>
+	<meta property="article:tag" content="Public Transit">
+	<meta property="article:tag" content="Budget">
+	<meta name="keywords" content="public transit, bus lanes,  cheap flights , city council">
+	<meta name="news_keywords" content="Bus Lanes">
+	</head><body><article><h1>Transit plan</h1><p>` +
+	strings.Repeat("The city council approved new bus lanes and a public transit plan after a year of hearings. ", 8) +
+	`</p></article></body></html>`
+
+func TestParseMergesDeclaredKeywords(t *testing.T) {
+	t.Parallel()
+
+	result, err := ParseFromString(context.Background(), keywordsArticle, &Options{KeywordExtractor: NewKeywordExtractor(), MaxKeywords: 5})
+	require.NoError(t, err)
+	require.Len(t, result.Keywords, 5)
+	assert.Equal(t, []Keyword{{Text: "Public Transit", Score: 1}, {Text: "bus lanes", Score: 1}, {Text: "city council", Score: 1}}, result.Keywords[:3])
+	for _, keyword := range result.Keywords[3:] {
+		assert.NotContains(t, []string{"public transit", "bus lanes", "city council", "budget", "cheap flights"}, keyword.Text)
+	}
+
+	ner := &entityExtractor{}
+	result, err = ParseFromString(context.Background(), keywordsArticle, &Options{KeywordExtractor: ner, MaxKeywords: 4})
+	require.NoError(t, err)
+	assert.Equal(t, []Keyword{{Text: "Public Transit", Score: 1}, {Text: "bus lanes", Score: 1}, {Text: "city council", Score: 1}, {Text: "Ada Lovelace", Score: 0.9}}, result.Keywords)
+
+	result, err = ParseFromString(context.Background(), keywordsArticle, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Keywords)
+}
+
+func TestDeclaredKeywords(t *testing.T) {
+	t.Parallel()
+
+	name, property := "Keywords", "article:tag"
+	content, tag := "go, parsing,, Go ", "Parsing"
+	result := &Result{
+		Metadata: Metadata{Tags: []string{tag}},
+		MetaTags: []MetaTag{{Name: &name, Content: &content}, {Property: &property, Content: &tag}},
+	}
+	assert.Equal(t, []string{"Parsing", "go"}, declaredKeywords(result))
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-json-experiment/json"
+)
+
+// DefaultMaxDomains is the number of domains Domains tracks individually
+// when NewDomains is given no limit.
+const DefaultMaxDomains = 10000
+
+// OtherDomains is the key under which Domains counts parses of domains
+// beyond its limit.
+const OtherDomains = "(other)"
+
+// ParseReport describes one parse for per-domain aggregation.
+type ParseReport struct {
+	// Domain is the result's domain, or the host of Options.URL when
+	// parsing failed.
+	Domain   string
+	Duration time.Duration
+	// Extractor names the site-specific extractor that handled the page,
+	// and is empty when the generic pipeline did.
+	Extractor string
+	// Retried reports that the sparse-content retry pass ran.
+	Retried   bool
+	WordCount int
+	Err       error
+}
+
+// Reporter is implemented by Collectors that also want one ParseReport per
+// parse. Cache hits are not reported.
+type Reporter interface {
+	ParseReported(report ParseReport)
+}
+
+// DomainStats aggregates the parses of one domain.
+type DomainStats struct {
+	Parses int `json:"parses"`
+	Errors int `json:"errors"`
+	// Fallbacks counts successful parses no site-specific extractor handled.
+	Fallbacks int `json:"fallbacks"`
+	Retries   int `json:"retries"`
+	// ParseTime is the total duration of every parse.
+	ParseTime time.Duration `json:"-"`
+	// Words is the total word count of successful parses.
+	Words int `json:"words"`
+}
+
+// FallbackRate is the share of successful parses handled by the generic
+// pipeline instead of an extractor.
+func (s DomainStats) FallbackRate() float64 {
+	return ratio(s.Fallbacks, s.Parses-s.Errors)
+}
+
+// RetryRate is the share of parses that needed the sparse-content retry.
+func (s DomainStats) RetryRate() float64 {
+	return ratio(s.Retries, s.Parses)
+}
+
+// ErrorRate is the share of parses that failed.
+func (s DomainStats) ErrorRate() float64 {
+	return ratio(s.Errors, s.Parses)
+}
+
+// AvgParseTime is the mean duration of a parse.
+func (s DomainStats) AvgParseTime() time.Duration {
+	if s.Parses == 0 {
+		return 0
+	}
+	return s.ParseTime / time.Duration(s.Parses)
+}
+
+// AvgWordCount is the mean word count of successful parses.
+func (s DomainStats) AvgWordCount() float64 {
+	return ratio(s.Words, s.Parses-s.Errors)
+}
+
+func ratio(n, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// Domains is an in-process Collector that tallies parses per domain, so
+// operators can spot domains whose fallback, retry, or error rate rose
+// after a layout change. It serves its tallies as JSON over HTTP.
+type Domains struct {
+	Nop
+	mu      sync.Mutex
+	limit   int
+	domains map[string]DomainStats
+}
+
+// NewDomains creates an empty per-domain aggregator that tracks up to
+// maxDomains domains individually, or DefaultMaxDomains when maxDomains is
+// not positive. Parses of further domains are counted under OtherDomains.
+func NewDomains(maxDomains int) *Domains {
+	if maxDomains <= 0 {
+		maxDomains = DefaultMaxDomains
+	}
+	return &Domains{limit: maxDomains, domains: make(map[string]DomainStats)}
+}
+
+// ParseReported implements Reporter.
+func (d *Domains) ParseReported(report ParseReport) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	domain := report.Domain
+	if _, ok := d.domains[domain]; !ok && len(d.domains) >= d.limit {
+		domain = OtherDomains
+	}
+	stats := d.domains[domain]
+	stats.Parses++
+	stats.ParseTime += report.Duration
+	if report.Retried {
+		stats.Retries++
+	}
+	if report.Err != nil {
+		stats.Errors++
+	} else {
+		stats.Words += report.WordCount
+		if report.Extractor == "" {
+			stats.Fallbacks++
+		}
+	}
+	d.domains[domain] = stats
+}
+
+// Snapshot returns a copy of the per-domain tallies.
+func (d *Domains) Snapshot() map[string]DomainStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return maps.Clone(d.domains)
+}
+
+// domainSummary is the JSON view of DomainStats served by Domains.
+type domainSummary struct {
+	DomainStats    `json:",inline"`
+	FallbackRate   float64 `json:"fallbackRate"`
+	RetryRate      float64 `json:"retryRate"`
+	ErrorRate      float64 `json:"errorRate"`
+	AvgParseTimeMs float64 `json:"avgParseTimeMs"`
+	AvgWordCount   float64 `json:"avgWordCount"`
+}
+
+// ServeHTTP writes the per-domain tallies and their rates as a JSON object
+// keyed by domain.
+func (d *Domains) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	snapshot := d.Snapshot()
+	summaries := make(map[string]domainSummary, len(snapshot))
+	for domain, stats := range snapshot {
+		summaries[domain] = domainSummary{
+			DomainStats:    stats,
+			FallbackRate:   stats.FallbackRate(),
+			RetryRate:      stats.RetryRate(),
+			ErrorRate:      stats.ErrorRate(),
+			AvgParseTimeMs: float64(stats.AvgParseTime()) / float64(time.Millisecond),
+			AvgWordCount:   stats.AvgWordCount(),
+		}
+	}
+	data, err := json.Marshal(summaries, json.Deterministic(true))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
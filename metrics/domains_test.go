@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainsAggregatesReports(t *testing.T) {
+	t.Parallel()
+
+	domains := NewDomains(0)
+	domains.ParseReported(ParseReport{Domain: "example.com", Duration: 2 * time.Millisecond, WordCount: 300})
+	domains.ParseReported(ParseReport{Domain: "example.com", Duration: 4 * time.Millisecond, Extractor: "rules", Retried: true, WordCount: 100})
+	domains.ParseReported(ParseReport{Domain: "example.com", Duration: 6 * time.Millisecond, Err: errors.New("too large")})
+
+	stats := domains.Snapshot()["example.com"]
+	assert.Equal(t, DomainStats{Parses: 3, Errors: 1, Fallbacks: 1, Retries: 1, ParseTime: 12 * time.Millisecond, Words: 400}, stats)
+	assert.InDelta(t, 0.5, stats.FallbackRate(), 1e-9)
+	assert.InDelta(t, 1.0/3, stats.RetryRate(), 1e-9)
+	assert.InDelta(t, 1.0/3, stats.ErrorRate(), 1e-9)
+	assert.Equal(t, 4*time.Millisecond, stats.AvgParseTime())
+	assert.InDelta(t, 200, stats.AvgWordCount(), 1e-9)
+
+	assert.Zero(t, DomainStats{}.FallbackRate())
+	assert.Zero(t, DomainStats{}.AvgParseTime())
+}
+
+func TestDomainsCountsDomainsBeyondTheLimitTogether(t *testing.T) {
+	t.Parallel()
+
+	domains := NewDomains(1)
+	domains.ParseReported(ParseReport{Domain: "a.example"})
+	domains.ParseReported(ParseReport{Domain: "b.example"})
+	domains.ParseReported(ParseReport{Domain: "c.example"})
+	domains.ParseReported(ParseReport{Domain: "a.example"})
+
+	snapshot := domains.Snapshot()
+	assert.Len(t, snapshot, 2)
+	assert.Equal(t, 2, snapshot["a.example"].Parses)
+	assert.Equal(t, 2, snapshot[OtherDomains].Parses)
+}
+
+func TestDomainsServesJSON(t *testing.T) {
+	t.Parallel()
+
+	domains := NewDomains(0)
+	domains.ParseReported(ParseReport{Domain: "example.com", Duration: 3 * time.Millisecond, WordCount: 50})
+
+	recorder := httptest.NewRecorder()
+	domains.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/domains", nil))
+
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var body map[string]map[string]float64
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, map[string]float64{
+		"parses": 1, "errors": 0, "fallbacks": 1, "retries": 0, "words": 50,
+		"fallbackRate": 1, "retryRate": 0, "errorRate": 0, "avgParseTimeMs": 3, "avgWordCount": 50,
+	}, body["example.com"])
+}
+
+var (
+	_ Collector = (*Domains)(nil)
+	_ Reporter  = (*Domains)(nil)
+)
@@ -0,0 +1,165 @@
+// Package metrics defines the instrumentation hooks that defuddle reports
+// parse and fetch activity to.
+//
+// Embedders plug a Collector into defuddle.Options.Metrics and forward the
+// calls to Prometheus, OpenTelemetry, expvar, or logs. Memory is a ready-made
+// in-process Collector for tests and simple dashboards.
+package metrics
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// Stage names a timed step of the parse pipeline.
+type Stage string
+
+// Parse pipeline stages reported through Collector.StageObserved.
+const (
+	// StageMetadata covers schema.org, meta tag, and metadata extraction.
+	StageMetadata Stage = "metadata"
+	// StageExtractor covers extractor lookup and site-specific extraction,
+	// reported only when an extractor handles the page.
+	StageExtractor Stage = "extractor"
+	// StageSelection covers main-content selection.
+	StageSelection Stage = "selection"
+	// StageCleanup covers image, hidden-element, score-based, and selector removal.
+	StageCleanup Stage = "cleanup"
+	// StageStandardize covers content standardization and alt-text generation.
+	StageStandardize Stage = "standardize"
+	// StageMarkdown covers Markdown conversion.
+	StageMarkdown Stage = "markdown"
+)
+
+// Collector receives parse and fetch measurements. Implementations must be
+// safe for concurrent use. Embed Nop to implement only the methods you need.
+type Collector interface {
+	// ParseStarted is called when Parse begins; pair it with ParseFinished
+	// to maintain an in-flight gauge.
+	ParseStarted()
+	// ParseFinished is called when Parse returns, with its error.
+	ParseFinished(duration time.Duration, err error)
+	// StageObserved reports the duration of one pipeline stage. Stages run
+	// again, and are reported again, when Parse retries a sparse result.
+	StageObserved(stage Stage, duration time.Duration)
+	// ExtractorHit reports that a site-specific extractor handled the page.
+	ExtractorHit(extractor string)
+	// FetchFinished reports a ParseFromURL fetch. statusCode is 0 when no
+	// response was received; err is non-nil for transport and HTTP status failures.
+	FetchFinished(duration time.Duration, statusCode int, err error)
+}
+
+// Nop is a Collector that discards every measurement.
+type Nop struct{}
+
+// ParseStarted implements Collector.
+func (Nop) ParseStarted() {}
+
+// ParseFinished implements Collector.
+func (Nop) ParseFinished(time.Duration, error) {}
+
+// StageObserved implements Collector.
+func (Nop) StageObserved(Stage, time.Duration) {}
+
+// ExtractorHit implements Collector.
+func (Nop) ExtractorHit(string) {}
+
+// FetchFinished implements Collector.
+func (Nop) FetchFinished(time.Duration, int, error) {}
+
+// StageStats aggregates the observations of one stage.
+type StageStats struct {
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+	Max   time.Duration `json:"max"`
+}
+
+// Snapshot is a point-in-time copy of Memory counters.
+type Snapshot struct {
+	Parses        int                  `json:"parses"`
+	ParseErrors   int                  `json:"parseErrors"`
+	InFlight      int                  `json:"inFlight"`
+	ParseTime     time.Duration        `json:"parseTime"`
+	Stages        map[Stage]StageStats `json:"stages"`
+	ExtractorHits map[string]int       `json:"extractorHits"`
+	Fetches       int                  `json:"fetches"`
+	FetchErrors   int                  `json:"fetchErrors"`
+	FetchStatuses map[int]int          `json:"fetchStatuses"`
+}
+
+// Memory is an in-process Collector that keeps running totals.
+type Memory struct {
+	mu       sync.Mutex
+	snapshot Snapshot
+}
+
+// NewMemory creates an empty in-memory collector.
+func NewMemory() *Memory {
+	return &Memory{snapshot: Snapshot{
+		Stages:        make(map[Stage]StageStats),
+		ExtractorHits: make(map[string]int),
+		FetchStatuses: make(map[int]int),
+	}}
+}
+
+// ParseStarted implements Collector.
+func (m *Memory) ParseStarted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot.InFlight++
+}
+
+// ParseFinished implements Collector.
+func (m *Memory) ParseFinished(duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot.InFlight--
+	m.snapshot.Parses++
+	m.snapshot.ParseTime += duration
+	if err != nil {
+		m.snapshot.ParseErrors++
+	}
+}
+
+// StageObserved implements Collector.
+func (m *Memory) StageObserved(stage Stage, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.snapshot.Stages[stage]
+	stats.Count++
+	stats.Total += duration
+	stats.Max = max(stats.Max, duration)
+	m.snapshot.Stages[stage] = stats
+}
+
+// ExtractorHit implements Collector.
+func (m *Memory) ExtractorHit(extractor string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot.ExtractorHits[extractor]++
+}
+
+// FetchFinished implements Collector.
+func (m *Memory) FetchFinished(_ time.Duration, statusCode int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot.Fetches++
+	if statusCode != 0 {
+		m.snapshot.FetchStatuses[statusCode]++
+	}
+	if err != nil {
+		m.snapshot.FetchErrors++
+	}
+}
+
+// Snapshot returns a copy of the current counters.
+func (m *Memory) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := m.snapshot
+	out.Stages = maps.Clone(m.snapshot.Stages)
+	out.ExtractorHits = maps.Clone(m.snapshot.ExtractorHits)
+	out.FetchStatuses = maps.Clone(m.snapshot.FetchStatuses)
+	return out
+}
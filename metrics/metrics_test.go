@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAggregatesMeasurements(t *testing.T) {
+	t.Parallel()
+
+	memory := NewMemory()
+	memory.ParseStarted()
+	memory.ParseStarted()
+	memory.ParseFinished(3*time.Millisecond, nil)
+	memory.StageObserved(StageCleanup, time.Millisecond)
+	memory.StageObserved(StageCleanup, 4*time.Millisecond)
+	memory.ExtractorHit("RedditExtractor")
+	memory.FetchFinished(time.Millisecond, 0, errors.New("dial tcp: refused"))
+	memory.FetchFinished(time.Millisecond, 503, errors.New("status 503"))
+
+	snapshot := memory.Snapshot()
+	assert.Equal(t, 1, snapshot.InFlight)
+	assert.Equal(t, 1, snapshot.Parses)
+	assert.Equal(t, 3*time.Millisecond, snapshot.ParseTime)
+	assert.Equal(t, StageStats{Count: 2, Total: 5 * time.Millisecond, Max: 4 * time.Millisecond}, snapshot.Stages[StageCleanup])
+	assert.Equal(t, map[string]int{"RedditExtractor": 1}, snapshot.ExtractorHits)
+	assert.Equal(t, 2, snapshot.Fetches)
+	assert.Equal(t, 2, snapshot.FetchErrors)
+	assert.Equal(t, map[int]int{503: 1}, snapshot.FetchStatuses)
+
+	memory.ExtractorHit("RedditExtractor")
+	assert.Equal(t, 1, snapshot.ExtractorHits["RedditExtractor"], "snapshot must not alias live counters")
+}
+
+func TestMemoryIsSafeForConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	memory := NewMemory()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			memory.ParseStarted()
+			memory.StageObserved(StageMetadata, time.Microsecond)
+			memory.ParseFinished(time.Microsecond, nil)
+		})
+	}
+	wg.Wait()
+
+	snapshot := memory.Snapshot()
+	assert.Equal(t, 8, snapshot.Parses)
+	assert.Equal(t, 0, snapshot.InFlight)
+	assert.Equal(t, 8, snapshot.Stages[StageMetadata].Count)
+}
+
+var _ Collector = Nop{}
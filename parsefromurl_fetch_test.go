@@ -0,0 +1,105 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fetchTestPage = `<html><head><title>Fetched</title></head><body><article><p>The fetched article.</p></article></body></html>`
+
+func TestParseFromURLUsesFetchOptions(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/echo":
+			_, _ = w.Write([]byte(`<html><head><title>` + r.UserAgent() + ` ` + r.Header.Get("X-Token") + `</title></head><body><p>Echo.</p></body></html>`))
+		case "/large":
+			_, _ = w.Write([]byte(fetchTestPage))
+		case "/streamed":
+			// Flushing first leaves Content-Length unset
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(fetchTestPage))
+		}
+	}))
+	defer server.Close()
+
+	result, err := ParseFromURL(context.Background(), server.URL+"/echo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultUserAgent, result.Title)
+
+	result, err = ParseFromURL(context.Background(), server.URL+"/echo", &Options{Fetch: &FetchOptions{
+		UserAgent: "test-agent",
+		Headers:   http.Header{"X-Token": {"secret"}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "test-agent secret", result.Title)
+
+	for _, path := range []string{"/large", "/streamed"} {
+		_, err = ParseFromURL(context.Background(), server.URL+path, &Options{Fetch: &FetchOptions{MaxBodySize: 32}})
+		require.ErrorIs(t, err, ErrResponseTooLarge, path)
+
+		result, err = ParseFromURL(context.Background(), server.URL+path, &Options{Fetch: &FetchOptions{MaxBodySize: int64(len(fetchTestPage))}})
+		require.NoError(t, err, path)
+		assert.Equal(t, "Fetched", result.Title)
+	}
+
+	var requests atomic.Int32
+	httpClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	_, err = ParseFromURL(context.Background(), server.URL+"/large", &Options{Fetch: &FetchOptions{HTTPClient: httpClient, Proxy: "::invalid"}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+
+	_, err = ParseFromURL(context.Background(), server.URL+"/large", &Options{Fetch: &FetchOptions{Proxy: "::invalid"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid proxy URL")
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestParseFromURLRetriesTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(fetchTestPage))
+	}))
+	defer server.Close()
+
+	result, err := ParseFromURL(context.Background(), server.URL, &Options{Fetch: &FetchOptions{MaxRetries: 2, RetryBackoff: time.Millisecond}})
+	require.NoError(t, err)
+	assert.Equal(t, "Fetched", result.Title)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	attempts.Store(0)
+	_, err = ParseFromURL(context.Background(), server.URL, &Options{Fetch: &FetchOptions{MaxRetries: 1, RetryBackoff: time.Millisecond}})
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.Equal(t, int32(2), attempts.Load())
+
+	attempts.Store(0)
+	_, err = ParseFromURL(context.Background(), server.URL, nil)
+	require.ErrorIs(t, err, ErrHTTPStatus)
+	assert.Equal(t, int32(1), attempts.Load())
+}
@@ -31,7 +31,7 @@ func TestParseFromURLUsesRedirectTargetForRelativeMetadata(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, result)
 
-	assert.Equal(t, server.URL+"/articles/story/", options.URL)
+	assert.Empty(t, options.URL)
 	assert.Equal(t, server.URL+"/articles/story/icon.svg", result.Favicon)
 }
 
@@ -61,3 +61,58 @@ func TestParseFromURLHTTPStatusErrorUsesRedirectTarget(t *testing.T) {
 	require.ErrorAs(t, err, &statusErr)
 	assert.Equal(t, server.URL+"/unavailable", statusErr.URL)
 }
+
+func TestParseFromURLFollowsClientRedirects(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/start":
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=/hop"></head><body><p>Redirecting…</p></body></html>`))
+		case "/hop":
+			_, _ = w.Write([]byte(`<html><body><p>One moment.</p><script>window.location.replace("/articles/story/");</script></body></html>`))
+		case "/articles/story/":
+			_, _ = w.Write([]byte(`<html><head><title>Destination</title><link rel="icon" href="icon.svg"></head><body><article><h1>Destination</h1><p>The article the interstitial pages pointed at.</p></article></body></html>`))
+		case "/loop-a":
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0;url=/loop-b"></head><body></body></html>`))
+		case "/loop-b":
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0;url=/loop-a"></head><body></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	options := &Options{MaxClientRedirects: 3}
+	result, err := ParseFromURL(context.Background(), server.URL+"/start", options)
+	require.NoError(t, err)
+	assert.Equal(t, "Destination", result.Title)
+	assert.Empty(t, options.URL)
+	assert.Equal(t, server.URL+"/articles/story/icon.svg", result.Favicon)
+	assert.Empty(t, result.ClientRedirect)
+
+	// An explicit URL names the start page, so the destination replaces it
+	// for the parse but not in the caller's options
+	options = &Options{URL: "https://example.com/start", MaxClientRedirects: 3}
+	result, err = ParseFromURL(context.Background(), server.URL+"/start", options)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/articles/story/icon.svg", result.Favicon)
+	assert.Equal(t, "https://example.com/start", options.URL)
+
+	// Out of hops, the page reached reports where it points
+	result, err = ParseFromURL(context.Background(), server.URL+"/start", &Options{MaxClientRedirects: 1})
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/articles/story/", result.ClientRedirect)
+
+	result, err = ParseFromURL(context.Background(), server.URL+"/start", nil)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/hop", result.ClientRedirect)
+
+	// A redirect back to a page already fetched is not followed
+	options = &Options{MaxClientRedirects: 10}
+	result, err = ParseFromURL(context.Background(), server.URL+"/loop-a", options)
+	require.NoError(t, err)
+	assert.Empty(t, options.URL)
+	assert.Equal(t, server.URL+"/loop-a", result.ClientRedirect)
+}
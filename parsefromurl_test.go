@@ -4,11 +4,15 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/kaptinlin/requests"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go/metrics"
 )
 
 func TestParseFromURL(t *testing.T) {
@@ -23,14 +27,14 @@ func TestParseFromURL(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, result)
 
-	assert.Equal(t, server.URL, options.URL)
+	assert.Empty(t, options.URL)
 	assert.Equal(t, "Fetched Title", result.Title)
 	assert.Contains(t, result.Content, "Fetched body content from test server")
 }
 
 func TestParseFromURLUsesDefaultRequestsClient(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "Mozilla/5.0 (compatible; Defuddle/1.0; +https://github.com/kaptinlin/defuddle-go)", r.Header.Get("User-Agent"))
+		assert.Equal(t, "Mozilla/5.0 (compatible; Defuddle/"+strings.TrimPrefix(Version(), "v")+"; +https://github.com/kaptinlin/defuddle-go)", r.Header.Get("User-Agent"))
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		_, _ = w.Write([]byte(`<html><head><title>Default Client Title</title></head><body><article><p>Default client content.</p></article></body></html>`))
 	}))
@@ -60,7 +64,7 @@ func TestParseFromURLUsesCustomRequestsClient(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, result)
 
-	assert.Equal(t, server.URL, options.URL)
+	assert.Empty(t, options.URL)
 	assert.Equal(t, "Custom Client Title", result.Title)
 	assert.Contains(t, result.Content, "Custom client content")
 }
@@ -166,10 +170,29 @@ func TestParseFromURLUsesFinalResponseURLForMetadata(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, result)
 
-	assert.Equal(t, server.URL+"/articles/story", options.URL)
+	assert.Empty(t, options.URL)
 	assert.Equal(t, server.URL+"/favicon.ico", result.Favicon)
 }
 
+func TestParseFromURLReusesOptionsAcrossCalls(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>` + r.URL.Path + `</title><link rel="icon" href="icon.svg"></head><body><article><p>Body of ` + r.URL.Path + `.</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	// Each call resolves against its own page, not the one parsed before
+	options := &Options{}
+	for _, path := range []string{"/first/", "/second/"} {
+		result, err := ParseFromURL(context.Background(), server.URL+path, options)
+		require.NoError(t, err)
+		assert.Equal(t, server.URL+path+"icon.svg", result.Favicon)
+		assert.Empty(t, options.URL)
+	}
+}
+
 func TestParseFromURLHonorsContextCancellation(t *testing.T) {
 	t.Parallel()
 
@@ -186,3 +209,66 @@ func TestParseFromURLHonorsContextCancellation(t *testing.T) {
 	require.ErrorIs(t, err, context.Canceled)
 	assert.Nil(t, result)
 }
+
+func TestParseFromURLReportsMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Metrics</title></head><body><article><h1>Metrics</h1><p>Measured body content.</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	collector := metrics.NewMemory()
+
+	_, err := ParseFromURL(context.Background(), server.URL, &Options{Markdown: true, Metrics: collector})
+	require.NoError(t, err)
+	_, err = ParseFromURL(context.Background(), server.URL+"/missing", &Options{Metrics: collector})
+	require.ErrorIs(t, err, ErrHTTPStatus)
+
+	snapshot := collector.Snapshot()
+	assert.Equal(t, 2, snapshot.Fetches)
+	assert.Equal(t, 1, snapshot.FetchErrors)
+	assert.Equal(t, map[int]int{http.StatusOK: 1, http.StatusNotFound: 1}, snapshot.FetchStatuses)
+	assert.Equal(t, 1, snapshot.Parses)
+	assert.Equal(t, 0, snapshot.InFlight)
+	for _, stage := range []metrics.Stage{metrics.StageMetadata, metrics.StageSelection, metrics.StageCleanup, metrics.StageStandardize, metrics.StageMarkdown} {
+		assert.Positive(t, snapshot.Stages[stage].Count, "stage %s", stage)
+	}
+}
+
+func TestParseFromURLReturnsCachedResultWithoutFetching(t *testing.T) {
+	t.Parallel()
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(fetchTestPage))
+	}))
+	defer server.Close()
+
+	cache := &mapCache{results: make(map[string]*Result)}
+	first, err := ParseFromURL(context.Background(), server.URL+"/a", &Options{Cache: cache})
+	require.NoError(t, err)
+	second, err := ParseFromURL(context.Background(), server.URL+"/a", &Options{Cache: cache})
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// Another URL or other options miss
+	_, err = ParseFromURL(context.Background(), server.URL+"/b", &Options{Cache: cache})
+	require.NoError(t, err)
+	_, err = ParseFromURL(context.Background(), server.URL+"/a", &Options{Cache: cache, Markdown: true})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), fetches.Load())
+
+	// Failures are not cached
+	stored := len(cache.results)
+	server.Close()
+	_, err = ParseFromURL(context.Background(), server.URL+"/c", &Options{Cache: cache})
+	require.Error(t, err)
+	assert.Len(t, cache.results, stored)
+}
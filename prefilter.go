@@ -0,0 +1,202 @@
+package defuddle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// prefilterStats describes what prefilterHTML dropped.
+type prefilterStats struct {
+	// scriptBytes is the inline script dropped, which shell detection
+	// counts as if it were still there
+	scriptBytes int
+	// truncated is set when output stopped at the byte limit
+	truncated bool
+}
+
+// prefilterEnabled reports whether the prefilter runs for options, with
+// byDefault deciding when Options.Prefilter is empty. It never runs with
+// Options.SourceMap, whose byte ranges are into the HTML as given.
+func prefilterEnabled(options *Options, byDefault bool) (bool, error) {
+	var mode PrefilterMode
+	if options != nil {
+		mode = options.Prefilter
+	}
+	switch mode {
+	case "", PrefilterOn, PrefilterOff:
+	default:
+		return false, fmt.Errorf("%w: %q", ErrUnsupportedPrefilterMode, mode)
+	}
+	switch {
+	case options != nil && options.SourceMap:
+		return false, nil
+	case mode == "":
+		return byDefault, nil
+	default:
+		return mode == PrefilterOn, nil
+	}
+}
+
+// prefilterMaxBytes returns the output limit of the prefilter, or 0.
+func prefilterMaxBytes(options *Options) int64 {
+	if options == nil || options.Limits == nil {
+		return 0
+	}
+	return options.Limits.MaxHTMLBytes
+}
+
+// prefilterHTML copies the HTML read from r to w token by token, before a
+// document is built from it, dropping what extraction never reads but a
+// parser would still turn into nodes: comments, the content of svg
+// elements and styles, and inline scripts longer than a redirect stub
+// other than JSON-LD and math sources. The elements themselves are kept
+// with their attributes, so external scripts, mount points, and the
+// placement of icons are still seen. Output stops before it would exceed
+// maxBytes when maxBytes is positive, cutting a text token at the limit.
+func prefilterHTML(w io.Writer, r io.Reader, maxBytes int64) (prefilterStats, error) {
+	var stats prefilterStats
+	out := bufio.NewWriter(w)
+	z := html.NewTokenizer(r)
+	var written int64
+	var svgDepth int
+	// rawTag is the script or style element whose content comes next
+	var rawTag atom.Atom
+	var keepScript bool
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return stats, err
+			}
+			break
+		}
+		// Raw shares the tokenizer's buffer until the next call to Next;
+		// TagName lowercases the tag name in it, which HTML ignores
+		raw := z.Raw()
+
+		switch tt {
+		case html.CommentToken:
+			continue
+		case html.TextToken:
+			if svgDepth > 0 {
+				continue
+			}
+			switch rawTag {
+			case atom.Style:
+				continue
+			case atom.Script:
+				if !keepScript && len(raw) > redirectMaxScriptBytes {
+					stats.scriptBytes += len(raw)
+					continue
+				}
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			a := atom.Lookup(name)
+			// Only the tags of the outermost svg element are kept
+			switch {
+			case a == atom.Svg && tt == html.StartTagToken:
+				svgDepth++
+				if svgDepth > 1 {
+					continue
+				}
+			case a == atom.Svg && tt == html.EndTagToken && svgDepth > 0:
+				svgDepth--
+				if svgDepth > 0 {
+					continue
+				}
+			case svgDepth > 0:
+				continue
+			}
+			rawTag = 0
+			if tt == html.StartTagToken && (a == atom.Script || a == atom.Style) {
+				rawTag = a
+				keepScript = a == atom.Script && hasAttr && keptScript(z)
+			}
+		}
+
+		if maxBytes > 0 && written+int64(len(raw)) > maxBytes {
+			if tt == html.TextToken {
+				cut := int(maxBytes - written)
+				for cut > 0 && !utf8.RuneStart(raw[cut]) {
+					cut--
+				}
+				if _, err := out.Write(raw[:cut]); err != nil {
+					return stats, err
+				}
+			}
+			stats.truncated = true
+			break
+		}
+		if _, err := out.Write(raw); err != nil {
+			return stats, err
+		}
+		written += int64(len(raw))
+	}
+	return stats, out.Flush()
+}
+
+// keptScript reports whether the script whose attributes z reads next
+// holds JSON-LD or a math source, which are read after parsing.
+func keptScript(z *html.Tokenizer) bool {
+	for {
+		key, value, more := z.TagAttr()
+		if string(key) == "type" {
+			scriptType := strings.ToLower(strings.TrimSpace(string(value)))
+			return scriptType == "application/ld+json" || scriptType == "application/x-tex" ||
+				scriptType == "text/latex" || strings.HasPrefix(scriptType, "math/")
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// prefilterString runs prefilterHTML over s.
+func prefilterString(s string, maxBytes int64) (string, prefilterStats, error) {
+	var filtered strings.Builder
+	stats, err := prefilterHTML(&filtered, strings.NewReader(s), maxBytes)
+	if err != nil {
+		return "", stats, fmt.Errorf("failed to prefilter HTML: %w", err)
+	}
+	return filtered.String(), stats, nil
+}
+
+// prefilterReader returns a reader of the HTML read from r after
+// prefilterHTML, which runs as the result is read. stats is complete once
+// the reader returns io.EOF.
+func prefilterReader(r io.Reader, maxBytes int64) (io.ReadCloser, *prefilterStats) {
+	stats := &prefilterStats{}
+	pr, pw := io.Pipe()
+	go func() {
+		s, err := prefilterHTML(pw, r, maxBytes)
+		*stats = s
+		pw.CloseWithError(err)
+	}()
+	return pr, stats
+}
+
+// prefilterIssues returns the Issue recording a cut at maxBytes, or nil.
+func prefilterIssues(stats prefilterStats, maxBytes int64) []Issue {
+	if !stats.truncated {
+		return nil
+	}
+	return []Issue{{
+		Code:    IssueHTMLTruncated,
+		Message: fmt.Sprintf("the HTML was cut at %d bytes after dropping scripts, styles, svg content, and comments", maxBytes),
+	}}
+}
+
+// prefiltered records what the prefilter dropped from d's input.
+func (d *Defuddle) prefiltered(stats prefilterStats, maxBytes int64) {
+	d.droppedScriptBytes = stats.scriptBytes
+	d.inputIssues = prefilterIssues(stats, maxBytes)
+}
@@ -0,0 +1,161 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefilterHTML(t *testing.T) {
+	t.Parallel()
+
+	payload := strings.Repeat("x", redirectMaxScriptBytes+1)
+	tests := []struct {
+		name        string
+		html        string
+		maxBytes    int64
+		want        string
+		scriptBytes int
+		truncated   bool
+	}{
+		{
+			name: "comments",
+			html: `<p>a<!-- note -->b</p>`,
+			want: `<p>ab</p>`,
+		},
+		{
+			name: "styles",
+			html: `<style>p { color: red }</style><p>a</p>`,
+			want: `<style></style><p>a</p>`,
+		},
+		{
+			name: "nested svg",
+			html: `<p><SVG viewBox="0 0 1 1"><g><svg><path d="M0"/></svg></g><text>icon</text></SVG>a</p>`,
+			want: `<p><svg viewBox="0 0 1 1"></svg>a</p>`,
+		},
+		{
+			name:        "inline payload",
+			html:        `<div id="__next"></div><script id="__NEXT_DATA__" type="application/json">` + payload + `</script>`,
+			want:        `<div id="__next"></div><script id="__NEXT_DATA__" type="application/json"></script>`,
+			scriptBytes: len(payload),
+		},
+		{
+			name: "kept scripts",
+			html: `<script type="application/ld+json">{"a":"` + payload + `"}</script><script type="math/tex">` + payload + `</script><script>location.href="/next"</script><script src="app.js"></script>`,
+			want: `<script type="application/ld+json">{"a":"` + payload + `"}</script><script type="math/tex">` + payload + `</script><script>location.href="/next"</script><script src="app.js"></script>`,
+		},
+		{
+			name:      "truncated inside text",
+			html:      `<p>añb</p><p>more</p>`,
+			maxBytes:  5,
+			want:      `<p>a`,
+			truncated: true,
+		},
+		{
+			name:     "at the limit",
+			html:     `<p>ab</p>`,
+			maxBytes: 9,
+			want:     `<p>ab</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, stats, err := prefilterString(tt.html, tt.maxBytes)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.scriptBytes, stats.scriptBytes)
+			assert.Equal(t, tt.truncated, stats.truncated)
+		})
+	}
+}
+
+func TestPrefilterKeepsParseResults(t *testing.T) {
+	t.Parallel()
+
+	icon := `<svg viewBox="0 0 24 24">` + strings.Repeat(`<path d="M12 2L2 7l10 5 10-5-10-5z"/>`, 200) + `</svg>`
+	html := `<html><head><title>Transit plan</title>
+		<style>` + strings.Repeat(".a{color:red}", 500) + `</style>
+		<script type="application/ld+json">{"@context":"https://schema.org","@type":"NewsArticle","headline":"Transit plan","author":{"@type":"Person","name":"Ada Lane"}}</script>
+		<script>window.__STATE__ = ` + strings.Repeat(`{"k":"v"},`, 500) + `</script>
+	</head><body><!-- build 1234 --><article><h1>Transit plan</h1>` + icon +
+		`<p>` + strings.Repeat("The council published the transit plan after a year of hearings. ", 20) + `</p></article></body></html>`
+
+	whole, err := ParseFromString(context.Background(), html, &Options{Prefilter: PrefilterOff})
+	require.NoError(t, err)
+	filtered, err := ParseFromString(context.Background(), html, &Options{Prefilter: PrefilterOn})
+	require.NoError(t, err)
+
+	assert.Equal(t, whole.Title, filtered.Title)
+	assert.Equal(t, "Ada Lane", filtered.Author)
+	assert.Equal(t, whole.SchemaOrgData, filtered.SchemaOrgData)
+	assert.Equal(t, whole.WordCount, filtered.WordCount)
+	assert.Empty(t, filtered.Issues)
+}
+
+func TestPrefilterKeepsShellDetection(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><body><div id="__next"></div><script id="__NEXT_DATA__" type="application/json">` + strings.Repeat("x", 4096) + `</script></body></html>`
+	_, err := ParseFromString(context.Background(), html, &Options{Prefilter: PrefilterOn})
+	require.ErrorIs(t, err, ErrClientRenderedPage)
+
+	_, err = ParseReader(context.Background(), strings.NewReader(html), &Options{Prefilter: PrefilterOn})
+	require.ErrorIs(t, err, ErrClientRenderedPage)
+}
+
+func TestPrefilterTruncatesAtMaxHTMLBytes(t *testing.T) {
+	t.Parallel()
+
+	paragraph := `<p>` + strings.Repeat("The council published the transit plan after a year of hearings. ", 5) + `</p>`
+	html := `<html><body><article><h1>Transit plan</h1>` + strings.Repeat(paragraph, 100) + `</article></body></html>`
+	options := &Options{Prefilter: PrefilterOn, Limits: &Limits{MaxHTMLBytes: 4096}}
+
+	result, err := ParseFromString(context.Background(), html, options)
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, IssueHTMLTruncated, result.Issues[0].Code)
+
+	streamed, err := ParseReader(context.Background(), strings.NewReader(html), options)
+	require.NoError(t, err)
+	assert.Equal(t, result.Issues, streamed.Issues)
+	assert.Equal(t, result.WordCount, streamed.WordCount)
+
+	whole, err := ParseFromString(context.Background(), html, &Options{Limits: &Limits{MaxHTMLBytes: 4096}})
+	require.NoError(t, err)
+	assert.Empty(t, whole.Issues)
+	assert.Less(t, result.WordCount, whole.WordCount/4)
+}
+
+func TestParseFromURLPrefiltersByDefault(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><body><article><h1>Transit plan</h1>` + strings.Repeat(`<p>The council published the transit plan after a year of hearings.</p>`, 100) + `</article></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	limits := &Limits{MaxHTMLBytes: 2048}
+	result, err := ParseFromURL(context.Background(), server.URL, &Options{Limits: limits})
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, IssueHTMLTruncated, result.Issues[0].Code)
+
+	result, err = ParseFromURL(context.Background(), server.URL, &Options{Limits: limits, Prefilter: PrefilterOff})
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+
+	_, err = ParseFromURL(context.Background(), server.URL, &Options{Prefilter: "strict"})
+	require.ErrorIs(t, err, ErrUnsupportedPrefilterMode)
+	_, err = ParseFromString(context.Background(), page, &Options{Prefilter: "strict"})
+	require.ErrorIs(t, err, ErrUnsupportedPrefilterMode)
+}
@@ -0,0 +1,243 @@
+package defuddle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kaptinlin/defuddle-go/internal/sanitize"
+)
+
+// DefaultFastFirstN is the number of blocks ParseFast returns when
+// Options.FastFirstN is not positive.
+const DefaultFastFirstN = 5
+
+// previewBlockTags are the elements ParseFast returns as content blocks.
+var previewBlockTags = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Pre: true, atom.Dt: true, atom.Dd: true, atom.Figcaption: true,
+}
+
+// previewInlineTags are kept inside blocks, without attributes except a href.
+var previewInlineTags = map[atom.Atom]bool{
+	atom.A: true, atom.Em: true, atom.Strong: true, atom.B: true, atom.I: true, atom.Code: true,
+	atom.Sub: true, atom.Sup: true, atom.Mark: true, atom.Kbd: true, atom.Br: true,
+}
+
+// previewContainerTags start or end a block's surroundings, so meeting one
+// inside a block means the block ended without its end tag.
+var previewContainerTags = map[atom.Atom]bool{
+	atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true, atom.Body: true,
+	atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Blockquote: true, atom.Figure: true,
+	atom.Table: true, atom.Tr: true, atom.Td: true, atom.Th: true, atom.Hr: true,
+}
+
+// previewSkipTags hold page chrome or no readable text; nothing inside them
+// becomes a block.
+var previewSkipTags = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+	atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Svg: true, atom.Iframe: true,
+}
+
+// Preview is the start of a page's content, returned by ParseFast
+type Preview struct {
+	// Title is the document title.
+	Title string `json:"title"`
+	// Blocks are the first content blocks as HTML, with attributes removed
+	// except link targets with a safe scheme.
+	Blocks []string `json:"blocks"`
+	// Content is Blocks joined by newlines.
+	Content string `json:"content"`
+	// Truncated reports that the context ended before Options.FastFirstN
+	// blocks were found.
+	Truncated bool `json:"truncated,omitempty"`
+	// Full is the background full parse when Options.FastBackground is set,
+	// and nil otherwise.
+	Full *Pending `json:"-"`
+}
+
+// Pending is a full parse running in the background
+type Pending struct {
+	done   chan struct{}
+	result *Result
+	err    error
+}
+
+// Done is closed when the parse finishes.
+func (p *Pending) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait returns the result of the parse once it finishes, or ctx's error if
+// ctx ends first. The parse keeps running after Wait gives up.
+func (p *Pending) Wait(ctx context.Context) (*Result, error) {
+	select {
+	case <-p.done:
+		return p.result, p.err
+	case <-ctx.Done():
+		// A parse that finished as ctx ended still counts
+		select {
+		case <-p.done:
+			return p.result, p.err
+		default:
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// ParseFast returns the first Options.FastFirstN content blocks of html for
+// previews. It streams the page instead of building and scoring a document,
+// so its cost depends on where the blocks are, not on the page size. Blocks
+// come from paragraphs, headings, list items, and preformatted text outside
+// page chrome such as nav, header, footer, and aside; no clutter removal or
+// standardization is applied.
+//
+// ctx's deadline is the time budget: when it passes, ParseFast returns the
+// blocks found so far with Truncated set rather than an error. With
+// Options.FastBackground, a full Parse of html starts before ParseFast
+// returns and is available from Preview.Full; it is not bound by ctx's
+// deadline or cancellation.
+func ParseFast(ctx context.Context, html string, options *Options) (*Preview, error) {
+	n := DefaultFastFirstN
+	if options != nil && options.FastFirstN > 0 {
+		n = options.FastFirstN
+	}
+
+	var full *Pending
+	if options != nil && options.FastBackground {
+		full = &Pending{done: make(chan struct{})}
+		go func() {
+			defer close(full.done)
+			full.result, full.err = ParseFromString(context.WithoutCancel(ctx), html, options)
+		}()
+	}
+
+	preview, err := scanPreview(ctx, strings.NewReader(html), n)
+	if err != nil {
+		return nil, err
+	}
+	preview.Full = full
+	return preview, nil
+}
+
+// scanPreview tokenizes r until it has found n blocks, r ends, or ctx ends.
+func scanPreview(ctx context.Context, r io.Reader, n int) (*Preview, error) {
+	preview := &Preview{}
+	z := html.NewTokenizer(r)
+
+	var title strings.Builder
+	inTitle := false
+	skipDepth := 0
+
+	var block strings.Builder
+	var blockTag atom.Atom
+	var open []atom.Atom // Inline tags open in the block
+	hasText := false
+	flush := func() {
+		if blockTag != 0 && hasText {
+			content := strings.TrimSpace(block.String())
+			for i := len(open) - 1; i >= 0; i-- {
+				content += "</" + open[i].String() + ">"
+			}
+			preview.Blocks = append(preview.Blocks, "<"+blockTag.String()+">"+content+"</"+blockTag.String()+">")
+		}
+		block.Reset()
+		blockTag, open, hasText = 0, open[:0], false
+	}
+
+	for len(preview.Blocks) < n {
+		if ctx.Err() != nil {
+			preview.Truncated = true
+			break
+		}
+
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("failed to scan HTML: %w", err)
+			}
+			flush()
+			break
+		}
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := atom.Lookup(name)
+			switch {
+			case tag == atom.Title:
+				inTitle = tt == html.StartTagToken
+			case tag == atom.Body:
+				skipDepth = 0
+			case previewSkipTags[tag]:
+				flush()
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+			case skipDepth > 0:
+			case previewBlockTags[tag]:
+				flush()
+				blockTag = tag
+			case blockTag != 0 && previewInlineTags[tag]:
+				block.WriteString(previewStartTag(z, tag, hasAttr))
+				if tag != atom.Br && tt == html.StartTagToken {
+					open = append(open, tag)
+				}
+			case blockTag != 0 && blockTag != atom.Pre && previewContainerTags[tag]:
+				// A nested list or other container ends the block
+				flush()
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := atom.Lookup(name)
+			switch {
+			case tag == atom.Title:
+				inTitle = false
+			case previewSkipTags[tag]:
+				skipDepth = max(skipDepth-1, 0)
+			case skipDepth > 0 || blockTag == 0:
+			case tag == blockTag || (blockTag != atom.Pre && previewContainerTags[tag]):
+				flush()
+			case len(open) > 0 && open[len(open)-1] == tag:
+				block.WriteString("</" + tag.String() + ">")
+				open = open[:len(open)-1]
+			}
+		case html.TextToken:
+			text := string(z.Text())
+			switch {
+			case inTitle:
+				title.WriteString(text)
+			case skipDepth == 0 && blockTag != 0:
+				block.WriteString(html.EscapeString(text))
+				hasText = hasText || strings.TrimSpace(text) != ""
+			}
+		default:
+		}
+	}
+
+	preview.Title = strings.Join(strings.Fields(title.String()), " ")
+	preview.Content = strings.Join(preview.Blocks, "\n")
+	return preview, nil
+}
+
+// previewStartTag writes an inline start tag without attributes, keeping
+// only the href of links when it is relative or uses a safe scheme.
+func previewStartTag(z *html.Tokenizer, tag atom.Atom, hasAttr bool) string {
+	if tag != atom.A {
+		return "<" + tag.String() + ">"
+	}
+	for hasAttr {
+		var key, value []byte
+		key, value, hasAttr = z.TagAttr()
+		if string(key) == "href" && sanitize.SafeURL(string(value)) {
+			return `<a href="` + html.EscapeString(string(value)) + `">`
+		}
+	}
+	return "<a>"
+}
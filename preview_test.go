@@ -0,0 +1,108 @@
+package defuddle
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFastReturnsFirstBlocksOutsideChrome(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title> Preview
+		Title </title><style>p { color: red }</style></head><body>
+		<header><p>Site tagline</p></header>
+		<nav><ul><li>Home</li></ul></nav>
+		<article>
+			<h1 class="title">Heading</h1>
+			<p>First <a href="/a?x=1&amp;y=2" class="link">link</a> and <em>emphasis</em> &amp; <span>span</span>.</p>
+			<p>   </p>
+			<ul><li>Item one<ul><li>Nested</li></ul></li></ul>
+			<p>Unclosed <strong>bold
+			<div>after</div>
+			<p>Fifth</p>
+		</article>
+		<footer><p>Copyright</p></footer>
+	</body></html>`
+
+	preview, err := ParseFast(context.Background(), html, &Options{FastFirstN: 6})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Preview Title", preview.Title)
+	assert.Equal(t, []string{
+		"<h1>Heading</h1>",
+		`<p>First <a href="/a?x=1&amp;y=2">link</a> and <em>emphasis</em> &amp; span.</p>`,
+		"<li>Item one</li>",
+		"<li>Nested</li>",
+		"<p>Unclosed <strong>bold</strong></p>",
+		"<p>Fifth</p>",
+	}, preview.Blocks)
+	assert.Equal(t, strings.Join(preview.Blocks, "\n"), preview.Content)
+	assert.False(t, preview.Truncated)
+	assert.Nil(t, preview.Full)
+}
+
+func TestParseFastDropsUnsafeLinks(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><body><article><p><a href="javascript:alert(1)">one</a> ` +
+		`<a href=" JavaScript:alert(2)">two</a> <a href="data:text/html,x">three</a> ` +
+		`<a href="mailto:a@example.com">four</a> <a href="https://example.com/">five</a></p></article></body></html>`
+
+	preview, err := ParseFast(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`<p><a>one</a> <a>two</a> <a>three</a> ` +
+		`<a href="mailto:a@example.com">four</a> <a href="https://example.com/">five</a></p>`}, preview.Blocks)
+}
+
+func TestParseFastStopsAfterFirstN(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><body><article>` + strings.Repeat(`<p>Paragraph.</p>`, 100000) + `</article></body></html>`
+
+	preview, err := ParseFast(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Len(t, preview.Blocks, DefaultFastFirstN)
+}
+
+func TestParseFastReturnsPartialPreviewWhenBudgetEnds(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	preview, err := ParseFast(ctx, `<html><body><p>One</p><p>Two</p></body></html>`, &Options{FastFirstN: 2})
+	require.NoError(t, err)
+	assert.True(t, preview.Truncated)
+	assert.Empty(t, preview.Blocks)
+}
+
+func TestParseFastContinuesFullParseInBackground(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><head><title>Background</title></head><body><article><h1>Background</h1><p>` + strings.Repeat("Full extraction keeps running. ", 40) + `</p></article></body></html>`
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	preview, err := ParseFast(ctx, html, &Options{FastFirstN: 1, FastBackground: true})
+	cancel()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"<h1>Background</h1>"}, preview.Blocks)
+	require.NotNil(t, preview.Full)
+
+	result, err := preview.Full.Wait(context.Background())
+	require.NoError(t, err, "the background parse must outlive the preview budget")
+	assert.Contains(t, result.Content, "Full extraction keeps running.")
+	select {
+	case <-preview.Full.Done():
+	default:
+		t.Fatal("Done() is not closed after Wait returned")
+	}
+
+	expired, stop := context.WithCancel(context.Background())
+	stop()
+	_, err = preview.Full.Wait(expired)
+	require.NoError(t, err, "a finished parse is returned even with an ended context")
+}
@@ -0,0 +1,86 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kaptinlin/requests"
+)
+
+// hostLimiter is a token bucket per host, shared by every goroutine that
+// sends requests through one client. Each bucket holds one token, so
+// requests to a host are spaced evenly.
+type hostLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newHostLimiter(perSecond float64) *hostLimiter {
+	return &hostLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		next:     make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a request to host may be sent, or ctx is done.
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the slot back to the requests queued behind this one
+		l.mu.Lock()
+		if l.next[host].Equal(at.Add(l.interval)) {
+			l.next[host] = at
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// rateLimitTransport delays each request until limiter allows one to its
+// host. It sits below the retry loop of requests, so every attempt and
+// every HTTP redirect waits its turn.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *hostLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context(), req.URL.Host); err != nil {
+		// RoundTrip closes the body, even on errors
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// rateLimitClient makes client space its requests to each host with
+// limiter, wrapping the transport it has at this point.
+func rateLimitClient(client *requests.Client, limiter *hostLimiter) {
+	base := client.HTTPClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.HTTPClient.Transport = &rateLimitTransport{base: base, limiter: limiter}
+}
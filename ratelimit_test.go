@@ -0,0 +1,95 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFromURLRateLimitsEachHost(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(fetchTestPage))
+	})
+	limited := httptest.NewServer(handler)
+	defer limited.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	client, err := NewFetchClient(&FetchOptions{RequestsPerSecond: 5})
+	require.NoError(t, err)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			_, err := ParseFromURL(context.Background(), limited.URL, &Options{Client: client})
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+	// The first request goes at once, the others 200ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 390*time.Millisecond)
+
+	start = time.Now()
+	_, err = ParseFromURL(context.Background(), other.URL, &Options{Client: client})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestNewFetchClientRateLimitsRetries(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		attempt := len(times)
+		mu.Unlock()
+		if attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(fetchTestPage))
+	}))
+	defer server.Close()
+
+	client, err := NewFetchClient(&FetchOptions{RequestsPerSecond: 5, MaxRetries: 2, RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = ParseFromURL(context.Background(), server.URL, &Options{Client: client})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, times, 3)
+	// Retries wait for the limiter, not just the 1ms backoff
+	for i := 1; i < len(times); i++ {
+		assert.GreaterOrEqual(t, times[i].Sub(times[i-1]), 190*time.Millisecond, "attempt %d", i+1)
+	}
+}
+
+func TestHostLimiterReturnsSlotWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	limiter := newHostLimiter(1)
+	require.NoError(t, limiter.wait(context.Background(), "example.com"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, limiter.wait(ctx, "example.com"), context.Canceled)
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	assert.WithinDuration(t, time.Now().Add(time.Second), limiter.next["example.com"], 100*time.Millisecond)
+}
@@ -0,0 +1,71 @@
+package defuddle
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+
+	"github.com/kaptinlin/defuddle-go/internal/metadata"
+	"github.com/kaptinlin/defuddle-go/internal/sanitize"
+	"github.com/kaptinlin/defuddle-go/internal/typography"
+)
+
+// recoveryWordRatio is how many times more words the schema.org
+// articleBody must have than the extracted content to replace it.
+const recoveryWordRatio = 2
+
+// recoverArticleBody replaces the content of result with the schema.org
+// articleBody of the page, rendered as paragraphs, when the body has at
+// least recoveryWordRatio times as many words. Paywalls and registration
+// walls often leave a teaser in the page but the whole article in its
+// JSON-LD. Results of site-specific extractors are kept as they are.
+func (d *Defuddle) recoverArticleBody(ctx context.Context, result *Result, options *Options) error {
+	if result.ExtractorType != nil {
+		return nil
+	}
+	paragraphs := metadata.SchemaArticleBody(result.SchemaOrgData)
+	if len(paragraphs) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, paragraph := range paragraphs {
+		b.WriteString("<p>" + html.EscapeString(paragraph) + "</p>")
+	}
+	content := typography.HTML(b.String(), options.Typography)
+	if options.Sanitize {
+		content = sanitize.HTML(content)
+	}
+	words := d.countWords(content)
+	if words < result.WordCount*recoveryWordRatio {
+		return nil
+	}
+	if err := d.memory.observe("content", len(content)); err != nil {
+		return err
+	}
+	if d.debug {
+		slog.Debug("Recovering content from schema.org articleBody", "words", result.WordCount, "articleBodyWords", words)
+	}
+
+	result.Issues = append(result.Issues, Issue{
+		Code:    IssueContentRecovered,
+		Message: fmt.Sprintf("content of %d words was replaced by the schema.org articleBody of %d words", result.WordCount, words),
+	})
+	result.Content = content
+	result.WordCount = words
+	result.Images = nil
+	result.SourceMap = nil
+	result.TOC = nil
+	result.Summary = d.summarize(ctx, options, content)
+	result.Keywords = d.extractKeywords(ctx, options, content)
+	if options.Markdown || options.SeparateMarkdown {
+		result.ContentMarkdown = nil
+		if markdownContent, err := d.convertHTMLToMarkdown(content, options.MarkdownOptions); err == nil {
+			result.ContentMarkdown = &markdownContent
+		} else if d.debug {
+			slog.Debug("Failed to convert recovered content to Markdown", "error", err)
+		}
+	}
+	return nil
+}
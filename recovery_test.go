@@ -0,0 +1,56 @@
+package defuddle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paywalledPage shows a teaser of an article whose whole text is in its
+// JSON-LD articleBody.
+var paywalledPage = `<html><head><title>The River Report</title>
+<script type="application/ld+json">{"@context":"https://schema.org","@type":"NewsArticle","headline":"The River Report","articleBody":"` +
+	strings.Repeat("The river rose through the night and the town watched it climb. ", 20) + `\n\n` +
+	strings.Repeat("By morning the water had reached the old mill & the bridge. ", 20) + `"}</script>
+</head><body><article><h1>The River Report</h1><p>The river rose through the night and the town watched it climb.</p>
+<div class="paywall"><p>Subscribe to keep reading.</p></div></article></body></html>`
+
+func TestRecoverFromStructuredDataReplacesTeaser(t *testing.T) {
+	t.Parallel()
+
+	result, err := ParseFromString(context.Background(), paywalledPage, DefaultOptions())
+	require.NoError(t, err)
+	assert.Less(t, result.WordCount, 50)
+	assert.Empty(t, result.Issues)
+
+	opts := DefaultOptions()
+	opts.RecoverFromStructuredData = true
+	opts.Markdown = true
+	result, err = ParseFromString(context.Background(), paywalledPage, opts)
+	require.NoError(t, err)
+	assert.Greater(t, result.WordCount, 200)
+	assert.Equal(t, 2, strings.Count(result.Content, "<p>"))
+	assert.Contains(t, result.Content, "the old mill &amp; the bridge.")
+	require.NotNil(t, result.ContentMarkdown)
+	assert.Contains(t, *result.ContentMarkdown, "By morning the water")
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, IssueContentRecovered, result.Issues[0].Code)
+}
+
+func TestRecoverFromStructuredDataKeepsFullContent(t *testing.T) {
+	t.Parallel()
+
+	// An articleBody repeating the visible article adds nothing
+	page := strings.Replace(paywalledPage, `<div class="paywall"><p>Subscribe to keep reading.</p></div>`,
+		"<p>"+strings.Repeat("The river rose through the night and the town watched it climb. ", 20)+"</p><p>"+
+			strings.Repeat("By morning the water had reached the old mill &amp; the bridge. ", 20)+"</p>", 1)
+	opts := DefaultOptions()
+	opts.RecoverFromStructuredData = true
+	result, err := ParseFromString(context.Background(), page, opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, "<p>The river rose through the night and the town watched it climb.</p>")
+	assert.Empty(t, result.Issues)
+}
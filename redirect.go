@@ -0,0 +1,154 @@
+package defuddle
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// redirectMaxTextLength is the most visible body text, in characters, a
+// page may have for a script location assignment to count as a redirect.
+// Pages with more text are content that also happens to navigate.
+const redirectMaxTextLength = 500
+
+// redirectMaxScriptBytes is the longest inline script searched for a
+// location assignment; redirect stubs are one statement long.
+const redirectMaxScriptBytes = 1024
+
+var (
+	// metaRefreshURLRe matches the URL of a refresh directive such as
+	// "0; url='https://example.com/'"
+	metaRefreshURLRe = regexp.MustCompile(`(?i)^\s*(\d+(?:\.\d*)?)?\s*[;,]?\s*(?:url\s*=\s*)?(.*)$`)
+
+	// scriptLocationRe matches a string literal assigned to, or passed to
+	// replace or assign of, window, document, top, or self location
+	scriptLocationRe = regexp.MustCompile(`(?:^|[\s;{(])(?:(?:window|document|top|self|parent)\.)*location(?:\.href)?\s*(?:=\s*|\.(?:replace|assign)\s*\(\s*)(["'])([^"'\\]+)(["'])`)
+)
+
+// clientRedirect returns the absolute http(s) URL that root sends the
+// browser to with a meta refresh or a trivial script location assignment,
+// resolved against base. It returns "" when the page does not redirect, or
+// only reloads itself.
+func clientRedirect(root *html.Node, base string) string {
+	var refresh string
+	var scripts []string
+	var text strings.Builder
+	var walk func(n *html.Node, visible bool)
+	walk = func(n *html.Node, visible bool) {
+		switch n.Type {
+		case html.TextNode:
+			if visible {
+				text.WriteString(n.Data)
+				text.WriteByte(' ')
+			}
+			return
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Meta:
+				if refresh == "" && strings.EqualFold(strings.TrimSpace(attrValue(n, "http-equiv")), "refresh") {
+					refresh = metaRefreshTarget(attrValue(n, "content"))
+				}
+			case atom.Script:
+				if n.FirstChild != nil && len(n.FirstChild.Data) <= redirectMaxScriptBytes && attrValue(n, "src") == "" {
+					scripts = append(scripts, n.FirstChild.Data)
+				}
+				return
+			case atom.Noscript:
+				// A noscript refresh is the fallback of a script redirect
+				if refresh == "" {
+					if target := noscriptRefresh(n); target != "" {
+						refresh = target
+					}
+				}
+			}
+			visible = visible && !shellHiddenTags[n.DataAtom]
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, visible)
+		}
+	}
+	walk(root, true)
+
+	if target := redirectTarget(refresh, base); target != "" {
+		return target
+	}
+	if utf8.RuneCountInString(strings.Join(strings.Fields(text.String()), " ")) > redirectMaxTextLength {
+		return ""
+	}
+	for _, script := range scripts {
+		if m := scriptLocationRe.FindStringSubmatch(script); m != nil && m[1] == m[3] {
+			if target := redirectTarget(m[2], base); target != "" {
+				return target
+			}
+		}
+	}
+	return ""
+}
+
+// metaRefreshTarget returns the URL of a refresh directive, or "" when it
+// only reloads the page.
+func metaRefreshTarget(content string) string {
+	m := metaRefreshURLRe.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	if m[1] != "" {
+		if _, err := strconv.ParseFloat(m[1], 64); err != nil {
+			return ""
+		}
+	}
+	return strings.Trim(strings.TrimSpace(m[2]), `"'`)
+}
+
+// noscriptRefresh returns the refresh URL of a meta element inside a
+// noscript element, which the parser keeps as raw text.
+func noscriptRefresh(n *html.Node) string {
+	var raw strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			raw.WriteString(c.Data)
+		}
+	}
+	if !strings.Contains(strings.ToLower(raw.String()), "refresh") {
+		return ""
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(raw.String()), &html.Node{Type: html.ElementNode, Data: "head", DataAtom: atom.Head})
+	if err != nil {
+		return ""
+	}
+	for _, node := range nodes {
+		if node.DataAtom == atom.Meta && strings.EqualFold(strings.TrimSpace(attrValue(node, "http-equiv")), "refresh") {
+			return metaRefreshTarget(attrValue(node, "content"))
+		}
+	}
+	return ""
+}
+
+// redirectTarget resolves ref against base and returns it when it is an
+// http(s) URL other than base itself.
+func redirectTarget(ref, base string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	target, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if baseURL, err := url.Parse(base); err == nil && base != "" {
+		target = baseURL.ResolveReference(target)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" || target.Host == "" {
+		return ""
+	}
+	target.Fragment = ""
+	if strings.TrimSuffix(target.String(), "/") == strings.TrimSuffix(strings.SplitN(base, "#", 2)[0], "/") {
+		return ""
+	}
+	return target.String()
+}
@@ -0,0 +1,45 @@
+package defuddle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestClientRedirect(t *testing.T) {
+	t.Parallel()
+
+	article := `<article><p>` + strings.Repeat("A full article that also has a script which navigates on click. ", 10) + `</p></article>`
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "meta refresh", html: `<head><meta http-equiv="Refresh" content="0; URL='/next?id=2'"></head>`, want: "https://example.com/next?id=2"},
+		{name: "meta refresh without delay", html: `<head><meta http-equiv="refresh" content="url=https://other.example/"></head>`, want: "https://other.example/"},
+		{name: "delayed meta refresh", html: `<head><meta http-equiv="refresh" content="5;url=/moved"></head>` + article, want: "https://example.com/moved"},
+		{name: "reload only", html: `<head><meta http-equiv="refresh" content="300"></head>`},
+		{name: "refresh to itself", html: `<head><meta http-equiv="refresh" content="60; url=/page#top"></head>`},
+		{name: "javascript scheme", html: `<head><meta http-equiv="refresh" content="0; url=javascript:alert(1)"></head>`},
+		{name: "location assignment", html: `<body><script>window.location.href = "/dest";</script></body>`, want: "https://example.com/dest"},
+		{name: "location replace", html: `<body><script>top.location.replace('https://dest.example/a')</script></body>`, want: "https://dest.example/a"},
+		{name: "document location", html: `<body><p>Redirecting</p><script>document.location='/d'</script></body>`, want: "https://example.com/d"},
+		{name: "noscript fallback", html: `<head><noscript><meta http-equiv="refresh" content="0;url=/nojs"></noscript></head>`, want: "https://example.com/nojs"},
+		{name: "script on a content page", html: `<body>` + article + `<script>if (clicked) { location.href = "/login"; }</script></body>`},
+		{name: "computed location", html: `<body><script>location.href = base + "/x";</script></body>`},
+		{name: "no redirect", html: `<body><p>Plain page.</p></body>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := html.Parse(strings.NewReader(`<html>` + tt.html + `</html>`))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, clientRedirect(doc, "https://example.com/page"))
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Package render turns a defuddle.Result into HTML with Go html/template
+// templates, for reader views and branded archives.
+//
+// Templates execute against a Page. Page embeds the Result, so every result
+// field is available ({{.Title}}, {{.Author}}, {{.Domain}}, ...), and adds
+// {{.Content}} as trusted HTML, {{.Byline}}, {{.ReadingTime}} in minutes,
+// and {{.CSS}}, a theme style sheet.
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// WordsPerMinute is the reading speed used for Page.ReadingTime.
+const WordsPerMinute = 200
+
+// Page is the data passed to templates.
+type Page struct {
+	*defuddle.Result
+
+	// Content is Result.Content marked as trusted HTML so templates render
+	// it as markup. It shadows the embedded string field.
+	Content template.HTML
+	// Byline lists the non-empty author, site, and published values.
+	Byline []string
+	// ReadingTime is the estimated reading time in whole minutes, at least one.
+	ReadingTime int
+	// CSS is a style sheet embedded after the built-in styles, such as one of
+	// Themes. It is empty unless set through HTMLWithCSS.
+	CSS template.CSS
+}
+
+// Themes are built-in style sheets for Page.CSS, by name. The reader view
+// follows the system color scheme without one.
+var Themes = map[string]string{
+	"light": `:root { color-scheme: light; }
+body { background: #fff; color: #1a1a1a; }`,
+	"dark": `:root { color-scheme: dark; }
+body { background: #121212; color: #e0e0e0; }`,
+	"sepia": `:root { color-scheme: light; }
+body { background: #f4ecd8; color: #5b4636; }
+pre { background: rgba(91, 70, 54, .08); }`,
+}
+
+// NewPage builds the template data for result.
+func NewPage(result *defuddle.Result) *Page {
+	page := &Page{
+		Result: result,
+		// Content is the cleaned extraction output and is rendered as-is,
+		// matching the default HTML output.
+		Content:     template.HTML(result.Content), // #nosec G203 - extracted content is the output itself
+		ReadingTime: ReadingTime(result.WordCount),
+	}
+	for _, part := range []string{result.Author, result.Site, result.Published} {
+		if part = strings.TrimSpace(part); part != "" {
+			page.Byline = append(page.Byline, part)
+		}
+	}
+	return page
+}
+
+// ReadingTime estimates reading time in whole minutes, at least one.
+func ReadingTime(wordCount int) int {
+	return max(1, (wordCount+WordsPerMinute-1)/WordsPerMinute)
+}
+
+// HTML executes tmpl with the Page for result and writes the output to w.
+// A nil tmpl uses Reader().
+func HTML(result *defuddle.Result, tmpl *template.Template, w io.Writer) error {
+	return HTMLWithCSS(result, tmpl, "", w)
+}
+
+// HTMLWithCSS is HTML with css as Page.CSS. The style sheet is trusted and
+// embedded as given.
+func HTMLWithCSS(result *defuddle.Result, tmpl *template.Template, css string, w io.Writer) error {
+	if tmpl == nil {
+		tmpl = Reader()
+	}
+	page := NewPage(result)
+	page.CSS = template.CSS(css) // #nosec G203 - the caller chose the style sheet
+	if err := tmpl.Execute(w, page); err != nil {
+		return fmt.Errorf("failed to render template %q: %w", tmpl.Name(), err)
+	}
+	return nil
+}
+
+// Parse parses template text under name.
+func Parse(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %q: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Reader returns the built-in reading view template: a self-contained page
+// with title, byline, reading time, content, and Page.CSS, and no scripts,
+// fonts, or other network requests.
+func Reader() *template.Template {
+	return readerTemplate
+}
+
+var readerTemplate = template.Must(template.New("reader").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+:root { color-scheme: light dark; }
+body { margin: 0; padding: 2rem 1rem; font: 1.125rem/1.65 Charter, "Bitstream Charter", Georgia, serif; }
+main { max-width: 38rem; margin: 0 auto; }
+h1 { font-size: 2rem; line-height: 1.2; margin: 0 0 .5rem; }
+.byline { font: .9rem/1.4 system-ui, sans-serif; opacity: .7; margin: 0 0 2rem; }
+img, video, iframe { max-width: 100%; height: auto; }
+pre { overflow-x: auto; padding: 1rem; background: rgba(127, 127, 127, .12); font-size: .9rem; }
+code { font-family: ui-monospace, Menlo, Consolas, monospace; }
+blockquote { margin-left: 0; padding-left: 1rem; border-left: 3px solid rgba(127, 127, 127, .4); }
+a { color: inherit; }
+</style>
+{{- if .CSS}}
+<style>
+{{.CSS}}
+</style>
+{{- end}}
+</head>
+<body>
+<main>
+<article>
+<header>
+{{- if .Title}}
+<h1>{{.Title}}</h1>
+{{- end}}
+<p class="byline">
+{{- range $i, $part := .Byline}}{{if $i}} · {{end}}{{$part}}{{end}}{{if .Byline}} · {{end}}{{.ReadingTime}} min read
+</p>
+</header>
+{{.Content}}
+</article>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+func sampleResult() *defuddle.Result {
+	return &defuddle.Result{
+		Metadata: defuddle.Metadata{Title: "<b>Title</b>", Author: "Jane Doe", Site: "Example", Domain: "example.com", WordCount: 450},
+		Content:  "<p>Body</p>",
+	}
+}
+
+func TestReadingTimeRoundsUpToWholeMinutes(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 1, ReadingTime(0))
+	assert.Equal(t, 1, ReadingTime(200))
+	assert.Equal(t, 2, ReadingTime(201))
+}
+
+func TestHTMLRendersReaderViewByDefault(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	require.NoError(t, HTML(sampleResult(), nil, &out))
+
+	page := out.String()
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	assert.Contains(t, page, "<title>&lt;b&gt;Title&lt;/b&gt;</title>")
+	assert.Contains(t, page, "Jane Doe · Example · 3 min read")
+	assert.Contains(t, page, "<p>Body</p>")
+	assert.NotContains(t, page, "<script")
+}
+
+func TestHTMLExposesResultFieldsToCustomTemplates(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := Parse("archive", `<h1>{{.Title}}</h1><small>{{.Domain}} {{.ReadingTime}}m</small>{{.Content}}`)
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, HTML(sampleResult(), tmpl, &out))
+	assert.Equal(t, `<h1>&lt;b&gt;Title&lt;/b&gt;</h1><small>example.com 3m</small><p>Body</p>`, out.String())
+}
+
+func TestHTMLReportsTemplateErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse("broken", `{{.Title`)
+	require.Error(t, err)
+
+	tmpl, err := Parse("missing", `{{.NoSuchField}}`)
+	require.NoError(t, err)
+	err = HTML(sampleResult(), tmpl, &strings.Builder{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"missing"`)
+}
+
+func TestHTMLWithCSSEmbedsTheme(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	require.NoError(t, HTMLWithCSS(sampleResult(), nil, Themes["sepia"], &out))
+	page := out.String()
+	assert.Contains(t, page, "<style>\n"+Themes["sepia"]+"\n</style>\n</head>")
+
+	var plain strings.Builder
+	require.NoError(t, HTML(sampleResult(), nil, &plain))
+	assert.Equal(t, 1, strings.Count(plain.String(), "<style>"))
+}
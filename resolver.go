@@ -0,0 +1,178 @@
+package defuddle
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// dohMediaType is the media type of DNS messages in DNS-over-HTTPS
+// requests and responses (RFC 8484).
+const dohMediaType = "application/dns-message"
+
+// maxDNSMessageSize caps the DNS-over-HTTPS response read, the largest
+// message the length prefix of DNS over TCP can carry.
+const maxDNSMessageSize = 65535
+
+// NewDNSResolver returns a resolver that sends every query to the DNS
+// server at server, a host or IP with an optional port that defaults to
+// 53, instead of the servers in the system configuration. The hosts file
+// is still consulted first.
+func NewDNSResolver(server string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// NewDoHResolver returns a resolver that sends every query as a
+// DNS-over-HTTPS POST to endpoint, such as
+// https://cloudflare-dns.com/dns-query. client sends the requests, or
+// http.DefaultClient when nil; it resolves the endpoint's own host itself,
+// so an endpoint with an IP address host needs no other DNS. The hosts
+// file is still consulted first.
+func NewDoHResolver(endpoint string, client *http.Client) *net.Resolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			conn := &dohConn{ctx: ctx, endpoint: endpoint, client: client}
+			if strings.HasPrefix(network, "udp") {
+				return dohPacketConn{conn}, nil
+			}
+			conn.stream = true
+			return conn, nil
+		},
+	}
+}
+
+// dohConn is the connection a DoH resolver hands the Go resolver. A query
+// written to it is POSTed when the answer is read. As a TCP connection,
+// messages carry the two-byte length prefix of DNS over TCP. As a UDP
+// connection, wrapped in a dohPacketConn, they are bare, and an answer too
+// large for the read is truncated with the TC bit set, making the resolver
+// ask again over TCP.
+type dohConn struct {
+	ctx      context.Context
+	endpoint string
+	client   *http.Client
+	stream   bool
+
+	query    []byte
+	answer   []byte
+	deadline time.Time
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	query := b
+	if c.stream {
+		if len(b) < 2 {
+			return 0, io.ErrShortWrite
+		}
+		query = b[2:]
+	}
+	c.query = bytes.Clone(query)
+	return len(b), nil
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.answer == nil {
+		if c.query == nil {
+			return 0, io.EOF
+		}
+		answer, err := c.exchange(c.query)
+		c.query = nil
+		if err != nil {
+			return 0, err
+		}
+		if c.stream {
+			answer = append(binary.BigEndian.AppendUint16(nil, uint16(len(answer))), answer...)
+		}
+		c.answer = answer
+	}
+	n := copy(b, c.answer)
+	if c.stream && n < len(c.answer) {
+		c.answer = c.answer[n:]
+		return n, nil
+	}
+	if n < len(c.answer) && n > 2 {
+		b[2] |= 0x02 // TC: the resolver asks again over TCP
+	}
+	c.answer = nil
+	return n, nil
+}
+
+// exchange POSTs query to the endpoint and returns the DNS message it
+// answers with.
+func (c *dohConn) exchange(query []byte) ([]byte, error) {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dohMediaType)
+	req.Header.Set("Accept", dohMediaType)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS endpoint %s: %s", c.endpoint, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDNSMessageSize))
+}
+
+func (c *dohConn) Close() error { return nil }
+
+func (c *dohConn) LocalAddr() net.Addr { return dohAddr{} }
+
+func (c *dohConn) RemoteAddr() net.Addr { return dohAddr{} }
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *dohConn) SetReadDeadline(t time.Time) error { return c.SetDeadline(t) }
+
+func (c *dohConn) SetWriteDeadline(time.Time) error { return nil }
+
+// dohPacketConn is a dohConn for UDP. Being a net.PacketConn tells the Go
+// resolver to send bare messages.
+type dohPacketConn struct {
+	*dohConn
+}
+
+func (c dohPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, err := c.Read(b)
+	return n, c.RemoteAddr(), err
+}
+
+func (c dohPacketConn) WriteTo(b []byte, _ net.Addr) (int, error) { return c.Write(b) }
+
+// dohAddr is the address of both ends of a dohConn.
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "doh" }
+
+func (dohAddr) String() string { return "doh" }
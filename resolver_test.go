@@ -0,0 +1,147 @@
+package defuddle
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// answerDNS answers an A query for a name under .test with 127.0.0.1 and
+// any other query with no records. extra A records pad the answer.
+func answerDNS(query []byte, extra int) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, RecursionAvailable: true})
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+	if question.Type == dnsmessage.TypeA && strings.HasSuffix(question.Name.String(), ".test.") {
+		resource := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+		for i := range extra + 1 {
+			a := [4]byte{127, 0, byte(i / 250), byte(1 + i%250)}
+			if err := builder.AResource(resource, dnsmessage.AResource{A: a}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.Finish()
+}
+
+func newDoHServer(t *testing.T, extra int, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != dohMediaType {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		query, err := io.ReadAll(r.Body)
+		if err == nil {
+			query, err = answerDNS(query, extra)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", dohMediaType)
+		_, _ = w.Write(query)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDoHResolverLooksUpHosts(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	doh := newDoHServer(t, 0, &requests)
+	addrs, err := NewDoHResolver(doh.URL, nil).LookupNetIP(context.Background(), "ip4", "article.test")
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("127.0.0.1")}, addrs)
+	assert.Positive(t, requests.Load())
+
+	_, err = NewDoHResolver(doh.URL, nil).LookupNetIP(context.Background(), "ip4", "missing.example")
+	require.Error(t, err)
+}
+
+func TestDoHResolverFallsBackToTCPForLargeAnswers(t *testing.T) {
+	t.Parallel()
+
+	// Too many records for a UDP-sized read, so the answer is truncated
+	// and asked for again with the TCP length prefix
+	var requests atomic.Int32
+	doh := newDoHServer(t, 200, &requests)
+	addrs, err := NewDoHResolver(doh.URL, nil).LookupNetIP(context.Background(), "ip4", "article.test")
+	require.NoError(t, err)
+	assert.Len(t, addrs, 201)
+}
+
+func TestDNSResolverQueriesServer(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if answer, err := answerDNS(buf[:n], 0); err == nil {
+				_, _ = conn.WriteTo(answer, addr)
+			}
+		}
+	}()
+
+	addrs, err := NewDNSResolver(conn.LocalAddr().String()).LookupNetIP(context.Background(), "ip4", "article.test")
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("127.0.0.1")}, addrs)
+}
+
+func TestParseFromURLUsesResolver(t *testing.T) {
+	t.Parallel()
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(fetchTestPage))
+	}))
+	defer page.Close()
+	_, port, err := net.SplitHostPort(page.Listener.Addr().String())
+	require.NoError(t, err)
+
+	var requests atomic.Int32
+	doh := newDoHServer(t, 0, &requests)
+	result, err := ParseFromURL(context.Background(), "http://article.test:"+port+"/", &Options{Fetch: &FetchOptions{
+		Resolver: NewDoHResolver(doh.URL, nil),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "Fetched", result.Title)
+	assert.Equal(t, "article.test", result.Domain)
+}
@@ -0,0 +1,259 @@
+package defuddle
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/kaptinlin/requests"
+)
+
+// maxRobotsSize is the most of a robots.txt file that is read; RFC 9309
+// lets crawlers ignore anything past 500 KiB.
+const maxRobotsSize = 500 << 10
+
+// robotsRule is one Allow or Disallow line of a robots.txt group.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsRules are the rules of the robots.txt group that applies to this
+// client. A nil *robotsRules allows everything.
+type robotsRules struct {
+	rules []robotsRule
+	// disallowAll is set when robots.txt could not be fetched because the
+	// server failed, which RFC 9309 treats as a complete disallow.
+	disallowAll bool
+}
+
+// parseRobots reads a robots.txt file and keeps the rules of the group
+// whose user-agent is the product token of userAgent, compared without
+// case as RFC 9309 requires, or else the "*" group. Groups naming the same
+// agent are merged.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	groups := map[string][]robotsRule{}
+	var agents []string
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			for _, agent := range agents {
+				groups[agent] = append(groups[agent], robotsRule{allow: key == "allow", pattern: value})
+			}
+		}
+	}
+
+	if rules, ok := groups[robotsProductToken(userAgent)]; ok {
+		return &robotsRules{rules: rules}
+	}
+	return &robotsRules{rules: groups["*"]}
+}
+
+// robotsProductToken returns the lowercased name a crawler with userAgent
+// goes by in robots.txt: the product before the first "/", or the first
+// product in the comment of a browser-compatible agent, so
+// "Mozilla/5.0 (compatible; Defuddle/1.0; +https://...)" is "defuddle".
+func robotsProductToken(userAgent string) string {
+	product, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	if _, comment, ok := strings.Cut(userAgent, "(compatible;"); ok {
+		comment, _, _ = strings.Cut(comment, ";")
+		comment, _, _ = strings.Cut(comment, ")")
+		product, _, _ = strings.Cut(strings.TrimSpace(comment), "/")
+	}
+	return strings.ToLower(strings.TrimSpace(product))
+}
+
+// allowed reports whether path, with its query, may be fetched, and the
+// pattern of the rule that decided it. The longest matching pattern wins,
+// and Allow wins a tie.
+func (r *robotsRules) allowed(path string) (bool, string) {
+	if r == nil || path == "/robots.txt" {
+		return true, ""
+	}
+	if r.disallowAll {
+		return false, "*"
+	}
+	allow, pattern := true, ""
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > len(pattern) || (len(rule.pattern) == len(pattern) && rule.allow) {
+			allow, pattern = rule.allow, rule.pattern
+		}
+	}
+	return allow, pattern
+}
+
+// robotsMatch reports whether path starts with pattern, where "*" in
+// pattern matches any run of characters and a trailing "$" anchors it to
+// the end of path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// robotsCache holds the robots.txt rules of each origin a client has
+// fetched from, so every origin's file is fetched once.
+type robotsCache struct {
+	mu      sync.Mutex
+	origins map[string]*robotsEntry
+}
+
+// robotsEntry is the rules of one origin; ready is closed once they are
+// fetched.
+type robotsEntry struct {
+	ready chan struct{}
+	rules *robotsRules
+	err   error
+}
+
+// rules returns the robots.txt rules for the origin of target, fetching
+// them with fetch the first time. Requests queued behind the first wait
+// for its fetch.
+func (c *robotsCache) rules(ctx context.Context, target *url.URL, fetch requests.MiddlewareHandlerFunc, userAgent string) (*robotsRules, error) {
+	origin := target.Scheme + "://" + target.Host
+	c.mu.Lock()
+	entry, ok := c.origins[origin]
+	if !ok {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		c.origins[origin] = entry
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		entry.rules, entry.err = fetchRobots(ctx, origin, fetch, userAgent)
+		if entry.err != nil {
+			// Let a later request try again rather than remember a cancellation
+			c.mu.Lock()
+			delete(c.origins, origin)
+			c.mu.Unlock()
+		}
+		close(entry.ready)
+	}
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if entry.err != nil {
+		if !ok {
+			return nil, entry.err
+		}
+		return c.rules(ctx, target, fetch, userAgent)
+	}
+	return entry.rules, nil
+}
+
+// fetchRobots fetches origin's robots.txt. Following RFC 9309, a missing
+// file or other client error allows everything, and a server error or
+// unreachable server disallows everything. Only a done ctx fails.
+func fetchRobots(ctx context.Context, origin string, fetch requests.MiddlewareHandlerFunc, userAgent string) (*robotsRules, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := fetch(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return &robotsRules{disallowAll: true}, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{disallowAll: true}, nil
+	case resp.StatusCode >= 400:
+		return nil, nil
+	case resp.StatusCode >= 300:
+		// http.Client follows redirects, so this is one it gave up on
+		return nil, nil
+	}
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), userAgent), nil
+}
+
+// robotsMiddleware fails requests that the robots.txt of their origin
+// disallows for userAgent with a *RobotsDisallowedError, before they are
+// sent. The final URL of an HTTP redirect is checked as well, though it has
+// already been fetched by then.
+func robotsMiddleware(userAgent string) requests.Middleware {
+	cache := &robotsCache{origins: make(map[string]*robotsEntry)}
+	check := func(ctx context.Context, target *url.URL, next requests.MiddlewareHandlerFunc) error {
+		rules, err := cache.rules(ctx, target, next, userAgent)
+		if err != nil {
+			return err
+		}
+		path := target.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		if target.RawQuery != "" {
+			path += "?" + target.RawQuery
+		}
+		if ok, rule := rules.allowed(path); !ok {
+			return &RobotsDisallowedError{URL: target.String(), Rule: rule}
+		}
+		return nil
+	}
+
+	return func(next requests.MiddlewareHandlerFunc) requests.MiddlewareHandlerFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := check(req.Context(), req.URL, next); err != nil {
+				return nil, err
+			}
+			resp, err := next(req)
+			if err != nil || resp == nil || resp.Request == nil || resp.Request.URL.String() == req.URL.String() {
+				return resp, err
+			}
+			if err := check(req.Context(), resp.Request.URL, next); err != nil {
+				_ = resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
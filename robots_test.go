@@ -0,0 +1,147 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRobotsPicksAgentGroup(t *testing.T) {
+	t.Parallel()
+
+	const robots = `# comments are ignored
+User-agent: *
+Disallow: /
+
+User-agent: OtherBot
+User-agent: defuddle
+Disallow: /private/
+Allow: /private/open
+Disallow: /*.pdf$
+Disallow:
+`
+	rules := parseRobots(strings.NewReader(robots), DefaultUserAgent)
+	tests := []struct {
+		path  string
+		allow bool
+		rule  string
+	}{
+		{"/", true, ""},
+		{"/robots.txt", true, ""},
+		{"/private/page", false, "/private/"},
+		{"/private/open/page", true, "/private/open"},
+		{"/files/report.pdf", false, "/*.pdf$"},
+		{"/files/report.pdf?download=1", true, ""},
+	}
+	for _, tt := range tests {
+		allow, rule := rules.allowed(tt.path)
+		assert.Equal(t, tt.allow, allow, tt.path)
+		assert.Equal(t, tt.rule, rule, tt.path)
+	}
+
+	allow, rule := parseRobots(strings.NewReader(robots), "SomeCrawler/1.0").allowed("/page")
+	assert.False(t, allow)
+	assert.Equal(t, "/", rule)
+}
+
+func TestParseRobotsMatchesProductToken(t *testing.T) {
+	t.Parallel()
+
+	const robots = `User-agent: *
+Disallow: /
+
+User-agent: Mozilla
+Disallow: /a/b/
+
+User-agent: DEFUDDLE
+Disallow: /a/
+
+User-agent: bot
+Disallow: /a/b/c/
+`
+	tests := []struct {
+		userAgent string
+		rule      string
+	}{
+		// The compatible product, not Mozilla or "bot" inside the URL
+		{"Mozilla/5.0 (compatible; Defuddle/1.0; +https://example.com/bot)", "/a/"},
+		{"defuddle/1.0", "/a/"},
+		// A token only contained in the agent is no match
+		{"Defuddlebot/2.0", "/"},
+		{"Mozilla/5.0 (X11; Linux x86_64) Firefox/130.0", "/a/b/"},
+	}
+	for _, tt := range tests {
+		_, rule := parseRobots(strings.NewReader(robots), tt.userAgent).allowed("/a/b/c/page")
+		assert.Equal(t, tt.rule, rule, tt.userAgent)
+	}
+}
+
+func TestParseFromURLRespectsRobots(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/moved":
+			http.Redirect(w, r, "/private/page", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(fetchTestPage))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewFetchClient(&FetchOptions{RespectRobots: true})
+	require.NoError(t, err)
+
+	_, err = ParseFromURL(context.Background(), server.URL+"/public", &Options{Client: client})
+	require.NoError(t, err)
+
+	for _, path := range []string{"/private/page", "/moved"} {
+		_, err = ParseFromURL(context.Background(), server.URL+path, &Options{Client: client})
+		require.ErrorIs(t, err, ErrDisallowedByRobots, path)
+		var robotsErr *RobotsDisallowedError
+		require.ErrorAs(t, err, &robotsErr)
+		assert.Equal(t, server.URL+"/private/page", robotsErr.URL)
+		assert.Equal(t, "/private", robotsErr.Rule)
+	}
+
+	// Without the option robots.txt is not consulted
+	_, err = ParseFromURL(context.Background(), server.URL+"/private/page", nil)
+	require.NoError(t, err)
+}
+
+func TestFetchRobotsFollowsRFC9309Statuses(t *testing.T) {
+	t.Parallel()
+
+	for status, allow := range map[int]bool{
+		http.StatusNotFound:           true,
+		http.StatusForbidden:          true,
+		http.StatusServiceUnavailable: false,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(fetchTestPage))
+		}))
+
+		client, err := NewFetchClient(&FetchOptions{RespectRobots: true})
+		require.NoError(t, err)
+		_, err = ParseFromURL(context.Background(), server.URL+"/page", &Options{Client: client})
+		if allow {
+			assert.NoError(t, err, status)
+		} else {
+			assert.ErrorIs(t, err, ErrDisallowedByRobots, status)
+		}
+		server.Close()
+	}
+}
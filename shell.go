@@ -0,0 +1,155 @@
+package defuddle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// shellMaxTextLength is the most visible body text, in characters, an
+	// application shell may have; loading messages and cookie notices fit.
+	shellMaxTextLength = 200
+
+	// shellMinScriptBytes is the least inline script, in bytes, that makes
+	// a shell without external scripts; serialized state and bundles
+	// inlined by the build are this large.
+	shellMinScriptBytes = 2048
+)
+
+// shellMountIDs are the ids of the elements client-side frameworks mount
+// into.
+var shellMountIDs = map[string]bool{
+	"root": true, "app": true, "app-root": true, "react-root": true,
+	"__next": true, "__nuxt": true, "___gatsby": true, "svelte": true,
+	"application": true,
+}
+
+// shellMountTags are custom elements frameworks mount into.
+var shellMountTags = map[string]bool{"app-root": true}
+
+// shellHiddenTags hold no visible body text.
+var shellHiddenTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Head: true,
+}
+
+// detectClientRendered returns a *ClientRenderedPageError when root is the
+// empty shell of a client-rendered page: little visible body text, an empty
+// element a framework mounts into, and external scripts or a large inline
+// script payload to fill it. It returns nil for any other document.
+// droppedScriptBytes is inline script the prefilter removed from root.
+func detectClientRendered(root *html.Node, droppedScriptBytes int) *ClientRenderedPageError {
+	shell := &ClientRenderedPageError{ScriptBytes: droppedScriptBytes}
+	var text strings.Builder
+	var walk func(n *html.Node, visible bool)
+	walk = func(n *html.Node, visible bool) {
+		switch n.Type {
+		case html.TextNode:
+			if visible {
+				text.WriteString(n.Data)
+				text.WriteByte(' ')
+			}
+			return
+		case html.ElementNode:
+			if n.DataAtom == atom.Script {
+				if src := strings.TrimSpace(attrValue(n, "src")); src != "" {
+					shell.ExternalScripts++
+				}
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					shell.ScriptBytes += len(c.Data)
+				}
+				return
+			}
+			if shell.Mount == "" && isShellMount(n) {
+				shell.Mount = n.Data
+				if id := attrValue(n, "id"); id != "" {
+					shell.Mount += "#" + id
+				}
+			}
+			visible = visible && !shellHiddenTags[n.DataAtom]
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, visible)
+		}
+	}
+	walk(root, true)
+
+	shell.TextLength = utf8.RuneCountInString(strings.Join(strings.Fields(text.String()), " "))
+	if shell.Mount == "" || shell.TextLength > shellMaxTextLength {
+		return nil
+	}
+	if shell.ExternalScripts == 0 && shell.ScriptBytes < shellMinScriptBytes {
+		return nil
+	}
+	return shell
+}
+
+// isShellMount reports whether n is a framework mount point with no
+// content of its own.
+func isShellMount(n *html.Node) bool {
+	if !shellMountIDs[strings.ToLower(attrValue(n, "id"))] && !shellMountTags[n.Data] {
+		return false
+	}
+	empty := true
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		for ; c != nil && empty; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode && strings.TrimSpace(c.Data) != "":
+				empty = false
+			case c.Type == html.ElementNode:
+				switch c.DataAtom {
+				case atom.Img, atom.Picture, atom.Video, atom.Iframe, atom.Canvas:
+					empty = false
+				case atom.Script, atom.Style, atom.Noscript, atom.Template:
+				default:
+					walk(c.FirstChild)
+				}
+			}
+		}
+	}
+	walk(n.FirstChild)
+	return empty
+}
+
+// attrValue returns the value of n's attribute key, or "".
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// renderShell parses the page at Options.URL as Options.Renderer renders
+// it, in place of the client-rendered shell, or returns shell when no
+// renderer is configured.
+func (d *Defuddle) renderShell(ctx context.Context, shell *ClientRenderedPageError) (*Result, error) {
+	options := d.mergeOptions(nil)
+	shell.URL = options.URL
+	if d.options == nil || d.options.Renderer == nil || options.URL == "" {
+		return nil, shell
+	}
+
+	rendered, err := d.options.Renderer.Render(ctx, options.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", options.URL, err)
+	}
+
+	// The caller's Parse already reports metrics and caches the result
+	renderOptions := *d.options
+	renderOptions.Renderer = nil
+	renderOptions.Cache = nil
+	renderOptions.Metrics = nil
+	parser, err := NewDefuddle(rendered, &renderOptions)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(ctx)
+}
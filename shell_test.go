@@ -0,0 +1,98 @@
+package defuddle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reactShell = `<!doctype html><html><head><title>Dashboard</title>
+<script defer src="/static/js/main.4f1c2.js"></script></head>
+<body><noscript>You need to enable JavaScript to run this app.</noscript><div id="root"></div></body></html>`
+
+// staticRenderer returns the same HTML for every URL and records the URLs
+// it was asked to render.
+type staticRenderer struct {
+	html string
+	err  error
+	urls []string
+}
+
+func (r *staticRenderer) Render(_ context.Context, url string) (string, error) {
+	r.urls = append(r.urls, url)
+	return r.html, r.err
+}
+
+func TestParseDetectsClientRenderedShells(t *testing.T) {
+	t.Parallel()
+
+	nextData := `<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"items":"` + strings.Repeat("x", 4096) + `"}}}</script>`
+	article := strings.Repeat("The council published the transit plan after a year of hearings. ", 20)
+
+	tests := []struct {
+		name  string
+		html  string
+		mount string
+	}{
+		{name: "react root", html: reactShell, mount: "div#root"},
+		{name: "next with inline state", html: `<html><body><div id="__next"><div class="spinner"></div></div>` + nextData + `</body></html>`, mount: "div#__next"},
+		{name: "angular element", html: `<html><body><app-root></app-root><script src="main.js" type="module"></script></body></html>`, mount: "app-root"},
+		{name: "server rendered", html: `<html><body><div id="__next"><article><h1>Transit plan</h1><p>` + article + `</p></article></div>` + nextData + `</body></html>`},
+		{name: "small static page", html: `<html><body><div id="app"></div><p>Nothing here yet.</p></body></html>`},
+		{name: "no mount point", html: `<html><body><div class="page"></div><script src="analytics.js"></script></body></html>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := ParseFromString(context.Background(), tt.html, &Options{URL: "https://app.example.com/dashboard"})
+			if tt.mount == "" {
+				require.NoError(t, err)
+				require.NotNil(t, result)
+				return
+			}
+			require.ErrorIs(t, err, ErrClientRenderedPage)
+			assert.Nil(t, result)
+			var shellErr *ClientRenderedPageError
+			require.True(t, errors.As(err, &shellErr))
+			assert.Equal(t, tt.mount, shellErr.Mount)
+			assert.Equal(t, "https://app.example.com/dashboard", shellErr.URL)
+			assert.Contains(t, err.Error(), "for https://app.example.com/dashboard")
+		})
+	}
+}
+
+func TestParseRendersClientRenderedShells(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Rendered dashboards show the weekly ridership by line and station. ", 10)
+	renderer := &staticRenderer{html: `<html><head><title>Ridership</title></head><body><div id="root"><article><h1>Ridership</h1><p>` + body + `</p></article></div></body></html>`}
+
+	result, err := ParseFromString(context.Background(), reactShell, &Options{URL: "https://app.example.com/dashboard", Renderer: renderer})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com/dashboard"}, renderer.urls)
+	assert.Equal(t, "Ridership", result.Title)
+	assert.Contains(t, result.Content, "weekly ridership")
+
+	// Without a URL there is nothing to render
+	_, err = ParseFromString(context.Background(), reactShell, &Options{Renderer: renderer})
+	require.ErrorIs(t, err, ErrClientRenderedPage)
+	assert.Len(t, renderer.urls, 1)
+
+	// A page that is still a shell once rendered is not rendered again
+	again := &staticRenderer{html: reactShell}
+	_, err = ParseFromString(context.Background(), reactShell, &Options{URL: "https://app.example.com/", Renderer: again})
+	require.ErrorIs(t, err, ErrClientRenderedPage)
+	assert.Len(t, again.urls, 1)
+
+	failing := &staticRenderer{err: errors.New("browser crashed")}
+	_, err = ParseFromString(context.Background(), reactShell, &Options{URL: "https://app.example.com/", Renderer: failing})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "browser crashed")
+	assert.NotErrorIs(t, err, ErrClientRenderedPage)
+}
@@ -0,0 +1,54 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// FileSink writes each result as a JSON file under Dir.
+type FileSink struct {
+	// Dir is the root directory for written files.
+	Dir string
+	// KeyTemplate builds the path relative to Dir. Defaults to DefaultKeyTemplate.
+	KeyTemplate string
+	// Now returns the write time used for {date}. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewFileSink creates a filesystem sink rooted at dir.
+func NewFileSink(dir string) *FileSink {
+	return &FileSink{Dir: dir, KeyTemplate: DefaultKeyTemplate}
+}
+
+// Write implements defuddle.Sink.
+func (s *FileSink) Write(ctx context.Context, source string, result *defuddle.Result) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := Encode(result)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(s.Dir, filepath.FromSlash(Key(s.KeyTemplate, source, result, now(s.Now))))
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(target, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+func now(clock func() time.Time) time.Time {
+	if clock != nil {
+		return clock()
+	}
+	return time.Now()
+}
@@ -0,0 +1,69 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGCSEndpoint is the Google Cloud Storage JSON API endpoint.
+const DefaultGCSEndpoint = "https://storage.googleapis.com"
+
+// TokenSource returns an OAuth 2.0 access token for each request. Callers
+// usually wrap golang.org/x/oauth2/google credentials.
+type TokenSource func(ctx context.Context) (string, error)
+
+// GCSConfig configures a GCSStore.
+type GCSConfig struct {
+	// Bucket is the destination bucket.
+	Bucket string
+	// Token supplies the bearer token for uploads.
+	Token TokenSource
+	// Endpoint overrides DefaultGCSEndpoint, for example for an emulator.
+	Endpoint string
+	// Client is the HTTP client used for uploads. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// GCSStore uploads objects through the Cloud Storage JSON API media upload.
+// For HMAC-key access, use S3Store with Endpoint set to DefaultGCSEndpoint.
+type GCSStore struct {
+	config GCSConfig
+}
+
+// NewGCSStore creates a Cloud Storage object store.
+func NewGCSStore(config GCSConfig) *GCSStore {
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultGCSEndpoint
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &GCSStore{config: config}
+}
+
+// PutObject implements ObjectStore.
+func (s *GCSStore) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		strings.TrimRight(s.config.Endpoint, "/"), url.PathEscape(s.config.Bucket), url.QueryEscape(key))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create GCS request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.config.Token != nil {
+		token, err := s.config.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get GCS access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return doUpload(s.config.Client, req, "GCS")
+}
@@ -0,0 +1,58 @@
+// Package kafka publishes defuddle results to Apache Kafka.
+//
+// It lives in its own module so the core defuddle module does not depend on a
+// Kafka client. Wrap a Writer in sink.NewPublisherSink to use it as a
+// defuddle.Sink.
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"github.com/kaptinlin/defuddle-go/sink"
+)
+
+// Publisher adapts a kafka-go Writer to sink.Publisher. Messages are keyed by
+// result domain, so the Writer's balancer keeps each domain on one partition.
+type Publisher struct {
+	writer *kafkago.Writer
+}
+
+// NewPublisher creates a publisher that writes to brokers with hash-based
+// partitioning by message key. The topic is taken from each message.
+func NewPublisher(brokers ...string) *Publisher {
+	return NewPublisherWithWriter(&kafkago.Writer{
+		Addr:         kafkago.TCP(brokers...),
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: kafkago.RequireAll,
+	})
+}
+
+// NewPublisherWithWriter wraps a caller-configured Writer. The Writer must not
+// set Topic, because the topic is taken from each message.
+func NewPublisherWithWriter(writer *kafkago.Writer) *Publisher {
+	return &Publisher{writer: writer}
+}
+
+// Publish implements sink.Publisher.
+func (p *Publisher) Publish(ctx context.Context, msg sink.Message) error {
+	if err := p.writer.WriteMessages(ctx, toKafkaMessage(msg)); err != nil {
+		return fmt.Errorf("kafka publish failed: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the Writer.
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
+
+func toKafkaMessage(msg sink.Message) kafkago.Message {
+	headers := make([]kafkago.Header, 0, len(msg.Headers))
+	for key, value := range msg.Headers {
+		headers = append(headers, kafkago.Header{Key: key, Value: []byte(value)})
+	}
+	return kafkago.Message{Topic: msg.Topic, Key: msg.Key, Value: msg.Value, Headers: headers}
+}
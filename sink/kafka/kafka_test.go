@@ -0,0 +1,25 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/kaptinlin/defuddle-go/sink"
+)
+
+func TestToKafkaMessageCopiesTopicKeyAndHeaders(t *testing.T) {
+	t.Parallel()
+
+	got := toKafkaMessage(sink.Message{
+		Topic:   "defuddle.results",
+		Key:     []byte("example.com"),
+		Value:   []byte(`{"schemaVersion":1}`),
+		Headers: map[string]string{sink.HeaderSchemaVersion: "1"},
+	})
+
+	if got.Topic != "defuddle.results" || string(got.Key) != "example.com" || string(got.Value) != `{"schemaVersion":1}` {
+		t.Fatalf("toKafkaMessage() = %+v, want topic, key, and value copied", got)
+	}
+	if len(got.Headers) != 1 || got.Headers[0].Key != sink.HeaderSchemaVersion || string(got.Headers[0].Value) != "1" {
+		t.Fatalf("toKafkaMessage() headers = %+v, want schema version header", got.Headers)
+	}
+}
@@ -0,0 +1,74 @@
+// Package nats publishes defuddle results to NATS.
+//
+// It lives in its own module so the core defuddle module does not depend on a
+// NATS client. Wrap a Publisher in sink.NewPublisherSink to use it as a
+// defuddle.Sink.
+package nats
+
+import (
+	"context"
+	"fmt"
+
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/kaptinlin/defuddle-go/sink"
+)
+
+// HeaderDomain carries the message key, the result domain, because NATS
+// subjects have no partitions.
+const HeaderDomain = "Defuddle-Domain"
+
+// Publisher adapts a NATS connection to sink.Publisher. Each message is
+// published to the subject in sink.Message.Topic.
+type Publisher struct {
+	conn *natsgo.Conn
+	// PerDomainSubjects appends the sanitized domain as a final subject token,
+	// e.g. "defuddle.results.example_com", so subscribers can filter by site.
+	PerDomainSubjects bool
+}
+
+// NewPublisher wraps an established NATS connection. The caller owns conn.
+func NewPublisher(conn *natsgo.Conn) *Publisher {
+	return &Publisher{conn: conn}
+}
+
+// Publish implements sink.Publisher. ctx is checked before publishing; core
+// NATS publishing itself is fire-and-forget.
+func (p *Publisher) Publish(ctx context.Context, msg sink.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := p.conn.PublishMsg(p.toNATSMessage(msg)); err != nil {
+		return fmt.Errorf("nats publish failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Publisher) toNATSMessage(msg sink.Message) *natsgo.Msg {
+	subject := msg.Topic
+	if p.PerDomainSubjects && len(msg.Key) > 0 {
+		subject += "." + subjectToken(string(msg.Key))
+	}
+
+	out := natsgo.NewMsg(subject)
+	out.Data = msg.Value
+	for key, value := range msg.Headers {
+		out.Header.Set(key, value)
+	}
+	if len(msg.Key) > 0 {
+		out.Header.Set(HeaderDomain, string(msg.Key))
+	}
+	return out
+}
+
+// subjectToken replaces characters that split or wildcard NATS subjects.
+func subjectToken(value string) string {
+	out := []rune(value)
+	for i, r := range out {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,31 @@
+package nats
+
+import (
+	"testing"
+
+	"github.com/kaptinlin/defuddle-go/sink"
+)
+
+func TestToNATSMessageSetsSubjectDataAndHeaders(t *testing.T) {
+	t.Parallel()
+
+	msg := sink.Message{
+		Topic:   "defuddle.results",
+		Key:     []byte("news.example.com"),
+		Value:   []byte(`{"schemaVersion":1}`),
+		Headers: map[string]string{sink.HeaderSchemaVersion: "1"},
+	}
+
+	got := (&Publisher{}).toNATSMessage(msg)
+	if got.Subject != "defuddle.results" || string(got.Data) != `{"schemaVersion":1}` {
+		t.Fatalf("toNATSMessage() = %q %q, want topic subject and envelope data", got.Subject, got.Data)
+	}
+	if got.Header.Get(sink.HeaderSchemaVersion) != "1" || got.Header.Get(HeaderDomain) != "news.example.com" {
+		t.Fatalf("toNATSMessage() headers = %v, want schema version and domain", got.Header)
+	}
+
+	perDomain := (&Publisher{PerDomainSubjects: true}).toNATSMessage(msg)
+	if perDomain.Subject != "defuddle.results.news_example_com" {
+		t.Fatalf("toNATSMessage() subject = %q, want per-domain subject", perDomain.Subject)
+	}
+}
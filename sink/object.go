@@ -0,0 +1,41 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// ObjectStore uploads a single object. S3Store and GCSStore implement it, and
+// callers can adapt any SDK client to it.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// ObjectSink writes each result as a JSON object to an ObjectStore.
+type ObjectSink struct {
+	// Store receives the encoded results.
+	Store ObjectStore
+	// KeyTemplate builds the object key. Defaults to DefaultKeyTemplate.
+	KeyTemplate string
+	// Now returns the write time used for {date}. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewObjectSink creates a sink that uploads results to store.
+func NewObjectSink(store ObjectStore, keyTemplate string) *ObjectSink {
+	if keyTemplate == "" {
+		keyTemplate = DefaultKeyTemplate
+	}
+	return &ObjectSink{Store: store, KeyTemplate: keyTemplate}
+}
+
+// Write implements defuddle.Sink.
+func (s *ObjectSink) Write(ctx context.Context, source string, result *defuddle.Result) error {
+	data, err := Encode(result)
+	if err != nil {
+		return err
+	}
+	return s.Store.PutObject(ctx, Key(s.KeyTemplate, source, result, now(s.Now)), data, ContentTypeJSON)
+}
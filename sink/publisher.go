@@ -0,0 +1,87 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-json-experiment/json"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// SchemaVersion is the version of the Envelope payload. It changes only when
+// the envelope or result JSON shape changes incompatibly.
+const SchemaVersion = 1
+
+// HeaderSchemaVersion carries SchemaVersion on published messages.
+const HeaderSchemaVersion = "defuddle-schema-version"
+
+// Envelope is the schema-versioned payload published for each result.
+type Envelope struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Source        string           `json:"source"`
+	Domain        string           `json:"domain"`
+	EmittedAt     time.Time        `json:"emittedAt"`
+	Result        *defuddle.Result `json:"result"`
+}
+
+// Message is a single broker message.
+type Message struct {
+	// Topic is the Kafka topic or NATS subject.
+	Topic string
+	// Key is the partitioning key; PublisherSink sets it to the result domain
+	// so results from one site stay ordered within a partition.
+	Key []byte
+	// Value is the encoded Envelope.
+	Value []byte
+	// Headers carry metadata such as HeaderSchemaVersion.
+	Headers map[string]string
+}
+
+// Publisher sends messages to a broker. The sink/kafka and sink/nats modules
+// provide implementations so the core module stays free of broker clients.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherSink publishes each result as an Envelope through a Publisher.
+type PublisherSink struct {
+	// Publisher delivers messages.
+	Publisher Publisher
+	// Topic is the destination topic or subject.
+	Topic string
+	// Now returns the EmittedAt time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewPublisherSink creates a sink that publishes results to topic.
+func NewPublisherSink(publisher Publisher, topic string) *PublisherSink {
+	return &PublisherSink{Publisher: publisher, Topic: topic}
+}
+
+// Write implements defuddle.Sink.
+func (s *PublisherSink) Write(ctx context.Context, source string, result *defuddle.Result) error {
+	domain := resultDomain(source, result)
+	value, err := json.Marshal(Envelope{
+		SchemaVersion: SchemaVersion,
+		Source:        source,
+		Domain:        domain,
+		EmittedAt:     now(s.Now).UTC(),
+		Result:        result,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode envelope: %w", err)
+	}
+
+	return s.Publisher.Publish(ctx, Message{
+		Topic: s.Topic,
+		Key:   []byte(domain),
+		Value: value,
+		Headers: map[string]string{
+			HeaderSchemaVersion: strconv.Itoa(SchemaVersion),
+			"content-type":      ContentTypeJSON,
+		},
+	})
+}
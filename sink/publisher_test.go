@@ -0,0 +1,56 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	messages []Message
+	err      error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, msg Message) error {
+	p.messages = append(p.messages, msg)
+	return p.err
+}
+
+func TestPublisherSinkPublishesVersionedEnvelopeKeyedByDomain(t *testing.T) {
+	t.Parallel()
+
+	publisher := &recordingPublisher{}
+	publisherSink := NewPublisherSink(publisher, "defuddle.results")
+	publisherSink.Now = func() time.Time { return fixedTime }
+
+	require.NoError(t, publisherSink.Write(context.Background(), "https://example.com/a", sampleResult()))
+	require.Len(t, publisher.messages, 1)
+
+	msg := publisher.messages[0]
+	assert.Equal(t, "defuddle.results", msg.Topic)
+	assert.Equal(t, "example.com", string(msg.Key))
+	assert.Equal(t, "1", msg.Headers[HeaderSchemaVersion])
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(msg.Value, &envelope))
+	assert.Equal(t, SchemaVersion, envelope.SchemaVersion)
+	assert.Equal(t, "https://example.com/a", envelope.Source)
+	assert.Equal(t, "example.com", envelope.Domain)
+	assert.True(t, fixedTime.Equal(envelope.EmittedAt))
+	require.NotNil(t, envelope.Result)
+	assert.Equal(t, "Example", envelope.Result.Title)
+}
+
+func TestPublisherSinkReturnsPublishError(t *testing.T) {
+	t.Parallel()
+
+	errBroker := errors.New("broker unavailable")
+	publisherSink := NewPublisherSink(&recordingPublisher{err: errBroker}, "t")
+
+	require.ErrorIs(t, publisherSink.Write(context.Background(), "s", sampleResult()), errBroker)
+}
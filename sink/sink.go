@@ -0,0 +1,132 @@
+// Package sink provides defuddle.Sink implementations that deliver parse
+// results to the filesystem, object storage such as S3 and GCS, and webhooks.
+//
+// Object keys and file paths are built from a key template. The placeholders
+// {domain}, {date}, and {hash} expand to the result domain, the UTC write date
+// (YYYY-MM-DD), and a short SHA-256 hash of the source; {slug} expands to the
+// result title in lowercase words joined by hyphens.
+package sink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// DefaultKeyTemplate is the key template used when a sink has none configured.
+const DefaultKeyTemplate = "{domain}/{date}/{hash}.json"
+
+// ContentTypeJSON is the content type of encoded results.
+const ContentTypeJSON = "application/json"
+
+// ErrUnexpectedStatus is returned when an upload or webhook answers with a non-2xx status.
+var ErrUnexpectedStatus = fmt.Errorf("unexpected response status")
+
+// Key expands template for a result parsed from source at time now.
+// Expanded values are sanitized so keys never contain empty, "." or ".."
+// segments or a leading slash.
+func Key(template, source string, result *defuddle.Result, now time.Time) string {
+	if template == "" {
+		template = DefaultKeyTemplate
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	hash := hex.EncodeToString(sum[:8])
+	replacer := strings.NewReplacer(
+		"{domain}", keySegment(resultDomain(source, result)),
+		"{date}", now.UTC().Format(time.DateOnly),
+		"{hash}", hash,
+		"{slug}", resultSlug(source, result, hash),
+	)
+
+	var segments []string
+	for segment := range strings.SplitSeq(replacer.Replace(template), "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return path.Join(segments...)
+}
+
+// Encode marshals a result into the JSON payload written by sinks.
+func Encode(result *defuddle.Result) ([]byte, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return data, nil
+}
+
+func resultDomain(source string, result *defuddle.Result) string {
+	if result != nil && result.Domain != "" {
+		return result.Domain
+	}
+	if parsed, err := url.Parse(source); err == nil && parsed.Hostname() != "" {
+		return parsed.Hostname()
+	}
+	return "unknown"
+}
+
+// maxSlugLength bounds {slug} so generated file names stay well under
+// filesystem limits.
+const maxSlugLength = 80
+
+// resultSlug slugifies the result title, falling back to the last segment of
+// the source URL path and then to hash.
+func resultSlug(source string, result *defuddle.Result, hash string) string {
+	if result != nil {
+		if slug := slugify(result.Title); slug != "" {
+			return slug
+		}
+	}
+	if parsed, err := url.Parse(source); err == nil {
+		name := path.Base(parsed.Path)
+		if slug := slugify(strings.TrimSuffix(name, path.Ext(name))); slug != "" {
+			return slug
+		}
+	}
+	return hash
+}
+
+// slugify lowercases value and joins its runs of ASCII letters and digits
+// with hyphens.
+func slugify(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// keySegment replaces characters that are unsafe in file names and object keys.
+func keySegment(value string) string {
+	value = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+	if value == "" || value == "." || value == ".." {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,165 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaptinlin/defuddle-go"
+)
+
+// Webhook request headers.
+const (
+	HeaderSignature = "X-Defuddle-Signature"
+	HeaderTimestamp = "X-Defuddle-Timestamp"
+	HeaderSource    = "X-Defuddle-Source"
+)
+
+// Webhook retry defaults.
+const (
+	DefaultWebhookRetries = 3
+	DefaultWebhookBackoff = 500 * time.Millisecond
+	maxWebhookBackoff     = 30 * time.Second
+)
+
+// WebhookSink POSTs each result as JSON to URL.
+//
+// When Secret is set, each request carries HeaderTimestamp (Unix seconds) and
+// HeaderSignature "sha256=<hex>", the HMAC-SHA256 of "<timestamp>.<body>"
+// keyed by Secret, so receivers can verify origin and reject replays.
+// Network errors, 429, and 5xx responses are retried with exponential backoff,
+// honoring Retry-After; other 4xx responses fail immediately.
+type WebhookSink struct {
+	// URL is the webhook endpoint.
+	URL string
+	// Secret signs requests when non-empty.
+	Secret []byte
+	// Headers are added to every request, e.g. an API key.
+	Headers map[string]string
+	// MaxRetries is the number of retries after the first attempt.
+	// Defaults to DefaultWebhookRetries; set a negative value to disable retries.
+	MaxRetries int
+	// Backoff is the delay before the first retry; it doubles after each retry.
+	// Defaults to DefaultWebhookBackoff.
+	Backoff time.Duration
+	// Client is the HTTP client used for delivery. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Now returns the signing time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewWebhookSink creates a webhook sink. An empty secret disables signing.
+func NewWebhookSink(url, secret string) *WebhookSink {
+	webhook := &WebhookSink{URL: url, MaxRetries: DefaultWebhookRetries, Backoff: DefaultWebhookBackoff}
+	if secret != "" {
+		webhook.Secret = []byte(secret)
+	}
+	return webhook
+}
+
+// Sign returns the HeaderSignature value for body signed at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Write implements defuddle.Sink.
+func (s *WebhookSink) Write(ctx context.Context, source string, result *defuddle.Result) error {
+	body, err := Encode(result)
+	if err != nil {
+		return err
+	}
+
+	retries := s.MaxRetries
+	if retries == 0 {
+		retries = DefaultWebhookRetries
+	}
+	retries = max(retries, 0)
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = DefaultWebhookBackoff
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		retryAfter, retryable, err := s.deliver(ctx, source, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable || attempt == retries {
+			break
+		}
+
+		wait := max(backoff, retryAfter)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("webhook delivery canceled: %w", ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxWebhookBackoff)
+	}
+	return lastErr
+}
+
+// deliver sends one request. It reports whether a failure may be retried and
+// the server-requested Retry-After delay.
+func (s *WebhookSink) deliver(ctx context.Context, source string, body []byte) (time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", ContentTypeJSON)
+	if source != "" {
+		req.Header.Set(HeaderSource, source)
+	}
+	for key, value := range s.Headers {
+		req.Header.Set(key, value)
+	}
+	if len(s.Secret) > 0 {
+		timestamp := strconv.FormatInt(now(s.Now).Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, body))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil, fmt.Errorf("webhook delivery failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return 0, false, nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	err = fmt.Errorf("%w: webhook returned %d: %s", ErrUnexpectedStatus, resp.StatusCode, strings.TrimSpace(string(detail)))
+	return parseRetryAfter(resp.Header.Get("Retry-After")), retryable, err
+}
+
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return min(time.Duration(seconds)*time.Second, maxWebhookBackoff)
+}
@@ -0,0 +1,105 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSinkSignsPayload(t *testing.T) {
+	t.Parallel()
+
+	var gotBody []byte
+	var gotHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookSink(server.URL, "s3cret")
+	webhook.Headers = map[string]string{"X-Api-Key": "k1"}
+	webhook.Now = func() time.Time { return fixedTime }
+
+	require.NoError(t, webhook.Write(context.Background(), "https://example.com/a", sampleResult()))
+
+	timestamp := gotHeaders.Get(HeaderTimestamp)
+	assert.Equal(t, "1709996645", timestamp)
+	assert.Equal(t, Sign([]byte("s3cret"), timestamp, gotBody), gotHeaders.Get(HeaderSignature))
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, gotHeaders.Get(HeaderSignature))
+	assert.Equal(t, "https://example.com/a", gotHeaders.Get(HeaderSource))
+	assert.Equal(t, "k1", gotHeaders.Get("X-Api-Key"))
+	assert.Equal(t, ContentTypeJSON, gotHeaders.Get("Content-Type"))
+	assert.Contains(t, string(gotBody), `"title":"Example"`)
+}
+
+func TestWebhookSinkRetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookSink(server.URL, "")
+	webhook.Backoff = time.Millisecond
+
+	require.NoError(t, webhook.Write(context.Background(), "s", sampleResult()))
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestWebhookSinkDoesNotRetryClientErrors(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		assert.Empty(t, r.Header.Get(HeaderSignature))
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookSink(server.URL, "")
+	webhook.Backoff = time.Millisecond
+
+	err := webhook.Write(context.Background(), "s", sampleResult())
+	require.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Contains(t, err.Error(), "bad payload")
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestWebhookSinkGivesUpAfterMaxRetries(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookSink(server.URL, "")
+	webhook.MaxRetries = 2
+	webhook.Backoff = time.Millisecond
+
+	require.ErrorIs(t, webhook.Write(context.Background(), "s", sampleResult()), ErrUnexpectedStatus)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	webhook.MaxRetries = -1
+	attempts.Store(0)
+	require.Error(t, webhook.Write(context.Background(), "s", sampleResult()))
+	assert.Equal(t, int32(1), attempts.Load())
+}
@@ -0,0 +1,352 @@
+package defuddle
+
+import (
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/kaptinlin/defuddle-go/extractors"
+	"github.com/kaptinlin/defuddle-go/internal/markdown"
+	"github.com/kaptinlin/defuddle-go/internal/metadata"
+)
+
+// siteBlockSelector selects the content blocks fingerprinted for
+// boilerplate, the block elements of SourceRange.
+const siteBlockSelector = "p, h1, h2, h3, h4, h5, h6, li, pre, blockquote, dt, dd, figcaption, table"
+
+// maxSiteTags caps the tags a SiteReport lists.
+const maxSiteTags = 50
+
+// maxBoilerplateText caps the sample text of a BoilerplateBlock, in bytes.
+const maxBoilerplateText = 200
+
+// SiteReport summarizes the results of many pages from one site, such as a
+// sample crawl of a site being onboarded
+type SiteReport struct {
+	// Domain is the domain the results share
+	Domain string `json:"domain"`
+	// Pages is the number of results aggregated
+	Pages int `json:"pages"`
+	// Authors counts the pages crediting each author, most pages first
+	Authors []SiteCount `json:"authors,omitempty"`
+	// Tags counts the pages each article:tag or extracted keyword came
+	// from, lowercased, most pages first, up to 50
+	Tags []SiteCount `json:"tags,omitempty"`
+	// Cadence describes when the pages were published, or is nil when fewer
+	// than two dates parse
+	Cadence *PublishingCadence `json:"cadence,omitempty"`
+	// Boilerplate lists content blocks found on at least half the pages,
+	// and on at least two, most pages first
+	Boilerplate []BoilerplateBlock `json:"boilerplate,omitempty"`
+	// Rule suggests a declarative extractor whose content selector is the
+	// element holding the content of the most pages, or is nil when no
+	// result has a source map
+	Rule *extractors.Rule `json:"rule,omitempty"`
+	// RuleCoverage is the share of the results with a source map whose
+	// content came from Rule.Content
+	RuleCoverage float64 `json:"ruleCoverage,omitempty"`
+}
+
+// SiteCount is a value and the number of pages it appears on
+type SiteCount struct {
+	Value string `json:"value"`
+	Pages int    `json:"pages"`
+}
+
+// PublishingCadence describes how often a site publishes
+type PublishingCadence struct {
+	// Dated is the number of pages whose published date parses
+	Dated int `json:"dated"`
+	// First and Last are the earliest and latest published dates
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+	// MedianDays is the median number of days between consecutive posts
+	MedianDays float64 `json:"medianDays"`
+	// PerWeek is the average number of posts a week between First and Last
+	PerWeek float64 `json:"perWeek"`
+}
+
+// BoilerplateBlock is a content block repeated across pages, such as a
+// newsletter prompt that content selection kept
+type BoilerplateBlock struct {
+	// Fingerprint is the FNV-1a hash of the block's text, lowercased and
+	// with whitespace collapsed, in hex
+	Fingerprint string `json:"fingerprint"`
+	// Text is the block's text, cut to 200 bytes
+	Text string `json:"text"`
+	// Pages is the number of pages with the block
+	Pages int `json:"pages"`
+}
+
+// AggregateSite builds a site-level report from results of pages on one
+// domain. Nil results are skipped, and results without a domain are
+// counted with the others. The suggested rule needs results parsed with
+// Options.SourceMap. It fails with ErrNoSiteResults when no result is
+// given and ErrMixedDomains when two results name different domains.
+func AggregateSite(results []*Result) (*SiteReport, error) {
+	report := &SiteReport{}
+	authors := make(map[string]int)
+	tags := make(map[string]int)
+	blocks := make(map[string]*BoilerplateBlock)
+	containers := make(map[string]int)
+	var dates []time.Time
+	var mapped int
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		if result.Domain != "" {
+			if report.Domain != "" && report.Domain != result.Domain {
+				return nil, fmt.Errorf("%w: %s and %s", ErrMixedDomains, report.Domain, result.Domain)
+			}
+			report.Domain = result.Domain
+		}
+		report.Pages++
+
+		for _, author := range uniqueValues(strings.Split(result.Author, ", ")) {
+			authors[author]++
+		}
+		var pageTags []string
+		for _, tag := range result.Tags {
+			pageTags = append(pageTags, strings.ToLower(tag))
+		}
+		for _, keyword := range result.Keywords {
+			pageTags = append(pageTags, strings.ToLower(keyword.Text))
+		}
+		for _, tag := range uniqueValues(pageTags) {
+			tags[tag]++
+		}
+		if t, ok := metadata.ParseDate(result.Published); ok {
+			dates = append(dates, t)
+		}
+		for fingerprint, text := range contentBlocks(result.Content) {
+			if block, ok := blocks[fingerprint]; ok {
+				block.Pages++
+			} else {
+				blocks[fingerprint] = &BoilerplateBlock{Fingerprint: fingerprint, Text: text, Pages: 1}
+			}
+		}
+		if container := contentContainer(result.SourceMap); container != "" {
+			containers[container]++
+			mapped++
+		}
+	}
+	if report.Pages == 0 {
+		return nil, ErrNoSiteResults
+	}
+
+	report.Authors = siteCounts(authors, 0)
+	report.Tags = siteCounts(tags, maxSiteTags)
+	report.Cadence = publishingCadence(dates)
+	minPages := max(2, (report.Pages+1)/2)
+	for _, block := range blocks {
+		if block.Pages >= minPages {
+			report.Boilerplate = append(report.Boilerplate, *block)
+		}
+	}
+	slices.SortFunc(report.Boilerplate, func(a, b BoilerplateBlock) int {
+		return cmp.Or(cmp.Compare(b.Pages, a.Pages), cmp.Compare(a.Text, b.Text))
+	})
+	if counts := siteCounts(containers, 1); len(counts) > 0 {
+		report.Rule = &extractors.Rule{Name: report.Domain, Content: counts[0].Value}
+		if report.Domain != "" {
+			report.Rule.Domains = []string{report.Domain}
+		}
+		report.RuleCoverage = float64(counts[0].Pages) / float64(mapped)
+	}
+	return report, nil
+}
+
+// uniqueValues returns the distinct non-empty trimmed values, so a page
+// counts once for each.
+func uniqueValues(values []string) []string {
+	var unique []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" && !slices.Contains(unique, value) {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// siteCounts sorts counts by pages, then value, keeping at most limit
+// when limit is positive.
+func siteCounts(counts map[string]int, limit int) []SiteCount {
+	var sorted []SiteCount
+	for _, value := range slices.Sorted(maps.Keys(counts)) {
+		sorted = append(sorted, SiteCount{Value: value, Pages: counts[value]})
+	}
+	slices.SortStableFunc(sorted, func(a, b SiteCount) int { return cmp.Compare(b.Pages, a.Pages) })
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// publishingCadence describes the spacing of dates, or returns nil for
+// fewer than two.
+func publishingCadence(dates []time.Time) *PublishingCadence {
+	if len(dates) < 2 {
+		return nil
+	}
+	slices.SortFunc(dates, time.Time.Compare)
+	gaps := make([]float64, 0, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		gaps = append(gaps, dates[i].Sub(dates[i-1]).Hours()/24)
+	}
+	slices.Sort(gaps)
+	median := gaps[len(gaps)/2]
+	if len(gaps)%2 == 0 {
+		median = (gaps[len(gaps)/2-1] + median) / 2
+	}
+
+	cadence := &PublishingCadence{Dated: len(dates), First: dates[0], Last: dates[len(dates)-1], MedianDays: median}
+	if weeks := cadence.Last.Sub(cadence.First).Hours() / (24 * 7); weeks > 0 {
+		cadence.PerWeek = float64(len(dates)-1) / weeks
+	}
+	return cadence
+}
+
+// contentBlocks returns the text of each block of content by fingerprint.
+func contentBlocks(content string) map[string]string {
+	blocks := make(map[string]string)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return blocks
+	}
+	doc.Find(siteBlockSelector).Each(func(_ int, s *goquery.Selection) {
+		if fingerprint, text := blockFingerprint(s); text != "" {
+			if len(text) > maxBoilerplateText {
+				text = strings.ToValidUTF8(text[:maxBoilerplateText], "")
+			}
+			blocks[fingerprint] = text
+		}
+	})
+	return blocks
+}
+
+// blockFingerprint returns the fingerprint of a block and its text with
+// whitespace collapsed, or "" for both when it has no text.
+func blockFingerprint(s *goquery.Selection) (string, string) {
+	text := strings.Join(strings.Fields(s.Text()), " ")
+	if text == "" {
+		return "", ""
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(strings.ToLower(text)))
+	return fmt.Sprintf("%016x", hash.Sum64()), text
+}
+
+// RemoveBoilerplate removes the blocks of result.Content whose fingerprint
+// is one of r.Boilerplate, with the blocks inside them, and returns how many
+// blocks it removed. WordCount, Images, the content counts of Stats, and
+// SourceMap are updated, and a ContentMarkdown is converted again with
+// markdownOptions, or DefaultMarkdownOptions when nil. Summary, Keywords,
+// and ReadingTime are kept.
+func (r *SiteReport) RemoveBoilerplate(result *Result, markdownOptions *MarkdownOptions) (int, error) {
+	if len(r.Boilerplate) == 0 || result == nil {
+		return 0, nil
+	}
+	fingerprints := make(map[string]bool, len(r.Boilerplate))
+	for _, block := range r.Boilerplate {
+		fingerprints[block.Fingerprint] = true
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(result.Content))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse content: %w", err)
+	}
+
+	blocks := doc.Find(siteBlockSelector)
+	removed := make(map[int]bool)
+	blocks.Each(func(i int, s *goquery.Selection) {
+		if removed[i] {
+			return
+		}
+		if fingerprint, _ := blockFingerprint(s); !fingerprints[fingerprint] {
+			return
+		}
+		removed[i] = true
+		s.Find(siteBlockSelector).Each(func(_ int, inner *goquery.Selection) {
+			removed[blocks.IndexOfSelection(inner)] = true
+		})
+	})
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	blocks.Each(func(i int, s *goquery.Selection) {
+		if removed[i] {
+			s.Remove()
+		}
+	})
+
+	content, err := doc.Find("body").Html()
+	if err != nil {
+		return 0, fmt.Errorf("failed to render content: %w", err)
+	}
+	if result.ContentMarkdown != nil {
+		markdownContent, err := markdown.Convert(content, cmp.Or(markdownOptions, DefaultMarkdownOptions()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert content to Markdown: %w", err)
+		}
+		result.ContentMarkdown = &markdownContent
+	}
+	result.Content = content
+	result.WordCount = countContentWords(content)
+	result.Images = contentImages(content)
+	if result.Stats != nil {
+		countContent(result.Stats, content)
+	}
+
+	// Blocks after a removed one move up by the blocks removed before them
+	var sourceMap []SourceRange
+	for _, source := range result.SourceMap {
+		if removed[source.Block] {
+			continue
+		}
+		for i := range source.Block {
+			if removed[i] {
+				source.Block--
+			}
+		}
+		sourceMap = append(sourceMap, source)
+	}
+	result.SourceMap = sourceMap
+	return len(removed), nil
+}
+
+// contentContainer returns a CSS selector for the deepest element holding
+// every block of a source map, as in "html > body > div:nth-of-type(2)",
+// or "" for an empty map.
+func contentContainer(sourceMap []SourceRange) string {
+	var common []string
+	for i, source := range sourceMap {
+		segments := strings.Split(strings.Trim(source.Path, "/"), "/")
+		parent := segments[:len(segments)-1]
+		if i == 0 {
+			common = parent
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(parent) && common[n] == parent[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	selectors := make([]string, len(common))
+	for i, segment := range common {
+		tag, index, ok := strings.Cut(segment, "[")
+		if ok {
+			tag += ":nth-of-type(" + strings.TrimSuffix(index, "]") + ")"
+		}
+		selectors[i] = tag
+	}
+	return strings.Join(selectors, " > ")
+}
@@ -0,0 +1,106 @@
+package defuddle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sitePage is a blog post whose body sits in the site's second div.
+func sitePage(n int, author, published string) string {
+	paragraph := fmt.Sprintf("<p>Post %d explains compost heaps, garden soil, and how worms turn kitchen scraps into rich soil over a season of careful tending.</p>", n)
+	return `<html><head><title>Post ` + fmt.Sprint(n) + `</title>
+<meta name="author" content="` + author + `">
+<meta property="article:published_time" content="` + published + `">
+<meta property="article:tag" content="Compost">
+</head><body><div class="nav"><a href="/">Home</a></div>
+<div class="post"><h1>Post ` + fmt.Sprint(n) + `</h1>` + strings.Repeat(paragraph, 4) + `
+<p>Subscribe to the newsletter for weekly garden tips.</p></div></body></html>`
+}
+
+func TestAggregateSite(t *testing.T) {
+	t.Parallel()
+
+	pages := []struct{ author, published string }{
+		{"Ada Gardener", "2024-01-01T09:00:00Z"},
+		{"Ada Gardener, Ben Digger", "2024-01-08T09:00:00Z"},
+		{"Ben Digger", "2024-01-22T09:00:00Z"},
+	}
+	results := []*Result{nil}
+	for i, page := range pages {
+		result, err := ParseFromString(context.Background(), sitePage(i+1, page.author, page.published), &Options{
+			URL:              fmt.Sprintf("https://www.garden.example/posts/%d", i+1),
+			SourceMap:        true,
+			KeywordExtractor: NewKeywordExtractor(),
+		})
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+
+	report, err := AggregateSite(results)
+	require.NoError(t, err)
+	assert.Equal(t, "garden.example", report.Domain)
+	assert.Equal(t, 3, report.Pages)
+	assert.Equal(t, []SiteCount{{"Ada Gardener", 2}, {"Ben Digger", 2}}, report.Authors)
+	assert.Contains(t, report.Tags, SiteCount{"compost", 3})
+
+	require.NotNil(t, report.Cadence)
+	assert.Equal(t, 3, report.Cadence.Dated)
+	assert.Equal(t, 10.5, report.Cadence.MedianDays)
+	assert.InDelta(t, 2.0/3, report.Cadence.PerWeek, 0.001)
+
+	require.Len(t, report.Boilerplate, 1)
+	assert.Equal(t, "Subscribe to the newsletter for weekly garden tips.", report.Boilerplate[0].Text)
+	assert.Equal(t, 3, report.Boilerplate[0].Pages)
+	assert.Len(t, report.Boilerplate[0].Fingerprint, 16)
+
+	require.NotNil(t, report.Rule)
+	assert.Equal(t, "html > body > div:nth-of-type(2)", report.Rule.Content)
+	assert.Equal(t, []string{"garden.example"}, report.Rule.Domains)
+	assert.Equal(t, 1.0, report.RuleCoverage)
+
+	_, err = AggregateSite([]*Result{nil})
+	require.ErrorIs(t, err, ErrNoSiteResults)
+	_, err = AggregateSite([]*Result{results[1], {Metadata: Metadata{Domain: "other.example"}}})
+	require.ErrorIs(t, err, ErrMixedDomains)
+}
+
+func TestSiteReportRemoveBoilerplate(t *testing.T) {
+	t.Parallel()
+
+	var results []*Result
+	for i := range 3 {
+		result, err := ParseFromString(context.Background(), sitePage(i+1, "Ada Gardener", "2024-01-01"), &Options{
+			URL:       "https://garden.example/posts/" + fmt.Sprint(i+1),
+			SourceMap: true,
+			Markdown:  true,
+		})
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+	report, err := AggregateSite(results)
+	require.NoError(t, err)
+
+	result := results[0]
+	blocks := len(result.SourceMap)
+	removed, err := report.RemoveBoilerplate(result, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NotContains(t, result.Content, "Subscribe")
+	require.NotNil(t, result.ContentMarkdown)
+	assert.NotContains(t, *result.ContentMarkdown, "Subscribe")
+	assert.Contains(t, result.Content, "compost heaps")
+	assert.Equal(t, (&Defuddle{}).countWords(result.Content), result.WordCount)
+	require.Len(t, result.SourceMap, blocks-1)
+	for i, source := range result.SourceMap {
+		assert.Equal(t, i, source.Block)
+	}
+
+	removed, err = report.RemoveBoilerplate(result, nil)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
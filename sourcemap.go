@@ -0,0 +1,286 @@
+package defuddle
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kaptinlin/defuddle-go/internal/constants"
+)
+
+// sourceMapBlockTags are the elements of Content that Result.SourceMap
+// traces back to the original HTML.
+var sourceMapBlockTags = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Pre: true, atom.Blockquote: true, atom.Dt: true, atom.Dd: true,
+	atom.Figcaption: true, atom.Table: true,
+}
+
+// sourceVoidTags never have an end tag, so they end with their start tag.
+var sourceVoidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// sourceAlignWindow is how many source start tags are searched for the
+// next parsed element before it is taken to be implied by the parser, like
+// a missing html, body, or tbody.
+const sourceAlignWindow = 16
+
+// sourceNode is where a stamped element sits in the original HTML.
+type sourceNode struct {
+	path       string
+	start, end int
+}
+
+// sourceToken is one token of the original HTML with its byte range.
+type sourceToken struct {
+	typ        html.TokenType
+	name       string
+	start, end int
+	blank      bool
+}
+
+// stampSources numbers every element of the document in a
+// constants.SourceAttribute attribute and returns the source of each
+// number, so the blocks of Content can be traced back after cleanup moved,
+// renamed, or rebuilt them. Stamps already in the page are removed first;
+// unless enabled, that is all it does.
+func (d *Defuddle) stampSources(enabled bool) []sourceNode {
+	d.doc.Find("[" + constants.SourceAttribute + "]").RemoveAttr(constants.SourceAttribute)
+	if !enabled {
+		return nil
+	}
+
+	var elements []*html.Node
+	var last []int
+	var paths []string
+	var walk func(n *html.Node, path string)
+	walk = func(n *html.Node, path string) {
+		counts := make(map[string]int)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode {
+				counts[c.Data]++
+			}
+		}
+		seen := make(map[string]int)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			seen[c.Data]++
+			childPath := path + "/" + c.Data
+			if counts[c.Data] > 1 {
+				childPath += "[" + strconv.Itoa(seen[c.Data]) + "]"
+			}
+			index := len(elements)
+			elements = append(elements, c)
+			paths = append(paths, childPath)
+			last = append(last, index)
+			walk(c, childPath)
+			last[index] = len(elements) - 1
+		}
+	}
+	for _, root := range d.doc.Nodes {
+		walk(root, "")
+	}
+
+	offsets := alignSourceOffsets(d.html, elements, last)
+	sources := make([]sourceNode, len(elements))
+	for i, n := range elements {
+		sources[i] = sourceNode{path: paths[i], start: offsets[i][0], end: offsets[i][1]}
+		n.Attr = append(n.Attr, html.Attribute{Key: constants.SourceAttribute, Val: strconv.Itoa(i)})
+	}
+	return sources
+}
+
+// alignSourceOffsets returns the byte range of each element, given in
+// document order with the index of its last descendant, in raw. Elements
+// are matched to source start tags by name in order; elements the parser
+// implied, and every element when raw is empty, get a zero range.
+func alignSourceOffsets(raw string, elements []*html.Node, last []int) [][2]int {
+	offsets := make([][2]int, len(elements))
+	if raw == "" {
+		return offsets
+	}
+
+	var tokens []sourceToken
+	var starts []int
+	z := html.NewTokenizer(strings.NewReader(raw))
+	offset := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := sourceToken{typ: tt, start: offset, end: offset + len(z.Raw())}
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			token.name = string(name)
+			if tt != html.EndTagToken {
+				starts = append(starts, len(tokens))
+			}
+		case html.TextToken:
+			token.blank = strings.TrimSpace(string(z.Raw())) == ""
+		}
+		offset = token.end
+		tokens = append(tokens, token)
+	}
+
+	matched := make([]int, len(elements))
+	next := 0
+	for i, n := range elements {
+		matched[i] = -1
+		for w := 0; w < sourceAlignWindow && next+w < len(starts); w++ {
+			if strings.EqualFold(tokens[starts[next+w]].name, n.Data) {
+				matched[i] = starts[next+w]
+				next += w + 1
+				break
+			}
+		}
+	}
+
+	// after[i] is the first matched token at or after element i, which
+	// bounds the elements before it
+	after := make([]int, len(elements)+1)
+	after[len(elements)] = len(tokens)
+	for i := len(elements) - 1; i >= 0; i-- {
+		after[i] = after[i+1]
+		if matched[i] >= 0 {
+			after[i] = matched[i]
+		}
+	}
+
+	for i, k := range matched {
+		if k < 0 {
+			continue
+		}
+		offsets[i] = [2]int{tokens[k].start, sourceElementEnd(tokens, k, after[last[i]+1])}
+	}
+	return offsets
+}
+
+// sourceElementEnd returns the end offset of the element whose start tag is
+// tokens[k]: the end of its end tag, or, when the source omits it, of its
+// last content before boundary.
+func sourceElementEnd(tokens []sourceToken, k, boundary int) int {
+	start := tokens[k]
+	if start.typ == html.SelfClosingTagToken || sourceVoidTags[start.name] {
+		return start.end
+	}
+	end := start.end
+	depth := 0
+	for _, token := range tokens[k+1 : max(boundary, k+1)] {
+		switch token.typ {
+		case html.StartTagToken:
+			if token.name == start.name {
+				depth++
+			}
+			end = token.end
+		case html.EndTagToken:
+			if token.name != start.name {
+				continue
+			}
+			if depth == 0 {
+				return token.end
+			}
+			depth--
+			end = token.end
+		case html.TextToken:
+			if !token.blank {
+				end = token.end
+			}
+		default:
+			end = token.end
+		}
+	}
+	return end
+}
+
+// sourceStamp returns the stamp of n, or -1 when it has none.
+func sourceStamp(n *html.Node) int {
+	for _, attr := range n.Attr {
+		if attr.Key == constants.SourceAttribute {
+			if i, err := strconv.Atoi(attr.Val); err == nil {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// collectSourceMap maps each block of content to the source of its stamp
+// and returns content without stamps. A block cleanup rebuilt without its
+// stamp takes that of its first stamped descendant, then of its nearest
+// stamped ancestor, then root; blocks with none of these are left out.
+func collectSourceMap(content string, sources []sourceNode, root int) (string, []SourceRange) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return content, nil
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	var ranges []SourceRange
+	block := 0
+	var walk func(n *html.Node, inherited int)
+	walk = func(n *html.Node, inherited int) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			stamp := sourceStamp(c)
+			if sourceMapBlockTags[c.DataAtom] {
+				source := stamp
+				if source < 0 {
+					source = firstDescendantStamp(c)
+				}
+				if source < 0 {
+					source = inherited
+				}
+				if source >= 0 && source < len(sources) {
+					s := sources[source]
+					ranges = append(ranges, SourceRange{Block: block, Tag: c.Data, Path: s.path, Start: s.start, End: s.end})
+				}
+				block++
+			}
+			if stamp >= 0 {
+				walk(c, stamp)
+			} else {
+				walk(c, inherited)
+			}
+		}
+	}
+	walk(body, root)
+
+	sel := goquery.NewDocumentFromNode(body).Selection
+	sel.Find("[" + constants.SourceAttribute + "]").RemoveAttr(constants.SourceAttribute)
+	stripped, err := sel.Html()
+	if err != nil {
+		return content, ranges
+	}
+	return stripped, ranges
+}
+
+// firstDescendantStamp returns the first stamp below n in document order,
+// or -1.
+func firstDescendantStamp(n *html.Node) int {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if stamp := sourceStamp(c); stamp >= 0 {
+			return stamp
+		}
+		if stamp := firstDescendantStamp(c); stamp >= 0 {
+			return stamp
+		}
+	}
+	return -1
+}
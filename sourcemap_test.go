@@ -0,0 +1,100 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSourceMap(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("The council met on Tuesday to discuss the budget. ", 6)
+	html := `<!DOCTYPE html><html><head><title>Budget vote</title></head><body>
+<nav><a href="/">Home</a></nav>
+<article>
+<h1>Budget vote</h1>
+<p class="lead">` + body + `</p>
+<div role="paragraph">Rebuilt paragraph text.</div>
+<ul><li>First item<li>Second item</ul>
+<p>Closing paragraph.
+</article>
+</body></html>`
+
+	result, err := ParseFromString(context.Background(), html, &Options{SourceMap: true})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content, "data-defuddle-src")
+	require.NotEmpty(t, result.SourceMap)
+
+	texts := map[string]string{}
+	for i, r := range result.SourceMap {
+		if i > 0 {
+			assert.Greater(t, r.Block, result.SourceMap[i-1].Block)
+		}
+		require.Less(t, r.Start, r.End, "range of %s", r.Path)
+		texts[r.Path] = html[r.Start:r.End]
+	}
+
+	assert.Equal(t, `<p class="lead">`+body+`</p>`, texts["/html/body/article/p[1]"])
+	assert.Equal(t, `<div role="paragraph">Rebuilt paragraph text.</div>`, texts["/html/body/article/div"])
+	assert.Equal(t, `<li>First item`, texts["/html/body/article/ul/li[1]"])
+	assert.Equal(t, "<p>Closing paragraph.\n", texts["/html/body/article/p[2]"])
+
+	plain, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.Nil(t, plain.SourceMap)
+	assert.Equal(t, plain.Content, result.Content)
+}
+
+func TestParseFromURLSourceMapPointsIntoFetchedPage(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("The council met on Tuesday to discuss the budget. ", 6)
+	page := `<!DOCTYPE html><html><head><title>Budget vote</title><style>` + strings.Repeat("p { color: red; } ", 40) + `</style></head><body>
+<!-- the prefilter would drop this comment -->
+<article><h1>Budget vote</h1><p>` + body + `</p><p>Closing paragraph.</p></article>
+</body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	result, err := ParseFromURL(context.Background(), server.URL, &Options{SourceMap: true})
+	require.NoError(t, err)
+
+	var paragraphs []string
+	for _, r := range result.SourceMap {
+		if r.Tag == "p" {
+			require.Less(t, r.Start, r.End)
+			paragraphs = append(paragraphs, page[r.Start:r.End])
+		}
+	}
+	assert.Equal(t, []string{"<p>" + body + "</p>", "<p>Closing paragraph.</p>"}, paragraphs)
+}
+
+func TestParseSourceMapStreamedInput(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><body><article><p>` + strings.Repeat("Streamed article text for the source map. ", 6) + `</p></article></body></html>`
+
+	result, err := ParseReader(context.Background(), strings.NewReader(html), &Options{SourceMap: true})
+	require.NoError(t, err)
+	require.Len(t, result.SourceMap, 1)
+	assert.Equal(t, SourceRange{Block: 0, Tag: "p", Path: "/html/body/article/p"}, result.SourceMap[0])
+}
+
+func TestParseStripsForgedSourceStamps(t *testing.T) {
+	t.Parallel()
+
+	html := `<html><body><article><p data-defuddle-src="3">` + strings.Repeat("Forged stamps never reach the content. ", 6) + `</p></article></body></html>`
+
+	result, err := ParseFromString(context.Background(), html, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content, "data-defuddle-src")
+}
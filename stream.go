@@ -0,0 +1,167 @@
+package defuddle
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// ParseReader parses HTML read from r, which must be UTF-8. Unlike
+// ParseFromString, the input is never held in memory as a whole: the
+// tokenizer builds the document from r in small reads, and the retry pass
+// for sparse results starts from a copy of the parsed tree instead of the
+// input. Peak memory is then about two parsed trees rather than the input
+// plus two trees, and Options.Limits.MaxMemoryBytes stops reading as soon
+// as the input exceeds it.
+//
+// Wrap r with golang.org/x/net/html/charset.NewReader to decode other
+// encodings.
+//
+// With Options.Prefilter set to PrefilterOn, the input is prefiltered as it
+// is read.
+func ParseReader(ctx context.Context, r io.Reader, options *Options) (*Result, error) {
+	defuddle, err := newDefuddleFromReader(r, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Defuddle instance: %w", err)
+	}
+
+	return defuddle.Parse(ctx)
+}
+
+// newDefuddleFromReader creates a parser from streamed HTML, keeping a
+// pristine copy of the document for the retry pass.
+func newDefuddleFromReader(r io.Reader, options *Options) (*Defuddle, error) {
+	var memory memoryUsage
+	if options != nil && options.Limits != nil {
+		memory.limit = options.Limits.MaxMemoryBytes
+	}
+
+	prefilter, err := prefilterEnabled(options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &inputLimitReader{r: r, limit: memory.limit}
+	var digest hash.Hash
+	var source io.Reader = input
+	if options != nil && options.Cache != nil {
+		digest = sha256.New()
+		source = io.TeeReader(input, digest)
+	}
+	var stats *prefilterStats
+	if prefilter {
+		filtered, filterStats := prefilterReader(source, prefilterMaxBytes(options))
+		defer func() { _ = filtered.Close() }()
+		source, stats = filtered, filterStats
+	}
+
+	root, err := html.Parse(source)
+	if err != nil {
+		if limitErr, ok := errors.AsType[*MemoryLimitError](err); ok {
+			return nil, limitErr
+		}
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	memory.nodes = countNodes(root)
+	// The document and its pristine copy; the input itself is not held
+	memory.document = 2 * int64(memory.nodes) * estimatedNodeBytes
+	if err := memory.observe("document", 0); err != nil {
+		return nil, err
+	}
+
+	d := newDefuddle(goquery.NewDocumentFromNode(root), options, memory)
+	d.pristine = cloneNode(root)
+	if digest != nil {
+		d.digest = digest.Sum(nil)
+	}
+	if stats != nil {
+		d.prefiltered(*stats, prefilterMaxBytes(options))
+	}
+	return d, nil
+}
+
+// fork returns a parser over an unmodified copy of the input, for the
+// retry pass. A streamed parser hands over its pristine document, so it can
+// fork only once.
+func (d *Defuddle) fork(options *Options) (*Defuddle, error) {
+	if d.pristine == nil {
+		// The input was prefiltered already, if at all
+		forked, err := newDefuddleFromHTML(d.html, options, false)
+		if err != nil {
+			return nil, err
+		}
+		forked.droppedScriptBytes, forked.inputIssues = d.droppedScriptBytes, d.inputIssues
+		forked.fetchedAt, forked.altText = d.fetchedAt, d.altText
+		return forked, nil
+	}
+
+	memory := memoryUsage{nodes: d.memory.nodes, document: d.memory.document}
+	if options != nil && options.Limits != nil {
+		memory.limit = options.Limits.MaxMemoryBytes
+	}
+	if err := memory.observe("document", 0); err != nil {
+		return nil, err
+	}
+
+	forked := newDefuddle(goquery.NewDocumentFromNode(d.pristine), options, memory)
+	forked.digest = d.digest
+	forked.droppedScriptBytes, forked.inputIssues = d.droppedScriptBytes, d.inputIssues
+	forked.fetchedAt, forked.altText = d.fetchedAt, d.altText
+	d.pristine = nil
+	return forked, nil
+}
+
+// inputDigest returns the SHA-256 of the input HTML for cache keys.
+func (d *Defuddle) inputDigest() []byte {
+	if d.digest != nil {
+		return d.digest
+	}
+	return htmlDigest(d.html)
+}
+
+// htmlDigest returns the SHA-256 of html.
+func htmlDigest(html string) []byte {
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, html)
+	return hash.Sum(nil)
+}
+
+// inputLimitReader fails with a *MemoryLimitError once more than limit
+// bytes have been read. A zero limit reads without bound.
+type inputLimitReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+}
+
+func (l *inputLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.limit > 0 && l.read > l.limit {
+		return n, &MemoryLimitError{Stage: "input", Estimate: l.read, Limit: l.limit}
+	}
+	return n, err
+}
+
+// cloneNode returns a deep copy of the tree rooted at n.
+func cloneNode(n *html.Node) *html.Node {
+	clone := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      slices.Clone(n.Attr),
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		clone.AppendChild(cloneNode(c))
+	}
+	return clone
+}
@@ -0,0 +1,108 @@
+package defuddle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const streamTestHTML = `<html><head><title>Streaming</title></head><body>
+<nav class="share-buttons">Share this</nav>
+<article><h1>Streaming</h1>
+<p>Archived pages can be parsed straight from a file without reading them into a string first.</p>
+<div class="related-content"><p>This paragraph sits in a block that partial selectors remove on the first pass.</p></div>
+</article></body></html>`
+
+func TestParseReaderMatchesParseFromString(t *testing.T) {
+	t.Parallel()
+
+	want, err := ParseFromString(context.Background(), streamTestHTML, &Options{Markdown: true})
+	require.NoError(t, err)
+	got, err := ParseReader(context.Background(), strings.NewReader(streamTestHTML), &Options{Markdown: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, want.Title, got.Title)
+	assert.Equal(t, want.Content, got.Content)
+	assert.Equal(t, want.WordCount, got.WordCount)
+	require.NotNil(t, got.ContentMarkdown)
+	assert.Equal(t, *want.ContentMarkdown, *got.ContentMarkdown)
+	assert.Contains(t, got.Content, "partial selectors remove", "the retry pass starts from the unmodified document")
+}
+
+// endlessReader yields an unbounded paragraph stream.
+type endlessReader struct {
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	n := copy(p, strings.Repeat("<p>more</p>", len(p)/11+1))
+	r.read += int64(n)
+	return n, nil
+}
+
+func TestParseReaderStopsReadingAboveMaxMemoryBytes(t *testing.T) {
+	t.Parallel()
+
+	body := &endlessReader{}
+	input := io.MultiReader(strings.NewReader("<html><body>"), body)
+
+	_, err := ParseReader(context.Background(), input, &Options{Limits: &Limits{MaxMemoryBytes: 64 << 10}})
+
+	require.ErrorIs(t, err, ErrMemoryLimit)
+	var limitErr *MemoryLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, "input", limitErr.Stage)
+	assert.Less(t, body.read, int64(128<<10))
+}
+
+func TestParseReaderReportsReadErrors(t *testing.T) {
+	t.Parallel()
+
+	readErr := errors.New("disk failed")
+	input := io.MultiReader(strings.NewReader("<html><body><p>partial"), &failingReader{err: readErr})
+
+	_, err := ParseReader(context.Background(), input, nil)
+
+	require.ErrorIs(t, err, readErr)
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestParseReaderSharesCacheKeysWithParseFromString(t *testing.T) {
+	t.Parallel()
+
+	cache := &mapCache{results: make(map[string]*Result)}
+	first, err := ParseFromString(context.Background(), streamTestHTML, &Options{Cache: cache})
+	require.NoError(t, err)
+
+	second, err := ParseReader(context.Background(), strings.NewReader(streamTestHTML), &Options{Cache: cache})
+	require.NoError(t, err)
+
+	assert.Same(t, first, second)
+	assert.Len(t, cache.results, 1)
+}
+
+func TestCloneNodeCopiesTreeIndependently(t *testing.T) {
+	t.Parallel()
+
+	d, err := newDefuddleFromReader(strings.NewReader(`<div id="a"><p class="x">Text</p></div>`), nil)
+	require.NoError(t, err)
+
+	d.doc.Find("p").SetAttr("class", "changed").SetText("Changed")
+	pristine := goquery.NewDocumentFromNode(d.pristine)
+
+	assert.Equal(t, "x", pristine.Find("p").AttrOr("class", ""))
+	assert.Equal(t, "Text", pristine.Find("p").Text())
+}
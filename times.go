@@ -0,0 +1,116 @@
+package defuddle
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/kaptinlin/defuddle-go/internal/metadata"
+)
+
+// minTimeContextWords is the fewest words around a time element in its
+// block for it to count as part of the text rather than a dateline.
+const minTimeContextWords = 2
+
+// timeContextSelector matches the blocks a time element is read in.
+const timeContextSelector = "p, li, td, th, dd, blockquote, figcaption"
+
+// relativeTimeRe matches an English relative time such as "3 days ago" or
+// "an hour ago".
+var relativeTimeRe = regexp.MustCompile(`(?i)^(?:about\s+)?(\d+|an?)\s+(second|minute|hour|day|week|month|year)s?\s+ago$`)
+
+// inTextTime reports whether element is a time element inside a block
+// with at least minTimeContextWords other words, such as "the vote on
+// <time>Thursday</time>", which clutter removal keeps while it still
+// removes datelines.
+func inTextTime(element *goquery.Selection) bool {
+	if goquery.NodeName(element) != "time" {
+		return false
+	}
+	block := element.Closest(timeContextSelector)
+	if block.Length() == 0 {
+		return false
+	}
+	words := len(strings.Fields(block.Text())) - len(strings.Fields(element.Text()))
+	return words >= minTimeContextWords
+}
+
+// normalizeTimes replaces the text of time elements in content that shows
+// no date, such as "3 days ago" or "Thursday", with the date of their
+// datetime attribute. A relative time without one is counted back from
+// now, when now is set, and gains a datetime attribute. It returns the
+// number of elements changed.
+func normalizeTimes(content *goquery.Selection, now time.Time) int {
+	changed := 0
+	content.Find("time").Each(func(_ int, element *goquery.Selection) {
+		text := strings.Join(strings.Fields(element.Text()), " ")
+		if _, ok := metadata.ParseDate(text); ok {
+			return
+		}
+		if datetime, ok := element.Attr("datetime"); ok {
+			t, ok := metadata.ParseDate(datetime)
+			if !ok {
+				return
+			}
+			element.SetText(t.Format(time.DateOnly))
+			changed++
+			return
+		}
+		if now.IsZero() {
+			return
+		}
+		if t, ok := resolveRelativeTime(text, now); ok {
+			element.SetAttr("datetime", t.Format(time.RFC3339))
+			element.SetText(t.Format(time.DateOnly))
+			changed++
+		}
+	})
+	return changed
+}
+
+// resolveRelativeTime returns the time an English relative time such as
+// "2 weeks ago", "yesterday", or "just now" names, counted back from now.
+func resolveRelativeTime(text string, now time.Time) (time.Time, bool) {
+	switch strings.ToLower(text) {
+	case "just now", "now", "today":
+		return now, true
+	case "yesterday":
+		return now.AddDate(0, 0, -1), true
+	}
+	match := relativeTimeRe.FindStringSubmatch(text)
+	if match == nil {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		n = 1
+	}
+	switch strings.ToLower(match[2]) {
+	case "second":
+		return now.Add(-time.Duration(n) * time.Second), true
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), true
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case "day":
+		return now.AddDate(0, 0, -n), true
+	case "week":
+		return now.AddDate(0, 0, -7*n), true
+	case "month":
+		return now.AddDate(0, -n, 0), true
+	default:
+		return now.AddDate(-n, 0, 0), true
+	}
+}
+
+// referenceTime returns the time relative times in content are counted
+// back from: Options.FetchTime, else when ParseFromURL received the page.
+func (d *Defuddle) referenceTime(options *Options) time.Time {
+	if !options.FetchTime.IsZero() {
+		return options.FetchTime
+	}
+	return d.fetchedAt
+}
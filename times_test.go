@@ -0,0 +1,123 @@
+package defuddle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const timesArticle = `<html><body><article><h1>Transit plan</h1>
+	<div class="byline"><time datetime="2024-01-01">Jan 1</time></div>
+	<p>The council voted <time datetime="2024-02-26T10:00:00Z">3 days ago</time> to publish the plan. ` +
+	`The council published the transit plan after a year of hearings, and the hearings drew hundreds of residents. ` +
+	`The council published the transit plan after a year of hearings, and the hearings drew hundreds of residents.</p>
+	<p>The next meeting is on <time datetime="2024-03-07">Thursday</time> at the town hall.</p>
+	<p>Comments closed <time>2 hours ago</time> on the draft.</p>
+	</article></body></html>`
+
+func TestParseKeepsTimesInText(t *testing.T) {
+	t.Parallel()
+
+	result, err := ParseFromString(context.Background(), timesArticle, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, `voted <time datetime="2024-02-26T10:00:00Z">3 days ago</time> to publish`)
+	assert.Contains(t, result.Content, `on <time datetime="2024-03-07">Thursday</time> at`)
+	assert.NotContains(t, result.Content, "Jan 1")
+}
+
+func TestParseNormalizesTimes(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultOptions()
+	options.NormalizeTimes = true
+	options.FetchTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	result, err := ParseFromString(context.Background(), timesArticle, options)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, `voted <time datetime="2024-02-26T10:00:00Z">2024-02-26</time> to publish`)
+	assert.Contains(t, result.Content, `on <time datetime="2024-03-07">2024-03-07</time> at`)
+	assert.Contains(t, result.Content, `closed <time datetime="2024-03-01T10:00:00Z">2024-03-01</time> on`)
+}
+
+func TestNormalizeTimes(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		html string
+		now  time.Time
+		want string
+	}{
+		{name: "relative with datetime", html: `<time datetime="2024-02-27">4 days ago</time>`, want: `<time datetime="2024-02-27">2024-02-27</time>`},
+		{name: "date kept", html: `<time datetime="2024-02-27">February 27, 2024</time>`, want: `<time datetime="2024-02-27">February 27, 2024</time>`},
+		{name: "unparsable datetime", html: `<time datetime="soon">Thursday</time>`, want: `<time datetime="soon">Thursday</time>`},
+		{name: "yesterday", html: `<time>yesterday</time>`, now: now, want: `<time datetime="2024-02-29T12:00:00Z">2024-02-29</time>`},
+		{name: "an hour ago", html: `<time>about an hour ago</time>`, now: now, want: `<time datetime="2024-03-01T11:00:00Z">2024-03-01</time>`},
+		{name: "weeks ago", html: `<time>2 weeks ago</time>`, now: now, want: `<time datetime="2024-02-16T12:00:00Z">2024-02-16</time>`},
+		{name: "relative without fetch time", html: `<time>2 weeks ago</time>`, want: `<time>2 weeks ago</time>`},
+		{name: "not relative", html: `<time>soon</time>`, now: now, want: `<time>soon</time>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<p>` + tt.html + `</p>`))
+			require.NoError(t, err)
+			normalizeTimes(doc.Selection, tt.now)
+			got, err := doc.Find("p").Html()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCachesNormalizedTimesByFetchTime(t *testing.T) {
+	t.Parallel()
+
+	cache := &mapCache{results: make(map[string]*Result)}
+	parse := func(fetchedAt time.Time) *Result {
+		t.Helper()
+		options := DefaultOptions()
+		options.NormalizeTimes = true
+		options.Cache = cache
+		d, err := NewDefuddle(timesArticle, options)
+		require.NoError(t, err)
+		d.fetchedAt = fetchedAt
+		result, err := d.Parse(context.Background())
+		require.NoError(t, err)
+		return result
+	}
+
+	first := parse(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	assert.Contains(t, first.Content, `closed <time datetime="2024-03-01T10:00:00Z">2024-03-01</time> on`)
+	// The same page fetched later misses, rather than keeping the old date
+	later := parse(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC))
+	assert.Contains(t, later.Content, `closed <time datetime="2024-03-05T10:00:00Z">2024-03-05</time> on`)
+	assert.Same(t, later, parse(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestParseFromURLNormalizesTimesFromFetchTime(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><body><article><p>The plan was published <time>yesterday</time> by the council, after a year of hearings.</p></article></body></html>`))
+	}))
+	defer server.Close()
+
+	before := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+	result, err := ParseFromURL(context.Background(), server.URL, &Options{NormalizeTimes: true})
+	require.NoError(t, err)
+	after := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+
+	if !strings.Contains(result.Content, ">"+before+"</time>") {
+		assert.Contains(t, result.Content, ">"+after+"</time>")
+	}
+}
@@ -0,0 +1,114 @@
+package defuddle
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kaptinlin/defuddle-go/internal/dom"
+	"github.com/kaptinlin/defuddle-go/internal/standardize"
+)
+
+// TOCEntry is a heading of Result.Content in the table of contents, with
+// the headings below it nested in Children
+type TOCEntry struct {
+	// Text is the heading text with whitespace collapsed.
+	Text string `json:"text"`
+	// Level is the heading level, 1 for h1 to 6 for h6.
+	Level int `json:"level"`
+	// ID is the heading's id in Content when it has one, and otherwise the
+	// id Options.HeadingIDs gives it.
+	ID string `json:"id"`
+	// Children are the entries of the deeper headings that follow it.
+	Children []TOCEntry `json:"children,omitempty"`
+}
+
+// outline returns the table of contents of content for
+// Options.GenerateTOC and, with Options.HeadingIDs, content with an id on
+// every heading. Content is returned unchanged when neither is set or it
+// cannot be parsed.
+func outline(content string, options *Options) (string, []TOCEntry) {
+	if !options.GenerateTOC && !options.HeadingIDs {
+		return content, nil
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return content, nil
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	// Ids already in the content are taken, so generated ones never collide
+	used := map[string]bool{}
+	var headings []*html.Node
+	for el := range dom.Elements(body) {
+		if id, ok := dom.Attr(el, "id"); ok {
+			used[id] = true
+		}
+		if headingLevel(el) > 0 {
+			headings = append(headings, el)
+		}
+	}
+	if len(headings) == 0 {
+		return content, nil
+	}
+
+	var entries []TOCEntry
+	for _, heading := range headings {
+		text := strings.Join(strings.Fields(dom.Text(heading)), " ")
+		id := dom.AttrOr(heading, "id", "")
+		if id == "" {
+			id = standardize.UniqueSlug(text, used)
+			if options.HeadingIDs {
+				dom.SetAttr(heading, "id", id)
+			}
+		}
+		entries = append(entries, TOCEntry{Text: text, Level: headingLevel(heading), ID: id})
+	}
+
+	var toc []TOCEntry
+	if options.GenerateTOC {
+		toc = nestEntries(entries)
+	}
+	if !options.HeadingIDs {
+		return content, toc
+	}
+
+	var b strings.Builder
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&b, n); err != nil {
+			return content, toc
+		}
+	}
+	return b.String(), toc
+}
+
+// headingLevel returns 1 to 6 for h1 to h6 and 0 for other nodes.
+func headingLevel(n *html.Node) int {
+	tag := dom.TagName(n)
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+// nestEntries nests each entry under the nearest entry before it with a
+// lower level.
+func nestEntries(entries []TOCEntry) []TOCEntry {
+	var nest func(i, parentLevel int) ([]TOCEntry, int)
+	nest = func(i, parentLevel int) ([]TOCEntry, int) {
+		var list []TOCEntry
+		for i < len(entries) && entries[i].Level > parentLevel {
+			entry := entries[i]
+			entry.Children, i = nest(i+1, entry.Level)
+			list = append(list, entry)
+		}
+		return list, i
+	}
+	toc, _ := nest(0, 0)
+	return toc
+}
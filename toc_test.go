@@ -0,0 +1,84 @@
+package defuddle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutline(t *testing.T) {
+	t.Parallel()
+
+	content := `<h2>Getting Started</h2><p>a</p><h3>What's new in Go 1.22?</h3><h3 id="kept">Install</h3>` +
+		`<h4>Linux</h4><h2>Getting  started</h2><p id="getting-started-1">b</p><h3>!!!</h3>`
+
+	got, toc := outline(content, &Options{GenerateTOC: true, HeadingIDs: true})
+	assert.Equal(t, []TOCEntry{
+		{Text: "Getting Started", Level: 2, ID: "getting-started", Children: []TOCEntry{
+			{Text: "What's new in Go 1.22?", Level: 3, ID: "whats-new-in-go-122"},
+			{Text: "Install", Level: 3, ID: "kept", Children: []TOCEntry{
+				{Text: "Linux", Level: 4, ID: "linux"},
+			}},
+		}},
+		{Text: "Getting started", Level: 2, ID: "getting-started-2", Children: []TOCEntry{
+			{Text: "!!!", Level: 3, ID: "section"},
+		}},
+	}, toc)
+	assert.Contains(t, got, `<h2 id="getting-started">Getting Started</h2>`)
+	assert.Contains(t, got, `<h3 id="kept">Install</h3>`)
+	assert.Contains(t, got, `<h2 id="getting-started-2">`)
+
+	got, toc = outline(content, &Options{GenerateTOC: true})
+	assert.Equal(t, content, got)
+	assert.Len(t, toc, 2)
+
+	got, toc = outline(content, &Options{})
+	assert.Equal(t, content, got)
+	assert.Nil(t, toc)
+}
+
+func TestParseGeneratesTOC(t *testing.T) {
+	t.Parallel()
+
+	paragraph := "<p>" + strings.Repeat("Long technical articles need navigable sections for readers. ", 10) + "</p>"
+	page := `<html><head><title>Guide</title></head><body><article><h1>Guide</h1>` + paragraph +
+		`<h2>Install</h2>` + paragraph + `<h3>From source</h3>` + paragraph + `<h2>Usage</h2>` + paragraph +
+		`</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), page, &Options{
+		GenerateTOC:     true,
+		HeadingIDs:      true,
+		Markdown:        true,
+		MarkdownOptions: &MarkdownOptions{HeadingIDs: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []TOCEntry{
+		{Text: "Install", Level: 2, ID: "install", Children: []TOCEntry{{Text: "From source", Level: 3, ID: "from-source"}}},
+		{Text: "Usage", Level: 2, ID: "usage"},
+	}, result.TOC)
+	assert.Contains(t, result.Content, `<h2 id="install">Install</h2>`)
+	require.NotNil(t, result.ContentMarkdown)
+	assert.Contains(t, *result.ContentMarkdown, "### From source {#from-source}")
+}
+
+func TestParseKeepsPageHeadingIDs(t *testing.T) {
+	t.Parallel()
+
+	paragraph := "<p>" + strings.Repeat("Deep links from other pages point at the publisher's own heading ids. ", 10) + "</p>"
+	page := `<html><body><article><h2 id="install-guide">Install</h2>` + paragraph +
+		`<h2>Usage</h2>` + paragraph + `</article></body></html>`
+
+	result, err := ParseFromString(context.Background(), page, &Options{GenerateTOC: true, HeadingIDs: true})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, `<h2 id="install-guide">Install</h2>`)
+	assert.Contains(t, result.Content, `<h2 id="usage">Usage</h2>`)
+	assert.Equal(t, []TOCEntry{{Text: "Install", Level: 2, ID: "install-guide"}, {Text: "Usage", Level: 2, ID: "usage"}}, result.TOC)
+
+	result, err = ParseFromString(context.Background(), page, &Options{GenerateTOC: true})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content, "install-guide")
+	assert.Equal(t, "install", result.TOC[0].ID)
+}
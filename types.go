@@ -1,11 +1,23 @@
 package defuddle
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
 	"github.com/kaptinlin/requests"
 
+	"github.com/kaptinlin/defuddle-go/extractors"
 	"github.com/kaptinlin/defuddle-go/internal/debug"
 	"github.com/kaptinlin/defuddle-go/internal/elements"
+	"github.com/kaptinlin/defuddle-go/internal/markdown"
 	"github.com/kaptinlin/defuddle-go/internal/metadata"
+	"github.com/kaptinlin/defuddle-go/internal/nlp"
+	"github.com/kaptinlin/defuddle-go/internal/scoring"
+	"github.com/kaptinlin/defuddle-go/internal/standardize"
+	"github.com/kaptinlin/defuddle-go/internal/typography"
+	"github.com/kaptinlin/defuddle-go/metrics"
 )
 
 // MetaTag represents a meta tag item from HTML
@@ -36,6 +48,10 @@ type Options struct {
 	// Include Markdown in the response
 	SeparateMarkdown bool `json:"separateMarkdown,omitempty"`
 
+	// Markdown conversion settings such as link style
+	// Defaults to DefaultMarkdownOptions() when nil.
+	MarkdownOptions *MarkdownOptions `json:"markdownOptions,omitempty"`
+
 	// Whether to remove elements matching exact selectors like ads, social buttons, etc.
 	// Defaults to true.
 	RemoveExactSelectors bool `json:"removeExactSelectors,omitempty"`
@@ -48,6 +64,136 @@ type Options struct {
 	// Defaults to false.
 	RemoveImages bool `json:"removeImages,omitempty"`
 
+	// Remove "Updated on ..." notes from the content; their date is still
+	// reported in Result.Modified
+	// Defaults to false.
+	RemoveUpdateNotes bool `json:"removeUpdateNotes,omitempty"`
+
+	// Remove corrections and editor's notes from the content; they are still
+	// reported in Result.Corrections
+	// Defaults to false.
+	RemoveCorrections bool `json:"removeCorrections,omitempty"`
+
+	// Move the fact boxes and key-point lists reported in Result.KeyPoints
+	// to the top of the content as one blockquote, so clutter removal
+	// cannot drop them as sidebars
+	// Defaults to false.
+	InsertKeyPoints bool `json:"insertKeyPoints,omitempty"`
+
+	// Standardize the whole body instead of selecting a main-content subtree
+	// Defaults to false.
+	SkipContentSelection bool `json:"skipContentSelection,omitempty"`
+
+	// Results with fewer words are parsed a second time with relaxed clutter
+	// removal, keeping whichever has more words
+	// Defaults to DefaultMinContentWords when zero.
+	MinContentWords int `json:"minContentWords,omitempty"`
+
+	// Reading speed Result.ReadingTime is estimated at
+	// Defaults to DefaultWordsPerMinute when zero.
+	WordsPerMinute int `json:"wordsPerMinute,omitempty"`
+
+	// NormalizeTimes replaces the text of time elements in the content that
+	// shows no date, such as "3 days ago" or "Thursday", with the date of
+	// their datetime attribute, or for English relative times without one,
+	// the date counted back from FetchTime. Defaults to false.
+	NormalizeTimes bool `json:"normalizeTimes,omitempty"`
+
+	// FetchTime is when the page was fetched, which NormalizeTimes counts
+	// relative times back from
+	// Defaults to the time ParseFromURL received the page when zero.
+	FetchTime time.Time `json:"fetchTime,omitzero"`
+
+	// What the second pass relaxes: RetryNone, RetryRelaxPartialSelectors,
+	// or RetryRelaxAll
+	// Defaults to RetryRelaxPartialSelectors when empty.
+	RetryStrategy RetryStrategy `json:"retryStrategy,omitempty"`
+
+	// RecoverFromStructuredData replaces content with less than half the
+	// words of the page's schema.org articleBody, such as the teaser a
+	// paywall or registration wall leaves, with articleBody rendered as
+	// paragraphs, and records an IssueContentRecovered. Results of
+	// site-specific extractors are kept. Defaults to false.
+	RecoverFromStructuredData bool `json:"recoverFromStructuredData,omitempty"`
+
+	// How JSON-LD scripts become SchemaOrgData: SchemaOrgNormalized or
+	// SchemaOrgRaw
+	// Defaults to SchemaOrgNormalized when empty.
+	SchemaOrgMode SchemaOrgMode `json:"schemaOrgMode,omitempty"`
+
+	// Let SchemaOrgNormalized processing fetch JSON-LD @context documents
+	// over the network. The schema.org context is always read from a
+	// bundled copy, and fetched contexts are cached for the process.
+	// Defaults to false, so parsing makes no network requests.
+	AllowRemoteContexts bool `json:"allowRemoteContexts,omitempty"`
+
+	// How the main content is found: ScoringDefuddle or ScoringReadability
+	// Defaults to ScoringDefuddle when empty.
+	ScoringStrategy ScoringStrategy `json:"scoringStrategy,omitempty"`
+
+	// Keep intentional link lists inside the main content, such as tables of
+	// contents and link roundups, that score like navigation
+	// Defaults to false.
+	KeepLinkLists bool `json:"keepLinkLists,omitempty"`
+
+	// Run Result.Content through a strict allowlist sanitizer so it can be
+	// embedded directly: no scripts, styles, frames, forms, event handlers,
+	// or javascript: URLs. ContentMarkdown is converted from the sanitized
+	// HTML.
+	// Defaults to false.
+	Sanitize bool `json:"sanitize,omitempty"`
+
+	// Per-rule typography normalization of the content, title, and
+	// description: quotes, dashes, ellipses, repeated punctuation, and
+	// spaced hyphens
+	// Defaults to nil (text left as published).
+	Typography *TypographyOptions `json:"typography,omitempty"`
+
+	// Report, for each block of Content, the element and byte range of the
+	// original HTML it came from in Result.SourceMap
+	// Defaults to false.
+	SourceMap bool `json:"sourceMap,omitempty"`
+
+	// Report the headings of the content as a nested table of contents in
+	// Result.TOC
+	// Defaults to false.
+	GenerateTOC bool `json:"generateTOC,omitempty"`
+
+	// Give every heading of the content an id: h2–h6 keep the id the page
+	// gave them through attribute stripping, and headings without one get a
+	// slug of their text made unique with a numeric suffix. Result.TOC
+	// entries name these ids, and MarkdownOptions.HeadingIDs writes them
+	// into Markdown. Defaults to false.
+	HeadingIDs bool `json:"headingIDs,omitempty"`
+
+	// The display width, in CSS pixels, images are resolved for: each img
+	// gets the smallest srcset or data-srcset candidate at least this wide,
+	// or the widest. Sets CleanupOptions.ImageTargetWidth.
+	// Defaults to DefaultImageTargetWidth when not positive.
+	ImageTargetWidth int `json:"imageTargetWidth,omitempty"`
+
+	// Per-pass cleanup toggles applied during standardization
+	// Defaults to DefaultCleanupOptions() when nil.
+	Cleanup *CleanupOptions `json:"cleanup,omitempty"`
+
+	// Summarizer produces Result.Summary from the extracted content blocks
+	// Use NewExtractiveSummarizer() for the built-in summarizer. Defaults to nil (no summary).
+	Summarizer Summarizer `json:"-"`
+
+	// Target summary length in words
+	// Defaults to 60 when zero.
+	SummaryLength int `json:"summaryLength,omitempty"`
+
+	// KeywordExtractor produces Result.Keywords from the cleaned content text,
+	// after the page's declared keywords that occur in it
+	// Use NewKeywordExtractor() for the built-in RAKE extractor, or plug in an
+	// external NER service. Defaults to nil (no keywords).
+	KeywordExtractor KeywordExtractor `json:"-"`
+
+	// Maximum number of keywords to return
+	// Defaults to 10 when zero.
+	MaxKeywords int `json:"maxKeywords,omitempty"`
+
 	// Element processing options
 	ProcessCode      bool                                 `json:"processCode,omitempty"`
 	ProcessImages    bool                                 `json:"processImages,omitempty"`
@@ -62,15 +208,293 @@ type Options struct {
 	FootnoteOptions  *elements.FootnoteProcessingOptions  `json:"footnoteOptions,omitempty"`
 	RoleOptions      *elements.RoleProcessingOptions      `json:"roleOptions,omitempty"`
 
+	// Extractors is the registry searched for a site-specific extractor
+	// Use extractors.NewBuiltinRegistry() for a registry isolated from other parsers.
+	// Defaults to extractors.DefaultRegistry when nil.
+	Extractors *extractors.Registry `json:"-"`
+
+	// Number of content blocks ParseFast returns
+	// Defaults to DefaultFastFirstN (5) when zero.
+	FastFirstN int `json:"fastFirstN,omitempty"`
+
+	// Start a full parse in the background from ParseFast, available from Preview.Full
+	// Defaults to false.
+	FastBackground bool `json:"fastBackground,omitempty"`
+
+	// Limits bounds the resources one parse may use
+	// Defaults to nil (no limits).
+	Limits *Limits `json:"limits,omitempty"`
+
+	// Whether the HTML is prefiltered before a document is built from it:
+	// PrefilterOn drops comments, the content of styles and svg elements,
+	// and inline scripts other than JSON-LD, math, and short redirect stubs,
+	// and cuts it at Limits.MaxHTMLBytes; PrefilterOff parses it whole
+	// SourceMap turns it off, so byte ranges are into the HTML as given
+	// Defaults to PrefilterOn for ParseFromURL and PrefilterOff otherwise
+	// when empty.
+	Prefilter PrefilterMode `json:"prefilter,omitempty"`
+
+	// Cache returns a stored result instead of re-extracting HTML, or
+	// refetching a URL in ParseFromURL, that was already parsed with the
+	// same options. Use cache.NewMemory for an in-process cache with a TTL,
+	// or cache.NewFile for one on disk. Defaults to nil (no caching).
+	Cache Cache `json:"-"`
+
+	// Metrics receives parse, stage, extractor, and fetch measurements.
+	// Defaults to nil (no instrumentation).
+	Metrics metrics.Collector `json:"-"`
+
 	// Client is a custom HTTP client for fetching URLs.
-	// If nil, a default client with standard User-Agent and 30s timeout is created.
+	// If nil, a client is built from Fetch with NewFetchClient.
 	Client *requests.Client `json:"-"`
+
+	// Fetch configures the client ParseFromURL builds when Client is nil.
+	// Defaults to nil (the Defuddle User-Agent and a 30s timeout).
+	Fetch *FetchOptions `json:"-"`
+
+	// FileRoot lets ParseFromURL read file:// URLs of files inside this
+	// directory, after cleaning their path, so ".." segments cannot leave
+	// it; symlinks are not resolved. Other file:// URLs fail with
+	// ErrFileOutsideRoot. Defaults to "" (no file:// URLs).
+	FileRoot string `json:"-"`
+
+	// MaxClientRedirects is the number of meta refresh and script location
+	// redirects ParseFromURL follows, so an interstitial page is replaced by
+	// its destination. Defaults to 0 (none; the destination is reported in
+	// Result.ClientRedirect).
+	MaxClientRedirects int `json:"maxClientRedirects,omitempty"`
+
+	// ConsentCookies makes ParseFromURL fetch a consent wall again, once,
+	// with the cookies its platform stores when a visitor accepts, for the
+	// platforms such cookies are known for. Defaults to false (the wall is
+	// only reported in Result.Interstitial).
+	ConsentCookies bool `json:"consentCookies,omitempty"`
+
+	// PreferAMP makes ParseFromURL fetch and parse the AMP version a page
+	// links with <link rel="amphtml"> instead of the page itself, since AMP
+	// markup is far simpler. Options.URL stays the page's, and the page is
+	// parsed when the AMP version cannot be fetched. Defaults to false.
+	PreferAMP bool `json:"preferAMP,omitempty"`
+
+	// Renderer loads Options.URL in a browser when the HTML is the empty
+	// shell of a client-rendered page, and the rendered page is parsed
+	// instead. Without it, such pages fail with ErrClientRenderedPage.
+	// Defaults to nil.
+	Renderer Renderer `json:"-"`
+
+	// EmbedArchiveImages replaces references to images saved in the archive
+	// read by ParseFromArchive, in img src and og:image metadata, with data:
+	// URLs, so the result shows them without the network
+	EmbedArchiveImages bool `json:"embedArchiveImages,omitempty"`
+}
+
+// ArchiveFormat names a saved-page format read by ParseFromArchive
+type ArchiveFormat string
+
+const (
+	// ArchiveMHTML is a MIME multipart file such as a browser's "Save page as"
+	ArchiveMHTML ArchiveFormat = "mhtml"
+	// ArchiveWARC is a WARC file, plain or gzip-compressed
+	ArchiveWARC ArchiveFormat = "warc"
+)
+
+// CleanupOptions toggles the individual cleanup passes of the standardization stage
+// This is an alias to the internal standardize.CleanupOptions type
+type CleanupOptions = standardize.CleanupOptions
+
+// DefaultImageTargetWidth is the display width, in CSS pixels, srcset
+// candidates are chosen for when neither Options.ImageTargetWidth nor
+// CleanupOptions.ImageTargetWidth is set
+const DefaultImageTargetWidth = standardize.DefaultImageTargetWidth
+
+// DefaultOptions returns the options ParseFromURL uses when given nil:
+// exact and partial selector removal enabled, everything else at its zero
+// value. Options are applied whole, so a literal &Options{} turns selector
+// removal off; start from DefaultOptions to change other fields only.
+func DefaultOptions() *Options {
+	return &Options{
+		RemoveExactSelectors:   true,
+		RemovePartialSelectors: true,
+	}
+}
+
+// DefaultCleanupOptions returns cleanup options with every pass enabled
+func DefaultCleanupOptions() *CleanupOptions {
+	return standardize.DefaultCleanupOptions()
+}
+
+// TypographyOptions selects the typography rules applied to output text
+// This is an alias to the internal typography.Options type
+type TypographyOptions = typography.Options
+
+// QuoteStyle selects straight or curly quotes
+// This is an alias to the internal typography.QuoteStyle type
+type QuoteStyle = typography.QuoteStyle
+
+// Quote styles
+const (
+	QuotesStraight = typography.QuotesStraight
+	QuotesCurly    = typography.QuotesCurly
+)
+
+// MarkdownOptions configures HTML to Markdown conversion
+// This is an alias to the internal markdown.Options type
+type MarkdownOptions = markdown.Options
+
+// LinkStyle selects inline or reference-style Markdown links
+// This is an alias to the internal markdown.LinkStyle type
+type LinkStyle = markdown.LinkStyle
+
+// Markdown link styles
+const (
+	LinkStyleInline    = markdown.LinkStyleInline
+	LinkStyleReference = markdown.LinkStyleReference
+)
+
+// Wrap selects how Markdown paragraph lines are broken
+// This is an alias to the internal markdown.Wrap type
+type Wrap = markdown.Wrap
+
+// Markdown wrap modes
+const (
+	WrapNone = markdown.WrapNone
+	WrapSoft = markdown.WrapSoft
+	WrapHard = markdown.WrapHard
+)
+
+// HTMLPassthrough selects how elements without a Markdown equivalent are written
+// This is an alias to the internal markdown.HTMLPassthrough type
+type HTMLPassthrough = markdown.HTMLPassthrough
+
+// HTML passthrough policies
+const (
+	HTMLPassthroughNone = markdown.HTMLPassthroughNone
+	HTMLPassthroughSafe = markdown.HTMLPassthroughSafe
+	HTMLPassthroughAll  = markdown.HTMLPassthroughAll
+)
+
+// DefaultMarkdownOptions returns Markdown options with inline links and no wrapping
+func DefaultMarkdownOptions() *MarkdownOptions {
+	return markdown.DefaultOptions()
+}
+
+// DefaultMinContentWords is the word count below which Parse retries when
+// Options.MinContentWords is zero
+const DefaultMinContentWords = 200
+
+// RetryStrategy selects what the second parse pass of a sparse result relaxes
+type RetryStrategy string
+
+// Retry strategies
+const (
+	// RetryNone never parses a second time
+	RetryNone RetryStrategy = "none"
+	// RetryRelaxPartialSelectors retries without partial-selector removal
+	RetryRelaxPartialSelectors RetryStrategy = "relax-partial-selectors"
+	// RetryRelaxAll retries without exact- and partial-selector removal
+	RetryRelaxAll RetryStrategy = "relax-all"
+)
+
+// PrefilterMode selects whether HTML is prefiltered before parsing
+type PrefilterMode string
+
+// Prefilter modes
+const (
+	// PrefilterOn streams the HTML through a tokenizer that drops content
+	// extraction never reads, which bloated pages are mostly made of
+	PrefilterOn PrefilterMode = "on"
+	// PrefilterOff parses the HTML as given
+	PrefilterOff PrefilterMode = "off"
+)
+
+// SchemaOrgMode selects how JSON-LD scripts are processed into schema.org data
+type SchemaOrgMode string
+
+// Schema.org processing modes
+const (
+	// SchemaOrgNormalized expands each script and compacts it against the
+	// schema.org context, falling back to the script as written
+	SchemaOrgNormalized SchemaOrgMode = "normalized"
+	// SchemaOrgRaw parses each script as written, without JSON-LD expansion,
+	// like Defuddle's TypeScript version
+	SchemaOrgRaw SchemaOrgMode = "raw"
+)
+
+// ScoringStrategy selects how the main content is found
+// This is an alias to the internal scoring.Strategy type
+type ScoringStrategy = scoring.Strategy
+
+// Main-content scoring strategies
+const (
+	// ScoringDefuddle tries entry-point selectors, table heuristics, and
+	// block scoring, in that order
+	ScoringDefuddle = scoring.StrategyDefuddle
+	// ScoringReadability picks the ancestor of the densest paragraphs, scored
+	// by text length, commas, and link density like Mozilla Readability
+	ScoringReadability = scoring.StrategyReadability
+)
+
+// ImageProcessingOptions configures image processing, including alt text generation
+// This is an alias to the internal elements.ImageProcessingOptions type
+type ImageProcessingOptions = elements.ImageProcessingOptions
+
+// AltTextProvider generates alt text for an image from an external captioning service
+// This is an alias to the internal elements.AltTextProvider type
+type AltTextProvider = elements.AltTextProvider
+
+// DefaultImageProcessingOptions returns the default image processing options
+func DefaultImageProcessingOptions() *ImageProcessingOptions {
+	return elements.DefaultImageProcessingOptions()
+}
+
+// Summarizer produces a summary from extracted content blocks
+// This is an alias to the internal nlp.Summarizer type
+type Summarizer = nlp.Summarizer
+
+// NewExtractiveSummarizer returns the built-in summarizer, which ranks sentences
+// by TF-IDF similarity to the document centroid without external services
+func NewExtractiveSummarizer() Summarizer {
+	return nlp.NewExtractiveSummarizer()
+}
+
+// Keyword represents a ranked keyword or entity found in the content
+// This is an alias to the internal nlp.Keyword type
+type Keyword = nlp.Keyword
+
+// KeywordExtractor extracts ranked keywords from cleaned content text
+// This is an alias to the internal nlp.KeywordExtractor type
+type KeywordExtractor = nlp.KeywordExtractor
+
+// NewKeywordExtractor returns the built-in RAKE keyword extractor
+func NewKeywordExtractor() KeywordExtractor {
+	return nlp.NewRAKEExtractor()
 }
 
 // Metadata represents extracted metadata from a document
 // This is an alias to the internal metadata.Metadata type
 type Metadata = metadata.Metadata
 
+// Series places an article within a multi-part series
+// This is an alias to the internal metadata.Series type
+type Series = metadata.Series
+
+// SourceRange traces one block of Result.Content back to the original HTML
+type SourceRange struct {
+	// Block is the position of the block among the p, h1-h6, li, pre,
+	// blockquote, dt, dd, figcaption, and table elements of Content, in
+	// document order
+	Block int `json:"block"`
+	// Tag is the block's element name in Content
+	Tag string `json:"tag"`
+	// Path locates the source element, as in "/html/body/article/p[2]"
+	Path string `json:"path"`
+	// Start and End are the byte offsets of the source element, from its
+	// start tag to its end tag; both are zero when the element was implied
+	// by the parser or the input was streamed
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
 // Result represents the complete response from Defuddle parsing
 // JavaScript original code:
 //
@@ -82,11 +506,145 @@ type Metadata = metadata.Metadata
 //	}
 type Result struct {
 	Metadata
-	Content         string      `json:"content"`
-	ContentMarkdown *string     `json:"contentMarkdown,omitempty"`
-	ExtractorType   *string     `json:"extractorType,omitempty"`
-	MetaTags        []MetaTag   `json:"metaTags,omitempty"`
-	DebugInfo       *debug.Info `json:"debugInfo,omitempty"`
+	Content         string         `json:"content"`
+	ContentMarkdown *string        `json:"contentMarkdown,omitempty"`
+	Summary         string         `json:"summary,omitempty"`
+	Keywords        []Keyword      `json:"keywords,omitempty"`
+	TOC             []TOCEntry     `json:"toc,omitempty"`
+	ExtractorType   *string        `json:"extractorType,omitempty"`
+	MetaTags        []MetaTag      `json:"metaTags,omitempty"`
+	Series          *Series        `json:"series,omitempty"`
+	AuthorBio       string         `json:"authorBio,omitempty"`
+	Corrections     []string       `json:"corrections,omitempty"`
+	KeyPoints       []string       `json:"keyPoints,omitempty"`
+	AudioVersionURL string         `json:"audioVersionUrl,omitempty"`
+	SourceMap       []SourceRange  `json:"sourceMap,omitempty"`
+	Issues          []Issue        `json:"issues,omitempty"`
+	ClientRedirect  string         `json:"clientRedirect,omitempty"`
+	AMPURL          string         `json:"ampUrl,omitempty"`
+	Interstitial    *Interstitial  `json:"interstitial,omitempty"`
+	Images          []ContentImage `json:"images,omitempty"`
+	Stats           *Stats         `json:"stats,omitempty"`
+	DebugInfo       *debug.Info    `json:"debugInfo,omitempty"`
+
+	// ReadingTime is the minutes needed to read the content at
+	// Options.WordsPerMinute, rounded up
+	ReadingTime int `json:"readingTime,omitempty"`
+
+	// AudioDuration is the length in seconds of the audio version at
+	// AudioVersionURL, or 0 when the page does not give it
+	AudioDuration int `json:"audioDuration,omitempty"`
+
+	// Variables are all the values a site-specific extractor reported, such
+	// as a comment count; title, author, published, description, image, and
+	// site also replace the page's metadata
+	Variables ExtractorVariables `json:"variables,omitempty"`
+}
+
+// ContentImage is an image of Result.Content with the caption and photo
+// credit of its figure
+type ContentImage struct {
+	// Src is the image URL.
+	Src string `json:"src"`
+	// Alt is the alt text.
+	Alt string `json:"alt,omitempty"`
+	// Caption is the figcaption text, without the credit.
+	Caption string `json:"caption,omitempty"`
+	// Credit is the photo credit split from the caption, such as
+	// "Photo: Jane Doe/Reuters".
+	Credit string `json:"credit,omitempty"`
+}
+
+// Limits bounds the resources of one parse, so a single huge page cannot
+// exhaust a batch worker
+type Limits struct {
+	// MaxMemoryBytes aborts the parse with a *MemoryLimitError when the
+	// estimated memory in use exceeds it. Zero means no limit.
+	MaxMemoryBytes int64 `json:"maxMemoryBytes,omitempty"`
+
+	// MaxTextNodeBytes truncates longer text nodes outside scripts and
+	// styles, recording an Issue. Zero uses DefaultMaxTextNodeBytes and a
+	// negative value disables truncation.
+	MaxTextNodeBytes int `json:"maxTextNodeBytes,omitempty"`
+
+	// MaxHTMLBytes cuts the HTML left by the prefilter at this many bytes,
+	// recording an Issue, so a huge page is parsed from its start. It has
+	// no effect when Options.Prefilter is off. Zero means no limit.
+	MaxHTMLBytes int64 `json:"maxHTMLBytes,omitempty"`
+}
+
+// DefaultMaxTextNodeBytes is the text node length kept when
+// Limits.MaxTextNodeBytes is zero
+const DefaultMaxTextNodeBytes = 256 << 10
+
+// IssueTextNodeTruncated is the Issue code for text nodes cut to
+// Limits.MaxTextNodeBytes
+const IssueTextNodeTruncated = "text-node-truncated"
+
+// IssueHTMLTruncated is the Issue code for HTML cut to Limits.MaxHTMLBytes
+// by the prefilter
+const IssueHTMLTruncated = "html-truncated"
+
+// IssueContentRecovered is the Issue code for content replaced by the
+// schema.org articleBody under Options.RecoverFromStructuredData
+const IssueContentRecovered = "content-recovered"
+
+// IssueExtractorFailed is the Issue code for a site extractor, such as an
+// external one, that failed, so generic extraction ran instead
+const IssueExtractorFailed = "extractor-failed"
+
+// IssueAltTextFailed is the Issue code for an image whose alt text
+// ImageOptions.AltTextProvider failed to generate
+const IssueAltTextFailed = "alt-text-failed"
+
+// Issue is a problem in the page that the parse worked around
+type Issue struct {
+	// Code identifies the kind of issue, such as IssueTextNodeTruncated.
+	Code string `json:"code"`
+	// Message describes the issue.
+	Message string `json:"message"`
+}
+
+// InterstitialKind classifies a page that stands in front of the content
+type InterstitialKind string
+
+// Interstitial kinds
+const (
+	// InterstitialConsent is a cookie or privacy consent wall
+	InterstitialConsent InterstitialKind = "consent"
+	// InterstitialChallenge is a bot check, such as a CAPTCHA
+	InterstitialChallenge InterstitialKind = "challenge"
+)
+
+// Interstitial describes the consent wall or bot challenge a page was
+// instead of its content
+type Interstitial struct {
+	// Kind is InterstitialConsent or InterstitialChallenge.
+	Kind InterstitialKind `json:"kind"`
+	// Vendor names the platform that served it, such as "onetrust" or
+	// "cloudflare".
+	Vendor string `json:"vendor"`
+}
+
+// Stats reports what Result.Content is made of and the resource usage of
+// the parse that produced it
+type Stats struct {
+	// Paragraphs is the number of p elements with text.
+	Paragraphs int `json:"paragraphs"`
+	// Headings is the number of h1 to h6 elements.
+	Headings int `json:"headings"`
+	// Images is the number of img elements.
+	Images int `json:"images"`
+	// Links is the number of a elements with an href.
+	Links int `json:"links"`
+	// CodeBlocks is the number of pre elements.
+	CodeBlocks int `json:"codeBlocks"`
+
+	// NodeCount is the number of nodes in the parsed document.
+	NodeCount int `json:"nodeCount"`
+	// PeakMemoryBytes is the highest memory estimate of the parse: the input
+	// HTML, parsed nodes, and the content and Markdown buffers built from them.
+	PeakMemoryBytes int64 `json:"peakMemoryBytes"`
 }
 
 // ExtractorVariables represents variables extracted by site-specific extractors
@@ -116,3 +674,99 @@ type ExtractedContent struct {
 	ContentHTML *string             `json:"contentHtml,omitempty"`
 	Variables   *ExtractorVariables `json:"variables,omitempty"`
 }
+
+// Sink receives completed parse results, for example the URLResults of
+// ParseURLs, which callers write to it themselves
+// source is the URL or path the result was parsed from. Implementations in the
+// sink package write to the filesystem, object storage, and webhooks; sinks that hold
+// resources also implement io.Closer
+type Sink interface {
+	Write(ctx context.Context, source string, result *Result) error
+}
+
+// FetchOptions configures the HTTP client NewFetchClient builds
+type FetchOptions struct {
+	// UserAgent replaces the Defuddle User-Agent.
+	UserAgent string
+
+	// Headers are sent with every request. A User-Agent header here takes
+	// precedence over UserAgent.
+	Headers http.Header
+
+	// Proxy is the proxy URL, such as http://localhost:8080 or
+	// socks5://localhost:1080.
+	Proxy string
+
+	// Timeout bounds each request. Defaults to 30s; negative disables it.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times a request that failed with a
+	// network error, a timeout, 408, 429, or a 5xx status is sent again.
+	// Defaults to 0 (no retries).
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry, doubled for each
+	// one after it up to 30s, with 25% jitter. A Retry-After header on a
+	// 429 or 503 response takes precedence. Defaults to 1s.
+	RetryBackoff time.Duration
+
+	// RequestsPerSecond spaces the requests the client sends to each host,
+	// from any number of goroutines, to at most this many a second. Retries
+	// and redirects count as requests. Defaults to 0 (no limit).
+	RequestsPerSecond float64
+
+	// RespectRobots checks the robots.txt of each origin, fetched once per
+	// client, before a request to it and fails disallowed URLs with a
+	// *RobotsDisallowedError. A missing robots.txt allows everything; one
+	// that fails with a server error disallows everything. Defaults to
+	// false.
+	RespectRobots bool
+
+	// CacheDir keeps the 200 responses to GET requests as files under
+	// this directory, keyed by URL, and answers repeated requests from
+	// them. Cache-Control is ignored, so this suits a developer's machine
+	// rather than a shared crawler. Defaults to "" (no cache).
+	CacheDir string
+
+	// CacheTTL is how long a response in CacheDir is used without asking
+	// the server. After it, the response is revalidated with the ETag and
+	// Last-Modified it was stored with, and a 304 Not Modified renews it.
+	// Defaults to 0 (revalidate every time).
+	CacheTTL time.Duration
+
+	// MaxBodySize fails a response with a larger body with
+	// ErrResponseTooLarge. Defaults to 0 (no limit).
+	MaxBodySize int64
+
+	// Resolver looks up the hosts the client connects to, such as one from
+	// NewDNSResolver or NewDoHResolver. Behind a proxy it only looks up the
+	// proxy, which resolves the rest. Defaults to nil (the system
+	// resolver).
+	Resolver *net.Resolver
+
+	// UnixSocket is the path of a Unix domain socket every connection is
+	// made to instead of the host of the URL, such as that of a sidecar
+	// fetcher; requests still name the host. Resolver is not used with it.
+	// Defaults to "" (TCP to each host).
+	UnixSocket string
+
+	// HTTPClient replaces the underlying *http.Client. Its transport and
+	// timeout are used as they are: Proxy, Resolver, UnixSocket, and
+	// Timeout are ignored.
+	HTTPClient *http.Client
+}
+
+// Renderer returns the HTML of the page at url after its scripts have run,
+// for example from a headless browser
+type Renderer interface {
+	Render(ctx context.Context, url string) (string, error)
+}
+
+// Cache stores parse results keyed by a hash of the input HTML, or the URL
+// for ParseFromURL, and options
+// Implementations decide expiry and eviction, and must be safe for concurrent
+// use. Cached results are shared between callers and must not be modified
+type Cache interface {
+	Get(ctx context.Context, key string) (*Result, bool)
+	Set(ctx context.Context, key string, result *Result)
+}
@@ -0,0 +1,42 @@
+package defuddle
+
+import (
+	"runtime/debug"
+)
+
+// modulePath is the import path of this module, as recorded in build info.
+const modulePath = "github.com/kaptinlin/defuddle-go"
+
+// version is the release, set at build time with
+// -ldflags "-X github.com/kaptinlin/defuddle-go.version=v1.2.3".
+var version string
+
+// Version returns the release of this module the program was built with:
+// the version set at build time, else the one the Go toolchain recorded
+// for the module, as "go install ...@v1.2.3" does, else "devel".
+func Version() string {
+	if version != "" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	module := &info.Main
+	if module.Path != modulePath {
+		module = nil
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				module = dep
+				break
+			}
+		}
+	}
+	if module != nil && module.Replace != nil {
+		module = module.Replace
+	}
+	if module == nil || module.Version == "" || module.Version == "(devel)" {
+		return "devel"
+	}
+	return module.Version
+}